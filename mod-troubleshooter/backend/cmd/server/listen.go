@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+
+	"github.com/mod-troubleshooter/backend/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// unixSocketMode lets the socket's owner and group, such as a local proxy, connect.
+const unixSocketMode = 0660
+
+// listener is a configured address the server accepts connections on.
+type listener struct {
+	net.Listener
+	// url is how the address is shown in logs.
+	url string
+}
+
+// openListeners opens the TCP (plain or TLS) and Unix socket listeners enabled in cfg.
+func openListeners(cfg *config.Config) ([]listener, error) {
+	var listeners []listener
+	closeAll := func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}
+
+	if !cfg.DisableTCP {
+		l, err := net.Listen("tcp", ":"+cfg.Port)
+		if err != nil {
+			return nil, fmt.Errorf("listen on port %s: %w", cfg.Port, err)
+		}
+		url := "http://localhost:" + cfg.Port
+
+		if cfg.TLSEnabled() {
+			tlsConfig, err := serverTLSConfig(cfg)
+			if err != nil {
+				l.Close()
+				return nil, err
+			}
+			l = tls.NewListener(l, tlsConfig)
+			url = "https://localhost:" + cfg.Port
+			if cfg.AutocertEnabled() {
+				url = "https://" + cfg.AutocertHosts[0] + ":" + cfg.Port
+			}
+		}
+
+		listeners = append(listeners, listener{Listener: l, url: url})
+	}
+
+	if cfg.UnixSocket != "" {
+		// A socket left behind by an unclean shutdown would block the listen
+		if info, err := os.Lstat(cfg.UnixSocket); err == nil && info.Mode().Type() == fs.ModeSocket {
+			os.Remove(cfg.UnixSocket)
+		} else if err == nil {
+			closeAll()
+			return nil, fmt.Errorf("unix socket path %s exists and is not a socket", cfg.UnixSocket)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			closeAll()
+			return nil, fmt.Errorf("check unix socket path: %w", err)
+		}
+
+		l, err := net.Listen("unix", cfg.UnixSocket)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("listen on unix socket %s: %w", cfg.UnixSocket, err)
+		}
+		if err := os.Chmod(cfg.UnixSocket, unixSocketMode); err != nil {
+			l.Close()
+			closeAll()
+			return nil, fmt.Errorf("set unix socket permissions: %w", err)
+		}
+
+		listeners = append(listeners, listener{Listener: l, url: "unix:" + cfg.UnixSocket})
+	}
+
+	return listeners, nil
+}
+
+// serverTLSConfig returns the TLS configuration for the certificate files or
+// automatic certificates enabled in cfg. Automatic certificates are obtained
+// with the TLS-ALPN-01 challenge, so Port must be reachable as 443 from the
+// internet.
+func serverTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.AutocertEnabled() {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertHosts...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+		}
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// newGRPCServer listens on GRPCPort and returns a gRPC server for it, using
+// the same TLS configuration as the HTTP server.
+func newGRPCServer(cfg *config.Config) (*grpc.Server, net.Listener, error) {
+	var opts []grpc.ServerOption
+	if cfg.TLSEnabled() {
+		tlsConfig, err := serverTLSConfig(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	l, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listen on gRPC port %s: %w", cfg.GRPCPort, err)
+	}
+	return grpc.NewServer(opts...), l, nil
+}
@@ -7,16 +7,36 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/audit"
 	"github.com/mod-troubleshooter/backend/internal/cache"
 	"github.com/mod-troubleshooter/backend/internal/config"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/discord"
+	"github.com/mod-troubleshooter/backend/internal/grpcapi"
 	"github.com/mod-troubleshooter/backend/internal/handlers"
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/history"
+	"github.com/mod-troubleshooter/backend/internal/idle"
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+	"github.com/mod-troubleshooter/backend/internal/netguard"
 	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/perf"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/proxy"
+	"github.com/mod-troubleshooter/backend/internal/resolve"
+	"github.com/mod-troubleshooter/backend/internal/sandbox"
+	"github.com/mod-troubleshooter/backend/internal/stats"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
+	"github.com/mod-troubleshooter/backend/internal/watch"
+	"github.com/mod-troubleshooter/backend/internal/workspace"
 	"github.com/rs/cors"
+	"google.golang.org/grpc"
 )
 
 // clientManager manages the Nexus client lifecycle with thread-safe updates.
@@ -38,6 +58,11 @@ func (m *clientManager) Set(client *nexus.Client) {
 }
 
 func main() {
+	// The binary doubles as the archive sandbox worker
+	if len(os.Args) > 1 && os.Args[1] == sandbox.WorkerCommand {
+		sandbox.RunWorker()
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
@@ -45,19 +70,44 @@ func main() {
 
 	mux := http.NewServeMux()
 
+	if cfg.ReadOnly {
+		log.Println("Read-only mode: endpoints that download from Nexus serve cached results only")
+	}
+
+	// Audit log of analysis requests, for operators of shared instances
+	var auditLog *audit.Log
+	if cfg.AuditLog {
+		auditLog, err = audit.New(audit.Config{
+			DBPath: filepath.Join(cfg.DataDir, "audit.db"),
+		})
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+	}
+	auditHandler := handlers.NewAuditHandler(auditLog)
+	audited := auditHandler.Wrap
+	mux.HandleFunc("GET /api/audit", auditHandler.QueryAuditLog)
+
 	// Health check endpoint
 	mux.HandleFunc("GET /api/health", healthHandler)
 
 	// Initialize settings store with initial API key
 	settingsStore := handlers.NewSettingsStore(cfg.NexusAPIKey)
+	if profile, err := pipeline.ParseProfile(cfg.AnalysisProfile); err == nil {
+		settingsStore.SetAnalysisProfile(profile)
+	}
 
 	// Client manager for dynamic client updates
 	clientMgr := &clientManager{}
 
+	// Circuit breaker shared by every client so it survives API key changes
+	nexusBreaker := nexus.NewBreaker(nexus.BreakerConfig{})
+
 	// Initialize Nexus client if API key is configured
 	if cfg.NexusAPIKey != "" {
 		nexusClient, err := nexus.NewClient(nexus.ClientConfig{
-			APIKey: cfg.NexusAPIKey,
+			APIKey:  cfg.NexusAPIKey,
+			Breaker: nexusBreaker,
 		})
 		if err != nil {
 			log.Fatalf("Failed to create Nexus client: %v", err)
@@ -76,7 +126,8 @@ func main() {
 		}
 
 		newClient, err := nexus.NewClient(nexus.ClientConfig{
-			APIKey: newKey,
+			APIKey:  newKey,
+			Breaker: nexusBreaker,
 		})
 		if err != nil {
 			log.Printf("Failed to create new Nexus client: %v", err)
@@ -107,22 +158,51 @@ func main() {
 	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}", collectionHandler.GetCollectionRevisionMods)
 
 	// Download endpoints (requires Premium)
-	downloadHandler := handlers.NewDownloadHandler(clientMgr)
+	downloadHandler := handlers.NewDownloadHandler(clientMgr, cfg.ReadOnly)
 	mux.HandleFunc("GET /api/games/{game}/mods/{modId}/files/{fileId}/download", downloadHandler.GetModFileDownloadLinks)
 
+	// File identification via Nexus MD5 search
+	identifyHandler := handlers.NewIdentifyHandler(clientMgr)
+	mux.HandleFunc("POST /api/identify/md5", identifyHandler.IdentifyByMD5)
+
+	// Optional virus scan of every download before it is extracted
+	var scanner archive.Scanner
+	switch {
+	case cfg.ScanCommand != "":
+		scanner, err = archive.NewCommandScanner(cfg.ScanCommand)
+		if err != nil {
+			log.Fatalf("Failed to create virus scanner: %v", err)
+		}
+	case cfg.ClamdAddress != "":
+		scanner = archive.NewClamdScanner(cfg.ClamdAddress)
+	}
+	if scanner != nil {
+		log.Printf("Scanning downloads, quarantine at %s", cfg.QuarantineDir)
+	}
+
 	// Initialize archive downloader and extractor
+	downloadDir := filepath.Join(cfg.DataDir, "downloads")
 	downloader, err := archive.NewDownloader(archive.DownloaderConfig{
-		TempDir:     filepath.Join(cfg.DataDir, "downloads"),
-		MaxFileSize: 5 * 1024 * 1024 * 1024, // 5GB max
+		TempDir:       downloadDir,
+		MaxFileSize:   5 * 1024 * 1024 * 1024, // 5GB max
+		Scanner:       scanner,
+		QuarantineDir: cfg.QuarantineDir,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create downloader: %v", err)
 	}
 
+	// Identical files across mods are extracted to disk once
+	blobStore, err := archive.NewBlobStore(filepath.Join(cfg.DataDir, "blobs"))
+	if err != nil {
+		log.Fatalf("Failed to create blob store: %v", err)
+	}
+
 	extractor, err := archive.NewExtractor(archive.ExtractorConfig{
 		TempDir:      filepath.Join(cfg.DataDir, "extracted"),
-		MaxFileSize:  100 * 1024 * 1024,        // 100MB per file
-		MaxTotalSize: 1024 * 1024 * 1024,       // 1GB total
+		MaxFileSize:  100 * 1024 * 1024,  // 100MB per file
+		MaxTotalSize: 1024 * 1024 * 1024, // 1GB total
+		Blobs:        blobStore,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create extractor: %v", err)
@@ -137,14 +217,118 @@ func main() {
 		log.Fatalf("Failed to create cache: %v", err)
 	}
 
+	// External 7z lister for archive variants the built-in extractor cannot read
+	var sevenZip *archive.SevenZip
+	if cfg.SevenZipPath != "" {
+		sevenZip, err = archive.NewSevenZip(cfg.SevenZipPath)
+		if err != nil {
+			log.Printf("Warning: 7z fallback disabled: %v", err)
+		}
+	}
+
+	// Downloads, extractions, hashing and plugin parsing are bounded across
+	// all analyses; the limits can be changed from the settings
+	limiter := pipeline.NewLimiter(pipeline.Concurrency{
+		Downloads:    cfg.DownloadConcurrency,
+		Extractions:  cfg.ExtractionConcurrency,
+		Hashes:       cfg.HashConcurrency,
+		PluginParses: cfg.PluginParseConcurrency,
+	})
+	settingsStore.SetLimiter(limiter)
+
+	// Optionally read untrusted archives in worker processes
+	var archiveSandbox pipeline.ArchiveReader
+	var sandboxClient *sandbox.Client
+	if cfg.SandboxExtraction {
+		executable, err := os.Executable()
+		if err != nil {
+			log.Fatalf("Failed to locate sandbox worker: %v", err)
+		}
+		// One worker per extraction slot, reading only downloaded archives
+		sandboxClient = sandbox.NewClient(sandbox.Config{
+			Command:  executable,
+			ReadDirs: []string{downloadDir},
+			Workers:  limiter.Concurrency().Extractions,
+		})
+		defer sandboxClient.Close()
+		archiveSandbox = sandboxClient
+		log.Println("Reading archives in sandboxed worker processes")
+	}
+
+	// Download sessions let back-to-back analyses of a revision reuse archives
+	downloadSessions := pipeline.NewSessions(pipeline.SessionsConfig{
+		TTL:         pipeline.DefaultSessionTTL,
+		MaxSessions: pipeline.DefaultMaxSessions,
+	})
+
+	// Single files read from mod archives, with their own sessions so browsing
+	// a mod's files doesn't evict the archives of analyses
+	fileSessions := pipeline.NewSessions(pipeline.SessionsConfig{
+		TTL:         pipeline.DefaultSessionTTL,
+		MaxSessions: pipeline.DefaultMaxSessions,
+	})
+	extractHandler := handlers.NewExtractHandler(handlers.ExtractHandlerConfig{
+		ClientGetter: clientMgr,
+		Downloader:   downloader,
+		Extractor:    extractor,
+		Sessions:     fileSessions,
+		ReadOnly:     cfg.ReadOnly,
+	})
+	mux.HandleFunc("GET /api/games/{game}/mods/{modId}/files/{fileId}/extract", extractHandler.ExtractFile)
+
+	// Local usage statistics (opt-in, never reported externally)
+	var usageStats *stats.Collector
+	if cfg.StatsEnabled {
+		usageStats, err = stats.New(stats.Config{
+			Path: filepath.Join(cfg.DataDir, "stats.json"),
+		})
+		if err != nil {
+			log.Printf("Warning: failed to load usage stats, starting fresh: %v", err)
+			usageStats, _ = stats.New(stats.Config{})
+		}
+	}
+
+	statsHandler := handlers.NewStatsHandler(usageStats)
+	mux.HandleFunc("GET /api/stats", statsHandler.GetStats)
+	mux.HandleFunc("DELETE /api/stats", statsHandler.ResetStats)
+
 	// FOMOD analysis endpoints (requires Premium)
 	fomodHandler := handlers.NewFomodHandler(handlers.FomodHandlerConfig{
 		ClientGetter: clientMgr,
 		Downloader:   downloader,
 		Extractor:    extractor,
 		Cache:        fomodCache,
+		Stats:        usageStats,
+		ReadOnly:     cfg.ReadOnly,
+	})
+	mux.HandleFunc("POST /api/fomod/analyze", audited(fomodHandler.AnalyzeFomod))
+	mux.HandleFunc("POST /api/fomod/validate", fomodHandler.ValidateFomod)
+
+	// Suppressions hide accepted findings from analysis results and reports.
+	// Curator rule files are checked against the keys the operator trusts.
+	var curatorKeys map[string]string
+	if cfg.CuratorKeysFile != "" {
+		curatorKeys, err = suppress.LoadCuratorKeys(cfg.CuratorKeysFile)
+		if err != nil {
+			log.Fatalf("Failed to load curator keys: %v", err)
+		}
+	}
+	suppressionStore, err := suppress.New(suppress.Config{
+		DBPath:      filepath.Join(cfg.DataDir, "suppressions.db"),
+		CuratorKeys: curatorKeys,
 	})
-	mux.HandleFunc("POST /api/fomod/analyze", fomodHandler.AnalyzeFomod)
+	if err != nil {
+		log.Fatalf("Failed to create suppression store: %v", err)
+	}
+
+	suppressionHandler := handlers.NewSuppressionHandler(suppressionStore)
+	mux.HandleFunc("GET /api/suppressions", suppressionHandler.ListSuppressions)
+	mux.HandleFunc("POST /api/suppressions", suppressionHandler.CreateSuppression)
+	mux.HandleFunc("GET /api/suppressions/{id}", suppressionHandler.GetSuppression)
+	mux.HandleFunc("DELETE /api/suppressions/{id}", suppressionHandler.RevokeSuppression)
+	mux.HandleFunc("GET /api/collections/{slug}/curator-rules", suppressionHandler.GetRuleFile)
+	mux.HandleFunc("PUT /api/collections/{slug}/curator-rules", suppressionHandler.AttachRuleFile)
+	mux.HandleFunc("DELETE /api/collections/{slug}/curator-rules", suppressionHandler.DetachRuleFile)
 
 	// Load order analysis endpoints (requires Premium for collection analysis)
 	loadOrderHandler := handlers.NewLoadOrderHandler(handlers.LoadOrderHandlerConfig{
@@ -152,32 +336,368 @@ func main() {
 		Downloader:   downloader,
 		Extractor:    extractor,
 		Cache:        fomodCache,
+		Stats:        usageStats,
+		Sessions:     downloadSessions,
+		Suppressions: suppressionStore,
+		ReadOnly:     cfg.ReadOnly,
+		SevenZip:     sevenZip,
+		Sandbox:      archiveSandbox,
+		Limiter:      limiter,
+	})
+	mux.HandleFunc("POST /api/loadorder/analyze", audited(loadOrderHandler.AnalyzeLoadOrder))
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/loadorder", audited(loadOrderHandler.AnalyzeCollectionLoadOrder))
+
+	// Revision comparison endpoint (requires Premium for downloading updated mods)
+	revisionHandler := handlers.NewRevisionHandler(handlers.RevisionHandlerConfig{
+		ClientGetter: clientMgr,
+		Downloader:   downloader,
+		Extractor:    extractor,
+		Cache:        fomodCache,
+		Sessions:     downloadSessions,
+		ReadOnly:     cfg.ReadOnly,
+		SevenZip:     sevenZip,
+		Sandbox:      archiveSandbox,
+		Limiter:      limiter,
 	})
-	mux.HandleFunc("POST /api/loadorder/analyze", loadOrderHandler.AnalyzeLoadOrder)
-	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/loadorder", loadOrderHandler.AnalyzeCollectionLoadOrder)
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{from}/compare/{to}", audited(revisionHandler.CompareRevisions))
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/removal", audited(revisionHandler.PreviewRemoval))
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/size", audited(revisionHandler.GetRevisionSize))
 
 	// Conflict analysis endpoints (requires Premium for downloading mod archives)
+	// Mod pair overlaps are shared by every conflict analysis, so a new revision
+	// only recomputes pairs involving the mods that changed
+	conflictPairs := conflict.NewMemoryPairCache(conflict.DefaultMaxPairs)
+	// Background jobs for analyses requested asynchronously
+	// Jobs are persisted in the cache, so they can be polled across restarts
+	jobQueue := jobs.New(jobs.Config{Store: fomodCache})
+	jobHandler := handlers.NewJobHandler(jobQueue)
+	mux.HandleFunc("POST /api/jobs", audited(jobHandler.CreateJob))
+	mux.HandleFunc("GET /api/jobs/{id}", jobHandler.GetJob)
+	mux.HandleFunc("GET /api/jobs/{id}/events", jobHandler.JobEvents)
+
 	conflictHandler := handlers.NewConflictHandler(handlers.ConflictHandlerConfig{
 		ClientGetter: clientMgr,
 		Downloader:   downloader,
+		Extractor:    extractor,
+		Cache:        fomodCache,
+		Stats:        usageStats,
+		Sessions:     downloadSessions,
+		Suppressions: suppressionStore,
+		ReadOnly:     cfg.ReadOnly,
+		SevenZip:     sevenZip,
+		Sandbox:      archiveSandbox,
+		Limiter:      limiter,
+		PairCache:    conflictPairs,
+		Jobs:         jobQueue,
+
+		ContentPreviews: cfg.ContentPreviews,
+		ManifestTTL:     time.Duration(cfg.ManifestCacheTTLHours) * time.Hour,
+	})
+	mux.HandleFunc("POST /api/conflicts/analyze", audited(conflictHandler.AnalyzeConflicts))
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/conflicts", audited(conflictHandler.AnalyzeCollectionConflicts))
+	jobHandler.Register("collection-conflicts", conflictHandler.CollectionConflictsJob)
+
+	// Interactive conflict resolution sessions, kept in memory
+	resolutionHandler := handlers.NewResolutionHandler(handlers.ResolutionHandlerConfig{
+		Manager:      resolve.NewManager(resolve.Config{}),
+		Cache:        fomodCache,
+		Suppressions: suppressionStore,
+	})
+	mux.HandleFunc("POST /api/resolutions", resolutionHandler.CreateResolution)
+	mux.HandleFunc("GET /api/resolutions/{id}", resolutionHandler.GetResolution)
+	mux.HandleFunc("DELETE /api/resolutions/{id}", resolutionHandler.DeleteResolution)
+	mux.HandleFunc("POST /api/resolutions/{id}/decisions", resolutionHandler.Decide)
+	mux.HandleFunc("DELETE /api/resolutions/{id}/decisions", resolutionHandler.UndoDecision)
+	mux.HandleFunc("GET /api/resolutions/{id}/plan", resolutionHandler.GetResolutionPlan)
+
+	// Known script extender plugins, optionally updated from a file
+	dllAllowlist := health.DefaultDLLAllowlist()
+	if cfg.DLLAllowlistFile != "" {
+		dllAllowlist, err = health.LoadDLLAllowlist(cfg.DLLAllowlistFile)
+		if err != nil {
+			log.Fatalf("Failed to load DLL allowlist: %v", err)
+		}
+		log.Printf("DLL allowlist: %d known plugins", dllAllowlist.Len())
+	}
+
+	// Combined analysis endpoint (downloads each mod once for all analyzers)
+	analysisPipeline, err := pipeline.New(
+		pipeline.NewConflictStageWithCache(conflictPairs),
+		pipeline.NewLoadOrderStage(),
+		pipeline.NewFomodStage(extractor),
+		pipeline.NewHealthStageWithDLLAllowlist(dllAllowlist),
+		pipeline.NewPerformanceStage(perf.DefaultProfiles),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create analysis pipeline: %v", err)
+	}
+
+	analyzeHandler := handlers.NewAnalyzeHandler(handlers.AnalyzeHandlerConfig{
+		ClientGetter: clientMgr,
+		Downloader:   downloader,
+		Extractor:    extractor,
+		Cache:        fomodCache,
+		Stats:        usageStats,
+		Sessions:     downloadSessions,
+		Suppressions: suppressionStore,
+		ReadOnly:     cfg.ReadOnly,
+		SevenZip:     sevenZip,
+		Sandbox:      archiveSandbox,
+		Limiter:      limiter,
+		Pipeline:     analysisPipeline,
+
+		ContentPreviews: cfg.ContentPreviews,
+		Settings:        settingsStore,
+	})
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/analyze", audited(analyzeHandler.AnalyzeCollection))
+
+	// Collection comparison, scoring both collections with the combined analysis
+	compareHandler := handlers.NewCompareHandler(handlers.CompareHandlerConfig{
+		ClientGetter: clientMgr,
+		Analyzer:     analyzeHandler,
+	})
+	mux.HandleFunc("GET /api/compare/collections", audited(compareHandler.CompareCollections))
+
+	// Optional Discord bot, answering "!analyze" commands with the combined analysis
+	botCtx, stopBot := context.WithCancel(context.Background())
+	defer stopBot()
+	if cfg.DiscordBotToken != "" {
+		bot := discord.NewBot(discord.Config{
+			Token:   cfg.DiscordBotToken,
+			Analyze: discordAnalyzer(analyzeHandler),
+		})
+		go bot.Run(botCtx)
+		log.Println("Discord bot: enabled")
+	}
+
+	// MO2 overwrite folder analysis (works without Nexus access)
+	overwriteHandler := handlers.NewOverwriteHandler()
+	mux.HandleFunc("POST /api/analyze/overwrite", audited(overwriteHandler.AnalyzeOverwrite))
+
+	// Save game compatibility check against a plugin list or analyzed collection
+	savegameHandler := handlers.NewSavegameHandler(fomodCache)
+	mux.HandleFunc("POST /api/savegame/check", audited(savegameHandler.CheckSavegame))
+
+	// Manifest similarity, e.g. to tell whether a reupload matches the original
+	similarityHandler := handlers.NewSimilarityHandler()
+	mux.HandleFunc("POST /api/manifests/similarity", similarityHandler.CompareManifests)
+
+	// Manifest diffs, e.g. to see what changed between two versions of a mod
+	manifestDiffHandler := handlers.NewManifestDiffHandler(handlers.ManifestDiffHandlerConfig{
+		ClientGetter: clientMgr,
+		Downloader:   downloader,
+		Sandbox:      archiveSandbox,
+		Sessions:     fileSessions,
+		ReadOnly:     cfg.ReadOnly,
+	})
+	mux.HandleFunc("POST /api/manifests/diff", manifestDiffHandler.DiffManifests)
+
+	// Export endpoints for external tools
+	exportHandler := handlers.NewExportHandler()
+	mux.HandleFunc("POST /api/export/loot", exportHandler.ExportLOOTUserlist)
+	mux.HandleFunc("POST /api/export/mo2", exportHandler.ExportMO2Notes)
+
+	// Analysis bundle export (serves cached results only)
+	bundleHandler := handlers.NewBundleHandler(handlers.BundleHandlerConfig{
+		ClientGetter: clientMgr,
 		Cache:        fomodCache,
+		Suppressions: suppressionStore,
+		APIKey:       settingsStore.GetNexusAPIKey,
+	})
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/bundle", audited(bundleHandler.ExportBundle))
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/provenance", audited(bundleHandler.ExportProvenance))
+
+	// Analysis history (imported bundles are viewable without Nexus access)
+	historyStore, err := history.New(history.Config{
+		DBPath: filepath.Join(cfg.DataDir, "history.db"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create history store: %v", err)
+	}
+
+	historyHandler := handlers.NewHistoryHandler(historyStore)
+	mux.HandleFunc("POST /api/import/bundle", historyHandler.ImportBundle)
+	mux.HandleFunc("GET /api/history", historyHandler.ListHistory)
+	mux.HandleFunc("GET /api/history/{id}", historyHandler.GetHistoryEntry)
+	mux.HandleFunc("GET /api/history/{id}/bundle", historyHandler.ExportHistoryBundle)
+	mux.HandleFunc("DELETE /api/history/{id}", historyHandler.DeleteHistoryEntry)
+	mux.HandleFunc("GET /api/history/{id}/notes", historyHandler.ListNotes)
+	mux.HandleFunc("POST /api/history/{id}/conflicts/{index}/notes", historyHandler.AddConflictNote)
+	mux.HandleFunc("POST /api/history/{id}/issues/{index}/notes", historyHandler.AddIssueNote)
+	mux.HandleFunc("DELETE /api/history/{id}/notes/{noteId}", historyHandler.DeleteNote)
+
+	// Batch analysis (one job per collection, each stored in the history)
+	batchHandler := handlers.NewBatchHandler(handlers.BatchHandlerConfig{
+		Queue:    jobQueue,
+		Analyzer: analyzeHandler,
+		History:  historyStore,
 	})
-	mux.HandleFunc("POST /api/conflicts/analyze", conflictHandler.AnalyzeConflicts)
-	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/conflicts", conflictHandler.AnalyzeCollectionConflicts)
+	mux.HandleFunc("POST /api/analyze/batch", audited(batchHandler.AnalyzeBatch))
+	mux.HandleFunc("GET /api/analyze/batch/{id}", batchHandler.GetBatch)
 
-	// Configure CORS for React frontend
+	// Watched collections (monitored for new revisions). Their targets only
+	// reach public addresses and the networks the operator allows.
+	notifyGuard, err := netguard.New(cfg.NotifyAllowedNetworks)
+	if err != nil {
+		log.Fatalf("Failed to configure notification targets: %v", err)
+	}
+	watchStore, err := watch.New(watch.Config{
+		DBPath: filepath.Join(cfg.DataDir, "watches.db"),
+		Guard:  notifyGuard,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create watch store: %v", err)
+	}
+
+	watchDispatcher := watch.NewDispatcher(watch.DispatcherConfig{
+		Guard: notifyGuard,
+		SMTP: watch.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		},
+	})
+	watchHandler := handlers.NewWatchHandler(handlers.WatchHandlerConfig{
+		Store:      watchStore,
+		Dispatcher: watchDispatcher,
+		Analyzers:  analysisPipeline.Names(),
+	})
+	mux.HandleFunc("GET /api/watches", watchHandler.ListWatches)
+	mux.HandleFunc("POST /api/watches", watchHandler.PutWatch)
+	mux.HandleFunc("GET /api/watches/{slug}", watchHandler.GetWatch)
+	mux.HandleFunc("DELETE /api/watches/{slug}", watchHandler.DeleteWatch)
+	mux.HandleFunc("POST /api/watches/{slug}/test", watchHandler.TestWatch)
+
+	// New revisions of watched collections are analyzed in the background,
+	// unless the server is read-only and can't store the results
+	var watchScheduler *watch.Scheduler
+	if !cfg.ReadOnly {
+		watchScheduler = watch.NewScheduler(watch.SchedulerConfig{
+			Store:      watchStore,
+			Revisions:  nexusRevisions{clientMgr},
+			Analyze:    watchAnalyzer(batchHandler),
+			Queue:      jobQueue,
+			Dispatcher: watchDispatcher,
+			Interval:   time.Duration(cfg.WatchIntervalMinutes) * time.Minute,
+		})
+	}
+
+	// Workspaces (users' own mod setups, imported once)
+	workspaceStore, err := workspace.New(workspace.Config{
+		DBPath: filepath.Join(cfg.DataDir, "workspaces.db"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create workspace store: %v", err)
+	}
+
+	workspaceHandler := handlers.NewWorkspaceHandler(handlers.WorkspaceHandlerConfig{
+		Store:        workspaceStore,
+		ClientGetter: clientMgr,
+		Downloader:   downloader,
+		Extractor:    extractor,
+		ReadOnly:     cfg.ReadOnly,
+		SevenZip:     sevenZip,
+		Sandbox:      archiveSandbox,
+		Limiter:      limiter,
+
+		ContentPreviews: cfg.ContentPreviews,
+	})
+	mux.HandleFunc("GET /api/workspaces", workspaceHandler.ListWorkspaces)
+	mux.HandleFunc("POST /api/workspaces", workspaceHandler.CreateWorkspace)
+	mux.HandleFunc("GET /api/workspaces/{id}", workspaceHandler.GetWorkspace)
+	mux.HandleFunc("PUT /api/workspaces/{id}", workspaceHandler.UpdateWorkspace)
+	mux.HandleFunc("DELETE /api/workspaces/{id}", workspaceHandler.DeleteWorkspace)
+	mux.HandleFunc("POST /api/workspaces/{id}/preview", workspaceHandler.PreviewAdd)
+	mux.HandleFunc("POST /api/workspaces/{id}/preview/remove", workspaceHandler.PreviewRemove)
+
+	// Desktop installs pause background work while the player is away, such
+	// as in game, and wake on the next request
+	var root http.Handler = mux
+	if cfg.DesktopMode {
+		hooks := []idle.Hook{
+			{Name: "jobs", Pause: jobQueue.Pause, Resume: jobQueue.Resume},
+			{Name: "download sessions", Pause: func() {
+				downloadSessions.ReleaseIdle()
+				fileSessions.ReleaseIdle()
+			}},
+		}
+		if sandboxClient != nil {
+			hooks = append(hooks, idle.Hook{Name: "sandbox workers", Pause: sandboxClient.StopIdle})
+		}
+		if watchScheduler != nil {
+			hooks = append(hooks, idle.Hook{Name: "watch scheduler", Pause: watchScheduler.Pause, Resume: watchScheduler.Resume})
+		}
+		monitor := idle.New(idle.Config{
+			Timeout: time.Duration(cfg.IdleTimeoutMinutes) * time.Minute,
+			Hooks:   hooks,
+			Busy:    func() bool { return jobQueue.Running() > 0 },
+			Quiet: func(r *http.Request) bool {
+				return r.URL.Path == "/api/health" || strings.HasPrefix(r.URL.Path, "/api/admin/")
+			},
+		})
+		defer monitor.Close()
+		root = monitor.Handler(mux)
+
+		adminHandler := handlers.NewAdminHandler(monitor)
+		mux.HandleFunc("GET /api/admin/status", adminHandler.GetStatus)
+		mux.HandleFunc("POST /api/admin/pause", adminHandler.Pause)
+		mux.HandleFunc("POST /api/admin/resume", adminHandler.Resume)
+		if cfg.IdleTimeoutMinutes > 0 {
+			log.Printf("Desktop mode: pausing background work after %d minutes without requests", cfg.IdleTimeoutMinutes)
+		} else {
+			log.Println("Desktop mode: pausing background work on demand only")
+		}
+	}
+
+	// Configure CORS for React frontend and any per-origin rules
 	c := cors.New(cors.Options{
-		AllowedOrigins:   cfg.CORSOrigins,
+		AllowOriginRequestFunc: func(r *http.Request, origin string) bool {
+			method := r.Method
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				method = r.Header.Get("Access-Control-Request-Method")
+			}
+			return cfg.AllowOrigin(origin, method)
+		},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{handlers.RequestIDHeader, "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
 
-	handler := c.Handler(mux)
+	// Honor X-Forwarded-* headers from trusted reverse proxies
+	proxyResolver, err := proxy.NewResolver(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+
+	handler := proxyResolver.Handler(handlers.RequestID(c.Handler(root)))
+
+	listeners, err := openListeners(cfg)
+	if err != nil {
+		log.Fatalf("Failed to start listening: %v", err)
+	}
+
+	// Optional gRPC API for programmatic consumers, sharing the HTTP handlers' analyses
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		s, l, err := newGRPCServer(cfg)
+		if err != nil {
+			log.Fatalf("Failed to start gRPC server: %v", err)
+		}
+		grpcapi.Register(s, analyzeHandler)
+		grpcServer = s
+		go func() {
+			log.Printf("gRPC server starting on port %s", cfg.GRPCPort)
+			if err := s.Serve(l); err != nil {
+				log.Fatalf("gRPC server error: %v", err)
+			}
+		}()
+	}
 
 	server := &http.Server{
-		Addr:         ":" + cfg.Port,
 		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
@@ -186,7 +706,6 @@ func main() {
 
 	// Graceful shutdown
 	go func() {
-		log.Printf("Server starting on http://localhost:%s", cfg.Port)
 		log.Printf("Environment: %s", cfg.Environment)
 		log.Printf("Data directory: %s", cfg.DataDir)
 		if cfg.NexusAPIKey != "" {
@@ -194,8 +713,16 @@ func main() {
 		} else {
 			log.Printf("Nexus API key: not configured")
 		}
-		if err := server.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+		if cfg.StatsEnabled {
+			log.Printf("Usage stats: enabled (local only)")
+		}
+		for _, l := range listeners {
+			go func(l listener) {
+				log.Printf("Server starting on %s", l.url)
+				if err := server.Serve(l); err != http.ErrServerClosed {
+					log.Fatalf("Server error: %v", err)
+				}
+			}(l)
 		}
 	}()
 
@@ -205,17 +732,47 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopBot()
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server shutdown error: %v", err)
 	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
 
 	// Cleanup resources
+	if watchScheduler != nil {
+		watchScheduler.Close()
+	}
+	jobQueue.Close()
 	if err := fomodCache.Close(); err != nil {
 		log.Printf("Error closing cache: %v", err)
 	}
+	if err := historyStore.Close(); err != nil {
+		log.Printf("Error closing history store: %v", err)
+	}
+	if auditLog != nil {
+		if err := auditLog.Close(); err != nil {
+			log.Printf("Error closing audit log: %v", err)
+		}
+	}
+	if err := watchStore.Close(); err != nil {
+		log.Printf("Error closing watch store: %v", err)
+	}
+	if err := workspaceStore.Close(); err != nil {
+		log.Printf("Error closing workspace store: %v", err)
+	}
+	if err := suppressionStore.Close(); err != nil {
+		log.Printf("Error closing suppression store: %v", err)
+	}
+	if err := usageStats.Save(); err != nil {
+		log.Printf("Error saving usage stats: %v", err)
+	}
+	downloadSessions.Close()
+	fileSessions.Close()
 	if err := downloader.Cleanup(); err != nil {
 		log.Printf("Error cleaning up downloads: %v", err)
 	}
@@ -223,6 +780,81 @@ func main() {
 	log.Println("Server stopped")
 }
 
+// discordAnalyzer adapts the combined analysis to the report the Discord bot posts.
+func discordAnalyzer(h *handlers.AnalyzeHandler) discord.AnalyzeFunc {
+	names := []string{pipeline.NameHealth, pipeline.NameConflicts}
+	return func(ctx context.Context, slug string, revision int) (*discord.Report, error) {
+		resp, err := h.Run(ctx, slug, revision, names)
+		if err != nil {
+			return nil, err
+		}
+
+		report := &discord.Report{
+			Slug:       resp.Slug,
+			Revision:   resp.Revision,
+			GameDomain: resp.GameDomain,
+			Warnings:   len(resp.Warnings),
+		}
+		report.Health, _ = resp.Results[pipeline.NameHealth].Data.(*health.Report)
+		report.Conflicts, _ = resp.Results[pipeline.NameConflicts].Data.(*conflict.AnalysisResult)
+		return report, nil
+	}
+}
+
+// nexusRevisions lists the revisions of watched collections from Nexus Mods.
+type nexusRevisions struct {
+	clients handlers.NexusClientGetter
+}
+
+func (r nexusRevisions) Revisions(ctx context.Context, slug string) ([]watch.Revision, error) {
+	client := r.clients.Get()
+	if client == nil {
+		return nil, nexus.ErrNoAPIKey
+	}
+	revisions, err := client.GetCollectionRevisions(ctx, "", slug)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]watch.Revision, 0, len(revisions))
+	for _, rev := range revisions {
+		result = append(result, watch.Revision{Number: rev.RevisionNumber, PublishedAt: rev.CreatedAt})
+	}
+	return result, nil
+}
+
+// watchAnalyzer adapts batch analysis, stored in the history, to the
+// findings watched collections are notified of.
+func watchAnalyzer(h *handlers.BatchHandler) watch.AnalyzeFunc {
+	return func(ctx context.Context, slug string, revision int, names []string) (*watch.Findings, error) {
+		result, err := h.AnalyzeCollection(ctx, slug, revision, names, history.SourceWatch)
+		if err != nil {
+			return nil, err
+		}
+		return &watch.Findings{
+			Conflicts:   result.Conflicts,
+			Issues:      result.Issues,
+			HealthScore: result.HealthScore,
+			HistoryURL:  result.HistoryURL,
+		}, nil
+	}
+}
+
+// stopGRPC stops the gRPC server gracefully, cancelling calls still running
+// once ctx is done.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,166 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BlobStore keeps extracted file bodies on disk, addressed by the SHA-256 of
+// their content and reference counted. Identical files extracted from
+// different archives are stored once and hard-linked into each output
+// directory, so common assets don't multiply disk usage across a collection.
+type BlobStore struct {
+	dir string
+
+	mu    sync.Mutex
+	refs  map[string]int
+	sizes map[string]int64
+	saved int64
+}
+
+// Blob is a stored file body.
+type Blob struct {
+	// Hash is the SHA-256 of the content.
+	Hash string
+	// Path is where the blob is stored. It must not be modified.
+	Path string
+	// Size is the content length in bytes.
+	Size int64
+}
+
+// BlobStats summarizes the contents of a blob store.
+type BlobStats struct {
+	// Blobs is the number of distinct file bodies stored.
+	Blobs int `json:"blobs"`
+	// References is the number of extracted files pointing at a blob.
+	References int `json:"references"`
+	// StoredBytes is the disk space used by the blobs.
+	StoredBytes int64 `json:"storedBytes"`
+	// SavedBytes is the disk space avoided by deduplication since the store was created.
+	SavedBytes int64 `json:"savedBytes"`
+}
+
+// NewBlobStore creates a blob store in dir, creating the directory if needed.
+// Blobs left over from a previous run are removed, since their references are gone.
+func NewBlobStore(dir string) (*BlobStore, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("clear blob dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	return &BlobStore{
+		dir:   dir,
+		refs:  make(map[string]int),
+		sizes: make(map[string]int64),
+	}, nil
+}
+
+// Put stores the content of r and adds a reference to it.
+func (s *BlobStore) Put(r io.Reader) (Blob, error) {
+	tmp, err := os.CreateTemp(s.dir, "incoming-*")
+	if err != nil {
+		return Blob{}, fmt.Errorf("create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	closeErr := tmp.Close()
+	if err != nil {
+		return Blob{}, fmt.Errorf("write blob: %w", err)
+	}
+	if closeErr != nil {
+		return Blob{}, fmt.Errorf("write blob: %w", closeErr)
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	blob := Blob{Hash: hash, Path: s.path(hash), Size: size}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refs[hash] > 0 {
+		s.refs[hash]++
+		s.saved += size
+		return blob, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blob.Path), 0755); err != nil {
+		return Blob{}, fmt.Errorf("create blob dir: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), blob.Path); err != nil {
+		return Blob{}, fmt.Errorf("store blob: %w", err)
+	}
+	s.refs[hash] = 1
+	s.sizes[hash] = size
+	return blob, nil
+}
+
+// Release drops a reference to a blob, deleting it once no references remain.
+func (s *BlobStore) Release(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refs[hash] == 0 {
+		return
+	}
+	s.refs[hash]--
+	if s.refs[hash] == 0 {
+		delete(s.refs, hash)
+		delete(s.sizes, hash)
+		os.Remove(s.path(hash))
+	}
+}
+
+// Stats returns a snapshot of the store's usage.
+func (s *BlobStore) Stats() BlobStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := BlobStats{Blobs: len(s.refs), SavedBytes: s.saved}
+	for hash, refs := range s.refs {
+		stats.References += refs
+		stats.StoredBytes += s.sizes[hash]
+	}
+	return stats
+}
+
+// path returns where a blob is stored, sharded by the first byte of its hash.
+func (s *BlobStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// linkOrCopy makes dest refer to the blob at src, hard-linking when the
+// filesystem allows it and copying otherwise. An existing dest, such as an
+// earlier entry with the same path in the archive, is replaced rather than
+// written through, since it may be a link to another blob.
+func linkOrCopy(src, dest string) error {
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlobStore_PutRelease(t *testing.T) {
+	store, err := NewBlobStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatalf("NewBlobStore() error = %v", err)
+	}
+
+	a, err := store.Put(strings.NewReader("shared"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	b, err := store.Put(strings.NewReader("shared"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if a.Hash != b.Hash || a.Path != b.Path {
+		t.Errorf("expected identical content to share a blob, got %s and %s", a.Path, b.Path)
+	}
+
+	stats := store.Stats()
+	if stats.Blobs != 1 || stats.References != 2 || stats.StoredBytes != 6 || stats.SavedBytes != 6 {
+		t.Errorf("expected 1 blob with 2 references, got %+v", stats)
+	}
+
+	store.Release(a.Hash)
+	if _, err := os.Stat(a.Path); err != nil {
+		t.Errorf("expected blob to remain while referenced, got %v", err)
+	}
+	store.Release(a.Hash)
+	if _, err := os.Stat(a.Path); !os.IsNotExist(err) {
+		t.Errorf("expected blob to be deleted at zero references, got %v", err)
+	}
+	if stats := store.Stats(); stats.Blobs != 0 || stats.References != 0 {
+		t.Errorf("expected empty store, got %+v", stats)
+	}
+
+	// Releasing an unknown hash is a no-op
+	store.Release(a.Hash)
+}
+
+func TestExtractor_BlobDedup(t *testing.T) {
+	store, err := NewBlobStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatalf("NewBlobStore() error = %v", err)
+	}
+	ext, err := NewExtractor(ExtractorConfig{TempDir: t.TempDir(), Blobs: store})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+
+	zipA := createTestZip(t, map[string]string{"fomod/images/logo.png": "logo", "a.esp": "plugin a"})
+	defer os.Remove(zipA)
+	zipB := createTestZip(t, map[string]string{"fomod/images/logo.png": "logo", "b.esp": "plugin b"})
+	defer os.Remove(zipB)
+
+	ctx := context.Background()
+	resultA, err := ext.Extract(ctx, zipA, "")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	resultB, err := ext.Extract(ctx, zipB, "")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(resultB.OutputDir, "fomod/images/logo.png"))
+	if err != nil || string(data) != "logo" {
+		t.Errorf("expected extracted file content %q, got %q (%v)", "logo", data, err)
+	}
+
+	stats := store.Stats()
+	if stats.Blobs != 3 || stats.References != 4 || stats.SavedBytes != 4 {
+		t.Errorf("expected shared file to be stored once, got %+v", stats)
+	}
+
+	if err := ext.Cleanup(resultA.OutputDir); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if stats := store.Stats(); stats.Blobs != 2 || stats.References != 2 {
+		t.Errorf("expected cleanup to release blobs, got %+v", stats)
+	}
+	if _, err := os.Stat(filepath.Join(resultB.OutputDir, "fomod/images/logo.png")); err != nil {
+		t.Errorf("expected other extraction to keep its files, got %v", err)
+	}
+
+	ext.Cleanup(resultB.OutputDir)
+	if stats := store.Stats(); stats.Blobs != 0 {
+		t.Errorf("expected empty store after cleanup, got %+v", stats)
+	}
+}
+
+func TestExtractor_BlobDedup_DuplicatePath(t *testing.T) {
+	store, err := NewBlobStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatalf("NewBlobStore() error = %v", err)
+	}
+	ext, err := NewExtractor(ExtractorConfig{TempDir: t.TempDir(), Blobs: store})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+
+	shared := createTestZip(t, map[string]string{"textures/a.dds": "first"})
+	defer os.Remove(shared)
+
+	// A map can't hold the same path twice, so write this archive by hand
+	dupPath := filepath.Join(t.TempDir(), "dup.zip")
+	f, err := os.Create(dupPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for _, content := range []string{"first", "second"} {
+		w, err := zw.Create("textures/b.dds")
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	f.Close()
+
+	ctx := context.Background()
+	sharedResult, err := ext.Extract(ctx, shared, "")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	dupResult, err := ext.Extract(ctx, dupPath, "")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dupResult.OutputDir, "textures/b.dds"))
+	if err != nil || string(data) != "second" {
+		t.Errorf("expected last entry to win with %q, got %q (%v)", "second", data, err)
+	}
+	data, err = os.ReadFile(filepath.Join(sharedResult.OutputDir, "textures/a.dds"))
+	if err != nil || string(data) != "first" {
+		t.Errorf("expected shared blob to be untouched with %q, got %q (%v)", "first", data, err)
+	}
+}
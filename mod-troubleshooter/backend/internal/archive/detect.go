@@ -0,0 +1,195 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is how many leading bytes Sniff looks at.
+const sniffLen = 512
+
+// Kind is the type of a file as determined from its content.
+type Kind string
+
+const (
+	// KindUnknown is content Sniff does not recognize.
+	KindUnknown Kind = ""
+	KindZip     Kind = "zip"
+	Kind7z      Kind = "7z"
+	KindRar     Kind = "rar"
+	// KindPlugin is a loose Bethesda plugin, starting with a TES4 record.
+	KindPlugin Kind = "plugin"
+	// KindHTML, KindXML and KindJSON are text documents. Downloads of these
+	// are almost always error pages served in place of the file.
+	KindHTML Kind = "html"
+	KindXML  Kind = "xml"
+	KindJSON Kind = "json"
+)
+
+// IsArchive reports whether the kind is a supported archive format.
+func (k Kind) IsArchive() bool {
+	return k == KindZip || k == Kind7z || k == KindRar
+}
+
+// IsDocument reports whether the kind is a text document rather than a mod file.
+func (k Kind) IsDocument() bool {
+	return k == KindHTML || k == KindXML || k == KindJSON
+}
+
+// Sniff determines the kind of a file from its first bytes. Pass at least
+// the first 512 bytes when available.
+func Sniff(header []byte) Kind {
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return KindZip
+	case bytes.HasPrefix(header, []byte("7z\xBC\xAF\x27\x1C")):
+		return Kind7z
+	case bytes.HasPrefix(header, []byte("Rar!\x1A\x07")):
+		return KindRar
+	case bytes.HasPrefix(header, []byte("TES4")):
+		return KindPlugin
+	}
+
+	text := bytes.TrimPrefix(header, []byte("\xEF\xBB\xBF"))
+	text = bytes.TrimLeft(text, " \t\r\n")
+	if len(text) > sniffLen {
+		text = text[:sniffLen]
+	}
+	lower := bytes.ToLower(text)
+	switch {
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")),
+		bytes.HasPrefix(lower, []byte("<head")), bytes.HasPrefix(lower, []byte("<body")):
+		return KindHTML
+	case bytes.HasPrefix(lower, []byte("<?xml")):
+		if bytes.Contains(lower, []byte("<html")) {
+			return KindHTML
+		}
+		return KindXML
+	case bytes.HasPrefix(text, []byte("{\"")), bytes.HasPrefix(text, []byte("{}")):
+		return KindJSON
+	}
+
+	return KindUnknown
+}
+
+// Extension returns the file extension for content of kind k, given the
+// header it was sniffed from. Plugins are .esm, .esl or .esp according to
+// their header flags. It returns "" for kinds without a mod file extension.
+func (k Kind) Extension(header []byte) string {
+	switch k {
+	case KindZip:
+		return ".zip"
+	case Kind7z:
+		return ".7z"
+	case KindRar:
+		return ".rar"
+	case KindPlugin:
+		// The record flags follow the TES4 signature and data size
+		if len(header) >= 12 {
+			flags := binary.LittleEndian.Uint32(header[8:12])
+			switch {
+			case flags&0x1 != 0:
+				return ".esm"
+			case flags&0x200 != 0:
+				return ".esl"
+			}
+		}
+		return ".esp"
+	}
+	return ""
+}
+
+// IsArchive checks if a file is an archive based on content type or extension.
+func IsArchive(filePath string) bool {
+	// Try to identify by reading file header
+	f, err := os.Open(filePath)
+	if err != nil {
+		return IsArchiveFilename(strings.ToLower(filePath))
+	}
+	defer f.Close()
+
+	header := make([]byte, 16)
+	n, _ := io.ReadFull(f, header)
+	if n < 4 {
+		return IsArchiveFilename(strings.ToLower(filePath))
+	}
+
+	// Magic bytes take priority over the extension
+	if Sniff(header[:n]).IsArchive() {
+		return true
+	}
+
+	return IsArchiveFilename(strings.ToLower(filePath))
+}
+
+// IsArchiveFilename checks if a filename has an archive extension.
+func IsArchiveFilename(filename string) bool {
+	switch {
+	case strings.HasSuffix(filename, ".zip"):
+		return true
+	case strings.HasSuffix(filename, ".7z"):
+		return true
+	case strings.HasSuffix(filename, ".rar"):
+		return true
+	default:
+		return false
+	}
+}
+
+// modFileExtensions are the archive and plugin extensions, which are
+// replaced rather than kept when the content disagrees.
+var modFileExtensions = map[string]bool{
+	".zip": true, ".7z": true, ".rar": true,
+	".esp": true, ".esm": true, ".esl": true,
+}
+
+// fixExtension returns filename with the extension matching its content.
+// A mod file extension that disagrees with the content is replaced;
+// anything else is kept and the correct extension appended.
+func fixExtension(filename string, kind Kind, header []byte) string {
+	want := kind.Extension(header)
+	if want == "" {
+		return filename
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == want {
+		return filename
+	}
+	// Plugin flags don't have to match the extension; master-flagged .esp
+	// files are common, and the name decides how they load
+	if kind == KindPlugin && (ext == ".esp" || ext == ".esm" || ext == ".esl") {
+		return filename
+	}
+	if modFileExtensions[ext] {
+		return strings.TrimSuffix(filename, filepath.Ext(filename)) + want
+	}
+	return filename + want
+}
+
+// documentExtensions are the extensions under which a text document is the
+// expected content of a download.
+var documentExtensions = map[Kind][]string{
+	KindHTML: {".html", ".htm"},
+	KindXML:  {".xml"},
+	KindJSON: {".json"},
+}
+
+// unexpectedDocument reports whether a download of kind is a text document
+// its filename does not call for, such as an error page saved as a .zip.
+func unexpectedDocument(filename string, kind Kind) bool {
+	if !kind.IsDocument() {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range documentExtensions[kind] {
+		if ext == allowed {
+			return false
+		}
+	}
+	return true
+}
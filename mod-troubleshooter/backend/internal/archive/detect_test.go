@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsArchiveFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{"mod.zip", true},
+		{"mod.7z", true},
+		{"mod.rar", true},
+		{"plugin.esp", false},
+		{"readme.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := IsArchiveFilename(tt.filename); got != tt.want {
+				t.Errorf("IsArchiveFilename(%q) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	// Magic bytes take priority over the extension
+	zipPath := filepath.Join(dir, "download.bin")
+	os.WriteFile(zipPath, []byte("PK\x03\x04rest-of-file"), 0644)
+	if !IsArchive(zipPath) {
+		t.Error("expected zip magic bytes to be detected")
+	}
+
+	textPath := filepath.Join(dir, "notes.txt")
+	os.WriteFile(textPath, []byte("just some text"), 0644)
+	if IsArchive(textPath) {
+		t.Error("expected plain text not to be detected as archive")
+	}
+
+	// Missing files fall back to the extension
+	if !IsArchive(filepath.Join(dir, "missing.7z")) {
+		t.Error("expected extension fallback for missing file")
+	}
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   Kind
+	}{
+		{"zip", "PK\x03\x04rest", KindZip},
+		{"empty zip", "PK\x05\x06\x00\x00", KindZip},
+		{"7z", "7z\xBC\xAF\x27\x1C\x00\x04", Kind7z},
+		{"rar", "Rar!\x1A\x07\x01\x00", KindRar},
+		{"plugin", "TES4\x2A\x00\x00\x00\x01\x00\x00\x00", KindPlugin},
+		{"html", "\xEF\xBB\xBF\n  <!DOCTYPE html><html><body>Not Found</body></html>", KindHTML},
+		{"xhtml", "<?xml version=\"1.0\"?><html xmlns=\"http://www.w3.org/1999/xhtml\">", KindHTML},
+		{"xml error", "<?xml version=\"1.0\" encoding=\"UTF-8\"?><Error><Code>AccessDenied</Code></Error>", KindXML},
+		{"json error", "{\"error\":\"expired\"}", KindJSON},
+		{"binary", "\x00\x01\x02\x03", KindUnknown},
+		{"text", "just some text", KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sniff([]byte(tt.header)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFixExtension(t *testing.T) {
+	esm := []byte("TES4\x2A\x00\x00\x00\x01\x00\x00\x00")
+	esl := []byte("TES4\x2A\x00\x00\x00\x00\x02\x00\x00")
+
+	tests := []struct {
+		name     string
+		filename string
+		kind     Kind
+		header   []byte
+		want     string
+	}{
+		{"matching", "mod.7z", Kind7z, nil, "mod.7z"},
+		{"case-insensitive match", "Mod.ZIP", KindZip, nil, "Mod.ZIP"},
+		{"missing extension", "1234567", KindZip, nil, "1234567.zip"},
+		{"wrong archive extension", "mod.zip", Kind7z, nil, "mod.7z"},
+		{"unrelated extension", "mod.bin", KindRar, nil, "mod.bin.rar"},
+		{"unknown content", "download", KindUnknown, nil, "download"},
+		{"plugin without extension", "download", KindPlugin, esm, "download.esm"},
+		{"light plugin", "download", KindPlugin, esl, "download.esl"},
+		{"plugin keeps its extension", "Patch.esp", KindPlugin, esm, "Patch.esp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixExtension(tt.filename, tt.kind, tt.header); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUnexpectedDocument(t *testing.T) {
+	tests := []struct {
+		filename string
+		kind     Kind
+		want     bool
+	}{
+		{"mod.zip", KindHTML, true},
+		{"download", KindXML, true},
+		{"readme.html", KindHTML, false},
+		{"config.json", KindJSON, false},
+		{"config.json", KindHTML, true},
+		{"mod.zip", KindZip, false},
+		{"mod.zip", KindUnknown, false},
+	}
+
+	for _, tt := range tests {
+		if got := unexpectedDocument(tt.filename, tt.kind); got != tt.want {
+			t.Errorf("%s as %s: expected %v, got %v", tt.filename, tt.kind, tt.want, got)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package archive
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -18,6 +20,9 @@ var (
 	ErrDownloadFailed  = errors.New("download failed")
 	ErrInvalidResponse = errors.New("invalid server response")
 	ErrFileTooLarge    = errors.New("file exceeds maximum allowed size")
+	// ErrUnexpectedContent is returned when the server sent something other
+	// than the file, such as an HTML error page.
+	ErrUnexpectedContent = errors.New("download is not a mod file")
 )
 
 // ProgressCallback is called periodically during download with progress information.
@@ -41,6 +46,13 @@ type DownloaderConfig struct {
 
 	// UserAgent is the User-Agent header for download requests.
 	UserAgent string
+
+	// Scanner checks each download before it is returned (optional).
+	Scanner Scanner
+
+	// QuarantineDir receives downloads the scanner flags. If empty, they are
+	// deleted.
+	QuarantineDir string
 }
 
 // Downloader handles downloading mod archives from URLs.
@@ -49,6 +61,8 @@ type Downloader struct {
 	httpClient  *http.Client
 	maxFileSize int64
 	userAgent   string
+	scanner     Scanner
+	quarantine  string
 
 	mu       sync.Mutex
 	tempDirs []string // Track created temp directories for cleanup
@@ -60,6 +74,9 @@ func NewDownloader(cfg DownloaderConfig) (*Downloader, error) {
 	if tempDir == "" {
 		tempDir = os.TempDir()
 	}
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("create download dir: %w", err)
+	}
 
 	httpClient := cfg.HTTPClient
 	if httpClient == nil {
@@ -78,6 +95,8 @@ func NewDownloader(cfg DownloaderConfig) (*Downloader, error) {
 		httpClient:  httpClient,
 		maxFileSize: cfg.MaxFileSize,
 		userAgent:   userAgent,
+		scanner:     cfg.Scanner,
+		quarantine:  cfg.QuarantineDir,
 		tempDirs:    make([]string, 0),
 	}, nil
 }
@@ -92,6 +111,12 @@ type DownloadResult struct {
 
 	// ContentType is the Content-Type header from the response.
 	ContentType string
+
+	// Kind is the file type detected from the content, if recognized.
+	Kind Kind
+
+	// Latency is how long the server took to start answering.
+	Latency time.Duration
 }
 
 // Download downloads a file from the given URL and returns the path to the downloaded file.
@@ -111,11 +136,13 @@ func (d *Downloader) Download(ctx context.Context, url string, onProgress Progre
 	req.Header.Set("User-Agent", d.userAgent)
 
 	// Execute request
+	started := time.Now()
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrDownloadFailed, err)
 	}
 	defer resp.Body.Close()
+	latency := time.Since(started)
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
@@ -128,6 +155,22 @@ func (d *Downloader) Download(ctx context.Context, url string, onProgress Progre
 		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrFileTooLarge, contentLength, d.maxFileSize)
 	}
 
+	// Extract filename from URL or use default
+	filename := extractFilename(url)
+	if filename == "" {
+		filename = "download"
+	}
+
+	// Trust the content over the URL: CDN links may lack an extension, and
+	// error pages are sometimes served with a success status
+	body := bufio.NewReaderSize(resp.Body, sniffLen)
+	header, _ := body.Peek(sniffLen)
+	kind := Sniff(header)
+	if unexpectedDocument(filename, kind) {
+		return nil, fmt.Errorf("%w: received %s content for %s", ErrUnexpectedContent, kind, filename)
+	}
+	filename = fixExtension(filename, kind, header)
+
 	// Create temp directory for this download
 	downloadDir, err := os.MkdirTemp(d.tempDir, "mod-download-*")
 	if err != nil {
@@ -139,12 +182,6 @@ func (d *Downloader) Download(ctx context.Context, url string, onProgress Progre
 	d.tempDirs = append(d.tempDirs, downloadDir)
 	d.mu.Unlock()
 
-	// Extract filename from URL or use default
-	filename := extractFilename(url)
-	if filename == "" {
-		filename = "download"
-	}
-
 	filePath := filepath.Join(downloadDir, filename)
 
 	// Create destination file
@@ -157,12 +194,12 @@ func (d *Downloader) Download(ctx context.Context, url string, onProgress Progre
 
 	// Download with progress tracking
 	var downloaded int64
-	var reader io.Reader = resp.Body
+	var reader io.Reader = body
 
 	// If we have a progress callback, wrap the reader
 	if onProgress != nil {
 		reader = &progressReader{
-			reader:     resp.Body,
+			reader:     body,
 			total:      contentLength,
 			onProgress: onProgress,
 		}
@@ -182,16 +219,54 @@ func (d *Downloader) Download(ctx context.Context, url string, onProgress Progre
 	if err != nil {
 		file.Close()
 		os.RemoveAll(downloadDir)
-		return nil, fmt.Errorf("%w: %v", ErrDownloadFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	if d.scanner != nil {
+		file.Close()
+		if err := d.scan(ctx, filePath); err != nil {
+			os.RemoveAll(downloadDir)
+			return nil, err
+		}
 	}
 
 	return &DownloadResult{
 		FilePath:    filePath,
 		Size:        written,
 		ContentType: resp.Header.Get("Content-Type"),
+		Kind:        kind,
+		Latency:     latency,
 	}, nil
 }
 
+// scan runs the scanner over a finished download, quarantining it if it is
+// infected. Downloads that cannot be scanned are rejected too.
+func (d *Downloader) scan(ctx context.Context, path string) error {
+	result, err := d.scanner.Scan(ctx, path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	if !result.Infected {
+		return nil
+	}
+
+	threat := result.Threat
+	if threat == "" {
+		threat = "unnamed threat"
+	}
+	dest, err := quarantine(path, d.quarantine)
+	switch {
+	case err != nil:
+		log.Printf("Warning: could not quarantine %s (%s), deleted it: %v", filepath.Base(path), threat, err)
+	case dest != "":
+		log.Printf("Quarantined %s (%s) as %s", filepath.Base(path), threat, dest)
+	}
+	return fmt.Errorf("%w: %s in %s", ErrInfected, threat, filepath.Base(path))
+}
+
 // Cleanup removes all temporary directories created by this downloader.
 func (d *Downloader) Cleanup() error {
 	d.mu.Lock()
@@ -2,6 +2,7 @@ package archive
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -247,6 +248,69 @@ func TestDownloader_Download(t *testing.T) {
 	})
 }
 
+func TestDownloader_Download_Sniffing(t *testing.T) {
+	payloads := map[string]string{
+		"/cdn/abc123":         "7z\xBC\xAF\x27\x1C\x00\x04rest-of-archive",
+		"/files/mod.zip":      "Rar!\x1A\x07\x00rest-of-archive",
+		"/files/expired.zip":  "<!DOCTYPE html><html><body>Link expired</body></html>",
+		"/files/denied":       "<?xml version=\"1.0\"?><Error><Code>AccessDenied</Code></Error>",
+		"/files/release.html": "<html><body>Release notes</body></html>",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payloads[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	d, err := NewDownloader(DownloaderConfig{TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+	defer d.Cleanup()
+
+	tests := []struct {
+		path     string
+		wantName string
+		wantKind Kind
+		wantErr  error
+	}{
+		{"/cdn/abc123", "abc123.7z", Kind7z, nil},
+		{"/files/mod.zip", "mod.rar", KindRar, nil},
+		{"/files/expired.zip", "", "", ErrUnexpectedContent},
+		{"/files/denied", "", "", ErrUnexpectedContent},
+		{"/files/release.html", "release.html", KindHTML, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := d.Download(context.Background(), server.URL+tt.path, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Download() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if filepath.Base(result.FilePath) != tt.wantName {
+				t.Errorf("FilePath = %q, want name %q", result.FilePath, tt.wantName)
+			}
+			if result.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", result.Kind, tt.wantKind)
+			}
+
+			// Sniffing must not consume the content
+			data, err := os.ReadFile(result.FilePath)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			if string(data) != payloads[tt.path] {
+				t.Errorf("file content = %q, want %q", data, payloads[tt.path])
+			}
+		})
+	}
+}
+
 func TestDownloader_Cleanup(t *testing.T) {
 	t.Run("cleanup removes temp directories", func(t *testing.T) {
 		content := "test content"
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/mholt/archiver/v4"
 )
@@ -34,6 +35,10 @@ type ExtractorConfig struct {
 	// MaxTotalSize is the maximum allowed total size of all extracted files in bytes.
 	// Zero or negative means no limit.
 	MaxTotalSize int64
+
+	// Blobs deduplicates extracted file bodies by content (optional).
+	// Extracted files are then links to shared blobs and must not be modified.
+	Blobs *BlobStore
 }
 
 // Extractor handles extracting files from archive formats.
@@ -41,6 +46,10 @@ type Extractor struct {
 	tempDir      string
 	maxFileSize  int64
 	maxTotalSize int64
+	blobs        *BlobStore
+
+	mu      sync.Mutex
+	outputs map[string][]string // blob hashes referenced by each output directory
 }
 
 // NewExtractor creates a new archive extractor with the given configuration.
@@ -54,6 +63,8 @@ func NewExtractor(cfg ExtractorConfig) (*Extractor, error) {
 		tempDir:      tempDir,
 		maxFileSize:  cfg.MaxFileSize,
 		maxTotalSize: cfg.MaxTotalSize,
+		blobs:        cfg.Blobs,
+		outputs:      make(map[string][]string),
 	}, nil
 }
 
@@ -70,14 +81,15 @@ type ExtractResult struct {
 }
 
 // Extract extracts all files from the archive to a temporary directory.
-func (e *Extractor) Extract(ctx context.Context, archivePath string) (*ExtractResult, error) {
-	return e.ExtractPaths(ctx, archivePath, nil)
+// Password opens encrypted archives and may be empty.
+func (e *Extractor) Extract(ctx context.Context, archivePath, password string) (*ExtractResult, error) {
+	return e.ExtractPaths(ctx, archivePath, password, nil)
 }
 
 // ExtractPaths extracts only files matching the given path prefixes from the archive.
 // If pathPrefixes is nil or empty, all files are extracted.
 // Path matching is case-insensitive to handle Windows-style archives.
-func (e *Extractor) ExtractPaths(ctx context.Context, archivePath string, pathPrefixes []string) (*ExtractResult, error) {
+func (e *Extractor) ExtractPaths(ctx context.Context, archivePath, password string, pathPrefixes []string) (*ExtractResult, error) {
 	if archivePath == "" {
 		return nil, ErrNoArchivePath
 	}
@@ -95,7 +107,7 @@ func (e *Extractor) ExtractPaths(ctx context.Context, archivePath string, pathPr
 	defer file.Close()
 
 	// Identify the archive format
-	format, input, err := archiver.Identify(ctx, archivePath, file)
+	format, input, err := Identify(ctx, archivePath, file, password)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
 	}
@@ -119,6 +131,7 @@ func (e *Extractor) ExtractPaths(ctx context.Context, archivePath string, pathPr
 	}
 
 	var extractedFiles []string
+	var blobHashes []string
 	var totalSize int64
 
 	// Extract files
@@ -181,17 +194,31 @@ func (e *Extractor) ExtractPaths(ctx context.Context, archivePath string, pathPr
 		}
 		defer rc.Close()
 
-		// Create the destination file
-		destFile, err := os.Create(destPath)
-		if err != nil {
-			return fmt.Errorf("create file %s: %w", destPath, err)
-		}
-		defer destFile.Close()
+		var written int64
+		if e.blobs != nil {
+			// Store the body once and link it into the output directory
+			blob, err := e.blobs.Put(rc)
+			if err != nil {
+				return fmt.Errorf("extract file %s: %w", filePath, err)
+			}
+			blobHashes = append(blobHashes, blob.Hash)
+			if err := linkOrCopy(blob.Path, destPath); err != nil {
+				return fmt.Errorf("create file %s: %w", destPath, err)
+			}
+			written = blob.Size
+		} else {
+			// Create the destination file
+			destFile, err := os.Create(destPath)
+			if err != nil {
+				return fmt.Errorf("create file %s: %w", destPath, err)
+			}
+			defer destFile.Close()
 
-		// Copy the file contents
-		written, err := io.Copy(destFile, rc)
-		if err != nil {
-			return fmt.Errorf("extract file %s: %w", filePath, err)
+			// Copy the file contents
+			written, err = io.Copy(destFile, rc)
+			if err != nil {
+				return fmt.Errorf("extract file %s: %w", filePath, err)
+			}
 		}
 
 		extractedFiles = append(extractedFiles, filePath)
@@ -203,9 +230,16 @@ func (e *Extractor) ExtractPaths(ctx context.Context, archivePath string, pathPr
 	if err != nil {
 		// Clean up on error
 		os.RemoveAll(outputDir)
+		e.releaseBlobs(blobHashes)
 		return nil, fmt.Errorf("%w: %v", ErrExtractionFailed, err)
 	}
 
+	if len(blobHashes) > 0 {
+		e.mu.Lock()
+		e.outputs[outputDir] = blobHashes
+		e.mu.Unlock()
+	}
+
 	return &ExtractResult{
 		OutputDir: outputDir,
 		Files:     extractedFiles,
@@ -215,12 +249,12 @@ func (e *Extractor) ExtractPaths(ctx context.Context, archivePath string, pathPr
 
 // ExtractFomod extracts only the fomod directory from the archive.
 // This is a convenience method for FOMOD analysis.
-func (e *Extractor) ExtractFomod(ctx context.Context, archivePath string) (*ExtractResult, error) {
-	return e.ExtractPaths(ctx, archivePath, []string{"fomod/", "fomod\\"})
+func (e *Extractor) ExtractFomod(ctx context.Context, archivePath, password string) (*ExtractResult, error) {
+	return e.ExtractPaths(ctx, archivePath, password, []string{"fomod/", "fomod\\"})
 }
 
 // ListFiles returns a list of all files in the archive without extracting.
-func (e *Extractor) ListFiles(ctx context.Context, archivePath string) ([]string, error) {
+func (e *Extractor) ListFiles(ctx context.Context, archivePath, password string) ([]string, error) {
 	if archivePath == "" {
 		return nil, ErrNoArchivePath
 	}
@@ -238,7 +272,7 @@ func (e *Extractor) ListFiles(ctx context.Context, archivePath string) ([]string
 	defer file.Close()
 
 	// Identify the archive format
-	format, input, err := archiver.Identify(ctx, archivePath, file)
+	format, input, err := Identify(ctx, archivePath, file, password)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
 	}
@@ -263,15 +297,95 @@ func (e *Extractor) ListFiles(ctx context.Context, archivePath string) ([]string
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("list archive: %w", err)
+		return nil, fmt.Errorf("%w: list archive: %w", ErrExtractionFailed, err)
 	}
 
 	return files, nil
 }
 
+// errFileRead stops walking an archive once the wanted file was read.
+var errFileRead = errors.New("file read")
+
+// ReadFile reads a single file from the archive into memory without
+// extracting anything else. The name is matched case-insensitively, with
+// either kind of slash. Files larger than maxSize fail with ErrFileTooLarge;
+// zero or negative means no limit.
+func (e *Extractor) ReadFile(ctx context.Context, archivePath, password, name string, maxSize int64) ([]byte, error) {
+	if archivePath == "" {
+		return nil, ErrNoArchivePath
+	}
+
+	file, err := os.Open(archivePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveNotFound, archivePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	format, input, err := Identify(ctx, archivePath, file, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	extractor, ok := format.(archiver.Extractor)
+	if !ok {
+		return nil, fmt.Errorf("%w: format does not support extraction", ErrUnsupportedFormat)
+	}
+
+	wanted := entryName(name)
+	var data []byte
+	err = extractor.Extract(ctx, input, func(ctx context.Context, f archiver.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if f.IsDir() || entryName(f.NameInArchive) != wanted {
+			return nil
+		}
+		if maxSize > 0 && f.Size() > maxSize {
+			return fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrFileTooLarge, f.NameInArchive, f.Size(), maxSize)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("open file %s in archive: %w", f.NameInArchive, err)
+		}
+		defer rc.Close()
+
+		// Sizes in archive headers can't be trusted, so the read is capped too
+		r := io.Reader(rc)
+		if maxSize > 0 {
+			r = io.LimitReader(rc, maxSize+1)
+		}
+		if data, err = io.ReadAll(r); err != nil {
+			return fmt.Errorf("read file %s: %w", f.NameInArchive, err)
+		}
+		if maxSize > 0 && int64(len(data)) > maxSize {
+			return fmt.Errorf("%w: %s exceeds %d bytes", ErrFileTooLarge, f.NameInArchive, maxSize)
+		}
+		return errFileRead
+	})
+
+	switch {
+	case errors.Is(err, errFileRead):
+		return data, nil
+	case errors.Is(err, ErrFileTooLarge), ctx.Err() != nil:
+		return nil, err
+	case err != nil:
+		return nil, fmt.Errorf("%w: %w", ErrExtractionFailed, err)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, name)
+	}
+}
+
+// entryName normalizes a path within an archive for comparison.
+func entryName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "/"))
+}
+
 // HasFomod checks if the archive contains a fomod directory.
-func (e *Extractor) HasFomod(ctx context.Context, archivePath string) (bool, error) {
-	files, err := e.ListFiles(ctx, archivePath)
+func (e *Extractor) HasFomod(ctx context.Context, archivePath, password string) (bool, error) {
+	files, err := e.ListFiles(ctx, archivePath, password)
 	if err != nil {
 		return false, err
 	}
@@ -286,10 +400,28 @@ func (e *Extractor) HasFomod(ctx context.Context, archivePath string) (bool, err
 	return false, nil
 }
 
-// Cleanup removes an extraction output directory.
+// Cleanup removes an extraction output directory and releases its blobs.
 func (e *Extractor) Cleanup(outputDir string) error {
 	if outputDir == "" {
 		return nil
 	}
-	return os.RemoveAll(outputDir)
+
+	e.mu.Lock()
+	hashes := e.outputs[outputDir]
+	delete(e.outputs, outputDir)
+	e.mu.Unlock()
+
+	err := os.RemoveAll(outputDir)
+	e.releaseBlobs(hashes)
+	return err
+}
+
+// releaseBlobs drops the references an extraction held.
+func (e *Extractor) releaseBlobs(hashes []string) {
+	if e.blobs == nil {
+		return
+	}
+	for _, hash := range hashes {
+		e.blobs.Release(hash)
+	}
 }
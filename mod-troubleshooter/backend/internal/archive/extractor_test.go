@@ -3,6 +3,7 @@ package archive
 import (
 	"archive/zip"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -54,9 +55,9 @@ func TestNewExtractor(t *testing.T) {
 func TestExtractor_Extract(t *testing.T) {
 	// Create a test zip file
 	zipPath := createTestZip(t, map[string]string{
-		"file1.txt":           "content1",
-		"subdir/file2.txt":    "content2",
-		"fomod/info.xml":      "<fomod><Name>Test</Name></fomod>",
+		"file1.txt":              "content1",
+		"subdir/file2.txt":       "content2",
+		"fomod/info.xml":         "<fomod><Name>Test</Name></fomod>",
 		"fomod/ModuleConfig.xml": "<config/>",
 	})
 	defer os.Remove(zipPath)
@@ -67,7 +68,7 @@ func TestExtractor_Extract(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	result, err := ext.Extract(ctx, zipPath)
+	result, err := ext.Extract(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("Extract() error = %v", err)
 	}
@@ -104,7 +105,7 @@ func TestExtractor_ExtractPaths(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	result, err := ext.ExtractPaths(ctx, zipPath, []string{"fomod/"})
+	result, err := ext.ExtractPaths(ctx, zipPath, "", []string{"fomod/"})
 	if err != nil {
 		t.Fatalf("ExtractPaths() error = %v", err)
 	}
@@ -138,7 +139,7 @@ func TestExtractor_ExtractFomod(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	result, err := ext.ExtractFomod(ctx, zipPath)
+	result, err := ext.ExtractFomod(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("ExtractFomod() error = %v", err)
 	}
@@ -159,14 +160,14 @@ func TestExtractor_Extract_Errors(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("empty path", func(t *testing.T) {
-		_, err := ext.Extract(ctx, "")
+		_, err := ext.Extract(ctx, "", "")
 		if err != ErrNoArchivePath {
 			t.Errorf("Extract() error = %v, want ErrNoArchivePath", err)
 		}
 	})
 
 	t.Run("non-existent file", func(t *testing.T) {
-		_, err := ext.Extract(ctx, "/nonexistent/archive.zip")
+		_, err := ext.Extract(ctx, "/nonexistent/archive.zip", "")
 		if err == nil || !strings.Contains(err.Error(), "not found") {
 			t.Errorf("Extract() error = %v, want error containing 'not found'", err)
 		}
@@ -182,7 +183,7 @@ func TestExtractor_Extract_Errors(t *testing.T) {
 		tmpFile.Close()
 		defer os.Remove(tmpFile.Name())
 
-		_, err = ext.Extract(ctx, tmpFile.Name())
+		_, err = ext.Extract(ctx, tmpFile.Name(), "")
 		if err == nil || !strings.Contains(err.Error(), "unsupported") {
 			t.Errorf("Extract() error = %v, want error containing 'unsupported'", err)
 		}
@@ -205,7 +206,7 @@ func TestExtractor_Extract_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err = ext.Extract(ctx, zipPath)
+	_, err = ext.Extract(ctx, zipPath, "")
 	if err == nil || !strings.Contains(err.Error(), "context canceled") {
 		t.Errorf("Extract() with cancelled context should fail, got error = %v", err)
 	}
@@ -227,7 +228,7 @@ func TestExtractor_Extract_MaxFileSize(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	_, err = ext.Extract(ctx, zipPath)
+	_, err = ext.Extract(ctx, zipPath, "")
 	if err == nil || !strings.Contains(err.Error(), "exceeds max file size") {
 		t.Errorf("Extract() with file exceeding limit should fail, got error = %v", err)
 	}
@@ -248,7 +249,7 @@ func TestExtractor_ListFiles(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	files, err := ext.ListFiles(ctx, zipPath)
+	files, err := ext.ListFiles(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("ListFiles() error = %v", err)
 	}
@@ -273,9 +274,9 @@ func TestExtractor_ListFiles(t *testing.T) {
 
 func TestExtractor_HasFomod(t *testing.T) {
 	tests := []struct {
-		name     string
-		files    map[string]string
-		wantHas  bool
+		name    string
+		files   map[string]string
+		wantHas bool
 	}{
 		{
 			name: "has fomod",
@@ -314,7 +315,7 @@ func TestExtractor_HasFomod(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			hasFomod, err := ext.HasFomod(ctx, zipPath)
+			hasFomod, err := ext.HasFomod(ctx, zipPath, "")
 			if err != nil {
 				t.Fatalf("HasFomod() error = %v", err)
 			}
@@ -406,3 +407,33 @@ func createTestZip(t *testing.T, files map[string]string) string {
 
 	return tmpFile.Name()
 }
+
+func TestExtractor_ReadFile(t *testing.T) {
+	zipPath := createTestZip(t, map[string]string{
+		"Readme.txt":                 "read me",
+		"fomod/ModuleConfig.xml":     "<config/>",
+		"textures/large_texture.dds": strings.Repeat("x", 64),
+	})
+	defer os.Remove(zipPath)
+
+	ext, err := NewExtractor(ExtractorConfig{})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+	ctx := context.Background()
+
+	data, err := ext.ReadFile(ctx, zipPath, "", "FOMOD\\moduleconfig.xml", 32)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "<config/>" {
+		t.Errorf("ReadFile() = %q, want %q", data, "<config/>")
+	}
+
+	if _, err := ext.ReadFile(ctx, zipPath, "", "textures/large_texture.dds", 32); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("ReadFile() error = %v, want ErrFileTooLarge", err)
+	}
+	if _, err := ext.ReadFile(ctx, zipPath, "", "missing.txt", 32); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("ReadFile() error = %v, want ErrPathNotFound", err)
+	}
+}
@@ -0,0 +1,199 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Mirror is one CDN offering a download.
+type Mirror struct {
+	// Name identifies the CDN across downloads, such as Nexus's short name
+	// for it.
+	Name string
+	// URL is where this CDN serves the file.
+	URL string
+}
+
+// MirrorStats is how downloads from one mirror have gone.
+type MirrorStats struct {
+	Name     string `json:"name"`
+	Attempts int    `json:"attempts"`
+	Failures int    `json:"failures"`
+	// FailureRate is Failures over Attempts.
+	FailureRate float64 `json:"failureRate"`
+	// AvgLatencyMs is the average time until the mirror started answering
+	// successful downloads.
+	AvgLatencyMs int64 `json:"avgLatencyMs"`
+	// Bytes is the total size of the files downloaded from the mirror.
+	Bytes int64 `json:"bytes"`
+}
+
+// MirrorHealth tracks failures and latency per mirror so that later
+// downloads prefer the mirrors that have done best. It is meant to live as
+// long as one job; a mirror having a bad minute should not follow it around.
+// It is safe for concurrent use.
+type MirrorHealth struct {
+	mu    sync.Mutex
+	stats map[string]*mirrorRecord
+}
+
+type mirrorRecord struct {
+	attempts int
+	failures int
+	latency  time.Duration // summed over successes
+	bytes    int64
+}
+
+// NewMirrorHealth creates an empty mirror health tracker.
+func NewMirrorHealth() *MirrorHealth {
+	return &MirrorHealth{stats: make(map[string]*mirrorRecord)}
+}
+
+// Record notes the outcome of a download from a mirror. latency and size
+// are ignored for failures.
+func (h *MirrorHealth) Record(name string, latency time.Duration, size int64, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rec := h.stats[name]
+	if rec == nil {
+		rec = &mirrorRecord{}
+		h.stats[name] = rec
+	}
+	rec.attempts++
+	if err != nil {
+		rec.failures++
+		return
+	}
+	rec.latency += latency
+	rec.bytes += size
+}
+
+// Order returns mirrors healthiest first: mirrors that have mostly worked,
+// by failure rate and then latency, then mirrors not tried yet, then mirrors
+// that have mostly failed. Ties keep the order given, which is usually the
+// order the site recommends.
+func (h *MirrorHealth) Order(mirrors []Mirror) []Mirror {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	type ranked struct {
+		mirror  Mirror
+		tier    int
+		rate    float64
+		latency time.Duration
+	}
+	rank := make([]ranked, len(mirrors))
+	for i, m := range mirrors {
+		rank[i] = ranked{mirror: m, tier: 1}
+		rec := h.stats[m.Name]
+		if rec == nil || rec.attempts == 0 {
+			continue
+		}
+		rank[i].rate = float64(rec.failures) / float64(rec.attempts)
+		if successes := rec.attempts - rec.failures; successes > 0 {
+			rank[i].latency = rec.latency / time.Duration(successes)
+		}
+		if rank[i].rate < 0.5 {
+			rank[i].tier = 0
+		} else {
+			rank[i].tier = 2
+		}
+	}
+
+	sort.SliceStable(rank, func(i, j int) bool {
+		a, b := rank[i], rank[j]
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		if a.rate != b.rate {
+			return a.rate < b.rate
+		}
+		return a.latency < b.latency
+	})
+
+	ordered := make([]Mirror, len(rank))
+	for i, r := range rank {
+		ordered[i] = r.mirror
+	}
+	return ordered
+}
+
+// Stats returns the stats of every mirror tried, sorted by name.
+func (h *MirrorHealth) Stats() []MirrorStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := make([]MirrorStats, 0, len(h.stats))
+	for name, rec := range h.stats {
+		s := MirrorStats{
+			Name:     name,
+			Attempts: rec.attempts,
+			Failures: rec.failures,
+			Bytes:    rec.bytes,
+		}
+		if rec.attempts > 0 {
+			s.FailureRate = float64(rec.failures) / float64(rec.attempts)
+		}
+		if successes := rec.attempts - rec.failures; successes > 0 {
+			s.AvgLatencyMs = (rec.latency / time.Duration(successes)).Milliseconds()
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// DownloadMirrors downloads a file offered by several mirrors, trying them
+// healthiest first and moving on to the next when one fails. Every attempt
+// is recorded in health, which may be nil to try the mirrors in the order
+// given. Failures that another mirror would not fix, such as a file over
+// the size limit, are returned straight away.
+func (d *Downloader) DownloadMirrors(ctx context.Context, mirrors []Mirror, health *MirrorHealth, onProgress ProgressCallback) (*DownloadResult, error) {
+	if len(mirrors) == 0 {
+		return nil, ErrNoURL
+	}
+	if health != nil {
+		mirrors = health.Order(mirrors)
+	}
+
+	var errs []error
+	for _, m := range mirrors {
+		result, err := d.Download(ctx, m.URL, onProgress)
+		if err != nil && ctx.Err() != nil {
+			// Cancellation says nothing about the mirror
+			return nil, ctx.Err()
+		}
+		if health != nil {
+			var latency time.Duration
+			var size int64
+			if result != nil {
+				latency, size = result.Latency, result.Size
+			}
+			health.Record(m.Name, latency, size, err)
+		}
+		if err == nil {
+			return result, nil
+		}
+		if !mirrorFailure(err) {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("mirror %s: %w", m.Name, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// mirrorFailure reports whether err is the mirror's fault, so another one
+// might serve the file.
+func mirrorFailure(err error) bool {
+	if errors.Is(err, ErrFileTooLarge) {
+		return false
+	}
+	return errors.Is(err, ErrDownloadFailed) ||
+		errors.Is(err, ErrInvalidResponse) ||
+		errors.Is(err, ErrUnexpectedContent)
+}
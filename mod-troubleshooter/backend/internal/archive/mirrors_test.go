@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func mirrorNames(mirrors []Mirror) []string {
+	names := make([]string, len(mirrors))
+	for i, m := range mirrors {
+		names[i] = m.Name
+	}
+	return names
+}
+
+func TestMirrorHealth_Order(t *testing.T) {
+	h := NewMirrorHealth()
+	h.Record("slow", 200*time.Millisecond, 10, nil)
+	h.Record("fast", 10*time.Millisecond, 10, nil)
+	h.Record("flaky", 0, 0, errors.New("reset"))
+	h.Record("flaky", 10*time.Millisecond, 10, nil)
+	h.Record("flaky", 0, 0, errors.New("reset"))
+
+	mirrors := []Mirror{{Name: "flaky"}, {Name: "new"}, {Name: "slow"}, {Name: "fast"}, {Name: "other"}}
+	got := mirrorNames(h.Order(mirrors))
+	want := []string{"fast", "slow", "new", "other", "flaky"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Order() = %v, want %v", got, want)
+		}
+	}
+
+	stats := h.Stats()
+	if len(stats) != 3 || stats[1].Name != "flaky" || stats[1].Attempts != 3 || stats[1].Failures != 2 || stats[1].AvgLatencyMs != 10 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestDownloader_DownloadMirrors(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("mod content"))
+	}))
+	defer working.Close()
+
+	d, err := NewDownloader(DownloaderConfig{TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+	defer d.Cleanup()
+
+	mirrors := []Mirror{
+		{Name: "broken", URL: broken.URL + "/mod.zip"},
+		{Name: "working", URL: working.URL + "/mod.zip"},
+	}
+	h := NewMirrorHealth()
+	if _, err := d.DownloadMirrors(context.Background(), mirrors, h, nil); err != nil {
+		t.Fatalf("DownloadMirrors() error = %v", err)
+	}
+	// The broken mirror is skipped once the working one has proven itself
+	if _, err := d.DownloadMirrors(context.Background(), mirrors, h, nil); err != nil {
+		t.Fatalf("DownloadMirrors() error = %v", err)
+	}
+
+	stats := h.Stats()
+	if stats[0].Name != "broken" || stats[0].Attempts != 1 || stats[0].Failures != 1 {
+		t.Errorf("unexpected broken mirror stats %+v", stats[0])
+	}
+	if stats[1].Name != "working" || stats[1].Attempts != 2 || stats[1].Failures != 0 || stats[1].Bytes != 22 {
+		t.Errorf("unexpected working mirror stats %+v", stats[1])
+	}
+
+	_, err = d.DownloadMirrors(context.Background(), mirrors[:1], h, nil)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse once every mirror failed, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package archive
+
+import (
+	"context"
+	"io"
+
+	"github.com/mholt/archiver/v4"
+)
+
+// Identify identifies the format of an archive like archiver.Identify, and
+// applies password to formats that support encryption (7z and RAR). Mod
+// authors sometimes protect archives with a password given in the mod
+// description; an empty password opens unencrypted archives only.
+// Encrypted zip archives can be listed but not extracted; the external 7z
+// fallback handles them.
+func Identify(ctx context.Context, filename string, stream io.Reader, password string) (archiver.Format, io.Reader, error) {
+	format, input, err := archiver.Identify(ctx, filename, stream)
+	if err != nil {
+		return nil, input, err
+	}
+
+	if password != "" {
+		switch f := format.(type) {
+		case archiver.SevenZip:
+			f.Password = password
+			format = f
+		case archiver.Rar:
+			f.Password = password
+			format = f
+		}
+	}
+
+	return format, input, nil
+}
@@ -0,0 +1,32 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mholt/archiver/v4"
+)
+
+func TestIdentify_AppliesPassword(t *testing.T) {
+	header := []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C, 0, 4}
+	format, _, err := Identify(context.Background(), "mod.7z", bytes.NewReader(header), "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sz, ok := format.(archiver.SevenZip)
+	if !ok {
+		t.Fatalf("expected 7z format, got %T", format)
+	}
+	if sz.Password != "secret" {
+		t.Errorf("expected password to be applied, got %q", sz.Password)
+	}
+
+	format, _, err = Identify(context.Background(), "mod.7z", bytes.NewReader(header), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sz := format.(archiver.SevenZip); sz.Password != "" {
+		t.Errorf("expected no password when none is given, got %q", sz.Password)
+	}
+}
@@ -0,0 +1,218 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Errors returned when a download is scanned.
+var (
+	// ErrInfected is returned when the scanner reports a threat in a download.
+	ErrInfected = errors.New("download flagged by virus scan")
+	// ErrScanFailed is returned when a download could not be scanned. The
+	// download is discarded rather than used unscanned.
+	ErrScanFailed = errors.New("virus scan failed")
+)
+
+// ScanResult is the verdict of a scan.
+type ScanResult struct {
+	// Infected is true when the scanner found a threat.
+	Infected bool
+	// Threat names what was found, if the scanner says.
+	Threat string
+}
+
+// Scanner checks a downloaded file before it is extracted.
+type Scanner interface {
+	Scan(ctx context.Context, path string) (ScanResult, error)
+}
+
+// CommandScanner runs an external scanner such as clamscan with the file
+// path as its last argument. Following the clamscan convention, exit status
+// 0 means clean, 1 means a threat was found and anything else is an error.
+type CommandScanner struct {
+	path string
+	args []string
+}
+
+// NewCommandScanner returns a scanner that runs command, a program name or
+// path followed by its arguments separated by spaces. It fails if the
+// program cannot be found.
+func NewCommandScanner(command string) (*CommandScanner, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("scan command is empty")
+	}
+	resolved, err := exec.LookPath(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("find scanner: %w", err)
+	}
+	return &CommandScanner{path: resolved, args: fields[1:]}, nil
+}
+
+// Scan implements Scanner.
+func (s *CommandScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.path, append(s.args, path)...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if err == nil {
+		return ScanResult{}, nil
+	}
+	if ctx.Err() != nil {
+		return ScanResult{}, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return ScanResult{Infected: true, Threat: commandThreat(output.String(), path)}, nil
+	}
+	return ScanResult{}, fmt.Errorf("%v: %s", err, strings.TrimSpace(output.String()))
+}
+
+// commandThreat picks the threat name out of scanner output, which for
+// clamscan is a line like "/path/file.zip: Win.Trojan.Agent FOUND".
+func commandThreat(output, path string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, path+": "); ok {
+			return strings.TrimSuffix(rest, " FOUND")
+		}
+	}
+	return ""
+}
+
+// clamdChunkSize is the size of the chunks streamed to clamd.
+const clamdChunkSize = 64 * 1024
+
+// ClamdScanner streams files to a clamd daemon with the INSTREAM command.
+// clamd must allow streams at least as large as the downloads
+// (StreamMaxLength in clamd.conf).
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner returns a scanner for the clamd listening at address: a
+// Unix socket path, or host:port for TCP.
+func NewClamdScanner(address string) *ClamdScanner {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") || strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	}
+	return &ClamdScanner{network: network, address: address, timeout: 10 * time.Minute}
+}
+
+// Scan implements Scanner.
+func (s *ClamdScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer file.Close()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return ScanResult{}, fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	reply, err := clamdStream(conn, file)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ScanResult{}, ctx.Err()
+		}
+		return ScanResult{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+// clamdStream sends r to clamd as an INSTREAM command and returns its reply.
+func clamdStream(conn net.Conn, r io.Reader) (string, error) {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return "", fmt.Errorf("send to clamd: %w", err)
+	}
+
+	// Each chunk is prefixed with its length; a zero length ends the stream
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := conn.Write(buf[:4+n]); werr != nil {
+				return "", fmt.Errorf("send to clamd: %w", werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", fmt.Errorf("send to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return "", fmt.Errorf("read clamd reply: %w", err)
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseClamdReply interprets a reply such as "stream: OK" or
+// "stream: Eicar-Test-Signature FOUND".
+func parseClamdReply(reply string) (ScanResult, error) {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return ScanResult{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return ScanResult{Infected: true, Threat: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return ScanResult{}, fmt.Errorf("clamd: %s", result)
+	}
+}
+
+// quarantine moves an infected download into dir so operators can inspect
+// it, or deletes it when dir is empty. The quarantined name records when
+// the file was caught.
+func quarantine(path, dir string) (string, error) {
+	if dir == "" {
+		return "", os.Remove(path)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, time.Now().UTC().Format("20060102T150405Z")+"-"+filepath.Base(path))
+	if err := os.Rename(path, dest); err != nil {
+		// The quarantine may be on another filesystem; never keep the file around
+		os.Remove(path)
+		return "", err
+	}
+	// Nothing should execute or read quarantined files by accident
+	os.Chmod(dest, 0400)
+	return dest, nil
+}
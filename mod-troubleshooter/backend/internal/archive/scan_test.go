@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeScanner flags files whose content contains "EICAR".
+type fakeScanner struct {
+	err error
+}
+
+func (s fakeScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	if s.err != nil {
+		return ScanResult{}, s.err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	if strings.Contains(string(data), "EICAR") {
+		return ScanResult{Infected: true, Threat: "Eicar-Test-Signature"}, nil
+	}
+	return ScanResult{}, nil
+}
+
+func TestDownloader_Scan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "bad.zip") {
+			w.Write([]byte("PK\x03\x04 EICAR"))
+			return
+		}
+		w.Write([]byte("PK\x03\x04 fine"))
+	}))
+	defer server.Close()
+
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+	d, err := NewDownloader(DownloaderConfig{TempDir: t.TempDir(), Scanner: fakeScanner{}, QuarantineDir: quarantineDir})
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+	defer d.Cleanup()
+
+	if _, err := d.Download(context.Background(), server.URL+"/good.zip", nil); err != nil {
+		t.Fatalf("expected clean download, got %v", err)
+	}
+
+	_, err = d.Download(context.Background(), server.URL+"/bad.zip", nil)
+	if !errors.Is(err, ErrInfected) || !strings.Contains(err.Error(), "Eicar-Test-Signature") {
+		t.Fatalf("expected ErrInfected naming the threat, got %v", err)
+	}
+	entries, _ := os.ReadDir(quarantineDir)
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), "-bad.zip") {
+		t.Errorf("expected the download in quarantine, got %v", entries)
+	}
+
+	d.scanner = fakeScanner{err: errors.New("clamd is down")}
+	if _, err := d.Download(context.Background(), server.URL+"/good.zip", nil); !errors.Is(err, ErrScanFailed) {
+		t.Errorf("expected unscanned download to be rejected, got %v", err)
+	}
+}
+
+func TestCommandThreat(t *testing.T) {
+	output := "/tmp/mod.zip: Win.Trojan.Agent-1 FOUND\n\n----------- SCAN SUMMARY -----------\n"
+	if got := commandThreat(output, "/tmp/mod.zip"); got != "Win.Trojan.Agent-1" {
+		t.Errorf("expected threat name, got %q", got)
+	}
+	if got := commandThreat("infected", "/tmp/mod.zip"); got != "" {
+		t.Errorf("expected no threat name, got %q", got)
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    ScanResult
+		wantErr bool
+	}{
+		{"stream: OK", ScanResult{}, false},
+		{"stream: Eicar-Test-Signature FOUND", ScanResult{Infected: true, Threat: "Eicar-Test-Signature"}, false},
+		{"INSTREAM size limit exceeded. ERROR", ScanResult{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseClamdReply(tt.reply)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseClamdReply(%q) = %+v, %v", tt.reply, got, err)
+		}
+	}
+}
+
+func TestClamdScanner(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+
+	// A minimal clamd that flags streams containing "EICAR"
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			if cmd, _ := r.ReadString(0); cmd != "zINSTREAM\x00" {
+				conn.Close()
+				continue
+			}
+			var data []byte
+			for {
+				var size uint32
+				if binary.Read(r, binary.BigEndian, &size) != nil || size == 0 {
+					break
+				}
+				chunk := make([]byte, size)
+				io.ReadFull(r, chunk)
+				data = append(data, chunk...)
+			}
+			if strings.Contains(string(data), "EICAR") {
+				io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+			} else {
+				io.WriteString(conn, "stream: OK\x00")
+			}
+			conn.Close()
+		}
+	}()
+
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.zip")
+	infected := filepath.Join(dir, "infected.zip")
+	os.WriteFile(clean, []byte(strings.Repeat("x", 3*clamdChunkSize)), 0644)
+	os.WriteFile(infected, []byte(strings.Repeat("x", clamdChunkSize)+"EICAR"), 0644)
+
+	s := NewClamdScanner(lis.Addr().String())
+	if result, err := s.Scan(context.Background(), clean); err != nil || result.Infected {
+		t.Errorf("expected clean result, got %+v, %v", result, err)
+	}
+	result, err := s.Scan(context.Background(), infected)
+	if err != nil || !result.Infected || result.Threat != "Eicar-Test-Signature" {
+		t.Errorf("expected infected result, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ArchiveEntry is a file listed from an archive.
+type ArchiveEntry struct {
+	// Name is the path of the file inside the archive.
+	Name string
+	// Size is the uncompressed size in bytes.
+	Size int64
+}
+
+// SevenZip lists archives with an external 7z binary. It is a fallback for
+// archive variants the built-in extractor cannot read, such as 7z archives
+// using BCJ2 or PPMd.
+type SevenZip struct {
+	path string
+}
+
+// NewSevenZip returns a lister using the 7z binary at path, which may also be
+// a name looked up in PATH. It fails if the binary cannot be found.
+func NewSevenZip(path string) (*SevenZip, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("find 7z binary: %w", err)
+	}
+	return &SevenZip{path: resolved}, nil
+}
+
+// List returns the files in an archive, using password for encrypted ones.
+// Directories are skipped.
+func (s *SevenZip) List(ctx context.Context, archivePath, password string) ([]ArchiveEntry, error) {
+	if archivePath == "" {
+		return nil, ErrNoArchivePath
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.path, "l", "-slt", "--", archivePath)
+	// 7z prompts for the password of encrypted archives on stdin. Passing it
+	// with -p would expose it in process listings.
+	cmd.Stdin = strings.NewReader(password + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: 7z: %v: %s", ErrExtractionFailed, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseSevenZipListing(&stdout)
+}
+
+// parseSevenZipListing parses the technical listing printed by "7z l -slt".
+// Entries follow a "----------" line as blocks of "Key = Value" lines
+// separated by blank lines; anything before it describes the archive itself.
+func parseSevenZipListing(r io.Reader) ([]ArchiveEntry, error) {
+	var entries []ArchiveEntry
+	var block map[string]string
+	inEntries := false
+
+	flush := func() {
+		if block == nil {
+			return
+		}
+		name, ok := block["Path"]
+		isDir := block["Folder"] == "+" || strings.HasPrefix(block["Attributes"], "D")
+		if ok && name != "" && !isDir {
+			size, _ := strconv.ParseInt(block["Size"], 10, 64)
+			entries = append(entries, ArchiveEntry{Name: name, Size: size})
+		}
+		block = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !inEntries {
+			inEntries = line == "----------"
+			continue
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			// Keys with empty values are printed without a trailing space
+			key, value = strings.TrimSuffix(line, " ="), ""
+		}
+		if block == nil {
+			block = make(map[string]string)
+		}
+		block[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read 7z listing: %w", err)
+	}
+	flush()
+
+	return entries, nil
+}
@@ -0,0 +1,143 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const sampleListing = `
+7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21
+
+Scanning the drive for archives:
+1 file, 1234 bytes (2 KiB)
+
+Listing archive: mod.7z
+
+--
+Path = mod.7z
+Type = 7z
+Physical Size = 1234
+Headers Size = 200
+Method = LZMA2:24 BCJ2
+Solid = +
+Blocks = 1
+
+----------
+Path = Data
+Size = 0
+Packed Size = 0
+Modified = 2024-01-02 03:04:05
+Attributes = D_ drwxr-xr-x
+CRC =
+Encrypted = -
+
+Path = Data\MyMod.esp
+Size = 2048
+Packed Size = 900
+Modified = 2024-01-02 03:04:05
+Attributes = A_ -rw-r--r--
+CRC = 1A2B3C4D
+Encrypted = -
+
+Path = Data\textures\sky.dds
+Size = 4096
+Packed Size =
+Modified = 2024-01-02 03:04:05
+Attributes = A_ -rw-r--r--
+CRC = 5E6F7A8B
+Encrypted = -
+`
+
+func TestParseSevenZipListing(t *testing.T) {
+	entries, err := parseSevenZipListing(strings.NewReader(sampleListing))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []ArchiveEntry{
+		{Name: `Data\MyMod.esp`, Size: 2048},
+		{Name: `Data\textures\sky.dds`, Size: 4096},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("expected %+v, got %+v", want, entries)
+	}
+}
+
+func TestParseSevenZipListing_Empty(t *testing.T) {
+	entries, err := parseSevenZipListing(strings.NewReader("Listing archive: empty.7z\n\n--\nPath = empty.7z\nType = 7z\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no entries, got %+v", entries)
+	}
+}
+
+func TestNewSevenZip_NotFound(t *testing.T) {
+	if _, err := NewSevenZip(filepath.Join(t.TempDir(), "missing-7z")); err == nil {
+		t.Error("expected error for missing binary")
+	}
+}
+
+func TestSevenZip_List(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake 7z binary")
+	}
+
+	dir := t.TempDir()
+	listing := filepath.Join(dir, "listing.txt")
+	if err := os.WriteFile(listing, []byte(sampleListing), 0644); err != nil {
+		t.Fatalf("failed to write listing: %v", err)
+	}
+	script := filepath.Join(dir, "7z")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n[ \"$1\" = l ] || exit 2\ncat '"+listing+"'\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	failing := filepath.Join(dir, "7z-broken")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'Unsupported Method' >&2\nexit 2\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	sz, err := NewSevenZip(script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := sz.List(context.Background(), "mod.7z", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+
+	// The archive password is passed on to 7z through stdin, never as an argument
+	protected := filepath.Join(dir, "7z-protected")
+	body := "#!/bin/sh\nfor arg; do case $arg in -p*) exit 3;; esac; done\nread pw\n[ \"$pw\" = secret ] || exit 2\ncat '" + listing + "'\n"
+	if err := os.WriteFile(protected, []byte(body), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	withPassword, err := NewSevenZip(protected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := withPassword.List(context.Background(), "mod.7z", ""); !errors.Is(err, ErrExtractionFailed) {
+		t.Errorf("expected ErrExtractionFailed without a password, got %v", err)
+	}
+	if _, err := withPassword.List(context.Background(), "mod.7z", "secret"); err != nil {
+		t.Errorf("unexpected error with password: %v", err)
+	}
+
+	broken, err := NewSevenZip(failing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := broken.List(context.Background(), "mod.7z", ""); !errors.Is(err, ErrExtractionFailed) {
+		t.Errorf("expected ErrExtractionFailed, got %v", err)
+	}
+}
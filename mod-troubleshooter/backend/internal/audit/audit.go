@@ -0,0 +1,266 @@
+// Package audit keeps an append-only log of analysis requests, so operators
+// of shared instances can see who analyzed what and how it went.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Outcome is how an audited request ended.
+type Outcome string
+
+const (
+	// OutcomeSuccess is a request that was answered.
+	OutcomeSuccess Outcome = "success"
+	// OutcomeRejected is a request refused for a client error, such as an
+	// invalid parameter, a missing API key or a rate limit.
+	OutcomeRejected Outcome = "rejected"
+	// OutcomeFailed is a request that failed on the server or upstream.
+	OutcomeFailed Outcome = "failed"
+)
+
+// OutcomeFor returns the outcome of a response status code.
+func OutcomeFor(status int) Outcome {
+	switch {
+	case status >= 500:
+		return OutcomeFailed
+	case status >= 400:
+		return OutcomeRejected
+	default:
+		return OutcomeSuccess
+	}
+}
+
+// Default and maximum number of entries returned by a query.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Config holds configuration for the audit log.
+type Config struct {
+	// DBPath is the path to the SQLite database file.
+	DBPath string
+}
+
+// Entry is one audited request.
+type Entry struct {
+	// ID is the unique identifier of the entry, increasing over time.
+	ID int64 `json:"id"`
+	// Time is when the request arrived.
+	Time time.Time `json:"time"`
+	// RequestID is the request's X-Request-ID.
+	RequestID string `json:"requestId,omitempty"`
+	// Client is the IP address of the client, as resolved from trusted proxies.
+	Client string `json:"client"`
+	// UserAgent is the client's User-Agent header.
+	UserAgent string `json:"userAgent,omitempty"`
+	// Endpoint is the route pattern, such as
+	// "GET /api/collections/{slug}/revisions/{revision}/analyze".
+	Endpoint string `json:"endpoint"`
+	// Slug is the analyzed collection, if any.
+	Slug string `json:"slug,omitempty"`
+	// Revision is the analyzed collection revision, if any.
+	Revision int `json:"revision,omitempty"`
+	// Settings are the request's query parameters, such as the analyzers
+	// and profile asked for.
+	Settings map[string]string `json:"settings,omitempty"`
+	// Status is the response status code.
+	Status int `json:"status"`
+	// Outcome summarizes Status.
+	Outcome Outcome `json:"outcome"`
+	// DurationMs is how long the request took, in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Filter selects entries from the log. Zero fields match every entry.
+type Filter struct {
+	Client  string
+	Slug    string
+	Outcome Outcome
+	// Since and Until bound the request time; Until is exclusive.
+	Since time.Time
+	Until time.Time
+	// Before only returns entries older than this ID, for paging.
+	Before int64
+	// Limit is the maximum number of entries (default: DefaultLimit).
+	Limit int
+}
+
+// Log is an append-only, SQLite-backed log of requests. Entries are never
+// changed or removed through it.
+type Log struct {
+	db *sql.DB
+}
+
+// New opens the audit log with the given configuration.
+func New(cfg Config) (*Log, error) {
+	// Ensure the directory exists
+	dir := filepath.Dir(cfg.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create audit directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize schema: %w", err)
+	}
+
+	return &Log{db: db}, nil
+}
+
+// initSchema creates the necessary tables. Triggers reject updates and
+// deletes, so entries cannot be changed even by mistake.
+func initSchema(db *sql.DB) error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			time INTEGER NOT NULL,
+			request_id TEXT NOT NULL DEFAULT '',
+			client TEXT NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			endpoint TEXT NOT NULL,
+			slug TEXT NOT NULL DEFAULT '',
+			revision INTEGER NOT NULL DEFAULT 0,
+			settings TEXT NOT NULL DEFAULT '',
+			status INTEGER NOT NULL,
+			outcome TEXT NOT NULL,
+			duration_ms INTEGER NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_audit_log_client ON audit_log(client, id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_slug ON audit_log(slug, id);
+
+		CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+		BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+
+		CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+		BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+	`
+	_, err := db.Exec(schema)
+	return err
+}
+
+// Append adds an entry to the log and sets its ID.
+func (l *Log) Append(ctx context.Context, e *Entry) error {
+	if e.Outcome == "" {
+		e.Outcome = OutcomeFor(e.Status)
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+
+	var settings string
+	if len(e.Settings) > 0 {
+		data, err := json.Marshal(e.Settings)
+		if err != nil {
+			return fmt.Errorf("marshal settings: %w", err)
+		}
+		settings = string(data)
+	}
+
+	res, err := l.db.ExecContext(ctx, `
+		INSERT INTO audit_log (time, request_id, client, user_agent, endpoint, slug, revision, settings, status, outcome, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.Time.UnixMilli(), e.RequestID, e.Client, e.UserAgent, e.Endpoint, e.Slug, e.Revision, settings, e.Status, string(e.Outcome), e.DurationMs)
+	if err != nil {
+		return fmt.Errorf("append audit entry: %w", err)
+	}
+
+	e.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("get audit entry id: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching a filter, newest first.
+func (l *Log) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	var where []string
+	var args []interface{}
+	if f.Client != "" {
+		where = append(where, "client = ?")
+		args = append(args, f.Client)
+	}
+	if f.Slug != "" {
+		where = append(where, "slug = ?")
+		args = append(args, f.Slug)
+	}
+	if f.Outcome != "" {
+		where = append(where, "outcome = ?")
+		args = append(args, string(f.Outcome))
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, f.Since.UnixMilli())
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "time < ?")
+		args = append(args, f.Until.UnixMilli())
+	}
+	if f.Before > 0 {
+		where = append(where, "id < ?")
+		args = append(args, f.Before)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	query := `SELECT id, time, request_id, client, user_agent, endpoint, slug, revision, settings, status, outcome, duration_ms FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var at int64
+		var settings, outcome string
+		if err := rows.Scan(&e.ID, &at, &e.RequestID, &e.Client, &e.UserAgent, &e.Endpoint, &e.Slug, &e.Revision, &settings, &e.Status, &outcome, &e.DurationMs); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		e.Time = time.UnixMilli(at).UTC()
+		e.Outcome = Outcome(outcome)
+		if settings != "" {
+			if err := json.Unmarshal([]byte(settings), &e.Settings); err != nil {
+				return nil, fmt.Errorf("unmarshal settings: %w", err)
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Close closes the database connection.
+func (l *Log) Close() error {
+	return l.db.Close()
+}
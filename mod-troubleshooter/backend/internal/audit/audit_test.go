@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestLog(t *testing.T) *Log {
+	t.Helper()
+	l, err := New(Config{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestLog_AppendQuery(t *testing.T) {
+	l := newTestLog(t)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: start, Client: "10.0.0.1", Endpoint: "GET /analyze", Slug: "abc", Revision: 3, Settings: map[string]string{"profile": "deep"}, Status: 200},
+		{Time: start.Add(time.Minute), Client: "10.0.0.2", Endpoint: "GET /analyze", Slug: "abc", Status: 429},
+		{Time: start.Add(2 * time.Minute), Client: "10.0.0.1", Endpoint: "GET /analyze", Slug: "def", Status: 502},
+	}
+	for i := range entries {
+		if err := l.Append(ctx, &entries[i]); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if entries[2].ID <= entries[0].ID || entries[1].Outcome != OutcomeRejected || entries[2].Outcome != OutcomeFailed {
+		t.Errorf("unexpected appended entries %+v", entries)
+	}
+
+	all, err := l.Query(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(all) != 3 || all[0].ID != entries[2].ID {
+		t.Fatalf("expected 3 entries newest first, got %+v", all)
+	}
+	if got := all[2]; got.Settings["profile"] != "deep" || got.Revision != 3 || !got.Time.Equal(start) {
+		t.Errorf("expected the first entry to round-trip, got %+v", got)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"client", Filter{Client: "10.0.0.1"}, 2},
+		{"slug", Filter{Slug: "abc"}, 2},
+		{"outcome", Filter{Outcome: OutcomeFailed}, 1},
+		{"time", Filter{Since: start.Add(time.Minute), Until: start.Add(2 * time.Minute)}, 1},
+		{"page", Filter{Before: entries[2].ID, Limit: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := l.Query(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("expected %d entries, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLog_AppendOnly(t *testing.T) {
+	l := newTestLog(t)
+	ctx := context.Background()
+
+	e := Entry{Client: "10.0.0.1", Endpoint: "GET /analyze", Status: 200}
+	if err := l.Append(ctx, &e); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	if _, err := l.db.ExecContext(ctx, "UPDATE audit_log SET client = 'x'"); err == nil {
+		t.Error("expected updates to be rejected")
+	}
+	if _, err := l.db.ExecContext(ctx, "DELETE FROM audit_log"); err == nil {
+		t.Error("expected deletes to be rejected")
+	}
+}
@@ -0,0 +1,266 @@
+// Package bsa lists the files packed in Bethesda archives: BSA files used
+// by Oblivion through Skyrim Special Edition, and BA2 files used by
+// Fallout 4 and Starfield. Only the directory is read; file data is skipped,
+// so archives can be listed while they are streamed out of a mod archive.
+package bsa
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUnsupported is returned for data that is not a supported BSA or BA2.
+var ErrUnsupported = errors.New("unsupported Bethesda archive")
+
+// maxFiles bounds the number of files an archive may declare, so a corrupt
+// or hostile header cannot make the reader allocate without limit.
+const maxFiles = 1 << 20
+
+// Entry is a file packed in an archive.
+type Entry struct {
+	// Path is the file's path relative to the game's Data folder, with
+	// forward slashes.
+	Path string
+	// Size is the uncompressed size in bytes, if the archive records it.
+	Size int64
+}
+
+// List reads the directory of a BSA or BA2 archive from r. It reads r
+// sequentially and may stop before the end of the archive.
+func List(r io.Reader) ([]Entry, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	switch string(magic) {
+	case "BSA\x00":
+		return listBSA(br)
+	case "BTDX":
+		return listBA2(br)
+	}
+	return nil, fmt.Errorf("%w: unknown signature %q", ErrUnsupported, magic)
+}
+
+// BSA archive flags.
+const (
+	bsaDirectoryNames = 0x1
+	bsaFileNames      = 0x2
+	// bsaSizeCompressed inverts the archive's compression for a file; it
+	// is stored in the file size field.
+	bsaSizeCompressed = 0x40000000
+)
+
+// bsaHeader is the fixed header of a BSA archive.
+type bsaHeader struct {
+	Magic             [4]byte
+	Version           uint32
+	Offset            uint32
+	ArchiveFlags      uint32
+	FolderCount       uint32
+	FileCount         uint32
+	FolderNamesLength uint32
+	FileNamesLength   uint32
+	FileFlags         uint16
+	_                 uint16
+}
+
+// listBSA reads a BSA directory: folder records, then for each folder its
+// name and file records, then the block of file names.
+func listBSA(r *bufio.Reader) ([]Entry, error) {
+	var h bsaHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("read BSA header: %w", err)
+	}
+
+	var folderRecordSize int
+	switch h.Version {
+	case 103, 104:
+		folderRecordSize = 16
+	case 105:
+		folderRecordSize = 24
+	default:
+		return nil, fmt.Errorf("%w: BSA version %d", ErrUnsupported, h.Version)
+	}
+	if h.FileCount > maxFiles || h.FolderCount > maxFiles {
+		return nil, fmt.Errorf("%w: %d files in %d folders", ErrUnsupported, h.FileCount, h.FolderCount)
+	}
+	if h.ArchiveFlags&bsaFileNames == 0 {
+		return nil, fmt.Errorf("%w: BSA without file names", ErrUnsupported)
+	}
+	if _, err := r.Discard(int(h.Offset) - binary.Size(h)); err != nil {
+		return nil, fmt.Errorf("read BSA header: %w", err)
+	}
+
+	// Only the file count of each folder is needed from its record
+	counts := make([]uint32, h.FolderCount)
+	record := make([]byte, folderRecordSize)
+	for i := range counts {
+		if _, err := io.ReadFull(r, record); err != nil {
+			return nil, fmt.Errorf("read BSA folders: %w", err)
+		}
+		counts[i] = binary.LittleEndian.Uint32(record[8:12])
+	}
+
+	type file struct {
+		folder string
+		size   int64
+	}
+	files := make([]file, 0, h.FileCount)
+	record = make([]byte, 16)
+	for _, count := range counts {
+		var folder string
+		if h.ArchiveFlags&bsaDirectoryNames != 0 {
+			name, err := readBString(r)
+			if err != nil {
+				return nil, fmt.Errorf("read BSA folder name: %w", err)
+			}
+			folder = name
+		}
+		for range count {
+			if len(files) == int(h.FileCount) {
+				return nil, fmt.Errorf("%w: more files than declared", ErrUnsupported)
+			}
+			if _, err := io.ReadFull(r, record); err != nil {
+				return nil, fmt.Errorf("read BSA files: %w", err)
+			}
+			size := binary.LittleEndian.Uint32(record[8:12]) &^ bsaSizeCompressed
+			files = append(files, file{folder: folder, size: int64(size)})
+		}
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for _, f := range files {
+		name, err := r.ReadString(0)
+		if err != nil {
+			return nil, fmt.Errorf("read BSA file names: %w", err)
+		}
+		entries = append(entries, Entry{Path: joinPath(f.folder, strings.TrimSuffix(name, "\x00")), Size: f.size})
+	}
+	return entries, nil
+}
+
+// readBString reads a length-prefixed, null-terminated string.
+func readBString(r *bufio.Reader) (string, error) {
+	n, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf), "\x00"), nil
+}
+
+// ba2Header is the fixed header of a BA2 archive.
+type ba2Header struct {
+	Magic           [4]byte
+	Version         uint32
+	Type            [4]byte
+	FileCount       uint32
+	NameTableOffset uint64
+}
+
+// BA2 file record sizes.
+const (
+	ba2GeneralRecordSize = 36
+	ba2TextureRecordSize = 24
+	ba2ChunkSize         = 24
+)
+
+// listBA2 reads a BA2 directory: file records follow the header and the
+// name table sits after the file data, so the data in between is skipped.
+func listBA2(r *bufio.Reader) ([]Entry, error) {
+	var h ba2Header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("read BA2 header: %w", err)
+	}
+	read := int64(binary.Size(h))
+
+	switch h.Version {
+	case 1, 7, 8:
+	case 2:
+		// Starfield adds two unknown fields
+		read += 8
+	case 3:
+		// and then a compression method
+		read += 12
+	default:
+		return nil, fmt.Errorf("%w: BA2 version %d", ErrUnsupported, h.Version)
+	}
+	if read > int64(binary.Size(h)) {
+		if _, err := r.Discard(int(read) - binary.Size(h)); err != nil {
+			return nil, fmt.Errorf("read BA2 header: %w", err)
+		}
+	}
+	if h.FileCount > maxFiles {
+		return nil, fmt.Errorf("%w: %d files", ErrUnsupported, h.FileCount)
+	}
+
+	sizes := make([]int64, h.FileCount)
+	switch string(h.Type[:]) {
+	case "GNRL":
+		record := make([]byte, ba2GeneralRecordSize)
+		for i := range sizes {
+			if _, err := io.ReadFull(r, record); err != nil {
+				return nil, fmt.Errorf("read BA2 files: %w", err)
+			}
+			sizes[i] = int64(binary.LittleEndian.Uint32(record[28:32]))
+			read += ba2GeneralRecordSize
+		}
+	case "DX10":
+		record := make([]byte, ba2TextureRecordSize)
+		chunk := make([]byte, ba2ChunkSize)
+		for i := range sizes {
+			if _, err := io.ReadFull(r, record); err != nil {
+				return nil, fmt.Errorf("read BA2 textures: %w", err)
+			}
+			read += ba2TextureRecordSize
+			// The texture is the sum of its chunks, without the DDS header
+			for range record[13] {
+				if _, err := io.ReadFull(r, chunk); err != nil {
+					return nil, fmt.Errorf("read BA2 textures: %w", err)
+				}
+				sizes[i] += int64(binary.LittleEndian.Uint32(chunk[12:16]))
+				read += ba2ChunkSize
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: BA2 type %q", ErrUnsupported, h.Type)
+	}
+
+	if h.NameTableOffset == 0 || int64(h.NameTableOffset) < read {
+		return nil, fmt.Errorf("%w: BA2 without file names", ErrUnsupported)
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(h.NameTableOffset)-read); err != nil {
+		return nil, fmt.Errorf("seek BA2 names: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(sizes))
+	for _, size := range sizes {
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("read BA2 names: %w", err)
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return nil, fmt.Errorf("read BA2 names: %w", err)
+		}
+		entries = append(entries, Entry{Path: joinPath("", string(name)), Size: size})
+	}
+	return entries, nil
+}
+
+// joinPath joins a folder and file name with forward slashes.
+func joinPath(folder, name string) string {
+	path := name
+	if folder != "" {
+		path = folder + "/" + name
+	}
+	return strings.ReplaceAll(path, "\\", "/")
+}
@@ -0,0 +1,120 @@
+package bsa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// buildBSA writes a version 105 BSA with the given folders and file sizes.
+func buildBSA(folders map[string]map[string]uint32, order []string) []byte {
+	var fileCount int
+	var names bytes.Buffer
+	for _, folder := range order {
+		fileCount += len(folders[folder])
+	}
+
+	var body bytes.Buffer
+	le := func(v any) { binary.Write(&body, binary.LittleEndian, v) }
+	le(bsaHeader{
+		Magic:        [4]byte{'B', 'S', 'A', 0},
+		Version:      105,
+		Offset:       36,
+		ArchiveFlags: bsaDirectoryNames | bsaFileNames,
+		FolderCount:  uint32(len(order)),
+		FileCount:    uint32(fileCount),
+	})
+	for _, folder := range order {
+		le(uint64(0))
+		le(uint32(len(folders[folder])))
+		le(uint32(0))
+		le(uint64(0))
+	}
+	for _, folder := range order {
+		body.WriteByte(byte(len(folder) + 1))
+		body.WriteString(folder + "\x00")
+		for _, name := range sortedKeys(folders[folder]) {
+			le(uint64(0))
+			le(folders[folder][name] | bsaSizeCompressed)
+			le(uint32(0))
+			names.WriteString(name + "\x00")
+		}
+	}
+	body.Write(names.Bytes())
+	body.WriteString("file data follows")
+	return body.Bytes()
+}
+
+func sortedKeys(m map[string]uint32) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestList_BSA(t *testing.T) {
+	data := buildBSA(map[string]map[string]uint32{
+		`textures\armor`: {"iron.dds": 100, "steel.dds": 200},
+		`meshes`:         {"sword.nif": 50},
+	}, []string{`textures\armor`, `meshes`})
+
+	entries, err := List(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []Entry{
+		{Path: "textures/armor/iron.dds", Size: 100},
+		{Path: "textures/armor/steel.dds", Size: 200},
+		{Path: "meshes/sword.nif", Size: 50},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("expected %+v, got %+v", want, entries)
+	}
+}
+
+func TestList_BA2(t *testing.T) {
+	var body bytes.Buffer
+	le := func(v any) { binary.Write(&body, binary.LittleEndian, v) }
+	names := []string{`Meshes\Weapons\Gun.nif`, `Sound\FX\shot.wav`}
+
+	headerSize := binary.Size(ba2Header{})
+	data := "padding standing in for file data"
+	nameTable := headerSize + len(names)*ba2GeneralRecordSize + len(data)
+	le(ba2Header{Magic: [4]byte{'B', 'T', 'D', 'X'}, Version: 1, Type: [4]byte{'G', 'N', 'R', 'L'}, FileCount: 2, NameTableOffset: uint64(nameTable)})
+	for i := range names {
+		record := make([]byte, ba2GeneralRecordSize)
+		binary.LittleEndian.PutUint32(record[28:32], uint32(1000*(i+1)))
+		body.Write(record)
+	}
+	body.WriteString(data)
+	for _, name := range names {
+		le(uint16(len(name)))
+		body.WriteString(name)
+	}
+
+	entries, err := List(&body)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []Entry{{Path: "Meshes/Weapons/Gun.nif", Size: 1000}, {Path: "Sound/FX/shot.wav", Size: 2000}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("expected %+v, got %+v", want, entries)
+	}
+}
+
+func TestList_Unsupported(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("PK\x03\x04 a zip"),
+		append([]byte("BSA\x00\x99\x00\x00\x00"), make([]byte, 28)...),
+		{},
+	} {
+		if _, err := List(bytes.NewReader(data)); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%q: expected ErrUnsupported, got %v", data, err)
+		}
+	}
+}
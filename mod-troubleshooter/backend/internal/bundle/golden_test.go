@@ -0,0 +1,66 @@
+package bundle
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRenderReport_Golden(t *testing.T) {
+	var first bytes.Buffer
+	if err := RenderReport(&first, testBundle()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var second bytes.Buffer
+	if err := RenderReport(&second, testBundle()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("expected identical bundles to render identical reports")
+	}
+
+	path := filepath.Join("testdata", "report.golden.html")
+	if *update {
+		if err := os.WriteFile(path, first.Bytes(), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), want) {
+		t.Errorf("report differs from %s; run go test -update to accept\ngot:\n%s", path, first.String())
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := testBundle(), testBundle()
+	b.Metadata.CreatedAt = b.Metadata.CreatedAt.Add(1)
+
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Error("expected creation time not to affect the fingerprint")
+	}
+
+	b.Conflicts.Conflicts[0].Score++
+	if Fingerprint(a) == Fingerprint(b) {
+		t.Error("expected changed results to change the fingerprint")
+	}
+}
+
+func TestRenderReport_SuppressedFindings(t *testing.T) {
+	b := testBundle()
+	b.Metadata.SuppressedFindings = 3
+
+	var buf bytes.Buffer
+	if err := RenderReport(&buf, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("<p>Suppressed findings: 3</p>")) {
+		t.Errorf("expected report to count suppressed findings, got:\n%s", buf.String())
+	}
+}
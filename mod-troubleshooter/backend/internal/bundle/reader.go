@@ -0,0 +1,102 @@
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+// maxEntrySize limits how much data is read from any single bundle entry.
+const maxEntrySize = 256 * 1024 * 1024 // 256MB
+
+// Common errors returned when reading bundles.
+var (
+	ErrMissingMetadata    = errors.New("bundle is missing metadata.json")
+	ErrUnsupportedVersion = errors.New("unsupported bundle format version")
+	ErrEntryTooLarge      = errors.New("bundle entry exceeds size limit")
+)
+
+// Read parses a bundle previously produced by Write.
+// The HTML report is ignored since it can be regenerated from the JSON results.
+func Read(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %w", err)
+	}
+
+	b := &Bundle{}
+	var manifestFiles []*zip.File
+	foundMetadata := false
+
+	for _, f := range zr.File {
+		switch {
+		case f.Name == metadataEntry:
+			if err := readJSONEntry(f, &b.Metadata); err != nil {
+				return nil, err
+			}
+			foundMetadata = true
+		case f.Name == conflictsEntry:
+			b.Conflicts = &conflict.AnalysisResult{}
+			if err := readJSONEntry(f, b.Conflicts); err != nil {
+				return nil, err
+			}
+		case f.Name == loadOrderEntry:
+			b.LoadOrder = &loadorder.AnalysisResult{}
+			if err := readJSONEntry(f, b.LoadOrder); err != nil {
+				return nil, err
+			}
+		case f.Name == notesEntry:
+			if err := readJSONEntry(f, &b.Notes); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(f.Name, manifestsDir) && strings.HasSuffix(f.Name, ".json"):
+			manifestFiles = append(manifestFiles, f)
+		}
+	}
+
+	if !foundMetadata {
+		return nil, ErrMissingMetadata
+	}
+	if b.Metadata.FormatVersion < 1 || b.Metadata.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Metadata.FormatVersion)
+	}
+	if b.Conflicts == nil && b.LoadOrder == nil {
+		return nil, ErrNoResults
+	}
+
+	// Manifests are written in load order, which the archive preserves
+	for _, f := range manifestFiles {
+		var m conflict.ModManifest
+		if err := readJSONEntry(f, &m); err != nil {
+			return nil, err
+		}
+		b.Manifests = append(b.Manifests, m)
+	}
+
+	return b, nil
+}
+
+// readJSONEntry decodes a JSON file from the archive into dest.
+func readJSONEntry(f *zip.File, dest interface{}) error {
+	if f.UncompressedSize64 > maxEntrySize {
+		return fmt.Errorf("%w: %s", ErrEntryTooLarge, f.Name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(io.LimitReader(rc, maxEntrySize)).Decode(dest); err != nil {
+		return fmt.Errorf("decode %s: %w", f.Name, err)
+	}
+
+	return nil
+}
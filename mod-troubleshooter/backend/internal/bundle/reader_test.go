@@ -0,0 +1,95 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRead_RoundTrip(t *testing.T) {
+	original := testBundle()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, original); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+
+	if got.Metadata.FormatVersion != FormatVersion {
+		t.Errorf("expected format version %d, got %d", FormatVersion, got.Metadata.FormatVersion)
+	}
+	if got.Metadata.Slug != "abc123" || got.Metadata.Revision != 3 {
+		t.Errorf("unexpected metadata: %+v", got.Metadata)
+	}
+	if !got.Metadata.CreatedAt.Equal(original.Metadata.CreatedAt) {
+		t.Errorf("expected created at %v, got %v", original.Metadata.CreatedAt, got.Metadata.CreatedAt)
+	}
+	if got.Conflicts == nil || len(got.Conflicts.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %+v", got.Conflicts)
+	}
+	if got.LoadOrder == nil || got.LoadOrder.Stats.TotalPlugins != 1 {
+		t.Errorf("expected load order with 1 plugin, got %+v", got.LoadOrder)
+	}
+	if len(got.Manifests) != 2 {
+		t.Fatalf("expected 2 manifests, got %d", len(got.Manifests))
+	}
+	if got.Manifests[0].ModID != "100-200" || got.Manifests[1].ModID != "101/../201" {
+		t.Errorf("manifests out of order: %s, %s", got.Manifests[0].ModID, got.Manifests[1].ModID)
+	}
+	if got.Manifests[0].Manifest == nil || !got.Manifests[0].Manifest.HasFile("textures/a.dds") {
+		t.Error("expected manifest file listing to survive round trip")
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	zipWith := func(entries map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range entries {
+			w, _ := zw.Create(name)
+			w.Write([]byte(content))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "missing metadata",
+			data:    zipWith(map[string]string{conflictsEntry: `{}`}),
+			wantErr: ErrMissingMetadata,
+		},
+		{
+			name:    "future version",
+			data:    zipWith(map[string]string{metadataEntry: `{"formatVersion":99}`, conflictsEntry: `{}`}),
+			wantErr: ErrUnsupportedVersion,
+		},
+		{
+			name:    "no results",
+			data:    zipWith(map[string]string{metadataEntry: `{"formatVersion":1}`}),
+			wantErr: ErrNoResults,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := Read(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("expected error for invalid archive")
+	}
+}
@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders left where redacted text was.
+const (
+	redactedSecret = "[redacted]"
+	redactedPath   = "[local path]"
+)
+
+// minSecretLength is the shortest secret replaced verbatim, so a blank or
+// trivial value cannot wipe out unrelated text.
+const minSecretLength = 8
+
+var (
+	// urlCredentials matches query parameters that carry credentials, such
+	// as the key and expiry of a Nexus download link.
+	urlCredentials = regexp.MustCompile(`(?i)([?&](?:apikey|api_key|key|token|access_token|md5|expires|user_id)=)[^&\s"'<>]+`)
+	// headerCredentials matches API keys and tokens written out as headers.
+	headerCredentials = regexp.MustCompile(`(?i)\b((?:apikey|api[_-]key|authorization)\s*[:=]\s*|bearer\s+)[A-Za-z0-9._~+/=-]{8,}`)
+	// windowsPath matches absolute Windows paths. Directories may contain
+	// spaces, the last element may not.
+	windowsPath = regexp.MustCompile(`\b[A-Za-z]:[\\/](?:[^\\/:*?"<>|\r\n]+[\\/])*[^\\/:*?"<>|\s]*`)
+	// unixPath matches absolute paths under the directories that hold user
+	// and temporary files.
+	unixPath = regexp.MustCompile(`(^|[\s"'(=:])/(?:home|Users|tmp|var|root|mnt|media|opt|run|private|srv)(?:/[^\s"'<>]*|\b)`)
+)
+
+// Redaction selects what Redact strips from a bundle besides credentials
+// and local paths, which are always removed.
+type Redaction struct {
+	// Secrets are values, such as the Nexus API key, removed wherever they
+	// appear.
+	Secrets []string
+	// Notes drops the user's notes.
+	Notes bool
+}
+
+// Redact strips what should not be in a publicly shared bundle: the given
+// secrets, credentials in URLs and headers, and absolute paths on the
+// machine that ran the analysis, in every result, manifest and note.
+func (b *Bundle) Redact(r Redaction) error {
+	if r.Notes {
+		b.Notes = nil
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("encode bundle: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("decode bundle: %w", err)
+	}
+
+	if data, err = json.Marshal(redactValue(v, r.Secrets)); err != nil {
+		return fmt.Errorf("encode redacted bundle: %w", err)
+	}
+
+	var redacted Bundle
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		return fmt.Errorf("decode redacted bundle: %w", err)
+	}
+	redacted.Metadata.Redacted = true
+	*b = redacted
+	return nil
+}
+
+// redactValue redacts every string, and every object key, in a decoded
+// JSON value.
+func redactValue(v interface{}, secrets []string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return RedactString(v, secrets)
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i], secrets)
+		}
+		return v
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[RedactString(key, secrets)] = redactValue(value, secrets)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// RedactString replaces secrets, credentials and local paths in s. Secrets
+// shorter than minSecretLength are ignored.
+func RedactString(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(strings.TrimSpace(secret)) >= minSecretLength {
+			s = strings.ReplaceAll(s, secret, redactedSecret)
+		}
+	}
+	s = urlCredentials.ReplaceAllString(s, "${1}"+redactedSecret)
+	s = headerCredentials.ReplaceAllString(s, "${1}"+redactedSecret)
+	s = windowsPath.ReplaceAllString(s, redactedPath)
+	s = unixPath.ReplaceAllString(s, "${1}"+redactedPath)
+	return s
+}
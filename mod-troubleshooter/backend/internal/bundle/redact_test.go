@@ -0,0 +1,74 @@
+package bundle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+func TestRedactString(t *testing.T) {
+	secrets := []string{"abcdefghijklmnop", ""}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"key abcdefghijklmnop leaked", "key [redacted] leaked"},
+		{"https://cf-files.nexusmods.com/a.7z?md5=xyz&expires=123&user_id=42", "https://cf-files.nexusmods.com/a.7z?md5=[redacted]&expires=[redacted]&user_id=[redacted]"},
+		{"apikey: zzzzzzzzzzzz", "apikey: [redacted]"},
+		{"Authorization: Bearer eyJhbGciOiJIUzI1", "Authorization: Bearer [redacted]"},
+		{`open C:\Users\Jane Doe\Downloads\mod.7z: access denied`, "open [local path]: access denied"},
+		{"read /home/jane/.cache/mods/a.zip failed", "read [local path] failed"},
+		{"extract /tmp/mod-downloads/123.7z", "extract [local path]"},
+		{"textures/armor/iron.dds overwrites /varying", "textures/armor/iron.dds overwrites /varying"},
+		{"Skyrim.esm is a missing master", "Skyrim.esm is a missing master"},
+	}
+
+	for _, tt := range tests {
+		if got := RedactString(tt.in, secrets); got != tt.want {
+			t.Errorf("RedactString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBundle_Redact(t *testing.T) {
+	b := testBundle()
+	b.LoadOrder.Issues = []loadorder.Issue{{Plugin: "A.esp", Message: "could not read /home/jane/mods/A.esp"}}
+	b.Notes = []Note{{Target: "conflict", Subject: "textures/a.dds", Author: "Jane", Text: "my key is abcdefghijklmnop", CreatedAt: time.Now()}}
+
+	if err := b.Redact(Redaction{Secrets: []string{"abcdefghijklmnop"}}); err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if !b.Metadata.Redacted {
+		t.Error("expected the bundle to be marked redacted")
+	}
+	if got := b.LoadOrder.Issues[0].Message; got != "could not read [local path]" {
+		t.Errorf("unexpected issue message %q", got)
+	}
+	if len(b.Notes) != 1 || b.Notes[0].Text != "my key is [redacted]" {
+		t.Errorf("unexpected notes %+v", b.Notes)
+	}
+	if b.Manifests[0].Manifest.TotalCount != 1 || b.Conflicts.Conflicts[0].Score != 45 {
+		t.Errorf("expected the results to survive redaction, got %+v", b.Conflicts.Conflicts[0])
+	}
+
+	if err := b.Redact(Redaction{Notes: true}); err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if len(b.Notes) != 0 {
+		t.Errorf("expected notes to be dropped, got %+v", b.Notes)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !got.Metadata.Redacted || len(got.Notes) != 0 {
+		t.Errorf("expected a redacted bundle without notes, got %+v", got.Metadata)
+	}
+}
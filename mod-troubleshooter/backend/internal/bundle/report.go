@@ -0,0 +1,94 @@
+package bundle
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+// maxReportConflicts limits how many conflicts are listed in the HTML report.
+// The full list is always available in conflicts.json.
+const maxReportConflicts = 200
+
+// reportTemplate is a self-contained HTML page summarizing a bundle.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Mod Troubleshooter Report - {{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1a1a1a; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { border: 1px solid #ccc; padding: 0.25rem 0.5rem; text-align: left; }
+th { background: #f0f0f0; }
+.critical { color: #b71c1c; font-weight: bold; }
+.high { color: #e65100; }
+.error { color: #b71c1c; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Collection <code>{{.Meta.Slug}}</code>, revision {{.Meta.Revision}}{{if .Meta.GameDomain}} ({{.Meta.GameDomain}}){{end}}. Generated {{.Meta.CreatedAt.Format "2006-01-02 15:04 MST"}}.</p>
+<p>Fingerprint <code>{{.Meta.Fingerprint}}</code></p>{{if .Meta.SuppressedFindings}}
+<p>Suppressed findings: {{.Meta.SuppressedFindings}}</p>{{end}}
+{{with .Conflicts}}
+<h2>File Conflicts</h2>
+<p>{{.Stats.TotalConflicts}} conflicts across {{.Stats.ModsAnalyzed}} mods: {{.Stats.CriticalCount}} critical, {{.Stats.HighCount}} high, {{.Stats.MediumCount}} medium, {{.Stats.LowCount}} low, {{.Stats.InfoCount}} info.</p>
+<table>
+<tr><th>Severity</th><th>Score</th><th>Path</th><th>Message</th></tr>
+{{range $.TopConflicts}}<tr><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Score}}</td><td><code>{{.Path}}</code></td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{if $.TruncatedConflicts}}<p>{{$.TruncatedConflicts}} more conflicts omitted; see conflicts.json.</p>{{end}}
+{{end}}
+{{with .LoadOrder}}
+<h2>Load Order</h2>
+<p>{{.Stats.TotalPlugins}} plugins ({{.Stats.ESMCount}} ESM, {{.Stats.ESPCount}} ESP, {{.Stats.ESLCount}} ESL) with {{.Stats.TotalIssues}} issues.</p>
+{{if .Issues}}<table>
+<tr><th>Severity</th><th>Plugin</th><th>Message</th></tr>
+{{range .Issues}}<tr><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Plugin}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}{{if .Notes}}
+<h2>Notes</h2>
+<table>
+<tr><th>Finding</th><th>Author</th><th>Note</th></tr>
+{{range .Notes}}<tr><td><code>{{.Subject}}</code></td><td>{{.Author}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+// reportData is the view model passed to the report template.
+type reportData struct {
+	*Bundle
+	Title              string
+	Meta               Metadata
+	TopConflicts       []conflict.Conflict
+	TruncatedConflicts int
+}
+
+// RenderReport writes a standalone HTML summary of the bundle.
+func RenderReport(w io.Writer, b *Bundle) error {
+	data := reportData{
+		Bundle: b,
+		Title:  b.Metadata.CollectionName,
+		Meta:   b.Metadata,
+	}
+	if data.Title == "" {
+		data.Title = b.Metadata.Slug
+	}
+	if data.Meta.Fingerprint == "" {
+		data.Meta.Fingerprint = Fingerprint(b)
+	}
+
+	if b.Conflicts != nil {
+		conflicts := b.Conflicts.Conflicts
+		if len(conflicts) > maxReportConflicts {
+			data.TruncatedConflicts = len(conflicts) - maxReportConflicts
+			conflicts = conflicts[:maxReportConflicts]
+		}
+		data.TopConflicts = conflicts
+	}
+
+	return reportTemplate.Execute(w, data)
+}
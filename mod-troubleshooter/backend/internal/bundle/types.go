@@ -0,0 +1,140 @@
+package bundle
+
+import (
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+// FormatVersion is the current bundle layout version.
+// Increment when the archive layout changes incompatibly.
+const FormatVersion = 1
+
+// Archive entry names used inside a bundle.
+const (
+	metadataEntry  = "metadata.json"
+	conflictsEntry = "conflicts.json"
+	loadOrderEntry = "loadorder.json"
+	notesEntry     = "notes.json"
+	reportEntry    = "report.html"
+	manifestsDir   = "manifests/"
+)
+
+// Metadata describes the collection revision a bundle was created from.
+type Metadata struct {
+	// FormatVersion is the bundle layout version.
+	FormatVersion int `json:"formatVersion"`
+	// Slug is the collection slug.
+	Slug string `json:"slug"`
+	// Revision is the collection revision number.
+	Revision int `json:"revision"`
+	// CollectionName is the display name of the collection, if known.
+	CollectionName string `json:"collectionName,omitempty"`
+	// GameDomain is the Nexus game domain of the collection, if known.
+	GameDomain string `json:"gameDomain,omitempty"`
+	// CreatedAt is when the bundle was created.
+	CreatedAt time.Time `json:"createdAt"`
+	// HiddenMods is the number of mods removed from the bundle, such as adult content.
+	HiddenMods int `json:"hiddenMods,omitempty"`
+	// SuppressedFindings is the number of conflicts and issues hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings,omitempty"`
+	// Fingerprint identifies the analysis results; bundles of identical results share it.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Redacted is true when secrets and local paths were stripped for sharing.
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// Note is a user's note on a finding, exported with a stored analysis.
+type Note struct {
+	// Target is the kind of finding, "conflict" or "issue".
+	Target string `json:"target"`
+	// Subject is the conflict path or issue plugin.
+	Subject string `json:"subject"`
+	// Author names who wrote the note, if given.
+	Author string `json:"author,omitempty"`
+	// Text is the note content.
+	Text string `json:"text"`
+	// CreatedAt is when the note was added.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Bundle is a complete diagnostic snapshot of a collection revision's analysis.
+type Bundle struct {
+	// Metadata identifies the collection revision.
+	Metadata Metadata `json:"metadata"`
+	// Conflicts is the file conflict analysis result, if available.
+	Conflicts *conflict.AnalysisResult `json:"conflicts,omitempty"`
+	// LoadOrder is the load order analysis result, if available.
+	LoadOrder *loadorder.AnalysisResult `json:"loadOrder,omitempty"`
+	// Manifests are the per-mod file manifests used for conflict analysis.
+	Manifests []conflict.ModManifest `json:"manifests,omitempty"`
+	// Notes are the user's notes on findings, when exported from history.
+	Notes []Note `json:"notes,omitempty"`
+}
+
+// HideMods removes the given mods from the bundle: their manifests, conflict
+// summaries and every conflict they take part in. Stats are left as computed,
+// so totals still describe the whole collection. It records and returns the
+// number of mods removed.
+func (b *Bundle) HideMods(ids map[string]bool) int {
+	if len(ids) == 0 {
+		return 0
+	}
+	hidden := make(map[string]bool)
+
+	manifests := b.Manifests[:0]
+	for _, m := range b.Manifests {
+		if ids[m.ModID] {
+			hidden[m.ModID] = true
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+	b.Manifests = manifests
+
+	if b.Conflicts != nil {
+		conflicts := make([]conflict.Conflict, 0, len(b.Conflicts.Conflicts))
+		for _, c := range b.Conflicts.Conflicts {
+			if !involvesAny(c, ids, hidden) {
+				conflicts = append(conflicts, c)
+			}
+		}
+		b.Conflicts.Conflicts = conflicts
+
+		summaries := make([]conflict.ModConflictSummary, 0, len(b.Conflicts.ModSummaries))
+		for _, s := range b.Conflicts.ModSummaries {
+			if ids[s.ModID] {
+				hidden[s.ModID] = true
+				continue
+			}
+			summaries = append(summaries, s)
+		}
+		b.Conflicts.ModSummaries = summaries
+
+		for path, mods := range b.Conflicts.FileToMods {
+			for _, id := range mods {
+				if ids[id] {
+					hidden[id] = true
+					delete(b.Conflicts.FileToMods, path)
+					break
+				}
+			}
+		}
+	}
+
+	b.Metadata.HiddenMods = len(hidden)
+	return len(hidden)
+}
+
+// involvesAny reports whether a conflict has a source in ids, recording matches in hidden.
+func involvesAny(c conflict.Conflict, ids, hidden map[string]bool) bool {
+	found := false
+	for _, src := range c.Sources {
+		if ids[src.ModID] {
+			hidden[src.ModID] = true
+			found = true
+		}
+	}
+	return found
+}
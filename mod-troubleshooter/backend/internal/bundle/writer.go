@@ -0,0 +1,104 @@
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/mod-troubleshooter/backend/internal/fingerprint"
+)
+
+// Common errors returned when writing bundles.
+var (
+	ErrNilBundle = errors.New("bundle is required")
+	ErrNoResults = errors.New("bundle has no analysis results")
+)
+
+// unsafeNameChars matches characters not allowed in manifest entry names.
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Write writes the bundle as a zip archive containing the JSON results,
+// any notes, one manifest file per mod, and a standalone HTML report.
+func Write(w io.Writer, b *Bundle) error {
+	if b == nil {
+		return ErrNilBundle
+	}
+	if b.Conflicts == nil && b.LoadOrder == nil {
+		return ErrNoResults
+	}
+
+	zw := zip.NewWriter(w)
+
+	meta := b.Metadata
+	meta.FormatVersion = FormatVersion
+	meta.Fingerprint = Fingerprint(b)
+	if err := writeJSONEntry(zw, metadataEntry, meta); err != nil {
+		return err
+	}
+
+	if b.Conflicts != nil {
+		if err := writeJSONEntry(zw, conflictsEntry, b.Conflicts); err != nil {
+			return err
+		}
+	}
+
+	if b.LoadOrder != nil {
+		if err := writeJSONEntry(zw, loadOrderEntry, b.LoadOrder); err != nil {
+			return err
+		}
+	}
+
+	if len(b.Notes) > 0 {
+		if err := writeJSONEntry(zw, notesEntry, b.Notes); err != nil {
+			return err
+		}
+	}
+
+	for i, m := range b.Manifests {
+		name := fmt.Sprintf("%s%03d-%s.json", manifestsDir, i, unsafeNameChars.ReplaceAllString(m.ModID, "_"))
+		if err := writeJSONEntry(zw, name, m); err != nil {
+			return err
+		}
+	}
+
+	rw, err := zw.Create(reportEntry)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", reportEntry, err)
+	}
+	if err := RenderReport(rw, b); err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finalize bundle: %w", err)
+	}
+
+	return nil
+}
+
+// Fingerprint identifies a bundle's analysis results, ignoring when it was created.
+func Fingerprint(b *Bundle) string {
+	return fingerprint.Of(struct {
+		Conflicts interface{} `json:"conflicts"`
+		LoadOrder interface{} `json:"loadOrder"`
+	}{b.Conflicts, b.LoadOrder})
+}
+
+// writeJSONEntry adds a pretty-printed JSON file to the archive.
+func writeJSONEntry(zw *zip.Writer, name string, v interface{}) error {
+	ew, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+
+	enc := json.NewEncoder(ew)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Metadata: Metadata{
+			Slug:           "abc123",
+			Revision:       3,
+			CollectionName: "Test <Collection>",
+			GameDomain:     "skyrimspecialedition",
+			CreatedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		Conflicts: &conflict.AnalysisResult{
+			Conflicts: []conflict.Conflict{
+				{Path: "textures/a.dds", Severity: conflict.SeverityMedium, Score: 45, Message: "File 'textures/a.dds' from 'B' overwrites 'A'"},
+			},
+			Stats: conflict.Stats{TotalConflicts: 1, MediumCount: 1, ModsAnalyzed: 2},
+		},
+		LoadOrder: &loadorder.AnalysisResult{
+			Plugins: []loadorder.PluginInfo{{Filename: "A.esp"}},
+			Stats:   loadorder.Stats{TotalPlugins: 1},
+		},
+		Manifests: []conflict.ModManifest{
+			{ModID: "100-200", ModName: "A", Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/a.dds", 10)})},
+			{ModID: "101/../201", ModName: "B", LoadOrder: 1},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testBundle()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open bundle: %v", err)
+	}
+
+	entries := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	for _, name := range []string{
+		metadataEntry,
+		conflictsEntry,
+		loadOrderEntry,
+		reportEntry,
+		"manifests/000-100-200.json",
+		"manifests/001-101_.._201.json",
+	} {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("expected entry %s in bundle", name)
+		}
+	}
+
+	rc, err := entries[reportEntry].Open()
+	if err != nil {
+		t.Fatalf("failed to open report: %v", err)
+	}
+	defer rc.Close()
+	report, _ := io.ReadAll(rc)
+
+	if !strings.Contains(string(report), "Test &lt;Collection&gt;") {
+		t.Error("expected escaped collection name in report")
+	}
+	if !strings.Contains(string(report), "textures/a.dds") {
+		t.Error("expected conflict path in report")
+	}
+}
+
+func TestWrite_Errors(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Write(&buf, nil); !errors.Is(err, ErrNilBundle) {
+		t.Errorf("expected ErrNilBundle, got %v", err)
+	}
+
+	if err := Write(&buf, &Bundle{Metadata: Metadata{Slug: "x"}}); !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected ErrNoResults, got %v", err)
+	}
+}
+
+func TestBundle_HideMods(t *testing.T) {
+	b := testBundle()
+	b.Conflicts.Conflicts[0].Sources = []conflict.ModFile{{ModID: "100-200"}, {ModID: "101/../201"}}
+	b.Conflicts.ModSummaries = []conflict.ModConflictSummary{{ModID: "100-200"}, {ModID: "101/../201"}}
+	b.Conflicts.FileToMods = map[string][]string{"textures/a.dds": {"100-200", "101/../201"}}
+
+	if n := b.HideMods(map[string]bool{"100-200": true}); n != 1 {
+		t.Errorf("expected 1 hidden mod, got %d", n)
+	}
+	if len(b.Manifests) != 1 || b.Manifests[0].ModID != "101/../201" {
+		t.Errorf("expected only mod B's manifest to remain, got %+v", b.Manifests)
+	}
+	if len(b.Conflicts.Conflicts) != 0 || len(b.Conflicts.ModSummaries) != 1 || len(b.Conflicts.FileToMods) != 0 {
+		t.Errorf("expected hidden mod removed from conflicts, got %+v", b.Conflicts)
+	}
+	if b.Metadata.HiddenMods != 1 {
+		t.Errorf("expected metadata to record 1 hidden mod, got %d", b.Metadata.HiddenMods)
+	}
+}
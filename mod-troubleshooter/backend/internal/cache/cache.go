@@ -85,6 +85,36 @@ func CacheKey(game string, modID, fileID int) string {
 	return fmt.Sprintf("fomod:%s:%d:%d", game, modID, fileID)
 }
 
+// ConflictsKey generates a cache key for a collection revision's conflict analysis.
+func ConflictsKey(slug string, revision int, includeHashes bool) string {
+	return fmt.Sprintf("conflicts:%s:%d:%t", slug, revision, includeHashes)
+}
+
+// LoadOrderKey generates a cache key for a collection revision's load order analysis.
+func LoadOrderKey(slug string, revision int) string {
+	return fmt.Sprintf("loadorder:%s:%d", slug, revision)
+}
+
+// ManifestsKey generates a cache key for the mod manifests extracted from a collection revision.
+func ManifestsKey(slug string, revision int, includeHashes bool) string {
+	return fmt.Sprintf("manifests:%s:%d:%t", slug, revision, includeHashes)
+}
+
+// RevisionDiffKey generates a cache key for the comparison of two collection revisions.
+func RevisionDiffKey(slug string, from, to int) string {
+	return fmt.Sprintf("revdiff:%s:%d:%d", slug, from, to)
+}
+
+// RemovalKey generates a cache key for the impact of removing a mod from a collection revision.
+func RemovalKey(slug string, revision, modID int) string {
+	return fmt.Sprintf("removal:%s:%d:%d", slug, revision, modID)
+}
+
+// SizeKey generates a cache key for the disk space a collection revision needs.
+func SizeKey(slug string, revision int) string {
+	return fmt.Sprintf("size:%s:%d", slug, revision)
+}
+
 // Get retrieves a cached entry.
 func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
 	var data string
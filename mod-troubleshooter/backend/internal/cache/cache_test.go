@@ -55,6 +55,25 @@ func TestCacheKey(t *testing.T) {
 	}
 }
 
+func TestRevisionKeys(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{ConflictsKey("abc123", 4, true), "conflicts:abc123:4:true"},
+		{LoadOrderKey("abc123", 4), "loadorder:abc123:4"},
+		{ManifestsKey("abc123", 4, false), "manifests:abc123:4:false"},
+		{RemovalKey("abc123", 4, 266), "removal:abc123:4:266"},
+		{SizeKey("abc123", 4), "size:abc123:4"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got key %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
 func TestCache_SetGet(t *testing.T) {
 	tempDir := t.TempDir()
 	cache, err := New(Config{
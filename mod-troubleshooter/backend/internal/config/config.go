@@ -3,11 +3,16 @@ package config
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// maxConcurrency bounds the concurrency settings, matching the limit the
+// settings endpoint enforces.
+const maxConcurrency = 64
+
 // Config holds all configuration for the application.
 type Config struct {
 	// Port is the HTTP server port (default: 8080)
@@ -22,11 +27,142 @@ type Config struct {
 	// CacheTTLHours is how long to cache data in hours (default: 168 = 1 week)
 	CacheTTLHours int
 
+	// ManifestCacheTTLHours is how long the file listings of Nexus mod
+	// files are cached in hours, so later analyses don't download them
+	// again, or 0 to not cache them (default: 720 = 30 days). Files on
+	// Nexus don't change, so listings outlive analyses.
+	ManifestCacheTTLHours int
+
 	// Environment is the running environment (development, production)
 	Environment string
 
-	// CORSOrigins are the allowed origins for CORS
+	// CORSOrigins are the allowed origins for CORS, for every method.
+	// An origin may contain one "*" wildcard, such as "https://*.example.com".
 	CORSOrigins []string
+
+	// CORSRules allow further origins for specific methods only.
+	CORSRules []CORSRule
+
+	// TLSCertFile and TLSKeyFile serve HTTPS on Port when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// AutocertHosts serve HTTPS on Port with certificates obtained
+	// automatically from Let's Encrypt for these hostnames (optional).
+	AutocertHosts []string
+
+	// AutocertCacheDir stores obtained certificates (default: DataDir/autocert).
+	AutocertCacheDir string
+
+	// UnixSocket is the path of a Unix domain socket to also serve on (optional).
+	UnixSocket string
+
+	// DisableTCP stops serving on Port, for setups that only use UnixSocket.
+	DisableTCP bool
+
+	// TrustedProxies are the IPs or CIDR ranges of reverse proxies whose
+	// X-Forwarded-* headers are honored.
+	TrustedProxies []string
+
+	// StatsEnabled turns on local usage statistics (default: false).
+	// Stats are stored in DataDir and never leave the machine.
+	StatsEnabled bool
+
+	// SevenZipPath is an external 7z binary used to list archives the built-in
+	// extractor cannot read (optional).
+	SevenZipPath string
+
+	// ReadOnly disables endpoints that download from Nexus, serving only
+	// cached results and history (default: false).
+	ReadOnly bool
+
+	// ContentPreviews lists mod archives from their Nexus content preview
+	// instead of downloading them when only file listings are needed
+	// (default: true).
+	ContentPreviews bool
+
+	// DiscordBotToken enables the Discord bot, which answers "!analyze"
+	// commands in the channels it can read (optional).
+	DiscordBotToken string
+
+	// GRPCPort serves the gRPC API on this port when set (optional). It uses
+	// the same TLS configuration as Port.
+	GRPCPort string
+
+	// DLLAllowlistFile is a JSON file of known script extender plugins that
+	// updates the built-in allowlist (optional).
+	DLLAllowlistFile string
+
+	// CuratorKeysFile is a JSON file mapping collection slugs to the
+	// Ed25519 keys their curator rule files must be signed with. It is how
+	// an operator replaces a key pinned by an earlier rule file (optional).
+	CuratorKeysFile string
+
+	// ScanCommand is a virus scanner command, such as "clamscan --no-summary",
+	// run on every download with the file path appended (optional).
+	ScanCommand string
+
+	// ClamdAddress is a clamd socket path or host:port that every download is
+	// streamed to for scanning (optional).
+	ClamdAddress string
+
+	// QuarantineDir receives downloads the scanner flags
+	// (default: DataDir/quarantine).
+	QuarantineDir string
+
+	// SandboxExtraction reads archives in separate worker processes, so
+	// archive parser bugs cannot compromise the server (default: false).
+	SandboxExtraction bool
+
+	// AnalysisProfile is the default analysis profile: quick, standard or
+	// deep. It can be changed at runtime from the settings (default: standard).
+	AnalysisProfile string
+
+	// DownloadConcurrency, ExtractionConcurrency, HashConcurrency and
+	// PluginParseConcurrency bound how many mod downloads, archive
+	// listings, content hashing passes and plugin parses run at once across
+	// all analyses. They can be changed at runtime from the settings
+	// (default: 0, meaning 4 downloads and one of each other per CPU).
+	DownloadConcurrency    int
+	ExtractionConcurrency  int
+	HashConcurrency        int
+	PluginParseConcurrency int
+
+	// AuditLog records every analysis request, with the client's address,
+	// in an append-only log served at /api/audit (default: false).
+	AuditLog bool
+
+	// SMTPHost is the mail server watch notifications are emailed through;
+	// email targets are disabled without it (optional).
+	SMTPHost string
+
+	// SMTPPort is the mail server's port (default: 587).
+	SMTPPort int
+
+	// SMTPUsername and SMTPPassword authenticate with the mail server (optional).
+	SMTPUsername string
+	SMTPPassword string
+
+	// SMTPFrom is the sender address of notification emails.
+	SMTPFrom string
+
+	// NotifyAllowedNetworks are the IPs or CIDR ranges that webhook, ntfy
+	// and Gotify targets may use besides public addresses, such as a
+	// self-hosted server on the LAN (optional).
+	NotifyAllowedNetworks []string
+
+	// WatchIntervalMinutes is how often watched collections are checked
+	// for new revisions (default: 60).
+	WatchIntervalMinutes int
+
+	// DesktopMode runs the server for one player on their own machine: it
+	// pauses background work after IdleTimeoutMinutes without requests,
+	// and serves /api/admin to pause it on demand (default: false).
+	DesktopMode bool
+
+	// IdleTimeoutMinutes is how long a desktop server waits without
+	// requests before pausing; 0 only pauses on demand (default: 15).
+	IdleTimeoutMinutes int
 }
 
 // Load reads configuration from environment variables and optional .env file.
@@ -41,12 +177,74 @@ func Load() (*Config, error) {
 		DataDir:       getEnv("DATA_DIR", "./data"),
 		CacheTTLHours: getEnvInt("CACHE_TTL_HOURS", 168),
 		Environment:   getEnv("ENVIRONMENT", "development"),
+		StatsEnabled:  getEnvBool("STATS_ENABLED", false),
+		TLSCertFile:   getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:    getEnv("TLS_KEY_FILE", ""),
+		UnixSocket:    getEnv("UNIX_SOCKET", ""),
+
+		ManifestCacheTTLHours: getEnvInt("MANIFEST_CACHE_TTL_HOURS", 720),
+
+		AutocertCacheDir: getEnv("AUTOCERT_CACHE_DIR", ""),
+		DisableTCP:       getEnvBool("DISABLE_TCP", false),
+		ReadOnly:         getEnvBool("READ_ONLY", false),
+		SevenZipPath:     getEnv("SEVENZIP_PATH", ""),
+
+		ContentPreviews: getEnvBool("NEXUS_CONTENT_PREVIEWS", true),
+
+		DiscordBotToken: getEnv("DISCORD_BOT_TOKEN", ""),
+
+		GRPCPort:         getEnv("GRPC_PORT", ""),
+		DLLAllowlistFile: getEnv("DLL_ALLOWLIST_FILE", ""),
+		CuratorKeysFile:  getEnv("CURATOR_KEYS_FILE", ""),
+
+		ScanCommand:   getEnv("SCAN_COMMAND", ""),
+		ClamdAddress:  getEnv("CLAMD_ADDRESS", ""),
+		QuarantineDir: getEnv("QUARANTINE_DIR", ""),
+
+		SandboxExtraction: getEnvBool("SANDBOX_EXTRACTION", false),
+
+		AnalysisProfile: strings.ToLower(getEnv("ANALYSIS_PROFILE", "standard")),
+
+		DownloadConcurrency:    getEnvInt("DOWNLOAD_CONCURRENCY", 0),
+		ExtractionConcurrency:  getEnvInt("EXTRACTION_CONCURRENCY", 0),
+		HashConcurrency:        getEnvInt("HASH_CONCURRENCY", 0),
+		PluginParseConcurrency: getEnvInt("PLUGIN_PARSE_CONCURRENCY", 0),
+
+		AuditLog: getEnvBool("AUDIT_LOG", false),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
+
+		WatchIntervalMinutes: getEnvInt("WATCH_INTERVAL_MINUTES", 60),
+
+		DesktopMode:        getEnvBool("DESKTOP_MODE", false),
+		IdleTimeoutMinutes: getEnvInt("IDLE_TIMEOUT_MINUTES", 15),
 	}
 
 	// Parse CORS origins
 	origins := getEnv("CORS_ORIGINS", "http://localhost:5173,http://localhost:3000")
 	cfg.CORSOrigins = parseCSV(origins)
 
+	rules, err := parseCORSRules(getEnv("CORS_ORIGIN_RULES", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.CORSRules = rules
+
+	cfg.TrustedProxies = parseCSV(getEnv("TRUSTED_PROXIES", ""))
+	cfg.NotifyAllowedNetworks = parseCSV(getEnv("NOTIFY_ALLOWED_NETWORKS", ""))
+
+	cfg.AutocertHosts = parseCSV(getEnv("AUTOCERT_HOSTS", ""))
+	if cfg.AutocertCacheDir == "" {
+		cfg.AutocertCacheDir = filepath.Join(cfg.DataDir, "autocert")
+	}
+	if cfg.QuarantineDir == "" {
+		cfg.QuarantineDir = filepath.Join(cfg.DataDir, "quarantine")
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -62,9 +260,144 @@ func (c *Config) Validate() error {
 		return errors.New("NEXUS_API_KEY is required in production")
 	}
 
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if c.TLSCertFile != "" && len(c.AutocertHosts) > 0 {
+		return errors.New("AUTOCERT_HOSTS cannot be combined with TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if c.DisableTCP && c.UnixSocket == "" {
+		return errors.New("UNIX_SOCKET is required when DISABLE_TCP is set")
+	}
+
+	if c.GRPCPort != "" && c.GRPCPort == c.Port && !c.DisableTCP {
+		return errors.New("GRPC_PORT must differ from PORT")
+	}
+
+	if c.ScanCommand != "" && c.ClamdAddress != "" {
+		return errors.New("SCAN_COMMAND and CLAMD_ADDRESS cannot be combined")
+	}
+
+	if c.SMTPHost != "" && c.SMTPFrom == "" {
+		return errors.New("SMTP_FROM is required when SMTP_HOST is set")
+	}
+
+	if c.ManifestCacheTTLHours < 0 {
+		return fmt.Errorf("MANIFEST_CACHE_TTL_HOURS must not be negative, got %d", c.ManifestCacheTTLHours)
+	}
+
+	if c.WatchIntervalMinutes < 0 {
+		return fmt.Errorf("WATCH_INTERVAL_MINUTES must not be negative, got %d", c.WatchIntervalMinutes)
+	}
+
+	if c.IdleTimeoutMinutes < 0 {
+		return fmt.Errorf("IDLE_TIMEOUT_MINUTES must not be negative, got %d", c.IdleTimeoutMinutes)
+	}
+
+	switch c.AnalysisProfile {
+	case "", "quick", "standard", "deep":
+	default:
+		return fmt.Errorf("ANALYSIS_PROFILE must be quick, standard or deep, got %q", c.AnalysisProfile)
+	}
+
+	for _, knob := range []struct {
+		name  string
+		value int
+	}{
+		{"DOWNLOAD_CONCURRENCY", c.DownloadConcurrency},
+		{"EXTRACTION_CONCURRENCY", c.ExtractionConcurrency},
+		{"HASH_CONCURRENCY", c.HashConcurrency},
+		{"PLUGIN_PARSE_CONCURRENCY", c.PluginParseConcurrency},
+	} {
+		if knob.value < 0 || knob.value > maxConcurrency {
+			return fmt.Errorf("%s must be between 0 and %d, got %d", knob.name, maxConcurrency, knob.value)
+		}
+	}
+
 	return nil
 }
 
+// TLSEnabled returns true if HTTPS is configured.
+func (c *Config) TLSEnabled() bool {
+	return (c.TLSCertFile != "" && c.TLSKeyFile != "") || c.AutocertEnabled()
+}
+
+// AutocertEnabled returns true if HTTPS certificates are obtained automatically.
+func (c *Config) AutocertEnabled() bool {
+	return len(c.AutocertHosts) > 0
+}
+
+// CORSRule allows an origin to make cross-origin requests with some methods.
+type CORSRule struct {
+	// Origin is the allowed origin and may contain one "*" wildcard.
+	Origin string
+	// Methods are the allowed HTTP methods, in upper case.
+	Methods []string
+}
+
+// AllowOrigin reports whether a cross-origin request from origin may use method.
+// Origins in CORSOrigins may use any method; rules restrict theirs.
+func (c *Config) AllowOrigin(origin, method string) bool {
+	for _, allowed := range c.CORSOrigins {
+		if matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	for _, rule := range c.CORSRules {
+		if !matchOrigin(rule.Origin, origin) {
+			continue
+		}
+		for _, m := range rule.Methods {
+			if m == method {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchOrigin matches an origin against a pattern with at most one "*".
+func matchOrigin(pattern, origin string) bool {
+	origin = strings.ToLower(origin)
+	pattern = strings.ToLower(pattern)
+	if pattern == "*" {
+		return true
+	}
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == origin
+	}
+	return len(origin) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
+
+// parseCORSRules parses rules of the form "origin=METHOD,METHOD;origin=METHOD".
+func parseCORSRules(s string) ([]CORSRule, error) {
+	var rules []CORSRule
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		origin, methods, ok := strings.Cut(entry, "=")
+		origin = strings.TrimSpace(origin)
+		if !ok || origin == "" || strings.Count(origin, "*") > 1 {
+			return nil, fmt.Errorf("invalid CORS_ORIGIN_RULES entry %q", entry)
+		}
+		rule := CORSRule{Origin: origin}
+		for _, m := range parseCSV(methods) {
+			rule.Methods = append(rule.Methods, strings.ToUpper(m))
+		}
+		if len(rule.Methods) == 0 {
+			return nil, fmt.Errorf("CORS_ORIGIN_RULES entry %q has no methods", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
 // IsDevelopment returns true if running in development mode.
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -162,6 +495,18 @@ func getEnvInt(key string, defaultValue int) int {
 	return result
 }
 
+// getEnvBool returns the environment variable as a bool or the default.
+func getEnvBool(key string, defaultValue bool) bool {
+	switch strings.ToLower(os.Getenv(key)) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return defaultValue
+	}
+}
+
 // parseCSV splits a comma-separated string into a slice.
 func parseCSV(s string) []string {
 	if s == "" {
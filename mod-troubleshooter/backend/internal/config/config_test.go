@@ -52,6 +52,38 @@ func TestGetEnvInt(t *testing.T) {
 	}
 }
 
+func TestGetEnvBool(t *testing.T) {
+	tests := []struct {
+		name         string
+		envValue     string
+		defaultValue bool
+		want         bool
+	}{
+		{"empty uses default", "", true, true},
+		{"true", "true", false, true},
+		{"one", "1", false, true},
+		{"uppercase yes", "YES", false, true},
+		{"false", "false", true, false},
+		{"invalid uses default", "maybe", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envValue != "" {
+				os.Setenv("TEST_BOOL_VAR", tt.envValue)
+				defer os.Unsetenv("TEST_BOOL_VAR")
+			} else {
+				os.Unsetenv("TEST_BOOL_VAR")
+			}
+
+			result := getEnvBool("TEST_BOOL_VAR", tt.defaultValue)
+			if result != tt.want {
+				t.Errorf("getEnvBool() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseCSV(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -81,6 +113,51 @@ func TestParseCSV(t *testing.T) {
 	}
 }
 
+func TestParseCORSRules(t *testing.T) {
+	rules, err := parseCORSRules(" https://a.example.com = get, head ; https://*.example.org=POST;")
+	if err != nil {
+		t.Fatalf("parseCORSRules() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("parseCORSRules() len = %d, want 2", len(rules))
+	}
+	if rules[0].Origin != "https://a.example.com" || len(rules[0].Methods) != 2 || rules[0].Methods[1] != "HEAD" {
+		t.Errorf("parseCORSRules()[0] = %+v", rules[0])
+	}
+
+	for _, invalid := range []string{"https://a.example.com", "https://a.example.com=", "=GET", "https://*.*.com=GET"} {
+		if _, err := parseCORSRules(invalid); err == nil {
+			t.Errorf("parseCORSRules(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestAllowOrigin(t *testing.T) {
+	cfg := &Config{
+		CORSOrigins: []string{"http://localhost:5173", "https://*.example.com"},
+		CORSRules:   []CORSRule{{Origin: "https://viewer.example.org", Methods: []string{"GET"}}},
+	}
+
+	tests := []struct {
+		origin string
+		method string
+		want   bool
+	}{
+		{"http://localhost:5173", "POST", true},
+		{"https://mods.example.com", "DELETE", true},
+		{"https://example.com", "GET", false},
+		{"https://viewer.example.org", "GET", true},
+		{"https://viewer.example.org", "POST", false},
+		{"https://other.example.org", "GET", false},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.AllowOrigin(tt.origin, tt.method); got != tt.want {
+			t.Errorf("AllowOrigin(%q, %q) = %v, want %v", tt.origin, tt.method, got, tt.want)
+		}
+	}
+}
+
 func TestTrimQuotes(t *testing.T) {
 	tests := []struct {
 		input string
@@ -113,6 +190,15 @@ func TestLoad(t *testing.T) {
 	os.Unsetenv("CACHE_TTL_HOURS")
 	os.Unsetenv("ENVIRONMENT")
 	os.Unsetenv("CORS_ORIGINS")
+	os.Unsetenv("CORS_ORIGIN_RULES")
+	os.Unsetenv("TRUSTED_PROXIES")
+	os.Unsetenv("TLS_CERT_FILE")
+	os.Unsetenv("TLS_KEY_FILE")
+	os.Unsetenv("AUTOCERT_HOSTS")
+	os.Unsetenv("AUTOCERT_CACHE_DIR")
+	os.Unsetenv("UNIX_SOCKET")
+	os.Unsetenv("DISABLE_TCP")
+	os.Unsetenv("READ_ONLY")
 
 	cfg, err := Load()
 	if err != nil {
@@ -129,12 +215,18 @@ func TestLoad(t *testing.T) {
 	if cfg.CacheTTLHours != 168 {
 		t.Errorf("CacheTTLHours = %d, want %d", cfg.CacheTTLHours, 168)
 	}
+	if cfg.ManifestCacheTTLHours != 720 {
+		t.Errorf("ManifestCacheTTLHours = %d, want %d", cfg.ManifestCacheTTLHours, 720)
+	}
 	if cfg.Environment != "development" {
 		t.Errorf("Environment = %q, want %q", cfg.Environment, "development")
 	}
 	if len(cfg.CORSOrigins) != 2 {
 		t.Errorf("CORSOrigins len = %d, want 2", len(cfg.CORSOrigins))
 	}
+	if cfg.ReadOnly {
+		t.Error("ReadOnly = true, want false")
+	}
 }
 
 func TestValidate(t *testing.T) {
@@ -159,6 +251,118 @@ func TestValidate(t *testing.T) {
 	if err := cfg.Validate(); err != nil {
 		t.Errorf("Validate() error = %v", err)
 	}
+
+	// A certificate without its key should fail
+	cfg.TLSCertFile = "cert.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for a TLS certificate without a key")
+	}
+	cfg.TLSKeyFile = "key.pem"
+	if err := cfg.Validate(); err != nil || !cfg.TLSEnabled() {
+		t.Errorf("Validate() error = %v, TLSEnabled() = %v", err, cfg.TLSEnabled())
+	}
+
+	// Automatic certificates replace a certificate file, not both at once
+	cfg.AutocertHosts = []string{"mods.example.org"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for a certificate file with automatic certificates")
+	}
+	cfg.TLSCertFile, cfg.TLSKeyFile = "", ""
+	if err := cfg.Validate(); err != nil || !cfg.TLSEnabled() || !cfg.AutocertEnabled() {
+		t.Errorf("Validate() error = %v, TLSEnabled() = %v, AutocertEnabled() = %v", err, cfg.TLSEnabled(), cfg.AutocertEnabled())
+	}
+
+	// Disabling TCP requires a Unix socket
+	cfg.DisableTCP = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail with no listeners")
+	}
+	cfg.UnixSocket = "/tmp/mod-troubleshooter.sock"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// gRPC needs its own port while HTTP listens on TCP
+	cfg.Port, cfg.GRPCPort = "8080", "8080"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	cfg.DisableTCP = false
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for gRPC on the HTTP port")
+	}
+	cfg.GRPCPort = "9090"
+
+	// Only one virus scanner can be configured
+	cfg.ScanCommand, cfg.ClamdAddress = "clamscan", "/run/clamd.ctl"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail with two virus scanners")
+	}
+	cfg.ClamdAddress = ""
+
+	// The analysis profile must be one the pipeline defines
+	cfg.AnalysisProfile = "thorough"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for an unknown analysis profile")
+	}
+	cfg.AnalysisProfile = "deep"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Concurrency knobs are bounded
+	cfg.HashConcurrency = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for a negative concurrency")
+	}
+	cfg.HashConcurrency = maxConcurrency + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for an excessive concurrency")
+	}
+	cfg.HashConcurrency = 2
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// So can the manifest cache
+	cfg.ManifestCacheTTLHours = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for a negative manifest cache TTL")
+	}
+	cfg.ManifestCacheTTLHours = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Watches can't be checked a negative number of minutes apart
+	cfg.WatchIntervalMinutes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for a negative watch interval")
+	}
+	cfg.WatchIntervalMinutes = 60
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// The idle timeout can be turned off, but not made negative
+	cfg.IdleTimeoutMinutes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for a negative idle timeout")
+	}
+	cfg.IdleTimeoutMinutes = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Notification emails need a sender address
+	cfg.SMTPHost = "smtp.example.org"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail for an SMTP server without a sender")
+	}
+	cfg.SMTPFrom = "alerts@example.org"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
 }
 
 func TestIsDevelopment(t *testing.T) {
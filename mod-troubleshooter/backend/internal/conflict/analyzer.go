@@ -3,6 +3,7 @@ package conflict
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/mod-troubleshooter/backend/internal/manifest"
@@ -30,30 +31,44 @@ func NewAnalyzerWithRules(rules []*IncompatibilityRule) *Analyzer {
 // Analyze detects conflicts between the given mod manifests.
 // Mods are expected to be in load order (index 0 = loads first, higher index = overwrites lower).
 func (a *Analyzer) Analyze(ctx context.Context, mods []ModManifest) (*AnalysisResult, error) {
+	mods = categorize(mods)
+
+	// Build file -> mods map
+	fileMap := a.buildFileMap(mods)
+
+	totalFiles := 0
+	for _, files := range fileMap {
+		totalFiles += len(files)
+	}
+
+	return a.analyzeFileMap(ctx, mods, fileMap, len(fileMap), totalFiles)
+}
+
+// analyzeFileMap builds the analysis result from a map of paths to the mods
+// providing them. Paths with a single source are ignored, so the map only
+// needs to be complete for conflicting paths.
+func (a *Analyzer) analyzeFileMap(ctx context.Context, mods []ModManifest, fileMap map[string][]fileWithContext, uniqueFiles, totalFiles int) (*AnalysisResult, error) {
 	result := &AnalysisResult{
 		Conflicts:    make([]Conflict, 0),
 		ModSummaries: make([]ModConflictSummary, 0, len(mods)),
 		FileToMods:   make(map[string][]string),
+		Groups:       make([]ConflictGroup, 0),
 		Stats: Stats{
 			ByFileType: make(map[manifest.FileType]int),
 		},
 	}
 
-	// Build file -> mods map
-	fileMap := a.buildFileMap(mods)
-
 	// Track total files and unique files
-	result.Stats.UniqueFiles = len(fileMap)
-	for _, files := range fileMap {
-		result.Stats.TotalFiles += len(files)
-	}
+	result.Stats.UniqueFiles = uniqueFiles
+	result.Stats.TotalFiles = totalFiles
 
 	// Initialize mod summaries
 	modSummaryMap := make(map[string]*ModConflictSummary)
 	for _, mod := range mods {
 		summary := ModConflictSummary{
-			ModID:   mod.ModID,
-			ModName: mod.ModName,
+			ModID:    mod.ModID,
+			ModName:  mod.ModName,
+			Category: mod.Category,
 		}
 		modSummaryMap[mod.ModID] = &summary
 	}
@@ -69,9 +84,7 @@ func (a *Analyzer) Analyze(ctx context.Context, mods []ModManifest) (*AnalysisRe
 		}
 
 		// Sort by load order to determine winner/losers
-		sort.Slice(files, func(i, j int) bool {
-			return files[i].loadOrder < files[j].loadOrder
-		})
+		sortByPrecedence(files)
 
 		conflict := a.createConflict(path, files)
 		result.Conflicts = append(result.Conflicts, conflict)
@@ -100,6 +113,7 @@ func (a *Analyzer) Analyze(ctx context.Context, mods []ModManifest) (*AnalysisRe
 
 	// Calculate stats
 	result.Stats = a.calculateStats(result, len(mods))
+	result.Groups = groupConflicts(result.Conflicts)
 
 	// Build mod summaries list
 	for _, mod := range mods {
@@ -117,6 +131,30 @@ type fileWithContext struct {
 	loadOrder int
 }
 
+// sortByPrecedence sorts the copies of a file so the one the game uses comes
+// last. Loose files override packed ones whatever the load order; mods
+// sharing a position are ordered by ID so repeated runs pick the same winner.
+func sortByPrecedence(files []fileWithContext) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if packedI, packedJ := files[i].modFile.Archive != "", files[j].modFile.Archive != ""; packedI != packedJ {
+			return packedI
+		}
+		if files[i].loadOrder != files[j].loadOrder {
+			return files[i].loadOrder < files[j].loadOrder
+		}
+		return files[i].modFile.ModID < files[j].modFile.ModID
+	})
+}
+
+// modTags lists the Nexus tags of a mod along with its Nexus category, which
+// rules treat as one more tag.
+func modTags(mod ModManifest) []string {
+	if mod.NexusCategory == "" {
+		return mod.NexusTags
+	}
+	return append(slices.Clip(mod.NexusTags), mod.NexusCategory)
+}
+
 // buildFileMap creates a map of file paths to all mods that provide them.
 func (a *Analyzer) buildFileMap(mods []ModManifest) map[string][]fileWithContext {
 	fileMap := make(map[string][]fileWithContext)
@@ -126,6 +164,7 @@ func (a *Analyzer) buildFileMap(mods []ModManifest) map[string][]fileWithContext
 			continue
 		}
 
+		tags := modTags(mod)
 		for _, entry := range mod.Manifest.Files {
 			modFile := ModFile{
 				ModID:    mod.ModID,
@@ -134,6 +173,9 @@ func (a *Analyzer) buildFileMap(mods []ModManifest) map[string][]fileWithContext
 				Size:     entry.Size,
 				Hash:     entry.Hash,
 				FileType: entry.Type,
+				Archive:  entry.Archive,
+				Category: mod.Category,
+				Tags:     tags,
 			}
 
 			fileMap[entry.Path] = append(fileMap[entry.Path], fileWithContext{
@@ -191,6 +233,11 @@ func (a *Analyzer) createConflict(path string, files []fileWithContext) Conflict
 		Losers:      losers,
 		IsIdentical: isIdentical,
 		Message:     message,
+		Group:       conflictGroup(sources),
+	}
+	conflict.Expected = expectedOverlap(&conflict)
+	if conflict.Expected && !isIdentical {
+		conflict.Severity = SeverityLow
 	}
 
 	// Calculate score using the scorer
@@ -671,3 +671,62 @@ func TestAnalyzer_Analyze_StatsCount(t *testing.T) {
 		t.Errorf("expected ByFileType[bsa]=1, got %d", stats.ByFileType[manifest.FileTypeBSA])
 	}
 }
+
+func TestAnalyzer_Analyze_LooseOverridesPacked(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	mods := []ModManifest{
+		{
+			ModID:     "mod1",
+			ModName:   "Loose Textures",
+			LoadOrder: 0,
+			Manifest: &manifest.Manifest{
+				Files: []manifest.FileEntry{
+					{Path: "textures/shared.dds", Size: 1000, Type: manifest.FileTypeTexture},
+				},
+			},
+		},
+		{
+			ModID:     "mod2",
+			ModName:   "Packed Textures",
+			LoadOrder: 1,
+			Manifest: &manifest.Manifest{
+				Files: []manifest.FileEntry{
+					{Path: "textures/shared.dds", Size: 2000, Type: manifest.FileTypeTexture, Archive: "packed.bsa"},
+				},
+			},
+		},
+	}
+
+	result, err := analyzer.Analyze(context.Background(), mods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d", len(result.Conflicts))
+	}
+	if winner := result.Conflicts[0].Winner; winner == nil || winner.ModID != "mod1" {
+		t.Errorf("expected the loose file to win despite loading first, got %+v", winner)
+	}
+}
+
+func TestAnalyzer_ModTagsIncludeNexusCategory(t *testing.T) {
+	analyzer := NewAnalyzerWithRules([]*IncompatibilityRule{{
+		ID:         "body-and-skin",
+		ScoreBonus: 10,
+		ModTags:    []string{"Body Replacer", "Skin"},
+	}})
+	mods := []ModManifest{
+		{ModID: "a", NexusTags: []string{"Body Replacer"}, Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/body.dds", 1)})},
+		{ModID: "b", NexusCategory: "Skin", Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/body.dds", 2)})},
+	}
+
+	result, err := analyzer.Analyze(context.Background(), mods)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(result.Conflicts) != 1 || len(result.Conflicts[0].MatchedRules) != 1 {
+		t.Errorf("expected the tag rule to match via the Nexus category, got %+v", result.Conflicts)
+	}
+}
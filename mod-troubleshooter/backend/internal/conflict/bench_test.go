@@ -0,0 +1,115 @@
+package conflict
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Size of the synthetic collection the benchmarks analyze, about as large
+// as the biggest collections on Nexus.
+const (
+	benchMods  = 300
+	benchFiles = 1_000_000
+)
+
+// benchExtensions cycles each mod's files through the common asset types.
+var benchExtensions = []string{".dds", ".nif", ".pex", ".dds", ".hkx", ".wav"}
+
+// benchCollection is built once and shared by every benchmark.
+var benchCollection = sync.OnceValue(func() []ModManifest {
+	return syntheticCollection(benchMods, benchFiles)
+})
+
+// syntheticCollection builds a collection in load order where every mod
+// ships files of its own, and one in ten of its files overrides a path
+// other mods ship too, as texture and mesh replacers do.
+func syntheticCollection(mods, files int) []ModManifest {
+	perMod := files / mods
+	shared := perMod / 2
+	collection := make([]ModManifest, 0, mods)
+	for i := 0; i < mods; i++ {
+		entries := make([]manifest.FileEntry, 0, perMod+1)
+		entries = append(entries, manifest.NewFileEntry(fmt.Sprintf("Mod%03d.esp", i), 4096))
+		for j := 0; j < perMod; j++ {
+			ext := benchExtensions[j%len(benchExtensions)]
+			var path string
+			if j%10 == 0 {
+				path = fmt.Sprintf("textures/shared/%05d%s", (j/10+i*7)%shared, ext)
+			} else {
+				path = fmt.Sprintf("meshes/mod%03d/dir%02d/%05d%s", i, j%50, j, ext)
+			}
+			entries = append(entries, manifest.NewFileEntry(path, int64(1024+j)))
+		}
+		collection = append(collection, ModManifest{
+			ModID:     fmt.Sprintf("mod-%03d", i),
+			ModName:   fmt.Sprintf("Mod %03d", i),
+			Manifest:  manifest.NewManifest(entries),
+			LoadOrder: i,
+		})
+	}
+	return collection
+}
+
+func BenchmarkAnalyzer_Analyze(b *testing.B) {
+	mods := benchCollection()
+	a := NewAnalyzer()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Analyze(context.Background(), mods); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAnalysisResult_EncodeJSON(b *testing.B) {
+	result, err := NewAnalyzer().Analyze(context.Background(), benchCollection())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := json.NewEncoder(io.Discard).Encode(result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchBaselines are the slowest acceptable times per operation. They
+// leave room for slower machines, so only real regressions trip them.
+var benchBaselines = []struct {
+	name     string
+	bench    func(*testing.B)
+	baseline time.Duration
+}{
+	{"Analyzer.Analyze", BenchmarkAnalyzer_Analyze, 8 * time.Second},
+	{"AnalysisResult JSON encoding", BenchmarkAnalysisResult_EncodeJSON, time.Second},
+}
+
+// TestBenchmarkBaselines fails when a hot path got slower than its
+// baseline. It takes a while, so it only runs with BENCH_GATE=1.
+func TestBenchmarkBaselines(t *testing.T) {
+	if os.Getenv("BENCH_GATE") == "" {
+		t.Skip("set BENCH_GATE=1 to check benchmark baselines")
+	}
+
+	for _, bb := range benchBaselines {
+		r := testing.Benchmark(bb.bench)
+		got := time.Duration(r.NsPerOp())
+		t.Logf("%s: %v/op, %d allocs/op (baseline %v)", bb.name, got, r.AllocsPerOp(), bb.baseline)
+		if got > bb.baseline {
+			t.Errorf("%s took %v per operation, over its baseline of %v", bb.name, got, bb.baseline)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package conflict
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Category is a rough classification of what a mod does, used to group
+// conflicts and to tell expected overlaps from suspicious ones.
+type Category string
+
+const (
+	// CategoryTextures is a texture or mesh replacer without gameplay changes.
+	CategoryTextures Category = "texture_pack"
+	// CategoryGameplay changes game mechanics through plugins and scripts.
+	CategoryGameplay Category = "gameplay_overhaul"
+	// CategoryQuest adds quests, usually with voiced dialogue.
+	CategoryQuest Category = "quest"
+	// CategoryPatch reconciles other mods, usually with a lone plugin.
+	CategoryPatch Category = "patch"
+	// CategoryFramework is a utility or library other mods build on.
+	CategoryFramework Category = "framework"
+	// CategoryOther is anything the heuristics cannot place.
+	CategoryOther Category = "other"
+)
+
+// nexusCategoryKeywords maps words in Nexus category names to categories,
+// checked in order. Nexus categories differ per game, so whole names are
+// not matched.
+var nexusCategoryKeywords = []struct {
+	keyword  string
+	category Category
+}{
+	{"patch", CategoryPatch},
+	{"resource", CategoryFramework},
+	{"utilit", CategoryFramework},
+	{"framework", CategoryFramework},
+	{"texture", CategoryTextures},
+	{"visual", CategoryTextures},
+	{"model", CategoryTextures},
+	{"environment", CategoryTextures},
+	{"quest", CategoryQuest},
+	{"adventure", CategoryQuest},
+	{"gameplay", CategoryGameplay},
+	{"overhaul", CategoryGameplay},
+	{"combat", CategoryGameplay},
+	{"immersion", CategoryGameplay},
+	{"skills", CategoryGameplay},
+	{"magic", CategoryGameplay},
+}
+
+// Categorize classifies a mod from the make-up of its files, falling back
+// to its Nexus category and name when the files are inconclusive.
+func Categorize(m *manifest.Manifest, nexusCategory, name string) Category {
+	if c := categorizeFiles(m); c != CategoryOther {
+		return c
+	}
+	lower := strings.ToLower(nexusCategory)
+	for _, k := range nexusCategoryKeywords {
+		if strings.Contains(lower, k.keyword) {
+			return k.category
+		}
+	}
+	if strings.Contains(strings.ToLower(name), "patch") {
+		return CategoryPatch
+	}
+	return CategoryOther
+}
+
+// categorizeFiles classifies a mod by the types of the files it installs.
+func categorizeFiles(m *manifest.Manifest) Category {
+	if m == nil || m.TotalCount == 0 {
+		return CategoryOther
+	}
+
+	var plugins, scripts, assets, voices, dlls int
+	for _, f := range m.Files {
+		switch f.Type {
+		case manifest.FileTypePlugin:
+			plugins++
+		case manifest.FileTypeScript:
+			scripts++
+		case manifest.FileTypeTexture, manifest.FileTypeMesh:
+			assets++
+		case manifest.FileTypeSound:
+			if strings.Contains(f.Path, "sound/voice/") {
+				voices++
+			}
+		}
+		if strings.HasSuffix(f.Path, ".dll") {
+			dlls++
+		}
+	}
+	total := m.TotalCount
+
+	switch {
+	case dlls > 0 && assets == 0:
+		// Script extender plugins and their scripts
+		return CategoryFramework
+	case plugins > 0 && voices > 0:
+		return CategoryQuest
+	case plugins > 0 && plugins == total:
+		// A lone plugin with no assets of its own reconciles other mods
+		return CategoryPatch
+	case assets*4 >= total*3 && plugins <= 1:
+		// At most a dummy plugin to load an archive
+		return CategoryTextures
+	case plugins > 0 && scripts > 0:
+		return CategoryGameplay
+	}
+	return CategoryOther
+}
+
+// categorize returns mods with their categories filled in where missing.
+// The input slice is not modified.
+func categorize(mods []ModManifest) []ModManifest {
+	out := make([]ModManifest, len(mods))
+	for i, mod := range mods {
+		if mod.Category == "" {
+			mod.Category = Categorize(mod.Manifest, mod.NexusCategory, mod.ModName)
+		}
+		out[i] = mod
+	}
+	return out
+}
+
+// expectedOverlap reports whether a conflict between mods of these
+// categories is routine: texture packs replacing each other's textures is
+// what users install them for.
+func expectedOverlap(conflict *Conflict) bool {
+	if conflict.FileType != manifest.FileTypeTexture && conflict.FileType != manifest.FileTypeMesh {
+		return false
+	}
+	for _, src := range conflict.Sources {
+		if src.Category != CategoryTextures {
+			return false
+		}
+	}
+	return true
+}
+
+// conflictGroup names the group of a conflict from the categories of the
+// mods involved, such as "patch+texture_pack".
+func conflictGroup(sources []ModFile) string {
+	seen := make(map[Category]bool)
+	var categories []string
+	for _, src := range sources {
+		c := src.Category
+		if c == "" {
+			c = CategoryOther
+		}
+		if !seen[c] {
+			seen[c] = true
+			categories = append(categories, string(c))
+		}
+	}
+	sort.Strings(categories)
+	return strings.Join(categories, "+")
+}
+
+// groupConflicts summarizes conflicts by group, largest group first.
+func groupConflicts(conflicts []Conflict) []ConflictGroup {
+	index := make(map[string]int)
+	groups := []ConflictGroup{}
+	for _, c := range conflicts {
+		i, ok := index[c.Group]
+		if !ok {
+			i = len(groups)
+			index[c.Group] = i
+			groups = append(groups, ConflictGroup{Group: c.Group, Expected: true})
+		}
+		groups[i].Conflicts++
+		groups[i].Expected = groups[i].Expected && c.Expected
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Conflicts != groups[j].Conflicts {
+			return groups[i].Conflicts > groups[j].Conflicts
+		}
+		return groups[i].Group < groups[j].Group
+	})
+	return groups
+}
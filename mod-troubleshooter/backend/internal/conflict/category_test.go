@@ -0,0 +1,77 @@
+package conflict
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func manifestOf(paths ...string) *manifest.Manifest {
+	entries := make([]manifest.FileEntry, 0, len(paths))
+	for _, p := range paths {
+		// Without content hashes, so no two files look identical
+		entry := manifest.NewFileEntry(p, 1)
+		entry.Hash = ""
+		entries = append(entries, entry)
+	}
+	return manifest.NewManifest(entries)
+}
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		name          string
+		manifest      *manifest.Manifest
+		nexusCategory string
+		modName       string
+		want          Category
+	}{
+		{"textures", manifestOf("textures/a.dds", "textures/b.dds", "meshes/c.nif", "readme.txt"), "", "", CategoryTextures},
+		{"textures with dummy plugin", manifestOf("Tex.esp", "textures/a.dds", "textures/b.dds", "textures/c.dds", "textures/d.dds", "textures/e.dds"), "", "", CategoryTextures},
+		{"lone plugin", manifestOf("Patch.esp"), "", "", CategoryPatch},
+		{"quest", manifestOf("Quest.esp", "scripts/q.pex", "sound/voice/quest.esp/npc/line.fuz"), "", "", CategoryQuest},
+		{"gameplay", manifestOf("Combat.esp", "scripts/combat.pex", "interface/combat.swf"), "", "", CategoryGameplay},
+		{"script extender plugin", manifestOf("SKSE/Plugins/engine.dll", "SKSE/Plugins/engine.ini"), "", "", CategoryFramework},
+		{"nexus category", manifestOf("readme.txt"), "Modders Resources and Tutorials", "", CategoryFramework},
+		{"mod name", manifestOf("readme.txt"), "", "USSEP Compatibility Patch", CategoryPatch},
+		{"unknown", nil, "", "", CategoryOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Categorize(tt.manifest, tt.nexusCategory, tt.modName); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_Analyze_Categories(t *testing.T) {
+	mods := []ModManifest{
+		{ModID: "a", ModName: "Textures A", LoadOrder: 0, Manifest: manifestOf("textures/x.dds", "textures/y.dds")},
+		{ModID: "b", ModName: "Textures B", LoadOrder: 1, Manifest: manifestOf("textures/x.dds", "textures/y.dds")},
+		{ModID: "c", ModName: "Armor Overhaul", LoadOrder: 2, Category: CategoryGameplay, Manifest: manifestOf("Armor.esp", "textures/y.dds")},
+	}
+
+	result, err := NewAnalyzer().Analyze(t.Context(), mods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byPath := make(map[string]Conflict)
+	for _, c := range result.Conflicts {
+		byPath[c.Path] = c
+	}
+	x, y := byPath["textures/x.dds"], byPath["textures/y.dds"]
+	if !x.Expected || x.Group != "texture_pack" || x.Severity != SeverityLow {
+		t.Errorf("expected a routine texture pack overlap, got %+v", x)
+	}
+	if y.Expected || y.Group != "gameplay_overhaul+texture_pack" || y.Score <= x.Score {
+		t.Errorf("expected an overhaul overwriting textures to stand out, got %+v", y)
+	}
+	if len(result.Groups) != 2 || result.Groups[0].Conflicts != 1 {
+		t.Errorf("unexpected groups %+v", result.Groups)
+	}
+	if mods[0].Category != "" {
+		t.Error("expected the input mods to be left alone")
+	}
+}
@@ -0,0 +1,94 @@
+package conflict
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenMods covers overwrites, identical files, a plugin conflict and two
+// mods sharing a load order position, which must still resolve the same way.
+func goldenMods() []ModManifest {
+	entry := func(path, hash string) manifest.FileEntry {
+		e := manifest.NewFileEntry(path, int64(len(path)))
+		e.Hash = hash
+		return e
+	}
+	return []ModManifest{
+		{ModID: "100-1", ModName: "Base Textures", LoadOrder: 0, Manifest: manifest.NewManifest([]manifest.FileEntry{
+			entry("textures/armor/iron.dds", "aaa"),
+			entry("textures/armor/steel.dds", "bbb"),
+			entry("meshes/armor/iron.nif", ""),
+		})},
+		{ModID: "200-2", ModName: "Armor Retexture", LoadOrder: 1, Manifest: manifest.NewManifest([]manifest.FileEntry{
+			entry("textures/armor/iron.dds", "ccc"),
+			entry("textures/armor/steel.dds", "bbb"),
+		})},
+		{ModID: "300-3", ModName: "Patch B", LoadOrder: 2, Manifest: manifest.NewManifest([]manifest.FileEntry{
+			entry("Patch.esp", ""),
+			entry("meshes/armor/iron.nif", ""),
+		})},
+		{ModID: "300-2", ModName: "Patch A", LoadOrder: 2, Manifest: manifest.NewManifest([]manifest.FileEntry{
+			entry("Patch.esp", ""),
+		})},
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; run go test -update to accept\ngot:\n%s", path, got)
+	}
+}
+
+func TestAnalyzer_Golden(t *testing.T) {
+	ctx := context.Background()
+	analyzer := NewAnalyzer()
+
+	// Map iteration order differs between runs, so repeat to catch instability
+	var first []byte
+	for run := 0; run < 10; run++ {
+		result, err := analyzer.Analyze(ctx, goldenMods())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			t.Fatalf("failed to encode result: %v", err)
+		}
+		if first == nil {
+			first = data
+		} else if !bytes.Equal(first, data) {
+			t.Fatalf("run %d produced different output", run)
+		}
+	}
+
+	cached, err := analyzer.AnalyzeCached(ctx, goldenMods(), NewMemoryPairCache(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := json.MarshalIndent(cached, "", "  ")
+	if !bytes.Equal(first, data) {
+		t.Error("expected cached analysis to produce identical output")
+	}
+
+	checkGolden(t, "analysis.golden.json", append(first, '\n'))
+}
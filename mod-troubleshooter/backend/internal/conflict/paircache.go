@@ -0,0 +1,224 @@
+package conflict
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultMaxPairs is the default capacity of a MemoryPairCache.
+const DefaultMaxPairs = 1_000_000
+
+// PairCache stores the paths shared by two mods, so that re-analyzing a
+// collection only compares pairs involving mods that changed.
+// Keys identify a specific mod file and its contents.
+type PairCache interface {
+	// Lookup returns the shared paths of two mods and whether the pair is cached.
+	Lookup(a, b string) ([]string, bool)
+	// Store records the shared paths of two mods; nil means no overlap.
+	Store(a, b string, shared []string)
+}
+
+// MemoryPairCache is an in-memory PairCache.
+// When full, it is emptied rather than evicting pairs one by one.
+type MemoryPairCache struct {
+	mu       sync.Mutex
+	pairs    map[string][]string
+	maxPairs int
+}
+
+// NewMemoryPairCache creates an in-memory pair cache holding up to maxPairs pairs.
+// If maxPairs <= 0, DefaultMaxPairs is used.
+func NewMemoryPairCache(maxPairs int) *MemoryPairCache {
+	if maxPairs <= 0 {
+		maxPairs = DefaultMaxPairs
+	}
+	return &MemoryPairCache{pairs: make(map[string][]string), maxPairs: maxPairs}
+}
+
+// Lookup implements PairCache.
+func (c *MemoryPairCache) Lookup(a, b string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shared, ok := c.pairs[pairKey(a, b)]
+	return shared, ok
+}
+
+// Store implements PairCache.
+func (c *MemoryPairCache) Store(a, b string, shared []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pairs) >= c.maxPairs {
+		c.pairs = make(map[string][]string)
+	}
+	c.pairs[pairKey(a, b)] = shared
+}
+
+// Len returns the number of cached pairs.
+func (c *MemoryPairCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pairs)
+}
+
+// pairKey builds an order-independent key for two mods.
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "\x00" + b
+}
+
+// modKey identifies a mod's manifest in the pair cache. Mod IDs come from
+// callers and may be reused for different mods, so files are keyed by their
+// Nexus identity when known and always by a digest of their paths, which
+// also catches files re-uploaded under the same file ID.
+func modKey(m ModManifest) string {
+	digest := pathDigest(m)
+	if m.NexusModID > 0 && m.FileID > 0 {
+		return fmt.Sprintf("%s/%d/%d/%s", m.Game, m.NexusModID, m.FileID, digest)
+	}
+	return digest
+}
+
+// pathDigest hashes the distinct paths in a mod's manifest, which are all
+// that shared paths depend on.
+func pathDigest(m ModManifest) string {
+	paths := uniquePaths(m)
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, path := range paths {
+		h.Write([]byte(path))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AnalyzeCached detects conflicts like Analyze, reusing the shared paths of
+// mod pairs from the cache and computing only the pairs it is missing.
+// A nil cache falls back to Analyze.
+func (a *Analyzer) AnalyzeCached(ctx context.Context, mods []ModManifest, pairs PairCache) (*AnalysisResult, error) {
+	if pairs == nil {
+		return a.Analyze(ctx, mods)
+	}
+	mods = categorize(mods)
+
+	// Only mods with a manifest take part in conflicts
+	var idx []int
+	keys := make([]string, len(mods))
+	for i, mod := range mods {
+		if mod.Manifest != nil {
+			idx = append(idx, i)
+			keys[i] = modKey(mod)
+		}
+	}
+
+	// Reuse cached pairs and note which mods have a missing pair
+	shared := make(map[[2]int][]string)
+	missing := make(map[[2]int]bool)
+	dirty := make(map[int]bool)
+	for x, i := range idx {
+		for _, j := range idx[x+1:] {
+			paths, ok := pairs.Lookup(keys[i], keys[j])
+			if !ok {
+				missing[[2]int{i, j}] = true
+				dirty[i], dirty[j] = true, true
+				continue
+			}
+			if len(paths) > 0 {
+				shared[[2]int{i, j}] = paths
+			}
+		}
+	}
+
+	// Index the paths of mods with missing pairs
+	dirtyPaths := make(map[string][]int)
+	for _, i := range idx {
+		if !dirty[i] {
+			continue
+		}
+		for _, path := range uniquePaths(mods[i]) {
+			dirtyPaths[path] = append(dirtyPaths[path], i)
+		}
+	}
+
+	// Scan every mod against the index to compute the missing pairs
+	allPaths := make(map[string]bool)
+	totalFiles := 0
+	for _, i := range idx {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		totalFiles += len(mods[i].Manifest.Files)
+		for _, path := range uniquePaths(mods[i]) {
+			allPaths[path] = true
+			for _, d := range dirtyPaths[path] {
+				// Pairs of two dirty mods are recorded once, from the lower index
+				if d == i || (dirty[i] && d < i) {
+					continue
+				}
+				pair := [2]int{min(i, d), max(i, d)}
+				if missing[pair] {
+					shared[pair] = append(shared[pair], path)
+				}
+			}
+		}
+	}
+
+	for pair := range missing {
+		pairs.Store(keys[pair[0]], keys[pair[1]], shared[pair])
+	}
+
+	// Merge pairs into the set of mods providing each conflicting path
+	providers := make(map[string]map[int]bool)
+	for pair, paths := range shared {
+		for _, path := range paths {
+			if providers[path] == nil {
+				providers[path] = make(map[int]bool)
+			}
+			providers[path][pair[0]] = true
+			providers[path][pair[1]] = true
+		}
+	}
+
+	fileMap := make(map[string][]fileWithContext, len(providers))
+	for _, i := range idx {
+		mod := mods[i]
+		for _, entry := range mod.Manifest.Files {
+			if !providers[entry.Path][i] {
+				continue
+			}
+			fileMap[entry.Path] = append(fileMap[entry.Path], fileWithContext{
+				modFile: ModFile{
+					ModID:    mod.ModID,
+					ModName:  mod.ModName,
+					Path:     entry.Path,
+					Size:     entry.Size,
+					Hash:     entry.Hash,
+					FileType: entry.Type,
+					Archive:  entry.Archive,
+					Category: mod.Category,
+				},
+				loadOrder: mod.LoadOrder,
+			})
+		}
+	}
+
+	return a.analyzeFileMap(ctx, mods, fileMap, len(allPaths), totalFiles)
+}
+
+// uniquePaths returns the distinct paths in a mod's manifest.
+func uniquePaths(mod ModManifest) []string {
+	seen := make(map[string]bool, len(mod.Manifest.Files))
+	paths := make([]string, 0, len(mod.Manifest.Files))
+	for _, entry := range mod.Manifest.Files {
+		if !seen[entry.Path] {
+			seen[entry.Path] = true
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
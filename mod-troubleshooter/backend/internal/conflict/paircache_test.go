@@ -0,0 +1,142 @@
+package conflict
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// countingPairCache counts lookups that miss.
+type countingPairCache struct {
+	*MemoryPairCache
+	misses int
+}
+
+func (c *countingPairCache) Lookup(a, b string) ([]string, bool) {
+	shared, ok := c.MemoryPairCache.Lookup(a, b)
+	if !ok {
+		c.misses++
+	}
+	return shared, ok
+}
+
+func pairTestMod(id string, order int, paths ...string) ModManifest {
+	entries := make([]manifest.FileEntry, 0, len(paths))
+	for _, p := range paths {
+		entries = append(entries, manifest.NewFileEntry(p, 10))
+	}
+	return ModManifest{ModID: id, ModName: id, LoadOrder: order, Manifest: manifest.NewManifest(entries)}
+}
+
+func TestAnalyzer_AnalyzeCached(t *testing.T) {
+	analyzer := NewAnalyzer()
+	ctx := context.Background()
+
+	mods := []ModManifest{
+		pairTestMod("a", 0, "meshes/x.nif", "textures/x.dds", "textures/a.dds"),
+		pairTestMod("b", 1, "meshes/x.nif", "textures/b.dds"),
+		pairTestMod("c", 2, "meshes/x.nif", "textures/x.dds"),
+		{ModID: "failed", LoadOrder: 3},
+	}
+
+	cache := &countingPairCache{MemoryPairCache: NewMemoryPairCache(0)}
+	want, err := analyzer.Analyze(ctx, mods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := analyzer.AnalyzeCached(ctx, mods, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.FileToMods, want.FileToMods) {
+		t.Errorf("expected conflicts %v, got %v", want.FileToMods, got.FileToMods)
+	}
+	if got.Stats.TotalFiles != want.Stats.TotalFiles || got.Stats.UniqueFiles != want.Stats.UniqueFiles {
+		t.Errorf("expected file stats %+v, got %+v", want.Stats, got.Stats)
+	}
+	if cache.misses != 3 || cache.Len() != 3 {
+		t.Errorf("expected 3 pairs computed and cached, got %d misses and %d cached", cache.misses, cache.Len())
+	}
+
+	// A new revision swaps mod b for d; only pairs with d are recomputed
+	cache.misses = 0
+	mods[1] = pairTestMod("d", 1, "textures/a.dds")
+	want, _ = analyzer.Analyze(ctx, mods)
+	got, err = analyzer.AnalyzeCached(ctx, mods, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cache.misses != 2 {
+		t.Errorf("expected 2 pairs recomputed, got %d", cache.misses)
+	}
+	if !reflect.DeepEqual(got.FileToMods, want.FileToMods) {
+		t.Errorf("expected conflicts %v, got %v", want.FileToMods, got.FileToMods)
+	}
+	if len(got.Conflicts) != len(want.Conflicts) || got.Stats.ModsWithConflicts != want.Stats.ModsWithConflicts {
+		t.Errorf("expected %d conflicts, got %d", len(want.Conflicts), len(got.Conflicts))
+	}
+}
+
+func TestAnalyzer_AnalyzeCached_ReusedModID(t *testing.T) {
+	analyzer := NewAnalyzer()
+	ctx := context.Background()
+	cache := NewMemoryPairCache(0)
+
+	// Ad-hoc requests name mods freely; the same IDs with the same file
+	// counts and sizes must not share cached overlaps
+	first := []ModManifest{
+		pairTestMod("a", 0, "meshes/x.nif"),
+		pairTestMod("b", 1, "meshes/x.nif"),
+	}
+	second := []ModManifest{
+		pairTestMod("a", 0, "meshes/y.nif"),
+		pairTestMod("b", 1, "meshes/z.nif"),
+	}
+
+	if _, err := analyzer.AnalyzeCached(ctx, first, cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := analyzer.AnalyzeCached(ctx, second, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", got.Conflicts)
+	}
+}
+
+func TestModKey(t *testing.T) {
+	nexusMod := pairTestMod("a", 0, "meshes/x.nif")
+	nexusMod.Game, nexusMod.NexusModID, nexusMod.FileID = "skyrimspecialedition", 12604, 35407
+	other := nexusMod
+	other.FileID = 35408
+
+	if modKey(nexusMod) == modKey(other) {
+		t.Error("expected different files of a mod to have different keys")
+	}
+	renamed := nexusMod
+	renamed.ModID = "renamed"
+	if modKey(nexusMod) != modKey(renamed) {
+		t.Error("expected key to ignore the caller's mod ID")
+	}
+}
+
+func TestMemoryPairCache(t *testing.T) {
+	cache := NewMemoryPairCache(2)
+	cache.Store("b", "a", []string{"x"})
+
+	shared, ok := cache.Lookup("a", "b")
+	if !ok || !reflect.DeepEqual(shared, []string{"x"}) {
+		t.Errorf("expected pair to be found in either order, got %v %v", shared, ok)
+	}
+
+	cache.Store("a", "c", nil)
+	cache.Store("b", "c", nil)
+	if cache.Len() != 1 {
+		t.Errorf("expected cache to reset when full, got %d pairs", cache.Len())
+	}
+}
@@ -0,0 +1,58 @@
+package conflict
+
+import (
+	"sort"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// ProvidedFile is a file of the effective Data folder and the mod it comes
+// from once every mod is installed.
+type ProvidedFile struct {
+	// Path is the normalized path in the Data folder.
+	Path string `json:"path"`
+	// ModID and ModName identify the mod providing the file, the winner
+	// of any conflict over it.
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// Archive is the BSA or BA2 the file is packed in, if it is not loose.
+	Archive string `json:"archive,omitempty"`
+	// Size is the file size in bytes.
+	Size int64 `json:"size"`
+	// Hash is the content hash, if the manifest has one.
+	Hash string `json:"hash,omitempty"`
+	// Overridden are the IDs of the other mods providing the file, whose
+	// copies are not used.
+	Overridden []string `json:"overridden,omitempty"`
+}
+
+// Provenance lists every file of the effective Data folder with the mod
+// that provides it, sorted by path. Winners are picked as in Analyze.
+// Mods are expected to be in load order.
+func (a *Analyzer) Provenance(mods []ModManifest) []ProvidedFile {
+	fileMap := a.buildFileMap(mods)
+
+	files := make([]ProvidedFile, 0, len(fileMap))
+	for path, sources := range fileMap {
+		sortByPrecedence(sources)
+		winner := sources[len(sources)-1].modFile
+
+		file := ProvidedFile{
+			Path:    path,
+			ModID:   winner.ModID,
+			ModName: winner.ModName,
+			Archive: winner.Archive,
+			Size:    winner.Size,
+		}
+		if winner.Hash != manifest.ComputePathHash(path) {
+			file.Hash = winner.Hash
+		}
+		for _, loser := range sources[:len(sources)-1] {
+			file.Overridden = append(file.Overridden, loser.modFile.ModID)
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
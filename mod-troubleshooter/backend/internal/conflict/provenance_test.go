@@ -0,0 +1,39 @@
+package conflict
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func TestAnalyzer_Provenance(t *testing.T) {
+	hashed := manifest.NewFileEntry("textures/b.dds", 30)
+	hashed.Hash = "content"
+	packed := manifest.NewFileEntry("meshes/a.nif", 5)
+	packed.Archive = "late.bsa"
+
+	mods := []ModManifest{
+		{ModID: "early", ModName: "Early", LoadOrder: 0, Manifest: manifest.NewManifest([]manifest.FileEntry{
+			manifest.NewFileEntry("meshes/a.nif", 10),
+			manifest.NewFileEntry("textures/b.dds", 20),
+		})},
+		{ModID: "late", ModName: "Late", LoadOrder: 1, Manifest: manifest.NewManifest([]manifest.FileEntry{
+			hashed,
+			packed,
+			manifest.NewFileEntry("scripts/c.pex", 1),
+		})},
+	}
+
+	files := NewAnalyzer().Provenance(mods)
+
+	want := []ProvidedFile{
+		// Loose files override packed ones from later mods
+		{Path: "meshes/a.nif", ModID: "early", ModName: "Early", Size: 10, Overridden: []string{"late"}},
+		{Path: "scripts/c.pex", ModID: "late", ModName: "Late", Size: 1},
+		{Path: "textures/b.dds", ModID: "late", ModName: "Late", Size: 30, Hash: "content", Overridden: []string{"early"}},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("expected %+v, got %+v", want, files)
+	}
+}
@@ -0,0 +1,91 @@
+package conflict
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RecordPlugin is a plugin whose records were scanned.
+type RecordPlugin struct {
+	// ModID is the mod that provides the plugin.
+	ModID string
+	// ModName is the display name of the mod.
+	ModName string
+	// Plugin is the plugin filename.
+	Plugin string
+	// Masters are the plugin's masters in header order.
+	Masters []string
+	// FormIDs are the form IDs of the plugin's records, with the file-local
+	// master index in the top byte.
+	FormIDs []uint32
+}
+
+// RecordConflict is a record that more than one plugin overrides. Only the
+// last plugin's version of the record reaches the game.
+type RecordConflict struct {
+	// FormID identifies the record within its origin plugin, in hex.
+	FormID string `json:"formId"`
+	// Origin is the plugin that defines the record.
+	Origin string `json:"origin"`
+	// Plugins are the overriding plugins in load order.
+	Plugins []string `json:"plugins"`
+	// Mods are the mods providing Plugins, in the same order.
+	Mods []string `json:"mods"`
+	// Winner is the plugin whose version of the record is used.
+	Winner string `json:"winner"`
+}
+
+// recordKey identifies a record independently of load order.
+type recordKey struct {
+	origin string
+	id     uint32
+}
+
+// RecordConflicts finds records overridden by two or more plugins. Plugins
+// must be in load order. A plugin defining a record is not an override, so
+// a record edited by a single mod is not reported.
+func RecordConflicts(plugins []RecordPlugin) []RecordConflict {
+	overrides := make(map[recordKey][]int)
+	origins := make(map[string]string)
+	for i, p := range plugins {
+		for _, formID := range p.FormIDs {
+			index := int(formID >> 24)
+			if index >= len(p.Masters) {
+				// Records indexed past the masters are the plugin's own
+				continue
+			}
+			origin := strings.ToLower(p.Masters[index])
+			if _, ok := origins[origin]; !ok {
+				origins[origin] = p.Masters[index]
+			}
+			key := recordKey{origin: origin, id: formID & 0xFFFFFF}
+			if users := overrides[key]; len(users) > 0 && users[len(users)-1] == i {
+				continue
+			}
+			overrides[key] = append(overrides[key], i)
+		}
+	}
+
+	conflicts := []RecordConflict{}
+	for key, users := range overrides {
+		if len(users) < 2 {
+			continue
+		}
+		c := RecordConflict{FormID: fmt.Sprintf("%06X", key.id), Origin: origins[key.origin]}
+		for _, i := range users {
+			c.Plugins = append(c.Plugins, plugins[i].Plugin)
+			c.Mods = append(c.Mods, plugins[i].ModName)
+		}
+		c.Winner = c.Plugins[len(c.Plugins)-1]
+		conflicts = append(conflicts, c)
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Origin != conflicts[j].Origin {
+			return conflicts[i].Origin < conflicts[j].Origin
+		}
+		return conflicts[i].FormID < conflicts[j].FormID
+	})
+	return conflicts
+}
@@ -0,0 +1,42 @@
+package conflict
+
+import "testing"
+
+func TestRecordConflicts(t *testing.T) {
+	plugins := []RecordPlugin{
+		{
+			ModName: "Patch A",
+			Plugin:  "PatchA.esp",
+			Masters: []string{"Skyrim.esm"},
+			// One override of Skyrim.esm and a new record
+			FormIDs: []uint32{0x00012E46, 0x01000800},
+		},
+		{
+			ModName: "Patch B",
+			Plugin:  "PatchB.esp",
+			Masters: []string{"Skyrim.esm", "PatchA.esp"},
+			FormIDs: []uint32{0x00012E46, 0x00013BBD, 0x01000800},
+		},
+		{
+			ModName: "Patch C",
+			Plugin:  "PatchC.esp",
+			Masters: []string{"SKYRIM.ESM"},
+			FormIDs: []uint32{0x00012E46},
+		},
+	}
+
+	// PatchB.esp alone overrides the record PatchA.esp defines, which is
+	// an ordinary edit rather than a conflict
+	conflicts := RecordConflicts(plugins)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 record conflict, got %+v", conflicts)
+	}
+
+	c := conflicts[0]
+	if c.Origin != "Skyrim.esm" || c.FormID != "012E46" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+	if len(c.Plugins) != 3 || c.Winner != "PatchC.esp" || c.Mods[0] != "Patch A" {
+		t.Errorf("expected all three patches with the last winning, got %+v", c)
+	}
+}
@@ -2,6 +2,7 @@ package conflict
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/mod-troubleshooter/backend/internal/manifest"
@@ -36,6 +37,9 @@ const (
 	multiModBonus = 5
 	// ruleMatchBonus is the base bonus for matching an incompatibility rule.
 	ruleMatchBonus = 10
+	// expectedOverlapDiscount is subtracted for routine overlaps, such as
+	// between texture packs.
+	expectedOverlapDiscount = 25
 )
 
 // RuleMatchType defines how a rule matches file paths or mods.
@@ -74,6 +78,11 @@ type IncompatibilityRule struct {
 	ModPatterns []string `json:"modPatterns,omitempty"`
 	// ModMatchType defines how ModPatterns are matched.
 	ModMatchType RuleMatchType `json:"modMatchType,omitempty"`
+	// ModTags matches against the Nexus tags and categories of mods
+	// involved in the conflict, ignoring case. As with ModPatterns, ALL
+	// must match different mods, so listing a tag twice targets any two
+	// mods carrying it.
+	ModTags []string `json:"modTags,omitempty"`
 	// FileTypes restricts the rule to specific file types.
 	// Empty means all file types.
 	FileTypes []manifest.FileType `json:"fileTypes,omitempty"`
@@ -124,6 +133,11 @@ func (s *Scorer) Score(conflict *Conflict) (int, []string) {
 		score -= identicalFileDiscount
 	}
 
+	// Apply expected overlap discount
+	if conflict.Expected {
+		score -= expectedOverlapDiscount
+	}
+
 	// Apply multi-mod bonus (more mods = more complex conflict)
 	if len(conflict.Sources) > 2 {
 		score += (len(conflict.Sources) - 2) * multiModBonus
@@ -208,6 +222,11 @@ func (s *Scorer) ruleMatches(rule *IncompatibilityRule, conflict *Conflict) bool
 		}
 	}
 
+	// Check mod tags (all tags must match different mods)
+	if len(rule.ModTags) > 0 && !matchModTags(rule.ModTags, conflict.Sources) {
+		return false
+	}
+
 	return true
 }
 
@@ -271,6 +290,28 @@ func (s *Scorer) matchModPatterns(rule *IncompatibilityRule, modIDs []string) bo
 	return true
 }
 
+// matchModTags checks if each tag is carried by a different mod.
+func matchModTags(tags []string, sources []ModFile) bool {
+	matchedMods := make(map[int]bool)
+	for _, tag := range tags {
+		found := false
+		for modIdx, src := range sources {
+			if matchedMods[modIdx] {
+				continue
+			}
+			if slices.ContainsFunc(src.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+				matchedMods[modIdx] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // GetRules returns the configured incompatibility rules.
 func (s *Scorer) GetRules() []*IncompatibilityRule {
 	return s.rules
@@ -382,11 +423,11 @@ func defaultRules() []*IncompatibilityRule {
 
 		// Plugin level conflicts
 		{
-			ID:            "plugin-overwrite",
-			Name:          "Plugin Overwrite",
-			Description:   "Plugin files being overwritten may lose custom patches",
-			ScoreBonus:    10,
-			FileTypes:     []manifest.FileType{manifest.FileTypePlugin},
+			ID:          "plugin-overwrite",
+			Name:        "Plugin Overwrite",
+			Description: "Plugin files being overwritten may lose custom patches",
+			ScoreBonus:  10,
+			FileTypes:   []manifest.FileType{manifest.FileTypePlugin},
 		},
 	}
 
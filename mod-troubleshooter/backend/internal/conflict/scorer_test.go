@@ -120,11 +120,11 @@ func TestScorer_Score_RuleMatching(t *testing.T) {
 	scorer := NewScorer()
 
 	tests := []struct {
-		name          string
-		path          string
-		fileType      manifest.FileType
-		expectRules   bool
-		minRuleBonus  int
+		name         string
+		path         string
+		fileType     manifest.FileType
+		expectRules  bool
+		minRuleBonus int
 	}{
 		{
 			name:         "skyui script",
@@ -262,6 +262,58 @@ func TestNewScorerWithRules_CustomRules(t *testing.T) {
 	}
 }
 
+func TestScorer_ModTagRules(t *testing.T) {
+	scorer := NewScorerWithRules([]*IncompatibilityRule{{
+		ID:         "two-body-replacers",
+		Name:       "Two Body Replacers",
+		ScoreBonus: 20,
+		ModTags:    []string{"body replacer", "body replacer"},
+	}})
+
+	tests := []struct {
+		name    string
+		sources []ModFile
+		want    bool
+	}{
+		{
+			name: "two tagged mods",
+			sources: []ModFile{
+				{ModID: "cbbe", Tags: []string{"Body Replacer", "NSFW"}},
+				{ModID: "bhunp", Tags: []string{"Models and Textures", "BODY REPLACER"}},
+			},
+			want: true,
+		},
+		{
+			name: "one tagged mod",
+			sources: []ModFile{
+				{ModID: "cbbe", Tags: []string{"Body Replacer"}},
+				{ModID: "skin", Tags: []string{"Skin"}},
+			},
+		},
+		{
+			name: "untagged mods",
+			sources: []ModFile{
+				{ModID: "a"},
+				{ModID: "b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflict := &Conflict{
+				Path:     "meshes/actors/character/character assets/femalebody_1.nif",
+				FileType: manifest.FileTypeMesh,
+				Sources:  tt.sources,
+			}
+			_, matchedRules := scorer.Score(conflict)
+			if got := len(matchedRules) == 1; got != tt.want {
+				t.Errorf("rule matched = %v, want %v (rules %v)", got, tt.want, matchedRules)
+			}
+		})
+	}
+}
+
 func TestScorer_matchPattern(t *testing.T) {
 	scorer := NewScorer()
 
@@ -1,6 +1,9 @@
 package conflict
 
-import "github.com/mod-troubleshooter/backend/internal/manifest"
+import (
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
 
 // ConflictType represents the type of file conflict.
 type ConflictType string
@@ -42,6 +45,16 @@ type ModFile struct {
 	Hash string `json:"hash,omitempty"`
 	// FileType is the type classification of the file.
 	FileType manifest.FileType `json:"fileType"`
+	// Archive is the BSA or BA2 the file is packed in, if it is not loose.
+	Archive string `json:"archive,omitempty"`
+	// Category is the category of the mod providing the file.
+	Category Category `json:"category,omitempty"`
+	// Condition is the FOMOD choice the mod only installs the file with,
+	// if other choices would leave it out.
+	Condition *fomod.Condition `json:"condition,omitempty"`
+	// Tags are the Nexus tags and category of the mod providing the file,
+	// kept for matching rules and left out of results.
+	Tags []string `json:"-"`
 }
 
 // Conflict represents a detected file conflict between mods.
@@ -68,6 +81,16 @@ type Conflict struct {
 	IsIdentical bool `json:"isIdentical"`
 	// MatchedRules contains IDs of any incompatibility rules that matched this conflict.
 	MatchedRules []string `json:"matchedRules,omitempty"`
+	// Group names the categories of the mods involved, such as
+	// "patch+texture_pack", so related conflicts can be shown together.
+	Group string `json:"group"`
+	// Expected is true for routine overlaps, such as texture packs
+	// replacing each other's textures. They are scored lower.
+	Expected bool `json:"expected,omitempty"`
+	// OptionalDependent is true when a file involved is only installed with
+	// some FOMOD choices, so other choices avoid the conflict. The sources
+	// carry the conditions.
+	OptionalDependent bool `json:"optionalDependent,omitempty"`
 	// Message is a human-readable description of the conflict.
 	Message string `json:"message"`
 }
@@ -83,6 +106,17 @@ type ModManifest struct {
 	// LoadOrder is the mod's position in the load order (0 = loads first).
 	// Higher numbers overwrite lower numbers.
 	LoadOrder int `json:"loadOrder"`
+	// Game, NexusModID and FileID identify the mod file on Nexus, if known.
+	Game       string `json:"game,omitempty"`
+	NexusModID int    `json:"nexusModId,omitempty"`
+	FileID     int    `json:"fileId,omitempty"`
+	// NexusCategory is the mod's category on Nexus, if known.
+	NexusCategory string `json:"nexusCategory,omitempty"`
+	// NexusTags are the mod's tags on Nexus, if known.
+	NexusTags []string `json:"nexusTags,omitempty"`
+	// Category classifies the mod. It is worked out from the manifest and
+	// NexusCategory when empty.
+	Category Category `json:"category,omitempty"`
 }
 
 // Stats contains summary statistics about detected conflicts.
@@ -137,6 +171,21 @@ type ModConflictSummary struct {
 	CriticalCount int `json:"criticalCount"`
 	// HighCount is the number of high severity conflicts for this mod.
 	HighCount int `json:"highCount"`
+	// Category classifies the mod.
+	Category Category `json:"category"`
+	// ArchiveVerified is whether the mod's download matches the MD5 Nexus
+	// published for it; nil when it was not checked.
+	ArchiveVerified *bool `json:"archiveVerified,omitempty"`
+}
+
+// ConflictGroup counts the conflicts between mods of the same categories.
+type ConflictGroup struct {
+	// Group names the categories, as in Conflict.Group.
+	Group string `json:"group"`
+	// Conflicts is the number of conflicts in the group.
+	Conflicts int `json:"conflicts"`
+	// Expected is true when every conflict in the group is expected.
+	Expected bool `json:"expected"`
 }
 
 // AnalysisResult contains the complete conflict analysis results.
@@ -150,4 +199,9 @@ type AnalysisResult struct {
 	// FileToMods maps file paths to the mods that provide them.
 	// Used for quick lookups in the frontend.
 	FileToMods map[string][]string `json:"fileToMods"`
+	// Groups counts conflicts by the categories of the mods involved.
+	Groups []ConflictGroup `json:"groups"`
+	// RecordConflicts lists plugin records overridden by more than one
+	// plugin. Only deep analyses scan plugin records.
+	RecordConflicts []RecordConflict `json:"recordConflicts,omitempty"`
 }
@@ -0,0 +1,169 @@
+// Package discord is an optional Discord bot that analyzes collections on
+// request. Users post "!analyze <collection url>" in a channel the bot can
+// read; the analysis runs through the same path as the HTTP API, and the
+// health summary and top critical conflicts are posted back as a reply once
+// it finishes.
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJobTimeout bounds a single analysis; large collections take a while to download.
+const DefaultJobTimeout = 30 * time.Minute
+
+// Reconnect backoff bounds for the gateway connection.
+const (
+	minReconnectDelay = 5 * time.Second
+	maxReconnectDelay = 5 * time.Minute
+)
+
+// AnalyzeFunc analyzes a collection revision. A revision of 0 means the
+// latest published revision. Identical analyses running at the same time,
+// from the bot or the HTTP API, are expected to share their work.
+type AnalyzeFunc func(ctx context.Context, slug string, revision int) (*Report, error)
+
+// Config holds configuration for a Bot.
+type Config struct {
+	// Token is the bot token from the Discord developer portal.
+	Token string
+	// Analyze runs the analyses requested through the bot.
+	Analyze AnalyzeFunc
+	// JobTimeout bounds a single analysis (default: 30 minutes).
+	JobTimeout time.Duration
+}
+
+// job is an analysis request and the message to reply to.
+type job struct {
+	request   Request
+	channelID string
+	messageID string
+}
+
+// Bot answers analyze commands posted in Discord channels.
+type Bot struct {
+	token      string
+	gatewayURL string
+	analyze    AnalyzeFunc
+	jobTimeout time.Duration
+
+	// send posts a reply; replaced in tests
+	send func(ctx context.Context, channelID, replyTo, content string) error
+
+	// jobs tracks running analyses so Run can wait for them
+	jobs sync.WaitGroup
+}
+
+// NewBot creates a bot. Call Run to connect it.
+func NewBot(cfg Config) *Bot {
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = DefaultJobTimeout
+	}
+	return &Bot{
+		token:      cfg.Token,
+		gatewayURL: GatewayURL,
+		analyze:    cfg.Analyze,
+		jobTimeout: cfg.JobTimeout,
+		send:       newRESTClient(cfg.Token).SendMessage,
+	}
+}
+
+// Run connects to Discord and serves commands until ctx is cancelled,
+// reconnecting with backoff when the connection drops. It returns once the
+// analyses it started have stopped.
+func (b *Bot) Run(ctx context.Context) {
+	defer b.jobs.Wait()
+
+	delay := minReconnectDelay
+	for {
+		start := time.Now()
+		err := runGateway(ctx, b.gatewayURL, b.token, func(msg Message) {
+			b.handleMessage(ctx, msg)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A connection that stayed up for a while starts the backoff over
+		if time.Since(start) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+		log.Printf("Warning: Discord gateway disconnected: %v; reconnecting in %s", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// handleMessage starts an analysis for analyze commands and acknowledges them.
+func (b *Bot) handleMessage(ctx context.Context, msg Message) {
+	if msg.Author.Bot {
+		return
+	}
+
+	req, ok, err := ParseCommand(msg.Content)
+	if !ok {
+		return
+	}
+	if err != nil {
+		b.reply(ctx, msg, fmt.Sprintf("Usage: `%s <collection url> [revision]` (%v)", AnalyzeCommand, err))
+		return
+	}
+
+	b.reply(ctx, msg, fmt.Sprintf("Analyzing `%s`. This can take a few minutes.", req.Slug))
+
+	b.jobs.Add(1)
+	go func() {
+		defer b.jobs.Done()
+		b.runJob(ctx, job{request: req, channelID: msg.ChannelID, messageID: msg.ID})
+	}()
+}
+
+// runJob analyzes a request and posts the result.
+func (b *Bot) runJob(ctx context.Context, j job) {
+	jobCtx, cancel := context.WithTimeout(ctx, b.jobTimeout)
+	defer cancel()
+
+	var content string
+	report, err := b.analyze(jobCtx, j.request.Slug, j.request.Revision)
+	switch {
+	case err == nil:
+		content = FormatSummary(report)
+	case ctx.Err() != nil:
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		content = fmt.Sprintf("Analysis of `%s` timed out.", j.request.Slug)
+	default:
+		log.Printf("Error analyzing collection %s for Discord: %v", j.request.Slug, err)
+		content = fmt.Sprintf("Analysis of `%s` failed: %s", j.request.Slug, firstLine(err.Error()))
+	}
+
+	if err := b.send(ctx, j.channelID, j.messageID, content); err != nil {
+		log.Printf("Error posting Discord reply: %v", err)
+	}
+}
+
+// reply answers a message, logging failures.
+func (b *Bot) reply(ctx context.Context, msg Message, content string) {
+	if err := b.send(ctx, msg.ChannelID, msg.ID, content); err != nil {
+		log.Printf("Error posting Discord reply: %v", err)
+	}
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
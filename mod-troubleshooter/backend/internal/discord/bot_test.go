@@ -0,0 +1,131 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// sentMessage is a reply recorded by a test bot.
+type sentMessage struct {
+	channelID, replyTo, content string
+}
+
+// newTestBot creates a bot that records its replies instead of posting them.
+func newTestBot(analyze AnalyzeFunc) (*Bot, *[]sentMessage, *sync.Mutex) {
+	var mu sync.Mutex
+	var sent []sentMessage
+	b := NewBot(Config{Token: "token", Analyze: analyze})
+	b.send = func(ctx context.Context, channelID, replyTo, content string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, sentMessage{channelID, replyTo, content})
+		return nil
+	}
+	return b, &sent, &mu
+}
+
+func testMessage(id, content string) Message {
+	return Message{ID: id, ChannelID: "chan", Content: content}
+}
+
+func TestBot_HandleMessage(t *testing.T) {
+	analyze := func(ctx context.Context, slug string, revision int) (*Report, error) {
+		if slug == "broken" {
+			return nil, errors.New("fetch collection: not found\ndetails")
+		}
+		return &Report{Slug: slug, Revision: 4}, nil
+	}
+	b, sent, mu := newTestBot(analyze)
+	ctx := context.Background()
+
+	b.handleMessage(ctx, testMessage("1", "just chatting"))
+	bot := testMessage("2", "!analyze abc")
+	bot.Author.Bot = true
+	b.handleMessage(ctx, bot)
+	if len(*sent) != 0 {
+		t.Fatalf("expected no replies to chat or bots, got %+v", *sent)
+	}
+
+	b.handleMessage(ctx, testMessage("3", "!analyze"))
+	if len(*sent) != 1 || !strings.Contains((*sent)[0].content, "Usage:") || (*sent)[0].replyTo != "3" {
+		t.Fatalf("expected usage reply, got %+v", *sent)
+	}
+
+	b.handleMessage(ctx, testMessage("4", "!analyze abc"))
+	b.handleMessage(ctx, testMessage("5", "!analyze broken"))
+	b.jobs.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	replies := make(map[string][]string)
+	for _, m := range (*sent)[1:] {
+		replies[m.replyTo] = append(replies[m.replyTo], m.content)
+	}
+	if got := replies["4"]; len(got) != 2 || got[0] != "Analyzing `abc`. This can take a few minutes." ||
+		!strings.HasPrefix(got[1], "**Collection `abc` revision 4**") {
+		t.Errorf("expected acknowledgement and summary, got %q", got)
+	}
+	if got := replies["5"]; len(got) != 2 || got[1] != "Analysis of `broken` failed: fetch collection: not found" {
+		t.Errorf("expected acknowledgement and failure, got %q", got)
+	}
+}
+
+func TestBot_RunJob_Timeout(t *testing.T) {
+	analyze := func(ctx context.Context, slug string, revision int) (*Report, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	b, sent, _ := newTestBot(analyze)
+	b.jobTimeout = time.Millisecond
+
+	b.runJob(context.Background(), job{request: Request{Slug: "abc"}, channelID: "chan", messageID: "1"})
+	if len(*sent) != 1 || (*sent)[0].content != "Analysis of `abc` timed out." {
+		t.Errorf("expected timeout reply, got %+v", *sent)
+	}
+}
+
+func TestRunGateway(t *testing.T) {
+	identified := make(chan map[string]interface{}, 1)
+
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, `{"op":10,"d":{"heartbeat_interval":60000}}`)
+
+		p, err := readPayload(ws)
+		if err != nil || p.Op != gatewayIdentify {
+			t.Errorf("expected identify, got %+v (%v)", p, err)
+			return
+		}
+		var d map[string]interface{}
+		json.Unmarshal(p.D, &d)
+		identified <- d
+
+		websocket.Message.Send(ws, `{"op":0,"s":1,"t":"MESSAGE_CREATE","d":{"id":"9","channel_id":"c","content":"!analyze abc","author":{"id":"u"}}}`)
+		websocket.Message.Send(ws, `{"op":7,"d":null}`)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var got []Message
+	err := runGateway(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), "secret", func(msg Message) {
+		got = append(got, msg)
+	})
+	if !errors.Is(err, errReconnect) {
+		t.Errorf("expected reconnect request, got %v", err)
+	}
+
+	d := <-identified
+	if d["token"] != "secret" {
+		t.Errorf("expected identify with token, got %v", d)
+	}
+	if len(got) != 1 || got[0].Content != "!analyze abc" || got[0].ChannelID != "c" {
+		t.Errorf("expected one dispatched message, got %+v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package discord
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AnalyzeCommand is the command that asks the bot to analyze a collection.
+const AnalyzeCommand = "!analyze"
+
+// ErrInvalidCollection is returned for a command whose argument is not a
+// collection URL or slug.
+var ErrInvalidCollection = errors.New("expected a Nexus Mods collection URL or slug")
+
+// collectionURLPattern matches nexusmods.com/.../collections/{slug}, with an
+// optional /revisions/{n} suffix.
+var collectionURLPattern = regexp.MustCompile(`(?i)nexusmods\.com/(?:[^/\s]+/)+collections/([^/?#\s]+)(?:/revisions/(\d+))?`)
+
+// slugPattern matches a bare collection slug.
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// Request is a parsed analyze command.
+type Request struct {
+	// Slug is the collection slug.
+	Slug string
+	// Revision is the revision to analyze; 0 means the latest published revision.
+	Revision int
+}
+
+// ParseCommand parses an "!analyze <collection url>" message. It returns
+// ok=false for messages that are not analyze commands, and an error for
+// commands whose argument cannot be understood. A revision can be given as
+// a /revisions/{n} URL suffix, a ?revision=n query parameter, or a second argument.
+func ParseCommand(content string) (req Request, ok bool, err error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], AnalyzeCommand) {
+		return Request{}, false, nil
+	}
+	if len(fields) < 2 {
+		return Request{}, true, ErrInvalidCollection
+	}
+
+	// Discord users can wrap links in <> to suppress embeds
+	arg := strings.Trim(fields[1], "<>")
+
+	if m := collectionURLPattern.FindStringSubmatch(arg); m != nil {
+		req.Slug = m[1]
+		if m[2] != "" {
+			req.Revision, _ = strconv.Atoi(m[2])
+		} else if u, err := url.Parse(arg); err == nil {
+			req.Revision, _ = strconv.Atoi(u.Query().Get("revision"))
+		}
+	} else if slugPattern.MatchString(arg) {
+		req.Slug = arg
+	} else {
+		return Request{}, true, ErrInvalidCollection
+	}
+
+	if len(fields) > 2 && req.Revision == 0 {
+		revision, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(fields[2]), "r"))
+		if err != nil || revision <= 0 {
+			return Request{}, true, errors.New("revision must be a positive number")
+		}
+		req.Revision = revision
+	}
+	if req.Revision < 0 {
+		req.Revision = 0
+	}
+
+	return req, true, nil
+}
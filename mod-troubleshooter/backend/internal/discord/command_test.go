@@ -0,0 +1,40 @@
+package discord
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		ok       bool
+		wantErr  bool
+		slug     string
+		revision int
+	}{
+		{"not a command", "hello there", false, false, "", 0},
+		{"other command", "!analyzer abc", false, false, "", 0},
+		{"missing argument", "!analyze", true, true, "", 0},
+		{"collection url", "!analyze https://next.nexusmods.com/skyrimspecialedition/collections/qdurkx", true, false, "qdurkx", 0},
+		{"games url with revision", "!analyze https://www.nexusmods.com/games/skyrimspecialedition/collections/qdurkx/revisions/42", true, false, "qdurkx", 42},
+		{"revision query", "!analyze <https://next.nexusmods.com/skyrimspecialedition/collections/qdurkx?tab=mods&revision=7>", true, false, "qdurkx", 7},
+		{"bare slug", "!ANALYZE qdurkx", true, false, "qdurkx", 0},
+		{"slug with revision argument", "!analyze qdurkx r12", true, false, "qdurkx", 12},
+		{"invalid revision argument", "!analyze qdurkx latest", true, true, "", 0},
+		{"not a collection", "!analyze https://example.com/mods/1", true, true, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, ok, err := ParseCommand(tt.content)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if req.Slug != tt.slug || req.Revision != tt.revision {
+				t.Errorf("expected %s revision %d, got %s revision %d", tt.slug, tt.revision, req.Slug, req.Revision)
+			}
+		})
+	}
+}
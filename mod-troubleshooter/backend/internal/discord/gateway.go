@@ -0,0 +1,213 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// GatewayURL is the Discord gateway endpoint, using API version 10 with JSON encoding.
+const GatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+
+// gatewayOrigin is the Origin header sent when connecting; Discord ignores it.
+const gatewayOrigin = "https://localhost/"
+
+// maxGatewayMessage bounds a single gateway message. Ready and guild create
+// events for large servers can be a few megabytes.
+const maxGatewayMessage = 16 << 20
+
+// Gateway opcodes.
+const (
+	gatewayDispatch       = 0
+	gatewayHeartbeat      = 1
+	gatewayIdentify       = 2
+	gatewayReconnect      = 7
+	gatewayInvalidSession = 9
+	gatewayHello          = 10
+	gatewayHeartbeatAck   = 11
+)
+
+// Gateway intents the bot subscribes to.
+const (
+	intentGuildMessages  = 1 << 9
+	intentDirectMessages = 1 << 12
+	// intentMessageContent is privileged and must be enabled for the bot in
+	// the Discord developer portal, or message content arrives empty.
+	intentMessageContent = 1 << 15
+)
+
+// errReconnect is returned when Discord asks the bot to reconnect.
+var errReconnect = errors.New("gateway requested reconnect")
+
+// gatewayPayload is the envelope of every gateway message.
+type gatewayPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+// Message is the part of a Discord message the bot reads.
+type Message struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	Content   string `json:"content"`
+	Author    struct {
+		ID  string `json:"id"`
+		Bot bool   `json:"bot"`
+	} `json:"author"`
+}
+
+// runGateway connects to the gateway and calls onMessage for every message
+// the bot can see, until the connection fails or ctx is cancelled. Sessions
+// are not resumed; after a disconnect the caller reconnects and identifies anew.
+func runGateway(ctx context.Context, gatewayURL, token string, onMessage func(Message)) error {
+	config, err := websocket.NewConfig(gatewayURL, gatewayOrigin)
+	if err != nil {
+		return fmt.Errorf("connect to gateway: %w", err)
+	}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to gateway: %w", err)
+	}
+	conn.MaxPayloadBytes = maxGatewayMessage
+	defer conn.Close()
+
+	// Unblock the read loop when the bot is stopped
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// hbCtx stops the heartbeat when this connection ends
+	hbCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var hello struct {
+		HeartbeatInterval int `json:"heartbeat_interval"`
+	}
+	first, err := readPayload(conn)
+	if err != nil {
+		return err
+	}
+	if first.Op != gatewayHello {
+		return fmt.Errorf("expected gateway hello, got opcode %d", first.Op)
+	}
+	if err := json.Unmarshal(first.D, &hello); err != nil || hello.HeartbeatInterval <= 0 {
+		return fmt.Errorf("invalid gateway hello: %s", first.D)
+	}
+
+	identify := map[string]interface{}{
+		"token":   token,
+		"intents": intentGuildMessages | intentDirectMessages | intentMessageContent,
+		"properties": map[string]string{
+			"os":      "linux",
+			"browser": "mod-troubleshooter",
+			"device":  "mod-troubleshooter",
+		},
+	}
+	if err := sendPayload(conn, gatewayIdentify, identify); err != nil {
+		return fmt.Errorf("identify: %w", err)
+	}
+
+	// The heartbeat carries the last sequence number seen by the read loop
+	seq := make(chan int64, 1)
+	heartbeatErr := make(chan error, 1)
+	go func() {
+		heartbeatErr <- heartbeat(hbCtx, conn, time.Duration(hello.HeartbeatInterval)*time.Millisecond, seq)
+	}()
+
+	var lastSeq *int64
+	for {
+		p, err := readPayload(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			select {
+			case hbErr := <-heartbeatErr:
+				return hbErr
+			default:
+				return err
+			}
+		}
+
+		if p.S != nil {
+			lastSeq = p.S
+			// Keep only the latest sequence number for the heartbeat
+			select {
+			case <-seq:
+			default:
+			}
+			seq <- *p.S
+		}
+
+		switch p.Op {
+		case gatewayDispatch:
+			if p.T == "MESSAGE_CREATE" {
+				var msg Message
+				if err := json.Unmarshal(p.D, &msg); err == nil {
+					onMessage(msg)
+				}
+			}
+		case gatewayHeartbeat:
+			if err := sendPayload(conn, gatewayHeartbeat, lastSeq); err != nil {
+				return err
+			}
+		case gatewayReconnect:
+			return errReconnect
+		case gatewayInvalidSession:
+			return errors.New("gateway session invalidated")
+		}
+	}
+}
+
+// heartbeat sends a heartbeat every interval until ctx is cancelled or a send fails.
+func heartbeat(ctx context.Context, conn *websocket.Conn, interval time.Duration, seq <-chan int64) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last *int64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s := <-seq:
+			last = &s
+		case <-ticker.C:
+			if err := sendPayload(conn, gatewayHeartbeat, last); err != nil {
+				conn.Close()
+				return fmt.Errorf("send heartbeat: %w", err)
+			}
+		}
+	}
+}
+
+// readPayload reads and decodes the next gateway message.
+func readPayload(conn *websocket.Conn) (*gatewayPayload, error) {
+	var data []byte
+	if err := websocket.Message.Receive(conn, &data); err != nil {
+		return nil, err
+	}
+	var p gatewayPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decode gateway message: %w", err)
+	}
+	return &p, nil
+}
+
+// sendPayload encodes and sends a gateway message. The connection
+// serializes writes, so the read loop and heartbeat can both send.
+func sendPayload(conn *websocket.Conn, op int, d interface{}) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	msg, err := json.Marshal(gatewayPayload{Op: op, D: data})
+	if err != nil {
+		return err
+	}
+	return websocket.Message.Send(conn, string(msg))
+}
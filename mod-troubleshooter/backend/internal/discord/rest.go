@@ -0,0 +1,92 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// APIBase is the Discord REST API root.
+const APIBase = "https://discord.com/api/v10"
+
+// maxMessageLength is the longest message content Discord accepts.
+const maxMessageLength = 2000
+
+// restClient posts messages through the Discord REST API.
+type restClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// newRESTClient creates a REST client authenticated as a bot.
+func newRESTClient(token string) *restClient {
+	return &restClient{
+		baseURL:    APIBase,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SendMessage posts a message to a channel, as a reply when replyTo is set.
+// Mentions in the content never ping anyone.
+func (c *restClient) SendMessage(ctx context.Context, channelID, replyTo, content string) error {
+	body := map[string]interface{}{
+		"content":          truncate(content, maxMessageLength),
+		"allowed_mentions": map[string][]string{"parse": {}},
+	}
+	if replyTo != "" {
+		body["message_reference"] = map[string]interface{}{
+			"message_id":         replyTo,
+			"fail_if_not_exists": false,
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/channels/"+channelID+"/messages", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DiscordBot (https://github.com/mod-troubleshooter, 1.0)")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("send message: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence,
+// marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	const ellipsis = "…"
+	cut := n - len(ellipsis)
+	for cut > 0 && !isRuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + ellipsis
+}
+
+// isRuneStart reports whether b can begin a UTF-8 sequence.
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/health"
+)
+
+// maxSummaryConflicts limits how many critical conflicts are listed in a reply.
+const maxSummaryConflicts = 5
+
+// Report is the analysis of a collection revision the bot summarizes.
+type Report struct {
+	Slug       string
+	Revision   int
+	GameDomain string
+	// Health is the collection health report, if the health analyzer ran.
+	Health *health.Report
+	// Conflicts is the file conflict analysis, if the conflict analyzer ran.
+	Conflicts *conflict.AnalysisResult
+	// Warnings is the number of mods whose data was incomplete.
+	Warnings int
+}
+
+// ratingLabels are the display names of health ratings.
+var ratingLabels = map[health.Rating]string{
+	health.RatingHealthy:        "healthy",
+	health.RatingNeedsAttention: "needs attention",
+	health.RatingBroken:         "broken",
+}
+
+// FormatSummary renders a report as a Discord message: the health summary,
+// conflict counts and the highest scoring critical conflicts.
+func FormatSummary(r *Report) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "**Collection `%s` revision %d**", r.Slug, r.Revision)
+	if r.GameDomain != "" {
+		fmt.Fprintf(&b, " (%s)", r.GameDomain)
+	}
+	b.WriteString("\n")
+
+	if h := r.Health; h != nil {
+		label := ratingLabels[h.Rating]
+		if label == "" {
+			label = string(h.Rating)
+		}
+		fmt.Fprintf(&b, "Health: **%d/100**, %s. %d errors, %d warnings across %d mods", h.Score, label, h.ErrorCount, h.WarningCount, h.ModsTotal)
+		if h.ModsFailed > 0 {
+			fmt.Fprintf(&b, " (%d could not be analyzed)", h.ModsFailed)
+		}
+		b.WriteString(".\n")
+	}
+
+	if c := r.Conflicts; c != nil {
+		s := c.Stats
+		fmt.Fprintf(&b, "Conflicts: %d total, %d critical, %d high, %d medium.\n", s.TotalConflicts, s.CriticalCount, s.HighCount, s.MediumCount)
+
+		critical := topCritical(c.Conflicts, maxSummaryConflicts)
+		if len(critical) > 0 {
+			b.WriteString("\nTop critical conflicts:\n")
+			for _, cf := range critical {
+				fmt.Fprintf(&b, "- `%s` (score %d)", cf.Path, cf.Score)
+				if cf.Message != "" {
+					fmt.Fprintf(&b, ": %s", cf.Message)
+				}
+				b.WriteString("\n")
+			}
+			if more := s.CriticalCount - len(critical); more > 0 {
+				fmt.Fprintf(&b, "…and %d more.\n", more)
+			}
+		}
+	}
+
+	if r.Warnings > 0 {
+		fmt.Fprintf(&b, "\nResults are partial: %d mods could not be fully read.\n", r.Warnings)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// topCritical returns up to n critical conflicts, highest score first.
+func topCritical(conflicts []conflict.Conflict, n int) []conflict.Conflict {
+	var critical []conflict.Conflict
+	for _, c := range conflicts {
+		if c.Severity == conflict.SeverityCritical {
+			critical = append(critical, c)
+		}
+	}
+	sort.SliceStable(critical, func(i, j int) bool {
+		return critical[i].Score > critical[j].Score
+	})
+	if len(critical) > n {
+		critical = critical[:n]
+	}
+	return critical
+}
@@ -0,0 +1,86 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/health"
+)
+
+func TestFormatSummary(t *testing.T) {
+	var conflicts []conflict.Conflict
+	for i := 0; i < 7; i++ {
+		conflicts = append(conflicts, conflict.Conflict{
+			Path:     fmt.Sprintf("scripts/s%d.pex", i),
+			Severity: conflict.SeverityCritical,
+			Score:    90 + i,
+			Message:  "Script overwritten",
+		})
+	}
+	conflicts = append(conflicts, conflict.Conflict{Path: "textures/a.dds", Severity: conflict.SeverityHigh, Score: 99})
+
+	report := &Report{
+		Slug:       "qdurkx",
+		Revision:   3,
+		GameDomain: "skyrimspecialedition",
+		Health: &health.Report{
+			Score:        70,
+			Rating:       health.RatingNeedsAttention,
+			ModsTotal:    120,
+			ModsFailed:   1,
+			ErrorCount:   1,
+			WarningCount: 3,
+		},
+		Conflicts: &conflict.AnalysisResult{
+			Conflicts: conflicts,
+			Stats:     conflict.Stats{TotalConflicts: 8, CriticalCount: 7, HighCount: 1},
+		},
+		Warnings: 2,
+	}
+
+	got := FormatSummary(report)
+
+	for _, want := range []string{
+		"**Collection `qdurkx` revision 3** (skyrimspecialedition)",
+		"Health: **70/100**, needs attention. 1 errors, 3 warnings across 120 mods (1 could not be analyzed).",
+		"Conflicts: 8 total, 7 critical, 1 high, 0 medium.",
+		"- `scripts/s6.pex` (score 96): Script overwritten",
+		"…and 2 more.",
+		"Results are partial: 2 mods could not be fully read.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	// Only the five highest scoring critical conflicts are listed
+	if strings.Contains(got, "s1.pex") || strings.Contains(got, "textures/a.dds") {
+		t.Errorf("expected only top critical conflicts, got:\n%s", got)
+	}
+	if strings.Index(got, "s6.pex") > strings.Index(got, "s2.pex") {
+		t.Errorf("expected conflicts sorted by score, got:\n%s", got)
+	}
+}
+
+func TestFormatSummary_NoResults(t *testing.T) {
+	got := FormatSummary(&Report{Slug: "abc", Revision: 1})
+	if got != "**Collection `abc` revision 1**" {
+		t.Errorf("expected header only, got %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("expected short string unchanged, got %q", got)
+	}
+
+	got := truncate(strings.Repeat("é", 10), 8)
+	if len(got) > 8 || !strings.HasSuffix(got, "…") {
+		t.Errorf("expected at most 8 bytes ending in an ellipsis, got %q", got)
+	}
+	if got != "éé…" {
+		t.Errorf("expected cut on a rune boundary, got %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+// LOOT message types understood by the userlist format.
+const (
+	// LOOTMessageSay is an informational note.
+	LOOTMessageSay = "say"
+	// LOOTMessageWarn is a warning shown in yellow.
+	LOOTMessageWarn = "warn"
+	// LOOTMessageError is an error shown in red.
+	LOOTMessageError = "error"
+)
+
+// LOOTMessage is a message attached to a plugin entry.
+type LOOTMessage struct {
+	// Type is one of say, warn, or error.
+	Type string `json:"type"`
+	// Content is the message text.
+	Content string `json:"content"`
+}
+
+// LOOTPluginEntry holds userlist metadata for a single plugin.
+type LOOTPluginEntry struct {
+	// Name is the plugin filename.
+	Name string `json:"name"`
+	// After lists plugins this plugin must load after.
+	After []string `json:"after,omitempty"`
+	// Req lists plugins this plugin requires to be present.
+	Req []string `json:"req,omitempty"`
+	// Msg lists messages to display for this plugin.
+	Msg []LOOTMessage `json:"msg,omitempty"`
+}
+
+// LOOTUserlist represents the contents of a LOOT userlist.yaml file.
+type LOOTUserlist struct {
+	// Plugins contains one entry per plugin with at least one rule or message.
+	Plugins []LOOTPluginEntry `json:"plugins"`
+}
+
+// NewLOOTUserlist builds userlist entries from load order analysis findings.
+// Wrong-order issues become load after rules, missing masters become
+// requirements, and every issue is attached as a message.
+// Entries are emitted in load order so output is stable between runs.
+func NewLOOTUserlist(result *loadorder.AnalysisResult) *LOOTUserlist {
+	userlist := &LOOTUserlist{
+		Plugins: make([]LOOTPluginEntry, 0),
+	}
+	if result == nil {
+		return userlist
+	}
+
+	// Group issues by plugin (case-insensitive, like the analyzer)
+	issuesByPlugin := make(map[string][]loadorder.Issue)
+	for _, issue := range result.Issues {
+		key := strings.ToLower(issue.Plugin)
+		issuesByPlugin[key] = append(issuesByPlugin[key], issue)
+	}
+
+	for _, p := range result.Plugins {
+		issues := issuesByPlugin[strings.ToLower(p.Filename)]
+		if len(issues) == 0 {
+			continue
+		}
+
+		entry := LOOTPluginEntry{Name: p.Filename}
+		for _, issue := range issues {
+			switch issue.Type {
+			case loadorder.IssueWrongOrder:
+				entry.After = appendUnique(entry.After, issue.RelatedPlugin)
+			case loadorder.IssueMissingMaster, loadorder.IssueMissingCreationClub:
+				entry.Req = appendUnique(entry.Req, issue.RelatedPlugin)
+			}
+
+			entry.Msg = append(entry.Msg, LOOTMessage{
+				Type:    lootMessageType(issue.Severity),
+				Content: issue.Message,
+			})
+		}
+
+		userlist.Plugins = append(userlist.Plugins, entry)
+	}
+
+	return userlist
+}
+
+// WriteYAML writes the userlist in LOOT's YAML format.
+func (u *LOOTUserlist) WriteYAML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if len(u.Plugins) == 0 {
+		fmt.Fprintln(bw, "plugins: []")
+		return bw.Flush()
+	}
+
+	fmt.Fprintln(bw, "plugins:")
+	for _, p := range u.Plugins {
+		fmt.Fprintf(bw, "  - name: %s\n", yamlQuote(p.Name))
+
+		if len(p.After) > 0 {
+			fmt.Fprintln(bw, "    after:")
+			for _, name := range p.After {
+				fmt.Fprintf(bw, "      - %s\n", yamlQuote(name))
+			}
+		}
+
+		if len(p.Req) > 0 {
+			fmt.Fprintln(bw, "    req:")
+			for _, name := range p.Req {
+				fmt.Fprintf(bw, "      - %s\n", yamlQuote(name))
+			}
+		}
+
+		if len(p.Msg) > 0 {
+			fmt.Fprintln(bw, "    msg:")
+			for _, m := range p.Msg {
+				fmt.Fprintf(bw, "      - type: %s\n", m.Type)
+				fmt.Fprintf(bw, "        content: %s\n", yamlQuote(m.Content))
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// lootMessageType maps an issue severity to a LOOT message type.
+func lootMessageType(severity loadorder.IssueSeverity) string {
+	switch severity {
+	case loadorder.SeverityCritical, loadorder.SeverityError:
+		return LOOTMessageError
+	case loadorder.SeverityWarning:
+		return LOOTMessageWarn
+	default:
+		return LOOTMessageSay
+	}
+}
+
+// yamlQuote returns s as a single-quoted YAML scalar.
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// appendUnique appends value to list unless it is already present (case-insensitive).
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, existing := range list {
+		if strings.EqualFold(existing, value) {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+func TestNewLOOTUserlist_RulesFromIssues(t *testing.T) {
+	result := &loadorder.AnalysisResult{
+		Plugins: []loadorder.PluginInfo{
+			{Filename: "Skyrim.esm", Index: 0},
+			{Filename: "MyMod.esp", Index: 1},
+			{Filename: "Update.esm", Index: 2},
+		},
+		Issues: []loadorder.Issue{
+			{
+				Type:          loadorder.IssueWrongOrder,
+				Severity:      loadorder.SeverityError,
+				Plugin:        "MyMod.esp",
+				RelatedPlugin: "Update.esm",
+				Message:       "Master Update.esm loads after this plugin",
+				Index:         1,
+			},
+			{
+				Type:          loadorder.IssueMissingMaster,
+				Severity:      loadorder.SeverityError,
+				Plugin:        "MyMod.esp",
+				RelatedPlugin: "Missing.esm",
+				Message:       "Missing required master: Missing.esm",
+				Index:         1,
+			},
+		},
+	}
+
+	userlist := NewLOOTUserlist(result)
+
+	if len(userlist.Plugins) != 1 {
+		t.Fatalf("expected 1 plugin entry, got %d", len(userlist.Plugins))
+	}
+
+	entry := userlist.Plugins[0]
+	if entry.Name != "MyMod.esp" {
+		t.Errorf("expected entry for MyMod.esp, got %s", entry.Name)
+	}
+	if len(entry.After) != 1 || entry.After[0] != "Update.esm" {
+		t.Errorf("expected after [Update.esm], got %v", entry.After)
+	}
+	if len(entry.Req) != 1 || entry.Req[0] != "Missing.esm" {
+		t.Errorf("expected req [Missing.esm], got %v", entry.Req)
+	}
+	if len(entry.Msg) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(entry.Msg))
+	}
+	if entry.Msg[0].Type != LOOTMessageError {
+		t.Errorf("expected message type %s, got %s", LOOTMessageError, entry.Msg[0].Type)
+	}
+}
+
+func TestNewLOOTUserlist_NilResult(t *testing.T) {
+	userlist := NewLOOTUserlist(nil)
+	if userlist == nil || len(userlist.Plugins) != 0 {
+		t.Errorf("expected empty userlist, got %+v", userlist)
+	}
+}
+
+func TestLOOTUserlist_WriteYAML(t *testing.T) {
+	userlist := &LOOTUserlist{
+		Plugins: []LOOTPluginEntry{
+			{
+				Name:  "Bob's Mod.esp",
+				After: []string{"Update.esm"},
+				Msg:   []LOOTMessage{{Type: LOOTMessageWarn, Content: "Check order"}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := userlist.WriteYAML(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `plugins:
+  - name: 'Bob''s Mod.esp'
+    after:
+      - 'Update.esm'
+    msg:
+      - type: warn
+        content: 'Check order'
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected YAML output:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestLOOTUserlist_WriteYAML_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewLOOTUserlist(&loadorder.AnalysisResult{}).WriteYAML(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "plugins: []" {
+		t.Errorf("expected empty plugin list, got %q", buf.String())
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+// MO2 note colors, chosen to match the severity palette used in the UI.
+const (
+	MO2ColorCritical = "#d32f2f"
+	MO2ColorHigh     = "#f57c00"
+	MO2ColorMedium   = "#fbc02d"
+	MO2ColorNone     = ""
+)
+
+// maxCriticalPathsInNote limits how many critical paths are listed per note.
+const maxCriticalPathsInNote = 5
+
+// mo2CSVHeader is the column layout of the exported notes file.
+var mo2CSVHeader = []string{"Mod Name", "Mod ID", "Color", "Notes"}
+
+// MO2Note is an annotation for a single mod in Mod Organizer 2.
+type MO2Note struct {
+	// ModID is the mod's identifier from the analysis.
+	ModID string `json:"modId"`
+	// ModName is the display name, which MO2 matches against its mod list.
+	ModName string `json:"modName"`
+	// Color is a hex color for the mod row, empty if no highlight is needed.
+	Color string `json:"color,omitempty"`
+	// Notes is the text placed in the mod's notes field.
+	Notes string `json:"notes"`
+}
+
+// NewMO2Notes builds per-mod notes from conflict analysis results.
+// Notes are emitted in the order of the result's mod summaries (load order).
+func NewMO2Notes(result *conflict.AnalysisResult) []MO2Note {
+	notes := make([]MO2Note, 0)
+	if result == nil {
+		return notes
+	}
+
+	// Collect critical conflict paths per mod
+	criticalPaths := make(map[string][]string)
+	for _, c := range result.Conflicts {
+		if c.Severity != conflict.SeverityCritical {
+			continue
+		}
+		for _, source := range c.Sources {
+			criticalPaths[source.ModID] = append(criticalPaths[source.ModID], c.Path)
+		}
+	}
+
+	for _, summary := range result.ModSummaries {
+		note := MO2Note{
+			ModID:   summary.ModID,
+			ModName: summary.ModName,
+			Color:   mo2Color(summary),
+		}
+
+		if summary.TotalConflicts == 0 {
+			note.Notes = "No file conflicts"
+			notes = append(notes, note)
+			continue
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d conflict(s): wins %d, loses %d", summary.TotalConflicts, summary.WinCount, summary.LoseCount)
+		if summary.CriticalCount > 0 || summary.HighCount > 0 {
+			fmt.Fprintf(&sb, " (%d critical, %d high)", summary.CriticalCount, summary.HighCount)
+		}
+
+		if paths := criticalPaths[summary.ModID]; len(paths) > 0 {
+			shown := paths
+			if len(shown) > maxCriticalPathsInNote {
+				shown = shown[:maxCriticalPathsInNote]
+			}
+			fmt.Fprintf(&sb, ". CRITICAL: %s", strings.Join(shown, ", "))
+			if len(paths) > len(shown) {
+				fmt.Fprintf(&sb, " and %d more", len(paths)-len(shown))
+			}
+		}
+
+		note.Notes = sb.String()
+		notes = append(notes, note)
+	}
+
+	return notes
+}
+
+// WriteMO2NotesCSV writes notes as CSV with a header row.
+func WriteMO2NotesCSV(w io.Writer, notes []MO2Note) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(mo2CSVHeader); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for _, note := range notes {
+		if err := cw.Write([]string{note.ModName, note.ModID, note.Color, note.Notes}); err != nil {
+			return fmt.Errorf("write row for %s: %w", note.ModID, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// mo2Color picks a highlight color based on a mod's worst conflict severity.
+func mo2Color(summary conflict.ModConflictSummary) string {
+	switch {
+	case summary.CriticalCount > 0:
+		return MO2ColorCritical
+	case summary.HighCount > 0:
+		return MO2ColorHigh
+	case summary.TotalConflicts > 0:
+		return MO2ColorMedium
+	default:
+		return MO2ColorNone
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+func TestNewMO2Notes(t *testing.T) {
+	result := &conflict.AnalysisResult{
+		Conflicts: []conflict.Conflict{
+			{
+				Path:     "plugin.esp",
+				Severity: conflict.SeverityCritical,
+				Sources: []conflict.ModFile{
+					{ModID: "mod1", ModName: "Mod One"},
+					{ModID: "mod2", ModName: "Mod Two"},
+				},
+			},
+		},
+		ModSummaries: []conflict.ModConflictSummary{
+			{ModID: "mod1", ModName: "Mod One", TotalConflicts: 1, LoseCount: 1, CriticalCount: 1},
+			{ModID: "mod2", ModName: "Mod Two", TotalConflicts: 1, WinCount: 1, CriticalCount: 1},
+			{ModID: "mod3", ModName: "Mod Three"},
+		},
+	}
+
+	notes := NewMO2Notes(result)
+
+	if len(notes) != 3 {
+		t.Fatalf("expected 3 notes, got %d", len(notes))
+	}
+
+	if notes[0].Color != MO2ColorCritical {
+		t.Errorf("expected critical color for mod1, got %q", notes[0].Color)
+	}
+	if !strings.Contains(notes[0].Notes, "CRITICAL: plugin.esp") {
+		t.Errorf("expected critical path in note, got %q", notes[0].Notes)
+	}
+	if !strings.Contains(notes[0].Notes, "loses 1") {
+		t.Errorf("expected lose count in note, got %q", notes[0].Notes)
+	}
+
+	if notes[2].Color != MO2ColorNone {
+		t.Errorf("expected no color for conflict-free mod, got %q", notes[2].Color)
+	}
+	if notes[2].Notes != "No file conflicts" {
+		t.Errorf("unexpected note for conflict-free mod: %q", notes[2].Notes)
+	}
+}
+
+func TestNewMO2Notes_TruncatesCriticalPaths(t *testing.T) {
+	result := &conflict.AnalysisResult{
+		ModSummaries: []conflict.ModConflictSummary{
+			{ModID: "mod1", ModName: "Mod One", TotalConflicts: 7, CriticalCount: 7},
+		},
+	}
+	for i := 0; i < 7; i++ {
+		result.Conflicts = append(result.Conflicts, conflict.Conflict{
+			Path:     "file" + string(rune('a'+i)) + ".esp",
+			Severity: conflict.SeverityCritical,
+			Sources:  []conflict.ModFile{{ModID: "mod1"}},
+		})
+	}
+
+	notes := NewMO2Notes(result)
+	if !strings.Contains(notes[0].Notes, "and 2 more") {
+		t.Errorf("expected truncation suffix, got %q", notes[0].Notes)
+	}
+}
+
+func TestWriteMO2NotesCSV(t *testing.T) {
+	notes := []MO2Note{
+		{ModID: "mod1", ModName: "Mod, One", Color: MO2ColorHigh, Notes: "2 conflict(s)"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMO2NotesCSV(&buf, notes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected header and 1 row, got %d records", len(records))
+	}
+	if records[0][0] != "Mod Name" {
+		t.Errorf("expected header row, got %v", records[0])
+	}
+	if records[1][0] != "Mod, One" || records[1][2] != MO2ColorHigh {
+		t.Errorf("unexpected row: %v", records[1])
+	}
+}
@@ -0,0 +1,35 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+// provenanceCSVHeader is the column layout of the exported provenance file.
+var provenanceCSVHeader = []string{"Path", "Mod ID", "Mod Name", "Archive", "Size", "Hash", "Overridden Mods"}
+
+// WriteProvenanceCSV writes the files of the effective Data folder and the
+// mods providing them as CSV with a header row. Overridden mod IDs are
+// separated by semicolons.
+func WriteProvenanceCSV(w io.Writer, files []conflict.ProvidedFile) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(provenanceCSVHeader); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for _, f := range files {
+		row := []string{f.Path, f.ModID, f.ModName, f.Archive, strconv.FormatInt(f.Size, 10), f.Hash, strings.Join(f.Overridden, ";")}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("write row for %s: %w", f.Path, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,36 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+func TestWriteProvenanceCSV(t *testing.T) {
+	files := []conflict.ProvidedFile{
+		{Path: "meshes/a.nif", ModID: "mod2", ModName: "Mod, Two", Size: 10, Overridden: []string{"mod1", "mod3"}},
+		{Path: "textures/b.dds", ModID: "mod1", ModName: "Mod One", Archive: "mod1.bsa", Size: 5, Hash: "abc"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteProvenanceCSV(&buf, files); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"Path", "Mod ID", "Mod Name", "Archive", "Size", "Hash", "Overridden Mods"},
+		{"meshes/a.nif", "mod2", "Mod, Two", "", "10", "", "mod1;mod3"},
+		{"textures/b.dds", "mod1", "Mod One", "mod1.bsa", "5", "abc", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("expected %v, got %v", want, records)
+	}
+}
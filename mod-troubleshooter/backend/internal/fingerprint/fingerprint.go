@@ -0,0 +1,20 @@
+// Package fingerprint identifies analysis results by their content.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Of returns a SHA-256 of v's JSON encoding, or "" if v cannot be encoded.
+// Map keys are encoded in sorted order, so identical results always have the
+// same fingerprint and can be compared without diffing them.
+func Of(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,21 @@
+package fingerprint
+
+import "testing"
+
+func TestOf(t *testing.T) {
+	a := map[string]int{"b": 2, "a": 1, "c": 3}
+	b := map[string]int{"c": 3, "a": 1, "b": 2}
+
+	if Of(a) != Of(b) {
+		t.Error("expected equal maps to have the same fingerprint")
+	}
+	if len(Of(a)) != 64 {
+		t.Errorf("expected a 64 character hex digest, got %q", Of(a))
+	}
+	if Of(a) == Of(map[string]int{"a": 1}) {
+		t.Error("expected different content to have a different fingerprint")
+	}
+	if Of(make(chan int)) != "" {
+		t.Error("expected empty fingerprint for unencodable values")
+	}
+}
@@ -0,0 +1,91 @@
+// Package flight deduplicates concurrent identical work, so requests for the
+// same collection, download or analysis share a single execution.
+package flight
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs work keyed by a string, sharing in-flight results between callers.
+// The zero value is ready to use.
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// call is an in-flight or completed execution.
+type call[T any] struct {
+	done    chan struct{}
+	val     T
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do runs fn and returns its result, unless a call with the same key is already
+// in flight, in which case it waits for that call and returns its result.
+//
+// fn receives a context carrying the first caller's values that is cancelled
+// only once every waiting caller has given up, so one cancelled request
+// doesn't fail the others. A caller whose ctx is done returns ctx.Err().
+func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	c, ok := g.calls[key]
+	if ok {
+		c.waiters++
+	} else {
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call[T]{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = c
+		go g.run(runCtx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// InFlight returns the number of keys currently being executed.
+func (g *Group[T]) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.calls)
+}
+
+func (g *Group[T]) run(ctx context.Context, key string, c *call[T], fn func(ctx context.Context) (T, error)) {
+	defer c.cancel()
+	c.val, c.err = fn(ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// leave removes a waiter, cancelling the call once nobody is waiting for it.
+func (g *Group[T]) leave(key string, c *call[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.cancel()
+	// Later callers start a fresh execution rather than joining a cancelled one
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,144 @@
+package flight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n callers are waiting on key.
+func waitForWaiters[T any](g *Group[T], key string, n int) {
+	for {
+		g.mu.Lock()
+		c := g.calls[key]
+		joined := c != nil && c.waiters == n
+		g.mu.Unlock()
+		if joined {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGroup_SharesConcurrentCalls(t *testing.T) {
+	var g Group[int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = g.Do(context.Background(), "key", fn)
+		}(i)
+	}
+
+	// Wait until every caller has joined before letting the call finish
+	waitForWaiters(&g, "key", len(results))
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 execution, got %d", calls.Load())
+	}
+	for i, got := range results {
+		if got != 42 {
+			t.Errorf("caller %d: expected 42, got %d", i, got)
+		}
+	}
+	if g.InFlight() != 0 {
+		t.Errorf("expected no calls in flight, got %d", g.InFlight())
+	}
+
+	// Completed calls are not cached
+	g.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		return 0, nil
+	})
+	if calls.Load() != 2 {
+		t.Errorf("expected a new execution after completion, got %d", calls.Load())
+	}
+}
+
+func TestGroup_CancelledWaiter(t *testing.T) {
+	var g Group[string]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	fn := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "done", nil
+		case <-ctx.Done():
+			close(cancelled)
+			return "", ctx.Err()
+		}
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := g.Do(ctx1, "key", fn)
+		errCh <- err
+	}()
+	<-started
+
+	// A second caller joins, then the first gives up; the call keeps running
+	resultCh := make(chan string, 1)
+	go func() {
+		v, _ := g.Do(context.Background(), "key", fn)
+		resultCh <- v
+	}()
+	waitForWaiters(&g, "key", 2)
+
+	cancel1()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancelled caller to get context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if v := <-resultCh; v != "done" {
+		t.Errorf("expected remaining caller to get the result, got %q", v)
+	}
+}
+
+func TestGroup_CancelledByLastWaiter(t *testing.T) {
+	var g Group[int]
+	cancelled := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for g.InFlight() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	_, err := g.Do(ctx, "key", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the call to be cancelled once nobody was waiting")
+	}
+}
@@ -0,0 +1,202 @@
+package fomod
+
+import "sort"
+
+// FlagConsumerKind is the part of an installer a condition flag controls.
+type FlagConsumerKind string
+
+const (
+	// ConsumerStepVisibility is a step shown only when the condition holds.
+	ConsumerStepVisibility FlagConsumerKind = "stepVisibility"
+	// ConsumerPluginType is an option whose type (such as required or not
+	// usable) depends on the condition.
+	ConsumerPluginType FlagConsumerKind = "pluginType"
+	// ConsumerConditionalInstall is a set of files installed when the
+	// condition holds.
+	ConsumerConditionalInstall FlagConsumerKind = "conditionalInstall"
+	// ConsumerModuleDependency is the installer's own requirement.
+	ConsumerModuleDependency FlagConsumerKind = "moduleDependency"
+)
+
+// FlagGraph shows how the condition flags of an installer connect the
+// options that set them to the steps, option types and conditional installs
+// that depend on them.
+type FlagGraph struct {
+	Flags []FlagNode `json:"flags"`
+}
+
+// FlagNode is a condition flag with the options that set it and the parts
+// of the installer that read it.
+type FlagNode struct {
+	Name      string         `json:"name"`
+	Setters   []FlagSetter   `json:"setters"`
+	Consumers []FlagConsumer `json:"consumers"`
+}
+
+// FlagSetter is an option that sets a flag when selected.
+type FlagSetter struct {
+	// StepIndex is the position of the option's step in InstallSteps.
+	StepIndex int    `json:"stepIndex"`
+	Step      string `json:"step"`
+	Group     string `json:"group"`
+	Option    string `json:"option"`
+	// Value is the value the flag is set to.
+	Value string `json:"value"`
+}
+
+// FlagConsumer is a part of the installer whose behavior depends on a flag.
+type FlagConsumer struct {
+	Kind FlagConsumerKind `json:"kind"`
+	// StepIndex is the position of the step in InstallSteps, for step
+	// visibility and option types; -1 otherwise.
+	StepIndex int    `json:"stepIndex"`
+	Step      string `json:"step,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Option    string `json:"option,omitempty"`
+	// PatternIndex is the position of the conditional install in
+	// ConditionalFileInstalls; -1 otherwise.
+	PatternIndex int `json:"patternIndex"`
+	// Value is the value the condition compares the flag with.
+	Value string `json:"value"`
+}
+
+// BuildFlagGraph collects the condition flags of an installer, sorted by name.
+func BuildFlagGraph(config *ModuleConfig) *FlagGraph {
+	nodes := make(map[string]*FlagNode)
+	node := func(name string) *FlagNode {
+		n, ok := nodes[name]
+		if !ok {
+			n = &FlagNode{Name: name, Setters: []FlagSetter{}, Consumers: []FlagConsumer{}}
+			nodes[name] = n
+		}
+		return n
+	}
+	consume := func(dep *Dependency, c FlagConsumer) {
+		for _, fd := range flagDependencies(dep) {
+			c.Value = fd.Value
+			n := node(fd.Flag)
+			n.Consumers = append(n.Consumers, c)
+		}
+	}
+
+	if config != nil {
+		consume(config.ModuleDependencies, FlagConsumer{Kind: ConsumerModuleDependency, StepIndex: -1, PatternIndex: -1})
+
+		for i, step := range config.InstallSteps {
+			consume(step.Visible, FlagConsumer{
+				Kind: ConsumerStepVisibility, StepIndex: i, Step: step.Name, PatternIndex: -1,
+			})
+			for _, group := range step.OptionGroups {
+				for _, plugin := range group.Plugins {
+					for _, flag := range plugin.ConditionFlags {
+						n := node(flag.Name)
+						n.Setters = append(n.Setters, FlagSetter{
+							StepIndex: i, Step: step.Name, Group: group.Name, Option: plugin.Name, Value: flag.Value,
+						})
+					}
+					if plugin.TypeDescriptor == nil || plugin.TypeDescriptor.DependencyType == nil {
+						continue
+					}
+					for _, pattern := range plugin.TypeDescriptor.DependencyType.Patterns {
+						consume(pattern.Dependencies, FlagConsumer{
+							Kind: ConsumerPluginType, StepIndex: i, Step: step.Name,
+							Group: group.Name, Option: plugin.Name, PatternIndex: -1,
+						})
+					}
+				}
+			}
+		}
+
+		for i, item := range config.ConditionalFileInstalls {
+			consume(item.Dependencies, FlagConsumer{Kind: ConsumerConditionalInstall, StepIndex: -1, PatternIndex: i})
+		}
+	}
+
+	graph := &FlagGraph{Flags: make([]FlagNode, 0, len(nodes))}
+	for _, n := range nodes {
+		graph.Flags = append(graph.Flags, *n)
+	}
+	sort.Slice(graph.Flags, func(i, j int) bool { return graph.Flags[i].Name < graph.Flags[j].Name })
+	return graph
+}
+
+// flagDependencies lists the flag conditions within a dependency.
+func flagDependencies(dep *Dependency) []FlagDependency {
+	if dep == nil {
+		return nil
+	}
+	var flags []FlagDependency
+	if dep.FlagDependency != nil {
+		flags = append(flags, *dep.FlagDependency)
+	}
+	for i := range dep.Children {
+		flags = append(flags, flagDependencies(&dep.Children[i])...)
+	}
+	return flags
+}
+
+// UnreachableSteps returns the indexes of the install steps that are never
+// shown, because their visibility depends on flag values no option in
+// another step sets. Unset flags have the empty value; conditions on files
+// and versions are assumed to be met.
+func UnreachableSteps(config *ModuleConfig) []int {
+	if config == nil {
+		return nil
+	}
+	graph := BuildFlagGraph(config)
+
+	var unreachable []int
+	for i, step := range config.InstallSteps {
+		if step.Visible == nil {
+			continue
+		}
+		// Values the flags can have by the time the step is reached
+		values := make(map[string]map[string]bool, len(graph.Flags))
+		for _, flag := range graph.Flags {
+			values[flag.Name] = map[string]bool{"": true}
+			for _, setter := range flag.Setters {
+				if setter.StepIndex != i {
+					values[flag.Name][setter.Value] = true
+				}
+			}
+		}
+		has := func(flag, value string) bool { return values[flag][value] }
+		if !satisfiable(step.Visible, has) {
+			unreachable = append(unreachable, i)
+		}
+	}
+	return unreachable
+}
+
+// satisfiable reports whether a dependency can hold given whether each flag
+// can have a value.
+func satisfiable(dep *Dependency, has func(flag, value string) bool) bool {
+	var results []bool
+	if dep.FlagDependency != nil {
+		results = append(results, has(dep.FlagDependency.Flag, dep.FlagDependency.Value))
+	}
+	if dep.FileDependency != nil || dep.GameDependency != nil || dep.FommDependency != nil {
+		results = append(results, true)
+	}
+	for i := range dep.Children {
+		results = append(results, satisfiable(&dep.Children[i], has))
+	}
+	if len(results) == 0 {
+		return true
+	}
+
+	if dep.Operator == DependencyOperatorOr {
+		for _, ok := range results {
+			if ok {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ok := range results {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package fomod
+
+import (
+	"strings"
+	"testing"
+)
+
+const flagsXML = `<config>
+  <moduleName>Flags</moduleName>
+  <moduleDependencies operator="And">
+    <flagDependency flag="ready" value=""/>
+  </moduleDependencies>
+  <installSteps order="Explicit">
+    <installStep name="Textures">
+      <optionalFileGroups>
+        <group name="Resolution" type="SelectExactlyOne">
+          <plugins>
+            <plugin name="2K">
+              <description/>
+              <conditionFlags><flag name="res">2K</flag></conditionFlags>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+            <plugin name="4K">
+              <description/>
+              <conditionFlags><flag name="res">4K</flag></conditionFlags>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+    <installStep name="4K Extras">
+      <visible><flagDependency flag="res" value="4K"/></visible>
+      <optionalFileGroups>
+        <group name="Extras" type="SelectAny">
+          <plugins>
+            <plugin name="Parallax">
+              <description/>
+              <typeDescriptor>
+                <dependencyType>
+                  <defaultType name="Optional"/>
+                  <patterns>
+                    <pattern>
+                      <dependencies><flagDependency flag="res" value="2K"/></dependencies>
+                      <type name="NotUsable"/>
+                    </pattern>
+                  </patterns>
+                </dependencyType>
+              </typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+    <installStep name="8K Extras">
+      <visible><flagDependency flag="res" value="8K"/></visible>
+      <optionalFileGroups>
+        <group name="More" type="SelectAny">
+          <plugins><plugin name="Nothing"><description/><typeDescriptor><type name="Optional"/></typeDescriptor></plugin></plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+  <conditionalFileInstalls>
+    <patterns>
+      <pattern>
+        <dependencies operator="Or">
+          <flagDependency flag="res" value="4K"/>
+          <flagDependency flag="res" value="8K"/>
+        </dependencies>
+        <files><folder source="4k"/></files>
+      </pattern>
+    </patterns>
+  </conditionalFileInstalls>
+</config>`
+
+func TestBuildFlagGraph(t *testing.T) {
+	config, err := ParseModuleConfigFromReader(strings.NewReader(flagsXML))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+
+	graph := BuildFlagGraph(config)
+	if len(graph.Flags) != 2 || graph.Flags[0].Name != "ready" || graph.Flags[1].Name != "res" {
+		t.Fatalf("expected flags ready and res, got %+v", graph.Flags)
+	}
+
+	ready := graph.Flags[0]
+	if len(ready.Setters) != 0 {
+		t.Errorf("expected ready to have no setters, got %+v", ready.Setters)
+	}
+	if len(ready.Consumers) != 1 || ready.Consumers[0].Kind != ConsumerModuleDependency {
+		t.Errorf("expected ready to be read by the module dependency, got %+v", ready.Consumers)
+	}
+
+	res := graph.Flags[1]
+	if len(res.Setters) != 2 {
+		t.Fatalf("expected 2 setters of res, got %+v", res.Setters)
+	}
+	if s := res.Setters[1]; s.StepIndex != 0 || s.Group != "Resolution" || s.Option != "4K" || s.Value != "4K" {
+		t.Errorf("unexpected setter %+v", s)
+	}
+
+	kinds := map[FlagConsumerKind]int{}
+	for _, c := range res.Consumers {
+		kinds[c.Kind]++
+	}
+	if kinds[ConsumerStepVisibility] != 2 || kinds[ConsumerPluginType] != 1 || kinds[ConsumerConditionalInstall] != 2 {
+		t.Errorf("unexpected consumers of res: %+v", res.Consumers)
+	}
+	for _, c := range res.Consumers {
+		if c.Kind == ConsumerPluginType && (c.StepIndex != 1 || c.Option != "Parallax" || c.Value != "2K") {
+			t.Errorf("unexpected option type consumer %+v", c)
+		}
+		if c.Kind == ConsumerConditionalInstall && c.PatternIndex != 0 {
+			t.Errorf("expected conditional install pattern 0, got %+v", c)
+		}
+	}
+}
+
+func TestUnreachableSteps(t *testing.T) {
+	config, err := ParseModuleConfigFromReader(strings.NewReader(flagsXML))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+
+	unreachable := UnreachableSteps(config)
+	if len(unreachable) != 1 || unreachable[0] != 2 {
+		t.Errorf("expected only step 2 to be unreachable, got %v", unreachable)
+	}
+}
+
+func TestValidate_UnreachableStep(t *testing.T) {
+	v := Validate([]byte(flagsXML))
+	for _, a := range v.Annotations {
+		if a.Rule == "unreachable-step" {
+			if a.Line != 48 {
+				t.Errorf("expected the unreachable step on line 48, got %d", a.Line)
+			}
+			return
+		}
+	}
+	t.Errorf("expected an unreachable-step annotation, got %+v", v.Annotations)
+}
@@ -0,0 +1,303 @@
+package fomod
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Element and attribute names of ModuleConfig.xml, by lowercase name, so
+// names with the wrong casing can be corrected.
+var (
+	moduleConfigElements = canonicalNames(
+		"config", "moduleName", "moduleImage", "moduleDependencies", "requiredInstallFiles",
+		"installSteps", "installStep", "visible", "optionalFileGroups", "group", "plugins",
+		"plugin", "description", "image", "files", "file", "folder", "conditionFlags", "flag",
+		"typeDescriptor", "type", "dependencyType", "defaultType", "patterns", "pattern",
+		"dependencies", "fileDependency", "flagDependency", "gameDependency", "fommDependency",
+		"conditionalFileInstalls",
+	)
+	moduleConfigAttrs = canonicalNames(
+		"alwaysInstall", "colour", "destination", "file", "flag", "height", "installIfUsable",
+		"name", "operator", "order", "path", "position", "priority", "showFade", "source",
+		"state", "type", "value", "version",
+	)
+)
+
+// pluginTypeNames are the plugin types, by lowercase name.
+var pluginTypeNames = canonicalNames(
+	string(PluginRequired), string(PluginOptional), string(PluginRecommended),
+	string(PluginNotUsable), string(PluginCouldBeUsable),
+)
+
+// moduleConfigValues lists the values of enumerated attributes, by
+// lowercase value, keyed by "element@attribute" or "@attribute" for any element.
+var moduleConfigValues = map[string]map[string]string{
+	"group@type": canonicalNames(
+		string(GroupSelectAtLeastOne), string(GroupSelectAtMostOne), string(GroupSelectExactlyOne),
+		string(GroupSelectAll), string(GroupSelectAny),
+	),
+	"type@name":        pluginTypeNames,
+	"defaultType@name": pluginTypeNames,
+	"@operator":        canonicalNames(string(DependencyOperatorAnd), string(DependencyOperatorOr)),
+	"@state":           canonicalNames(string(FileStateMissing), string(FileStateInactive), string(FileStateActive)),
+	"@order":           canonicalNames(string(OrderAscending), string(OrderDescending), string(OrderExplicit)),
+}
+
+// configSingletons are the children of <config> that may appear only once.
+var configSingletons = map[string]bool{
+	"moduleName": true, "moduleImage": true, "moduleDependencies": true,
+	"requiredInstallFiles": true, "installSteps": true, "conditionalFileInstalls": true,
+}
+
+func canonicalNames(names ...string) map[string]string {
+	m := make(map[string]string, len(names))
+	for _, name := range names {
+		m[strings.ToLower(name)] = name
+	}
+	return m
+}
+
+// ParseModuleConfigLenient parses a ModuleConfig.xml like mod managers do,
+// recovering from common mistakes: byte order marks, UTF-16 text or a wrong
+// encoding declaration, element, attribute and value names with the wrong
+// casing, repeated elements, unclosed elements and a missing module name.
+// Malformed XML is read up to the first error. Each recovery is described
+// by a diagnostic. It fails only if no <config> element can be read or the
+// document exceeds the limits on untrusted XML.
+func ParseModuleConfigLenient(data []byte) (*ModuleConfig, []Annotation, error) {
+	if err := checkXMLSize(data); err != nil {
+		return nil, nil, fmt.Errorf("parse ModuleConfig.xml: %w", err)
+	}
+	var diags []Annotation
+	data = decodeText(data, &diags)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		// Many installers declare UTF-16 but are saved as UTF-8; the text
+		// is UTF-8 by now either way
+		if strings.HasPrefix(strings.ToLower(label), "utf-16") {
+			return input, nil
+		}
+		return charset.NewReaderLabel(label, input)
+	}
+
+	reader := &lenientReader{decoder: decoder, seen: make(map[string]bool)}
+	var xmlData xmlConfig
+	err := xml.NewTokenDecoder(&limitedReader{tokens: reader}).Decode(&xmlData)
+	diags = append(diags, reader.diags...)
+	if limitError(err) {
+		return nil, sortAnnotations(diags), fmt.Errorf("parse ModuleConfig.xml: %w", err)
+	}
+	if err != nil {
+		return nil, sortAnnotations(diags), fmt.Errorf("parse ModuleConfig.xml: %w: %v", ErrInvalidXML, err)
+	}
+
+	if strings.TrimSpace(xmlData.ModuleName.Value) == "" {
+		diags = append(diags, Annotation{
+			Line:     reader.rootLine,
+			Column:   reader.rootCol,
+			Severity: SeverityWarning,
+			Rule:     "missing-module-name",
+			Message:  "<moduleName> is missing, so the installer has no title",
+		})
+	}
+	return buildConfig(&xmlData), sortAnnotations(diags), nil
+}
+
+// decodeText converts UTF-16 text to UTF-8 and removes byte order marks,
+// which some tools repeat or leave in the middle of the file.
+func decodeText(data []byte, diags *[]Annotation) []byte {
+	var label string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		label = "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		label = "utf-16be"
+	}
+	if label != "" {
+		if r, err := charset.NewReaderLabel(label, bytes.NewReader(data[2:])); err == nil {
+			if decoded, err := io.ReadAll(r); err == nil {
+				data = decoded
+			}
+		}
+	}
+
+	const bom = "\uFEFF"
+	if !bytes.Contains(data, []byte(bom)) {
+		return data
+	}
+	if !bytes.HasPrefix(data, []byte(bom)) || bytes.Count(data, []byte(bom)) > 1 {
+		line := 1
+		if i := bytes.LastIndex(data, []byte(bom)); i >= 0 {
+			line = bytes.Count(data[:i], []byte("\n")) + 1
+		}
+		*diags = append(*diags, Annotation{
+			Line:     line,
+			Severity: SeverityWarning,
+			Rule:     "stray-bom",
+			Message:  "byte order mark inside the file was removed",
+		})
+	}
+	return bytes.ReplaceAll(data, []byte(bom), nil)
+}
+
+// lenientReader passes the tokens of a ModuleConfig.xml on to a decoder,
+// correcting the mistakes it can and recording a diagnostic for each.
+type lenientReader struct {
+	decoder *xml.Decoder
+	diags   []Annotation
+	// stack holds the corrected names of open elements.
+	stack []string
+	// skip is the depth inside an element being dropped.
+	skip int
+	// seen holds the singleton children of <config> read so far, and the
+	// misspelled names already reported.
+	seen map[string]bool
+	// pending are end tokens closing elements left open at the end.
+	pending []xml.Token
+	done    bool
+
+	rootLine, rootCol int
+}
+
+func (r *lenientReader) add(line, col int, sev Severity, rule, format string, args ...interface{}) {
+	r.diags = append(r.diags, Annotation{
+		Line:     line,
+		Column:   col,
+		Severity: sev,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Token implements xml.TokenReader.
+func (r *lenientReader) Token() (xml.Token, error) {
+	for {
+		if len(r.pending) > 0 {
+			tok := r.pending[0]
+			r.pending = r.pending[1:]
+			return tok, nil
+		}
+		if r.done {
+			return nil, io.EOF
+		}
+
+		line, col := r.decoder.InputPos()
+		tok, err := r.decoder.Token()
+		if err != nil {
+			r.stop(line, col, err)
+			continue
+		}
+		tok = xml.CopyToken(tok)
+
+		if r.skip > 0 {
+			switch tok.(type) {
+			case xml.StartElement:
+				r.skip++
+			case xml.EndElement:
+				r.skip--
+			}
+			continue
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			t.Name.Local = r.correctName(moduleConfigElements, t.Name.Local, "element <%s> should be <%s>", line, col)
+			for i, a := range t.Attr {
+				t.Attr[i].Name.Local = r.correctName(moduleConfigAttrs, a.Name.Local, "attribute %q should be %q", line, col)
+				t.Attr[i].Value = r.correctValue(t.Name.Local, t.Attr[i], line, col)
+			}
+
+			if len(r.stack) == 0 {
+				r.rootLine, r.rootCol = line, col
+			}
+			if len(r.stack) == 1 && r.stack[0] == "config" && configSingletons[t.Name.Local] {
+				if r.seen[t.Name.Local] {
+					r.add(line, col, SeverityWarning, "duplicate-element",
+						"<%s> appears more than once; only the first is used", t.Name.Local)
+					r.skip = 1
+					continue
+				}
+				r.seen[t.Name.Local] = true
+			}
+			r.stack = append(r.stack, t.Name.Local)
+			return t, nil
+
+		case xml.EndElement:
+			if len(r.stack) == 0 {
+				continue
+			}
+			t.Name.Local = r.stack[len(r.stack)-1]
+			r.stack = r.stack[:len(r.stack)-1]
+			return t, nil
+
+		default:
+			return tok, nil
+		}
+	}
+}
+
+// stop ends the document at a read error, closing every open element so
+// what was read so far can still be used.
+func (r *lenientReader) stop(line, col int, err error) {
+	r.done = true
+	r.skip = 0
+
+	var syntaxErr *xml.SyntaxError
+	switch {
+	case len(r.stack) == 0:
+	case err == io.EOF || errors.As(err, &syntaxErr) && syntaxErr.Msg == "unexpected EOF":
+		r.add(line, col, SeverityWarning, "unclosed-element", "<%s> is never closed", r.stack[len(r.stack)-1])
+	case syntaxErr != nil:
+		r.add(syntaxErr.Line, 0, SeverityError, "xml-syntax", "%s; the rest of the file was ignored", syntaxErr.Msg)
+	default:
+		r.add(line, col, SeverityError, "xml-syntax", "%v; the rest of the file was ignored", err)
+	}
+
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		r.pending = append(r.pending, xml.EndElement{Name: xml.Name{Local: r.stack[i]}})
+	}
+	r.stack = nil
+}
+
+// correctName returns the known name matching name regardless of case,
+// reporting the first use of each misspelling.
+func (r *lenientReader) correctName(known map[string]string, name, format string, line, col int) string {
+	canonical, ok := known[strings.ToLower(name)]
+	if !ok || canonical == name {
+		return name
+	}
+	if key := "name:" + name; !r.seen[key] {
+		r.seen[key] = true
+		r.add(line, col, SeverityWarning, "name-casing", format, name, canonical)
+	}
+	return canonical
+}
+
+// correctValue returns the known value of an enumerated attribute matching
+// its value regardless of case.
+func (r *lenientReader) correctValue(element string, attr xml.Attr, line, col int) string {
+	values, ok := moduleConfigValues[element+"@"+attr.Name.Local]
+	if !ok {
+		values, ok = moduleConfigValues["@"+attr.Name.Local]
+	}
+	if !ok {
+		return attr.Value
+	}
+	canonical, ok := values[strings.ToLower(strings.TrimSpace(attr.Value))]
+	if !ok || canonical == attr.Value {
+		return attr.Value
+	}
+	if key := "value:" + attr.Value; !r.seen[key] {
+		r.seen[key] = true
+		r.add(line, col, SeverityWarning, "value-casing", "%s %q should be %q", attr.Name.Local, attr.Value, canonical)
+	}
+	return canonical
+}
@@ -0,0 +1,179 @@
+package fomod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseModuleConfigLenient(t *testing.T) {
+	tests := []struct {
+		name       string
+		xml        string
+		moduleName string
+		steps      int
+		groupType  GroupType
+		rules      []string
+	}{
+		{
+			name: "valid config",
+			xml: `<config>
+  <moduleName>Valid</moduleName>
+  <installSteps><installStep name="A"><optionalFileGroups>
+    <group name="G" type="SelectAny"><plugins><plugin name="P"/></plugins></group>
+  </optionalFileGroups></installStep></installSteps>
+</config>`,
+			moduleName: "Valid",
+			steps:      1,
+			groupType:  GroupSelectAny,
+		},
+		{
+			name: "wrong casing",
+			xml: `<Config>
+  <ModuleName>Cased</ModuleName>
+  <installSteps><InstallStep Name="A"><optionalFileGroups>
+    <group name="G" type="selectexactlyone"><plugins><plugin name="P"/></plugins></group>
+  </optionalFileGroups></InstallStep></installSteps>
+</Config>`,
+			moduleName: "Cased",
+			steps:      1,
+			groupType:  GroupSelectExactlyOne,
+			rules:      []string{"name-casing", "name-casing", "name-casing", "name-casing", "value-casing"},
+		},
+		{
+			name: "duplicate module name",
+			xml: `<config>
+  <moduleName>First</moduleName>
+  <moduleName>Second</moduleName>
+</config>`,
+			moduleName: "First",
+			rules:      []string{"duplicate-element"},
+		},
+		{
+			name:       "stray byte order marks",
+			xml:        "\uFEFF<config>\n\uFEFF<moduleName>Marked</moduleName>\n</config>",
+			moduleName: "Marked",
+			rules:      []string{"stray-bom"},
+		},
+		{
+			name:       "declared UTF-16 but saved as UTF-8",
+			xml:        `<?xml version="1.0" encoding="UTF-16"?><config><moduleName>Declared</moduleName></config>`,
+			moduleName: "Declared",
+		},
+		{
+			name: "unclosed elements",
+			xml: `<config>
+  <moduleName>Unclosed</moduleName>
+  <installSteps><installStep name="A">`,
+			moduleName: "Unclosed",
+			steps:      1,
+			rules:      []string{"unclosed-element"},
+		},
+		{
+			name: "malformed after the module name",
+			xml: `<config>
+  <moduleName>Broken</moduleName>
+  <installSteps <<
+</config>`,
+			moduleName: "Broken",
+			rules:      []string{"xml-syntax"},
+		},
+		{
+			name:  "missing module name",
+			xml:   `<config><installSteps><installStep name="A"/></installSteps></config>`,
+			steps: 1,
+			rules: []string{"missing-module-name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, diags, err := ParseModuleConfigLenient([]byte(tt.xml))
+			if err != nil {
+				t.Fatalf("ParseModuleConfigLenient() error = %v", err)
+			}
+			if config.ModuleName != tt.moduleName {
+				t.Errorf("ModuleName = %q, want %q", config.ModuleName, tt.moduleName)
+			}
+			if len(config.InstallSteps) != tt.steps {
+				t.Errorf("expected %d install steps, got %d", tt.steps, len(config.InstallSteps))
+			}
+			if tt.groupType != "" && config.InstallSteps[0].OptionGroups[0].Type != tt.groupType {
+				t.Errorf("group type = %q, want %q", config.InstallSteps[0].OptionGroups[0].Type, tt.groupType)
+			}
+			if len(diags) != len(tt.rules) {
+				t.Fatalf("expected %d diagnostics, got %+v", len(tt.rules), diags)
+			}
+			for i, rule := range tt.rules {
+				if diags[i].Rule != rule {
+					t.Errorf("diagnostic %d rule = %q, want %q", i, diags[i].Rule, rule)
+				}
+			}
+		})
+	}
+}
+
+func TestParseModuleConfigLenient_UTF16(t *testing.T) {
+	text := "<?xml version=\"1.0\" encoding=\"UTF-16\"?>\n<config><moduleName>Wide</moduleName></config>"
+	data := []byte{0xFF, 0xFE}
+	for _, r := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(r), byte(r>>8))
+	}
+
+	config, diags, err := ParseModuleConfigLenient(data)
+	if err != nil {
+		t.Fatalf("ParseModuleConfigLenient() error = %v", err)
+	}
+	if config.ModuleName != "Wide" {
+		t.Errorf("ModuleName = %q, want Wide", config.ModuleName)
+	}
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", diags)
+	}
+}
+
+func TestParseModuleConfigLenient_NoConfig(t *testing.T) {
+	for _, xml := range []string{"", "not xml at all", "<fomod><Name>Info</Name></fomod>"} {
+		if _, _, err := ParseModuleConfigLenient([]byte(xml)); err == nil {
+			t.Errorf("expected an error for %q", xml)
+		}
+	}
+}
+
+func TestParser_ParseLenient(t *testing.T) {
+	dir := t.TempDir()
+	fomodDir := filepath.Join(dir, "fomod")
+	if err := os.MkdirAll(fomodDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := "<config><moduleName>Lenient</moduleName><moduleName>Again</moduleName></config>"
+	if err := os.WriteFile(filepath.Join(fomodDir, "ModuleConfig.xml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fomodDir, "info.xml"), []byte("<fomod><Name>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := NewParser(dir)
+	if err != nil {
+		t.Fatalf("NewParser() error = %v", err)
+	}
+	data, err := parser.ParseLenient()
+	if err != nil {
+		t.Fatalf("ParseLenient() error = %v", err)
+	}
+	if data.Config.ModuleName != "Lenient" {
+		t.Errorf("ModuleName = %q, want Lenient", data.Config.ModuleName)
+	}
+	if data.Info != nil {
+		t.Errorf("expected the broken info.xml to be ignored, got %+v", data.Info)
+	}
+	rules := map[string]bool{}
+	for _, d := range data.Diagnostics {
+		rules[d.Rule] = true
+	}
+	if !rules["duplicate-element"] || !rules["invalid-info"] {
+		t.Errorf("expected duplicate-element and invalid-info diagnostics, got %+v", data.Diagnostics)
+	}
+}
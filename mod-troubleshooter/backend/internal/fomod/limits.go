@@ -0,0 +1,78 @@
+package fomod
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// Installer XML comes from arbitrary archives, so it is read with limits that
+// keep a malicious document from exhausting memory.
+const (
+	// MaxXMLSize is the largest XML document that is parsed.
+	MaxXMLSize = 4 << 20
+	// MaxXMLDepth is the deepest nesting of elements that is parsed. Real
+	// installers stay well under 20.
+	MaxXMLDepth = 128
+)
+
+// Errors returned for documents that exceed the limits.
+var (
+	ErrXMLTooLarge = errors.New("XML document is too large")
+	ErrXMLTooDeep  = errors.New("XML elements are nested too deeply")
+	ErrXMLEntity   = errors.New("XML entity declarations are not allowed")
+)
+
+// checkXMLSize fails for documents larger than MaxXMLSize.
+func checkXMLSize(data []byte) error {
+	if len(data) > MaxXMLSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrXMLTooLarge, len(data), MaxXMLSize)
+	}
+	return nil
+}
+
+// limitedReader passes tokens through from another reader, failing once
+// elements nest deeper than MaxXMLDepth or a DTD declares an entity. The
+// decoder never expands entities beyond the predefined ones, but refusing
+// declarations outright keeps entity tricks like billion laughs from ever
+// reaching code that might.
+type limitedReader struct {
+	tokens xml.TokenReader
+	depth  int
+}
+
+// Token implements xml.TokenReader.
+func (r *limitedReader) Token() (xml.Token, error) {
+	tok, err := r.tokens.Token()
+	if err != nil {
+		return tok, err
+	}
+	switch t := tok.(type) {
+	case xml.StartElement:
+		r.depth++
+		if r.depth > MaxXMLDepth {
+			return nil, fmt.Errorf("%w: limit is %d levels", ErrXMLTooDeep, MaxXMLDepth)
+		}
+	case xml.EndElement:
+		r.depth--
+	case xml.Directive:
+		if declaresEntity(t) {
+			return nil, ErrXMLEntity
+		}
+	}
+	return tok, nil
+}
+
+// declaresEntity reports whether a directive declares an entity, on its own
+// or in the internal subset of a DOCTYPE.
+func declaresEntity(d xml.Directive) bool {
+	upper := bytes.ToUpper(d)
+	return bytes.HasPrefix(upper, []byte("ENTITY")) || bytes.Contains(upper, []byte("<!ENTITY"))
+}
+
+// limitError reports whether err comes from exceeding a limit rather than
+// from malformed XML.
+func limitError(err error) bool {
+	return errors.Is(err, ErrXMLTooLarge) || errors.Is(err, ErrXMLTooDeep) || errors.Is(err, ErrXMLEntity)
+}
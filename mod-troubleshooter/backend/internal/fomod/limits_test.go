@@ -0,0 +1,67 @@
+package fomod
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const billionLaughs = `<?xml version="1.0"?>
+<!DOCTYPE config [
+  <!ENTITY lol "lol">
+  <!ENTITY lol2 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+  <!ENTITY lol3 "&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;">
+]>
+<config><moduleName>&lol3;</moduleName></config>`
+
+func nestedXML(depth int) string {
+	return "<config><moduleName>Deep</moduleName>" +
+		strings.Repeat("<installSteps>", depth) + strings.Repeat("</installSteps>", depth) +
+		"</config>"
+}
+
+func TestParseModuleConfig_Limits(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want error
+	}{
+		{name: "entity declarations", xml: billionLaughs, want: ErrXMLEntity},
+		{name: "external entity", xml: `<!DOCTYPE config [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><config><moduleName>&xxe;</moduleName></config>`, want: ErrXMLEntity},
+		{name: "deep nesting", xml: nestedXML(MaxXMLDepth), want: ErrXMLTooDeep},
+		{name: "too large", xml: "<config><moduleName>" + strings.Repeat("a", MaxXMLSize) + "</moduleName></config>", want: ErrXMLTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseModuleConfigFromReader(strings.NewReader(tt.xml)); !errors.Is(err, tt.want) {
+				t.Errorf("ParseModuleConfigFromReader() error = %v, want %v", err, tt.want)
+			}
+			if _, _, err := ParseModuleConfigLenient([]byte(tt.xml)); !errors.Is(err, tt.want) {
+				t.Errorf("ParseModuleConfigLenient() error = %v, want %v", err, tt.want)
+			}
+
+			v := ValidateStrict([]byte(tt.xml))
+			if v.Valid {
+				t.Error("expected validation to fail")
+			}
+			found := false
+			for _, a := range v.Annotations {
+				found = found || a.Rule == "xml-limit"
+			}
+			if !found {
+				t.Errorf("expected an xml-limit annotation, got %+v", v.Annotations)
+			}
+		})
+	}
+}
+
+func TestParseModuleConfig_WithinLimits(t *testing.T) {
+	if _, err := ParseModuleConfigFromReader(strings.NewReader(nestedXML(MaxXMLDepth - 2))); err != nil {
+		t.Errorf("expected nesting within the limit to parse, got %v", err)
+	}
+	doctype := `<!DOCTYPE config SYSTEM "ModConfig.dtd"><config><moduleName>Doctype</moduleName></config>`
+	if _, err := ParseModuleConfigFromReader(strings.NewReader(doctype)); err != nil {
+		t.Errorf("expected a DOCTYPE without entities to parse, got %v", err)
+	}
+}
@@ -70,6 +70,42 @@ func (p *Parser) Parse() (*FomodData, error) {
 	return &FomodData{
 		Info:   info,
 		Config: config,
+		Flags:  BuildFlagGraph(config),
+	}, nil
+}
+
+// ParseLenient parses both files like Parse, but recovers from mistakes in
+// ModuleConfig.xml as ParseModuleConfigLenient does and ignores an unreadable
+// info.xml, describing both in the returned data's diagnostics.
+func (p *Parser) ParseLenient() (*FomodData, error) {
+	configPath := p.findFile("ModuleConfig.xml")
+	if configPath == "" {
+		return nil, ErrNoModuleConfig
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("read ModuleConfig.xml: %w", err)
+	}
+	config, diags, err := ParseModuleConfigLenient(data)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := p.ParseInfo()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		diags = append(diags, Annotation{
+			Severity: SeverityWarning,
+			Rule:     "invalid-info",
+			Message:  fmt.Sprintf("info.xml was ignored: %v", err),
+		})
+		info = nil
+	}
+
+	return &FomodData{
+		Info:        info,
+		Config:      config,
+		Flags:       BuildFlagGraph(config),
+		Diagnostics: diags,
 	}, nil
 }
 
@@ -136,14 +172,22 @@ func (p *Parser) findFile(filename string) string {
 	return ""
 }
 
-// decodeXML decodes XML data handling various encodings.
+// decodeXML decodes XML data handling various encodings, within the limits
+// on untrusted XML.
 func decodeXML(data []byte, v interface{}) error {
+	if err := checkXMLSize(data); err != nil {
+		return err
+	}
+
 	// Create a reader that can handle different character encodings
 	reader := bytes.NewReader(data)
 	decoder := xml.NewDecoder(reader)
 	decoder.CharsetReader = charset.NewReaderLabel
 
-	if err := decoder.Decode(v); err != nil {
+	if err := xml.NewTokenDecoder(&limitedReader{tokens: decoder}).Decode(v); err != nil {
+		if limitError(err) {
+			return err
+		}
 		return fmt.Errorf("%w: %v", ErrInvalidXML, err)
 	}
 
@@ -157,8 +201,15 @@ func convertConfig(xml *xmlConfig) (*ModuleConfig, error) {
 		return nil, ErrMissingModuleName
 	}
 
+	config := buildConfig(xml)
+	config.ModuleName = moduleName
+	return config, nil
+}
+
+// buildConfig converts everything but the module name, which callers check.
+func buildConfig(xml *xmlConfig) *ModuleConfig {
 	config := &ModuleConfig{
-		ModuleName: moduleName,
+		ModuleName: strings.TrimSpace(xml.ModuleName.Value),
 	}
 
 	// Convert module image
@@ -186,7 +237,7 @@ func convertConfig(xml *xmlConfig) (*ModuleConfig, error) {
 		config.ConditionalFileInstalls = convertConditionalInstalls(xml.ConditionalFileInstalls)
 	}
 
-	return config, nil
+	return config
 }
 
 func convertHeaderImage(xml *xmlHeaderImage) *HeaderImage {
@@ -220,7 +271,7 @@ func convertInstallSteps(xml *xmlInstallSteps) []InstallStep {
 		}
 
 		if xmlStep.Visible != nil {
-			step.Visible = convertDependency(xmlStep.Visible)
+			step.Visible = convertDependency(visibleDependency(xmlStep.Visible))
 		}
 
 		if xmlStep.OptionalFileGroups != nil {
@@ -233,6 +284,17 @@ func convertInstallSteps(xml *xmlInstallSteps) []InstallStep {
 	return steps
 }
 
+// visibleDependency returns the conditions of a step's <visible> element,
+// which holds them directly as the schema has it, or wrapped in a single
+// <dependencies> element as many installers do.
+func visibleDependency(xml *xmlCompositeDependency) *xmlCompositeDependency {
+	if len(xml.Dependencies) == 1 && len(xml.FileDependencies) == 0 && len(xml.FlagDependencies) == 0 &&
+		len(xml.GameDependencies) == 0 && len(xml.FommDependencies) == 0 {
+		return &xml.Dependencies[0]
+	}
+	return xml
+}
+
 func convertGroups(xml *xmlOptionalFileGroups) []OptionGroup {
 	if xml == nil || len(xml.Groups) == 0 {
 		return nil
@@ -0,0 +1,597 @@
+package fomod
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html/charset"
+)
+
+// modConfigXSD is the FOMOD module configuration schema, version 5.0.
+//
+//go:embed ModConfig5.0.xsd
+var modConfigXSD []byte
+
+// SchemaVersion names the schema strict validation checks against.
+const SchemaVersion = "ModConfig 5.0"
+
+// modConfigSchema is the parsed built-in schema.
+var modConfigSchema = sync.OnceValue(func() *schema {
+	s, err := parseSchema(modConfigXSD)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in FOMOD schema: %v", err))
+	}
+	return s
+})
+
+// ValidateStrict validates a ModuleConfig.xml like Validate and also checks
+// it against the ModConfig 5.0 schema, for authors who want full compliance.
+// Each schema violation is an error with rule "schema".
+func ValidateStrict(data []byte) *Validation {
+	v := Validate(data)
+	v.Schema = SchemaVersion
+
+	violations := modConfigSchema().validate(data)
+	if len(violations) == 0 {
+		return v
+	}
+	v.Annotations = sortAnnotations(append(v.Annotations, violations...))
+	v.Valid = false
+	return v
+}
+
+// schema is the subset of XML Schema the ModConfig schema uses: named
+// simple and complex types, sequences, choices, occurrence bounds,
+// attributes, enumerations, lengths and lower bounds.
+type schema struct {
+	simpleTypes  map[string]*simpleType
+	complexTypes map[string]*complexType
+	// roots maps the top-level element names to their types.
+	roots map[string]string
+}
+
+type simpleType struct {
+	base         string
+	enums        []string
+	length       int // -1 if unrestricted
+	minInclusive *int
+}
+
+type complexType struct {
+	// content is the model of child elements; nil if there are none.
+	content *particle
+	attrs   []attrDecl
+	// textType is the type of the text for simple content; empty if the
+	// element holds no text.
+	textType string
+}
+
+type attrDecl struct {
+	name, typ string
+	required  bool
+}
+
+// particle is an element, sequence or choice in a content model.
+type particle struct {
+	kind     string
+	name     string // for elements
+	typ      string // for elements
+	children []*particle
+	min, max int // max is -1 for unbounded
+}
+
+// xsdNode is a generic element of a schema document.
+type xsdNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []xsdNode  `xml:",any"`
+}
+
+func (n *xsdNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// parseSchema reads an XML Schema document.
+func parseSchema(data []byte) (*schema, error) {
+	var root xsdNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	s := &schema{
+		simpleTypes:  make(map[string]*simpleType),
+		complexTypes: make(map[string]*complexType),
+		roots:        make(map[string]string),
+	}
+	for i := range root.Nodes {
+		n := &root.Nodes[i]
+		switch n.XMLName.Local {
+		case "simpleType":
+			st, err := parseSimpleType(n)
+			if err != nil {
+				return nil, err
+			}
+			s.simpleTypes[n.attr("name")] = st
+		case "complexType":
+			ct, err := parseComplexType(n)
+			if err != nil {
+				return nil, err
+			}
+			s.complexTypes[n.attr("name")] = ct
+		case "element":
+			s.roots[n.attr("name")] = n.attr("type")
+		}
+	}
+	return s, nil
+}
+
+func parseSimpleType(n *xsdNode) (*simpleType, error) {
+	st := &simpleType{length: -1}
+	for i := range n.Nodes {
+		r := &n.Nodes[i]
+		if r.XMLName.Local != "restriction" {
+			return nil, fmt.Errorf("simple type %q: unsupported <%s>", n.attr("name"), r.XMLName.Local)
+		}
+		st.base = r.attr("base")
+		for j := range r.Nodes {
+			facet := &r.Nodes[j]
+			value := facet.attr("value")
+			switch facet.XMLName.Local {
+			case "enumeration":
+				st.enums = append(st.enums, value)
+			case "length":
+				l, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("simple type %q: invalid length %q", n.attr("name"), value)
+				}
+				st.length = l
+			case "minInclusive":
+				m, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("simple type %q: invalid minInclusive %q", n.attr("name"), value)
+				}
+				st.minInclusive = &m
+			default:
+				return nil, fmt.Errorf("simple type %q: unsupported facet <%s>", n.attr("name"), facet.XMLName.Local)
+			}
+		}
+	}
+	return st, nil
+}
+
+func parseComplexType(n *xsdNode) (*complexType, error) {
+	ct := &complexType{}
+	for i := range n.Nodes {
+		c := &n.Nodes[i]
+		switch c.XMLName.Local {
+		case "sequence", "choice":
+			p, err := parseParticle(c)
+			if err != nil {
+				return nil, fmt.Errorf("complex type %q: %w", n.attr("name"), err)
+			}
+			ct.content = p
+		case "attribute":
+			ct.attrs = append(ct.attrs, parseAttr(c))
+		case "simpleContent":
+			for j := range c.Nodes {
+				ext := &c.Nodes[j]
+				if ext.XMLName.Local != "extension" {
+					return nil, fmt.Errorf("complex type %q: unsupported <%s>", n.attr("name"), ext.XMLName.Local)
+				}
+				ct.textType = ext.attr("base")
+				for k := range ext.Nodes {
+					ct.attrs = append(ct.attrs, parseAttr(&ext.Nodes[k]))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("complex type %q: unsupported <%s>", n.attr("name"), c.XMLName.Local)
+		}
+	}
+	return ct, nil
+}
+
+func parseAttr(n *xsdNode) attrDecl {
+	return attrDecl{name: n.attr("name"), typ: n.attr("type"), required: n.attr("use") == "required"}
+}
+
+func parseParticle(n *xsdNode) (*particle, error) {
+	p := &particle{kind: n.XMLName.Local, min: 1, max: 1}
+	if v := n.attr("minOccurs"); v != "" {
+		p.min, _ = strconv.Atoi(v)
+	}
+	switch v := n.attr("maxOccurs"); v {
+	case "":
+	case "unbounded":
+		p.max = -1
+	default:
+		p.max, _ = strconv.Atoi(v)
+	}
+
+	switch p.kind {
+	case "element":
+		p.name, p.typ = n.attr("name"), n.attr("type")
+	case "sequence", "choice":
+		for i := range n.Nodes {
+			c, err := parseParticle(&n.Nodes[i])
+			if err != nil {
+				return nil, err
+			}
+			p.children = append(p.children, c)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported <%s>", p.kind)
+	}
+	return p, nil
+}
+
+// elementTypes maps the names of the elements in a content model to their types.
+func (p *particle) elementTypes(types map[string]string) {
+	if p == nil {
+		return
+	}
+	if p.kind == "element" {
+		types[p.name] = p.typ
+	}
+	for _, c := range p.children {
+		c.elementTypes(types)
+	}
+}
+
+// docNode is an element of the document being validated.
+type docNode struct {
+	name      string
+	line, col int
+	attrs     []xml.Attr
+	children  []*docNode
+	text      strings.Builder
+}
+
+// readDocument reads a document into a tree of elements with their
+// positions. It returns nil if the document is not well-formed or exceeds
+// the limits on untrusted XML, which Validate already reports.
+func readDocument(data []byte) *docNode {
+	if checkXMLSize(data) != nil {
+		return nil
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	tokens := &limitedReader{tokens: decoder}
+
+	var root *docNode
+	var stack []*docNode
+	for {
+		line, col := decoder.InputPos()
+		tok, err := tokens.Token()
+		if err == io.EOF {
+			return root
+		}
+		if err != nil {
+			return nil
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &docNode{name: t.Name.Local, line: line, col: col, attrs: t.Attr}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+}
+
+// validate checks a document against the schema.
+func (s *schema) validate(data []byte) []Annotation {
+	root := readDocument(data)
+	if root == nil {
+		return nil
+	}
+	v := &schemaValidator{schema: s}
+	typ, ok := s.roots[root.name]
+	if !ok {
+		v.add(root, "<%s> is not a root element of the schema", root.name)
+		return v.violations
+	}
+	v.element(root, typ)
+	return v.violations
+}
+
+// schemaValidator collects the violations of a document.
+type schemaValidator struct {
+	schema     *schema
+	violations []Annotation
+}
+
+func (v *schemaValidator) add(n *docNode, format string, args ...interface{}) {
+	v.violations = append(v.violations, Annotation{
+		Line:     n.line,
+		Column:   n.col,
+		Severity: SeverityError,
+		Rule:     "schema",
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// element checks an element and its descendants against a type.
+func (v *schemaValidator) element(n *docNode, typ string) {
+	ct, ok := v.schema.complexTypes[typ]
+	if !ok {
+		// Elements of simple types hold only text
+		for _, c := range n.children {
+			v.add(c, "<%s> is not allowed in <%s>, which holds only text", c.name, n.name)
+		}
+		if msg := v.checkValue(typ, n.text.String()); msg != "" {
+			v.add(n, "<%s> %s", n.name, msg)
+		}
+		return
+	}
+
+	v.attributes(n, ct)
+
+	text := strings.TrimSpace(n.text.String())
+	switch {
+	case ct.textType != "":
+		if msg := v.checkValue(ct.textType, n.text.String()); msg != "" {
+			v.add(n, "<%s> %s", n.name, msg)
+		}
+	case text != "":
+		v.add(n, "<%s> may not contain text", n.name)
+	}
+
+	if ct.content == nil {
+		for _, c := range n.children {
+			v.add(c, "<%s> is not allowed in <%s>", c.name, n.name)
+		}
+		return
+	}
+
+	m := &contentMatcher{kids: n.children, expected: make(map[string]bool)}
+	ends := m.occurs(ct.content, 0)
+	if !contains(ends, len(n.children)) {
+		expected := expectedList(m.expected)
+		if m.furthest < len(n.children) {
+			kid := n.children[m.furthest]
+			if expected == "" {
+				v.add(kid, "<%s> is not allowed here in <%s>", kid.name, n.name)
+			} else {
+				v.add(kid, "<%s> is not allowed here in <%s>; expected %s", kid.name, n.name, expected)
+			}
+		} else {
+			v.add(n, "<%s> is incomplete; expected %s", n.name, expected)
+		}
+	}
+
+	types := make(map[string]string)
+	ct.content.elementTypes(types)
+	for _, c := range n.children {
+		if childType, ok := types[c.name]; ok {
+			v.element(c, childType)
+		}
+	}
+}
+
+// attributes checks the attributes of an element against its type.
+func (v *schemaValidator) attributes(n *docNode, ct *complexType) {
+	declared := make(map[string]attrDecl, len(ct.attrs))
+	for _, a := range ct.attrs {
+		declared[a.name] = a
+	}
+	present := make(map[string]bool, len(n.attrs))
+	for _, a := range n.attrs {
+		// Namespace declarations and schema locations are not part of the content
+		if a.Name.Space != "" || a.Name.Local == "xmlns" {
+			continue
+		}
+		present[a.Name.Local] = true
+		decl, ok := declared[a.Name.Local]
+		if !ok {
+			v.add(n, "attribute %q is not allowed on <%s>", a.Name.Local, n.name)
+			continue
+		}
+		if msg := v.checkValue(decl.typ, a.Value); msg != "" {
+			v.add(n, "attribute %q of <%s> %s", a.Name.Local, n.name, msg)
+		}
+	}
+	for _, a := range ct.attrs {
+		if a.required && !present[a.name] {
+			v.add(n, "<%s> is missing the required attribute %q", n.name, a.name)
+		}
+	}
+}
+
+// checkValue checks a value against a simple type, describing the problem
+// if it doesn't conform.
+func (v *schemaValidator) checkValue(typ, value string) string {
+	st, ok := v.schema.simpleTypes[typ]
+	if !ok {
+		return checkBuiltin(typ, value)
+	}
+	value = strings.TrimSpace(value)
+	if msg := checkBuiltin(st.base, value); msg != "" {
+		return msg
+	}
+	if len(st.enums) > 0 {
+		found := false
+		for _, e := range st.enums {
+			if e == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("value %q is not one of %s", value, strings.Join(st.enums, ", "))
+		}
+	}
+	if st.length >= 0 {
+		length := len(value)
+		if st.base == "xs:hexBinary" {
+			length /= 2
+		}
+		if length != st.length {
+			return fmt.Sprintf("value %q must be %d long", value, st.length)
+		}
+	}
+	if st.minInclusive != nil {
+		if n, _ := strconv.Atoi(value); n < *st.minInclusive {
+			return fmt.Sprintf("value %q is less than %d", value, *st.minInclusive)
+		}
+	}
+	return ""
+}
+
+// checkBuiltin checks a value against a built-in XML Schema type.
+func checkBuiltin(typ, value string) string {
+	value = strings.TrimSpace(value)
+	switch typ {
+	case "xs:int":
+		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return fmt.Sprintf("value %q is not an integer", value)
+		}
+	case "xs:boolean":
+		switch value {
+		case "true", "false", "1", "0":
+		default:
+			return fmt.Sprintf("value %q is not true or false", value)
+		}
+	case "xs:hexBinary":
+		if _, err := hex.DecodeString(value); err != nil {
+			return fmt.Sprintf("value %q is not hexadecimal", value)
+		}
+	}
+	return ""
+}
+
+// contentMatcher matches child elements against a content model, keeping
+// track of how far any match got and which elements were expected there.
+type contentMatcher struct {
+	kids     []*docNode
+	furthest int
+	expected map[string]bool
+}
+
+// occurs returns the positions a particle, repeated within its bounds, can
+// end at when it starts at pos.
+func (m *contentMatcher) occurs(p *particle, pos int) []int {
+	var ends []int
+	if p.min == 0 {
+		ends = append(ends, pos)
+	}
+	seen := map[int]bool{pos: true}
+	current := []int{pos}
+	for i := 1; (p.max < 0 || i <= p.max) && len(current) > 0; i++ {
+		var next []int
+		for _, c := range current {
+			for _, e := range m.once(p, c) {
+				// Repeats past the minimum must make progress
+				if i > p.min && seen[e] {
+					continue
+				}
+				seen[e] = true
+				next = append(next, e)
+			}
+		}
+		if i >= p.min {
+			ends = append(ends, next...)
+		}
+		current = next
+	}
+	return ends
+}
+
+// once returns the positions a single occurrence of a particle can end at.
+func (m *contentMatcher) once(p *particle, pos int) []int {
+	switch p.kind {
+	case "element":
+		if pos < len(m.kids) && m.kids[pos].name == p.name {
+			m.reach(pos + 1)
+			return []int{pos + 1}
+		}
+		m.expect(pos, p.name)
+		return nil
+
+	case "sequence":
+		positions := []int{pos}
+		for _, c := range p.children {
+			var next []int
+			for _, start := range positions {
+				for _, e := range m.occurs(c, start) {
+					if !contains(next, e) {
+						next = append(next, e)
+					}
+				}
+			}
+			if len(next) == 0 {
+				return nil
+			}
+			positions = next
+		}
+		return positions
+
+	default: // choice
+		var ends []int
+		for _, c := range p.children {
+			for _, e := range m.occurs(c, pos) {
+				if !contains(ends, e) {
+					ends = append(ends, e)
+				}
+			}
+		}
+		return ends
+	}
+}
+
+func (m *contentMatcher) reach(pos int) {
+	if pos > m.furthest {
+		m.furthest = pos
+		m.expected = make(map[string]bool)
+	}
+}
+
+func (m *contentMatcher) expect(pos int, name string) {
+	m.reach(pos)
+	if pos == m.furthest {
+		m.expected[name] = true
+	}
+}
+
+func contains(positions []int, pos int) bool {
+	for _, p := range positions {
+		if p == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedList formats element names as "<a>, <b> or <c>".
+func expectedList(names map[string]bool) string {
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, "<"+name+">")
+	}
+	sort.Strings(list)
+	if len(list) <= 1 {
+		return strings.Join(list, "")
+	}
+	return strings.Join(list[:len(list)-1], ", ") + " or " + list[len(list)-1]
+}
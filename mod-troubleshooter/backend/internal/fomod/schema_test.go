@@ -0,0 +1,171 @@
+package fomod
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		line    int
+		message string
+	}{
+		{
+			name: "compliant",
+			xml: `<config xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="http://qconsulting.ca/fo3/ModConfig5.0.xsd">
+  <moduleName colour="FF00AA">Compliant</moduleName>
+  <installSteps order="Explicit">
+    <installStep name="Main">
+      <visible><flagDependency flag="a" value="On"/></visible>
+      <optionalFileGroups>
+        <group name="Style" type="SelectAny">
+          <plugins>
+            <plugin name="Dark">
+              <description>Dark style</description>
+              <conditionFlags><flag name="a">On</flag></conditionFlags>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+</config>`,
+		},
+		{
+			name: "elements out of order",
+			xml: `<config>
+  <installSteps order="Explicit">
+    <installStep name="Main"><optionalFileGroups><group name="G" type="SelectAny"><plugins>
+      <plugin name="P"><description/><files/><typeDescriptor><type name="Optional"/></typeDescriptor></plugin>
+    </plugins></group></optionalFileGroups></installStep>
+  </installSteps>
+  <moduleName>Late</moduleName>
+</config>`,
+			line:    2,
+			message: "<installSteps> is not allowed here in <config>; expected <moduleName>",
+		},
+		{
+			name: "missing description",
+			xml: `<config><moduleName>Mod</moduleName><installSteps><installStep name="Main">
+<optionalFileGroups><group name="G" type="SelectAny"><plugins>
+<plugin name="P">
+  <files/>
+  <typeDescriptor><type name="Optional"/></typeDescriptor>
+</plugin>
+</plugins></group></optionalFileGroups></installStep></installSteps></config>`,
+			line:    4,
+			message: "<files> is not allowed here in <plugin>; expected <description>",
+		},
+		{
+			name: "missing type descriptor",
+			xml: `<config><moduleName>Mod</moduleName><installSteps><installStep name="Main">
+<optionalFileGroups><group name="G" type="SelectAny"><plugins>
+<plugin name="P"><description/><files/></plugin>
+</plugins></group></optionalFileGroups></installStep></installSteps></config>`,
+			line:    3,
+			message: "<plugin> is incomplete; expected <conditionFlags> or <typeDescriptor>",
+		},
+		{
+			name:    "missing required attribute",
+			xml:     "<config>\n<moduleName>Mod</moduleName>\n<requiredInstallFiles><file destination=\"x\"/></requiredInstallFiles>\n</config>",
+			line:    3,
+			message: `<file> is missing the required attribute "source"`,
+		},
+		{
+			name:    "unknown attribute",
+			xml:     "<config>\n<moduleName align=\"Left\">Mod</moduleName>\n</config>",
+			line:    2,
+			message: `attribute "align" is not allowed on <moduleName>`,
+		},
+		{
+			name:    "invalid enumeration",
+			xml:     "<config>\n<moduleName position=\"Center\">Mod</moduleName>\n</config>",
+			line:    2,
+			message: `attribute "position" of <moduleName> value "Center" is not one of Left, Right, RightOfImage`,
+		},
+		{
+			name:    "invalid boolean",
+			xml:     "<config>\n<moduleName>Mod</moduleName>\n<requiredInstallFiles><folder source=\"a\" alwaysInstall=\"yes\"/></requiredInstallFiles>\n</config>",
+			line:    3,
+			message: `attribute "alwaysInstall" of <folder> value "yes" is not true or false`,
+		},
+		{
+			name:    "invalid colour",
+			xml:     "<config>\n<moduleName colour=\"red\">Mod</moduleName>\n</config>",
+			line:    2,
+			message: `attribute "colour" of <moduleName> value "red" is not hexadecimal`,
+		},
+		{
+			name:    "height below minimum",
+			xml:     "<config>\n<moduleName>Mod</moduleName>\n<moduleImage path=\"a.png\" height=\"-5\"/>\n</config>",
+			line:    3,
+			message: `attribute "height" of <moduleImage> value "-5" is less than -1`,
+		},
+		{
+			name:    "text in element-only content",
+			xml:     "<config>\n<moduleName>Mod</moduleName>\n<requiredInstallFiles>stray</requiredInstallFiles>\n</config>",
+			line:    3,
+			message: "<requiredInstallFiles> may not contain text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := ValidateStrict([]byte(tt.xml))
+			if v.Schema != SchemaVersion {
+				t.Errorf("Schema = %q, want %q", v.Schema, SchemaVersion)
+			}
+
+			var schemaErrors []Annotation
+			for _, a := range v.Annotations {
+				if a.Rule == "schema" {
+					schemaErrors = append(schemaErrors, a)
+				}
+			}
+			if tt.message == "" {
+				if len(schemaErrors) != 0 || !v.Valid {
+					t.Errorf("expected a compliant document, got %+v", v.Annotations)
+				}
+				return
+			}
+
+			if v.Valid {
+				t.Error("expected validation to fail")
+			}
+			if len(schemaErrors) != 1 {
+				t.Fatalf("expected 1 schema violation, got %+v", schemaErrors)
+			}
+			if schemaErrors[0].Line != tt.line {
+				t.Errorf("line = %d, want %d", schemaErrors[0].Line, tt.line)
+			}
+			if schemaErrors[0].Message != tt.message {
+				t.Errorf("message = %q, want %q", schemaErrors[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateStrict_Malformed(t *testing.T) {
+	v := ValidateStrict([]byte("<config><moduleName>Mod</config>"))
+	for _, a := range v.Annotations {
+		if a.Rule == "schema" {
+			t.Errorf("expected no schema violations for malformed XML, got %+v", a)
+		}
+	}
+	if v.Valid {
+		t.Error("expected validation to fail")
+	}
+}
+
+func TestParseSchema_BuiltIn(t *testing.T) {
+	s := modConfigSchema()
+	if s.roots["config"] != "moduleConfiguration" {
+		t.Errorf("expected <config> root of type moduleConfiguration, got %q", s.roots["config"])
+	}
+	if _, err := parseSchema([]byte(strings.Replace(string(modConfigXSD), "xs:restriction", "xs:union", 2))); err == nil {
+		t.Error("expected an error for unsupported schema constructs")
+	}
+}
@@ -0,0 +1,377 @@
+package fomod
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Choices are the options picked in an installer, in the form Vortex records
+// them in a collection's collection.json.
+type Choices struct {
+	// Type is "fomod" for FOMOD installers.
+	Type    string       `json:"type,omitempty"`
+	Options []StepChoice `json:"options"`
+}
+
+// StepChoice is the options picked on one install step.
+type StepChoice struct {
+	Name   string        `json:"name"`
+	Groups []GroupChoice `json:"groups"`
+}
+
+// GroupChoice is the options picked in one group of a step.
+type GroupChoice struct {
+	Name    string         `json:"name"`
+	Choices []OptionChoice `json:"choices"`
+}
+
+// OptionChoice is a picked option.
+type OptionChoice struct {
+	Name string `json:"name"`
+	// Idx is the option's position in its group.
+	Idx int `json:"idx"`
+}
+
+// group returns the picks recorded for a group, and false if the group was
+// not recorded at all.
+func (c *Choices) group(step, group string) ([]OptionChoice, bool) {
+	if c == nil {
+		return nil, false
+	}
+	for _, s := range c.Options {
+		if !strings.EqualFold(s.Name, step) {
+			continue
+		}
+		for _, g := range s.Groups {
+			if strings.EqualFold(g.Name, group) {
+				return g.Choices, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Installation is what an installer installs for a set of choices.
+type Installation struct {
+	// Files are the files and folders installed, in the order the installer
+	// copies them: by ascending priority, then in the order they were
+	// selected. Later ones overwrite earlier ones.
+	Files []InstalledFile `json:"files"`
+	// Flags are the condition flags set by the selected options.
+	Flags map[string]string `json:"flags"`
+}
+
+// InstalledFile is a file or folder of the archive and where it is installed.
+type InstalledFile struct {
+	// Source is the path in the archive, relative to the installer root.
+	Source string `json:"source"`
+	// Destination is the path in the game's data directory.
+	Destination string `json:"destination"`
+	// Folder is true when Source is a folder installed with its contents.
+	Folder   bool `json:"folder,omitempty"`
+	Priority int  `json:"priority,omitempty"`
+	// Condition is the choice the file depends on, if other choices would
+	// leave it out. Files of required options have none.
+	Condition *Condition `json:"condition,omitempty"`
+}
+
+// Condition is the installer choice a file is only installed with.
+type Condition struct {
+	// Options are the picked options the file depends on: the option that
+	// lists it, and those setting the flags it needs.
+	Options []OptionRef `json:"options"`
+	// Flags are the flags the file needs, as "name=value", when it is a
+	// conditional install or in a step only some choices show.
+	Flags []string `json:"flags,omitempty"`
+}
+
+// OptionRef names an option of an installer.
+type OptionRef struct {
+	Step   string `json:"step"`
+	Group  string `json:"group"`
+	Option string `json:"option"`
+}
+
+// join returns a condition needing both c and other. Either may be nil.
+func (c *Condition) join(other *Condition) *Condition {
+	if c == nil {
+		return other
+	}
+	if other == nil {
+		return c
+	}
+	joined := &Condition{
+		Options: append([]OptionRef{}, c.Options...),
+		Flags:   append([]string{}, c.Flags...),
+	}
+	for _, o := range other.Options {
+		if !containsOption(joined.Options, o) {
+			joined.Options = append(joined.Options, o)
+		}
+	}
+	for _, f := range other.Flags {
+		if !containsString(joined.Flags, f) {
+			joined.Flags = append(joined.Flags, f)
+		}
+	}
+	return joined
+}
+
+func containsOption(options []OptionRef, o OptionRef) bool {
+	for _, existing := range options {
+		if existing == o {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, existing := range values {
+		if existing == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Simulate works out what an installer installs when run with the given
+// choices. Steps are visited in document order, skipping those whose
+// visibility conditions don't hold. Groups the choices don't mention get the
+// installer's defaults: required and recommended options, or the first
+// usable option of groups that need one. Conditions on files and versions
+// are assumed to be met.
+//
+// Like the installers themselves, files with a higher priority are copied
+// after, and so overwrite, files with a lower one wherever they were listed.
+//
+// Files that other choices would leave out get a Condition naming the
+// options they depend on, directly or through the flags those options set.
+func Simulate(config *ModuleConfig, choices *Choices) *Installation {
+	inst := &Installation{Files: []InstalledFile{}, Flags: make(map[string]string)}
+	if config == nil {
+		return inst
+	}
+	has := func(flag, value string) bool { return inst.Flags[flag] == value }
+	// setBy holds the condition each flag was set under, nil for flags set
+	// by required options
+	setBy := make(map[string]*Condition)
+
+	inst.addFiles(config.RequiredInstallFiles, nil)
+
+	for _, step := range config.InstallSteps {
+		if step.Visible != nil && !satisfiable(step.Visible, has) {
+			continue
+		}
+		stepCond := inst.flagCondition(step.Visible, setBy)
+		for _, group := range step.OptionGroups {
+			picks, recorded := choices.group(step.Name, group.Name)
+			selected, forced := selectedOptions(group, picks, recorded, has)
+			for i, plugin := range group.Plugins {
+				if selected[i] {
+					cond := stepCond
+					if !forced[i] {
+						option := &Condition{Options: []OptionRef{{Step: step.Name, Group: group.Name, Option: plugin.Name}}}
+						cond = cond.join(option)
+					}
+					for _, flag := range plugin.ConditionFlags {
+						inst.Flags[flag.Name] = flag.Value
+						setBy[flag.Name] = cond
+					}
+					inst.addFiles(plugin.Files, cond)
+					continue
+				}
+				// Files can be installed even when their option isn't picked
+				usable := pluginType(plugin, has) != PluginNotUsable
+				inst.addFiles(unselectedFiles(plugin.Files, usable), stepCond)
+			}
+		}
+	}
+
+	for _, item := range config.ConditionalFileInstalls {
+		if item.Dependencies == nil || satisfiable(item.Dependencies, has) {
+			inst.addFiles(item.Files, inst.flagCondition(item.Dependencies, setBy))
+		}
+	}
+
+	sort.SliceStable(inst.Files, func(i, j int) bool { return inst.Files[i].Priority < inst.Files[j].Priority })
+	return inst
+}
+
+// flagCondition returns the condition of the flags a dependency needs that
+// hold, or nil if none of them was set by an option other choices skip.
+func (inst *Installation) flagCondition(dep *Dependency, setBy map[string]*Condition) *Condition {
+	var cond *Condition
+	for _, fd := range flagDependencies(dep) {
+		set := setBy[fd.Flag]
+		if set == nil || inst.Flags[fd.Flag] != fd.Value {
+			continue
+		}
+		cond = cond.join(set).join(&Condition{Flags: []string{fd.Flag + "=" + fd.Value}})
+	}
+	return cond
+}
+
+// selectedOptions reports which options of a group are selected, and which
+// of those are selected whatever the choices.
+func selectedOptions(group OptionGroup, picks []OptionChoice, recorded bool, has func(flag, value string) bool) (selected, forced []bool) {
+	selected = make([]bool, len(group.Plugins))
+	forced = make([]bool, len(group.Plugins))
+	for i, plugin := range group.Plugins {
+		typ := pluginType(plugin, has)
+		switch {
+		case group.Type == GroupSelectAll, typ == PluginRequired:
+			selected[i] = true
+			forced[i] = true
+		case recorded:
+			selected[i] = picked(picks, plugin.Name, i)
+		default:
+			selected[i] = typ == PluginRecommended
+		}
+	}
+	if recorded || (group.Type != GroupSelectExactlyOne && group.Type != GroupSelectAtLeastOne) {
+		return selected, forced
+	}
+	for _, ok := range selected {
+		if ok {
+			return selected, forced
+		}
+	}
+	for i, plugin := range group.Plugins {
+		if pluginType(plugin, has) != PluginNotUsable {
+			selected[i] = true
+			break
+		}
+	}
+	return selected, forced
+}
+
+// picked reports whether the option with the given name and position is
+// among the picks. Names identify options; the position breaks ties
+// between options with the same name.
+func picked(picks []OptionChoice, name string, idx int) bool {
+	for _, p := range picks {
+		if p.Name == name && p.Idx == idx {
+			return true
+		}
+	}
+	for _, p := range picks {
+		if strings.EqualFold(p.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginType resolves the type of an option given the current flags.
+func pluginType(plugin Plugin, has func(flag, value string) bool) PluginType {
+	td := plugin.TypeDescriptor
+	switch {
+	case td == nil:
+		return PluginOptional
+	case td.DependencyType != nil:
+		for _, pattern := range td.DependencyType.Patterns {
+			if pattern.Dependencies == nil || satisfiable(pattern.Dependencies, has) {
+				return pattern.Type
+			}
+		}
+		return td.DependencyType.DefaultType
+	case td.Type != "":
+		return td.Type
+	default:
+		return PluginOptional
+	}
+}
+
+// unselectedFiles returns the files of an option that are installed even
+// though it wasn't selected.
+func unselectedFiles(files *FileList, usable bool) *FileList {
+	if files == nil {
+		return nil
+	}
+	kept := &FileList{}
+	for _, f := range files.Files {
+		if f.AlwaysInstall || (f.InstallIfUsable && usable) {
+			kept.Files = append(kept.Files, f)
+		}
+	}
+	for _, f := range files.Folders {
+		if f.AlwaysInstall || (f.InstallIfUsable && usable) {
+			kept.Folders = append(kept.Folders, f)
+		}
+	}
+	return kept
+}
+
+// addFiles appends a file list to the installation, installed under cond.
+// A file without a destination keeps its source path; a folder without one
+// is installed to the root of the data directory.
+func (inst *Installation) addFiles(files *FileList, cond *Condition) {
+	if files == nil {
+		return
+	}
+	for _, f := range files.Files {
+		dest := f.Destination
+		if dest == "" {
+			dest = f.Source
+		}
+		inst.Files = append(inst.Files, InstalledFile{
+			Source: installPath(f.Source), Destination: installPath(dest), Priority: f.Priority, Condition: cond,
+		})
+	}
+	for _, f := range files.Folders {
+		inst.Files = append(inst.Files, InstalledFile{
+			Source: installPath(f.Source), Destination: installPath(f.Destination), Folder: true, Priority: f.Priority, Condition: cond,
+		})
+	}
+}
+
+// Placement is a copy of an archive file made by an installation.
+type Placement struct {
+	// Destination is the path in the game's data directory.
+	Destination string `json:"destination"`
+	// Order is the position in Files of the entry that makes the copy. Where
+	// two copies land on the same destination, the higher order wins.
+	Order int `json:"order"`
+	// Condition is the choice the copy depends on, if any.
+	Condition *Condition `json:"condition,omitempty"`
+}
+
+// Placements returns the copies the installation makes of the archive file
+// at name, a path relative to the installer root, in the order they are
+// made. It returns nothing for files the installation leaves out.
+func (inst *Installation) Placements(name string) []Placement {
+	name = installPath(name)
+	var placements []Placement
+	for i, f := range inst.Files {
+		switch {
+		case !f.Folder && f.Source == name:
+			placements = append(placements, Placement{Destination: f.Destination, Order: i, Condition: f.Condition})
+		case f.Folder && f.Source == "":
+			placements = append(placements, Placement{Destination: path.Join(f.Destination, name), Order: i, Condition: f.Condition})
+		case f.Folder && strings.HasPrefix(name, f.Source+"/"):
+			dest := path.Join(f.Destination, strings.TrimPrefix(name, f.Source+"/"))
+			placements = append(placements, Placement{Destination: dest, Order: i, Condition: f.Condition})
+		}
+	}
+	return placements
+}
+
+// Destinations returns where the installation puts the archive file at
+// name, in the order it is copied there.
+func (inst *Installation) Destinations(name string) []string {
+	var dests []string
+	for _, p := range inst.Placements(name) {
+		dests = append(dests, p.Destination)
+	}
+	return dests
+}
+
+// installPath normalizes an installer path for comparison: forward slashes,
+// lowercase, and no leading or trailing slashes or dot segments.
+func installPath(p string) string {
+	p = strings.ToLower(strings.ReplaceAll(p, "\\", "/"))
+	p = strings.Trim(path.Clean("/"+p), "/")
+	return p
+}
@@ -0,0 +1,189 @@
+package fomod
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	config, err := ParseModuleConfigFromReader(strings.NewReader(flagsXML))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		choices *Choices
+		dests   map[string][]string
+	}{
+		{
+			name: "2K",
+			choices: &Choices{Options: []StepChoice{{
+				Name:   "Textures",
+				Groups: []GroupChoice{{Name: "Resolution", Choices: []OptionChoice{{Name: "2K", Idx: 0}}}},
+			}}},
+			dests: map[string][]string{"4k/textures/a.dds": nil},
+		},
+		{
+			name: "4K",
+			choices: &Choices{Options: []StepChoice{{
+				Name:   "Textures",
+				Groups: []GroupChoice{{Name: "Resolution", Choices: []OptionChoice{{Name: "4K", Idx: 1}}}},
+			}}},
+			dests: map[string][]string{"4K\\Textures\\A.dds": {"textures/a.dds"}},
+		},
+		{
+			name:  "installer defaults",
+			dests: map[string][]string{"4k/textures/a.dds": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := Simulate(config, tt.choices)
+			for name, want := range tt.dests {
+				if got := inst.Destinations(name); !reflect.DeepEqual(got, want) {
+					t.Errorf("Destinations(%q) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulate_Files(t *testing.T) {
+	config, err := ParseModuleConfigFromReader(strings.NewReader(`<config>
+  <moduleName>Files</moduleName>
+  <requiredInstallFiles><file source="core\Core.esp"/></requiredInstallFiles>
+  <installSteps order="Explicit">
+    <installStep name="Main">
+      <optionalFileGroups>
+        <group name="Style" type="SelectAny">
+          <plugins>
+            <plugin name="Dark">
+              <description/>
+              <files><folder source="dark" destination="interface"/></files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+            <plugin name="Light">
+              <description/>
+              <files>
+                <folder source="light" destination="interface"/>
+                <file source="docs/light.txt" destination="docs/light.txt" alwaysInstall="true"/>
+              </files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+            <plugin name="Patch">
+              <description/>
+              <files><file source="patch.esp" destination=""/></files>
+              <typeDescriptor><type name="Required"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+</config>`))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+
+	inst := Simulate(config, &Choices{Options: []StepChoice{{
+		Name:   "Main",
+		Groups: []GroupChoice{{Name: "Style", Choices: []OptionChoice{{Name: "Dark", Idx: 0}}}},
+	}}})
+
+	want := map[string][]string{
+		"core/core.esp":      {"core/core.esp"},
+		"dark/menu.swf":      {"interface/menu.swf"},
+		"light/menu.swf":     nil,
+		"docs/light.txt":     {"docs/light.txt"},
+		"patch.esp":          {"patch.esp"},
+		"unlisted/readme.md": nil,
+	}
+	for name, dests := range want {
+		if got := inst.Destinations(name); !reflect.DeepEqual(got, dests) {
+			t.Errorf("Destinations(%q) = %v, want %v", name, got, dests)
+		}
+	}
+}
+
+func TestSimulate_Conditions(t *testing.T) {
+	config, err := ParseModuleConfigFromReader(strings.NewReader(flagsXML))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+
+	inst := Simulate(config, &Choices{Options: []StepChoice{{
+		Name:   "Textures",
+		Groups: []GroupChoice{{Name: "Resolution", Choices: []OptionChoice{{Name: "4K", Idx: 1}}}},
+	}}})
+
+	// The conditional install only happens because 4K set its flag
+	placements := inst.Placements("4k/textures/a.dds")
+	if len(placements) != 1 {
+		t.Fatalf("Placements() = %+v, want one", placements)
+	}
+	want := &Condition{
+		Options: []OptionRef{{Step: "Textures", Group: "Resolution", Option: "4K"}},
+		Flags:   []string{"res=4K"},
+	}
+	if got := placements[0].Condition; !reflect.DeepEqual(got, want) {
+		t.Errorf("Condition = %+v, want %+v", got, want)
+	}
+
+	// Files of required options don't depend on any choice
+	config, err = ParseModuleConfigFromReader(strings.NewReader(`<config>
+  <moduleName>Required</moduleName>
+  <requiredInstallFiles><file source="core.esp"/></requiredInstallFiles>
+  <installSteps order="Explicit">
+    <installStep name="Main">
+      <optionalFileGroups>
+        <group name="Patches" type="SelectAll">
+          <plugins>
+            <plugin name="Patch">
+              <description/>
+              <files><file source="patch.esp"/></files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+</config>`))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+	for _, f := range Simulate(config, nil).Files {
+		if f.Condition != nil {
+			t.Errorf("%s: Condition = %+v, want none", f.Source, f.Condition)
+		}
+	}
+}
+
+func TestSimulate_Priority(t *testing.T) {
+	config, err := ParseModuleConfigFromReader(strings.NewReader(`<config>
+  <moduleName>Priority</moduleName>
+  <requiredInstallFiles>
+    <folder source="patched" destination="" priority="1"/>
+    <folder source="base" destination=""/>
+    <file source="extra/a.esp" destination="a.esp" priority="-1"/>
+  </requiredInstallFiles>
+</config>`))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+
+	inst := Simulate(config, nil)
+	var order []string
+	for _, f := range inst.Files {
+		order = append(order, f.Source)
+	}
+	if want := []string{"extra/a.esp", "base", "patched"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("install order = %v, want %v", order, want)
+	}
+	if got := inst.Destinations("base/a.esp"); !reflect.DeepEqual(got, []string{"a.esp"}) {
+		t.Errorf("Destinations(base/a.esp) = %v", got)
+	}
+}
@@ -4,11 +4,11 @@ package fomod
 type GroupType string
 
 const (
-	GroupSelectAtLeastOne  GroupType = "SelectAtLeastOne"
-	GroupSelectAtMostOne   GroupType = "SelectAtMostOne"
-	GroupSelectExactlyOne  GroupType = "SelectExactlyOne"
-	GroupSelectAll         GroupType = "SelectAll"
-	GroupSelectAny         GroupType = "SelectAny"
+	GroupSelectAtLeastOne GroupType = "SelectAtLeastOne"
+	GroupSelectAtMostOne  GroupType = "SelectAtMostOne"
+	GroupSelectExactlyOne GroupType = "SelectExactlyOne"
+	GroupSelectAll        GroupType = "SelectAll"
+	GroupSelectAny        GroupType = "SelectAny"
 )
 
 // PluginType represents the installation status/recommendation of a plugin.
@@ -77,9 +77,9 @@ type HeaderImage struct {
 
 // InstallStep represents a single installation step/page.
 type InstallStep struct {
-	Name         string           `json:"name"`
-	Visible      *Dependency      `json:"visible,omitempty"`
-	OptionGroups []OptionGroup    `json:"optionGroups,omitempty"`
+	Name         string        `json:"name"`
+	Visible      *Dependency   `json:"visible,omitempty"`
+	OptionGroups []OptionGroup `json:"optionGroups,omitempty"`
 }
 
 // OptionGroup represents a group of plugins with selection constraints.
@@ -110,8 +110,8 @@ type TypeDescriptor struct {
 
 // DependencyPluginType describes a plugin type that depends on conditions.
 type DependencyPluginType struct {
-	DefaultType PluginType            `json:"defaultType"`
-	Patterns    []DependencyPattern   `json:"patterns,omitempty"`
+	DefaultType PluginType          `json:"defaultType"`
+	Patterns    []DependencyPattern `json:"patterns,omitempty"`
 }
 
 // DependencyPattern maps a dependency condition to a plugin type.
@@ -202,4 +202,9 @@ type CompositeDependency = Dependency
 type FomodData struct {
 	Info   *Info         `json:"info,omitempty"`
 	Config *ModuleConfig `json:"config"`
+	// Flags shows which options set each condition flag and what reads it.
+	Flags *FlagGraph `json:"flags,omitempty"`
+	// Diagnostics describe mistakes in ModuleConfig.xml that lenient parsing
+	// recovered from.
+	Diagnostics []Annotation `json:"diagnostics,omitempty"`
 }
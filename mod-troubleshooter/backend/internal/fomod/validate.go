@@ -0,0 +1,347 @@
+package fomod
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Severity is how serious a validation finding is.
+type Severity string
+
+const (
+	// SeverityError findings make mod managers reject or misinstall the mod.
+	SeverityError Severity = "error"
+	// SeverityWarning findings are likely mistakes that still install.
+	SeverityWarning Severity = "warning"
+)
+
+// Annotation is a problem found in ModuleConfig.xml, mapped to the line
+// that caused it.
+type Annotation struct {
+	// Line and Column are 1-based, or 0 if unknown.
+	Line     int      `json:"line"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	// Rule identifies the check, such as "empty-group".
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Validation is the result of validating a ModuleConfig.xml.
+type Validation struct {
+	// Source is the original text, so annotations can be shown in place.
+	Source string `json:"source"`
+	// Valid is false if any annotation is an error.
+	Valid       bool         `json:"valid"`
+	Annotations []Annotation `json:"annotations"`
+	// Config is the parsed configuration, if it could be parsed.
+	Config *ModuleConfig `json:"config,omitempty"`
+	// Schema names the schema the document was checked against in strict mode.
+	Schema string `json:"schema,omitempty"`
+}
+
+// Validate parses a ModuleConfig.xml and checks it for mistakes mod
+// managers trip over, annotating each with its line. The configuration is
+// parsed leniently, so mistakes it recovers from are annotated as well.
+func Validate(data []byte) *Validation {
+	l := lint(data)
+	v := &Validation{
+		Source:      strings.ToValidUTF8(string(data), "\uFFFD"),
+		Annotations: l.annotations,
+	}
+
+	config, diags, err := ParseModuleConfigLenient(data)
+	if err == nil {
+		v.Config = config
+		for _, i := range UnreachableSteps(config) {
+			if i >= len(l.steps) {
+				continue
+			}
+			v.Annotations = append(v.Annotations, Annotation{
+				Line:     l.steps[i][0],
+				Column:   l.steps[i][1],
+				Severity: SeverityWarning,
+				Rule:     "unreachable-step",
+				Message: fmt.Sprintf("install step %q is never shown: no option in another step sets the flags it needs",
+					config.InstallSteps[i].Name),
+			})
+		}
+	}
+	found := make(map[string]bool, len(v.Annotations))
+	for _, a := range v.Annotations {
+		found[fmt.Sprintf("%d:%s", a.Line, a.Rule)] = true
+	}
+	for _, d := range diags {
+		if !found[fmt.Sprintf("%d:%s", d.Line, d.Rule)] {
+			v.Annotations = append(v.Annotations, d)
+		}
+	}
+	v.Annotations = sortAnnotations(v.Annotations)
+
+	v.Valid = true
+	for _, a := range v.Annotations {
+		if a.Severity == SeverityError {
+			v.Valid = false
+			break
+		}
+	}
+	return v
+}
+
+// lintNode is an open element during linting.
+type lintNode struct {
+	name      string
+	line, col int
+	attrs     []xml.Attr
+	// children counts the groups of a step or the plugins of a group.
+	children int
+	// names holds the plugin names of a group.
+	names map[string]bool
+	text  strings.Builder
+}
+
+func (n *lintNode) attr(name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// flagUse is a flag dependency, checked once every set flag is known.
+type flagUse struct {
+	flag      string
+	line, col int
+}
+
+// linter holds the state of a lint walk.
+type linter struct {
+	annotations []Annotation
+	stack       []*lintNode
+	setFlags    map[string]bool
+	flagUses    []flagUse
+	// steps holds the line and column of each install step, in order.
+	steps [][2]int
+}
+
+func (l *linter) add(line, col int, sev Severity, rule, format string, args ...interface{}) {
+	l.annotations = append(l.annotations, Annotation{
+		Line:     line,
+		Column:   col,
+		Severity: sev,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// nearest returns the innermost open element with the given name.
+func (l *linter) nearest(name string) *lintNode {
+	for i := len(l.stack) - 1; i >= 0; i-- {
+		if l.stack[i].name == name {
+			return l.stack[i]
+		}
+	}
+	return nil
+}
+
+// lint walks the XML tokens of a ModuleConfig.xml, reporting problems at
+// the elements that cause them.
+func lint(data []byte) *linter {
+	l := &linter{setFlags: make(map[string]bool)}
+	if err := checkXMLSize(data); err != nil {
+		l.add(1, 1, SeverityError, "xml-limit", "%v", err)
+		return l
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	tokens := &limitedReader{tokens: decoder}
+
+	var root *lintNode
+	moduleName := false
+	for {
+		// The position before a start tag is read is where the tag begins
+		line, col := decoder.InputPos()
+		tok, err := tokens.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if limitError(err) {
+				l.add(line, col, SeverityError, "xml-limit", "%v", err)
+			} else if errors.As(err, &syntaxErr) {
+				l.add(syntaxErr.Line, 0, SeverityError, "xml-syntax", "%s", syntaxErr.Msg)
+			} else {
+				l.add(line, col, SeverityError, "xml-syntax", "%v", err)
+			}
+			l.annotations = sortAnnotations(l.annotations)
+			return l
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &lintNode{name: t.Name.Local, line: line, col: col, attrs: t.Attr}
+			if root == nil {
+				root = node
+				if node.name != "config" {
+					l.add(node.line, node.col, SeverityError, "root-element",
+						"root element is <%s>, expected <config>", node.name)
+				}
+			}
+			l.start(node)
+			l.stack = append(l.stack, node)
+
+		case xml.CharData:
+			if len(l.stack) > 0 {
+				l.stack[len(l.stack)-1].text.Write(t)
+			}
+
+		case xml.EndElement:
+			if len(l.stack) == 0 {
+				continue
+			}
+			node := l.stack[len(l.stack)-1]
+			l.stack = l.stack[:len(l.stack)-1]
+			if node.name == "moduleName" && len(l.stack) == 1 && strings.TrimSpace(node.text.String()) != "" {
+				moduleName = true
+			}
+			l.end(node)
+		}
+	}
+
+	if root == nil {
+		l.add(1, 0, SeverityError, "xml-syntax", "document has no root element")
+		return l
+	}
+	if root.name == "config" && !moduleName {
+		l.add(root.line, root.col, SeverityError, "missing-module-name", "<moduleName> is required")
+	}
+	for _, use := range l.flagUses {
+		if !l.setFlags[use.flag] {
+			l.add(use.line, use.col, SeverityWarning, "unset-flag",
+				"flag %q is never set by any plugin, so this condition can't change", use.flag)
+		}
+	}
+	l.annotations = sortAnnotations(l.annotations)
+	return l
+}
+
+// start checks an element when it opens.
+func (l *linter) start(node *lintNode) {
+	switch node.name {
+	case "installStep":
+		if l.nearest("installSteps") != nil {
+			l.steps = append(l.steps, [2]int{node.line, node.col})
+		}
+
+	case "group":
+		if step := l.nearest("installStep"); step != nil {
+			step.children++
+		}
+		node.names = make(map[string]bool)
+		typ, _ := node.attr("type")
+		if !validGroupType(typ) {
+			l.add(node.line, node.col, SeverityError, "unknown-group-type",
+				"group type %q is not one of SelectAtLeastOne, SelectAtMostOne, SelectExactlyOne, SelectAll or SelectAny", typ)
+		}
+
+	case "plugin":
+		group := l.nearest("group")
+		if group == nil {
+			return
+		}
+		group.children++
+		name, _ := node.attr("name")
+		key := strings.ToLower(strings.TrimSpace(name))
+		if group.names[key] {
+			l.add(node.line, node.col, SeverityWarning, "duplicate-plugin",
+				"option %q appears more than once in this group", name)
+		}
+		group.names[key] = true
+
+	case "type", "defaultType":
+		name, _ := node.attr("name")
+		if !validPluginType(name) {
+			l.add(node.line, node.col, SeverityError, "unknown-plugin-type",
+				"plugin type %q is not one of Required, Optional, Recommended, NotUsable or CouldBeUsable", name)
+		}
+
+	case "file", "folder":
+		if source, _ := node.attr("source"); strings.TrimSpace(source) == "" {
+			l.add(node.line, node.col, SeverityError, "empty-source", "<%s> has no source", node.name)
+		}
+
+	case "flag":
+		if l.nearest("conditionFlags") != nil {
+			name, _ := node.attr("name")
+			l.setFlags[name] = true
+		}
+
+	case "flagDependency":
+		flag, _ := node.attr("flag")
+		l.flagUses = append(l.flagUses, flagUse{flag: flag, line: node.line, col: node.col})
+	}
+}
+
+// end checks an element once all of its children are known.
+func (l *linter) end(node *lintNode) {
+	switch node.name {
+	case "installStep":
+		if node.children == 0 {
+			name, _ := node.attr("name")
+			l.add(node.line, node.col, SeverityWarning, "empty-step", "install step %q has no option groups", name)
+		}
+
+	case "group":
+		if node.children > 0 {
+			return
+		}
+		name, _ := node.attr("name")
+		typ, _ := node.attr("type")
+		// A group that needs a selection can't be completed without options
+		severity := SeverityWarning
+		if GroupType(typ) == GroupSelectExactlyOne || GroupType(typ) == GroupSelectAtLeastOne {
+			severity = SeverityError
+		}
+		l.add(node.line, node.col, severity, "empty-group", "group %q has no options", name)
+	}
+}
+
+func validGroupType(t string) bool {
+	switch GroupType(t) {
+	case GroupSelectAtLeastOne, GroupSelectAtMostOne, GroupSelectExactlyOne, GroupSelectAll, GroupSelectAny:
+		return true
+	}
+	return false
+}
+
+func validPluginType(t string) bool {
+	switch PluginType(t) {
+	case PluginRequired, PluginOptional, PluginRecommended, PluginNotUsable, PluginCouldBeUsable:
+		return true
+	}
+	return false
+}
+
+// sortAnnotations orders annotations by position in the document.
+func sortAnnotations(annotations []Annotation) []Annotation {
+	if annotations == nil {
+		return []Annotation{}
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		if annotations[i].Line != annotations[j].Line {
+			return annotations[i].Line < annotations[j].Line
+		}
+		return annotations[i].Column < annotations[j].Column
+	})
+	return annotations
+}
@@ -0,0 +1,159 @@
+package fomod
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<config>
+  <moduleName>Test Mod</moduleName>
+  <installSteps order="Explicit">
+    <installStep name="Options">
+      <optionalFileGroups>
+        <group name="Textures" type="SelectExactlyOne">
+          <plugins>
+            <plugin name="2K">
+              <files><file source=""/></files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+            <plugin name="2k">
+              <typeDescriptor><type name="Maybe"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+        <group name="Empty" type="SelectExactlyOne">
+        </group>
+      </optionalFileGroups>
+    </installStep>
+    <installStep name="Nothing"/>
+  </installSteps>
+  <conditionalFileInstalls>
+    <patterns>
+      <pattern>
+        <dependencies><flagDependency flag="hd" value="On"/></dependencies>
+      </pattern>
+    </patterns>
+  </conditionalFileInstalls>
+</config>`
+
+	v := Validate([]byte(xml))
+	if v.Source != xml {
+		t.Error("expected the original source to be returned")
+	}
+	if v.Valid {
+		t.Error("expected validation to fail")
+	}
+	if v.Config == nil || v.Config.ModuleName != "Test Mod" {
+		t.Errorf("expected the config to be parsed, got %+v", v.Config)
+	}
+
+	want := []struct {
+		line int
+		rule string
+	}{
+		{10, "empty-source"},
+		{13, "duplicate-plugin"},
+		{14, "unknown-plugin-type"},
+		{18, "empty-group"},
+		{22, "empty-step"},
+		{27, "unset-flag"},
+	}
+	if len(v.Annotations) != len(want) {
+		t.Fatalf("expected %d annotations, got %+v", len(want), v.Annotations)
+	}
+	for i, w := range want {
+		got := v.Annotations[i]
+		if got.Line != w.line || got.Rule != w.rule {
+			t.Errorf("annotation %d = line %d %s, want line %d %s", i, got.Line, got.Rule, w.line, w.rule)
+		}
+	}
+	if col := v.Annotations[0].Column; col != 22 {
+		t.Errorf("expected column 22 for the empty source, got %d", col)
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		line int
+		rule string
+	}{
+		{
+			name: "syntax error",
+			xml:  "<config>\n  <moduleName>Mod</moduleName>\n  <installSteps>\n</config>",
+			line: 4,
+			rule: "xml-syntax",
+		},
+		{
+			name: "missing module name",
+			xml:  "\n<config>\n</config>",
+			line: 2,
+			rule: "missing-module-name",
+		},
+		{
+			name: "wrong root element",
+			xml:  "<fomod><moduleName>Mod</moduleName></fomod>",
+			line: 1,
+			rule: "root-element",
+		},
+		{
+			name: "unknown group type",
+			xml:  "<config><moduleName>Mod</moduleName>\n<installSteps><installStep name=\"A\"><optionalFileGroups>\n<group name=\"G\" type=\"PickOne\"><plugins><plugin name=\"P\"/></plugins></group>\n</optionalFileGroups></installStep></installSteps></config>",
+			line: 3,
+			rule: "unknown-group-type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Validate([]byte(tt.xml))
+			if v.Valid {
+				t.Error("expected validation to fail")
+			}
+			for _, a := range v.Annotations {
+				if a.Rule == tt.rule {
+					if a.Line != tt.line {
+						t.Errorf("expected %s on line %d, got %d", tt.rule, tt.line, a.Line)
+					}
+					return
+				}
+			}
+			t.Errorf("expected a %s annotation, got %+v", tt.rule, v.Annotations)
+		})
+	}
+}
+
+func TestValidate_Clean(t *testing.T) {
+	xml := `<config>
+  <moduleName>Clean</moduleName>
+  <installSteps order="Explicit">
+    <installStep name="Main">
+      <optionalFileGroups>
+        <group name="Style" type="SelectAny">
+          <plugins>
+            <plugin name="Dark">
+              <conditionFlags><flag name="dark">On</flag></conditionFlags>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+  <conditionalFileInstalls><patterns><pattern>
+    <dependencies><flagDependency flag="dark" value="On"/></dependencies>
+    <files><folder source="dark"/></files>
+  </pattern></patterns></conditionalFileInstalls>
+</config>`
+
+	v := Validate([]byte(xml))
+	if !v.Valid || len(v.Annotations) != 0 {
+		t.Errorf("expected a clean validation, got %+v", v.Annotations)
+	}
+	if !strings.Contains(v.Source, "Clean") {
+		t.Error("expected the source to be returned")
+	}
+}
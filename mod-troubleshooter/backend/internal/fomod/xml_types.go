@@ -45,7 +45,7 @@ type xmlInstallSteps struct {
 
 type xmlInstallStep struct {
 	Name               string                  `xml:"name,attr"`
-	Visible            *xmlCompositeDependency `xml:"visible>dependencies"`
+	Visible            *xmlCompositeDependency `xml:"visible"`
 	OptionalFileGroups *xmlOptionalFileGroups  `xml:"optionalFileGroups"`
 }
 
@@ -66,12 +66,12 @@ type xmlPlugins struct {
 }
 
 type xmlPlugin struct {
-	Name           string              `xml:"name,attr"`
-	Description    string              `xml:"description"`
-	Image          *xmlImage           `xml:"image"`
-	Files          *xmlFileList        `xml:"files"`
-	ConditionFlags *xmlConditionFlags  `xml:"conditionFlags"`
-	TypeDescriptor *xmlTypeDescriptor  `xml:"typeDescriptor"`
+	Name           string             `xml:"name,attr"`
+	Description    string             `xml:"description"`
+	Image          *xmlImage          `xml:"image"`
+	Files          *xmlFileList       `xml:"files"`
+	ConditionFlags *xmlConditionFlags `xml:"conditionFlags"`
+	TypeDescriptor *xmlTypeDescriptor `xml:"typeDescriptor"`
 }
 
 type xmlImage struct {
@@ -88,8 +88,8 @@ type xmlPluginType struct {
 }
 
 type xmlDependencyPluginType struct {
-	DefaultType *xmlPluginType      `xml:"defaultType"`
-	Patterns    *xmlPatternList     `xml:"patterns"`
+	DefaultType *xmlPluginType  `xml:"defaultType"`
+	Patterns    *xmlPatternList `xml:"patterns"`
 }
 
 type xmlPatternList struct {
@@ -132,12 +132,12 @@ type xmlFolder struct {
 }
 
 type xmlCompositeDependency struct {
-	Operator         string                    `xml:"operator,attr"`
-	FileDependencies []xmlFileDependency       `xml:"fileDependency"`
-	FlagDependencies []xmlFlagDependency       `xml:"flagDependency"`
-	GameDependencies []xmlVersionDependency    `xml:"gameDependency"`
-	FommDependencies []xmlVersionDependency    `xml:"fommDependency"`
-	Dependencies     []xmlCompositeDependency  `xml:"dependencies"`
+	Operator         string                   `xml:"operator,attr"`
+	FileDependencies []xmlFileDependency      `xml:"fileDependency"`
+	FlagDependencies []xmlFlagDependency      `xml:"flagDependency"`
+	GameDependencies []xmlVersionDependency   `xml:"gameDependency"`
+	FommDependencies []xmlVersionDependency   `xml:"fommDependency"`
+	Dependencies     []xmlCompositeDependency `xml:"dependencies"`
 }
 
 type xmlFileDependency struct {
@@ -0,0 +1,250 @@
+// Package grpcapi serves the analyzers over gRPC, as described in
+// proto/modtroubleshooter/v1/analyzer.proto. The services run the same
+// analysis path as the HTTP handlers; only the encoding differs.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/handlers"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+	pb "github.com/mod-troubleshooter/backend/proto/modtroubleshooter/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Analyzer runs analyses for the services. *handlers.AnalyzeHandler implements it.
+type Analyzer interface {
+	// Run analyzes a collection revision with the named analyzers.
+	Run(ctx context.Context, slug string, revision int, names []string) (*handlers.CollectionAnalyzeResponse, error)
+	// Manifest downloads a mod file and lists its contents.
+	Manifest(ctx context.Context, gameDomain string, modID, fileID int, hashes bool) (*manifest.Manifest, error)
+}
+
+// Register adds the analyzer, manifest and plugin header services to s.
+func Register(s grpc.ServiceRegistrar, analyzer Analyzer) {
+	pb.RegisterAnalyzerServiceServer(s, &analyzerService{analyzer: analyzer})
+	pb.RegisterManifestServiceServer(s, &manifestService{analyzer: analyzer})
+	pb.RegisterPluginHeaderServiceServer(s, &pluginHeaderService{parser: plugin.NewParser()})
+}
+
+// analyzerService implements pb.AnalyzerServiceServer.
+type analyzerService struct {
+	pb.UnimplementedAnalyzerServiceServer
+	analyzer Analyzer
+}
+
+// Analyze streams progress while the collection is gathered, then the result.
+func (s *analyzerService) Analyze(req *pb.AnalyzeRequest, stream grpc.ServerStreamingServer[pb.AnalyzeEvent]) error {
+	if req.GetSlug() == "" {
+		return status.Error(codes.InvalidArgument, "slug is required")
+	}
+
+	// Progress arrives from the analysis goroutine, which may outlive this
+	// call when other requests share the analysis
+	var mu sync.Mutex
+	done := false
+	ctx := pipeline.WithProgress(stream.Context(), func(p pipeline.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			stream.Send(&pb.AnalyzeEvent{Event: &pb.AnalyzeEvent_Progress{Progress: progressMessage(p)}})
+		}
+	})
+
+	resp, err := s.analyzer.Run(ctx, req.GetSlug(), int(req.GetRevision()), req.GetAnalyzers())
+
+	mu.Lock()
+	done = true
+	mu.Unlock()
+
+	if err != nil {
+		return toStatus(err)
+	}
+	result, err := analyzeResult(resp)
+	if err != nil {
+		return status.Errorf(codes.Internal, "encode result: %v", err)
+	}
+	return stream.Send(&pb.AnalyzeEvent{Event: &pb.AnalyzeEvent_Result{Result: result}})
+}
+
+// manifestService implements pb.ManifestServiceServer.
+type manifestService struct {
+	pb.UnimplementedManifestServiceServer
+	analyzer Analyzer
+}
+
+// GetManifest downloads a mod file and returns its file listing.
+func (s *manifestService) GetManifest(ctx context.Context, req *pb.GetManifestRequest) (*pb.Manifest, error) {
+	if req.GetGameDomain() == "" || req.GetModId() <= 0 || req.GetFileId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "game domain, mod ID and file ID are required")
+	}
+
+	m, err := s.analyzer.Manifest(ctx, req.GetGameDomain(), int(req.GetModId()), int(req.GetFileId()), req.GetIncludeHashes())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return manifestMessage(m), nil
+}
+
+// pluginHeaderService implements pb.PluginHeaderServiceServer.
+type pluginHeaderService struct {
+	pb.UnimplementedPluginHeaderServiceServer
+	parser *plugin.Parser
+}
+
+// ParsePluginHeader parses the TES4 header of an uploaded plugin.
+func (s *pluginHeaderService) ParsePluginHeader(ctx context.Context, req *pb.ParsePluginHeaderRequest) (*pb.PluginHeader, error) {
+	if req.GetFilename() == "" || len(req.GetData()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "filename and data are required")
+	}
+
+	header, err := s.parser.Parse(ctx, bytes.NewReader(req.GetData()), req.GetFilename())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "parse plugin header: %v", err)
+	}
+	return pluginHeaderMessage(header), nil
+}
+
+// toStatus maps an analysis error to a gRPC status, mirroring the HTTP
+// status the same error produces.
+func toStatus(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, pipeline.ErrUnknownAnalyzer):
+		code = codes.InvalidArgument
+	case errors.Is(err, nexus.ErrNotFound), errors.Is(err, pipeline.ErrUnavailable):
+		code = codes.NotFound
+	case errors.Is(err, nexus.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, nexus.ErrPremiumOnly), errors.Is(err, nexus.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, nexus.ErrRateLimited):
+		code = codes.ResourceExhausted
+	case errors.Is(err, nexus.ErrNoAPIKey), errors.Is(err, handlers.ErrReadOnly), errors.Is(err, archive.ErrInfected):
+		code = codes.FailedPrecondition
+	case errors.Is(err, nexus.ErrDegraded), errors.Is(err, pipeline.ErrSourceDown), errors.Is(err, archive.ErrScanFailed):
+		code = codes.Unavailable
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
+func progressMessage(p pipeline.Progress) *pb.Progress {
+	stage := pb.Progress_STAGE_UNSPECIFIED
+	switch p.Stage {
+	case pipeline.StageDownloading:
+		stage = pb.Progress_STAGE_DOWNLOADING
+	case pipeline.StageExtracting, pipeline.StageParsing:
+		// Parsing plugins is the last part of extracting for the gRPC API
+		stage = pb.Progress_STAGE_EXTRACTING
+	case pipeline.StageAnalyzing:
+		stage = pb.Progress_STAGE_ANALYZING
+	}
+	return &pb.Progress{
+		Stage:      stage,
+		ModsDone:   int32(p.ModsDone),
+		ModsTotal:  int32(p.ModsTotal),
+		CurrentMod: p.CurrentMod,
+	}
+}
+
+// analyzeResult converts an analysis response. Analyzer results are carried
+// as the JSON the HTTP API returns for them.
+func analyzeResult(resp *handlers.CollectionAnalyzeResponse) (*pb.AnalyzeResult, error) {
+	result := &pb.AnalyzeResult{
+		Slug:               resp.Slug,
+		Revision:           int32(resp.Revision),
+		GameDomain:         resp.GameDomain,
+		Analyzers:          resp.Analyzers,
+		ModsTotal:          int32(resp.ModsTotal),
+		Results:            make(map[string]*pb.AnalyzerResult, len(resp.Results)),
+		Fingerprint:        resp.Fingerprint,
+		SuppressedFindings: int32(resp.SuppressedFindings),
+	}
+	for name, r := range resp.Results {
+		message := &pb.AnalyzerResult{Error: r.Error}
+		if r.Data != nil {
+			data, err := json.Marshal(r.Data)
+			if err != nil {
+				return nil, err
+			}
+			message.DataJson = data
+		}
+		result.Results[name] = message
+	}
+	for _, w := range resp.Warnings {
+		result.Warnings = append(result.Warnings, &pb.Warning{
+			Type:    string(w.Type),
+			ModId:   w.ModID,
+			ModName: w.ModName,
+			Message: w.Message,
+		})
+	}
+	return result, nil
+}
+
+func manifestMessage(m *manifest.Manifest) *pb.Manifest {
+	message := &pb.Manifest{
+		Files:       make([]*pb.FileEntry, 0, len(m.Files)),
+		TotalSize:   m.TotalSize,
+		TotalCount:  int32(m.TotalCount),
+		ByType:      make(map[string]int32, len(m.ByType)),
+		ByExtension: make(map[string]int32, len(m.ByExtension)),
+	}
+	for _, f := range m.Files {
+		message.Files = append(message.Files, &pb.FileEntry{
+			Path:         f.Path,
+			OriginalPath: f.OriginalPath,
+			Size:         f.Size,
+			Hash:         f.Hash,
+			Type:         string(f.Type),
+			Extension:    f.Extension,
+			Directory:    f.Directory,
+			Filename:     f.Filename,
+		})
+	}
+	for t, n := range m.ByType {
+		message.ByType[string(t)] = int32(n)
+	}
+	for ext, n := range m.ByExtension {
+		message.ByExtension[ext] = int32(n)
+	}
+	return message
+}
+
+func pluginHeaderMessage(h *plugin.PluginHeader) *pb.PluginHeader {
+	message := &pb.PluginHeader{
+		Filename: h.Filename,
+		Type:     string(h.Type),
+		Flags: &pb.PluginFlags{
+			IsMaster:    h.Flags.IsMaster,
+			IsLight:     h.Flags.IsLight,
+			IsLocalized: h.Flags.IsLocalized,
+		},
+		Author:       h.Author,
+		Description:  h.Description,
+		FormVersion:  uint32(h.FormVersion),
+		NumRecords:   h.NumRecords,
+		NextObjectId: h.NextObjectID,
+	}
+	for _, m := range h.Masters {
+		message.Masters = append(message.Masters, &pb.Master{Filename: m.Filename, Size: m.Size})
+	}
+	return message
+}
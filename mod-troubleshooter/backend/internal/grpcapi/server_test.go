@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/handlers"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	pb "github.com/mod-troubleshooter/backend/proto/modtroubleshooter/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAnalyzer reports one progress step and returns a canned result.
+type fakeAnalyzer struct {
+	err error
+}
+
+func (f *fakeAnalyzer) Run(ctx context.Context, slug string, revision int, names []string) (*handlers.CollectionAnalyzeResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	pipeline.ReportProgress(ctx, pipeline.Progress{Stage: pipeline.StageDownloading, ModsTotal: 2, CurrentMod: "Armors"})
+	return &handlers.CollectionAnalyzeResponse{
+		Slug:       slug,
+		Revision:   revision,
+		GameDomain: "skyrimspecialedition",
+		Analyzers:  names,
+		ModsTotal:  2,
+		Results: map[string]pipeline.Result{
+			"health":    {Data: map[string]int{"score": 90}},
+			"conflicts": {Error: "no manifests"},
+		},
+		Warnings: []pipeline.Warning{{Type: "download_failed", ModID: "1", Message: "gone"}},
+	}, nil
+}
+
+func (f *fakeAnalyzer) Manifest(ctx context.Context, gameDomain string, modID, fileID int, hashes bool) (*manifest.Manifest, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("Textures/X.dds", 10)}), nil
+}
+
+// dial starts the services on an in-memory listener and returns a client connection.
+func dial(t *testing.T, analyzer Analyzer) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, analyzer)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestAnalyze(t *testing.T) {
+	client := pb.NewAnalyzerServiceClient(dial(t, &fakeAnalyzer{}))
+
+	stream, err := client.Analyze(context.Background(), &pb.AnalyzeRequest{Slug: "abc", Revision: 3, Analyzers: []string{"health", "conflicts"}})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	var events []*pb.AnalyzeEvent
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected progress and result, got %v", events)
+	}
+	if p := events[0].GetProgress(); p.GetStage() != pb.Progress_STAGE_DOWNLOADING || p.GetModsTotal() != 2 || p.GetCurrentMod() != "Armors" {
+		t.Errorf("unexpected progress %v", p)
+	}
+	result := events[1].GetResult()
+	if result.GetSlug() != "abc" || result.GetRevision() != 3 || len(result.GetWarnings()) != 1 {
+		t.Errorf("unexpected result %v", result)
+	}
+	if got := string(result.GetResults()["health"].GetDataJson()); got != `{"score":90}` {
+		t.Errorf("expected health data as JSON, got %s", got)
+	}
+	if got := result.GetResults()["conflicts"]; got.GetError() != "no manifests" || got.GetDataJson() != nil {
+		t.Errorf("expected conflicts error, got %v", got)
+	}
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{nexus.ErrNoAPIKey, codes.FailedPrecondition},
+		{handlers.ErrReadOnly, codes.FailedPrecondition},
+		{fmt.Errorf("fetch collection: %w", nexus.ErrNotFound), codes.NotFound},
+		{fmt.Errorf("%w: fomods", pipeline.ErrUnknownAnalyzer), codes.InvalidArgument},
+		{errors.New("boom"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		client := pb.NewAnalyzerServiceClient(dial(t, &fakeAnalyzer{err: tt.err}))
+		stream, err := client.Analyze(context.Background(), &pb.AnalyzeRequest{Slug: "abc"})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%v: expected %v, got %v (%v)", tt.err, tt.want, got, err)
+		}
+	}
+}
+
+func TestGetManifest(t *testing.T) {
+	client := pb.NewManifestServiceClient(dial(t, &fakeAnalyzer{}))
+
+	if _, err := client.GetManifest(context.Background(), &pb.GetManifestRequest{GameDomain: "skyrim"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument without mod and file IDs, got %v", err)
+	}
+
+	m, err := client.GetManifest(context.Background(), &pb.GetManifestRequest{GameDomain: "skyrim", ModId: 1, FileId: 2})
+	if err != nil {
+		t.Fatalf("GetManifest: %v", err)
+	}
+	if m.GetTotalCount() != 1 || m.GetFiles()[0].GetPath() != "textures/x.dds" || m.GetByType()["texture"] != 1 {
+		t.Errorf("unexpected manifest %v", m)
+	}
+}
+
+func TestParsePluginHeader(t *testing.T) {
+	client := pb.NewPluginHeaderServiceClient(dial(t, &fakeAnalyzer{}))
+
+	// A TES4 record flagged as a master with a single MAST subrecord
+	mast := append([]byte("MAST"), 0, 0)
+	mast = append(mast, "Skyrim.esm\x00"...)
+	binary.LittleEndian.PutUint16(mast[4:6], uint16(len(mast)-6))
+	data := append([]byte("TES4"), make([]byte, 20)...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(mast)))
+	binary.LittleEndian.PutUint32(data[8:12], 1)
+	data = append(data, mast...)
+
+	header, err := client.ParsePluginHeader(context.Background(), &pb.ParsePluginHeaderRequest{Filename: "Test.esp", Data: data})
+	if err != nil {
+		t.Fatalf("ParsePluginHeader: %v", err)
+	}
+	if header.GetType() != "ESM" || !header.GetFlags().GetIsMaster() || len(header.GetMasters()) != 1 || header.GetMasters()[0].GetFilename() != "Skyrim.esm" {
+		t.Errorf("unexpected header %v", header)
+	}
+
+	_, err = client.ParsePluginHeader(context.Background(), &pb.ParsePluginHeaderRequest{Filename: "Test.esp", Data: []byte("not a plugin")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument for a non-plugin, got %v", err)
+	}
+}
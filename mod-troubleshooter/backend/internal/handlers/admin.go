@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mod-troubleshooter/backend/internal/idle"
+)
+
+// AdminHandler lets the user of a desktop install pause the server while
+// they play.
+type AdminHandler struct {
+	monitor *idle.Monitor
+}
+
+// NewAdminHandler creates a new admin handler.
+func NewAdminHandler(monitor *idle.Monitor) *AdminHandler {
+	return &AdminHandler{monitor: monitor}
+}
+
+// GetStatus handles GET /api/admin/status
+// Returns whether background work is paused, and why.
+func (h *AdminHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, h.monitor.Status())
+}
+
+// Pause handles POST /api/admin/pause
+// Suspends background work and frees the downloads and worker processes
+// held for later analyses. The next analysis request wakes the server.
+func (h *AdminHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.monitor.Pause()
+	WriteJSON(w, http.StatusOK, h.monitor.Status())
+}
+
+// Resume handles POST /api/admin/resume
+// Restarts background work after a pause.
+func (h *AdminHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.monitor.Resume()
+	WriteJSON(w, http.StatusOK, h.monitor.Status())
+}
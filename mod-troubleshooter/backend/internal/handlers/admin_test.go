@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/idle"
+)
+
+func TestAdminHandler_PauseAndResume(t *testing.T) {
+	paused := false
+	monitor := idle.New(idle.Config{Hooks: []idle.Hook{{
+		Name:   "test",
+		Pause:  func() { paused = true },
+		Resume: func() { paused = false },
+	}}})
+	defer monitor.Close()
+	handler := NewAdminHandler(monitor)
+
+	tests := []struct {
+		name   string
+		serve  http.HandlerFunc
+		paused bool
+	}{
+		{name: "pause", serve: handler.Pause, paused: true},
+		{name: "status", serve: handler.GetStatus, paused: true},
+		{name: "resume", serve: handler.Resume, paused: false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.serve(w, httptest.NewRequest(http.MethodPost, "/api/admin/"+tt.name, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.name, w.Code)
+		}
+		var resp struct {
+			Data idle.Status `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: failed to decode status: %v", tt.name, err)
+		}
+		if resp.Data.Paused != tt.paused || paused != tt.paused {
+			t.Errorf("%s: expected paused=%v, got status %+v and hook state %v", tt.name, tt.paused, resp.Data, paused)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/cache"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/fingerprint"
+	"github.com/mod-troubleshooter/backend/internal/flight"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/stats"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
+)
+
+// CollectionAnalyzeResponse is the response from a combined collection analysis.
+type CollectionAnalyzeResponse struct {
+	Slug       string `json:"slug"`
+	Revision   int    `json:"revision"`
+	GameDomain string `json:"gameDomain"`
+	// Analyzers lists the analyzers that were run, in request order.
+	Analyzers []string `json:"analyzers"`
+	// Profile is the analysis profile the results were gathered with.
+	Profile pipeline.Profile `json:"profile"`
+	// ModsTotal is the number of mod files in the revision.
+	ModsTotal int `json:"modsTotal"`
+	// Results maps analyzer name to its result.
+	Results map[string]pipeline.Result `json:"results"`
+	// Fingerprint identifies the results; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+	// SuppressedFindings is the number of findings hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings"`
+	// Suppressed lists the hidden findings when showSuppressed=true is requested.
+	Suppressed *SuppressedFindings `json:"suppressed,omitempty"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+	// ModNotes are the collection curator's notes on its mods, such as
+	// which installer options to choose.
+	ModNotes []pipeline.ModNote `json:"modNotes,omitempty"`
+	// Timings breaks down where the time went and which mods were slowest.
+	Timings *pipeline.Timings `json:"timings,omitempty"`
+}
+
+// AnalyzeHandler runs several analyzers over a collection from a single download pass.
+type AnalyzeHandler struct {
+	clientGetter NexusClientGetter
+	downloader   *archive.Downloader
+	extractor    *archive.Extractor
+	cache        *cache.Cache
+	stats        *stats.Collector
+	sessions     *pipeline.Sessions
+	suppressions *suppress.Store
+	readOnly     bool
+	sevenZip     *archive.SevenZip
+	sandbox      pipeline.ArchiveReader
+	limiter      *pipeline.Limiter
+	pipeline     *pipeline.Pipeline
+	previews     bool
+	settings     *SettingsStore
+
+	// jobs shares in-flight analyses between identical requests
+	jobs flight.Group[CollectionAnalyzeResponse]
+}
+
+// AnalyzeHandlerConfig holds configuration for the AnalyzeHandler.
+type AnalyzeHandlerConfig struct {
+	ClientGetter NexusClientGetter
+	Downloader   *archive.Downloader
+	Extractor    *archive.Extractor
+	Cache        *cache.Cache
+	Stats        *stats.Collector
+	// Sessions shares downloads between analyses of the same collection revision (optional).
+	Sessions *pipeline.Sessions
+	// Suppressions hides findings matched by active suppressions (optional).
+	Suppressions *suppress.Store
+	Pipeline     *pipeline.Pipeline
+	// ReadOnly serves cached results only, rejecting requests that would download from Nexus.
+	ReadOnly bool
+	// SevenZip lists archives the built-in extractor cannot read (optional).
+	SevenZip *archive.SevenZip
+	// Sandbox reads archives in worker processes (optional).
+	Sandbox pipeline.ArchiveReader
+	// Limiter bounds gathering work across analyses (optional).
+	Limiter *pipeline.Limiter
+	// ContentPreviews lists archives from their Nexus content preview instead
+	// of downloading them, when only file listings are needed.
+	ContentPreviews bool
+	// Settings supplies the default analysis profile (optional; the
+	// standard profile is used without it).
+	Settings *SettingsStore
+}
+
+// NewAnalyzeHandler creates a new combined analysis handler.
+func NewAnalyzeHandler(cfg AnalyzeHandlerConfig) *AnalyzeHandler {
+	return &AnalyzeHandler{
+		clientGetter: cfg.ClientGetter,
+		downloader:   cfg.Downloader,
+		extractor:    cfg.Extractor,
+		cache:        cfg.Cache,
+		stats:        cfg.Stats,
+		sessions:     cfg.Sessions,
+		suppressions: cfg.Suppressions,
+		readOnly:     cfg.ReadOnly,
+		sevenZip:     cfg.SevenZip,
+		sandbox:      cfg.Sandbox,
+		limiter:      cfg.Limiter,
+		pipeline:     cfg.Pipeline,
+		previews:     cfg.ContentPreviews,
+		settings:     cfg.Settings,
+	}
+}
+
+// AnalyzeCollection handles GET /api/collections/{slug}/revisions/{revision}/analyze
+// Downloads each mod file once and runs the requested analyzers over the result.
+// The optional include query parameter is a comma-separated list of analyzers;
+// all registered analyzers run when it is omitted. The optional profile query
+// parameter (quick, standard or deep) overrides the default analysis profile.
+// With communityReports=true, the latest bug reports of each mod on Nexus are
+// scanned for crashes and incompatibilities, which the health report lists
+// as unverified hints.
+func (h *AnalyzeHandler) AnalyzeCollection(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	client := h.clientGetter.Get()
+	if client == nil {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+
+	revisionStr := r.PathValue("revision")
+	if revisionStr == "" {
+		WriteError(w, http.StatusBadRequest, "Revision number is required")
+		return
+	}
+
+	revision, err := strconv.Atoi(revisionStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+
+	names := parseInclude(r.URL.Query().Get("include"))
+	if len(names) == 0 {
+		names = h.pipeline.Names()
+	}
+
+	need, err := h.pipeline.Requires(names)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrUnknownAnalyzer) {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid include parameter: %v (available: %s)", err, strings.Join(h.pipeline.Names(), ", ")))
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "Invalid include parameter")
+		return
+	}
+
+	profile := h.defaultProfile()
+	if name := r.URL.Query().Get("profile"); name != "" {
+		if profile, err = pipeline.ParseProfile(name); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid profile parameter: %v", err))
+			return
+		}
+	}
+
+	communityReports, _ := strconv.ParseBool(r.URL.Query().Get("communityReports"))
+
+	response, err := h.run(ctx, client, slug, revision, names, need, profile, communityReports)
+	if err != nil {
+		writeJobError(w, err, "analyze collection")
+		return
+	}
+	response.applySuppressions(activeSuppressions(ctx, h.suppressions, slug), showSuppressed(r))
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// Run analyzes a collection revision for callers outside of HTTP requests,
+// such as chat integrations. A revision of 0 analyzes the latest published
+// revision, and all registered analyzers run when names is empty.
+// The default analysis profile is used, and suppressed findings are left out
+// of the result.
+func (h *AnalyzeHandler) Run(ctx context.Context, slug string, revision int, names []string) (*CollectionAnalyzeResponse, error) {
+	if h.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	client := h.clientGetter.Get()
+	if client == nil {
+		return nil, nexus.ErrNoAPIKey
+	}
+
+	if revision <= 0 {
+		collection, err := client.GetCollection(ctx, slug)
+		if err != nil {
+			return nil, fmt.Errorf("fetch collection: %w", err)
+		}
+		if collection.LatestRevision == nil {
+			return nil, fmt.Errorf("collection %s has no published revision", slug)
+		}
+		revision = collection.LatestRevision.RevisionNumber
+	}
+
+	if len(names) == 0 {
+		names = h.pipeline.Names()
+	}
+	need, err := h.pipeline.Requires(names)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.run(ctx, client, slug, revision, names, need, h.defaultProfile(), false)
+	if err != nil {
+		return nil, err
+	}
+	response.applySuppressions(activeSuppressions(ctx, h.suppressions, slug), false)
+
+	return &response, nil
+}
+
+// Manifest downloads a single mod file and lists its contents, for callers
+// outside of HTTP requests. Content hashes are included when hashes is set.
+func (h *AnalyzeHandler) Manifest(ctx context.Context, gameDomain string, modID, fileID int, hashes bool) (*manifest.Manifest, error) {
+	if h.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	client := h.clientGetter.Get()
+	if client == nil {
+		return nil, nexus.ErrNoAPIKey
+	}
+
+	fetcher := &nexusFetcher{client: client, downloader: h.downloader}
+	gatherer := pipeline.NewGatherer(pipeline.GathererConfig{
+		Fetcher:       fetcher,
+		Extractor:     h.extractor,
+		ContentHashes: hashes,
+		SevenZip:      h.sevenZip,
+		Sandbox:       h.sandbox,
+		Limiter:       h.limiter,
+		Previewer:     previewer(fetcher, h.previews),
+		Verifier:      fetcher,
+	})
+	sources := []pipeline.Source{{
+		ModID:      sourceModID(modID, fileID),
+		Game:       gameDomain,
+		NexusModID: modID,
+		FileID:     fileID,
+	}}
+	in, release, err := gatherer.Gather(ctx, sources, pipeline.InputManifests)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	mod := in.Mods[0]
+	if mod.Unavailable {
+		return nil, pipeline.ErrUnavailable
+	}
+	if mod.Error != "" {
+		return nil, errors.New(mod.Error)
+	}
+	return mod.Manifest, nil
+}
+
+// defaultProfile returns the analysis profile used when a request does not
+// name one.
+func (h *AnalyzeHandler) defaultProfile() pipeline.Profile {
+	if h.settings == nil {
+		return pipeline.ProfileStandard
+	}
+	return h.settings.GetAnalysisProfile()
+}
+
+// run analyzes a collection revision, sharing one analysis between concurrent
+// callers asking for the same revision, analyzers, profile and reports.
+func (h *AnalyzeHandler) run(ctx context.Context, client *nexus.Client, slug string, revision int, names []string, need pipeline.Input, profile pipeline.Profile, communityReports bool) (CollectionAnalyzeResponse, error) {
+	key := fmt.Sprintf("analyze:%s:%d:%s:%s:%t", slug, revision, strings.Join(names, ","), profile, communityReports)
+	return h.jobs.Do(ctx, key, func(ctx context.Context) (CollectionAnalyzeResponse, error) {
+		return h.analyze(ctx, client, slug, revision, names, profile.Inputs(need), profile, communityReports)
+	})
+}
+
+// analyze downloads a collection revision and runs the named analyzers over
+// it, reading archives as thoroughly as profile asks and fetching each mod's
+// bug reports if communityReports is set.
+func (h *AnalyzeHandler) analyze(ctx context.Context, client *nexus.Client, slug string, revision int, names []string, need pipeline.Input, profile pipeline.Profile, communityReports bool) (CollectionAnalyzeResponse, error) {
+	started := time.Now()
+
+	// Get collection revision mods
+	revisionDetails, err := client.GetCollectionRevisionMods(ctx, slug, revision)
+	if err != nil {
+		return CollectionAnalyzeResponse{}, fmt.Errorf("fetch collection revision: %w", err)
+	}
+
+	// Get the collection to determine the game
+	collection, err := client.GetCollection(ctx, slug)
+	if err != nil {
+		return CollectionAnalyzeResponse{}, fmt.Errorf("fetch collection: %w", err)
+	}
+
+	gameDomain := collection.Game.DomainName
+
+	// Download each mod file once, reusing this revision's session, and gather everything the analyzers need
+	session := h.sessions.Acquire(slug, revision)
+	defer session.Done()
+
+	fetcher := &nexusFetcher{client: client, downloader: h.downloader, mirrors: archive.NewMirrorHealth()}
+	gatherer := pipeline.NewGatherer(pipeline.GathererConfig{
+		Fetcher:     session.Fetcher(fetcher),
+		Extractor:   h.extractor,
+		SevenZip:    h.sevenZip,
+		Sandbox:     h.sandbox,
+		Limiter:     h.limiter,
+		Previewer:   previewer(fetcher, h.previews || profile == pipeline.ProfileQuick),
+		Profile:     profile,
+		BugReporter: bugReporter(fetcher, communityReports),
+		Verifier:    fetcher,
+	})
+	sources, err := revisionSources(ctx, client, gameDomain, revisionDetails)
+	if err != nil {
+		return CollectionAnalyzeResponse{}, err
+	}
+	in, release, err := gatherer.Gather(ctx, sources, need)
+	if err != nil {
+		return CollectionAnalyzeResponse{}, gatherError(err, "Failed to extract mod information")
+	}
+
+	defer release()
+
+	analyzeStart := time.Now()
+	results, err := h.pipeline.Run(ctx, names, in)
+	if err != nil {
+		return CollectionAnalyzeResponse{}, &jobError{status: http.StatusInternalServerError, message: "Failed to analyze collection", err: err}
+	}
+	timings := in.Timings(pipeline.DefaultSlowestMods)
+	timings.SetAnalyze(time.Since(analyzeStart), time.Since(started))
+	timings.Mirrors = fetcher.mirrors.Stats()
+
+	h.storeResults(ctx, slug, revision, in, results, profile)
+
+	return CollectionAnalyzeResponse{
+		Slug:        slug,
+		Revision:    revision,
+		GameDomain:  gameDomain,
+		Analyzers:   names,
+		Profile:     profile,
+		ModsTotal:   len(in.Mods),
+		Results:     results,
+		Warnings:    in.Warnings(),
+		ModNotes:    in.Notes(),
+		Fingerprint: fingerprint.Of(results),
+		Timings:     timings,
+	}, nil
+}
+
+// storeResults records stats and caches results that have a dedicated endpoint,
+// so later conflict, load order and bundle requests are served without re-downloading.
+// Deep results carry content hashes, so they are cached as hashed analyses.
+func (h *AnalyzeHandler) storeResults(ctx context.Context, slug string, revision int, in *pipeline.Inputs, results map[string]pipeline.Result, profile pipeline.Profile) {
+	if result, ok := results[pipeline.NameConflicts].Data.(*conflict.AnalysisResult); ok {
+		h.stats.RecordConflicts(result)
+		if h.cache != nil {
+			hashes := profile == pipeline.ProfileDeep
+			response := ConflictAnalyzeResponse{AnalysisResult: result, Fingerprint: fingerprint.Of(result), ModNotes: in.Notes()}
+			if err := h.cache.Set(ctx, cache.ConflictsKey(slug, revision, hashes), response); err != nil {
+				log.Printf("Error caching result: %v", err)
+			}
+			if err := h.cache.Set(ctx, cache.ManifestsKey(slug, revision, hashes), pipeline.ModManifests(in)); err != nil {
+				log.Printf("Error caching manifests: %v", err)
+			}
+		}
+	}
+
+	if result, ok := results[pipeline.NameLoadOrder].Data.(*loadorder.AnalysisResult); ok {
+		h.stats.RecordAnalysis(stats.KindLoadOrder)
+		if h.cache != nil {
+			response := LoadOrderAnalyzeResponse{AnalysisResult: result, Fingerprint: fingerprint.Of(result), ModNotes: in.Notes()}
+			if err := h.cache.Set(ctx, cache.LoadOrderKey(slug, revision), response); err != nil {
+				log.Printf("Error caching result: %v", err)
+			}
+		}
+	}
+
+	if res, ok := results[pipeline.NameFomod]; ok && res.Error == "" {
+		h.stats.RecordAnalysis(stats.KindFomod)
+	}
+}
+
+// parseInclude splits a comma-separated analyzer list, dropping blanks and duplicates.
+func parseInclude(include string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(include, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
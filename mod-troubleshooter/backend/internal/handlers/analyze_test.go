@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+func TestParseInclude(t *testing.T) {
+	tests := []struct {
+		include string
+		want    []string
+	}{
+		{"", nil},
+		{"conflicts", []string{"conflicts"}},
+		{"conflicts, LoadOrder ,,health", []string{"conflicts", "loadorder", "health"}},
+		{"fomod,fomod", []string{"fomod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.include, func(t *testing.T) {
+			if got := parseInclude(tt.include); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHandler_UnknownAnalyzer(t *testing.T) {
+	client, err := nexus.NewClient(nexus.ClientConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	p, _ := pipeline.New(pipeline.NewConflictStage(), pipeline.NewHealthStage())
+	handler := NewAnalyzeHandler(AnalyzeHandlerConfig{
+		ClientGetter: &mockNexusClientGetter{client: client},
+		Pipeline:     p,
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/analyze", handler.AnalyzeCollection)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/abc/revisions/1/analyze?include=conflicts,bogus", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAnalyzeHandler_ReadOnly(t *testing.T) {
+	p, _ := pipeline.New(pipeline.NewConflictStage())
+	handler := NewAnalyzeHandler(AnalyzeHandlerConfig{
+		ClientGetter: &mockNexusClientGetter{},
+		Pipeline:     p,
+		ReadOnly:     true,
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/analyze", handler.AnalyzeCollection)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/abc/revisions/1/analyze", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+}
+
+func TestAnalyzeHandler_Profile(t *testing.T) {
+	client, err := nexus.NewClient(nexus.ClientConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	p, _ := pipeline.New(pipeline.NewConflictStage())
+	settings := NewSettingsStore("")
+	handler := NewAnalyzeHandler(AnalyzeHandlerConfig{
+		ClientGetter: &mockNexusClientGetter{client: client},
+		Pipeline:     p,
+		Settings:     settings,
+	})
+
+	if got := handler.defaultProfile(); got != pipeline.ProfileStandard {
+		t.Errorf("expected the standard profile by default, got %q", got)
+	}
+	settings.SetAnalysisProfile(pipeline.ProfileDeep)
+	if got := handler.defaultProfile(); got != pipeline.ProfileDeep {
+		t.Errorf("expected the profile from the settings, got %q", got)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/analyze", handler.AnalyzeCollection)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/abc/revisions/1/analyze?profile=thorough", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
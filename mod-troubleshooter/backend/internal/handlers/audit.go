@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/audit"
+)
+
+// maxAuditSettingLen bounds the query parameter values kept in the audit log.
+const maxAuditSettingLen = 256
+
+// AuditHandler records analysis requests in the audit log and serves it.
+type AuditHandler struct {
+	log *audit.Log
+}
+
+// NewAuditHandler creates a new audit handler.
+// A nil log means the audit log is disabled.
+func NewAuditHandler(l *audit.Log) *AuditHandler {
+	return &AuditHandler{log: l}
+}
+
+// Wrap returns next recording every request it serves in the audit log:
+// the client, the collection and settings asked for, and the outcome.
+// With the audit log disabled, next is returned unchanged.
+func (h *AuditHandler) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	if h.log == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		entry := auditEntry(r, start)
+		entry.Status = rec.status()
+		entry.Outcome = audit.OutcomeFor(entry.Status)
+		entry.DurationMs = time.Since(start).Milliseconds()
+		entry.RequestID = w.Header().Get(RequestIDHeader)
+
+		if err := h.log.Append(context.WithoutCancel(r.Context()), entry); err != nil {
+			log.Printf("Error appending to audit log: %v", err)
+		}
+	}
+}
+
+// auditEntry describes a request for the audit log.
+func auditEntry(r *http.Request, start time.Time) *audit.Entry {
+	entry := &audit.Entry{
+		Time:      start,
+		Client:    clientAddr(r),
+		UserAgent: truncate(r.UserAgent(), maxAuditSettingLen),
+		Endpoint:  r.Pattern,
+		Slug:      r.PathValue("slug"),
+	}
+	if entry.Endpoint == "" {
+		entry.Endpoint = r.Method + " " + r.URL.Path
+	}
+	entry.Revision, _ = strconv.Atoi(r.PathValue("revision"))
+
+	query := r.URL.Query()
+	if len(query) > 0 {
+		entry.Settings = make(map[string]string, len(query))
+		for key, values := range query {
+			entry.Settings[truncate(key, maxAuditSettingLen)] = truncate(strings.Join(values, ","), maxAuditSettingLen)
+		}
+	}
+	return entry
+}
+
+// clientAddr returns the IP address of the client of a request, as
+// resolved from trusted proxies.
+func clientAddr(r *http.Request) string {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return client
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.code == 0 {
+		rec.code = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// status returns the written status code; a handler that wrote nothing
+// answered with 200.
+func (rec *statusRecorder) status() int {
+	if rec.code == 0 {
+		return http.StatusOK
+	}
+	return rec.code
+}
+
+// QueryAuditLog handles GET /api/audit
+// Returns audited requests, newest first. The optional client, slug and
+// outcome query parameters filter them; since and until (RFC 3339) bound
+// their time, and before (an entry ID) and limit page through them.
+func (h *AuditHandler) QueryAuditLog(w http.ResponseWriter, r *http.Request) {
+	if h.log == nil {
+		WriteError(w, http.StatusNotFound, "The audit log is disabled. Set AUDIT_LOG=true to enable it.")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := audit.Filter{
+		Client:  q.Get("client"),
+		Slug:    q.Get("slug"),
+		Outcome: audit.Outcome(q.Get("outcome")),
+	}
+	switch filter.Outcome {
+	case "", audit.OutcomeSuccess, audit.OutcomeRejected, audit.OutcomeFailed:
+	default:
+		WriteError(w, http.StatusBadRequest, "Invalid outcome parameter (expected success, rejected or failed)")
+		return
+	}
+
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if v := q.Get(p.name); v != "" {
+			if *p.dst, err = time.Parse(time.RFC3339, v); err != nil {
+				WriteError(w, http.StatusBadRequest, "Invalid "+p.name+" parameter (expected RFC 3339 time)")
+				return
+			}
+		}
+	}
+	if v := q.Get("before"); v != "" {
+		if filter.Before, err = strconv.ParseInt(v, 10, 64); err != nil || filter.Before <= 0 {
+			WriteError(w, http.StatusBadRequest, "Invalid before parameter")
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			WriteError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	entries, err := h.log.Query(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error querying audit log: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to query audit log")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/audit"
+)
+
+func TestAuditHandler_Wrap(t *testing.T) {
+	l, err := audit.New(audit.Config{DBPath: filepath.Join(t.TempDir(), "audit.db")})
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer l.Close()
+
+	h := NewAuditHandler(l)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/collections/{slug}/revisions/{revision}/analyze", h.Wrap(func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, http.StatusTooManyRequests, "slow down")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/abc/revisions/4/analyze?profile=deep&include=conflicts", nil)
+	req.RemoteAddr = "203.0.113.9:51234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, err := l.Query(context.Background(), audit.Filter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Client != "203.0.113.9" || e.UserAgent != "curl/8.0" || e.Slug != "abc" || e.Revision != 4 ||
+		e.Endpoint != "GET /api/collections/{slug}/revisions/{revision}/analyze" {
+		t.Errorf("unexpected request details %+v", e)
+	}
+	if e.Settings["profile"] != "deep" || e.Settings["include"] != "conflicts" {
+		t.Errorf("unexpected settings %v", e.Settings)
+	}
+	if e.Status != http.StatusTooManyRequests || e.Outcome != audit.OutcomeRejected {
+		t.Errorf("expected a rejected request, got status %d outcome %s", e.Status, e.Outcome)
+	}
+}
+
+func TestAuditHandler_Disabled(t *testing.T) {
+	h := NewAuditHandler(nil)
+
+	rec := httptest.NewRecorder()
+	h.QueryAuditLog(rec, httptest.NewRequest(http.MethodGet, "/api/audit", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with the audit log disabled, got %d", rec.Code)
+	}
+}
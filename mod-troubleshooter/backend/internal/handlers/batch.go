@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/bundle"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/history"
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+const (
+	// maxBatchCollections bounds the collections of one batch.
+	maxBatchCollections = 100
+	// batchRetryInterval is how often a batch tries to queue more
+	// collections while the queue has no room for them.
+	batchRetryInterval = 5 * time.Second
+	// batchTTL is how long a finished batch is kept for polling. Its
+	// analyses stay in the history.
+	batchTTL = 24 * time.Hour
+	// batchJobKind names the jobs analyzing the collections of a batch.
+	batchJobKind = "batch-collection"
+)
+
+// BatchAnalyzeRequest is the request body for a batch analysis.
+type BatchAnalyzeRequest struct {
+	// Slugs are the collections to analyze, each at its latest revision.
+	Slugs []string `json:"slugs"`
+	// Include names the analyzers to run (optional). All registered
+	// analyzers run when it is empty.
+	Include []string `json:"include,omitempty"`
+}
+
+// Batch is a batch analysis and how far each of its collections has got.
+type Batch struct {
+	// ID identifies the batch.
+	ID string `json:"id"`
+	// Done is true once every collection has been analyzed or has failed.
+	Done bool `json:"done"`
+	// CreatedAt is when the batch was submitted.
+	CreatedAt time.Time `json:"createdAt"`
+	// FinishedAt is when the last collection finished, if it has.
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Collections are the collections of the batch, in request order.
+	Collections []BatchCollection `json:"collections"`
+	// Summary combines the results once the batch is done.
+	Summary *BatchSummary `json:"summary,omitempty"`
+}
+
+// BatchCollection is one collection of a batch.
+type BatchCollection struct {
+	Slug string `json:"slug"`
+	// JobID is the job analyzing the collection, once it is queued. The
+	// batch queues collections as the job queue makes room for them.
+	JobID string `json:"jobId,omitempty"`
+	// Status is the status of the job, queued until there is one.
+	Status jobs.Status `json:"status"`
+	// Progress is how far a running analysis has got.
+	Progress *jobs.Progress `json:"progress,omitempty"`
+	// Result summarizes a succeeded analysis.
+	Result *BatchCollectionResult `json:"result,omitempty"`
+	// Error describes why the analysis failed.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchCollectionResult summarizes the analysis of one collection.
+type BatchCollectionResult struct {
+	Revision   int    `json:"revision"`
+	GameDomain string `json:"gameDomain"`
+	ModsTotal  int    `json:"modsTotal"`
+	// HistoryID is the history entry holding the full analysis, and
+	// HistoryURL where to fetch it. Both are empty if it was not stored.
+	HistoryID  int64  `json:"historyId,omitempty"`
+	HistoryURL string `json:"historyUrl,omitempty"`
+	// Conflicts is the number of file conflicts found.
+	Conflicts int `json:"conflicts"`
+	// Issues is the number of load order issues found.
+	Issues int `json:"issues"`
+	// HealthScore and HealthRating rate the collection, when its health
+	// was checked.
+	HealthScore  *int          `json:"healthScore,omitempty"`
+	HealthRating health.Rating `json:"healthRating,omitempty"`
+	// ModWarnings is the number of mods whose data was incomplete.
+	ModWarnings int `json:"modWarnings"`
+	// Fingerprint identifies the results; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// BatchSummary combines the results of a finished batch.
+type BatchSummary struct {
+	Collections int `json:"collections"`
+	Succeeded   int `json:"succeeded"`
+	Failed      int `json:"failed"`
+	// Conflicts and Issues total the findings of the succeeded analyses.
+	Conflicts int `json:"conflicts"`
+	Issues    int `json:"issues"`
+	// LowestHealth is the slug of the collection with the lowest health
+	// score, if any was rated.
+	LowestHealth string `json:"lowestHealth,omitempty"`
+}
+
+// batch is the state of a batch analysis. Only its run goroutine changes
+// jobIDs and outcomes, under the handler's lock.
+type batch struct {
+	id       string
+	owner    string
+	names    []string
+	slugs    []string
+	created  time.Time
+	finished time.Time
+	// jobIDs are the jobs analyzing each collection, "" until queued
+	jobIDs []string
+	// outcomes are the finished jobs, nil until each finishes
+	outcomes []*jobs.Job
+}
+
+// done reports whether every collection has finished.
+func (b *batch) done() bool {
+	return !b.finished.IsZero()
+}
+
+// BatchHandler analyzes many collections at once, such as a curator's
+// whole portfolio, as one job per collection.
+type BatchHandler struct {
+	queue    *jobs.Queue
+	analyzer *AnalyzeHandler
+	history  *history.Store
+	// analyze runs the analysis of one collection, the analyzer's Run
+	analyze func(ctx context.Context, slug string, revision int, names []string) (*CollectionAnalyzeResponse, error)
+
+	mu      sync.Mutex
+	batches map[string]*batch
+}
+
+// BatchHandlerConfig holds configuration for the BatchHandler.
+type BatchHandlerConfig struct {
+	// Queue runs the analysis of each collection.
+	Queue *jobs.Queue
+	// Analyzer analyzes each collection.
+	Analyzer *AnalyzeHandler
+	// History stores each analysis, so the batch can link to it (optional).
+	History *history.Store
+}
+
+// NewBatchHandler creates a new batch analysis handler.
+func NewBatchHandler(cfg BatchHandlerConfig) *BatchHandler {
+	return &BatchHandler{
+		queue:    cfg.Queue,
+		analyzer: cfg.Analyzer,
+		history:  cfg.History,
+		analyze:  cfg.Analyzer.Run,
+		batches:  make(map[string]*batch),
+	}
+}
+
+// AnalyzeBatch handles POST /api/analyze/batch
+// Analyzes the latest revision of every collection listed, and returns 202
+// Accepted with the batch to poll at GET /api/analyze/batch/{id}. Each
+// collection is analyzed by a low priority job of its own, queued as the
+// client's share of the job queue makes room. Finished analyses are stored
+// in the history, and the batch links to them.
+func (h *BatchHandler) AnalyzeBatch(w http.ResponseWriter, r *http.Request) {
+	if h.analyzer.readOnly {
+		writeReadOnly(w)
+		return
+	}
+	if h.analyzer.clientGetter.Get() == nil {
+		writeNoAPIKey(w)
+		return
+	}
+
+	var req BatchAnalyzeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobRequestBytes)).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	slugs := uniqueSlugs(req.Slugs)
+	if len(slugs) == 0 {
+		WriteError(w, http.StatusBadRequest, "At least one collection slug is required")
+		return
+	}
+	if len(slugs) > maxBatchCollections {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("A batch may have at most %d collections", maxBatchCollections))
+		return
+	}
+
+	names := parseInclude(strings.Join(req.Include, ","))
+	if len(names) == 0 {
+		names = h.analyzer.pipeline.Names()
+	}
+	if _, err := h.analyzer.pipeline.Requires(names); err != nil {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid include: %v (available: %s)", err, strings.Join(h.analyzer.pipeline.Names(), ", ")))
+		return
+	}
+
+	b := &batch{
+		id:       newRequestID(),
+		owner:    clientAddr(r),
+		names:    names,
+		slugs:    slugs,
+		created:  time.Now().UTC(),
+		jobIDs:   make([]string, len(slugs)),
+		outcomes: make([]*jobs.Job, len(slugs)),
+	}
+	h.mu.Lock()
+	h.prune()
+	h.batches[b.id] = b
+	h.mu.Unlock()
+
+	// Queue what fits now, so the response already lists those jobs
+	h.queueWaiting(b)
+	go h.run(b)
+
+	w.Header().Set("Location", "/api/analyze/batch/"+b.id)
+	WriteJSON(w, http.StatusAccepted, h.snapshot(b))
+}
+
+// GetBatch handles GET /api/analyze/batch/{id}
+// Returns the state of each collection of a batch and, once all of them
+// have finished, a summary of their results.
+func (h *BatchHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.prune()
+	b, ok := h.batches[r.PathValue("id")]
+	h.mu.Unlock()
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Batch not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, h.snapshot(b))
+}
+
+// run queues the collections of a batch as the job queue makes room for
+// them, and records each analysis as it finishes, until all have.
+func (h *BatchHandler) run(b *batch) {
+	retry := time.NewTicker(batchRetryInterval)
+	defer retry.Stop()
+	for {
+		h.queueWaiting(b)
+		changed, done := h.collect(b)
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-retry.C:
+		}
+	}
+}
+
+// queueWaiting submits the collections of a batch that have no job yet,
+// until the queue refuses more.
+func (h *BatchHandler) queueWaiting(b *batch) {
+	h.mu.Lock()
+	var waiting []int
+	for i, id := range b.jobIDs {
+		if id == "" && b.outcomes[i] == nil {
+			waiting = append(waiting, i)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, i := range waiting {
+		job, err := h.queue.SubmitWith(batchJobKind, jobs.Options{Priority: jobs.PriorityLow, Owner: b.owner}, h.analyzeFunc(b.slugs[i], b.names))
+		if errors.Is(err, jobs.ErrQueueFull) {
+			return
+		}
+
+		h.mu.Lock()
+		if err != nil {
+			// The queue is closed, so nothing else will be queued either
+			b.outcomes[i] = &jobs.Job{Kind: batchJobKind, Status: jobs.StatusFailed, Error: "The server is shutting down"}
+		} else {
+			b.jobIDs[i] = job.ID
+		}
+		h.mu.Unlock()
+	}
+}
+
+// collect records the jobs of a batch that have finished. It returns a
+// channel closed when an unfinished job changes, nil if none is queued,
+// and whether the whole batch is done.
+func (h *BatchHandler) collect(b *batch) (<-chan struct{}, bool) {
+	h.mu.Lock()
+	ids := append([]string(nil), b.jobIDs...)
+	outcomes := append([]*jobs.Job(nil), b.outcomes...)
+	h.mu.Unlock()
+
+	var changed <-chan struct{}
+	for i, id := range ids {
+		if id == "" || outcomes[i] != nil {
+			continue
+		}
+		job, ch, err := h.queue.Watch(id)
+		switch {
+		case err != nil:
+			outcomes[i] = &jobs.Job{ID: id, Kind: batchJobKind, Status: jobs.StatusFailed, Error: "Job not found"}
+		case job.Status.Done():
+			outcomes[i] = &job
+		case changed == nil:
+			changed = ch
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	copy(b.outcomes, outcomes)
+	for _, outcome := range b.outcomes {
+		if outcome == nil {
+			return changed, false
+		}
+	}
+	b.finished = time.Now().UTC()
+	return nil, true
+}
+
+// snapshot returns the current state of a batch.
+func (h *BatchHandler) snapshot(b *batch) Batch {
+	h.mu.Lock()
+	batch := Batch{ID: b.id, Done: b.done(), CreatedAt: b.created, Collections: make([]BatchCollection, len(b.slugs))}
+	if batch.Done {
+		finished := b.finished
+		batch.FinishedAt = &finished
+	}
+	ids := append([]string(nil), b.jobIDs...)
+	outcomes := append([]*jobs.Job(nil), b.outcomes...)
+	h.mu.Unlock()
+
+	for i, slug := range b.slugs {
+		c := BatchCollection{Slug: slug, JobID: ids[i], Status: jobs.StatusQueued}
+		job := outcomes[i]
+		if job == nil && ids[i] != "" {
+			if live, err := h.queue.Get(ids[i]); err == nil {
+				job = &live
+			}
+		}
+		if job != nil {
+			c.Status = job.Status
+			c.Progress = job.Progress
+			c.Error = job.Error
+			if result, ok := job.Result.(BatchCollectionResult); ok {
+				c.Result = &result
+			}
+		}
+		batch.Collections[i] = c
+	}
+
+	if batch.Done {
+		batch.Summary = summarizeBatch(batch.Collections)
+	}
+	return batch
+}
+
+// summarizeBatch combines the results of the collections of a batch.
+func summarizeBatch(collections []BatchCollection) *BatchSummary {
+	summary := &BatchSummary{Collections: len(collections)}
+	lowest := -1
+	for _, c := range collections {
+		if c.Result == nil {
+			summary.Failed++
+			continue
+		}
+		summary.Succeeded++
+		summary.Conflicts += c.Result.Conflicts
+		summary.Issues += c.Result.Issues
+		if score := c.Result.HealthScore; score != nil && (lowest < 0 || *score < lowest) {
+			lowest = *score
+			summary.LowestHealth = c.Slug
+		}
+	}
+	return summary
+}
+
+// analyzeFunc returns the job analyzing one collection of a batch, which
+// stores the analysis in the history and returns its summary.
+func (h *BatchHandler) analyzeFunc(slug string, names []string) jobs.Func {
+	return func(ctx context.Context) (interface{}, error) {
+		result, err := h.AnalyzeCollection(ctx, slug, 0, names, history.SourceBatch)
+		if err != nil {
+			return nil, jobFailure(err, "analyze collection "+slug)
+		}
+		return result, nil
+	}
+}
+
+// AnalyzeCollection analyzes a collection revision from within a job, like
+// the collections of a batch, stores the analysis in the history under
+// source and returns its summary. A revision of 0 analyzes the latest one.
+func (h *BatchHandler) AnalyzeCollection(ctx context.Context, slug string, revision int, names []string, source history.Source) (BatchCollectionResult, error) {
+	response, err := h.analyze(withJobProgress(ctx), slug, revision, names)
+	if err != nil {
+		return BatchCollectionResult{}, err
+	}
+
+	result := BatchCollectionResult{
+		Revision:    response.Revision,
+		GameDomain:  response.GameDomain,
+		ModsTotal:   response.ModsTotal,
+		ModWarnings: len(response.Warnings),
+		Fingerprint: response.Fingerprint,
+	}
+	b := &bundle.Bundle{
+		Metadata: bundle.Metadata{
+			Slug:               slug,
+			Revision:           response.Revision,
+			GameDomain:         response.GameDomain,
+			CreatedAt:          time.Now().UTC(),
+			SuppressedFindings: response.SuppressedFindings,
+			Fingerprint:        response.Fingerprint,
+		},
+	}
+	if conflicts, ok := response.Results[pipeline.NameConflicts].Data.(*conflict.AnalysisResult); ok {
+		b.Conflicts = conflicts
+		result.Conflicts = conflicts.Stats.TotalConflicts
+	}
+	if loadOrder, ok := response.Results[pipeline.NameLoadOrder].Data.(*loadorder.AnalysisResult); ok {
+		b.LoadOrder = loadOrder
+		result.Issues = loadOrder.Stats.TotalIssues
+	}
+	if report, ok := response.Results[pipeline.NameHealth].Data.(*health.Report); ok {
+		score := report.Score
+		result.HealthScore = &score
+		result.HealthRating = report.Rating
+	}
+
+	// A bundle needs conflicts or load order results to be read back
+	if h.history != nil && (b.Conflicts != nil || b.LoadOrder != nil) {
+		entry, err := h.history.Add(ctx, source, b)
+		if err != nil {
+			log.Printf("Error storing %s analysis of %s: %v", source, slug, err)
+		} else {
+			result.HistoryID = entry.ID
+			result.HistoryURL = "/api/history/" + strconv.FormatInt(entry.ID, 10)
+		}
+	}
+	return result, nil
+}
+
+// uniqueSlugs trims collection slugs, dropping blanks and duplicates.
+func uniqueSlugs(slugs []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, slug := range slugs {
+		slug = strings.TrimSpace(slug)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		unique = append(unique, slug)
+	}
+	return unique
+}
+
+// prune drops batches that finished more than batchTTL ago. The caller
+// holds h.mu.
+func (h *BatchHandler) prune() {
+	cutoff := time.Now().Add(-batchTTL)
+	for id, b := range h.batches {
+		if b.done() && b.finished.Before(cutoff) {
+			delete(h.batches, id)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/history"
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+func newTestBatchHandler(t *testing.T, queue *jobs.Queue, store *history.Store) *BatchHandler {
+	t.Helper()
+	client, err := nexus.NewClient(nexus.ClientConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	p, _ := pipeline.New(pipeline.NewConflictStage())
+	analyzer := NewAnalyzeHandler(AnalyzeHandlerConfig{
+		ClientGetter: &mockNexusClientGetter{client: client},
+		Pipeline:     p,
+	})
+	return NewBatchHandler(BatchHandlerConfig{Queue: queue, Analyzer: analyzer, History: store})
+}
+
+func TestBatchHandler_InvalidRequests(t *testing.T) {
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+	handler := newTestBatchHandler(t, queue, nil)
+
+	tooMany := make([]string, maxBatchCollections+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("a", i+1)
+	}
+	body, _ := json.Marshal(BatchAnalyzeRequest{Slugs: tooMany})
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed", "{"},
+		{"no slugs", `{"slugs": [" ", ""]}`},
+		{"too many slugs", string(body)},
+		{"unknown analyzer", `{"slugs": ["abc"], "include": ["conflicts", "bogus"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/analyze/batch", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.AnalyzeBatch(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestBatchHandler_ReadOnly(t *testing.T) {
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+	p, _ := pipeline.New(pipeline.NewConflictStage())
+	handler := NewBatchHandler(BatchHandlerConfig{
+		Queue: queue,
+		Analyzer: NewAnalyzeHandler(AnalyzeHandlerConfig{
+			ClientGetter: &mockNexusClientGetter{},
+			Pipeline:     p,
+			ReadOnly:     true,
+		}),
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze/batch", strings.NewReader(`{"slugs": ["abc"]}`))
+	w := httptest.NewRecorder()
+	handler.AnalyzeBatch(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+}
+
+func TestBatchHandler_AnalyzesEveryCollection(t *testing.T) {
+	// One waiting job per owner, so the batch has to feed the queue as it
+	// drains
+	queue := jobs.New(jobs.Config{Workers: 1, OwnerQueueSize: 1})
+	defer queue.Close()
+	store, err := history.New(history.Config{DBPath: filepath.Join(t.TempDir(), "history.db")})
+	if err != nil {
+		t.Fatalf("failed to create history store: %v", err)
+	}
+	defer store.Close()
+
+	handler := newTestBatchHandler(t, queue, store)
+	handler.analyze = func(ctx context.Context, slug string, revision int, names []string) (*CollectionAnalyzeResponse, error) {
+		if slug == "broken" {
+			return nil, errors.New("collection not found")
+		}
+		conflicts := &conflict.AnalysisResult{Stats: conflict.Stats{TotalConflicts: len(slug)}}
+		return &CollectionAnalyzeResponse{
+			Revision:   3,
+			GameDomain: "skyrimspecialedition",
+			Results:    map[string]pipeline.Result{pipeline.NameConflicts: {Data: conflicts}},
+		}, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/analyze/batch", handler.AnalyzeBatch)
+	mux.HandleFunc("GET /api/analyze/batch/{id}", handler.GetBatch)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze/batch", strings.NewReader(`{"slugs": ["one", "broken", "three", "one"]}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+
+	var batch Batch
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, location, nil))
+		var resp struct {
+			Data Batch `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode batch: %v", err)
+		}
+		batch = resp.Data
+		if batch.Done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not finish: %+v", batch)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var slugs []string
+	for _, c := range batch.Collections {
+		slugs = append(slugs, c.Slug)
+	}
+	if want := []string{"one", "broken", "three"}; !reflect.DeepEqual(slugs, want) {
+		t.Errorf("expected collections %v, got %v", want, slugs)
+	}
+	if c := batch.Collections[1]; c.Status != jobs.StatusFailed || c.Error == "" || c.Result != nil {
+		t.Errorf("expected the broken collection to fail, got %+v", c)
+	}
+
+	want := BatchSummary{Collections: 3, Succeeded: 2, Failed: 1, Conflicts: 8}
+	if batch.Summary == nil || *batch.Summary != want {
+		t.Errorf("expected summary %+v, got %+v", want, batch.Summary)
+	}
+
+	result := batch.Collections[2].Result
+	if result == nil || result.HistoryID == 0 || result.HistoryURL == "" {
+		t.Fatalf("expected a history link, got %+v", result)
+	}
+	record, err := store.Get(context.Background(), result.HistoryID)
+	if err != nil {
+		t.Fatalf("failed to get history entry: %v", err)
+	}
+	if record.Source != history.SourceBatch || record.Slug != "three" || record.Revision != 3 {
+		t.Errorf("unexpected history entry %+v", record.Entry)
+	}
+}
+
+func TestBatchHandler_NotFound(t *testing.T) {
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+	handler := newTestBatchHandler(t, queue, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/analyze/batch/{id}", handler.GetBatch)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/analyze/batch/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestSummarizeBatch(t *testing.T) {
+	score := func(n int) *int { return &n }
+	summary := summarizeBatch([]BatchCollection{
+		{Slug: "a", Result: &BatchCollectionResult{Conflicts: 2, Issues: 1, HealthScore: score(80)}},
+		{Slug: "b", Result: &BatchCollectionResult{Conflicts: 5, HealthScore: score(40)}},
+		{Slug: "c", Result: &BatchCollectionResult{Issues: 3}},
+		{Slug: "d", Status: jobs.StatusFailed, Error: "boom"},
+	})
+
+	want := BatchSummary{Collections: 4, Succeeded: 3, Failed: 1, Conflicts: 7, Issues: 4, LowestHealth: "b"}
+	if *summary != want {
+		t.Errorf("expected %+v, got %+v", want, *summary)
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/bundle"
+	"github.com/mod-troubleshooter/backend/internal/cache"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/export"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
+)
+
+// BundleHandler handles exporting analysis bundles for collection revisions.
+type BundleHandler struct {
+	clientGetter NexusClientGetter
+	cache        *cache.Cache
+	suppressions *suppress.Store
+	apiKey       func() string
+}
+
+// BundleHandlerConfig holds configuration for the BundleHandler.
+type BundleHandlerConfig struct {
+	ClientGetter NexusClientGetter
+	Cache        *cache.Cache
+	// Suppressions hides findings matched by active suppressions (optional).
+	Suppressions *suppress.Store
+	// APIKey returns the configured Nexus API key, which redacted bundles
+	// never contain (optional).
+	APIKey func() string
+}
+
+// NewBundleHandler creates a new bundle handler.
+func NewBundleHandler(cfg BundleHandlerConfig) *BundleHandler {
+	return &BundleHandler{
+		clientGetter: cfg.ClientGetter,
+		cache:        cfg.Cache,
+		suppressions: cfg.Suppressions,
+		apiKey:       cfg.APIKey,
+	}
+}
+
+// ExportBundle handles GET /api/collections/{slug}/revisions/{revision}/bundle
+// Returns a zip of the cached analysis results, mod manifests and an HTML report.
+// Pass ?hideAdult=true to leave adult mods out of the shared bundle.
+// Suppressed findings are left out and counted unless ?showSuppressed=true is passed.
+// Pass ?redact=true to strip API key traces and local paths before sharing.
+func (h *BundleHandler) ExportBundle(w http.ResponseWriter, r *http.Request) {
+	if h.cache == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Cache is not available")
+		return
+	}
+
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+
+	revision, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+
+	b := &bundle.Bundle{
+		Metadata: bundle.Metadata{
+			Slug:      slug,
+			Revision:  revision,
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+
+	// Prefer the hash-enabled analysis when both variants are cached
+	for _, includeHashes := range []bool{true, false} {
+		var conflicts ConflictAnalyzeResponse
+		if err := h.cache.Get(ctx, cache.ConflictsKey(slug, revision, includeHashes), &conflicts); err != nil {
+			continue
+		}
+		b.Conflicts = conflicts.AnalysisResult
+
+		var manifests []conflict.ModManifest
+		if err := h.cache.Get(ctx, cache.ManifestsKey(slug, revision, includeHashes), &manifests); err == nil {
+			b.Manifests = manifests
+		}
+		break
+	}
+
+	var loadOrder LoadOrderAnalyzeResponse
+	if err := h.cache.Get(ctx, cache.LoadOrderKey(slug, revision), &loadOrder); err == nil {
+		b.LoadOrder = loadOrder.AnalysisResult
+	}
+
+	if b.Conflicts == nil && b.LoadOrder == nil {
+		WriteError(w, http.StatusNotFound, "No analysis results for this revision. Run conflict or load order analysis first.")
+		return
+	}
+
+	if !showSuppressed(r) {
+		set := activeSuppressions(ctx, h.suppressions, slug)
+		var conflicts []conflict.Conflict
+		var issues []loadorder.Issue
+		b.Conflicts, conflicts = set.FilterConflicts(b.Conflicts)
+		b.LoadOrder, issues = set.FilterIssues(b.LoadOrder)
+		b.Metadata.SuppressedFindings = len(conflicts) + len(issues)
+	}
+
+	// Adult entries can only be identified from the revision's Nexus metadata
+	if hideAdultParam(r) {
+		var client *nexus.Client
+		if h.clientGetter != nil {
+			client = h.clientGetter.Get()
+		}
+		if client == nil {
+			WriteAPIError(w, http.StatusServiceUnavailable, APIError{
+				Code:    CodeNoAPIKey,
+				Message: "Nexus API key not configured. Hiding adult content requires Nexus access.",
+			})
+			return
+		}
+		revisionDetails, err := client.GetCollectionRevisionMods(ctx, slug, revision)
+		if err != nil {
+			handleNexusError(w, err, "fetch revision mods")
+			return
+		}
+		b.HideMods(adultModIDs(revisionDetails))
+	}
+
+	// Collection details are optional; the bundle is still useful without them
+	if h.clientGetter != nil {
+		if client := h.clientGetter.Get(); client != nil {
+			if collection, err := client.GetCollection(ctx, slug); err == nil {
+				b.Metadata.CollectionName = collection.Name
+				b.Metadata.GameDomain = collection.Game.DomainName
+			} else {
+				log.Printf("Warning: failed to fetch collection %s for bundle: %v", slug, err)
+			}
+		}
+	}
+
+	if redactParam(r) {
+		var secrets []string
+		if h.apiKey != nil {
+			secrets = append(secrets, h.apiKey())
+		}
+		if err := b.Redact(bundle.Redaction{Secrets: secrets}); err != nil {
+			log.Printf("Error redacting bundle: %v", err)
+			WriteError(w, http.StatusInternalServerError, "Failed to build bundle")
+			return
+		}
+	}
+
+	writeBundle(w, b, fmt.Sprintf("%s-rev%d-bundle.zip", slug, revision))
+}
+
+// ExportProvenance handles GET /api/collections/{slug}/revisions/{revision}/provenance
+// Returns every file of the revision's effective Data folder with the mod that
+// provides it, the winner of any conflict over it, from the mod manifests of a
+// cached conflict analysis. Pass ?format=csv for a CSV download instead of JSON,
+// e.g. to look up in xEdit or a script which mod a loose file came from.
+func (h *BundleHandler) ExportProvenance(w http.ResponseWriter, r *http.Request) {
+	if h.cache == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Cache is not available")
+		return
+	}
+
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+
+	revision, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		WriteError(w, http.StatusBadRequest, "Invalid format (available: json, csv)")
+		return
+	}
+
+	// Prefer the hash-enabled analysis when both variants are cached
+	var manifests []conflict.ModManifest
+	found := false
+	for _, includeHashes := range []bool{true, false} {
+		if err := h.cache.Get(ctx, cache.ManifestsKey(slug, revision, includeHashes), &manifests); err == nil {
+			found = true
+			break
+		}
+	}
+	if !found {
+		WriteError(w, http.StatusNotFound, "No mod manifests for this revision. Run conflict analysis first.")
+		return
+	}
+
+	files := conflict.NewAnalyzer().Provenance(manifests)
+
+	if format != "csv" {
+		WriteJSON(w, http.StatusOK, files)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-rev%d-provenance.csv"`, slug, revision))
+	w.WriteHeader(http.StatusOK)
+	if err := export.WriteProvenanceCSV(w, files); err != nil {
+		log.Printf("Error writing provenance: %v", err)
+	}
+}
+
+// redactParam reports whether ?redact=true was passed.
+func redactParam(r *http.Request) bool {
+	redact, _ := strconv.ParseBool(r.URL.Query().Get("redact"))
+	return redact
+}
+
+// writeBundle sends a bundle as a zip download.
+func writeBundle(w http.ResponseWriter, b *bundle.Bundle, filename string) {
+	// Build in memory so errors can still be reported as JSON
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b); err != nil {
+		log.Printf("Error writing bundle: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to build bundle")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("Error sending bundle: %v", err)
+	}
+}
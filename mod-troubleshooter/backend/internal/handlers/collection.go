@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mod-troubleshooter/backend/internal/nexus"
 )
@@ -80,7 +81,7 @@ func extractSlug(input string) string {
 func (h *DynamicCollectionHandler) GetCollection(w http.ResponseWriter, r *http.Request) {
 	client := h.clientGetter.Get()
 	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -113,7 +114,7 @@ func (h *DynamicCollectionHandler) GetCollection(w http.ResponseWriter, r *http.
 func (h *DynamicCollectionHandler) GetCollectionRevisions(w http.ResponseWriter, r *http.Request) {
 	client := h.clientGetter.Get()
 	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -141,10 +142,11 @@ func (h *DynamicCollectionHandler) GetCollectionRevisions(w http.ResponseWriter,
 }
 
 // GetCollectionRevisionMods handles GET /api/collections/{slug}/revisions/{revision}
+// Includes a compliance audit; ?hideAdult=true removes adult entries from the mod list.
 func (h *DynamicCollectionHandler) GetCollectionRevisionMods(w http.ResponseWriter, r *http.Request) {
 	client := h.clientGetter.Get()
 	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -179,7 +181,7 @@ func (h *DynamicCollectionHandler) GetCollectionRevisionMods(w http.ResponseWrit
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, revisionDetails)
+	WriteJSON(w, http.StatusOK, auditRevision(revisionDetails, hideAdultParam(r)))
 }
 
 // GetCollection handles GET /api/collections/{slug}
@@ -238,7 +240,8 @@ func (h *CollectionHandler) GetCollectionRevisions(w http.ResponseWriter, r *htt
 }
 
 // GetCollectionRevisionMods handles GET /api/collections/{slug}/revisions/{revision}
-// Returns mod files for a specific collection revision.
+// Returns mod files for a specific collection revision with a compliance audit.
+// Pass ?hideAdult=true to remove adult entries from the mod list.
 func (h *CollectionHandler) GetCollectionRevisionMods(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -272,7 +275,7 @@ func (h *CollectionHandler) GetCollectionRevisionMods(w http.ResponseWriter, r *
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, revisionDetails)
+	WriteJSON(w, http.StatusOK, auditRevision(revisionDetails, hideAdultParam(r)))
 }
 
 // handleNexusError maps Nexus client errors to HTTP responses.
@@ -282,38 +285,54 @@ func handleNexusError(w http.ResponseWriter, err error, action string) {
 		WriteError(w, http.StatusInternalServerError, "Unknown error occurred")
 		return
 	}
-	
+
 	// Always log the full error details
 	log.Printf("Nexus API error during %s: %+v (type: %T)", action, err, err)
-	
-	// Build error message with details
-	errorDetail := err.Error()
-	
+
 	switch {
 	case errors.Is(err, nexus.ErrNotFound):
-		WriteError(w, http.StatusNotFound, "Resource not found: "+errorDetail)
+		writeNexusError(w, http.StatusNotFound, err, "Resource not found")
 		return
 	case errors.Is(err, nexus.ErrUnauthorized):
-		WriteError(w, http.StatusUnauthorized, "Invalid or missing Nexus API key: "+errorDetail)
+		writeNexusError(w, http.StatusUnauthorized, err, "Invalid or missing Nexus API key")
 		return
 	case errors.Is(err, nexus.ErrPremiumOnly):
-		WriteError(w, http.StatusForbidden, "This feature requires a Nexus Mods Premium account: "+errorDetail)
+		writeNexusError(w, http.StatusForbidden, err, "This feature requires a Nexus Mods Premium account")
 		return
 	case errors.Is(err, nexus.ErrRateLimited):
-		WriteError(w, http.StatusTooManyRequests, "Nexus API rate limit exceeded, please try again later: "+errorDetail)
+		var rateErr *nexus.RateLimitError
+		if errors.As(err, &rateErr) && rateErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(rateErr.RetryAfter.Seconds())+1))
+		}
+		writeNexusError(w, http.StatusTooManyRequests, err, "Nexus API rate limit exceeded, please try again later")
 		return
 	case errors.Is(err, nexus.ErrNoAPIKey):
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured: "+errorDetail)
+		writeNexusError(w, http.StatusServiceUnavailable, err, "Nexus API key not configured")
 		return
 	case errors.Is(err, nexus.ErrGraphQLErrors):
-		WriteError(w, http.StatusInternalServerError, "GraphQL error: "+errorDetail)
+		writeNexusError(w, http.StatusInternalServerError, err, "GraphQL error")
+		return
+	case errors.Is(err, nexus.ErrDegraded):
+		var degraded *nexus.DegradedError
+		if errors.As(err, &degraded) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(degraded.RetryAt).Seconds())+1))
+			writeNexusError(w, http.StatusServiceUnavailable, err, degraded.Error())
+			return
+		}
+		writeNexusError(w, http.StatusServiceUnavailable, err, "Nexus is degraded, please try again later")
 		return
 	case errors.Is(err, nexus.ErrServerError):
-		WriteError(w, http.StatusBadGateway, "Nexus server error: "+errorDetail)
+		writeNexusError(w, http.StatusBadGateway, err, "Nexus server error")
 		return
 	default:
 		// Include full error details for debugging
-		WriteError(w, http.StatusInternalServerError, "Failed to "+action+": "+errorDetail)
+		writeNexusError(w, http.StatusInternalServerError, err, "Failed to "+action)
 		return
 	}
 }
+
+// writeNexusError writes a Nexus error response, with the underlying error
+// as details for debugging.
+func writeNexusError(w http.ResponseWriter, status int, err error, message string) {
+	WriteAPIError(w, status, APIError{Code: errorCode(err), Message: message, Details: err.Error()})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/revision"
+)
+
+// CollectionRunner analyzes collection revisions.
+type CollectionRunner interface {
+	// Run analyzes a collection revision with the named analyzers.
+	Run(ctx context.Context, slug string, revision int, names []string) (*CollectionAnalyzeResponse, error)
+}
+
+// CollectionCompareResponse is the response from comparing two collections.
+type CollectionCompareResponse struct {
+	GameDomain string `json:"gameDomain"`
+	// A and B describe the compared collections.
+	A ComparedCollection `json:"a"`
+	B ComparedCollection `json:"b"`
+	*revision.Overlap
+	// Healthier is "a" or "b" for the collection with the higher health
+	// score, or "tie". It is empty when either score is unknown.
+	Healthier string `json:"healthier,omitempty"`
+	// ScoreDifference is A's health score minus B's.
+	ScoreDifference int `json:"scoreDifference"`
+}
+
+// ComparedCollection is one side of a collection comparison.
+type ComparedCollection struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Revision int    `json:"revision"`
+	// ModsTotal is the number of mod files in the revision.
+	ModsTotal int `json:"modsTotal"`
+	// Health summarizes the health report of the revision, if it was analyzed.
+	Health *HealthSummary `json:"health,omitempty"`
+	// HealthError describes why the revision could not be analyzed.
+	HealthError string `json:"healthError,omitempty"`
+}
+
+// HealthSummary is the headline of a health report.
+type HealthSummary struct {
+	Score        int           `json:"score"`
+	Rating       health.Rating `json:"rating"`
+	ErrorCount   int           `json:"errorCount"`
+	WarningCount int           `json:"warningCount"`
+}
+
+// CompareHandler compares different collections.
+type CompareHandler struct {
+	clientGetter NexusClientGetter
+	analyzer     CollectionRunner
+}
+
+// CompareHandlerConfig holds configuration for the CompareHandler.
+type CompareHandlerConfig struct {
+	ClientGetter NexusClientGetter
+	// Analyzer scores the health of each collection (optional).
+	Analyzer CollectionRunner
+}
+
+// NewCompareHandler creates a new collection comparison handler.
+func NewCompareHandler(cfg CompareHandlerConfig) *CompareHandler {
+	return &CompareHandler{
+		clientGetter: cfg.ClientGetter,
+		analyzer:     cfg.Analyzer,
+	}
+}
+
+// CompareCollections handles GET /api/compare/collections?a={slugA}&b={slugB}
+// Compares the latest published revisions of two collections for the same
+// game: the mods they share, the mods unique to each and their health
+// scores, for users deciding which list to install.
+func (h *CompareHandler) CompareCollections(w http.ResponseWriter, r *http.Request) {
+	client := h.clientGetter.Get()
+	if client == nil {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	slugA := strings.TrimSpace(r.URL.Query().Get("a"))
+	slugB := strings.TrimSpace(r.URL.Query().Get("b"))
+	if slugA == "" || slugB == "" {
+		WriteError(w, http.StatusBadRequest, "Both collection slugs, a and b, are required")
+		return
+	}
+	if strings.EqualFold(slugA, slugB) {
+		WriteError(w, http.StatusBadRequest, "Collections to compare must differ")
+		return
+	}
+
+	collectionA, sourcesA, err := latestSources(ctx, client, slugA)
+	if err != nil {
+		writeJobError(w, err, "fetch collection")
+		return
+	}
+	collectionB, sourcesB, err := latestSources(ctx, client, slugB)
+	if err != nil {
+		writeJobError(w, err, "fetch collection")
+		return
+	}
+
+	gameDomain := collectionA.Game.DomainName
+	if !strings.EqualFold(gameDomain, collectionB.Game.DomainName) {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("Collections are for different games: %s and %s", gameDomain, collectionB.Game.DomainName))
+		return
+	}
+
+	response := CollectionCompareResponse{
+		GameDomain: gameDomain,
+		A:          comparedCollection(slugA, collectionA, len(sourcesA)),
+		B:          comparedCollection(slugB, collectionB, len(sourcesB)),
+		Overlap:    revision.CompareCollections(sourcesA, sourcesB),
+	}
+	h.addHealth(ctx, &response.A)
+	h.addHealth(ctx, &response.B)
+
+	if response.A.Health != nil && response.B.Health != nil {
+		response.ScoreDifference = response.A.Health.Score - response.B.Health.Score
+		switch {
+		case response.ScoreDifference > 0:
+			response.Healthier = "a"
+		case response.ScoreDifference < 0:
+			response.Healthier = "b"
+		default:
+			response.Healthier = "tie"
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// latestSources fetches a collection and lists the mod files of its latest
+// published revision, which the collection query includes.
+func latestSources(ctx context.Context, client *nexus.Client, slug string) (*nexus.Collection, []pipeline.Source, error) {
+	collection, err := client.GetCollection(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	if collection.LatestRevision == nil {
+		return nil, nil, &jobError{
+			status:  http.StatusNotFound,
+			message: fmt.Sprintf("Collection %s has no published revision", slug),
+			err:     fmt.Errorf("collection %s has no published revision", slug),
+		}
+	}
+	return collection, collectionSources(collection.Game.DomainName, collection.LatestRevision), nil
+}
+
+func comparedCollection(slug string, collection *nexus.Collection, modsTotal int) ComparedCollection {
+	return ComparedCollection{
+		Slug:      slug,
+		Name:      collection.Name,
+		Revision:  collection.LatestRevision.RevisionNumber,
+		ModsTotal: modsTotal,
+	}
+}
+
+// addHealth scores a compared collection. A failed analysis leaves the
+// comparison of mods intact, so its error is reported alongside.
+func (h *CompareHandler) addHealth(ctx context.Context, c *ComparedCollection) {
+	if h.analyzer == nil {
+		return
+	}
+	resp, err := h.analyzer.Run(ctx, c.Slug, c.Revision, []string{pipeline.NameHealth})
+	if err != nil {
+		log.Printf("Warning: could not analyze health of collection %s: %v", c.Slug, err)
+		c.HealthError = err.Error()
+		return
+	}
+	result := resp.Results[pipeline.NameHealth]
+	report, ok := result.Data.(*health.Report)
+	if !ok {
+		c.HealthError = result.Error
+		return
+	}
+	c.Health = &HealthSummary{
+		Score:        report.Score,
+		Rating:       report.Rating,
+		ErrorCount:   report.ErrorCount,
+		WarningCount: report.WarningCount,
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+)
+
+// RevisionModsResponse is a revision's mod list with a compliance audit.
+type RevisionModsResponse struct {
+	*nexus.RevisionDetails
+	Compliance ComplianceSummary `json:"compliance"`
+}
+
+// ComplianceSummary lists the mods a curator should review before sharing a collection.
+type ComplianceSummary struct {
+	// AdultMods is the number of mods flagged as adult content, including hidden ones.
+	AdultMods int `json:"adultMods"`
+	// Reuploads is the number of mods published by someone other than their author.
+	Reuploads int `json:"reuploads"`
+	// HiddenAdultMods is the number of adult entries removed by the hideAdult filter.
+	HiddenAdultMods int `json:"hiddenAdultMods"`
+	// Permissions aggregates what the mods' authors allow.
+	Permissions PermissionSummary `json:"permissions"`
+	// Flagged lists every mod with at least one compliance note.
+	Flagged []ModComplianceFlag `json:"flagged"`
+}
+
+// PermissionSummary counts a revision's mods by the permissions their
+// authors set on Nexus.
+type PermissionSummary struct {
+	// Reported is the number of mods whose permissions Nexus reported.
+	Reported int `json:"reported"`
+	// Restricted is the number of mods with a permission that is denied or
+	// needs the author's consent.
+	Restricted int `json:"restricted"`
+	// ByKind counts mods by permission level for each kind of permission.
+	ByKind map[nexus.PermissionKind]map[nexus.PermissionLevel]int `json:"byKind"`
+}
+
+// permissionNotes explain restrictive permissions to curators.
+var permissionNotes = map[nexus.PermissionKind]map[nexus.PermissionLevel]string{
+	nexus.PermissionUpload: {
+		nexus.PermissionDenied: "The author does not allow its files to be uploaded elsewhere, so they cannot be bundled with the collection",
+		nexus.PermissionAsk:    "The author must be asked before its files are bundled with the collection or uploaded elsewhere",
+	},
+	nexus.PermissionModification: {
+		nexus.PermissionDenied: "The author does not allow modified versions, including patches, to be released",
+		nexus.PermissionAsk:    "The author must be asked before modified versions or patches are released",
+	},
+	nexus.PermissionConversion: {
+		nexus.PermissionDenied: "The author does not allow conversions to other games",
+		nexus.PermissionAsk:    "The author must be asked before the mod is converted to other games",
+	},
+	nexus.PermissionAssetUse: {
+		nexus.PermissionDenied: "The author does not allow its assets to be used in other mods",
+		nexus.PermissionAsk:    "The author must be asked before its assets are used in other mods",
+	},
+}
+
+// ModComplianceFlag describes why a mod needs review.
+type ModComplianceFlag struct {
+	ModID   int    `json:"modId"`
+	FileID  int    `json:"fileId"`
+	ModName string `json:"modName"`
+	// Adult is true when the mod is flagged as adult content.
+	Adult bool `json:"adult"`
+	// Reupload is true when the uploader is not the credited author.
+	Reupload bool `json:"reupload"`
+	// Permissions lists the mod's restrictive permissions, if any.
+	Permissions map[nexus.PermissionKind]nexus.PermissionLevel `json:"permissions,omitempty"`
+	// Notes are human-readable permission and content notes.
+	Notes []string `json:"notes"`
+}
+
+// hideAdultParam reports whether the request asks to hide adult entries.
+func hideAdultParam(r *http.Request) bool {
+	hide, _ := strconv.ParseBool(r.URL.Query().Get("hideAdult"))
+	return hide
+}
+
+// auditRevision builds the compliance summary for a revision and, when
+// hideAdult is set, removes adult entries from a copy of its mod list.
+func auditRevision(details *nexus.RevisionDetails, hideAdult bool) RevisionModsResponse {
+	summary := ComplianceSummary{
+		Permissions: PermissionSummary{ByKind: make(map[nexus.PermissionKind]map[nexus.PermissionLevel]int)},
+		Flagged:     []ModComplianceFlag{},
+	}
+	for _, kind := range nexus.PermissionKinds {
+		summary.Permissions.ByKind[kind] = make(map[nexus.PermissionLevel]int)
+	}
+	kept := make([]nexus.ModFileReference, 0, len(details.ModFiles))
+
+	for _, ref := range details.ModFiles {
+		if ref.File == nil || ref.File.Mod == nil {
+			kept = append(kept, ref)
+			continue
+		}
+
+		mod := ref.File.Mod
+		flag := ModComplianceFlag{
+			ModID:    mod.ModID,
+			FileID:   ref.FileID,
+			ModName:  mod.Name,
+			Adult:    mod.AdultContent,
+			Reupload: mod.IsReupload(),
+		}
+		if flag.Adult {
+			summary.AdultMods++
+			flag.Notes = append(flag.Notes, "Flagged as adult content on Nexus")
+		}
+		if flag.Reupload {
+			summary.Reuploads++
+			flag.Notes = append(flag.Notes, fmt.Sprintf("Uploaded by %s on behalf of %s; check the author's distribution permissions", mod.Uploader.Name, mod.Author))
+		}
+		auditPermissions(mod, &flag, &summary.Permissions)
+
+		if flag.Adult && hideAdult {
+			summary.HiddenAdultMods++
+			continue
+		}
+		kept = append(kept, ref)
+		if len(flag.Notes) > 0 {
+			summary.Flagged = append(summary.Flagged, flag)
+		}
+	}
+
+	filtered := *details
+	filtered.ModFiles = kept
+	return RevisionModsResponse{RevisionDetails: &filtered, Compliance: summary}
+}
+
+// auditPermissions counts a mod's permissions and notes the restrictive ones.
+func auditPermissions(mod *nexus.Mod, flag *ModComplianceFlag, summary *PermissionSummary) {
+	if len(mod.Permissions) > 0 {
+		summary.Reported++
+	}
+	restricted := false
+	for _, kind := range nexus.PermissionKinds {
+		level := mod.Permission(kind)
+		summary.ByKind[kind][level]++
+		note, ok := permissionNotes[kind][level]
+		if !ok {
+			continue
+		}
+		restricted = true
+		if flag.Permissions == nil {
+			flag.Permissions = make(map[nexus.PermissionKind]nexus.PermissionLevel)
+		}
+		flag.Permissions[kind] = level
+		flag.Notes = append(flag.Notes, note)
+	}
+	if restricted {
+		summary.Restricted++
+	}
+}
+
+// adultModIDs returns the pipeline mod IDs of a revision's adult mods.
+func adultModIDs(details *nexus.RevisionDetails) map[string]bool {
+	ids := make(map[string]bool)
+	for _, ref := range details.ModFiles {
+		if ref.File != nil && ref.File.Mod != nil && ref.File.Mod.AdultContent {
+			ids[sourceModID(ref.File.Mod.ModID, ref.File.FileID)] = true
+		}
+	}
+	return ids
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+)
+
+func TestAuditRevision_Permissions(t *testing.T) {
+	details := &nexus.RevisionDetails{ModFiles: []nexus.ModFileReference{
+		{FileID: 1, File: &nexus.ModFile{FileID: 1, Mod: &nexus.Mod{ModID: 10, Name: "Open", Permissions: []nexus.ModPermission{
+			{Key: "upload", Value: "yes"},
+			{Key: "assetUse", Value: "yes"},
+		}}}},
+		{FileID: 2, File: &nexus.ModFile{FileID: 2, Mod: &nexus.Mod{ModID: 20, Name: "Closed", Permissions: []nexus.ModPermission{
+			{Key: "upload", Value: "no"},
+			{Key: "conversion", Value: "You must get permission from me before converting"},
+		}}}},
+		{FileID: 3, File: &nexus.ModFile{FileID: 3, Mod: &nexus.Mod{ModID: 30, Name: "Unreported"}}},
+	}}
+
+	resp := auditRevision(details, false)
+	p := resp.Compliance.Permissions
+
+	if p.Reported != 2 || p.Restricted != 1 {
+		t.Errorf("expected 2 reported and 1 restricted mod, got %+v", p)
+	}
+	if upload := p.ByKind[nexus.PermissionUpload]; upload[nexus.PermissionAllowed] != 1 || upload[nexus.PermissionDenied] != 1 || upload[nexus.PermissionUnknown] != 1 {
+		t.Errorf("unexpected upload counts %v", upload)
+	}
+	if len(resp.Compliance.Flagged) != 1 {
+		t.Fatalf("expected 1 flagged mod, got %+v", resp.Compliance.Flagged)
+	}
+	flag := resp.Compliance.Flagged[0]
+	if flag.ModID != 20 || flag.Permissions[nexus.PermissionUpload] != nexus.PermissionDenied ||
+		flag.Permissions[nexus.PermissionConversion] != nexus.PermissionAsk || len(flag.Notes) != 2 {
+		t.Errorf("unexpected flag %+v", flag)
+	}
+}
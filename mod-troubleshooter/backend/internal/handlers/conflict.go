@@ -5,18 +5,41 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/mod-troubleshooter/backend/internal/archive"
 	"github.com/mod-troubleshooter/backend/internal/cache"
 	"github.com/mod-troubleshooter/backend/internal/conflict"
-	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/fingerprint"
+	"github.com/mod-troubleshooter/backend/internal/flight"
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/jobs"
 	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/patch"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/stats"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
 )
 
+// Limits on conflict analysis requests.
+const (
+	// maxConflictRequestBytes bounds the request body, leaving room for
+	// installer choices and passwords on every mod.
+	maxConflictRequestBytes = 8 << 20
+	// maxConflictMods bounds the number of mods in one request.
+	maxConflictMods = 1000
+	// interactiveMods is the most mods an asynchronous analysis may have
+	// to be treated as interactive and run ahead of larger ones.
+	interactiveMods = 5
+)
+
+// errTooManyMods is returned for requests with more than maxConflictMods mods.
+var errTooManyMods = errors.New("too many mods")
+
 // ConflictAnalyzeRequest is the request body for conflict analysis.
 type ConflictAnalyzeRequest struct {
 	// Mods is a list of mods to analyze for conflicts in their intended load order.
@@ -36,58 +59,235 @@ type ModReference struct {
 	Game string `json:"game"`
 	// NexusModID is the mod ID on Nexus.
 	NexusModID int `json:"nexusModId"`
-	// FileID is the file ID on Nexus.
+	// FileID is the file ID on Nexus (optional). Without it the mod's
+	// current main file is used.
 	FileID int `json:"fileId"`
+	// Password opens the archive if it is encrypted (optional).
+	Password string `json:"password,omitempty"`
+	// Choices are the options picked in the mod's FOMOD installer, as
+	// recorded in a collection's collection.json (optional). Only the files
+	// the installer places with them are checked for conflicts.
+	Choices *fomod.Choices `json:"choices,omitempty"`
+	// Patches are the binary patches (xdelta or bsdiff) a collection
+	// applies to the mod's files after installing it (optional). Files are
+	// analyzed as patched where the patch can be applied.
+	Patches []patch.File `json:"patches,omitempty"`
+}
+
+// validate checks the fields a mod reference needs for downloading.
+func (m *ModReference) validate(index int) error {
+	if m.ModID == "" {
+		return fmt.Errorf("ModID is required for mod at index %d", index)
+	}
+	if m.Game == "" {
+		return fmt.Errorf("Game domain is required for mod '%s'", m.ModID)
+	}
+	if m.NexusModID <= 0 {
+		return fmt.Errorf("Valid Nexus mod ID is required for mod '%s'", m.ModID)
+	}
+	for _, p := range m.Patches {
+		if p.Path == "" {
+			return fmt.Errorf("Patch path is required for mod '%s'", m.ModID)
+		}
+		if _, ok := patch.Detect(p.Data); !ok {
+			return fmt.Errorf("Unsupported patch format for '%s' in mod '%s'", p.Path, m.ModID)
+		}
+	}
+	return nil
+}
+
+// decodeConflictRequest reads a conflict analysis request one mod at a
+// time, validating each as it arrives, so a bad or oversized list fails
+// at the first offending mod instead of after the whole body is read.
+func decodeConflictRequest(r io.Reader) (ConflictAnalyzeRequest, error) {
+	var req ConflictAnalyzeRequest
+	invalid := errors.New("Invalid request body")
+
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return req, readError(err, invalid)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return req, readError(err, invalid)
+		}
+		switch key, _ := tok.(string); key {
+		case "mods":
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				return req, readError(err, invalid)
+			}
+			for dec.More() {
+				if len(req.Mods) == maxConflictMods {
+					return req, errTooManyMods
+				}
+				var mod ModReference
+				if err := dec.Decode(&mod); err != nil {
+					return req, readError(err, invalid)
+				}
+				if err := mod.validate(len(req.Mods)); err != nil {
+					return req, err
+				}
+				req.Mods = append(req.Mods, mod)
+			}
+			if _, err := dec.Token(); err != nil {
+				return req, readError(err, invalid)
+			}
+		case "includeContentHashes":
+			if err := dec.Decode(&req.IncludeContentHashes); err != nil {
+				return req, readError(err, invalid)
+			}
+		default:
+			// Unknown fields are ignored, as json.Unmarshal would
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return req, readError(err, invalid)
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return req, readError(err, invalid)
+	}
+	return req, nil
+}
+
+// readError returns the error of a failed body read, which tells an
+// oversized body apart, or fallback for malformed JSON.
+func readError(err, fallback error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	return fallback
 }
 
 // ConflictAnalyzeResponse is the response from conflict analysis.
 type ConflictAnalyzeResponse struct {
 	*conflict.AnalysisResult
 	Cached bool `json:"cached"`
+	// Fingerprint identifies the result; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+	// SuppressedFindings is the number of findings hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings"`
+	// Suppressed lists the hidden findings when showSuppressed=true is requested.
+	Suppressed *SuppressedFindings `json:"suppressed,omitempty"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+	// ModNotes are the collection curator's notes on its mods, such as
+	// which installer options to choose.
+	ModNotes []pipeline.ModNote `json:"modNotes,omitempty"`
 }
 
 // ConflictHandler handles conflict analysis HTTP requests.
 type ConflictHandler struct {
-	clientGetter      NexusClientGetter
-	downloader        *archive.Downloader
-	manifestExtractor *manifest.Extractor
-	cache             *cache.Cache
-	analyzer          *conflict.Analyzer
+	clientGetter NexusClientGetter
+	downloader   *archive.Downloader
+	extractor    *archive.Extractor
+	cache        *cache.Cache
+	stats        *stats.Collector
+	sessions     *pipeline.Sessions
+	suppressions *suppress.Store
+	readOnly     bool
+	sevenZip     *archive.SevenZip
+	sandbox      pipeline.ArchiveReader
+	limiter      *pipeline.Limiter
+	previews     bool
+	manifestTTL  time.Duration
+	stage        *pipeline.ConflictStage
+	queue        *jobs.Queue
+
+	// jobs shares in-flight collection analyses between identical requests
+	jobs flight.Group[ConflictAnalyzeResponse]
 }
 
 // ConflictHandlerConfig holds configuration for the ConflictHandler.
 type ConflictHandlerConfig struct {
 	ClientGetter NexusClientGetter
 	Downloader   *archive.Downloader
-	Cache        *cache.Cache
+	// Extractor reads the FOMOD installers of mods given installer choices
+	// (optional). Without it their whole archives are checked.
+	Extractor *archive.Extractor
+	Cache     *cache.Cache
+	Stats     *stats.Collector
+	// Sessions shares downloads between analyses of the same collection revision (optional).
+	Sessions *pipeline.Sessions
+	// Suppressions hides findings matched by active suppressions (optional).
+	Suppressions *suppress.Store
+	// PairCache reuses mod pair overlaps between analyses (optional).
+	PairCache conflict.PairCache
+	// ReadOnly serves cached results only, rejecting requests that would download from Nexus.
+	ReadOnly bool
+	// SevenZip lists archives the built-in extractor cannot read (optional).
+	SevenZip *archive.SevenZip
+	// Sandbox reads archives in worker processes (optional).
+	Sandbox pipeline.ArchiveReader
+	// Limiter bounds gathering work across analyses (optional).
+	Limiter *pipeline.Limiter
+	// ContentPreviews lists archives from their Nexus content preview instead
+	// of downloading them, unless content hashes are requested.
+	ContentPreviews bool
+	// Jobs runs analyses requested asynchronously (optional). Without it
+	// every request is answered synchronously.
+	Jobs *jobs.Queue
+	// ManifestTTL keeps the listings of mod files in Cache for this long,
+	// so later analyses of the same files skip the download. Zero turns
+	// it off.
+	ManifestTTL time.Duration
 }
 
 // NewConflictHandler creates a new conflict handler.
 func NewConflictHandler(cfg ConflictHandlerConfig) *ConflictHandler {
 	return &ConflictHandler{
-		clientGetter:      cfg.ClientGetter,
-		downloader:        cfg.Downloader,
-		manifestExtractor: manifest.NewExtractor(),
-		cache:             cfg.Cache,
-		analyzer:          conflict.NewAnalyzer(),
+		clientGetter: cfg.ClientGetter,
+		downloader:   cfg.Downloader,
+		extractor:    cfg.Extractor,
+		cache:        cfg.Cache,
+		stats:        cfg.Stats,
+		sessions:     cfg.Sessions,
+		suppressions: cfg.Suppressions,
+		readOnly:     cfg.ReadOnly,
+		sevenZip:     cfg.SevenZip,
+		sandbox:      cfg.Sandbox,
+		limiter:      cfg.Limiter,
+		previews:     cfg.ContentPreviews,
+		manifestTTL:  cfg.ManifestTTL,
+		stage:        pipeline.NewConflictStageWithCache(cfg.PairCache),
+		queue:        cfg.Jobs,
 	}
 }
 
 // AnalyzeConflicts handles POST /api/conflicts/analyze
 // Analyzes a list of mods and returns file conflict information.
+// The mod list is validated as it is read, so an oversized or invalid
+// request fails before the whole body is buffered. With async=true or a
+// "Prefer: respond-async" header, the analysis is handed to the job queue
+// and 202 Accepted is returned with the job to poll at GET /api/jobs/{id};
+// analyses of a handful of mods run ahead of larger ones. When the queue,
+// or the client's share of it, is full, 503 is returned with a Retry-After
+// header.
 func (h *ConflictHandler) AnalyzeConflicts(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
 	client := h.clientGetter.Get()
 	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+		writeNoAPIKey(w)
 		return
 	}
 
-	ctx := r.Context()
-
-	// Parse request body
-	var req ConflictAnalyzeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid request body")
+	req, err := decodeConflictRequest(http.MaxBytesReader(w, r.Body, maxConflictRequestBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", maxConflictRequestBytes))
+		case errors.Is(err, errTooManyMods):
+			WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("At most %d mods can be analyzed at once", maxConflictMods))
+		default:
+			WriteError(w, http.StatusBadRequest, err.Error())
+		}
 		return
 	}
 
@@ -101,68 +301,85 @@ func (h *ConflictHandler) AnalyzeConflicts(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Validate all mod references
-	for i, mod := range req.Mods {
-		if mod.ModID == "" {
-			WriteError(w, http.StatusBadRequest, fmt.Sprintf("ModID is required for mod at index %d", i))
-			return
-		}
-		if mod.Game == "" {
-			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Game domain is required for mod '%s'", mod.ModID))
-			return
+	show := showSuppressed(r)
+	if h.queue != nil && wantsAsync(r) {
+		opts := jobs.Options{Priority: jobs.PriorityNormal, Owner: clientAddr(r)}
+		if len(req.Mods) <= interactiveMods {
+			opts.Priority = jobs.PriorityHigh
 		}
-		if mod.NexusModID <= 0 {
-			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Valid Nexus mod ID is required for mod '%s'", mod.ModID))
-			return
-		}
-		if mod.FileID <= 0 {
-			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Valid file ID is required for mod '%s'", mod.ModID))
+		job, err := h.queue.SubmitWith("conflicts", opts, func(ctx context.Context) (interface{}, error) {
+			response, err := h.analyzeMods(withJobProgress(ctx), client, req, show)
+			if err != nil {
+				return nil, jobFailure(err, "analyze conflicts")
+			}
+			return response, nil
+		})
+		if err != nil {
+			writeQueueError(w, err)
 			return
 		}
+		writeJobAccepted(w, job)
+		return
 	}
 
-	// Build list of mod manifests for analysis
-	modManifests, err := h.fetchModManifests(ctx, client, req.Mods, req.IncludeContentHashes)
+	response, err := h.analyzeMods(r.Context(), client, req, show)
+	if err != nil {
+		writeJobError(w, err, "analyze conflicts")
+		return
+	}
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// analyzeMods downloads the mods of a validated request and analyzes their
+// conflicts.
+func (h *ConflictHandler) analyzeMods(ctx context.Context, client *nexus.Client, req ConflictAnalyzeRequest, show bool) (*ConflictAnalyzeResponse, error) {
+	// Mods given without a file use their current main file
+	sources := modReferenceSources(req.Mods)
+	if err := resolveMainFiles(ctx, client, sources); err != nil {
+		return nil, err
+	}
+
+	// Download each mod once and extract its manifest
+	fetcher := &nexusFetcher{client: client, downloader: h.downloader}
+	in, release, err := h.gatherer(fetcher, fetcher, req.IncludeContentHashes).Gather(ctx, sources, h.stage.Inputs())
 	if err != nil {
-		if errors.Is(err, nexus.ErrPremiumOnly) {
-			WriteError(w, http.StatusForbidden, "This feature requires a Nexus Mods Premium account")
-			return
-		}
 		if errors.Is(err, context.Canceled) {
-			WriteError(w, http.StatusRequestTimeout, "Request cancelled")
-			return
+			return nil, &jobError{status: http.StatusRequestTimeout, message: "Request cancelled", err: err}
 		}
-		log.Printf("Error fetching mod manifests: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to fetch mod information")
-		return
+		return nil, gatherError(err, "Failed to fetch mod information")
 	}
 
+	defer release()
+
 	// Perform conflict analysis
-	result, err := h.analyzer.Analyze(ctx, modManifests)
+	pipeline.ReportAnalyzing(ctx, in, h.stage.Name())
+	result, err := h.stage.AnalyzeConflicts(ctx, in)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
-			WriteError(w, http.StatusRequestTimeout, "Request cancelled")
-			return
+			return nil, &jobError{status: http.StatusRequestTimeout, message: "Request cancelled", err: err}
 		}
-		log.Printf("Error analyzing conflicts: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to analyze conflicts")
-		return
+		return nil, &jobError{status: http.StatusInternalServerError, message: "Failed to analyze conflicts", err: err}
 	}
 
-	response := ConflictAnalyzeResponse{
+	h.stats.RecordConflicts(result)
+
+	response := &ConflictAnalyzeResponse{
 		AnalysisResult: result,
 		Cached:         false,
+		Fingerprint:    fingerprint.Of(result),
+		Warnings:       in.Warnings(),
 	}
-
-	WriteJSON(w, http.StatusOK, response)
+	response.applySuppressions(activeSuppressions(ctx, h.suppressions, ""), show)
+	return response, nil
 }
 
 // AnalyzeCollectionConflicts handles GET /api/collections/{slug}/revisions/{revision}/conflicts
 // Analyzes file conflicts for all mods in a collection revision.
 func (h *ConflictHandler) AnalyzeCollectionConflicts(w http.ResponseWriter, r *http.Request) {
 	client := h.clientGetter.Get()
-	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+	if client == nil && !h.readOnly {
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -190,215 +407,152 @@ func (h *ConflictHandler) AnalyzeCollectionConflicts(w http.ResponseWriter, r *h
 	includeHashes := r.URL.Query().Get("includeHashes") == "true"
 
 	// Check cache
-	cacheKey := fmt.Sprintf("conflicts:%s:%d:%t", slug, revision, includeHashes)
+	cacheKey := cache.ConflictsKey(slug, revision, includeHashes)
 	var cachedResult ConflictAnalyzeResponse
 	if h.cache != nil {
 		if err := h.cache.Get(ctx, cacheKey, &cachedResult); err == nil {
+			h.stats.RecordCacheHit(stats.KindConflicts)
 			cachedResult.Cached = true
-			WriteJSON(w, http.StatusOK, cachedResult)
+			cachedResult.applySuppressions(activeSuppressions(ctx, h.suppressions, slug), showSuppressed(r))
+			WriteResult(w, r, http.StatusOK, cachedResult)
 			return
 		}
+		h.stats.RecordCacheMiss(stats.KindConflicts)
+	}
+
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	// Concurrent requests for the same revision share one analysis
+	response, err := h.jobs.Do(ctx, cacheKey, func(ctx context.Context) (ConflictAnalyzeResponse, error) {
+		return h.analyzeCollection(ctx, client, slug, revision, includeHashes)
+	})
+	if err != nil {
+		writeJobError(w, err, "analyze collection conflicts")
+		return
+	}
+	response.applySuppressions(activeSuppressions(ctx, h.suppressions, slug), showSuppressed(r))
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// CollectionConflictsJob starts a job analyzing the conflicts of a collection
+// revision, as GET /api/collections/{slug}/revisions/{revision}/conflicts
+// does. It takes the includeHashes and showSuppressed options.
+func (h *ConflictHandler) CollectionConflictsJob(req JobRequest) (jobs.Func, error) {
+	if h.readOnly {
+		return nil, errJobReadOnly
+	}
+	client := h.clientGetter.Get()
+	if client == nil {
+		return nil, errJobNoAPIKey
 	}
+	includeHashes := req.Options["includeHashes"] == "true"
+	show := req.Options["showSuppressed"] == "true"
+
+	return func(ctx context.Context) (interface{}, error) {
+		cacheKey := cache.ConflictsKey(req.Slug, req.Revision, includeHashes)
+		var response ConflictAnalyzeResponse
+		if h.cache != nil && h.cache.Get(ctx, cacheKey, &response) == nil {
+			h.stats.RecordCacheHit(stats.KindConflicts)
+			response.Cached = true
+		} else {
+			h.stats.RecordCacheMiss(stats.KindConflicts)
+			var err error
+			response, err = h.jobs.Do(withJobProgress(ctx), cacheKey, func(ctx context.Context) (ConflictAnalyzeResponse, error) {
+				return h.analyzeCollection(ctx, client, req.Slug, req.Revision, includeHashes)
+			})
+			if err != nil {
+				return nil, jobFailure(err, "analyze collection conflicts")
+			}
+		}
+		response.applySuppressions(activeSuppressions(ctx, h.suppressions, req.Slug), show)
+		return response, nil
+	}, nil
+}
 
+// analyzeCollection downloads a collection revision and analyzes its conflicts,
+// caching the result.
+func (h *ConflictHandler) analyzeCollection(ctx context.Context, client *nexus.Client, slug string, revision int, includeHashes bool) (ConflictAnalyzeResponse, error) {
 	// Get collection revision mods
 	revisionDetails, err := client.GetCollectionRevisionMods(ctx, slug, revision)
 	if err != nil {
-		handleNexusError(w, err, "fetch collection revision")
-		return
+		return ConflictAnalyzeResponse{}, fmt.Errorf("fetch collection revision: %w", err)
 	}
 
 	// Get the collection to determine the game
 	collection, err := client.GetCollection(ctx, slug)
 	if err != nil {
-		handleNexusError(w, err, "fetch collection")
-		return
+		return ConflictAnalyzeResponse{}, fmt.Errorf("fetch collection: %w", err)
 	}
 
 	gameDomain := collection.Game.DomainName
 
-	// Extract mod manifests from the collection
-	modManifests, err := h.extractManifestsFromCollection(ctx, client, gameDomain, revisionDetails, includeHashes)
+	// Download each archive once, reusing this revision's session, and extract its manifest
+	session := h.sessions.Acquire(slug, revision)
+	defer session.Done()
+
+	sources, err := revisionSources(ctx, client, gameDomain, revisionDetails)
 	if err != nil {
-		if errors.Is(err, nexus.ErrPremiumOnly) {
-			WriteError(w, http.StatusForbidden, "This feature requires a Nexus Mods Premium account")
-			return
-		}
-		log.Printf("Error extracting manifests: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to extract mod information")
-		return
+		return ConflictAnalyzeResponse{}, err
 	}
-
-	if len(modManifests) < 2 {
-		// Not enough mods for conflict analysis, return empty result
-		response := ConflictAnalyzeResponse{
-			AnalysisResult: &conflict.AnalysisResult{
-				Conflicts:    []conflict.Conflict{},
-				ModSummaries: []conflict.ModConflictSummary{},
-				FileToMods:   make(map[string][]string),
-				Stats:        conflict.Stats{ByFileType: make(map[manifest.FileType]int)},
-			},
-			Cached: false,
-		}
-		WriteJSON(w, http.StatusOK, response)
-		return
+	fetcher := &nexusFetcher{client: client, downloader: h.downloader}
+	in, release, err := h.gatherer(session.Fetcher(fetcher), fetcher, includeHashes).Gather(ctx, sources, h.stage.Inputs())
+	if err != nil {
+		return ConflictAnalyzeResponse{}, gatherError(err, "Failed to extract mod information")
 	}
 
-	// Perform conflict analysis
-	result, err := h.analyzer.Analyze(ctx, modManifests)
+	defer release()
+
+	// Perform conflict analysis (returns an empty result with fewer than two mods)
+	pipeline.ReportAnalyzing(ctx, in, h.stage.Name())
+	result, err := h.stage.AnalyzeConflicts(ctx, in)
 	if err != nil {
-		log.Printf("Error analyzing conflicts: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to analyze conflicts")
-		return
+		return ConflictAnalyzeResponse{}, &jobError{status: http.StatusInternalServerError, message: "Failed to analyze conflicts", err: err}
 	}
 
+	h.stats.RecordConflicts(result)
+
 	response := ConflictAnalyzeResponse{
 		AnalysisResult: result,
 		Cached:         false,
+		Fingerprint:    fingerprint.Of(result),
+		Warnings:       in.Warnings(),
+		ModNotes:       in.Notes(),
 	}
 
-	// Cache the result
+	// Cache the result along with the manifests so the revision can be exported as a bundle
 	if h.cache != nil {
-		if err := h.cache.Set(ctx, cacheKey, response); err != nil {
+		if err := h.cache.Set(ctx, cache.ConflictsKey(slug, revision, includeHashes), response); err != nil {
 			log.Printf("Error caching result: %v", err)
 		}
-	}
-
-	WriteJSON(w, http.StatusOK, response)
-}
-
-// fetchModManifests downloads mod archives and extracts their file manifests.
-func (h *ConflictHandler) fetchModManifests(ctx context.Context, client *nexus.Client, mods []ModReference, includeHashes bool) ([]conflict.ModManifest, error) {
-	modManifests := make([]conflict.ModManifest, 0, len(mods))
-
-	for i, mod := range mods {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		modManifest := conflict.ModManifest{
-			ModID:     mod.ModID,
-			ModName:   mod.ModName,
-			LoadOrder: i,
-		}
-
-		// Get download links (map game ID to Nexus domain)
-		modGameDomain := GetNexusDomain(mod.Game)
-		links, err := client.GetModFileDownloadLinks(ctx, modGameDomain, mod.NexusModID, mod.FileID)
-		if err != nil {
-			// Log and continue with empty manifest
-			log.Printf("Warning: could not get download links for mod %s: %v", mod.ModID, err)
-			modManifests = append(modManifests, modManifest)
-			continue
-		}
-
-		if len(links) == 0 {
-			log.Printf("Warning: no download links available for mod %s", mod.ModID)
-			modManifests = append(modManifests, modManifest)
-			continue
-		}
-
-		// Download the archive
-		downloadResult, err := h.downloader.Download(ctx, links[0].URI, nil)
-		if err != nil {
-			log.Printf("Warning: could not download mod %s: %v", mod.ModID, err)
-			modManifests = append(modManifests, modManifest)
-			continue
-		}
-
-		// Extract manifest
-		var manifestData *manifest.Manifest
-		if includeHashes {
-			manifestData, err = h.manifestExtractor.ExtractManifestWithHashes(ctx, downloadResult.FilePath)
-		} else {
-			manifestData, err = h.manifestExtractor.ExtractManifest(ctx, downloadResult.FilePath)
+		if err := h.cache.Set(ctx, cache.ManifestsKey(slug, revision, includeHashes), pipeline.ModManifests(in)); err != nil {
+			log.Printf("Error caching manifests: %v", err)
 		}
-
-		// Clean up downloaded file
-		h.downloader.CleanupPath(downloadResult.FilePath)
-
-		if err != nil {
-			log.Printf("Warning: could not extract manifest for mod %s: %v", mod.ModID, err)
-			modManifests = append(modManifests, modManifest)
-			continue
-		}
-
-		modManifest.Manifest = manifestData
-		modManifests = append(modManifests, modManifest)
 	}
 
-	return modManifests, nil
+	return response, nil
 }
 
-// extractManifestsFromCollection extracts file manifests from all mods in a collection.
-func (h *ConflictHandler) extractManifestsFromCollection(ctx context.Context, client *nexus.Client, gameDomain string, revision *nexus.RevisionDetails, includeHashes bool) ([]conflict.ModManifest, error) {
-	var modManifests []conflict.ModManifest
-
-	for i, modFile := range revision.ModFiles {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		if modFile.File == nil || modFile.File.Mod == nil {
-			continue
-		}
-
-		modName := modFile.File.Mod.Name
-		if modName == "" {
-			modName = modFile.File.Name
-		}
-
-		modManifest := conflict.ModManifest{
-			ModID:     fmt.Sprintf("%d-%d", modFile.File.Mod.ModID, modFile.File.FileID),
-			ModName:   modName,
-			LoadOrder: i,
-		}
-
-		// Only process archive files for conflict detection
-		filename := modFile.File.Name
-		lowerName := strings.ToLower(filename)
-		if !isArchiveFilename(lowerName) {
-			// Skip non-archive files (individual plugins, etc.)
-			continue
-		}
-
-		// Get download links
-		links, err := client.GetModFileDownloadLinks(ctx, gameDomain, modFile.File.Mod.ModID, modFile.File.FileID)
-		if err != nil {
-			log.Printf("Warning: could not get download links for %s: %v", filename, err)
-			continue
-		}
-
-		if len(links) == 0 {
-			log.Printf("Warning: no download links for %s", filename)
-			continue
-		}
-
-		// Download the archive
-		downloadResult, err := h.downloader.Download(ctx, links[0].URI, nil)
-		if err != nil {
-			log.Printf("Warning: could not download %s: %v", filename, err)
-			continue
-		}
-
-		// Extract manifest
-		var manifestData *manifest.Manifest
-		if includeHashes {
-			manifestData, err = h.manifestExtractor.ExtractManifestWithHashes(ctx, downloadResult.FilePath)
-		} else {
-			manifestData, err = h.manifestExtractor.ExtractManifest(ctx, downloadResult.FilePath)
-		}
-
-		// Clean up downloaded file
-		h.downloader.CleanupPath(downloadResult.FilePath)
-
-		if err != nil {
-			log.Printf("Warning: could not extract manifest from %s: %v", filename, err)
-			continue
-		}
-
-		modManifest.Manifest = manifestData
-		modManifests = append(modManifests, modManifest)
+// gatherer creates a pipeline gatherer that downloads through the given
+// fetcher, listing archives through nf first when content previews are
+// enabled. Listings kept in the cache from earlier analyses come first.
+func (h *ConflictHandler) gatherer(fetcher pipeline.Fetcher, nf *nexusFetcher, includeHashes bool) *pipeline.Gatherer {
+	cfg := pipeline.GathererConfig{
+		Fetcher:       fetcher,
+		Extractor:     h.extractor,
+		ContentHashes: includeHashes,
+		SevenZip:      h.sevenZip,
+		Sandbox:       h.sandbox,
+		Limiter:       h.limiter,
+		Previewer:     previewer(nf, h.previews),
+		Verifier:      nf,
 	}
-
-	return modManifests, nil
+	if h.cache != nil && h.manifestTTL > 0 {
+		cfg.Manifests = h.cache
+		cfg.ManifestTTL = h.manifestTTL
+	}
+	return pipeline.NewGatherer(cfg)
 }
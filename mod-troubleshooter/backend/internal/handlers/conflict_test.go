@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+)
+
+func TestDecodeConflictRequest(t *testing.T) {
+	mod := `{"modId":"a","game":"skyrimspecialedition","nexusModId":1}`
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+		mods    int
+	}{
+		{name: "valid", body: `{"mods":[` + mod + `,` + mod + `],"includeContentHashes":true,"extra":{"x":1}}`, mods: 2},
+		{name: "malformed", body: `{"mods":[`, wantErr: "Invalid request body"},
+		{name: "not an object", body: `[]`, wantErr: "Invalid request body"},
+		// The invalid mod fails the request before the malformed rest is read
+		{name: "unknown patch format", body: `{"mods":[{"modId":"a","game":"skyrim","nexusModId":1,"patches":[{"path":"a.esp","data":"UEFUQ0g="}]}]}`, wantErr: "Unsupported patch format for 'a.esp' in mod 'a'"},
+		{name: "invalid mod", body: `{"mods":[` + mod + `,{"modId":"b","nexusModId":2},garbage`, wantErr: "Game domain is required for mod 'b'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := decodeConflictRequest(strings.NewReader(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(req.Mods) != tt.mods || !req.IncludeContentHashes {
+				t.Errorf("unexpected request %+v", req)
+			}
+		})
+	}
+}
+
+func TestDecodeConflictRequest_TooManyMods(t *testing.T) {
+	mods := make([]string, maxConflictMods+1)
+	for i := range mods {
+		mods[i] = fmt.Sprintf(`{"modId":"m%d","game":"skyrim","nexusModId":%d}`, i, i+1)
+	}
+	body := `{"mods":[` + strings.Join(mods, ",") + `]}`
+
+	if _, err := decodeConflictRequest(strings.NewReader(body)); !errors.Is(err, errTooManyMods) {
+		t.Errorf("expected errTooManyMods, got %v", err)
+	}
+}
+
+func newTestConflictHandler(t *testing.T, queue *jobs.Queue) *http.ServeMux {
+	t.Helper()
+	client, err := nexus.NewClient(nexus.ClientConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	handler := NewConflictHandler(ConflictHandlerConfig{
+		ClientGetter: &mockNexusClientGetter{client: client},
+		Jobs:         queue,
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/conflicts/analyze", handler.AnalyzeConflicts)
+	return mux
+}
+
+func TestConflictHandler_BodyTooLarge(t *testing.T) {
+	mux := newTestConflictHandler(t, nil)
+
+	body := `{"extra":"` + strings.Repeat("a", maxConflictRequestBytes) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/conflicts/analyze", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+}
+
+func TestConflictHandler_Async(t *testing.T) {
+	queue := jobs.New(jobs.Config{})
+	mux := newTestConflictHandler(t, queue)
+
+	body := `{"mods":[{"modId":"a","game":"skyrim","nexusModId":1,"fileId":1},{"modId":"b","game":"skyrim","nexusModId":2,"fileId":2}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/conflicts/analyze", strings.NewReader(body))
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	// Closing cancels the job before it can reach Nexus
+	queue.Close()
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, "/api/jobs/") {
+		t.Errorf("expected a job location, got %q", loc)
+	}
+	if !strings.Contains(w.Body.String(), `"status":"queued"`) {
+		t.Errorf("expected the queued job in the body, got %s", w.Body.String())
+	}
+}
@@ -12,20 +12,27 @@ import (
 // DownloadHandler handles download-related HTTP requests.
 type DownloadHandler struct {
 	clientGetter NexusClientGetter
+	readOnly     bool
 }
 
 // NewDownloadHandler creates a new download handler with a dynamic client getter.
-func NewDownloadHandler(getter NexusClientGetter) *DownloadHandler {
-	return &DownloadHandler{clientGetter: getter}
+// In read-only mode no download links are handed out.
+func NewDownloadHandler(getter NexusClientGetter, readOnly bool) *DownloadHandler {
+	return &DownloadHandler{clientGetter: getter, readOnly: readOnly}
 }
 
 // GetModFileDownloadLinks handles GET /api/games/{game}/mods/{modId}/files/{fileId}/download
 // Returns download URLs for the specified mod file.
 // This endpoint requires a Nexus Mods Premium account.
 func (h *DownloadHandler) GetModFileDownloadLinks(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
 	client := h.clientGetter.Get()
 	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -79,15 +86,15 @@ func (h *DownloadHandler) GetModFileDownloadLinks(w http.ResponseWriter, r *http
 func handleDownloadError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, nexus.ErrNotFound):
-		WriteError(w, http.StatusNotFound, "Mod file not found")
+		writeErrorFor(w, http.StatusNotFound, err, "Mod file not found")
 	case errors.Is(err, nexus.ErrUnauthorized):
-		WriteError(w, http.StatusUnauthorized, "Invalid or missing Nexus API key")
+		writeErrorFor(w, http.StatusUnauthorized, err, "Invalid or missing Nexus API key")
 	case errors.Is(err, nexus.ErrPremiumOnly):
-		WriteError(w, http.StatusForbidden, "This feature requires a Nexus Mods Premium account")
+		writeErrorFor(w, http.StatusForbidden, err, "This feature requires a Nexus Mods Premium account")
 	case errors.Is(err, nexus.ErrRateLimited):
-		WriteError(w, http.StatusTooManyRequests, "Nexus API rate limit exceeded, please try again later")
+		writeErrorFor(w, http.StatusTooManyRequests, err, "Nexus API rate limit exceeded, please try again later")
 	case errors.Is(err, nexus.ErrNoAPIKey):
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured")
+		writeErrorFor(w, http.StatusServiceUnavailable, err, "Nexus API key not configured")
 	default:
 		log.Printf("Error: failed to fetch download links: %v", err)
 		WriteError(w, http.StatusInternalServerError, "Failed to fetch download links")
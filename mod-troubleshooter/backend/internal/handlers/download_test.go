@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadHandler_ReadOnly(t *testing.T) {
+	handler := NewDownloadHandler(&mockNexusClientGetter{}, true)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/games/{game}/mods/{modId}/files/{fileId}/download", handler.GetModFileDownloadLinks)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/games/skyrimse/mods/1/files/2/download", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if apiErr := decodeAPIError(t, w); apiErr.Code != CodeReadOnly {
+		t.Errorf("expected code %s, got %s", CodeReadOnly, apiErr.Code)
+	}
+}
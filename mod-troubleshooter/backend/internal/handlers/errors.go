@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+)
+
+// ErrReadOnly is returned by analyses run outside of HTTP requests while
+// the server is in read-only mode.
+var ErrReadOnly = errors.New("analyses that download from Nexus are disabled in read-only mode")
+
+// ErrorCode is a stable, machine-readable error identifier. Clients branch
+// on codes rather than messages, which may change.
+type ErrorCode string
+
+// Error codes. These are part of the API; don't rename them.
+const (
+	CodeBadRequest        ErrorCode = "bad_request"
+	CodeUnauthorized      ErrorCode = "unauthorized"
+	CodeForbidden         ErrorCode = "forbidden"
+	CodePremiumRequired   ErrorCode = "premium_required"
+	CodeNotFound          ErrorCode = "not_found"
+	CodeMethodNotAllowed  ErrorCode = "method_not_allowed"
+	CodeTimeout           ErrorCode = "timeout"
+	CodePayloadTooLarge   ErrorCode = "payload_too_large"
+	CodeUnprocessable     ErrorCode = "unprocessable"
+	CodeRateLimited       ErrorCode = "rate_limited"
+	CodeInternal          ErrorCode = "internal"
+	CodeUpstream          ErrorCode = "upstream_error"
+	CodeUnavailable       ErrorCode = "unavailable"
+	CodeReadOnly          ErrorCode = "read_only"
+	CodeNoAPIKey          ErrorCode = "no_api_key"
+	CodeNexusDegraded     ErrorCode = "nexus_degraded"
+	CodeDownloadFailed    ErrorCode = "download_failed"
+	CodeUnexpectedContent ErrorCode = "unexpected_content"
+	CodeInfected          ErrorCode = "infected"
+	CodeScanFailed        ErrorCode = "scan_failed"
+)
+
+// APIError is the error object of a failed response.
+type APIError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// Details is extra context for debugging, such as the underlying error.
+	Details string `json:"details,omitempty"`
+	// CorrelationID is the request ID, for matching a report with the logs.
+	CorrelationID string `json:"correlationId,omitempty"`
+	// RetryAfter is the number of seconds to wait before retrying.
+	RetryAfter int `json:"retryAfter,omitempty"`
+}
+
+// statusCode returns the default error code for an HTTP status.
+func statusCode(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return CodeTimeout
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// errorCode maps an internal error to its code, or "" when the error has no
+// specific code and the status decides.
+func errorCode(err error) ErrorCode {
+	switch {
+	case errors.Is(err, nexus.ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, nexus.ErrUnauthorized):
+		return CodeUnauthorized
+	case errors.Is(err, nexus.ErrPremiumOnly):
+		return CodePremiumRequired
+	case errors.Is(err, nexus.ErrRateLimited):
+		return CodeRateLimited
+	case errors.Is(err, nexus.ErrNoAPIKey):
+		return CodeNoAPIKey
+	case errors.Is(err, nexus.ErrDegraded):
+		return CodeNexusDegraded
+	case errors.Is(err, nexus.ErrServerError), errors.Is(err, nexus.ErrGraphQLErrors):
+		return CodeUpstream
+	case errors.Is(err, archive.ErrNoURL):
+		return CodeBadRequest
+	case errors.Is(err, archive.ErrDownloadFailed):
+		return CodeDownloadFailed
+	case errors.Is(err, archive.ErrFileTooLarge):
+		return CodePayloadTooLarge
+	case errors.Is(err, archive.ErrUnexpectedContent), errors.Is(err, archive.ErrInvalidResponse):
+		return CodeUnexpectedContent
+	case errors.Is(err, archive.ErrInfected):
+		return CodeInfected
+	case errors.Is(err, archive.ErrScanFailed):
+		return CodeScanFailed
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	}
+	return ""
+}
+
+// RequestIDHeader carries the correlation ID of a request and its response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds client-supplied request IDs.
+const maxRequestIDLen = 64
+
+// RequestID wraps next so that every response carries an X-Request-ID
+// header, which error responses repeat as their correlationId. A valid ID
+// sent by the client or a proxy is kept; otherwise a new one is generated.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID reports whether id is safe to echo back and log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 16-byte hex ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// retryAfterSeconds returns the Retry-After header already set on the
+// response, or 0.
+func retryAfterSeconds(w http.ResponseWriter) int {
+	seconds, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return seconds
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+)
+
+func decodeAPIError(t *testing.T, w *httptest.ResponseRecorder) *APIError {
+	t.Helper()
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Error == nil {
+		t.Fatal("expected response to have error field")
+	}
+	return resp.Error
+}
+
+func TestWriteError_Envelope(t *testing.T) {
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		WriteError(w, http.StatusTooManyRequests, "Slow down")
+	})
+	handler = RequestID(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/abc", nil)
+	req.Header.Set(RequestIDHeader, "client-id-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	apiErr := decodeAPIError(t, w)
+	if apiErr.Code != CodeRateLimited {
+		t.Errorf("expected code %s, got %s", CodeRateLimited, apiErr.Code)
+	}
+	if apiErr.Message != "Slow down" {
+		t.Errorf("expected message, got %q", apiErr.Message)
+	}
+	if apiErr.CorrelationID != "client-id-1" || w.Header().Get(RequestIDHeader) != "client-id-1" {
+		t.Errorf("expected client request ID to be kept, got %q", apiErr.CorrelationID)
+	}
+	if apiErr.RetryAfter != 30 {
+		t.Errorf("expected retryAfter 30, got %d", apiErr.RetryAfter)
+	}
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, http.StatusBadRequest, "Bad")
+	}))
+
+	for _, sent := range []string{"", "has spaces\r\ninjected: header"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if sent != "" {
+			req.Header.Set(RequestIDHeader, sent)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		id := w.Header().Get(RequestIDHeader)
+		if len(id) != 32 {
+			t.Errorf("expected generated 32 character ID for %q, got %q", sent, id)
+		}
+		if apiErr := decodeAPIError(t, w); apiErr.CorrelationID != id {
+			t.Errorf("expected correlationId %q, got %q", id, apiErr.CorrelationID)
+		}
+	}
+}
+
+func TestHandleNexusError_Codes(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   ErrorCode
+	}{
+		{fmt.Errorf("fetch collection: %w", nexus.ErrNotFound), http.StatusNotFound, CodeNotFound},
+		{nexus.ErrPremiumOnly, http.StatusForbidden, CodePremiumRequired},
+		{nexus.ErrNoAPIKey, http.StatusServiceUnavailable, CodeNoAPIKey},
+		{nexus.ErrServerError, http.StatusBadGateway, CodeUpstream},
+		{fmt.Errorf("something broke"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handleNexusError(w, tt.err, "fetch collection")
+
+		if w.Code != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, w.Code)
+		}
+		apiErr := decodeAPIError(t, w)
+		if apiErr.Code != tt.code {
+			t.Errorf("%v: expected code %s, got %s", tt.err, tt.code, apiErr.Code)
+		}
+		if apiErr.Details != tt.err.Error() {
+			t.Errorf("%v: expected details to carry the error, got %q", tt.err, apiErr.Details)
+		}
+	}
+}
+
+func TestHandleFomodError_Codes(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleFomodError(w, fmt.Errorf("download: %w", archive.ErrUnexpectedContent))
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", w.Code)
+	}
+	if apiErr := decodeAPIError(t, w); apiErr.Code != CodeUnexpectedContent {
+		t.Errorf("expected code %s, got %s", CodeUnexpectedContent, apiErr.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handleFomodError(w, fmt.Errorf("download: %w: Eicar-Test-Signature in a.zip", archive.ErrInfected))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+	if apiErr := decodeAPIError(t, w); apiErr.Code != CodeInfected {
+		t.Errorf("expected code %s, got %s", CodeInfected, apiErr.Code)
+	}
+}
+
+func TestWriteResult_Negotiation(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/msgpack", "application/msgpack"},
+		{"application/json, application/x-msgpack", "application/msgpack"},
+		{"application/json, application/msgpack;q=0.5", "application/json"},
+		{"application/msgpack;q=0", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/conflicts", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			WriteResult(w, req, http.StatusOK, map[string]int{"conflicts": 3})
+
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected %s, got %s", tt.contentType, got)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Error("expected the response to vary on Accept")
+			}
+		})
+	}
+}
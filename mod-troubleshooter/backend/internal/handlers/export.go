@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/export"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+// ExportHandler handles converting analysis results into external tool formats.
+type ExportHandler struct{}
+
+// NewExportHandler creates a new export handler.
+func NewExportHandler() *ExportHandler {
+	return &ExportHandler{}
+}
+
+// ExportLOOTUserlist handles POST /api/export/loot
+// Accepts a load order analysis result and returns a LOOT userlist.yaml.
+func (h *ExportHandler) ExportLOOTUserlist(w http.ResponseWriter, r *http.Request) {
+	var result loadorder.AnalysisResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	userlist := export.NewLOOTUserlist(&result)
+
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="userlist.yaml"`)
+	w.WriteHeader(http.StatusOK)
+	if err := userlist.WriteYAML(w); err != nil {
+		log.Printf("Error writing LOOT userlist: %v", err)
+	}
+}
+
+// ExportMO2Notes handles POST /api/export/mo2
+// Accepts a conflict analysis result and returns per-mod notes and colors as CSV
+// for annotating the mod list in Mod Organizer 2.
+func (h *ExportHandler) ExportMO2Notes(w http.ResponseWriter, r *http.Request) {
+	var result conflict.AnalysisResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	notes := export.NewMO2Notes(&result)
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="mo2-notes.csv"`)
+	w.WriteHeader(http.StatusOK)
+	if err := export.WriteMO2NotesCSV(w, notes); err != nil {
+		log.Printf("Error writing MO2 notes: %v", err)
+	}
+}
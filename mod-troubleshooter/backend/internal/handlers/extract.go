@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+// DefaultMaxExtractSize is the largest file served from a mod archive,
+// enough for readmes, INI files and FOMOD configurations.
+const DefaultMaxExtractSize = 2 * 1024 * 1024
+
+// ExtractHandler serves single files from mod archives.
+type ExtractHandler struct {
+	clientGetter NexusClientGetter
+	downloader   *archive.Downloader
+	extractor    *archive.Extractor
+	sessions     *pipeline.Sessions
+	readOnly     bool
+	maxSize      int64
+}
+
+// ExtractHandlerConfig holds configuration for the ExtractHandler.
+type ExtractHandlerConfig struct {
+	ClientGetter NexusClientGetter
+	Downloader   *archive.Downloader
+	Extractor    *archive.Extractor
+	// Sessions keeps recently read archives, so further files from the same
+	// mod are served without downloading it again (optional).
+	Sessions *pipeline.Sessions
+	// ReadOnly rejects requests, since they download from Nexus.
+	ReadOnly bool
+	// MaxSize is the largest file served (default: DefaultMaxExtractSize).
+	MaxSize int64
+}
+
+// NewExtractHandler creates a new archive file handler.
+func NewExtractHandler(cfg ExtractHandlerConfig) *ExtractHandler {
+	maxSize := cfg.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxExtractSize
+	}
+	return &ExtractHandler{
+		clientGetter: cfg.ClientGetter,
+		downloader:   cfg.Downloader,
+		extractor:    cfg.Extractor,
+		sessions:     cfg.Sessions,
+		readOnly:     cfg.ReadOnly,
+		maxSize:      maxSize,
+	}
+}
+
+// ExtractFile handles GET /api/games/{game}/mods/{modId}/files/{fileId}/extract?path={path}
+// Returns a single file from a mod archive, such as readme.txt, an INI file
+// or fomod/ModuleConfig.xml, so its contents can be shown on demand. Text is
+// served as text/plain; anything else as a download.
+func (h *ExtractHandler) ExtractFile(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	client := h.clientGetter.Get()
+	if client == nil {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	game := r.PathValue("game")
+	if game == "" {
+		WriteError(w, http.StatusBadRequest, "Game domain is required")
+		return
+	}
+	modID, err := strconv.Atoi(r.PathValue("modId"))
+	if err != nil || modID <= 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid mod ID")
+		return
+	}
+	fileID, err := strconv.Atoi(r.PathValue("fileId"))
+	if err != nil || fileID <= 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid file ID")
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("path"))
+	if name == "" {
+		WriteError(w, http.StatusBadRequest, "File path is required")
+		return
+	}
+
+	gameDomain := GetNexusDomain(game)
+	src := pipeline.Source{
+		ModID:      sourceModID(modID, fileID),
+		Game:       gameDomain,
+		NexusModID: modID,
+		FileID:     fileID,
+	}
+
+	// Each mod file gets its own session, so reading several files from it
+	// downloads it once
+	session := h.sessions.Acquire(gameDomain+"/"+src.ModID, fileID)
+	defer session.Done()
+	fetcher := session.Fetcher(&nexusFetcher{client: client, downloader: h.downloader})
+
+	archivePath, err := fetcher.Fetch(ctx, src)
+	if err != nil {
+		handleExtractError(w, err)
+		return
+	}
+	defer fetcher.Release(archivePath)
+
+	data, err := h.extractor.ReadFile(ctx, archivePath, "", name, h.maxSize)
+	if err != nil {
+		handleExtractError(w, err)
+		return
+	}
+
+	filename := strings.ReplaceAll(path.Base(strings.ReplaceAll(name, "\\", "/")), `"`, "")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if utf8.Valid(data) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// handleExtractError maps errors to HTTP responses for archive file extraction.
+func handleExtractError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pipeline.ErrUnavailable):
+		writeErrorFor(w, http.StatusNotFound, err, "Mod file not found")
+	case errors.Is(err, archive.ErrPathNotFound):
+		writeErrorFor(w, http.StatusNotFound, err, "File not found in the mod archive")
+	case errors.Is(err, archive.ErrFileTooLarge):
+		writeErrorFor(w, http.StatusRequestEntityTooLarge, err, "File is too large to extract")
+	case errors.Is(err, archive.ErrUnsupportedFormat):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "Mod file is not a supported archive")
+	case errors.Is(err, archive.ErrExtractionFailed):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "Mod archive could not be read")
+	case errors.Is(err, archive.ErrInfected):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "The mod archive was flagged by the virus scanner and quarantined")
+	case errors.Is(err, archive.ErrDownloadFailed), errors.Is(err, archive.ErrUnexpectedContent), errors.Is(err, archive.ErrInvalidResponse):
+		writeErrorFor(w, http.StatusBadGateway, err, "Failed to download mod archive")
+	default:
+		log.Printf("Error extracting file from mod archive: %v", err)
+		handleNexusError(w, err, "extract file from mod archive")
+	}
+}
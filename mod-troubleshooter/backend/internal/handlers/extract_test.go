@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+func TestExtractHandler_ReadOnly(t *testing.T) {
+	handler := NewExtractHandler(ExtractHandlerConfig{ClientGetter: &mockNexusClientGetter{}, ReadOnly: true})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/games/{game}/mods/{modId}/files/{fileId}/extract", handler.ExtractFile)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/games/skyrimse/mods/1/files/2/extract?path=readme.txt", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+}
+
+func TestHandleExtractError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("fetch: %w", pipeline.ErrUnavailable), http.StatusNotFound},
+		{fmt.Errorf("%w: readme.txt", archive.ErrPathNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: readme.txt", archive.ErrFileTooLarge), http.StatusRequestEntityTooLarge},
+		{fmt.Errorf("%w: rar", archive.ErrUnsupportedFormat), http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w: bad header", archive.ErrExtractionFailed), http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w: status 500", archive.ErrDownloadFailed), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handleExtractError(w, tt.err)
+		if w.Code != tt.want {
+			t.Errorf("handleExtractError(%v) status = %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
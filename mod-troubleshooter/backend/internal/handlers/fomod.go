@@ -3,31 +3,39 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
 
 	"github.com/mod-troubleshooter/backend/internal/archive"
 	"github.com/mod-troubleshooter/backend/internal/cache"
 	"github.com/mod-troubleshooter/backend/internal/fomod"
 	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/stats"
 )
 
+// maxModuleConfigSize limits the size of ModuleConfig.xml files sent for validation.
+const maxModuleConfigSize = 4 * 1024 * 1024 // 4MB
+
 // FomodAnalyzeRequest is the request body for FOMOD analysis.
 type FomodAnalyzeRequest struct {
 	Game   string `json:"game"`
 	ModID  int    `json:"modId"`
 	FileID int    `json:"fileId"`
+	// Password opens the archive if it is encrypted (optional).
+	Password string `json:"password,omitempty"`
 }
 
 // FomodAnalyzeResponse is the response from FOMOD analysis.
 type FomodAnalyzeResponse struct {
-	Game     string          `json:"game"`
-	ModID    int             `json:"modId"`
-	FileID   int             `json:"fileId"`
-	HasFomod bool            `json:"hasFomod"`
+	Game     string           `json:"game"`
+	ModID    int              `json:"modId"`
+	FileID   int              `json:"fileId"`
+	HasFomod bool             `json:"hasFomod"`
 	Data     *fomod.FomodData `json:"data,omitempty"`
-	Cached   bool            `json:"cached"`
+	Cached   bool             `json:"cached"`
 }
 
 // FomodHandler handles FOMOD analysis HTTP requests.
@@ -36,6 +44,8 @@ type FomodHandler struct {
 	downloader   *archive.Downloader
 	extractor    *archive.Extractor
 	cache        *cache.Cache
+	stats        *stats.Collector
+	readOnly     bool
 }
 
 // FomodHandlerConfig holds configuration for the FomodHandler.
@@ -44,6 +54,9 @@ type FomodHandlerConfig struct {
 	Downloader   *archive.Downloader
 	Extractor    *archive.Extractor
 	Cache        *cache.Cache
+	Stats        *stats.Collector
+	// ReadOnly serves cached results only, rejecting requests that would download from Nexus.
+	ReadOnly bool
 }
 
 // NewFomodHandler creates a new FOMOD handler.
@@ -53,6 +66,8 @@ func NewFomodHandler(cfg FomodHandlerConfig) *FomodHandler {
 		downloader:   cfg.Downloader,
 		extractor:    cfg.Extractor,
 		cache:        cfg.Cache,
+		stats:        cfg.Stats,
+		readOnly:     cfg.ReadOnly,
 	}
 }
 
@@ -60,8 +75,8 @@ func NewFomodHandler(cfg FomodHandlerConfig) *FomodHandler {
 // Downloads a mod archive, extracts the FOMOD data, and returns the parsed configuration.
 func (h *FomodHandler) AnalyzeFomod(w http.ResponseWriter, r *http.Request) {
 	client := h.clientGetter.Get()
-	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+	if client == nil && !h.readOnly {
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -84,8 +99,17 @@ func (h *FomodHandler) AnalyzeFomod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if req.FileID <= 0 {
-		WriteError(w, http.StatusBadRequest, "Valid file ID is required")
-		return
+		// Without a file, use the mod's current main file
+		if client == nil {
+			WriteError(w, http.StatusBadRequest, "Valid file ID is required in read-only mode")
+			return
+		}
+		file, err := mainFile(ctx, client, GetNexusDomain(req.Game), req.ModID)
+		if err != nil {
+			handleFomodError(w, err)
+			return
+		}
+		req.FileID = file.FileID
 	}
 
 	// Check cache first
@@ -93,10 +117,17 @@ func (h *FomodHandler) AnalyzeFomod(w http.ResponseWriter, r *http.Request) {
 	var cachedResult FomodAnalyzeResponse
 	if h.cache != nil {
 		if err := h.cache.Get(ctx, cacheKey, &cachedResult); err == nil {
+			h.stats.RecordCacheHit(stats.KindFomod)
 			cachedResult.Cached = true
 			WriteJSON(w, http.StatusOK, cachedResult)
 			return
 		}
+		h.stats.RecordCacheMiss(stats.KindFomod)
+	}
+
+	if h.readOnly {
+		writeReadOnly(w)
+		return
 	}
 
 	// Map game ID to Nexus domain name
@@ -122,28 +153,27 @@ func (h *FomodHandler) AnalyzeFomod(w http.ResponseWriter, r *http.Request) {
 	downloadResult, err := h.downloader.Download(ctx, downloadURL, nil)
 	if err != nil {
 		log.Printf("Error downloading archive: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to download mod archive")
+		handleFomodError(w, err)
 		return
 	}
 	defer h.downloader.CleanupPath(downloadResult.FilePath)
 
-	// Check if archive has FOMOD directory
-	hasFomod, err := h.extractor.HasFomod(ctx, downloadResult.FilePath)
-	if err != nil {
-		log.Printf("Error checking for FOMOD: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to inspect archive")
-		return
+	response := FomodAnalyzeResponse{
+		Game:   req.Game,
+		ModID:  req.ModID,
+		FileID: req.FileID,
+		Cached: false,
 	}
 
-	response := FomodAnalyzeResponse{
-		Game:     req.Game,
-		ModID:    req.ModID,
-		FileID:   req.FileID,
-		HasFomod: hasFomod,
-		Cached:   false,
+	// Extract and parse the FOMOD installer (nil when the archive has none)
+	fomodData, err := pipeline.FomodFromArchive(ctx, h.extractor, downloadResult.FilePath, req.Password)
+	if err != nil {
+		log.Printf("Error analyzing FOMOD: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to parse FOMOD data")
+		return
 	}
 
-	if !hasFomod {
+	if fomodData == nil {
 		// Cache the negative result
 		if h.cache != nil {
 			if err := h.cache.Set(ctx, cacheKey, response); err != nil {
@@ -154,90 +184,76 @@ func (h *FomodHandler) AnalyzeFomod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract FOMOD directory
-	extractResult, err := h.extractor.ExtractFomod(ctx, downloadResult.FilePath)
-	if err != nil {
-		log.Printf("Error extracting FOMOD: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to extract FOMOD data")
-		return
-	}
-	defer h.extractor.Cleanup(extractResult.OutputDir)
+	response.HasFomod = true
+	response.Data = fomodData
+	h.stats.RecordAnalysis(stats.KindFomod)
 
-	// Parse FOMOD XML
-	parser, err := fomod.NewParser(extractResult.OutputDir)
-	if err != nil {
-		if errors.Is(err, fomod.ErrNoFomodDir) {
-			// This shouldn't happen since we checked HasFomod, but handle gracefully
-			response.HasFomod = false
-			if h.cache != nil {
-				if err := h.cache.Set(ctx, cacheKey, response); err != nil {
-					log.Printf("Error caching result: %v", err)
-				}
-			}
-			WriteJSON(w, http.StatusOK, response)
-			return
+	// Cache the result
+	if h.cache != nil {
+		if err := h.cache.Set(ctx, cacheKey, response); err != nil {
+			log.Printf("Error caching result: %v", err)
 		}
-		log.Printf("Error creating FOMOD parser: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to parse FOMOD data")
-		return
 	}
 
-	fomodData, err := parser.Parse()
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// ValidateFomod handles POST /api/fomod/validate
+// Accepts the text of a ModuleConfig.xml as the request body, such as one read
+// through the archive extraction endpoint, and returns it with annotations
+// mapped to the lines that caused them. With strict=true it is also checked
+// against the ModConfig 5.0 schema.
+func (h *FomodHandler) ValidateFomod(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxModuleConfigSize))
 	if err != nil {
-		if errors.Is(err, fomod.ErrNoModuleConfig) {
-			// Has fomod directory but no ModuleConfig.xml
-			response.HasFomod = false
-			if h.cache != nil {
-				if err := h.cache.Set(ctx, cacheKey, response); err != nil {
-					log.Printf("Error caching result: %v", err)
-				}
-			}
-			WriteJSON(w, http.StatusOK, response)
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "ModuleConfig.xml is too large")
 			return
 		}
-		if errors.Is(err, os.ErrNotExist) {
-			// info.xml doesn't exist but ModuleConfig.xml does - this is okay
-			// The parse should have continued, so this is an unexpected error
-			log.Printf("Error parsing FOMOD: %v", err)
-			WriteError(w, http.StatusInternalServerError, "Failed to parse FOMOD data")
-			return
-		}
-		log.Printf("Error parsing FOMOD: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to parse FOMOD data")
+		WriteError(w, http.StatusBadRequest, "Failed to read request body")
 		return
 	}
-
-	response.Data = fomodData
-
-	// Cache the result
-	if h.cache != nil {
-		if err := h.cache.Set(ctx, cacheKey, response); err != nil {
-			log.Printf("Error caching result: %v", err)
-		}
+	if len(data) == 0 {
+		WriteError(w, http.StatusBadRequest, "ModuleConfig.xml is required")
+		return
 	}
 
-	WriteJSON(w, http.StatusOK, response)
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+	if strict {
+		WriteJSON(w, http.StatusOK, fomod.ValidateStrict(data))
+		return
+	}
+	WriteJSON(w, http.StatusOK, fomod.Validate(data))
 }
 
 // handleFomodError maps errors to HTTP responses for FOMOD analysis.
 func handleFomodError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, nexus.ErrNotFound):
-		WriteError(w, http.StatusNotFound, "Mod file not found")
+		writeErrorFor(w, http.StatusNotFound, err, "Mod file not found")
 	case errors.Is(err, nexus.ErrUnauthorized):
-		WriteError(w, http.StatusUnauthorized, "Invalid or missing Nexus API key")
+		writeErrorFor(w, http.StatusUnauthorized, err, "Invalid or missing Nexus API key")
 	case errors.Is(err, nexus.ErrPremiumOnly):
-		WriteError(w, http.StatusForbidden, "This feature requires a Nexus Mods Premium account")
+		writeErrorFor(w, http.StatusForbidden, err, "This feature requires a Nexus Mods Premium account")
 	case errors.Is(err, nexus.ErrRateLimited):
-		WriteError(w, http.StatusTooManyRequests, "Nexus API rate limit exceeded, please try again later")
+		writeErrorFor(w, http.StatusTooManyRequests, err, "Nexus API rate limit exceeded, please try again later")
 	case errors.Is(err, nexus.ErrNoAPIKey):
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured")
+		writeErrorFor(w, http.StatusServiceUnavailable, err, "Nexus API key not configured")
+	case errors.Is(err, errNoMainFile):
+		WriteError(w, http.StatusNotFound, "Mod has no current files on Nexus")
 	case errors.Is(err, archive.ErrNoURL):
-		WriteError(w, http.StatusBadRequest, "Download URL is required")
+		writeErrorFor(w, http.StatusBadRequest, err, "Download URL is required")
 	case errors.Is(err, archive.ErrDownloadFailed):
-		WriteError(w, http.StatusBadGateway, "Failed to download mod archive")
+		writeErrorFor(w, http.StatusBadGateway, err, "Failed to download mod archive")
 	case errors.Is(err, archive.ErrFileTooLarge):
-		WriteError(w, http.StatusRequestEntityTooLarge, "Mod archive is too large")
+		writeErrorFor(w, http.StatusRequestEntityTooLarge, err, "Mod archive is too large")
+	case errors.Is(err, archive.ErrUnexpectedContent), errors.Is(err, archive.ErrInvalidResponse):
+		writeErrorFor(w, http.StatusBadGateway, err, "The download server did not return the mod archive")
+	case errors.Is(err, archive.ErrInfected):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "The mod archive was flagged by the virus scanner and quarantined")
+	case errors.Is(err, archive.ErrScanFailed):
+		writeErrorFor(w, http.StatusServiceUnavailable, err, "The mod archive could not be scanned for viruses")
 	default:
 		log.Printf("Error: FOMOD analysis failed: %v", err)
 		WriteError(w, http.StatusInternalServerError, "Failed to analyze FOMOD")
@@ -85,7 +85,7 @@ func TestGetNexusDomain(t *testing.T) {
 		{"skyrim", "skyrimspecialedition"},
 		{"stardew", "stardewvalley"},
 		{"cyberpunk", "cyberpunk2077"},
-		{"unknown", "unknown"},                      // Falls back to input
+		{"unknown", "unknown"},                           // Falls back to input
 		{"skyrimspecialedition", "skyrimspecialedition"}, // Already a domain name
 	}
 
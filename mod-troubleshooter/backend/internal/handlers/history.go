@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mod-troubleshooter/backend/internal/bundle"
+	"github.com/mod-troubleshooter/backend/internal/history"
+)
+
+// maxBundleUploadSize limits the size of imported bundles.
+const maxBundleUploadSize = 512 * 1024 * 1024 // 512MB
+
+// HistoryHandler handles stored analysis history and bundle imports.
+type HistoryHandler struct {
+	store *history.Store
+}
+
+// NewHistoryHandler creates a new history handler.
+func NewHistoryHandler(store *history.Store) *HistoryHandler {
+	return &HistoryHandler{store: store}
+}
+
+// ImportBundle handles POST /api/import/bundle
+// Accepts an exported bundle zip as the request body and stores it in the history.
+// Works without Nexus access so curators can inspect bundles shared by users.
+func (h *HistoryHandler) ImportBundle(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleUploadSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "Bundle is too large")
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	b, err := bundle.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		switch {
+		case errors.Is(err, bundle.ErrUnsupportedVersion):
+			WriteError(w, http.StatusUnprocessableEntity, "Bundle was created by an unsupported version")
+		case errors.Is(err, bundle.ErrMissingMetadata), errors.Is(err, bundle.ErrNoResults):
+			WriteError(w, http.StatusUnprocessableEntity, "Bundle is incomplete")
+		default:
+			WriteError(w, http.StatusBadRequest, "Invalid bundle file")
+		}
+		return
+	}
+
+	entry, err := h.store.Add(r.Context(), history.SourceImport, b)
+	if err != nil {
+		log.Printf("Error storing imported bundle: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to store bundle")
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, entry)
+}
+
+// ListHistory handles GET /api/history
+// Returns summaries of all stored analyses.
+func (h *HistoryHandler) ListHistory(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.store.List(r.Context())
+	if err != nil {
+		log.Printf("Error listing history: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to list history")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, entries)
+}
+
+// GetHistoryEntry handles GET /api/history/{id}
+// Returns a stored analysis with its full results.
+func (h *HistoryHandler) GetHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	rec, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, history.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "History entry not found")
+			return
+		}
+		log.Printf("Error fetching history entry %d: %v", id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch history entry")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, rec)
+}
+
+// ExportHistoryBundle handles GET /api/history/{id}/bundle
+// Returns a stored analysis as a bundle zip, with the notes attached to its findings.
+// Pass ?redact=true to strip API key traces and local paths, and
+// ?redactNotes=true to leave the notes out, before sharing it publicly.
+func (h *HistoryHandler) ExportHistoryBundle(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	rec, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, history.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "History entry not found")
+			return
+		}
+		log.Printf("Error fetching history entry %d: %v", id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch history entry")
+		return
+	}
+
+	// Notes that came with an imported bundle are kept ahead of local ones
+	b := rec.Bundle
+	for _, n := range rec.Notes {
+		b.Notes = append(b.Notes, bundle.Note{
+			Target:    string(n.Target),
+			Subject:   n.Subject,
+			Author:    n.Author,
+			Text:      n.Text,
+			CreatedAt: n.CreatedAt,
+		})
+	}
+
+	redactNotes, _ := strconv.ParseBool(r.URL.Query().Get("redactNotes"))
+	if redactParam(r) || redactNotes {
+		if err := b.Redact(bundle.Redaction{Notes: redactNotes}); err != nil {
+			log.Printf("Error redacting history entry %d: %v", id, err)
+			WriteError(w, http.StatusInternalServerError, "Failed to build bundle")
+			return
+		}
+	}
+
+	writeBundle(w, b, fmt.Sprintf("%s-rev%d-bundle.zip", rec.Slug, rec.Revision))
+}
+
+// DeleteHistoryEntry handles DELETE /api/history/{id}
+// Removes a stored analysis.
+func (h *HistoryHandler) DeleteHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	if err := h.store.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, history.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "History entry not found")
+			return
+		}
+		log.Printf("Error deleting history entry %d: %v", id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to delete history entry")
+		return
+	}
+
+	WriteSuccess(w, "History entry deleted")
+}
+
+// AddNoteRequest is the request body for attaching a note to a finding.
+type AddNoteRequest struct {
+	// Author names who wrote the note (optional).
+	Author string `json:"author"`
+	// Text is the note content.
+	Text string `json:"text"`
+}
+
+// AddConflictNote handles POST /api/history/{id}/conflicts/{index}/notes
+// Attaches a note to a file conflict of a stored analysis.
+func (h *HistoryHandler) AddConflictNote(w http.ResponseWriter, r *http.Request) {
+	h.addNote(w, r, history.NoteConflict)
+}
+
+// AddIssueNote handles POST /api/history/{id}/issues/{index}/notes
+// Attaches a note to a load order issue of a stored analysis.
+func (h *HistoryHandler) AddIssueNote(w http.ResponseWriter, r *http.Request) {
+	h.addNote(w, r, history.NoteIssue)
+}
+
+// addNote attaches a note to the finding identified by the request path.
+// Findings are identified by their position in the stored results.
+func (h *HistoryHandler) addNote(w http.ResponseWriter, r *http.Request, target history.NoteTarget) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid finding index")
+		return
+	}
+
+	var req AddNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	note, err := h.store.AddNote(r.Context(), id, target, index, req.Author, req.Text)
+	if err != nil {
+		switch {
+		case errors.Is(err, history.ErrEmptyNote):
+			WriteError(w, http.StatusBadRequest, "Note text is required")
+		case errors.Is(err, history.ErrNotFound):
+			WriteError(w, http.StatusNotFound, "History entry not found")
+		case errors.Is(err, history.ErrFindingNotFound):
+			WriteError(w, http.StatusNotFound, "Finding not found")
+		default:
+			log.Printf("Error adding note to history entry %d: %v", id, err)
+			WriteError(w, http.StatusInternalServerError, "Failed to add note")
+		}
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, note)
+}
+
+// ListNotes handles GET /api/history/{id}/notes
+// Returns the notes attached to a stored analysis.
+func (h *HistoryHandler) ListNotes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	rec, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, history.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "History entry not found")
+			return
+		}
+		log.Printf("Error fetching notes for history entry %d: %v", id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch notes")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, rec.Notes)
+}
+
+// DeleteNote handles DELETE /api/history/{id}/notes/{noteId}
+// Removes a note from a stored analysis.
+func (h *HistoryHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	noteID, err := strconv.ParseInt(r.PathValue("noteId"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	if err := h.store.DeleteNote(r.Context(), id, noteID); err != nil {
+		if errors.Is(err, history.ErrNoteNotFound) {
+			WriteError(w, http.StatusNotFound, "Note not found")
+			return
+		}
+		log.Printf("Error deleting note %d: %v", noteID, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to delete note")
+		return
+	}
+
+	WriteSuccess(w, "Note deleted")
+}
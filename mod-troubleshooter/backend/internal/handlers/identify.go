@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+)
+
+// maxMD5Lookups limits how many distinct hashes one request may look up,
+// since each lookup is a separate Nexus API call.
+const maxMD5Lookups = 200
+
+// IdentifyRequest is the request body for identifying local files by MD5.
+type IdentifyRequest struct {
+	// Game is the game ID or Nexus domain to search.
+	Game string `json:"game"`
+	// Files are the local files to identify.
+	Files []FileHash `json:"files"`
+}
+
+// FileHash is a local file and its MD5 hash.
+type FileHash struct {
+	// Path is the file's local path, used only for display (optional).
+	Path string `json:"path,omitempty"`
+	// MD5 is the hex-encoded MD5 hash of the file contents.
+	MD5 string `json:"md5"`
+}
+
+// FileIdentification is the lookup result for a single local file.
+type FileIdentification struct {
+	Path    string                  `json:"path,omitempty"`
+	MD5     string                  `json:"md5"`
+	Matches []nexus.MD5SearchResult `json:"matches"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// IdentifyResponse is the response from MD5 identification.
+type IdentifyResponse struct {
+	Files        []FileIdentification `json:"files"`
+	Identified   int                  `json:"identified"`
+	Unidentified int                  `json:"unidentified"`
+}
+
+// IdentifyHandler identifies local files using the Nexus MD5 search.
+type IdentifyHandler struct {
+	clientGetter NexusClientGetter
+}
+
+// NewIdentifyHandler creates a new identify handler with a dynamic client getter.
+func NewIdentifyHandler(getter NexusClientGetter) *IdentifyHandler {
+	return &IdentifyHandler{clientGetter: getter}
+}
+
+// IdentifyByMD5 handles POST /api/identify/md5
+// Looks up which Nexus mod and file each hash came from.
+func (h *IdentifyHandler) IdentifyByMD5(w http.ResponseWriter, r *http.Request) {
+	client := h.clientGetter.Get()
+	if client == nil {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	var req IdentifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Game == "" {
+		WriteError(w, http.StatusBadRequest, "Game is required")
+		return
+	}
+
+	if len(req.Files) == 0 {
+		WriteError(w, http.StatusBadRequest, "At least one file hash is required")
+		return
+	}
+
+	// Validate and normalize hashes
+	unique := make(map[string]bool)
+	for i := range req.Files {
+		hash := strings.ToLower(strings.TrimSpace(req.Files[i].MD5))
+		if !isMD5(hash) {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid MD5 hash for file at index %d", i))
+			return
+		}
+		req.Files[i].MD5 = hash
+		unique[hash] = true
+	}
+
+	if len(unique) > maxMD5Lookups {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("Too many distinct hashes (maximum %d per request)", maxMD5Lookups))
+		return
+	}
+
+	gameDomain := GetNexusDomain(req.Game)
+
+	// Look up each distinct hash once
+	matches := make(map[string][]nexus.MD5SearchResult, len(unique))
+	lookupErrors := make(map[string]string)
+	for _, file := range req.Files {
+		if _, done := matches[file.MD5]; done {
+			continue
+		}
+		if _, failed := lookupErrors[file.MD5]; failed {
+			continue
+		}
+
+		results, err := client.SearchMD5(ctx, gameDomain, file.MD5)
+		if err != nil {
+			// Errors that affect every lookup abort the request
+			if errors.Is(err, nexus.ErrUnauthorized) || errors.Is(err, nexus.ErrRateLimited) || ctx.Err() != nil {
+				handleNexusError(w, err, "search md5")
+				return
+			}
+			log.Printf("Warning: md5 search failed for %s: %v", file.MD5, err)
+			lookupErrors[file.MD5] = err.Error()
+			continue
+		}
+		matches[file.MD5] = results
+	}
+
+	response := IdentifyResponse{Files: make([]FileIdentification, 0, len(req.Files))}
+	for _, file := range req.Files {
+		id := FileIdentification{
+			Path:    file.Path,
+			MD5:     file.MD5,
+			Matches: matches[file.MD5],
+			Error:   lookupErrors[file.MD5],
+		}
+		if id.Matches == nil {
+			id.Matches = []nexus.MD5SearchResult{}
+		}
+		if len(id.Matches) > 0 {
+			response.Identified++
+		} else {
+			response.Unidentified++
+		}
+		response.Files = append(response.Files, id)
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// isMD5 reports whether s is a lowercase hex-encoded MD5 hash.
+func isMD5(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+// queueRetryAfter is the Retry-After, in seconds, sent when the job queue is full.
+const queueRetryAfter = 30
+
+// eventsHeartbeat is how often an idle job event stream sends a comment.
+const eventsHeartbeat = 15 * time.Second
+
+// maxJobRequestBytes bounds the body of a request to start a job.
+const maxJobRequestBytes = 64 << 10
+
+// Errors a JobStarter returns to refuse a job for the server's state.
+var (
+	errJobNoAPIKey = errors.New("no Nexus API key configured")
+	errJobReadOnly = errors.New("server is read-only")
+)
+
+// JobRequest is the body of a request to start a job.
+type JobRequest struct {
+	// Kind names the analysis to run, such as "collection-conflicts".
+	Kind string `json:"kind"`
+	// Slug and Revision identify the collection revision to analyze.
+	Slug     string `json:"slug"`
+	Revision int    `json:"revision"`
+	// Options are the settings the analysis's endpoint takes as query
+	// parameters, such as includeHashes.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// JobStarter checks a request to start a job of one kind and returns the
+// work to queue. It refuses the job with errJobNoAPIKey, errJobReadOnly or
+// a *jobError.
+type JobStarter func(req JobRequest) (jobs.Func, error)
+
+// JobHandler starts analyses in the job queue and reports on them.
+type JobHandler struct {
+	queue *jobs.Queue
+	kinds map[string]JobStarter
+}
+
+// NewJobHandler creates a new job handler.
+func NewJobHandler(queue *jobs.Queue) *JobHandler {
+	return &JobHandler{queue: queue, kinds: make(map[string]JobStarter)}
+}
+
+// Register makes a kind of job available to CreateJob.
+func (h *JobHandler) Register(kind string, start JobStarter) {
+	h.kinds[kind] = start
+}
+
+// CreateJob handles POST /api/jobs
+// Starts an analysis of a collection revision in the background and
+// returns 202 Accepted with the job to poll at GET /api/jobs/{id}. These
+// are long scans, so they run at low priority, after interactive requests.
+// When the queue, or the client's share of it, is full, 503 is returned
+// with a Retry-After header.
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobRequestBytes)).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	start, ok := h.kinds[req.Kind]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, "Unknown job kind")
+		return
+	}
+	if req.Slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+	if req.Revision <= 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+
+	fn, err := start(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errJobNoAPIKey):
+			writeNoAPIKey(w)
+		case errors.Is(err, errJobReadOnly):
+			writeReadOnly(w)
+		default:
+			writeJobError(w, err, "start job")
+		}
+		return
+	}
+
+	job, err := h.queue.SubmitWith(req.Kind, jobs.Options{Priority: jobs.PriorityLow, Owner: clientAddr(r)}, fn)
+	if err != nil {
+		writeQueueError(w, err)
+		return
+	}
+	writeJobAccepted(w, job)
+}
+
+// GetJob handles GET /api/jobs/{id}
+// Returns the status of a job: its progress while it runs, and its result
+// once it has succeeded.
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.queue.Get(r.PathValue("id"))
+	if err != nil {
+		writeJobLookupError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, job)
+}
+
+// JobEvents handles GET /api/jobs/{id}/events
+// Streams a job as Server-Sent Events, so clients can show its progress
+// live instead of polling GetJob. A "progress" event carries the job each
+// time its status or progress changes, and a final "done" event carries
+// the finished job, with its result or error, before the stream ends.
+func (h *JobHandler) JobEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	job, changed, err := h.queue.Watch(id)
+	if err != nil {
+		writeJobLookupError(w, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream lasts as long as the job, well past the server's write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		event := "progress"
+		if job.Status.Done() {
+			event = "done"
+		}
+		if err := writeEvent(w, event, job); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil || changed == nil {
+			return
+		}
+
+		if !waitForChange(r.Context(), w, rc, changed, heartbeat.C) {
+			return
+		}
+		if job, changed, err = h.queue.Watch(id); err != nil {
+			// The job was dropped while the client watched
+			return
+		}
+	}
+}
+
+// waitForChange waits for a watched job to change, sending a comment on
+// every heartbeat so proxies and clients keep the stream open. It returns
+// false when the client has gone.
+func waitForChange(ctx context.Context, w http.ResponseWriter, rc *http.ResponseController, changed <-chan struct{}, heartbeat <-chan time.Time) bool {
+	for {
+		select {
+		case <-changed:
+			return true
+		case <-heartbeat:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return false
+			}
+			if err := rc.Flush(); err != nil {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event whose data is v as JSON.
+func writeEvent(w io.Writer, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// writeJobLookupError writes the response for a job that could not be looked up.
+func writeJobLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, jobs.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	log.Printf("Error fetching job: %v", err)
+	WriteError(w, http.StatusInternalServerError, "Failed to fetch job")
+}
+
+// wantsAsync reports whether a request asks to be answered with a job
+// instead of waiting for the result.
+func wantsAsync(r *http.Request) bool {
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Prefer"), "respond-async")
+}
+
+// withJobProgress returns a context that reports the progress of the
+// analysis run with it as the progress of its job.
+func withJobProgress(ctx context.Context) context.Context {
+	return pipeline.WithProgress(ctx, func(p pipeline.Progress) {
+		jobs.SetProgress(ctx, jobs.Progress{
+			Stage:      p.Stage.String(),
+			Done:       p.ModsDone,
+			Total:      p.ModsTotal,
+			Current:    p.CurrentMod,
+			Step:       p.Analyzer,
+			Bytes:      p.BytesDone,
+			BytesTotal: p.BytesTotal,
+		})
+	})
+}
+
+// writeJobAccepted answers a request handed to the job queue with the job
+// and where to poll it.
+func writeJobAccepted(w http.ResponseWriter, job jobs.Job) {
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	WriteJSON(w, http.StatusAccepted, job)
+}
+
+// writeQueueError writes the response for a job the queue did not take.
+func writeQueueError(w http.ResponseWriter, err error) {
+	if errors.Is(err, jobs.ErrQueueFull) {
+		w.Header().Set("Retry-After", strconv.Itoa(queueRetryAfter))
+		WriteError(w, http.StatusServiceUnavailable, "Too many analyses are queued, please try again later")
+		return
+	}
+	WriteError(w, http.StatusServiceUnavailable, "The server is shutting down")
+}
+
+// jobFailure turns the error of a background analysis into the message its
+// job reports. The full error is logged, as writeJobError would.
+func jobFailure(err error, action string) error {
+	var jobErr *jobError
+	if errors.As(err, &jobErr) {
+		log.Printf("Error during %s: %v", action, jobErr.err)
+		return errors.New(jobErr.message)
+	}
+
+	log.Printf("Error during %s: %v", action, err)
+	switch {
+	case errors.Is(err, nexus.ErrNotFound):
+		return errors.New("Resource not found")
+	case errors.Is(err, nexus.ErrUnauthorized):
+		return errors.New("Invalid or missing Nexus API key")
+	case errors.Is(err, nexus.ErrPremiumOnly):
+		return errors.New("This feature requires a Nexus Mods Premium account")
+	case errors.Is(err, nexus.ErrRateLimited):
+		return errors.New("Nexus API rate limit exceeded, please try again later")
+	case errors.Is(err, nexus.ErrDegraded):
+		return errors.New("Nexus is degraded, please try again later")
+	}
+	return errors.New("Failed to " + action)
+}
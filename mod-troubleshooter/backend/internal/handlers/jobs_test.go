@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+)
+
+func TestJobHandler_CreateJob(t *testing.T) {
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+
+	handler := NewJobHandler(queue)
+	var got JobRequest
+	handler.Register("echo", func(req JobRequest) (jobs.Func, error) {
+		got = req
+		return func(ctx context.Context) (interface{}, error) {
+			return req.Slug, nil
+		}, nil
+	})
+	handler.Register("offline", func(req JobRequest) (jobs.Func, error) {
+		return nil, errJobNoAPIKey
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/jobs", handler.CreateJob)
+	mux.HandleFunc("GET /api/jobs/{id}", handler.GetJob)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "malformed", body: `{"kind":`, status: http.StatusBadRequest},
+		{name: "unknown kind", body: `{"kind":"nope","slug":"abc","revision":1}`, status: http.StatusBadRequest},
+		{name: "missing slug", body: `{"kind":"echo","revision":1}`, status: http.StatusBadRequest},
+		{name: "invalid revision", body: `{"kind":"echo","slug":"abc"}`, status: http.StatusBadRequest},
+		{name: "refused", body: `{"kind":"offline","slug":"abc","revision":1}`, status: http.StatusServiceUnavailable},
+		{name: "accepted", body: `{"kind":"echo","slug":"abc","revision":2,"options":{"includeHashes":"true"}}`, status: http.StatusAccepted},
+	}
+	var accepted *httptest.ResponseRecorder
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusAccepted {
+				accepted = w
+			}
+		})
+	}
+	if accepted == nil {
+		t.Fatal("no job was accepted")
+	}
+	if got.Revision != 2 || got.Options["includeHashes"] != "true" {
+		t.Errorf("unexpected request passed to the starter %+v", got)
+	}
+
+	loc := accepted.Header().Get("Location")
+	if !strings.HasPrefix(loc, "/api/jobs/") {
+		t.Fatalf("expected a job location, got %q", loc)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, loc, nil))
+		var resp struct {
+			Data jobs.Job `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode job: %v", err)
+		}
+		job := resp.Data
+		if job.Status.Done() {
+			if job.Status != jobs.StatusSucceeded || job.Kind != "echo" || job.Result != "abc" {
+				t.Errorf("unexpected finished job %+v", job)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobHandler_JobEvents(t *testing.T) {
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+
+	reported := make(chan struct{})
+	release := make(chan struct{})
+	job, err := queue.Submit("test", func(ctx context.Context) (interface{}, error) {
+		jobs.SetProgress(ctx, jobs.Progress{Stage: "downloading", Done: 0, Total: 1, Current: "Armors", Bytes: 10, BytesTotal: 20})
+		close(reported)
+		<-release
+		return "result", nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-reported
+
+	handler := NewJobHandler(queue)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/jobs/{id}/events", handler.JobEvents)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/jobs/missing/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown job, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/api/jobs/" + job.ID + "/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected an event stream, got %q", ct)
+	}
+
+	type event struct {
+		name string
+		job  jobs.Job
+	}
+	events := make(chan event)
+	go func() {
+		defer close(events)
+		var name string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				var job jobs.Job
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &job); err != nil {
+					t.Errorf("failed to decode event data: %v", err)
+				}
+				events <- event{name: name, job: job}
+			}
+		}
+	}()
+
+	first := <-events
+	if first.name != "progress" || first.job.Progress == nil || first.job.Progress.Bytes != 10 {
+		t.Errorf("unexpected first event %+v", first)
+	}
+
+	close(release)
+	var last event
+	for e := range events {
+		last = e
+	}
+	if last.name != "done" || last.job.Status != jobs.StatusSucceeded || last.job.Result != "result" {
+		t.Errorf("unexpected last event %+v", last)
+	}
+}
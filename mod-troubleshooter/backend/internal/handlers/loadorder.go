@@ -5,19 +5,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/mod-troubleshooter/backend/internal/archive"
 	"github.com/mod-troubleshooter/backend/internal/cache"
+	"github.com/mod-troubleshooter/backend/internal/fingerprint"
+	"github.com/mod-troubleshooter/backend/internal/flight"
 	"github.com/mod-troubleshooter/backend/internal/loadorder"
 	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
 	"github.com/mod-troubleshooter/backend/internal/plugin"
+	"github.com/mod-troubleshooter/backend/internal/stats"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
 )
 
 // LoadOrderAnalyzeRequest is the request body for load order analysis.
@@ -36,14 +39,28 @@ type PluginReference struct {
 	Game string `json:"game,omitempty"`
 	// ModID is the mod ID on Nexus (optional).
 	ModID int `json:"modId,omitempty"`
-	// FileID is the file ID on Nexus (optional).
+	// FileID is the file ID on Nexus (optional). With only ModID, the mod's
+	// current main file is used.
 	FileID int `json:"fileId,omitempty"`
+	// Password opens the downloaded archive if it is encrypted (optional).
+	Password string `json:"password,omitempty"`
 }
 
 // LoadOrderAnalyzeResponse is the response from load order analysis.
 type LoadOrderAnalyzeResponse struct {
 	*loadorder.AnalysisResult
 	Cached bool `json:"cached"`
+	// Fingerprint identifies the result; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+	// SuppressedFindings is the number of findings hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings"`
+	// Suppressed lists the hidden findings when showSuppressed=true is requested.
+	Suppressed *SuppressedFindings `json:"suppressed,omitempty"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+	// ModNotes are the collection curator's notes on its mods, such as
+	// which installer options to choose.
+	ModNotes []pipeline.ModNote `json:"modNotes,omitempty"`
 }
 
 // LoadOrderHandler handles load order analysis HTTP requests.
@@ -52,8 +69,18 @@ type LoadOrderHandler struct {
 	downloader   *archive.Downloader
 	extractor    *archive.Extractor
 	cache        *cache.Cache
-	analyzer     *loadorder.Analyzer
+	stats        *stats.Collector
+	sessions     *pipeline.Sessions
+	suppressions *suppress.Store
+	readOnly     bool
+	sevenZip     *archive.SevenZip
+	sandbox      pipeline.ArchiveReader
+	limiter      *pipeline.Limiter
+	stage        *pipeline.LoadOrderStage
 	parser       *plugin.Parser
+
+	// jobs shares in-flight collection analyses between identical requests
+	jobs flight.Group[LoadOrderAnalyzeResponse]
 }
 
 // LoadOrderHandlerConfig holds configuration for the LoadOrderHandler.
@@ -62,6 +89,19 @@ type LoadOrderHandlerConfig struct {
 	Downloader   *archive.Downloader
 	Extractor    *archive.Extractor
 	Cache        *cache.Cache
+	Stats        *stats.Collector
+	// Sessions shares downloads between analyses of the same collection revision (optional).
+	Sessions *pipeline.Sessions
+	// Suppressions hides findings matched by active suppressions (optional).
+	Suppressions *suppress.Store
+	// ReadOnly serves cached results only, rejecting requests that would download from Nexus.
+	ReadOnly bool
+	// SevenZip lists archives the built-in extractor cannot read (optional).
+	SevenZip *archive.SevenZip
+	// Sandbox reads archives in worker processes (optional).
+	Sandbox pipeline.ArchiveReader
+	// Limiter bounds gathering work across analyses (optional).
+	Limiter *pipeline.Limiter
 }
 
 // NewLoadOrderHandler creates a new load order handler.
@@ -71,7 +111,14 @@ func NewLoadOrderHandler(cfg LoadOrderHandlerConfig) *LoadOrderHandler {
 		downloader:   cfg.Downloader,
 		extractor:    cfg.Extractor,
 		cache:        cfg.Cache,
-		analyzer:     loadorder.NewAnalyzer(),
+		stats:        cfg.Stats,
+		sessions:     cfg.Sessions,
+		suppressions: cfg.Suppressions,
+		readOnly:     cfg.ReadOnly,
+		sevenZip:     cfg.SevenZip,
+		sandbox:      cfg.Sandbox,
+		limiter:      cfg.Limiter,
+		stage:        pipeline.NewLoadOrderStage(),
 		parser:       plugin.NewParser(),
 	}
 }
@@ -95,6 +142,7 @@ func (h *LoadOrderHandler) AnalyzeLoadOrder(w http.ResponseWriter, r *http.Reque
 
 	// Build list of plugin files for analysis
 	pluginFiles := make([]loadorder.PluginFile, 0, len(req.Plugins))
+	var game string
 
 	for _, ref := range req.Plugins {
 		if ref.Filename == "" {
@@ -105,9 +153,13 @@ func (h *LoadOrderHandler) AnalyzeLoadOrder(w http.ResponseWriter, r *http.Reque
 		pf := loadorder.PluginFile{
 			Filename: ref.Filename,
 		}
+		if game == "" {
+			game = ref.Game
+		}
 
 		// If Nexus info is provided, try to fetch and parse the plugin
-		if ref.Game != "" && ref.ModID > 0 && ref.FileID > 0 {
+		// (read-only mode analyzes by filename only)
+		if ref.Game != "" && ref.ModID > 0 && !h.readOnly {
 			header, err := h.fetchAndParsePlugin(ctx, ref)
 			if err != nil {
 				// Log the error but continue with just the filename
@@ -121,7 +173,8 @@ func (h *LoadOrderHandler) AnalyzeLoadOrder(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Perform analysis
-	result, err := h.analyzer.Analyze(ctx, pluginFiles)
+	in := &pipeline.Inputs{Mods: []pipeline.Mod{{Plugins: pluginFiles}}, Game: game}
+	result, err := h.stage.AnalyzeLoadOrder(ctx, in)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			WriteError(w, http.StatusRequestTimeout, "Request cancelled")
@@ -132,20 +185,24 @@ func (h *LoadOrderHandler) AnalyzeLoadOrder(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	h.stats.RecordAnalysis(stats.KindLoadOrder)
+
 	response := LoadOrderAnalyzeResponse{
 		AnalysisResult: result,
 		Cached:         false,
+		Fingerprint:    fingerprint.Of(result),
 	}
+	response.applySuppressions(activeSuppressions(ctx, h.suppressions, ""), showSuppressed(r))
 
-	WriteJSON(w, http.StatusOK, response)
+	WriteResult(w, r, http.StatusOK, response)
 }
 
 // AnalyzeCollectionLoadOrder handles GET /api/collections/{slug}/revisions/{revision}/loadorder
 // Analyzes the load order of all plugins in a collection revision.
 func (h *LoadOrderHandler) AnalyzeCollectionLoadOrder(w http.ResponseWriter, r *http.Request) {
 	client := h.clientGetter.Get()
-	if client == nil {
-		WriteError(w, http.StatusServiceUnavailable, "Nexus API key not configured. Please configure it in Settings.")
+	if client == nil && !h.readOnly {
+		writeNoAPIKey(w)
 		return
 	}
 
@@ -170,65 +227,100 @@ func (h *LoadOrderHandler) AnalyzeCollectionLoadOrder(w http.ResponseWriter, r *
 	}
 
 	// Check cache
-	cacheKey := fmt.Sprintf("loadorder:%s:%d", slug, revision)
+	cacheKey := cache.LoadOrderKey(slug, revision)
 	var cachedResult LoadOrderAnalyzeResponse
 	if h.cache != nil {
 		if err := h.cache.Get(ctx, cacheKey, &cachedResult); err == nil {
+			h.stats.RecordCacheHit(stats.KindLoadOrder)
 			cachedResult.Cached = true
-			WriteJSON(w, http.StatusOK, cachedResult)
+			cachedResult.applySuppressions(activeSuppressions(ctx, h.suppressions, slug), showSuppressed(r))
+			WriteResult(w, r, http.StatusOK, cachedResult)
 			return
 		}
+		h.stats.RecordCacheMiss(stats.KindLoadOrder)
 	}
 
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	// Concurrent requests for the same revision share one analysis
+	response, err := h.jobs.Do(ctx, cacheKey, func(ctx context.Context) (LoadOrderAnalyzeResponse, error) {
+		return h.analyzeCollection(ctx, client, slug, revision)
+	})
+	if err != nil {
+		writeJobError(w, err, "analyze collection load order")
+		return
+	}
+	response.applySuppressions(activeSuppressions(ctx, h.suppressions, slug), showSuppressed(r))
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// analyzeCollection downloads a collection revision and analyzes its load order,
+// caching the result.
+func (h *LoadOrderHandler) analyzeCollection(ctx context.Context, client *nexus.Client, slug string, revision int) (LoadOrderAnalyzeResponse, error) {
 	// Get collection revision mods
 	revisionDetails, err := client.GetCollectionRevisionMods(ctx, slug, revision)
 	if err != nil {
-		handleNexusError(w, err, "fetch collection revision")
-		return
+		return LoadOrderAnalyzeResponse{}, fmt.Errorf("fetch collection revision: %w", err)
 	}
 
 	// Get the collection to determine the game
 	collection, err := client.GetCollection(ctx, slug)
 	if err != nil {
-		handleNexusError(w, err, "fetch collection")
-		return
+		return LoadOrderAnalyzeResponse{}, fmt.Errorf("fetch collection: %w", err)
 	}
 
 	gameDomain := collection.Game.DomainName
 
-	// Extract plugin files from the collection mods
-	pluginFiles, err := h.extractPluginsFromCollection(ctx, client, gameDomain, revisionDetails)
+	// Download each mod file once, reusing this revision's session, and parse its plugins
+	session := h.sessions.Acquire(slug, revision)
+	defer session.Done()
+
+	gatherer := pipeline.NewGatherer(pipeline.GathererConfig{
+		Fetcher:   session.Fetcher(&nexusFetcher{client: client, downloader: h.downloader}),
+		Extractor: h.extractor,
+		SevenZip:  h.sevenZip,
+		Sandbox:   h.sandbox,
+		Limiter:   h.limiter,
+	})
+	sources, err := revisionSources(ctx, client, gameDomain, revisionDetails)
 	if err != nil {
-		if errors.Is(err, nexus.ErrPremiumOnly) {
-			WriteError(w, http.StatusForbidden, "This feature requires a Nexus Mods Premium account")
-			return
-		}
-		log.Printf("Error extracting plugins: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to extract plugin information")
-		return
+		return LoadOrderAnalyzeResponse{}, err
 	}
+	in, release, err := gatherer.Gather(ctx, sources, h.stage.Inputs())
+	if err != nil {
+		return LoadOrderAnalyzeResponse{}, gatherError(err, "Failed to extract plugin information")
+	}
+
+	defer release()
 
 	// Perform analysis
-	result, err := h.analyzer.Analyze(ctx, pluginFiles)
+	result, err := h.stage.AnalyzeLoadOrder(ctx, in)
 	if err != nil {
-		log.Printf("Error analyzing load order: %v", err)
-		WriteError(w, http.StatusInternalServerError, "Failed to analyze load order")
-		return
+		return LoadOrderAnalyzeResponse{}, &jobError{status: http.StatusInternalServerError, message: "Failed to analyze load order", err: err}
 	}
 
+	h.stats.RecordAnalysis(stats.KindLoadOrder)
+
 	response := LoadOrderAnalyzeResponse{
 		AnalysisResult: result,
 		Cached:         false,
+		Fingerprint:    fingerprint.Of(result),
+		Warnings:       in.Warnings(),
+		ModNotes:       in.Notes(),
 	}
 
 	// Cache the result
 	if h.cache != nil {
-		if err := h.cache.Set(ctx, cacheKey, response); err != nil {
+		if err := h.cache.Set(ctx, cache.LoadOrderKey(slug, revision), response); err != nil {
 			log.Printf("Error caching result: %v", err)
 		}
 	}
 
-	WriteJSON(w, http.StatusOK, response)
+	return response, nil
 }
 
 // fetchAndParsePlugin downloads a plugin and parses its header.
@@ -238,6 +330,14 @@ func (h *LoadOrderHandler) fetchAndParsePlugin(ctx context.Context, ref PluginRe
 		return nil, errors.New("nexus client not available")
 	}
 
+	if ref.FileID <= 0 {
+		file, err := mainFile(ctx, client, ref.Game, ref.ModID)
+		if err != nil {
+			return nil, err
+		}
+		ref.FileID = file.FileID
+	}
+
 	// Get download links
 	links, err := client.GetModFileDownloadLinks(ctx, ref.Game, ref.ModID, ref.FileID)
 	if err != nil {
@@ -256,8 +356,8 @@ func (h *LoadOrderHandler) fetchAndParsePlugin(ctx context.Context, ref PluginRe
 	defer h.downloader.CleanupPath(downloadResult.FilePath)
 
 	// If it's an archive, try to extract the plugin
-	if isArchive(downloadResult.FilePath) {
-		return h.extractAndParsePluginFromArchive(ctx, downloadResult.FilePath, ref.Filename)
+	if archive.IsArchive(downloadResult.FilePath) {
+		return h.extractAndParsePluginFromArchive(ctx, downloadResult.FilePath, ref.Password, ref.Filename)
 	}
 
 	// If it's a direct plugin file, parse it
@@ -269,9 +369,10 @@ func (h *LoadOrderHandler) fetchAndParsePlugin(ctx context.Context, ref PluginRe
 }
 
 // extractAndParsePluginFromArchive extracts a specific plugin from an archive and parses it.
-func (h *LoadOrderHandler) extractAndParsePluginFromArchive(ctx context.Context, archivePath, pluginFilename string) (*plugin.PluginHeader, error) {
+// Password opens encrypted archives and may be empty.
+func (h *LoadOrderHandler) extractAndParsePluginFromArchive(ctx context.Context, archivePath, password, pluginFilename string) (*plugin.PluginHeader, error) {
 	// List files to find the plugin
-	files, err := h.extractor.ListFiles(ctx, archivePath)
+	files, err := h.extractor.ListFiles(ctx, archivePath, password)
 	if err != nil {
 		return nil, fmt.Errorf("list archive: %w", err)
 	}
@@ -291,7 +392,7 @@ func (h *LoadOrderHandler) extractAndParsePluginFromArchive(ctx context.Context,
 	}
 
 	// Extract just this plugin
-	result, err := h.extractor.ExtractPaths(ctx, archivePath, []string{pluginPath})
+	result, err := h.extractor.ExtractPaths(ctx, archivePath, password, []string{pluginPath})
 	if err != nil {
 		return nil, fmt.Errorf("extract plugin: %w", err)
 	}
@@ -305,193 +406,3 @@ func (h *LoadOrderHandler) extractAndParsePluginFromArchive(ctx context.Context,
 	extractedPath := filepath.Join(result.OutputDir, result.Files[0])
 	return h.parser.ParseFile(ctx, extractedPath)
 }
-
-// extractPluginsFromCollection extracts plugin information from collection mods.
-func (h *LoadOrderHandler) extractPluginsFromCollection(ctx context.Context, client *nexus.Client, gameDomain string, revision *nexus.RevisionDetails) ([]loadorder.PluginFile, error) {
-	var pluginFiles []loadorder.PluginFile
-
-	for _, modFile := range revision.ModFiles {
-		if modFile.File == nil || modFile.File.Mod == nil {
-			continue
-		}
-
-		// Check if this mod file might contain plugins
-		filename := modFile.File.Name
-		lowerName := strings.ToLower(filename)
-
-		// If the file itself is a plugin
-		if plugin.IsPluginFile(filename) {
-			pf := loadorder.PluginFile{
-				Filename: filename,
-			}
-
-			// Try to get actual plugin header
-			header, err := h.fetchModFilePlugin(ctx, client, gameDomain, modFile)
-			if err != nil {
-				log.Printf("Warning: could not fetch plugin %s: %v", filename, err)
-			} else if header != nil {
-				pf.Header = header
-			}
-
-			pluginFiles = append(pluginFiles, pf)
-			continue
-		}
-
-		// If it's an archive, try to find plugins inside
-		if isArchiveFilename(lowerName) {
-			plugins, err := h.extractPluginsFromModFile(ctx, client, gameDomain, modFile)
-			if err != nil {
-				log.Printf("Warning: could not extract plugins from %s: %v", filename, err)
-				continue
-			}
-			pluginFiles = append(pluginFiles, plugins...)
-		}
-	}
-
-	return pluginFiles, nil
-}
-
-// fetchModFilePlugin downloads a mod file and parses its plugin header.
-func (h *LoadOrderHandler) fetchModFilePlugin(ctx context.Context, client *nexus.Client, gameDomain string, modFile nexus.ModFileReference) (*plugin.PluginHeader, error) {
-	if modFile.File == nil || modFile.File.Mod == nil {
-		return nil, errors.New("incomplete mod file reference")
-	}
-
-	// Get download links
-	links, err := client.GetModFileDownloadLinks(ctx, gameDomain, modFile.File.Mod.ModID, modFile.File.FileID)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(links) == 0 {
-		return nil, errors.New("no download links")
-	}
-
-	// Download
-	downloadResult, err := h.downloader.Download(ctx, links[0].URI, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer h.downloader.CleanupPath(downloadResult.FilePath)
-
-	// Parse plugin
-	return h.parser.ParseFile(ctx, downloadResult.FilePath)
-}
-
-// extractPluginsFromModFile extracts plugin files from an archive mod file.
-func (h *LoadOrderHandler) extractPluginsFromModFile(ctx context.Context, client *nexus.Client, gameDomain string, modFile nexus.ModFileReference) ([]loadorder.PluginFile, error) {
-	if modFile.File == nil || modFile.File.Mod == nil {
-		return nil, errors.New("incomplete mod file reference")
-	}
-
-	// Get download links
-	links, err := client.GetModFileDownloadLinks(ctx, gameDomain, modFile.File.Mod.ModID, modFile.File.FileID)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(links) == 0 {
-		return nil, errors.New("no download links")
-	}
-
-	// Download
-	downloadResult, err := h.downloader.Download(ctx, links[0].URI, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer h.downloader.CleanupPath(downloadResult.FilePath)
-
-	// List files in archive
-	files, err := h.extractor.ListFiles(ctx, downloadResult.FilePath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Find all plugin files
-	var pluginPaths []string
-	for _, f := range files {
-		if plugin.IsPluginFile(f) {
-			pluginPaths = append(pluginPaths, f)
-		}
-	}
-
-	if len(pluginPaths) == 0 {
-		return nil, nil
-	}
-
-	// Extract plugin files
-	extractResult, err := h.extractor.ExtractPaths(ctx, downloadResult.FilePath, pluginPaths)
-	if err != nil {
-		return nil, err
-	}
-	defer h.extractor.Cleanup(extractResult.OutputDir)
-
-	// Parse each plugin
-	var pluginFiles []loadorder.PluginFile
-	for _, extractedFile := range extractResult.Files {
-		extractedPath := filepath.Join(extractResult.OutputDir, extractedFile)
-		filename := filepath.Base(extractedFile)
-
-		pf := loadorder.PluginFile{
-			Filename: filename,
-		}
-
-		header, err := h.parser.ParseFile(ctx, extractedPath)
-		if err != nil {
-			log.Printf("Warning: could not parse plugin %s: %v", filename, err)
-		} else {
-			pf.Header = header
-		}
-
-		pluginFiles = append(pluginFiles, pf)
-	}
-
-	return pluginFiles, nil
-}
-
-// isArchive checks if a file is an archive based on content type or extension.
-func isArchive(filePath string) bool {
-	// Try to identify by reading file header
-	f, err := os.Open(filePath)
-	if err != nil {
-		return isArchiveFilename(strings.ToLower(filePath))
-	}
-	defer f.Close()
-
-	// Read first few bytes
-	header := make([]byte, 10)
-	n, err := io.ReadFull(f, header)
-	if err != nil || n < 4 {
-		return isArchiveFilename(strings.ToLower(filePath))
-	}
-
-	// Check magic bytes
-	// ZIP: PK\x03\x04
-	if header[0] == 'P' && header[1] == 'K' && header[2] == 0x03 && header[3] == 0x04 {
-		return true
-	}
-	// 7z: 7z\xBC\xAF\x27\x1C
-	if header[0] == '7' && header[1] == 'z' && header[2] == 0xBC && header[3] == 0xAF {
-		return true
-	}
-	// RAR: Rar!\x1A\x07
-	if header[0] == 'R' && header[1] == 'a' && header[2] == 'r' && header[3] == '!' {
-		return true
-	}
-
-	return isArchiveFilename(strings.ToLower(filePath))
-}
-
-// isArchiveFilename checks if a filename has an archive extension.
-func isArchiveFilename(filename string) bool {
-	switch {
-	case strings.HasSuffix(filename, ".zip"):
-		return true
-	case strings.HasSuffix(filename, ".7z"):
-		return true
-	case strings.HasSuffix(filename, ".rar"):
-		return true
-	default:
-		return false
-	}
-}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+// maxManifestDiffBytes limits the request body, which may carry two large
+// file listings.
+const maxManifestDiffBytes = 32 * 1024 * 1024 // 32MB
+
+// ManifestDiffRequest is the request body for diffing two manifests.
+type ManifestDiffRequest struct {
+	// A is the old version, B the new one.
+	A ManifestDiffSource `json:"a"`
+	B ManifestDiffSource `json:"b"`
+}
+
+// ManifestDiffSource is a manifest to diff: either a mod file on Nexus,
+// which is downloaded and listed with content hashes, or a file listing.
+type ManifestDiffSource struct {
+	Game   string `json:"game,omitempty"`
+	ModID  int    `json:"modId,omitempty"`
+	FileID int    `json:"fileId,omitempty"`
+	// Files is a file listing, as returned by the similarity endpoint's
+	// JSON form.
+	Files []SimilarityFile `json:"files,omitempty"`
+}
+
+// ManifestDiffResponse is the result of diffing two manifests.
+type ManifestDiffResponse struct {
+	manifest.Diff
+	FilesA int `json:"filesA"`
+	FilesB int `json:"filesB"`
+}
+
+// ManifestDiffHandler diffs the file listings of two mod files.
+type ManifestDiffHandler struct {
+	clientGetter NexusClientGetter
+	downloader   *archive.Downloader
+	extractor    *manifest.Extractor
+	sandbox      pipeline.ArchiveReader
+	sessions     *pipeline.Sessions
+	readOnly     bool
+}
+
+// ManifestDiffHandlerConfig holds configuration for the ManifestDiffHandler.
+type ManifestDiffHandlerConfig struct {
+	ClientGetter NexusClientGetter
+	Downloader   *archive.Downloader
+	// Sandbox lists downloaded archives in place of the built-in extractor
+	// (optional).
+	Sandbox pipeline.ArchiveReader
+	// Sessions keeps recently downloaded archives (optional).
+	Sessions *pipeline.Sessions
+	// ReadOnly rejects mod file references, since they download from Nexus.
+	ReadOnly bool
+}
+
+// NewManifestDiffHandler creates a new manifest diff handler.
+func NewManifestDiffHandler(cfg ManifestDiffHandlerConfig) *ManifestDiffHandler {
+	return &ManifestDiffHandler{
+		clientGetter: cfg.ClientGetter,
+		downloader:   cfg.Downloader,
+		extractor:    manifest.NewExtractor(),
+		sandbox:      cfg.Sandbox,
+		sessions:     cfg.Sessions,
+		readOnly:     cfg.ReadOnly,
+	}
+}
+
+// DiffManifests handles POST /api/manifests/diff
+// Lists the files added, removed and changed between two manifests, such as
+// two versions of the same mod. Each side is either a mod file reference
+// ({"game", "modId", "fileId"}), downloaded and hashed so edits that keep a
+// file's size are found, or a JSON file listing ({"files": [...]}).
+func (h *ManifestDiffHandler) DiffManifests(w http.ResponseWriter, r *http.Request) {
+	var req ManifestDiffRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxManifestDiffBytes)).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sides := map[string]ManifestDiffSource{"a": req.A, "b": req.B}
+	needsNexus := false
+	for _, name := range []string{"a", "b"} {
+		if err := sides[name].validate(); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid manifest %q: %v", name, err))
+			return
+		}
+		needsNexus = needsNexus || sides[name].isReference()
+	}
+
+	var client *nexus.Client
+	if needsNexus {
+		if h.readOnly {
+			writeReadOnly(w)
+			return
+		}
+		if client = h.clientGetter.Get(); client == nil {
+			writeNoAPIKey(w)
+			return
+		}
+	}
+
+	manifests := make([]*manifest.Manifest, 0, 2)
+	for _, name := range []string{"a", "b"} {
+		side := sides[name]
+		if !side.isReference() {
+			manifests = append(manifests, SimilarityManifest{Files: side.Files}.toManifest())
+			continue
+		}
+		m, err := h.listModFile(r.Context(), client, side)
+		if err != nil {
+			handleManifestDiffError(w, err)
+			return
+		}
+		manifests = append(manifests, m)
+	}
+
+	a, b := manifests[0], manifests[1]
+	if a.TotalCount == 0 || b.TotalCount == 0 {
+		WriteError(w, http.StatusBadRequest, "Both manifests must contain at least one file")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, ManifestDiffResponse{
+		Diff:   manifest.CompareFiles(a, b),
+		FilesA: a.TotalCount,
+		FilesB: b.TotalCount,
+	})
+}
+
+// isReference reports whether the source names a mod file on Nexus.
+func (s ManifestDiffSource) isReference() bool {
+	return s.Game != "" || s.ModID != 0 || s.FileID != 0
+}
+
+// validate checks that the source is either a complete mod file reference
+// or a file listing.
+func (s ManifestDiffSource) validate() error {
+	switch {
+	case s.isReference() && len(s.Files) > 0:
+		return errors.New("give either a mod file or a file listing, not both")
+	case !s.isReference():
+		return nil
+	case s.Game == "":
+		return errors.New("game domain is required")
+	case s.ModID <= 0:
+		return errors.New("invalid mod ID")
+	case s.FileID <= 0:
+		return errors.New("invalid file ID")
+	}
+	return nil
+}
+
+// listModFile downloads a mod file and lists it with content hashes.
+func (h *ManifestDiffHandler) listModFile(ctx context.Context, client *nexus.Client, s ManifestDiffSource) (*manifest.Manifest, error) {
+	gameDomain := GetNexusDomain(s.Game)
+	src := pipeline.Source{
+		ModID:      sourceModID(s.ModID, s.FileID),
+		Game:       gameDomain,
+		NexusModID: s.ModID,
+		FileID:     s.FileID,
+	}
+
+	session := h.sessions.Acquire(gameDomain+"/"+src.ModID, s.FileID)
+	defer session.Done()
+	fetcher := session.Fetcher(&nexusFetcher{client: client, downloader: h.downloader})
+
+	archivePath, err := fetcher.Fetch(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer fetcher.Release(archivePath)
+
+	if h.sandbox != nil {
+		return h.sandbox.Manifest(ctx, archivePath, "", manifest.Options{Hashes: true})
+	}
+	return h.extractor.ExtractManifestWithHashes(ctx, archivePath, "")
+}
+
+// handleManifestDiffError maps errors to HTTP responses for manifest diffs.
+func handleManifestDiffError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pipeline.ErrUnavailable):
+		writeErrorFor(w, http.StatusNotFound, err, "Mod file not found")
+	case errors.Is(err, manifest.ErrUnsupportedFormat), errors.Is(err, archive.ErrUnsupportedFormat):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "Mod file is not a supported archive")
+	case errors.Is(err, manifest.ErrExtractionFailed), errors.Is(err, archive.ErrExtractionFailed):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "Mod archive could not be read")
+	case errors.Is(err, archive.ErrInfected):
+		writeErrorFor(w, http.StatusUnprocessableEntity, err, "The mod archive was flagged by the virus scanner and quarantined")
+	case errors.Is(err, archive.ErrDownloadFailed), errors.Is(err, archive.ErrUnexpectedContent), errors.Is(err, archive.ErrInvalidResponse):
+		writeErrorFor(w, http.StatusBadGateway, err, "Failed to download mod archive")
+	default:
+		log.Printf("Error listing mod file for diff: %v", err)
+		handleNexusError(w, err, "list mod file")
+	}
+}
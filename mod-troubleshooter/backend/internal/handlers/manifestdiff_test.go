@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestManifestDiffHandler_Listings(t *testing.T) {
+	handler := NewManifestDiffHandler(ManifestDiffHandlerConfig{ClientGetter: &mockNexusClientGetter{}})
+
+	body := `{
+		"a": {"files": [{"path": "Mod v1/meshes/a.nif", "size": 10}, {"path": "Mod v1/scripts/old.pex", "size": 5}]},
+		"b": {"files": [{"path": "Mod v2/meshes/a.nif", "size": 12}, {"path": "Mod v2/scripts/new.pex", "size": 5}]}
+	}`
+	w := httptest.NewRecorder()
+	handler.DiffManifests(w, httptest.NewRequest(http.MethodPost, "/api/manifests/diff", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data ManifestDiffResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	diff := resp.Data
+	if len(diff.Added) != 1 || diff.Added[0].Path != "scripts/new.pex" ||
+		len(diff.Removed) != 1 || diff.Removed[0].Path != "scripts/old.pex" ||
+		len(diff.Changed) != 1 || diff.Changed[0].Path != "meshes/a.nif" {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+	if diff.FilesA != 2 || diff.FilesB != 2 {
+		t.Errorf("expected 2 files on each side, got %d and %d", diff.FilesA, diff.FilesB)
+	}
+}
+
+func TestManifestDiffHandler_InvalidRequests(t *testing.T) {
+	handler := NewManifestDiffHandler(ManifestDiffHandlerConfig{ClientGetter: &mockNexusClientGetter{}, ReadOnly: true})
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"malformed", "{", http.StatusBadRequest},
+		{"empty listing", `{"a": {"files": [{"path": "a.esp"}]}, "b": {}}`, http.StatusBadRequest},
+		{"reference and listing", `{"a": {"game": "skyrim", "modId": 1, "fileId": 2, "files": [{"path": "a.esp"}]}, "b": {"files": [{"path": "a.esp"}]}}`, http.StatusBadRequest},
+		{"incomplete reference", `{"a": {"game": "skyrim", "modId": 1}, "b": {"files": [{"path": "a.esp"}]}}`, http.StatusBadRequest},
+		{"reference while read-only", `{"a": {"game": "skyrim", "modId": 1, "fileId": 2}, "b": {"files": [{"path": "a.esp"}]}}`, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.DiffManifests(w, httptest.NewRequest(http.MethodPost, "/api/manifests/diff", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/overwrite"
+)
+
+// maxOverwriteUploadSize limits the size of uploaded overwrite archives.
+const maxOverwriteUploadSize = 1024 * 1024 * 1024 // 1GB
+
+// OverwriteAnalyzeRequest is the request body for overwrite folder analysis.
+type OverwriteAnalyzeRequest struct {
+	// Files lists the contents of the overwrite folder.
+	Files []OverwriteFile `json:"files"`
+}
+
+// OverwriteFile is a file in the overwrite folder.
+type OverwriteFile struct {
+	// Path is relative to the overwrite folder.
+	Path string `json:"path"`
+	// Size is the file size in bytes (optional).
+	Size int64 `json:"size,omitempty"`
+}
+
+// OverwriteHandler handles MO2 overwrite folder analysis.
+type OverwriteHandler struct {
+	extractor *manifest.Extractor
+}
+
+// NewOverwriteHandler creates a new overwrite handler.
+func NewOverwriteHandler() *OverwriteHandler {
+	return &OverwriteHandler{extractor: manifest.NewExtractor()}
+}
+
+// AnalyzeOverwrite handles POST /api/analyze/overwrite
+// Accepts either a JSON file listing or an archive of the overwrite folder
+// (any non-JSON content type) and recommends where each file should go.
+func (h *OverwriteHandler) AnalyzeOverwrite(w http.ResponseWriter, r *http.Request) {
+	var files []manifest.FileEntry
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/json" {
+		var req OverwriteAnalyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		for _, f := range req.Files {
+			if f.Path == "" {
+				continue
+			}
+			files = append(files, manifest.NewFileEntry(f.Path, f.Size))
+		}
+	} else {
+		m, status, msg := h.listUpload(w, r)
+		if m == nil {
+			WriteError(w, status, msg)
+			return
+		}
+		files = m.Files
+	}
+
+	if len(files) == 0 {
+		WriteError(w, http.StatusBadRequest, "At least one file is required")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, overwrite.Classify(files))
+}
+
+// listUpload saves an uploaded archive to a temp file and lists its contents.
+// On failure it returns a nil manifest with the status and message to report.
+func (h *OverwriteHandler) listUpload(w http.ResponseWriter, r *http.Request) (*manifest.Manifest, int, string) {
+	tmp, err := os.CreateTemp("", "overwrite-*")
+	if err != nil {
+		log.Printf("Error creating temp file: %v", err)
+		return nil, http.StatusInternalServerError, "Failed to store upload"
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, http.MaxBytesReader(w, r.Body, maxOverwriteUploadSize)); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, http.StatusRequestEntityTooLarge, "Upload is too large"
+		}
+		return nil, http.StatusBadRequest, "Failed to read request body"
+	}
+
+	m, err := h.extractor.ExtractManifest(r.Context(), tmp.Name(), "")
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid or unsupported archive"
+	}
+
+	return m, 0, ""
+}
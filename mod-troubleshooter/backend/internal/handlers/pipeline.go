@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+// nexusFetcher downloads mod files for the analysis pipeline using Nexus download links.
+type nexusFetcher struct {
+	client     *nexus.Client
+	downloader *archive.Downloader
+	// mirrors, if set, steers downloads to the CDNs that have done best so
+	// far in this job. Without it the CDNs are tried in the order Nexus
+	// recommends.
+	mirrors *archive.MirrorHealth
+}
+
+// Fetch implements pipeline.Fetcher.
+func (f *nexusFetcher) Fetch(ctx context.Context, src pipeline.Source) (string, error) {
+	links, err := f.client.GetModFileDownloadLinks(ctx, src.NexusGame(), src.NexusModID, src.FileID)
+	if errors.Is(err, nexus.ErrNotFound) {
+		return "", fmt.Errorf("%w: %v", pipeline.ErrUnavailable, err)
+	}
+	if errors.Is(err, nexus.ErrDegraded) {
+		return "", fmt.Errorf("%w: %w", pipeline.ErrSourceDown, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get download links: %w", err)
+	}
+
+	if len(links) == 0 {
+		return "", errors.New("no download links available")
+	}
+
+	mirrors := make([]archive.Mirror, 0, len(links))
+	for _, link := range links {
+		mirrors = append(mirrors, archive.Mirror{Name: mirrorName(link), URL: link.URI})
+	}
+	downloadResult, err := f.downloader.DownloadMirrors(ctx, mirrors, f.mirrors, pipeline.DownloadProgress(ctx))
+	if err != nil {
+		return "", fmt.Errorf("download: %w", err)
+	}
+
+	return downloadResult.FilePath, nil
+}
+
+// mirrorName identifies the CDN behind a download link.
+func mirrorName(link nexus.DownloadLink) string {
+	if link.ShortName != "" {
+		return link.ShortName
+	}
+	if link.Name != "" {
+		return link.Name
+	}
+	if u, err := url.Parse(link.URI); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return link.URI
+}
+
+// Release implements pipeline.Fetcher.
+func (f *nexusFetcher) Release(path string) {
+	f.downloader.CleanupPath(path)
+}
+
+// Preview implements pipeline.Previewer using the file tree Nexus publishes
+// for uploaded archives. Looking up the preview link costs one API request,
+// like a download link, but the archive itself is never downloaded.
+func (f *nexusFetcher) Preview(ctx context.Context, src pipeline.Source) (*manifest.Manifest, error) {
+	file, err := f.client.GetModFile(ctx, src.NexusGame(), src.NexusModID, src.FileID)
+	if errors.Is(err, nexus.ErrDegraded) {
+		return nil, fmt.Errorf("%w: %w", pipeline.ErrSourceDown, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get file details: %w", err)
+	}
+
+	preview, err := f.client.GetContentPreview(ctx, file.ContentPreviewLink)
+	if err != nil {
+		return nil, err
+	}
+
+	files := preview.Files()
+	if len(files) == 0 {
+		return nil, nexus.ErrNoContentPreview
+	}
+	entries := make([]manifest.FileEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, manifest.NewFileEntry(file.Path, file.Size))
+	}
+	return manifest.NewManifest(entries), nil
+}
+
+// maxBugReports is how many of each mod's latest bug reports are checked
+// for community report hints.
+const maxBugReports = 10
+
+// BugReports implements pipeline.BugReporter.
+func (f *nexusFetcher) BugReports(ctx context.Context, src pipeline.Source) ([]string, error) {
+	reports, err := f.client.GetModBugReports(ctx, src.NexusGame(), src.NexusModID, maxBugReports)
+	if err != nil {
+		return nil, fmt.Errorf("get bug reports: %w", err)
+	}
+	titles := make([]string, 0, len(reports))
+	for _, report := range reports {
+		titles = append(titles, report.Title)
+	}
+	return titles, nil
+}
+
+// VerifyArchive implements pipeline.ArchiveVerifier using the Nexus MD5
+// search. A hash Nexus does not know, or knows for other files only, fails
+// verification.
+func (f *nexusFetcher) VerifyArchive(ctx context.Context, src pipeline.Source, md5Hash string) (bool, error) {
+	results, err := f.client.SearchMD5(ctx, src.NexusGame(), md5Hash)
+	if err != nil {
+		return false, fmt.Errorf("search md5: %w", err)
+	}
+	for _, result := range results {
+		if result.Mod.ModID == src.NexusModID && result.FileDetails.FileID == src.FileID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// bugReporter returns f as the gatherer's bug reporter when community
+// reports were asked for.
+func bugReporter(f *nexusFetcher, enabled bool) pipeline.BugReporter {
+	if !enabled {
+		return nil
+	}
+	return f
+}
+
+// previewer returns f as the gatherer's previewer when content previews are enabled.
+func previewer(f *nexusFetcher, enabled bool) pipeline.Previewer {
+	if !enabled {
+		return nil
+	}
+	return f
+}
+
+// jobError is an analysis failure with the response to send for it.
+// Analyses are shared between concurrent requests, so they report failures
+// as errors and each request writes its own response.
+type jobError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *jobError) Error() string {
+	return fmt.Sprintf("%s: %v", e.message, e.err)
+}
+
+func (e *jobError) Unwrap() error {
+	return e.err
+}
+
+// gatherError wraps a gather failure, leaving Nexus errors that have their
+// own responses, such as a missing Premium account, to handleNexusError.
+func gatherError(err error, message string) error {
+	if errors.Is(err, nexus.ErrPremiumOnly) || errors.Is(err, nexus.ErrDegraded) {
+		return err
+	}
+	return &jobError{status: http.StatusInternalServerError, message: message, err: err}
+}
+
+// writeJobError writes the response for a failed analysis.
+func writeJobError(w http.ResponseWriter, err error, action string) {
+	var jobErr *jobError
+	if errors.As(err, &jobErr) {
+		log.Printf("Error during %s: %v", action, jobErr.err)
+		WriteError(w, jobErr.status, jobErr.message)
+		return
+	}
+	handleNexusError(w, err, action)
+}
+
+// collectionSources lists the mod files of a collection revision as pipeline sources.
+func collectionSources(gameDomain string, revision *nexus.RevisionDetails) []pipeline.Source {
+	sources := make([]pipeline.Source, 0, len(revision.ModFiles))
+	gameVersion := revision.GameVersion()
+
+	for i, modFile := range revision.ModFiles {
+		// Nexus omits files and mods that were deleted or hidden by their author
+		if modFile.File == nil || modFile.File.Mod == nil {
+			src := pipeline.Source{
+				ModID:       fmt.Sprintf("file-%d", modFile.FileID),
+				ModName:     fmt.Sprintf("File %d", modFile.FileID),
+				LoadOrder:   i,
+				Game:        gameDomain,
+				GameVersion: gameVersion,
+				FileID:      modFile.FileID,
+				Unavailable: "mod file was deleted or hidden on Nexus",
+			}
+			if modFile.File != nil {
+				src.ModName = modFile.File.Name
+				src.Filename = modFile.File.Name
+				src.DownloadSize = modFile.File.Size
+			}
+			sources = append(sources, src)
+			continue
+		}
+
+		modGame := modGameDomain(gameDomain, modFile.File.Mod)
+
+		if !modFile.File.Mod.IsAvailable() {
+			sources = append(sources, pipeline.Source{
+				ModID:        sourceModID(modFile.File.Mod.ModID, modFile.File.FileID),
+				ModName:      modFile.File.Mod.Name,
+				LoadOrder:    i,
+				Filename:     modFile.File.Name,
+				Game:         gameDomain,
+				GameVersion:  gameVersion,
+				ModGame:      modGame,
+				NexusModID:   modFile.File.Mod.ModID,
+				FileID:       modFile.File.FileID,
+				DownloadSize: modFile.File.Size,
+				Unavailable:  fmt.Sprintf("mod is %s on Nexus", modFile.File.Mod.Status),
+			})
+			continue
+		}
+
+		modName := modFile.File.Mod.Name
+		if modName == "" {
+			modName = modFile.File.Name
+		}
+
+		sources = append(sources, pipeline.Source{
+			ModID:         sourceModID(modFile.File.Mod.ModID, modFile.File.FileID),
+			ModName:       modName,
+			LoadOrder:     i,
+			Filename:      modFile.File.Name,
+			Game:          gameDomain,
+			GameVersion:   gameVersion,
+			ModGame:       modGame,
+			NexusModID:    modFile.File.Mod.ModID,
+			FileID:        modFile.File.FileID,
+			DownloadSize:  modFile.File.Size,
+			NexusCategory: nexusCategory(modFile.File.Mod),
+			NexusTags:     nexusTags(modFile.File.Mod),
+			Version:       modFile.File.Version,
+			PinnedVersion: modFile.Version,
+			CuratorNote:   modFile.Instructions,
+		})
+	}
+
+	return sources
+}
+
+// nexusCategory returns the name of a mod's Nexus category, or "".
+func nexusCategory(mod *nexus.Mod) string {
+	if mod.ModCategory == nil {
+		return ""
+	}
+	return mod.ModCategory.Name
+}
+
+// nexusTags returns the names of a mod's Nexus tags.
+func nexusTags(mod *nexus.Mod) []string {
+	var tags []string
+	for _, tag := range mod.Tags {
+		if tag.Name != "" {
+			tags = append(tags, tag.Name)
+		}
+	}
+	return tags
+}
+
+// modGameDomain returns the game domain a collection mod is published under
+// when it is not the collection's own, or "".
+func modGameDomain(gameDomain string, mod *nexus.Mod) string {
+	if mod.Game == nil || mod.Game.DomainName == "" || strings.EqualFold(mod.Game.DomainName, gameDomain) {
+		return ""
+	}
+	return mod.Game.DomainName
+}
+
+// sourceModID identifies a Nexus mod file in pipeline results.
+func sourceModID(nexusModID, fileID int) string {
+	return fmt.Sprintf("%d-%d", nexusModID, fileID)
+}
+
+// errNoMainFile is returned when a mod has no current file to pick.
+var errNoMainFile = errors.New("mod has no current files on Nexus")
+
+// mainFile picks the current main file of a mod, for references that give
+// only a mod ID.
+func mainFile(ctx context.Context, client *nexus.Client, gameDomain string, modID int) (nexus.FileDetails, error) {
+	files, err := client.GetModFiles(ctx, gameDomain, modID)
+	if err != nil {
+		return nexus.FileDetails{}, fmt.Errorf("list mod files: %w", err)
+	}
+	file, ok := nexus.MainFile(files)
+	if !ok {
+		return nexus.FileDetails{}, errNoMainFile
+	}
+	return file, nil
+}
+
+// resolveMainFiles fills in the main file of sources that give only a mod ID.
+// Mods that were not found or have no current files are marked unavailable;
+// other errors, such as a degraded Nexus, would fail every lookup and are returned.
+func resolveMainFiles(ctx context.Context, client *nexus.Client, sources []pipeline.Source) error {
+	for i := range sources {
+		src := &sources[i]
+		if src.FileID > 0 || src.NexusModID <= 0 || src.Unavailable != "" {
+			continue
+		}
+
+		file, err := mainFile(ctx, client, src.NexusGame(), src.NexusModID)
+		switch {
+		case errors.Is(err, nexus.ErrNotFound):
+			src.Unavailable = "mod was not found on Nexus"
+		case errors.Is(err, errNoMainFile):
+			src.Unavailable = err.Error()
+		case err != nil:
+			return err
+		default:
+			// Collection entries without a file were given a placeholder ID
+			if src.ModID == "" || src.ModID == sourceModID(src.NexusModID, 0) {
+				src.ModID = sourceModID(src.NexusModID, file.FileID)
+			}
+			src.FileID = file.FileID
+			if src.Filename == "" {
+				src.Filename = file.FileName
+			}
+			if src.Version == "" {
+				src.Version = file.Version
+			}
+		}
+	}
+	return nil
+}
+
+// revisionSources lists the mod files of a collection revision as pipeline
+// sources, using the current main file of entries that don't pin a file.
+func revisionSources(ctx context.Context, client *nexus.Client, gameDomain string, revision *nexus.RevisionDetails) ([]pipeline.Source, error) {
+	sources := collectionSources(gameDomain, revision)
+	if err := resolveMainFiles(ctx, client, sources); err != nil {
+		return nil, fmt.Errorf("look up mod files: %w", err)
+	}
+	return sources, nil
+}
+
+// modReferenceSources converts explicit mod references into pipeline sources.
+func modReferenceSources(mods []ModReference) []pipeline.Source {
+	sources := make([]pipeline.Source, 0, len(mods))
+
+	for i, mod := range mods {
+		sources = append(sources, pipeline.Source{
+			ModID:      mod.ModID,
+			ModName:    mod.ModName,
+			LoadOrder:  i,
+			Game:       GetNexusDomain(mod.Game),
+			NexusModID: mod.NexusModID,
+			FileID:     mod.FileID,
+			Password:   mod.Password,
+			Choices:    mod.Choices,
+			Patches:    mod.Patches,
+		})
+	}
+
+	return sources
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+// redirectTransport sends every request to a test server.
+type redirectTransport struct {
+	server *httptest.Server
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = "http"
+	req.URL.Host = strings.TrimPrefix(t.server.URL, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestResolveMainFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/games/skyrimspecialedition/mods/1/files.json":
+			w.Write([]byte(`{"files":[
+				{"file_id":10,"category_id":4,"file_name":"old.7z","uploaded_timestamp":100},
+				{"file_id":11,"category_id":1,"file_name":"main.7z","version":"2.0","uploaded_timestamp":200}
+			]}`))
+		case "/v1/games/skyrimspecialedition/mods/2/files.json":
+			w.Write([]byte(`{"files":[{"file_id":20,"category_id":7}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := nexus.NewClient(nexus.ClientConfig{
+		APIKey:     "test",
+		HTTPClient: &http.Client{Transport: &redirectTransport{server: server}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	sources := []pipeline.Source{
+		{ModID: "pinned", Game: "skyrimspecialedition", NexusModID: 1, FileID: 99},
+		{ModID: "main", Game: "skyrimspecialedition", NexusModID: 1},
+		{ModID: "archived", Game: "skyrimspecialedition", NexusModID: 2},
+		{ModID: "missing", Game: "skyrimspecialedition", NexusModID: 3},
+		{ModID: "1-0", Game: "skyrimspecialedition", NexusModID: 1},
+	}
+	if err := resolveMainFiles(context.Background(), client, sources); err != nil {
+		t.Fatalf("resolveMainFiles failed: %v", err)
+	}
+
+	if sources[0].FileID != 99 {
+		t.Errorf("expected pinned file to be kept, got %d", sources[0].FileID)
+	}
+	if got := sources[1]; got.FileID != 11 || got.Filename != "main.7z" || got.Version != "2.0" {
+		t.Errorf("expected main file 11, got %+v", got)
+	}
+	if got := sources[4].ModID; got != "1-11" {
+		t.Errorf("expected placeholder ID to be rebuilt as 1-11, got %s", got)
+	}
+	if got := sources[1].ModID; got != "main" {
+		t.Errorf("expected given ID to be kept, got %s", got)
+	}
+	if sources[2].Unavailable == "" || sources[3].Unavailable == "" {
+		t.Errorf("expected mods without current files to be unavailable, got %+v and %+v", sources[2], sources[3])
+	}
+}
+
+func TestCollectionSources_ModGame(t *testing.T) {
+	revision := &nexus.RevisionDetails{ModFiles: []nexus.ModFileReference{
+		{FileID: 1, File: &nexus.ModFile{FileID: 1, Mod: &nexus.Mod{ModID: 10, Name: "Same", Game: &nexus.Game{DomainName: "SkyrimSpecialEdition"}}}},
+		{FileID: 2, File: &nexus.ModFile{FileID: 2, Mod: &nexus.Mod{ModID: 20, Name: "Textures", Game: &nexus.Game{DomainName: "skyrim"}}}},
+		{FileID: 3, File: &nexus.ModFile{FileID: 3, Mod: &nexus.Mod{ModID: 30, Name: "Unknown"}}},
+	}}
+
+	sources := collectionSources("skyrimspecialedition", revision)
+	if got := sources[0].ModGame; got != "" {
+		t.Errorf("expected no mod game when it matches the collection, got %q", got)
+	}
+	if got := sources[1]; got.ModGame != "skyrim" || got.NexusGame() != "skyrim" || got.Game != "skyrimspecialedition" {
+		t.Errorf("expected LE mod to be looked up under skyrim, got %+v", got)
+	}
+	if got := sources[2].NexusGame(); got != "skyrimspecialedition" {
+		t.Errorf("expected collection game without mod game, got %q", got)
+	}
+}
+
+func TestCollectionSources_GameVersion(t *testing.T) {
+	revision := &nexus.RevisionDetails{
+		GameVersions: []nexus.GameVersion{{Reference: "1.6.1170.0"}},
+		ModFiles: []nexus.ModFileReference{
+			{FileID: 1, File: &nexus.ModFile{FileID: 1, Mod: &nexus.Mod{ModID: 10, Name: "Mod"}}},
+		},
+	}
+
+	sources := collectionSources("skyrimspecialedition", revision)
+	if got := sources[0].GameVersion; got != "1.6.1170.0" {
+		t.Errorf("expected game version of the revision, got %q", got)
+	}
+}
+
+func TestCollectionSources_CuratorNotes(t *testing.T) {
+	revision := &nexus.RevisionDetails{ModFiles: []nexus.ModFileReference{
+		{FileID: 1, Instructions: "Choose option B in the installer", File: &nexus.ModFile{FileID: 1, Mod: &nexus.Mod{ModID: 10, Name: "Lighting"}}},
+		{FileID: 2, Instructions: "  ", File: &nexus.ModFile{FileID: 2, Mod: &nexus.Mod{ModID: 20, Name: "Textures"}}},
+		{FileID: 3, File: &nexus.ModFile{FileID: 3, Mod: &nexus.Mod{ModID: 30, Name: "Patch"}}},
+	}}
+
+	in := &pipeline.Inputs{}
+	for _, src := range collectionSources("skyrimspecialedition", revision) {
+		in.Mods = append(in.Mods, pipeline.Mod{ModID: src.ModID, ModName: src.ModName, CuratorNote: src.CuratorNote})
+	}
+
+	notes := in.Notes()
+	if len(notes) != 1 || notes[0].ModName != "Lighting" || notes[0].Note != "Choose option B in the installer" {
+		t.Errorf("expected the one curator note, got %+v", notes)
+	}
+}
@@ -14,6 +14,8 @@ type QuotaResponse struct {
 	DailyLimit      int  `json:"dailyLimit"`
 	DailyRemaining  int  `json:"dailyRemaining"`
 	Available       bool `json:"available"`
+	// Breaker reports whether requests are paused because Nexus is degraded.
+	Breaker nexus.BreakerStatus `json:"breaker"`
 }
 
 // QuotaHandler handles quota-related endpoints.
@@ -52,6 +54,7 @@ func (h *QuotaHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
 		// No rate limit info available yet (no requests made)
 		resp := QuotaResponse{
 			Available: false,
+			Breaker:   client.BreakerStatus(),
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(Response{Data: resp})
@@ -64,6 +67,7 @@ func (h *QuotaHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
 		DailyLimit:      info.DailyLimit,
 		DailyRemaining:  info.DailyRemaining,
 		Available:       true,
+		Breaker:         client.BreakerStatus(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/cache"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/resolve"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
+)
+
+// CreateResolutionRequest is the request body for starting a resolution session.
+type CreateResolutionRequest struct {
+	// Slug and Revision identify a collection whose conflict analysis is cached.
+	Slug     string `json:"slug,omitempty"`
+	Revision int    `json:"revision,omitempty"`
+	// Conflicts are resolved directly when given, instead of a cached analysis.
+	Conflicts []conflict.Conflict `json:"conflicts,omitempty"`
+}
+
+// DecisionRequest is the request body for choosing the winner of a conflict.
+type DecisionRequest struct {
+	Path   string `json:"path"`
+	Winner string `json:"winner"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ResolutionHandler manages interactive conflict resolution sessions.
+type ResolutionHandler struct {
+	manager      *resolve.Manager
+	cache        *cache.Cache
+	suppressions *suppress.Store
+}
+
+// ResolutionHandlerConfig holds configuration for the ResolutionHandler.
+type ResolutionHandlerConfig struct {
+	Manager *resolve.Manager
+	// Cache provides the conflict analyses sessions start from.
+	Cache *cache.Cache
+	// Suppressions leaves suppressed conflicts out of new sessions (optional).
+	Suppressions *suppress.Store
+}
+
+// NewResolutionHandler creates a new resolution handler.
+func NewResolutionHandler(cfg ResolutionHandlerConfig) *ResolutionHandler {
+	return &ResolutionHandler{
+		manager:      cfg.Manager,
+		cache:        cfg.Cache,
+		suppressions: cfg.Suppressions,
+	}
+}
+
+// CreateResolution handles POST /api/resolutions
+// Starts a session over the given conflicts, or over the cached conflict
+// analysis of a collection revision.
+func (h *ResolutionHandler) CreateResolution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateResolutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result := &conflict.AnalysisResult{Conflicts: req.Conflicts}
+	if len(req.Conflicts) == 0 {
+		if req.Slug == "" || req.Revision <= 0 {
+			WriteError(w, http.StatusBadRequest, "Either conflicts or a collection slug and revision are required")
+			return
+		}
+
+		result = nil
+		if h.cache != nil {
+			// Prefer the hash-enabled analysis when both variants are cached
+			for _, includeHashes := range []bool{true, false} {
+				var cached ConflictAnalyzeResponse
+				if err := h.cache.Get(ctx, cache.ConflictsKey(req.Slug, req.Revision, includeHashes), &cached); err == nil {
+					result = cached.AnalysisResult
+					break
+				}
+			}
+		}
+		if result == nil {
+			WriteError(w, http.StatusNotFound, "No conflict analysis for this revision. Run conflict analysis first.")
+			return
+		}
+	}
+
+	// Suppressed conflicts are accepted as they are and need no decision
+	result, _ = activeSuppressions(ctx, h.suppressions, req.Slug).FilterConflicts(result)
+
+	state, err := h.manager.Create(req.Slug, req.Revision, result.Conflicts)
+	if err != nil {
+		log.Printf("Error creating resolution session: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to create resolution session")
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, state)
+}
+
+// GetResolution handles GET /api/resolutions/{id}
+// Returns the current winner of every conflict in the session.
+func (h *ResolutionHandler) GetResolution(w http.ResponseWriter, r *http.Request) {
+	state, err := h.manager.Get(r.PathValue("id"))
+	if err != nil {
+		writeResolutionError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, state)
+}
+
+// Decide handles POST /api/resolutions/{id}/decisions
+// Chooses the winner of a conflict. Undecided conflicts between the same mods
+// follow the choice, and the recomputed state is returned.
+func (h *ResolutionHandler) Decide(w http.ResponseWriter, r *http.Request) {
+	var req DecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Path == "" || req.Winner == "" {
+		WriteError(w, http.StatusBadRequest, "Path and winner are required")
+		return
+	}
+
+	state, err := h.manager.Decide(r.PathValue("id"), req.Path, req.Winner, req.Reason)
+	if err != nil {
+		writeResolutionError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, state)
+}
+
+// UndoDecision handles DELETE /api/resolutions/{id}/decisions?path=...
+// Removes the decision for a conflict and returns the recomputed state.
+func (h *ResolutionHandler) UndoDecision(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSpace(r.URL.Query().Get("path"))
+	if path == "" {
+		WriteError(w, http.StatusBadRequest, "The path parameter is required")
+		return
+	}
+
+	state, err := h.manager.Undo(r.PathValue("id"), path)
+	if err != nil {
+		writeResolutionError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, state)
+}
+
+// GetResolutionPlan handles GET /api/resolutions/{id}/plan
+// Returns the install order changes and file overrides the decisions call for.
+func (h *ResolutionHandler) GetResolutionPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := h.manager.Plan(r.PathValue("id"))
+	if err != nil {
+		writeResolutionError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, plan)
+}
+
+// DeleteResolution handles DELETE /api/resolutions/{id}
+// Ends a session.
+func (h *ResolutionHandler) DeleteResolution(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.Delete(r.PathValue("id")); err != nil {
+		writeResolutionError(w, err)
+		return
+	}
+
+	WriteSuccess(w, "Resolution session deleted")
+}
+
+// writeResolutionError writes the response for a failed session operation.
+func writeResolutionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, resolve.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "Resolution session not found or expired")
+	case errors.Is(err, resolve.ErrUnknownConflict):
+		WriteError(w, http.StatusNotFound, "Conflict not found in this session")
+	case errors.Is(err, resolve.ErrUnknownMod):
+		WriteError(w, http.StatusBadRequest, "The winner must be one of the mods providing the file")
+	default:
+		log.Printf("Error in resolution session: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Resolution session failed")
+	}
+}
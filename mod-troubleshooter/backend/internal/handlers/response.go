@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/msgpack"
 )
 
 // Response is the standard API response envelope.
 type Response struct {
 	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Error   *APIError   `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
 }
 
@@ -19,11 +24,97 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(Response{Data: data})
 }
 
-// WriteError writes a JSON error response with the given status code and message.
+// WriteResult writes an analysis result in the encoding the request asks
+// for: MessagePack if its Accept header prefers application/msgpack, JSON
+// otherwise. Both carry the same envelope. Errors are always JSON.
+func WriteResult(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	w.Header().Add("Vary", "Accept")
+	if !prefersMsgpack(r.Header.Get("Accept")) {
+		WriteJSON(w, status, data)
+		return
+	}
+
+	body, err := msgpack.Marshal(Response{Data: data})
+	if err != nil {
+		WriteJSON(w, status, data)
+		return
+	}
+	w.Header().Set("Content-Type", msgpack.ContentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// prefersMsgpack reports whether an Accept header weighs MessagePack at
+// least as highly as JSON.
+func prefersMsgpack(accept string) bool {
+	msgpackQ, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case msgpack.ContentType, "application/x-msgpack":
+			msgpackQ = max(msgpackQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return msgpackQ > 0 && msgpackQ >= jsonQ
+}
+
+// WriteError writes a JSON error response with the given status code and
+// message. The error code is the default for the status.
 func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteAPIError(w, status, APIError{Message: message})
+}
+
+// WriteAPIError writes a JSON error response. A missing code defaults to the
+// one for the status, and the correlation ID and retry delay are filled in
+// from the X-Request-ID and Retry-After response headers.
+func WriteAPIError(w http.ResponseWriter, status int, apiErr APIError) {
+	if apiErr.Code == "" {
+		apiErr.Code = statusCode(status)
+	}
+	if apiErr.CorrelationID == "" {
+		apiErr.CorrelationID = w.Header().Get(RequestIDHeader)
+	}
+	if apiErr.RetryAfter == 0 {
+		apiErr.RetryAfter = retryAfterSeconds(w)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(Response{Error: message})
+	json.NewEncoder(w).Encode(Response{Error: &apiErr})
+}
+
+// writeErrorFor writes an error response for err, using the error's code
+// when it has one.
+func writeErrorFor(w http.ResponseWriter, status int, err error, message string) {
+	WriteAPIError(w, status, APIError{Code: errorCode(err), Message: message})
+}
+
+// writeReadOnly rejects a request that would download from Nexus while the
+// server is in read-only mode.
+func writeReadOnly(w http.ResponseWriter) {
+	WriteAPIError(w, http.StatusServiceUnavailable, APIError{
+		Code:    CodeReadOnly,
+		Message: "Server is in read-only mode; only cached results and history are available",
+	})
+}
+
+// writeNoAPIKey rejects a request that needs Nexus while no API key is configured.
+func writeNoAPIKey(w http.ResponseWriter) {
+	WriteAPIError(w, http.StatusServiceUnavailable, APIError{
+		Code:    CodeNoAPIKey,
+		Message: "Nexus API key not configured. Please configure it in Settings.",
+	})
 }
 
 // WriteSuccess writes a JSON success response with a message.
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/cache"
+	"github.com/mod-troubleshooter/backend/internal/flight"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/revision"
+)
+
+// RevisionCompareResponse is the response from comparing two collection revisions.
+type RevisionCompareResponse struct {
+	*revision.Diff
+	From   int  `json:"from"`
+	To     int  `json:"to"`
+	Cached bool `json:"cached"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+}
+
+// RemovalPreviewResponse is the response from previewing the removal of a
+// mod from a collection revision.
+type RemovalPreviewResponse struct {
+	*revision.Removal
+	Revision int  `json:"revision"`
+	Cached   bool `json:"cached"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+}
+
+// RevisionSizeResponse is the disk space a collection revision needs.
+type RevisionSizeResponse struct {
+	*revision.SizeBudget
+	Revision int  `json:"revision"`
+	Cached   bool `json:"cached"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+}
+
+// RevisionHandler compares collection revisions.
+type RevisionHandler struct {
+	clientGetter NexusClientGetter
+	downloader   *archive.Downloader
+	extractor    *archive.Extractor
+	cache        *cache.Cache
+	sessions     *pipeline.Sessions
+	readOnly     bool
+	sevenZip     *archive.SevenZip
+	sandbox      pipeline.ArchiveReader
+	limiter      *pipeline.Limiter
+
+	// jobs shares in-flight comparisons between identical requests
+	jobs flight.Group[RevisionCompareResponse]
+}
+
+// RevisionHandlerConfig holds configuration for the RevisionHandler.
+type RevisionHandlerConfig struct {
+	ClientGetter NexusClientGetter
+	Downloader   *archive.Downloader
+	Extractor    *archive.Extractor
+	Cache        *cache.Cache
+	// Sessions shares downloads with analyses of the same collection revisions (optional).
+	Sessions *pipeline.Sessions
+	// ReadOnly serves cached results only, rejecting requests that would download from Nexus.
+	ReadOnly bool
+	// SevenZip lists archives the built-in extractor cannot read (optional).
+	SevenZip *archive.SevenZip
+	// Sandbox reads archives in worker processes (optional).
+	Sandbox pipeline.ArchiveReader
+	// Limiter bounds gathering work across analyses (optional).
+	Limiter *pipeline.Limiter
+}
+
+// NewRevisionHandler creates a new revision comparison handler.
+func NewRevisionHandler(cfg RevisionHandlerConfig) *RevisionHandler {
+	return &RevisionHandler{
+		clientGetter: cfg.ClientGetter,
+		downloader:   cfg.Downloader,
+		extractor:    cfg.Extractor,
+		cache:        cfg.Cache,
+		sessions:     cfg.Sessions,
+		readOnly:     cfg.ReadOnly,
+		sevenZip:     cfg.SevenZip,
+		sandbox:      cfg.Sandbox,
+		limiter:      cfg.Limiter,
+	}
+}
+
+// CompareRevisions handles GET /api/collections/{slug}/revisions/{from}/compare/{to}
+// Lists the mods that changed between two revisions and diffs the plugin
+// headers of updated mods, with a verdict on whether each change is safe
+// to take mid-playthrough.
+func (h *RevisionHandler) CompareRevisions(w http.ResponseWriter, r *http.Request) {
+	client := h.clientGetter.Get()
+	if client == nil && !h.readOnly {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+
+	from, err := strconv.Atoi(r.PathValue("from"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+	to, err := strconv.Atoi(r.PathValue("to"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+	if from == to {
+		WriteError(w, http.StatusBadRequest, "Revisions to compare must differ")
+		return
+	}
+
+	// Check cache
+	cacheKey := cache.RevisionDiffKey(slug, from, to)
+	if h.cache != nil {
+		var cachedResult RevisionCompareResponse
+		if err := h.cache.Get(ctx, cacheKey, &cachedResult); err == nil {
+			cachedResult.Cached = true
+			WriteResult(w, r, http.StatusOK, cachedResult)
+			return
+		}
+	}
+
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	// Concurrent requests for the same revisions share one comparison
+	response, err := h.jobs.Do(ctx, cacheKey, func(ctx context.Context) (RevisionCompareResponse, error) {
+		return h.compare(ctx, client, slug, from, to)
+	})
+	if err != nil {
+		writeJobError(w, err, "compare collection revisions")
+		return
+	}
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// PreviewRemoval handles GET /api/collections/{slug}/revisions/{revision}/removal?modId={modId}
+// Simulates removing a mod from a collection revision: the plugins that
+// would lose masters, the files whose winning copy would change, and
+// whether saves made with the mod would likely break.
+func (h *RevisionHandler) PreviewRemoval(w http.ResponseWriter, r *http.Request) {
+	client := h.clientGetter.Get()
+	if client == nil && !h.readOnly {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+	rev, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+	modID, err := strconv.Atoi(r.URL.Query().Get("modId"))
+	if err != nil || modID <= 0 {
+		WriteError(w, http.StatusBadRequest, "Valid mod ID is required")
+		return
+	}
+
+	cacheKey := cache.RemovalKey(slug, rev, modID)
+	if h.cache != nil {
+		var cachedResult RemovalPreviewResponse
+		if err := h.cache.Get(ctx, cacheKey, &cachedResult); err == nil {
+			cachedResult.Cached = true
+			WriteResult(w, r, http.StatusOK, cachedResult)
+			return
+		}
+	}
+
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	details, err := client.GetCollectionRevisionMods(ctx, slug, rev)
+	if err != nil {
+		handleNexusError(w, err, "fetch collection revision")
+		return
+	}
+	collection, err := client.GetCollection(ctx, slug)
+	if err != nil {
+		handleNexusError(w, err, "fetch collection")
+		return
+	}
+
+	sources := collectionSources(collection.Game.DomainName, details)
+	var removedID string
+	for _, src := range sources {
+		if src.NexusModID == modID {
+			removedID = src.ModID
+			break
+		}
+	}
+	if removedID == "" {
+		WriteError(w, http.StatusNotFound, "Mod is not part of the collection revision")
+		return
+	}
+
+	in, err := h.gather(ctx, client, slug, rev, sources, pipeline.InputManifests|pipeline.InputPluginHeaders)
+	if err != nil {
+		writeJobError(w, err, "preview mod removal")
+		return
+	}
+	removal, _ := revision.PreviewRemoval(in, removedID)
+
+	response := RemovalPreviewResponse{
+		Removal:  removal,
+		Revision: rev,
+		Warnings: in.Warnings(),
+	}
+	if h.cache != nil {
+		if err := h.cache.Set(ctx, cacheKey, response); err != nil {
+			log.Printf("Error caching result: %v", err)
+		}
+	}
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// GetRevisionSize handles GET /api/collections/{slug}/revisions/{revision}/size
+// Reports the disk space the revision needs: download and install size
+// per mod and per file type, so users short on space know what to trim.
+// Mods that cannot be listed are estimated from their Nexus file size.
+func (h *RevisionHandler) GetRevisionSize(w http.ResponseWriter, r *http.Request) {
+	client := h.clientGetter.Get()
+	if client == nil && !h.readOnly {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		WriteError(w, http.StatusBadRequest, "Collection slug is required")
+		return
+	}
+	rev, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+
+	cacheKey := cache.SizeKey(slug, rev)
+	if h.cache != nil {
+		var cachedResult RevisionSizeResponse
+		if err := h.cache.Get(ctx, cacheKey, &cachedResult); err == nil {
+			cachedResult.Cached = true
+			WriteResult(w, r, http.StatusOK, cachedResult)
+			return
+		}
+	}
+
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	details, err := client.GetCollectionRevisionMods(ctx, slug, rev)
+	if err != nil {
+		handleNexusError(w, err, "fetch collection revision")
+		return
+	}
+	collection, err := client.GetCollection(ctx, slug)
+	if err != nil {
+		handleNexusError(w, err, "fetch collection")
+		return
+	}
+
+	sources := collectionSources(collection.Game.DomainName, details)
+	in, err := h.gather(ctx, client, slug, rev, sources, pipeline.InputManifests)
+	if err != nil {
+		writeJobError(w, err, "compute collection size")
+		return
+	}
+
+	response := RevisionSizeResponse{
+		SizeBudget: revision.Size(in),
+		Revision:   rev,
+		Warnings:   in.Warnings(),
+	}
+	if h.cache != nil {
+		if err := h.cache.Set(ctx, cacheKey, response); err != nil {
+			log.Printf("Error caching result: %v", err)
+		}
+	}
+
+	WriteResult(w, r, http.StatusOK, response)
+}
+
+// compare fetches both revisions, downloads the changed mods in each and
+// diffs their plugins and scripts, caching the result.
+func (h *RevisionHandler) compare(ctx context.Context, client *nexus.Client, slug string, from, to int) (RevisionCompareResponse, error) {
+	fromDetails, err := client.GetCollectionRevisionMods(ctx, slug, from)
+	if err != nil {
+		return RevisionCompareResponse{}, fmt.Errorf("fetch collection revision: %w", err)
+	}
+	toDetails, err := client.GetCollectionRevisionMods(ctx, slug, to)
+	if err != nil {
+		return RevisionCompareResponse{}, fmt.Errorf("fetch collection revision: %w", err)
+	}
+
+	// Get the collection to determine the game
+	collection, err := client.GetCollection(ctx, slug)
+	if err != nil {
+		return RevisionCompareResponse{}, fmt.Errorf("fetch collection: %w", err)
+	}
+
+	gameDomain := collection.Game.DomainName
+	fromSources := collectionSources(gameDomain, fromDetails)
+	toSources := collectionSources(gameDomain, toDetails)
+
+	diff := revision.Compare(fromSources, toSources)
+	fromChanged, toChanged := diff.Sources(fromSources, toSources)
+
+	fromIn, err := h.gather(ctx, client, slug, from, fromChanged, pipeline.InputManifests|pipeline.InputPluginHeaders)
+	if err != nil {
+		return RevisionCompareResponse{}, err
+	}
+	toIn, err := h.gather(ctx, client, slug, to, toChanged, pipeline.InputManifests|pipeline.InputPluginHeaders)
+	if err != nil {
+		return RevisionCompareResponse{}, err
+	}
+
+	diff.AddDetails(fromIn, toIn)
+
+	response := RevisionCompareResponse{
+		Diff:     diff,
+		From:     from,
+		To:       to,
+		Warnings: append(fromIn.Warnings(), toIn.Warnings()...),
+	}
+
+	// Cache the result
+	if h.cache != nil {
+		if err := h.cache.Set(ctx, cache.RevisionDiffKey(slug, from, to), response); err != nil {
+			log.Printf("Error caching result: %v", err)
+		}
+	}
+
+	return response, nil
+}
+
+// gather downloads the given mods of a revision, listing their files and,
+// if asked, parsing their plugin headers. Both are read during gathering,
+// so the downloads are released on return.
+func (h *RevisionHandler) gather(ctx context.Context, client *nexus.Client, slug string, rev int, sources []pipeline.Source, need pipeline.Input) (*pipeline.Inputs, error) {
+	session := h.sessions.Acquire(slug, rev)
+	defer session.Done()
+
+	gatherer := pipeline.NewGatherer(pipeline.GathererConfig{
+		Fetcher:   session.Fetcher(&nexusFetcher{client: client, downloader: h.downloader}),
+		Extractor: h.extractor,
+		SevenZip:  h.sevenZip,
+		Sandbox:   h.sandbox,
+		Limiter:   h.limiter,
+	})
+	in, release, err := gatherer.Gather(ctx, sources, need)
+	if err != nil {
+		return nil, gatherError(err, "Failed to extract plugin information")
+	}
+	release()
+	return in, nil
+}
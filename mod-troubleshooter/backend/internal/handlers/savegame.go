@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/cache"
+	"github.com/mod-troubleshooter/backend/internal/savegame"
+)
+
+// maxSaveUploadSize limits the size of uploaded save files.
+const maxSaveUploadSize = 256 * 1024 * 1024 // 256MB
+
+// SavegameCheckResponse is the response from a save game check.
+type SavegameCheckResponse struct {
+	Save  *savegame.SaveInfo    `json:"save"`
+	Check *savegame.CheckResult `json:"check"`
+}
+
+// SavegameHandler handles save game compatibility checks.
+type SavegameHandler struct {
+	cache *cache.Cache
+}
+
+// NewSavegameHandler creates a new save game handler.
+// The cache supplies plugin lists from earlier collection load order analyses.
+func NewSavegameHandler(c *cache.Cache) *SavegameHandler {
+	return &SavegameHandler{cache: c}
+}
+
+// CheckSavegame handles POST /api/savegame/check
+// Accepts a multipart form with the save file in "save" and the plugins to
+// compare against, either as a newline-separated "plugins" field or as
+// "slug" and "revision" of a collection whose load order was already analyzed.
+func (h *SavegameHandler) CheckSavegame(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSaveUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "Save file is too large")
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "Invalid multipart form")
+		return
+	}
+
+	file, _, err := r.FormFile("save")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Save file is required")
+		return
+	}
+	defer file.Close()
+
+	plugins, status, msg := h.pluginList(r)
+	if plugins == nil {
+		WriteError(w, status, msg)
+		return
+	}
+
+	info, err := savegame.Parse(file)
+	if err != nil {
+		switch {
+		case errors.Is(err, savegame.ErrUnknownFormat):
+			WriteError(w, http.StatusBadRequest, "Not a Skyrim or Fallout 4 save file")
+		case errors.Is(err, savegame.ErrUnsupportedCompression):
+			WriteError(w, http.StatusUnprocessableEntity, "Save file uses an unsupported compression format")
+		default:
+			log.Printf("Error parsing save file: %v", err)
+			WriteError(w, http.StatusBadRequest, "Failed to read save file")
+		}
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, SavegameCheckResponse{
+		Save:  info,
+		Check: savegame.Check(info, plugins),
+	})
+}
+
+// pluginList returns the plugins to compare against from the form.
+// On failure it returns nil with the status and message to report.
+func (h *SavegameHandler) pluginList(r *http.Request) ([]string, int, string) {
+	if raw := r.FormValue("plugins"); raw != "" {
+		plugins := []string{}
+		for _, line := range strings.Split(raw, "\n") {
+			// Accept plugins.txt lines, which mark active plugins with '*'
+			name := strings.TrimPrefix(strings.TrimSpace(line), "*")
+			if name == "" || strings.HasPrefix(name, "#") {
+				continue
+			}
+			plugins = append(plugins, name)
+		}
+		return plugins, 0, ""
+	}
+
+	slug := r.FormValue("slug")
+	if slug == "" {
+		return nil, http.StatusBadRequest, "Either plugins or a collection slug and revision is required"
+	}
+
+	revision, err := strconv.Atoi(r.FormValue("revision"))
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid revision number"
+	}
+
+	if h.cache == nil {
+		return nil, http.StatusNotFound, "No load order analysis available for this revision"
+	}
+
+	var loadOrder LoadOrderAnalyzeResponse
+	if err := h.cache.Get(r.Context(), cache.LoadOrderKey(slug, revision), &loadOrder); err != nil || loadOrder.AnalysisResult == nil {
+		return nil, http.StatusNotFound, "No load order analysis available for this revision. Analyze its load order first."
+	}
+
+	plugins := make([]string, 0, len(loadOrder.Plugins))
+	for _, p := range loadOrder.Plugins {
+		plugins = append(plugins, p.Filename)
+	}
+	return plugins, 0, ""
+}
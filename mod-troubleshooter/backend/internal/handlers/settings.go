@@ -2,24 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
 
 	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
 )
 
 // SettingsStore manages runtime settings with thread-safe access.
 type SettingsStore struct {
-	mu        sync.RWMutex
-	nexusKey  string
+	mu          sync.RWMutex
+	nexusKey    string
 	onKeyChange func(string) // Callback when API key changes
+	profile     pipeline.Profile
+	limiter     *pipeline.Limiter
 }
 
 // NewSettingsStore creates a new settings store with initial API key.
 func NewSettingsStore(initialKey string) *SettingsStore {
 	return &SettingsStore{
 		nexusKey: initialKey,
+		profile:  pipeline.ProfileStandard,
 	}
 }
 
@@ -49,16 +54,69 @@ func (s *SettingsStore) SetNexusAPIKey(key string) {
 	}
 }
 
+// GetAnalysisProfile returns the profile used by analyses that don't ask for one.
+func (s *SettingsStore) GetAnalysisProfile() pipeline.Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+// SetAnalysisProfile updates the default analysis profile.
+func (s *SettingsStore) SetAnalysisProfile(profile pipeline.Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile = profile
+}
+
+// SetLimiter sets the limiter whose concurrency the settings control.
+func (s *SettingsStore) SetLimiter(l *pipeline.Limiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limiter = l
+}
+
+// GetConcurrency returns the current analysis concurrency, or nil if no
+// limiter is set.
+func (s *SettingsStore) GetConcurrency() *pipeline.Concurrency {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.limiter == nil {
+		return nil
+	}
+	c := s.limiter.Concurrency()
+	return &c
+}
+
+// SetConcurrency updates the analysis concurrency. Zero fields keep their
+// current value. It does nothing if no limiter is set.
+func (s *SettingsStore) SetConcurrency(c pipeline.Concurrency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limiter != nil {
+		s.limiter.Set(c.WithDefaults(s.limiter.Concurrency()))
+	}
+}
+
 // Settings represents the user-configurable settings.
 type Settings struct {
-	NexusAPIKey   string `json:"nexusApiKey"`
-	HasNexusKey   bool   `json:"hasNexusKey"`
-	KeyConfigured bool   `json:"keyConfigured"`
+	NexusAPIKey     string           `json:"nexusApiKey"`
+	HasNexusKey     bool             `json:"hasNexusKey"`
+	KeyConfigured   bool             `json:"keyConfigured"`
+	AnalysisProfile pipeline.Profile `json:"analysisProfile"`
+	// Concurrency is how much gathering work analyses do at once.
+	Concurrency *pipeline.Concurrency `json:"concurrency,omitempty"`
 }
 
 // UpdateSettingsRequest is the request body for updating settings.
 type UpdateSettingsRequest struct {
 	NexusAPIKey string `json:"nexusApiKey"`
+	// AnalysisProfile changes the default analysis profile when set. A
+	// request setting only the profile leaves the API key alone.
+	AnalysisProfile *string `json:"analysisProfile,omitempty"`
+	// Concurrency changes the analysis concurrency when set; zero fields
+	// keep their current value. Like the profile, it leaves the API key
+	// alone when set on its own.
+	Concurrency *pipeline.Concurrency `json:"concurrency,omitempty"`
 }
 
 // SettingsHandler handles settings-related HTTP requests.
@@ -77,16 +135,19 @@ func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
 	key := h.store.GetNexusAPIKey()
 
 	settings := Settings{
-		NexusAPIKey:   maskAPIKey(key),
-		HasNexusKey:   key != "",
-		KeyConfigured: key != "",
+		NexusAPIKey:     maskAPIKey(key),
+		HasNexusKey:     key != "",
+		KeyConfigured:   key != "",
+		AnalysisProfile: h.store.GetAnalysisProfile(),
+		Concurrency:     h.store.GetConcurrency(),
 	}
 
 	WriteJSON(w, http.StatusOK, settings)
 }
 
 // UpdateSettings handles POST /api/settings
-// Updates the Nexus API key.
+// Updates the Nexus API key, the default analysis profile and the analysis
+// concurrency.
 func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 	var req UpdateSettingsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -94,6 +155,22 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var profile pipeline.Profile
+	if req.AnalysisProfile != nil {
+		var err error
+		if profile, err = pipeline.ParseProfile(*req.AnalysisProfile); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid analysis profile: %v", err))
+			return
+		}
+	}
+
+	if req.Concurrency != nil {
+		if err := req.Concurrency.Validate(); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid concurrency: %v", err))
+			return
+		}
+	}
+
 	// Trim whitespace from API key
 	apiKey := strings.TrimSpace(req.NexusAPIKey)
 
@@ -103,7 +180,17 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.store.SetNexusAPIKey(apiKey)
+	if profile != "" {
+		h.store.SetAnalysisProfile(profile)
+	}
+	if req.Concurrency != nil {
+		h.store.SetConcurrency(*req.Concurrency)
+	}
+	// An empty key clears it, unless only the profile or concurrency was
+	// being changed
+	if apiKey != "" || (req.AnalysisProfile == nil && req.Concurrency == nil) {
+		h.store.SetNexusAPIKey(apiKey)
+	}
 
 	WriteSuccess(w, "Settings updated successfully")
 }
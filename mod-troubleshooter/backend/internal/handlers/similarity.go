@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// maxSimilarityUploadSize limits the combined size of two uploaded archives.
+const maxSimilarityUploadSize = 2 * 1024 * 1024 * 1024 // 2GB
+
+// SimilarityRequest is the request body for comparing two file listings.
+type SimilarityRequest struct {
+	A SimilarityManifest `json:"a"`
+	B SimilarityManifest `json:"b"`
+}
+
+// SimilarityManifest is a file listing to compare.
+type SimilarityManifest struct {
+	Files []SimilarityFile `json:"files"`
+}
+
+// SimilarityFile is a file in a listing.
+type SimilarityFile struct {
+	// Path is relative to the archive root.
+	Path string `json:"path"`
+	// Size is the file size in bytes (optional).
+	Size int64 `json:"size,omitempty"`
+	// Hash is the SHA-256 of the file contents (optional).
+	Hash string `json:"hash,omitempty"`
+}
+
+// SimilarityResponse is the result of comparing two manifests.
+type SimilarityResponse struct {
+	manifest.Similarity
+	FilesA int `json:"filesA"`
+	FilesB int `json:"filesB"`
+}
+
+// SimilarityHandler handles manifest similarity comparisons.
+type SimilarityHandler struct {
+	extractor *manifest.Extractor
+}
+
+// NewSimilarityHandler creates a new similarity handler.
+func NewSimilarityHandler() *SimilarityHandler {
+	return &SimilarityHandler{extractor: manifest.NewExtractor()}
+}
+
+// CompareManifests handles POST /api/manifests/similarity
+// Accepts either two JSON file listings or a multipart form with two
+// archives, "a" and "b", which are hashed so renamed files still match.
+func (h *SimilarityHandler) CompareManifests(w http.ResponseWriter, r *http.Request) {
+	var a, b *manifest.Manifest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		var status int
+		var msg string
+		a, b, status, msg = h.manifestsFromUpload(w, r)
+		if a == nil {
+			WriteError(w, status, msg)
+			return
+		}
+	} else {
+		var req SimilarityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		a, b = req.A.toManifest(), req.B.toManifest()
+	}
+
+	if a.TotalCount == 0 || b.TotalCount == 0 {
+		WriteError(w, http.StatusBadRequest, "Both manifests must contain at least one file")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, SimilarityResponse{
+		Similarity: manifest.Compare(a, b),
+		FilesA:     a.TotalCount,
+		FilesB:     b.TotalCount,
+	})
+}
+
+// toManifest converts a request listing into a manifest.
+func (m SimilarityManifest) toManifest() *manifest.Manifest {
+	entries := make([]manifest.FileEntry, 0, len(m.Files))
+	for _, f := range m.Files {
+		if f.Path == "" {
+			continue
+		}
+		entry := manifest.NewFileEntry(f.Path, f.Size)
+		if f.Hash != "" {
+			entry.Hash = f.Hash
+		}
+		entries = append(entries, entry)
+	}
+	return manifest.NewManifest(entries)
+}
+
+// manifestsFromUpload lists the contents of the two uploaded archives.
+// On failure it returns nil manifests with the status and message to report.
+func (h *SimilarityHandler) manifestsFromUpload(w http.ResponseWriter, r *http.Request) (*manifest.Manifest, *manifest.Manifest, int, string) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSimilarityUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, nil, http.StatusRequestEntityTooLarge, "Upload is too large"
+		}
+		return nil, nil, http.StatusBadRequest, "Invalid multipart form"
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	manifests := make([]*manifest.Manifest, 0, 2)
+	for _, field := range []string{"a", "b"} {
+		file, _, err := r.FormFile(field)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, "Archives \"a\" and \"b\" are required"
+		}
+		m, err := h.hashUpload(r, file)
+		file.Close()
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, "Invalid or unsupported archive"
+		}
+		manifests = append(manifests, m)
+	}
+
+	return manifests[0], manifests[1], 0, ""
+}
+
+// hashUpload copies an uploaded archive to a temp file and lists it with content hashes.
+func (h *SimilarityHandler) hashUpload(r *http.Request, file multipart.File) (*manifest.Manifest, error) {
+	tmp, err := os.CreateTemp("", "similarity-*")
+	if err != nil {
+		log.Printf("Error creating temp file: %v", err)
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, file); err != nil {
+		return nil, err
+	}
+
+	return h.extractor.ExtractManifestWithHashes(r.Context(), tmp.Name(), "")
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mod-troubleshooter/backend/internal/stats"
+)
+
+// StatsHandler exposes locally collected usage statistics.
+type StatsHandler struct {
+	collector *stats.Collector
+}
+
+// NewStatsHandler creates a new stats handler.
+// A nil collector means stats are disabled.
+func NewStatsHandler(collector *stats.Collector) *StatsHandler {
+	return &StatsHandler{collector: collector}
+}
+
+// GetStats handles GET /api/stats
+// Returns usage statistics, or enabled=false when collection is turned off.
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, h.collector.Snapshot())
+}
+
+// ResetStats handles DELETE /api/stats
+// Clears all collected usage statistics.
+func (h *StatsHandler) ResetStats(w http.ResponseWriter, r *http.Request) {
+	if h.collector == nil {
+		WriteError(w, http.StatusNotFound, "Usage statistics are disabled. Set STATS_ENABLED=true to enable them.")
+		return
+	}
+
+	h.collector.Reset()
+	WriteSuccess(w, "Usage statistics reset")
+}
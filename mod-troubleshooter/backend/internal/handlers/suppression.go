@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/suppress"
+)
+
+// SuppressedFindings lists the findings hidden by suppressions.
+// Analysis responses only include it when showSuppressed=true is requested.
+type SuppressedFindings struct {
+	Conflicts []conflict.Conflict `json:"conflicts,omitempty"`
+	Issues    []loadorder.Issue   `json:"issues,omitempty"`
+}
+
+// SuppressionHandler manages suppression records and curator rule files.
+type SuppressionHandler struct {
+	store       *suppress.Store
+	fetchClient *http.Client
+}
+
+// NewSuppressionHandler creates a new suppression handler.
+func NewSuppressionHandler(store *suppress.Store) *SuppressionHandler {
+	return &SuppressionHandler{
+		store:       store,
+		fetchClient: suppress.NewRuleFileClient(30 * time.Second),
+	}
+}
+
+// AttachRuleFileRequest is the body of a curator rule file upload: either
+// the signed file itself, or the URL it is published at.
+type AttachRuleFileRequest struct {
+	suppress.SignedRuleFile
+	// URL is an https URL the curator controls to fetch the file from.
+	URL string `json:"url,omitempty"`
+}
+
+// ListSuppressions handles GET /api/suppressions
+// Returns every suppression, including expired and revoked ones, as an audit trail.
+func (h *SuppressionHandler) ListSuppressions(w http.ResponseWriter, r *http.Request) {
+	sups, err := h.store.List(r.Context())
+	if err != nil {
+		log.Printf("Error listing suppressions: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to list suppressions")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, sups)
+}
+
+// CreateSuppression handles POST /api/suppressions
+// Adds a suppression scoped to a path, rule or mod pair.
+func (h *SuppressionHandler) CreateSuppression(w http.ResponseWriter, r *http.Request) {
+	var req suppress.Suppression
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sup, err := h.store.Add(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, suppress.ErrInvalid) {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error storing suppression: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to store suppression")
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, sup)
+}
+
+// GetSuppression handles GET /api/suppressions/{id}
+// Returns a single suppression.
+func (h *SuppressionHandler) GetSuppression(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid suppression ID")
+		return
+	}
+
+	sup, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, suppress.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Suppression not found")
+			return
+		}
+		log.Printf("Error fetching suppression %d: %v", id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch suppression")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, sup)
+}
+
+// RevokeSuppression handles DELETE /api/suppressions/{id}?by=name
+// Stops a suppression from applying. The record is kept for the audit trail.
+func (h *SuppressionHandler) RevokeSuppression(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid suppression ID")
+		return
+	}
+
+	by := strings.TrimSpace(r.URL.Query().Get("by"))
+	if by == "" {
+		WriteError(w, http.StatusBadRequest, "The by parameter is required to revoke a suppression")
+		return
+	}
+
+	sup, err := h.store.Revoke(r.Context(), id, by)
+	if err != nil {
+		if errors.Is(err, suppress.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Suppression not found")
+			return
+		}
+		log.Printf("Error revoking suppression %d: %v", id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to revoke suppression")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, sup)
+}
+
+// GetRuleFile handles GET /api/collections/{slug}/curator-rules
+// Returns the curator rule file attached to a collection.
+func (h *SuppressionHandler) GetRuleFile(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	rules, err := h.store.RuleFile(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, suppress.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "No curator rule file is attached to this collection")
+			return
+		}
+		log.Printf("Error fetching curator rules for %q: %v", slug, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch curator rules")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, rules)
+}
+
+// AttachRuleFile handles PUT /api/collections/{slug}/curator-rules
+// Verifies a signed curator rule file, uploaded or fetched from a URL, and
+// attaches it to the collection. Its overrides are merged into the
+// suppressions of every later analysis of the collection.
+func (h *SuppressionHandler) AttachRuleFile(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	var req AttachRuleFileRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, suppress.MaxRuleFileSize)).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	signed := req.SignedRuleFile
+	if req.URL != "" {
+		var err error
+		signed, err = suppress.FetchRuleFile(r.Context(), h.fetchClient, req.URL)
+		if err != nil {
+			if errors.Is(err, suppress.ErrInvalid) {
+				WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			log.Printf("Error fetching curator rules for %q from %s: %v", slug, req.URL, err)
+			WriteError(w, http.StatusBadGateway, "Failed to fetch the rule file")
+			return
+		}
+	}
+
+	rules, err := h.store.AttachRuleFile(r.Context(), slug, signed, req.URL)
+	if err != nil {
+		switch {
+		case errors.Is(err, suppress.ErrInvalid), errors.Is(err, suppress.ErrSignature):
+			WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, suppress.ErrKeyMismatch):
+			WriteError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("Error attaching curator rules for %q: %v", slug, err)
+			WriteError(w, http.StatusInternalServerError, "Failed to attach curator rules")
+		}
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, rules)
+}
+
+// DetachRuleFile handles DELETE /api/collections/{slug}/curator-rules
+// Removes the curator rule file from a collection. The curator's key stays
+// pinned; only the operator can replace it, through CURATOR_KEYS_FILE.
+func (h *SuppressionHandler) DetachRuleFile(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	if err := h.store.DetachRuleFile(r.Context(), slug); err != nil {
+		if errors.Is(err, suppress.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "No curator rule file is attached to this collection")
+			return
+		}
+		log.Printf("Error detaching curator rules for %q: %v", slug, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to detach curator rules")
+		return
+	}
+
+	WriteSuccess(w, "Curator rules detached")
+}
+
+// activeSuppressions loads the suppressions that apply to a collection.
+// Errors are logged and treated as no suppressions so results are still served.
+func activeSuppressions(ctx context.Context, store *suppress.Store, slug string) *suppress.Set {
+	if store == nil {
+		return nil
+	}
+	sups, err := store.Active(ctx, slug)
+	if err != nil {
+		log.Printf("Warning: failed to load suppressions for %q: %v", slug, err)
+		return nil
+	}
+	return suppress.NewSet(sups)
+}
+
+// showSuppressed reports whether the request asks for suppressed findings to be listed.
+func showSuppressed(r *http.Request) bool {
+	return r.URL.Query().Get("showSuppressed") == "true"
+}
+
+// applySuppressions hides suppressed conflicts, recording how many were hidden
+// and, when show is set, which ones. The shared analysis result is not modified.
+func (resp *ConflictAnalyzeResponse) applySuppressions(set *suppress.Set, show bool) {
+	var hidden []conflict.Conflict
+	resp.AnalysisResult, hidden = set.FilterConflicts(resp.AnalysisResult)
+	resp.SuppressedFindings = len(hidden)
+	if show && len(hidden) > 0 {
+		resp.Suppressed = &SuppressedFindings{Conflicts: hidden}
+	}
+}
+
+// applySuppressions hides suppressed load order issues, recording how many were
+// hidden and, when show is set, which ones. The shared analysis result is not modified.
+func (resp *LoadOrderAnalyzeResponse) applySuppressions(set *suppress.Set, show bool) {
+	var hidden []loadorder.Issue
+	resp.AnalysisResult, hidden = set.FilterIssues(resp.AnalysisResult)
+	resp.SuppressedFindings = len(hidden)
+	if show && len(hidden) > 0 {
+		resp.Suppressed = &SuppressedFindings{Issues: hidden}
+	}
+}
+
+// applySuppressions hides suppressed findings from the conflict and load order
+// results. The results map is copied so the shared analysis is not modified.
+func (resp *CollectionAnalyzeResponse) applySuppressions(set *suppress.Set, show bool) {
+	if set.Len() == 0 {
+		return
+	}
+
+	var hidden SuppressedFindings
+	results := make(map[string]pipeline.Result, len(resp.Results))
+	for name, res := range resp.Results {
+		switch data := res.Data.(type) {
+		case *conflict.AnalysisResult:
+			res.Data, hidden.Conflicts = set.FilterConflicts(data)
+		case *loadorder.AnalysisResult:
+			res.Data, hidden.Issues = set.FilterIssues(data)
+		}
+		results[name] = res
+	}
+	resp.Results = results
+
+	resp.SuppressedFindings = len(hidden.Conflicts) + len(hidden.Issues)
+	if show && resp.SuppressedFindings > 0 {
+		resp.Suppressed = &hidden
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/watch"
+)
+
+// WatchResponse is a watch as the API returns it. Target tokens are
+// write-only: responses only tell whether one is stored, and a watch
+// submitted again without them keeps the stored ones.
+type WatchResponse struct {
+	watch.Watch
+	Targets []WatchTargetResponse `json:"targets"`
+}
+
+// WatchTargetResponse is a notification target without its token.
+type WatchTargetResponse struct {
+	Type     watch.TargetType `json:"type"`
+	URL      string           `json:"url,omitempty"`
+	HasToken bool             `json:"hasToken,omitempty"`
+	To       []string         `json:"to,omitempty"`
+}
+
+// newWatchResponse redacts the tokens of a watch.
+func newWatchResponse(wt watch.Watch) WatchResponse {
+	resp := WatchResponse{Watch: wt, Targets: make([]WatchTargetResponse, len(wt.Targets))}
+	for i, t := range wt.Targets {
+		resp.Targets[i] = WatchTargetResponse{Type: t.Type, URL: t.URL, HasToken: t.Token != "", To: t.To}
+	}
+	return resp
+}
+
+// WatchHandler manages the collections monitored for new revisions.
+type WatchHandler struct {
+	store      *watch.Store
+	dispatcher *watch.Dispatcher
+	analyzers  map[string]bool
+}
+
+// WatchHandlerConfig holds configuration for the watch handler.
+type WatchHandlerConfig struct {
+	Store *watch.Store
+	// Dispatcher sends notifications to watch targets.
+	Dispatcher *watch.Dispatcher
+	// Analyzers lists the analyzer names a watch may request.
+	Analyzers []string
+}
+
+// NewWatchHandler creates a new watch handler.
+func NewWatchHandler(cfg WatchHandlerConfig) *WatchHandler {
+	analyzers := make(map[string]bool, len(cfg.Analyzers))
+	for _, name := range cfg.Analyzers {
+		analyzers[name] = true
+	}
+	return &WatchHandler{store: cfg.Store, dispatcher: cfg.Dispatcher, analyzers: analyzers}
+}
+
+// ListWatches handles GET /api/watches
+// Returns all watched collections with their options.
+func (h *WatchHandler) ListWatches(w http.ResponseWriter, r *http.Request) {
+	watches, err := h.store.List(r.Context())
+	if err != nil {
+		log.Printf("Error listing watches: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to list watches")
+		return
+	}
+	resp := make([]WatchResponse, len(watches))
+	for i, wt := range watches {
+		resp[i] = newWatchResponse(wt)
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// GetWatch handles GET /api/watches/{slug}
+// Returns the watch for a single collection.
+func (h *WatchHandler) GetWatch(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	wt, err := h.store.Get(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, watch.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Watch not found")
+			return
+		}
+		log.Printf("Error fetching watch %s: %v", slug, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch watch")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, newWatchResponse(*wt))
+}
+
+// PutWatch handles POST /api/watches
+// Adds a watch or replaces the options of an existing one with the same slug.
+func (h *WatchHandler) PutWatch(w http.ResponseWriter, r *http.Request) {
+	var req watch.Watch
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Slug = strings.TrimSpace(req.Slug)
+
+	for _, name := range req.Analyses {
+		if !h.analyzers[name] {
+			WriteError(w, http.StatusBadRequest, "Unknown analysis: "+name)
+			return
+		}
+	}
+
+	// Email targets are only accepted when the server can send them
+	for _, t := range req.Targets {
+		if t.Type != watch.TargetEmail {
+			continue
+		}
+		if _, err := h.dispatcher.Notifier(t); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	wt, created, err := h.store.Put(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, watch.ErrInvalid) {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error storing watch %s: %v", req.Slug, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to store watch")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	WriteJSON(w, status, newWatchResponse(*wt))
+}
+
+// DeleteWatch handles DELETE /api/watches/{slug}
+// Stops watching a collection.
+func (h *WatchHandler) DeleteWatch(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	if err := h.store.Delete(r.Context(), slug); err != nil {
+		if errors.Is(err, watch.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Watch not found")
+			return
+		}
+		log.Printf("Error deleting watch %s: %v", slug, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to delete watch")
+		return
+	}
+
+	WriteSuccess(w, "Watch deleted")
+}
+
+// TestWatch handles POST /api/watches/{slug}/test
+// Sends a test notification to every target of a watch and reports which
+// ones received it.
+func (h *WatchHandler) TestWatch(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	wt, err := h.store.Get(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, watch.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "Watch not found")
+			return
+		}
+		log.Printf("Error fetching watch %s: %v", slug, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to fetch watch")
+		return
+	}
+
+	deliveries := h.dispatcher.Notify(r.Context(), wt, watch.Notification{
+		Slug:    wt.Slug,
+		Title:   "Mod Troubleshooter test notification",
+		Message: "Notifications for collection " + wt.Slug + " will be sent here.",
+	})
+
+	WriteJSON(w, http.StatusOK, deliveries)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/watch"
+)
+
+func TestWatchHandler_HidesTokens(t *testing.T) {
+	store, err := watch.New(watch.Config{DBPath: filepath.Join(t.TempDir(), "watches.db")})
+	if err != nil {
+		t.Fatalf("failed to create watch store: %v", err)
+	}
+	defer store.Close()
+
+	h := NewWatchHandler(WatchHandlerConfig{Store: store, Dispatcher: watch.NewDispatcher(watch.DispatcherConfig{})})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/watches", h.ListWatches)
+	mux.HandleFunc("POST /api/watches", h.PutWatch)
+	mux.HandleFunc("GET /api/watches/{slug}", h.GetWatch)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/watches", strings.NewReader(body)))
+		return rec
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := put(`{"slug": "abc123", "targets": [{"type": "gotify", "url": "https://gotify.example.org", "token": "s3cret-token"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	for _, rec := range []*httptest.ResponseRecorder{rec, get("/api/watches"), get("/api/watches/abc123")} {
+		if strings.Contains(rec.Body.String(), "s3cret-token") || !strings.Contains(rec.Body.String(), `"hasToken":true`) {
+			t.Errorf("expected the token to be redacted, got %s", rec.Body)
+		}
+	}
+
+	// Sending the watch back as returned keeps its token
+	if rec := put(`{"slug": "abc123", "analyses": [], "targets": [{"type": "gotify", "url": "https://gotify.example.org", "hasToken": true}]}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	wt, err := store.Get(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(wt.Targets) != 1 || wt.Targets[0].Token != "s3cret-token" {
+		t.Errorf("expected the stored token to be kept, got %+v", wt.Targets)
+	}
+}
@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/nexus"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/revision"
+	"github.com/mod-troubleshooter/backend/internal/workspace"
+)
+
+// WorkspaceRequest is the request body for importing or replacing a
+// workspace. Mods and plugins are given directly, or as the files a mod
+// manager writes, which are parsed into them.
+type WorkspaceRequest struct {
+	Name string `json:"name"`
+	// Game is the Nexus game domain of the setup.
+	Game    string             `json:"game"`
+	Mods    []workspace.Mod    `json:"mods,omitempty"`
+	Plugins []workspace.Plugin `json:"plugins,omitempty"`
+	// PluginsTxt is the contents of plugins.txt, replacing Plugins.
+	PluginsTxt string `json:"pluginsTxt,omitempty"`
+	// ModlistTxt is the contents of a Mod Organizer 2 modlist.txt, replacing Mods.
+	ModlistTxt string `json:"modlistTxt,omitempty"`
+	// VortexState is a Vortex state backup, replacing Mods.
+	VortexState json.RawMessage `json:"vortexState,omitempty"`
+	// VortexGame is Vortex's ID for the game, such as "skyrimse"; only
+	// needed when the backup holds several games.
+	VortexGame string `json:"vortexGame,omitempty"`
+}
+
+// workspace parses the request into a workspace.
+func (req *WorkspaceRequest) workspace() (workspace.Workspace, error) {
+	w := workspace.Workspace{
+		Name:    req.Name,
+		Game:    strings.ToLower(strings.TrimSpace(req.Game)),
+		Mods:    req.Mods,
+		Plugins: req.Plugins,
+	}
+	if req.PluginsTxt != "" {
+		plugins, err := workspace.ParsePluginsTxt(strings.NewReader(req.PluginsTxt))
+		if err != nil {
+			return w, err
+		}
+		w.Plugins = plugins
+	}
+	if req.ModlistTxt != "" && len(req.VortexState) > 0 {
+		return w, fmt.Errorf("%w: give either modlistTxt or vortexState, not both", workspace.ErrInvalid)
+	}
+	if req.ModlistTxt != "" {
+		mods, err := workspace.ParseModlist(strings.NewReader(req.ModlistTxt))
+		if err != nil {
+			return w, err
+		}
+		w.Mods = mods
+	}
+	if len(req.VortexState) > 0 {
+		mods, err := workspace.ParseVortex(req.VortexState, req.VortexGame)
+		if err != nil {
+			return w, err
+		}
+		w.Mods = mods
+	}
+	return w, nil
+}
+
+// WorkspacePreviewRequest is the request body for previewing a mod in a workspace.
+type WorkspacePreviewRequest struct {
+	// NexusModID is the mod on Nexus.
+	NexusModID int `json:"nexusModId"`
+	// FileID is the file on Nexus (optional). Without it the mod's current
+	// main file is used.
+	FileID int `json:"fileId,omitempty"`
+	// ModName is the display name of the mod (optional). Without it the
+	// file's name is used.
+	ModName string `json:"modName,omitempty"`
+	// Password opens the archive if it is encrypted (optional).
+	Password string `json:"password,omitempty"`
+}
+
+// WorkspacePreviewResponse is the response from previewing a mod in a workspace.
+type WorkspacePreviewResponse struct {
+	*workspace.AddPreview
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+}
+
+// WorkspaceRemovalRequest is the request body for previewing the removal of
+// a mod from a workspace.
+type WorkspaceRemovalRequest struct {
+	// NexusModID is the mod on Nexus, for mods installed from there.
+	NexusModID int `json:"nexusModId,omitempty"`
+	// ModName is the mod's name in the workspace, used without a Nexus mod ID.
+	ModName string `json:"modName,omitempty"`
+}
+
+// WorkspaceRemovalResponse is the response from previewing the removal of a
+// mod from a workspace.
+type WorkspaceRemovalResponse struct {
+	*revision.Removal
+	// Unlisted names enabled mods whose files are not known, so their
+	// dependencies on the removed mod could not be checked.
+	Unlisted []string `json:"unlisted,omitempty"`
+	// Warnings lists mods whose data was incomplete, making the result partial.
+	Warnings []pipeline.Warning `json:"warnings,omitempty"`
+}
+
+// WorkspaceHandler manages users' own mod setups.
+type WorkspaceHandler struct {
+	store        *workspace.Store
+	clientGetter NexusClientGetter
+	downloader   *archive.Downloader
+	extractor    *archive.Extractor
+	readOnly     bool
+	sevenZip     *archive.SevenZip
+	sandbox      pipeline.ArchiveReader
+	limiter      *pipeline.Limiter
+	previews     bool
+}
+
+// WorkspaceHandlerConfig holds configuration for the workspace handler.
+type WorkspaceHandlerConfig struct {
+	Store        *workspace.Store
+	ClientGetter NexusClientGetter
+	Downloader   *archive.Downloader
+	Extractor    *archive.Extractor
+	// ReadOnly rejects previews, which download from Nexus.
+	ReadOnly bool
+	// SevenZip lists archives the built-in extractor cannot read (optional).
+	SevenZip *archive.SevenZip
+	// Sandbox reads archives in worker processes (optional).
+	Sandbox pipeline.ArchiveReader
+	// Limiter bounds gathering work across analyses (optional).
+	Limiter *pipeline.Limiter
+	// ContentPreviews lists the workspace's mods from their Nexus content
+	// preview instead of downloading them.
+	ContentPreviews bool
+}
+
+// NewWorkspaceHandler creates a new workspace handler.
+func NewWorkspaceHandler(cfg WorkspaceHandlerConfig) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		store:        cfg.Store,
+		clientGetter: cfg.ClientGetter,
+		downloader:   cfg.Downloader,
+		extractor:    cfg.Extractor,
+		readOnly:     cfg.ReadOnly,
+		sevenZip:     cfg.SevenZip,
+		sandbox:      cfg.Sandbox,
+		limiter:      cfg.Limiter,
+		previews:     cfg.ContentPreviews,
+	}
+}
+
+// ListWorkspaces handles GET /api/workspaces
+// Returns all stored workspaces.
+func (h *WorkspaceHandler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	workspaces, err := h.store.List(r.Context())
+	if err != nil {
+		log.Printf("Error listing workspaces: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to list workspaces")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, workspaces)
+}
+
+// CreateWorkspace handles POST /api/workspaces
+// Imports a mod setup once, so later questions about it are answered
+// without uploading it again.
+func (h *WorkspaceHandler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var req WorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ws, err := req.workspace()
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := h.store.Create(r.Context(), ws)
+	if err != nil {
+		if errors.Is(err, workspace.ErrInvalid) {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error storing workspace: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to store workspace")
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, created)
+}
+
+// GetWorkspace handles GET /api/workspaces/{id}
+// Returns a single workspace.
+func (h *WorkspaceHandler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := workspaceID(w, r)
+	if !ok {
+		return
+	}
+
+	ws, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		writeWorkspaceError(w, err, id, "fetch")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, ws)
+}
+
+// UpdateWorkspace handles PUT /api/workspaces/{id}
+// Replaces a workspace with a fresh import, such as after installing mods
+// outside of the tool.
+func (h *WorkspaceHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := workspaceID(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ws, err := req.workspace()
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ws.ID = id
+
+	updated, err := h.store.Update(r.Context(), ws)
+	if err != nil {
+		writeWorkspaceError(w, err, id, "store")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, updated)
+}
+
+// DeleteWorkspace handles DELETE /api/workspaces/{id}
+// Removes a workspace.
+func (h *WorkspaceHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := workspaceID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(r.Context(), id); err != nil {
+		writeWorkspaceError(w, err, id, "delete")
+		return
+	}
+
+	WriteSuccess(w, "Workspace deleted")
+}
+
+// PreviewAdd handles POST /api/workspaces/{id}/preview
+// Shows the conflicts, load order issues and FOMOD choices a mod would
+// introduce into a workspace, before the user installs it. Files of the
+// workspace's mods are looked up once and kept with the workspace.
+func (h *WorkspaceHandler) PreviewAdd(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		writeReadOnly(w)
+		return
+	}
+
+	client := h.clientGetter.Get()
+	if client == nil {
+		writeNoAPIKey(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	id, ok := workspaceID(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorkspacePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.NexusModID <= 0 {
+		WriteError(w, http.StatusBadRequest, "Valid mod ID is required")
+		return
+	}
+
+	ws, err := h.store.Get(ctx, id)
+	if err != nil {
+		writeWorkspaceError(w, err, id, "fetch")
+		return
+	}
+
+	src, err := previewSource(ctx, client, ws.Game, req)
+	if err != nil {
+		handleFomodError(w, err)
+		return
+	}
+
+	nf := &nexusFetcher{client: client, downloader: h.downloader}
+	warnings, err := h.addGathered(ctx, nf, ws, pipeline.InputManifests)
+	if err != nil {
+		writeJobError(w, err, "list workspace mods")
+		return
+	}
+
+	in, release, err := h.gatherer(nf).Gather(ctx, []pipeline.Source{src}, pipeline.InputManifests|pipeline.InputPluginHeaders|pipeline.InputArchives)
+	if err != nil {
+		writeJobError(w, gatherError(err, "Failed to download mod"), "preview mod")
+		return
+	}
+	defer release()
+
+	mod := in.Mods[0]
+	if mod.Manifest == nil && len(mod.Plugins) == 0 && mod.Error != "" {
+		switch {
+		case mod.Unavailable:
+			WriteError(w, http.StatusNotFound, "Mod file is no longer available")
+		case mod.Infected:
+			WriteError(w, http.StatusUnprocessableEntity, "The mod archive was flagged by the virus scanner and quarantined")
+		default:
+			log.Printf("Error downloading mod %s for preview: %s", mod.ModID, mod.Error)
+			WriteError(w, http.StatusBadGateway, "Failed to download mod archive")
+		}
+		return
+	}
+
+	preview, err := workspace.PreviewAdd(ctx, ws, mod)
+	if err != nil {
+		log.Printf("Error previewing mod %s in workspace %d: %v", mod.ModID, id, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to preview mod")
+		return
+	}
+	if mod.ArchivePath != "" {
+		data, err := pipeline.FomodFromArchive(ctx, h.extractor, mod.ArchivePath, mod.Password)
+		if err != nil {
+			log.Printf("Warning: could not analyze FOMOD for mod %s: %v", mod.ModID, err)
+		}
+		preview.Fomod = data
+	}
+
+	WriteJSON(w, http.StatusOK, WorkspacePreviewResponse{
+		AddPreview: preview,
+		Warnings:   append(warnings, in.Warnings()...),
+	})
+}
+
+// PreviewRemove handles POST /api/workspaces/{id}/preview/remove
+// Simulates removing a mod from a workspace: the plugins that would lose
+// masters, the files whose winning copy would change, and whether saves
+// made with the mod would likely break.
+func (h *WorkspaceHandler) PreviewRemove(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := workspaceID(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorkspaceRemovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.NexusModID <= 0 && strings.TrimSpace(req.ModName) == "" {
+		WriteError(w, http.StatusBadRequest, "Mod ID or name is required")
+		return
+	}
+
+	ws, err := h.store.Get(ctx, id)
+	if err != nil {
+		writeWorkspaceError(w, err, id, "fetch")
+		return
+	}
+
+	mod, ok := ws.Find(req.ModName, req.NexusModID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Mod is not part of the workspace")
+		return
+	}
+	if !mod.Enabled {
+		WriteError(w, http.StatusBadRequest, "Mod is not enabled in the workspace")
+		return
+	}
+
+	// Mods are only downloaded the first time their plugins are needed
+	need := pipeline.InputManifests | pipeline.InputPluginHeaders
+	var warnings []pipeline.Warning
+	if len(ws.Sources(need)) > 0 {
+		if h.readOnly {
+			writeReadOnly(w)
+			return
+		}
+		client := h.clientGetter.Get()
+		if client == nil {
+			writeNoAPIKey(w)
+			return
+		}
+		nf := &nexusFetcher{client: client, downloader: h.downloader}
+		if warnings, err = h.addGathered(ctx, nf, ws, need); err != nil {
+			writeJobError(w, err, "read workspace mods")
+			return
+		}
+	}
+
+	in := ws.Inputs()
+	removal, _ := revision.PreviewRemoval(in, mod.Key())
+
+	response := WorkspaceRemovalResponse{Removal: removal, Warnings: warnings}
+	for _, m := range in.Mods {
+		if m.Manifest == nil && m.ModID != mod.Key() {
+			response.Unlisted = append(response.Unlisted, m.ModName)
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// addGathered gathers the needed inputs of the workspace's mods that are
+// not known yet and stores them with the workspace.
+func (h *WorkspaceHandler) addGathered(ctx context.Context, nf *nexusFetcher, ws *workspace.Workspace, need pipeline.Input) ([]pipeline.Warning, error) {
+	sources := ws.Sources(need)
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	in, release, err := h.gatherer(nf).Gather(ctx, sources, need)
+	if err != nil {
+		return nil, gatherError(err, "Failed to read workspace mods")
+	}
+	release()
+
+	if ws.AddGathered(in, need) {
+		if err := h.store.SaveGathered(ctx, ws); err != nil {
+			log.Printf("Error storing mod files of workspace %d: %v", ws.ID, err)
+		}
+	}
+	return in.Warnings(), nil
+}
+
+// gatherer creates a pipeline gatherer that downloads through nf, listing
+// archives from their content previews first when those are enabled.
+func (h *WorkspaceHandler) gatherer(nf *nexusFetcher) *pipeline.Gatherer {
+	return pipeline.NewGatherer(pipeline.GathererConfig{
+		Fetcher:   nf,
+		Extractor: h.extractor,
+		SevenZip:  h.sevenZip,
+		Sandbox:   h.sandbox,
+		Limiter:   h.limiter,
+		Previewer: previewer(nf, h.previews),
+	})
+}
+
+// previewSource looks up the mod file to preview, using the mod's current
+// main file unless one is given.
+func previewSource(ctx context.Context, client *nexus.Client, gameDomain string, req WorkspacePreviewRequest) (pipeline.Source, error) {
+	var file nexus.FileDetails
+	if req.FileID > 0 {
+		details, err := client.GetModFile(ctx, gameDomain, req.NexusModID, req.FileID)
+		if err != nil {
+			return pipeline.Source{}, err
+		}
+		file = *details
+	} else {
+		var err error
+		if file, err = mainFile(ctx, client, gameDomain, req.NexusModID); err != nil {
+			return pipeline.Source{}, err
+		}
+	}
+
+	name := strings.TrimSpace(req.ModName)
+	if name == "" {
+		name = file.Name
+	}
+	return pipeline.Source{
+		ModID:      sourceModID(req.NexusModID, file.FileID),
+		ModName:    name,
+		Filename:   file.FileName,
+		Game:       gameDomain,
+		NexusModID: req.NexusModID,
+		FileID:     file.FileID,
+		Version:    file.Version,
+		Password:   req.Password,
+	}, nil
+}
+
+// workspaceID parses the workspace ID from the path, writing an error
+// response if it is invalid.
+func workspaceID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		WriteError(w, http.StatusBadRequest, "Invalid workspace ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeWorkspaceError writes the response for a failed store operation.
+func writeWorkspaceError(w http.ResponseWriter, err error, id int64, action string) {
+	switch {
+	case errors.Is(err, workspace.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "Workspace not found")
+	case errors.Is(err, workspace.ErrInvalid):
+		WriteError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Error during workspace %d %s: %v", id, action, err)
+		WriteError(w, http.StatusInternalServerError, "Failed to "+action+" workspace")
+	}
+}
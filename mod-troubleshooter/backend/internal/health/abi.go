@@ -0,0 +1,382 @@
+package health
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errNotPE is returned for data that is not a Windows DLL.
+var errNotPE = errors.New("not a PE file")
+
+// Exports SKSE looks for in a plugin. Plugins for runtimes before 1.6
+// export a query function; plugins for 1.6 and later export a version data
+// struct instead, and multi-target plugins export both.
+const (
+	skseQueryExport   = "SKSEPlugin_Query"
+	skseVersionExport = "SKSEPlugin_Version"
+)
+
+// Layout of SKSEPluginVersionData, the struct behind SKSEPlugin_Version.
+const (
+	skseVersionDataSize         = 848
+	skseVersionNameOffset       = 8
+	skseVersionNameSize         = 256
+	skseVersionIndependenceOff  = 776
+	skseCompatibleVersionsOff   = 780
+	skseCompatibleVersionsCount = 16
+)
+
+// Version independence flags of SKSEPluginVersionData. Plugins that look
+// up game addresses through Address Library or by signature work on every
+// runtime from 1.6.629 on, rather than only those they list.
+const (
+	skseIndependentAddressLibrary = 1 << 0
+	skseIndependentSignatures     = 1 << 1
+)
+
+// Runtime families of Skyrim, which script extender plugins are built for.
+const (
+	RuntimeLE = "Legendary Edition"
+	RuntimeSE = "Special Edition before 1.6"
+	RuntimeAE = "1.6 and later"
+)
+
+// PluginABI is what a script extender plugin's PE headers say about the
+// game binary it was built for.
+type PluginABI struct {
+	// Filename is the DLL's name as the mod spells it.
+	Filename string `json:"filename"`
+	// Machine is "x64" or "x86"; Skyrim LE is 32-bit, SE and later 64-bit.
+	Machine string `json:"machine"`
+	// Linker is the version of the linker that built the DLL, such as "14.38".
+	Linker string `json:"linker"`
+	// CRuntime lists the C and C++ runtime DLLs it links against.
+	CRuntime []string `json:"cRuntime,omitempty"`
+	// Legacy is true when the DLL exports SKSEPlugin_Query, which script
+	// extenders before 1.6 load plugins through.
+	Legacy bool `json:"legacy,omitempty"`
+	// VersionData is true when the DLL exports SKSEPlugin_Version, which
+	// script extenders for 1.6 and later require.
+	VersionData bool `json:"versionData,omitempty"`
+	// Name and Version are the plugin's name and version from its version data.
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	// AddressIndependent is true when the plugin declares that it works on
+	// every runtime from 1.6.629 on.
+	AddressIndependent bool `json:"addressIndependent,omitempty"`
+	// Runtimes are the game runtimes the version data lists as compatible.
+	Runtimes []string `json:"runtimes,omitempty"`
+}
+
+// NativePlugin is a script extender plugin and the mod that ships it.
+type NativePlugin struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	PluginABI
+}
+
+// Families returns the runtime families the plugin can be loaded on.
+func (p *PluginABI) Families() []string {
+	if p.Machine == "x86" {
+		return []string{RuntimeLE}
+	}
+	var families []string
+	if p.Legacy {
+		families = append(families, RuntimeSE)
+	}
+	if p.VersionData {
+		families = append(families, RuntimeAE)
+	}
+	return families
+}
+
+// Supports reports whether the plugin loads on a game runtime.
+func (p *PluginABI) Supports(runtime string) bool {
+	family := RuntimeFamily(runtime)
+	if !containsString(p.Families(), family) {
+		return false
+	}
+	if family != RuntimeAE || p.AddressIndependent || len(p.Runtimes) == 0 {
+		return true
+	}
+	for _, r := range p.Runtimes {
+		if SameVersion(r, runtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// RuntimeFamily returns the family of a Skyrim runtime version, or "" if
+// the version is not one.
+func RuntimeFamily(runtime string) string {
+	parts := strings.Split(normalizeVersion(runtime), ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return ""
+	}
+	switch parts[1] {
+	case "9":
+		return RuntimeLE
+	case "5":
+		return RuntimeSE
+	case "6":
+		return RuntimeAE
+	default:
+		return ""
+	}
+}
+
+// ParsePluginABI reads the PE headers and exports of a script extender
+// plugin.
+func ParsePluginABI(filename string, data []byte) (*PluginABI, error) {
+	f, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, errNotPE
+	}
+	defer f.Close()
+
+	abi := &PluginABI{Filename: filename}
+	switch f.Machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		abi.Machine = "x64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		abi.Machine = "x86"
+	default:
+		abi.Machine = fmt.Sprintf("0x%x", f.Machine)
+	}
+
+	var exportDir pe.DataDirectory
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader64:
+		abi.Linker = fmt.Sprintf("%d.%d", oh.MajorLinkerVersion, oh.MinorLinkerVersion)
+		if oh.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_EXPORT {
+			exportDir = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT]
+		}
+	case *pe.OptionalHeader32:
+		abi.Linker = fmt.Sprintf("%d.%d", oh.MajorLinkerVersion, oh.MinorLinkerVersion)
+		if oh.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_EXPORT {
+			exportDir = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT]
+		}
+	}
+
+	if libs, err := f.ImportedLibraries(); err == nil {
+		for _, lib := range libs {
+			if isCRuntime(lib) {
+				abi.CRuntime = append(abi.CRuntime, lib)
+			}
+		}
+		sort.Strings(abi.CRuntime)
+	}
+
+	exports := peExports(f, exportDir)
+	if _, ok := exports[skseQueryExport]; ok {
+		abi.Legacy = true
+	}
+	if rva, ok := exports[skseVersionExport]; ok {
+		abi.VersionData = true
+		if vd := readRVA(f, rva, skseVersionDataSize); vd != nil {
+			readVersionData(abi, vd)
+		}
+	}
+	return abi, nil
+}
+
+// readVersionData fills in the plugin's SKSEPluginVersionData.
+func readVersionData(abi *PluginABI, vd []byte) {
+	abi.Version = unpackVersion(binary.LittleEndian.Uint32(vd[4:]), false)
+	name := vd[skseVersionNameOffset : skseVersionNameOffset+skseVersionNameSize]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	abi.Name = string(name)
+
+	flags := binary.LittleEndian.Uint32(vd[skseVersionIndependenceOff:])
+	abi.AddressIndependent = flags&(skseIndependentAddressLibrary|skseIndependentSignatures) != 0
+	for i := 0; i < skseCompatibleVersionsCount; i++ {
+		v := binary.LittleEndian.Uint32(vd[skseCompatibleVersionsOff+4*i:])
+		if v == 0 {
+			break
+		}
+		abi.Runtimes = append(abi.Runtimes, unpackVersion(v, true))
+	}
+}
+
+// unpackVersion unpacks a version packed as SKSE's MAKE_EXE_VERSION does:
+// 8 bits major, 8 bits minor, 12 bits build and 4 bits sub. The sub
+// version is only included when asked for.
+func unpackVersion(v uint32, sub bool) string {
+	s := fmt.Sprintf("%d.%d.%d", v>>24, (v>>16)&0xff, (v>>4)&0xfff)
+	if sub {
+		s += "." + strconv.Itoa(int(v&0xf))
+	}
+	return s
+}
+
+// isCRuntime reports whether an imported DLL is part of the C or C++ runtime.
+func isCRuntime(lib string) bool {
+	lib = strings.ToLower(lib)
+	for _, prefix := range []string{"vcruntime", "msvcp", "msvcr", "ucrtbase", "api-ms-win-crt-"} {
+		if strings.HasPrefix(lib, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// peExports returns the RVAs of the named exports of a PE file.
+func peExports(f *pe.File, dir pe.DataDirectory) map[string]uint32 {
+	exports := make(map[string]uint32)
+	if dir.VirtualAddress == 0 || dir.Size < 40 {
+		return exports
+	}
+	hdr := readRVA(f, dir.VirtualAddress, 40)
+	if hdr == nil {
+		return exports
+	}
+	numFuncs := binary.LittleEndian.Uint32(hdr[20:])
+	numNames := binary.LittleEndian.Uint32(hdr[24:])
+	if numFuncs > 1<<16 || numNames > 1<<16 {
+		return exports
+	}
+	funcs := readRVA(f, binary.LittleEndian.Uint32(hdr[28:]), 4*numFuncs)
+	names := readRVA(f, binary.LittleEndian.Uint32(hdr[32:]), 4*numNames)
+	ordinals := readRVA(f, binary.LittleEndian.Uint32(hdr[36:]), 2*numNames)
+	if funcs == nil || names == nil || ordinals == nil {
+		return exports
+	}
+
+	for i := uint32(0); i < numNames; i++ {
+		name := readCString(f, binary.LittleEndian.Uint32(names[4*i:]))
+		ordinal := uint32(binary.LittleEndian.Uint16(ordinals[2*i:]))
+		if name == "" || ordinal >= numFuncs {
+			continue
+		}
+		exports[name] = binary.LittleEndian.Uint32(funcs[4*ordinal:])
+	}
+	return exports
+}
+
+// readRVA reads n bytes at a relative virtual address, or returns nil if
+// they are not in the file.
+func readRVA(f *pe.File, rva, n uint32) []byte {
+	for _, s := range f.Sections {
+		if rva < s.VirtualAddress || rva-s.VirtualAddress >= s.Size {
+			continue
+		}
+		buf := make([]byte, n)
+		if _, err := s.ReadAt(buf, int64(rva-s.VirtualAddress)); err != nil {
+			return nil
+		}
+		return buf
+	}
+	return nil
+}
+
+// readCString reads a NUL-terminated string of at most 256 bytes at a
+// relative virtual address.
+func readCString(f *pe.File, rva uint32) string {
+	for _, s := range f.Sections {
+		if rva < s.VirtualAddress || rva-s.VirtualAddress >= s.Size {
+			continue
+		}
+		off := rva - s.VirtualAddress
+		n := s.Size - off
+		if n > 256 {
+			n = 256
+		}
+		buf := make([]byte, n)
+		if _, err := s.ReadAt(buf, int64(off)); err != nil {
+			return ""
+		}
+		if i := bytes.IndexByte(buf, 0); i >= 0 {
+			return string(buf[:i])
+		}
+		return ""
+	}
+	return ""
+}
+
+// CheckPluginABI flags SKSE plugins that cannot be loaded on the game
+// runtime the collection targets. When the runtime is unknown, the target
+// is the runtime family most of the collection's plugins are built for,
+// and the mismatches are warnings rather than errors.
+func CheckPluginABI(gameVersion string, plugins []NativePlugin) []Finding {
+	if len(plugins) == 0 {
+		return nil
+	}
+
+	target := RuntimeFamily(gameVersion)
+	inferred := target == ""
+	if inferred {
+		counts := make(map[string]int)
+		for _, p := range plugins {
+			for _, family := range p.Families() {
+				counts[family]++
+			}
+		}
+		best := 0
+		for _, family := range []string{RuntimeAE, RuntimeSE, RuntimeLE} {
+			if counts[family] > best {
+				target, best = family, counts[family]
+			}
+		}
+		if target == "" {
+			return nil
+		}
+	}
+
+	var findings []Finding
+	for _, p := range plugins {
+		if len(p.Families()) == 0 {
+			// A helper library rather than a plugin SKSE loads
+			continue
+		}
+		var reason string
+		switch {
+		case inferred && !containsString(p.Families(), target):
+			reason = fmt.Sprintf("built for %s runtimes, while most of the collection's plugins are built for %s", familyList(p.Families()), target)
+		case inferred:
+			continue
+		case p.Supports(gameVersion):
+			continue
+		case !containsString(p.Families(), target):
+			reason = fmt.Sprintf("built for %s runtimes; the script extender for runtime %s will not load it", familyList(p.Families()), gameVersion)
+		default:
+			reason = fmt.Sprintf("built only for runtime %s; it will not load on runtime %s", strings.Join(p.Runtimes, ", "), gameVersion)
+		}
+
+		severity := SeverityError
+		if inferred {
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Type:     FindingPluginRuntimeMismatch,
+			Severity: severity,
+			ModID:    p.ModID,
+			ModName:  p.ModName,
+			Message:  fmt.Sprintf("%s ships the script extender plugin %s, %s", nameOf(ModIdentity{ModID: p.ModID, ModName: p.ModName}), p.Filename, reason),
+		})
+	}
+	return findings
+}
+
+// familyList describes runtime families for a message.
+func familyList(families []string) string {
+	return strings.Join(families, " and ")
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
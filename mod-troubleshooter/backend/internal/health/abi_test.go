@@ -0,0 +1,168 @@
+package health
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+)
+
+// packVersion packs a version as SKSE's MAKE_EXE_VERSION does.
+func packVersion(major, minor, build, sub uint32) uint32 {
+	return major<<24 | minor<<16 | (build&0xfff)<<4 | sub&0xf
+}
+
+// buildPlugin builds a minimal 64-bit DLL exporting the given names. An
+// SKSEPlugin_Version export points to version data with the given flags
+// and compatible runtimes.
+func buildPlugin(t *testing.T, exports []string, independence uint32, runtimes ...uint32) []byte {
+	t.Helper()
+	const sectionRVA, sectionOffset = 0x1000, 0x400
+
+	// Section layout: export directory, function and name tables, ordinals,
+	// names, then the version data.
+	var sec bytes.Buffer
+	dirOff := 0
+	funcsOff := 40
+	namesOff := funcsOff + 4*len(exports)
+	ordinalsOff := namesOff + 4*len(exports)
+	stringsOff := ordinalsOff + 2*len(exports)
+	strOffsets := make([]int, len(exports))
+	off := stringsOff
+	for i, name := range exports {
+		strOffsets[i] = off
+		off += len(name) + 1
+	}
+	dataOff := (off + 7) &^ 7
+
+	le := binary.LittleEndian
+	dir := make([]byte, 40)
+	le.PutUint32(dir[20:], uint32(len(exports)))
+	le.PutUint32(dir[24:], uint32(len(exports)))
+	le.PutUint32(dir[28:], uint32(sectionRVA+funcsOff))
+	le.PutUint32(dir[32:], uint32(sectionRVA+namesOff))
+	le.PutUint32(dir[36:], uint32(sectionRVA+ordinalsOff))
+	sec.Write(dir)
+	for range exports {
+		binary.Write(&sec, le, uint32(sectionRVA+dataOff))
+	}
+	for _, o := range strOffsets {
+		binary.Write(&sec, le, uint32(sectionRVA+o))
+	}
+	for i := range exports {
+		binary.Write(&sec, le, uint16(i))
+	}
+	for _, name := range exports {
+		sec.WriteString(name)
+		sec.WriteByte(0)
+	}
+	sec.Write(make([]byte, dataOff-sec.Len()))
+
+	vd := make([]byte, skseVersionDataSize)
+	le.PutUint32(vd[0:], 1)
+	le.PutUint32(vd[4:], packVersion(2, 1, 3, 0))
+	copy(vd[skseVersionNameOffset:], "TestPlugin")
+	le.PutUint32(vd[skseVersionIndependenceOff:], independence)
+	for i, r := range runtimes {
+		le.PutUint32(vd[skseCompatibleVersionsOff+4*i:], r)
+	}
+	sec.Write(vd)
+	sec.Write(make([]byte, (0x200-sec.Len()%0x200)%0x200))
+
+	var buf bytes.Buffer
+	dos := make([]byte, 0x40)
+	copy(dos, "MZ")
+	le.PutUint32(dos[0x3c:], 0x40)
+	buf.Write(dos)
+	buf.WriteString("PE\x00\x00")
+	binary.Write(&buf, le, pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_AMD64,
+		NumberOfSections:     1,
+		SizeOfOptionalHeader: uint16(binary.Size(pe.OptionalHeader64{})),
+		Characteristics:      0x2022,
+	})
+	oh := pe.OptionalHeader64{
+		Magic:               0x20b,
+		MajorLinkerVersion:  14,
+		MinorLinkerVersion:  38,
+		SectionAlignment:    0x1000,
+		FileAlignment:       0x200,
+		SizeOfImage:         0x2000 + uint32(sec.Len()),
+		SizeOfHeaders:       sectionOffset,
+		NumberOfRvaAndSizes: 16,
+	}
+	oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT] = pe.DataDirectory{VirtualAddress: sectionRVA + uint32(dirOff), Size: uint32(dataOff)}
+	binary.Write(&buf, le, oh)
+	var name [8]uint8
+	copy(name[:], ".rdata")
+	binary.Write(&buf, le, pe.SectionHeader32{
+		Name:             name,
+		VirtualSize:      uint32(sec.Len()),
+		VirtualAddress:   sectionRVA,
+		SizeOfRawData:    uint32(sec.Len()),
+		PointerToRawData: sectionOffset,
+		Characteristics:  0x40000040,
+	})
+	buf.Write(make([]byte, sectionOffset-buf.Len()))
+	buf.Write(sec.Bytes())
+	return buf.Bytes()
+}
+
+func TestParsePluginABI(t *testing.T) {
+	data := buildPlugin(t, []string{skseVersionExport}, 0, packVersion(1, 6, 1170, 0), packVersion(1, 6, 1130, 0))
+
+	abi, err := ParsePluginABI("TestPlugin.dll", data)
+	if err != nil {
+		t.Fatalf("ParsePluginABI() error = %v", err)
+	}
+	if abi.Machine != "x64" || abi.Linker != "14.38" || abi.Legacy || !abi.VersionData {
+		t.Errorf("unexpected headers %+v", abi)
+	}
+	if abi.Name != "TestPlugin" || abi.Version != "2.1.3" || abi.AddressIndependent {
+		t.Errorf("unexpected version data %+v", abi)
+	}
+	if len(abi.Runtimes) != 2 || abi.Runtimes[0] != "1.6.1170.0" || abi.Runtimes[1] != "1.6.1130.0" {
+		t.Errorf("unexpected runtimes %v", abi.Runtimes)
+	}
+	if !abi.Supports("1.6.1170.0") || abi.Supports("1.6.640.0") || abi.Supports("1.5.97.0") {
+		t.Error("expected the plugin to support only its listed runtimes")
+	}
+
+	if _, err := ParsePluginABI("readme.dll", []byte("not a dll")); err == nil {
+		t.Error("expected an error for data that is not a DLL")
+	}
+}
+
+func TestParsePluginABI_Legacy(t *testing.T) {
+	abi, err := ParsePluginABI("Old.dll", buildPlugin(t, []string{"SKSEPlugin_Load", skseQueryExport}, 0))
+	if err != nil {
+		t.Fatalf("ParsePluginABI() error = %v", err)
+	}
+	if !abi.Legacy || abi.VersionData || !abi.Supports("1.5.97") || abi.Supports("1.6.1170") {
+		t.Errorf("expected a plugin for runtimes before 1.6, got %+v", abi)
+	}
+}
+
+func TestCheckPluginABI(t *testing.T) {
+	ae := NativePlugin{ModID: "a", ModName: "AE Plugin", PluginABI: PluginABI{Filename: "a.dll", Machine: "x64", VersionData: true, AddressIndependent: true}}
+	pinned := NativePlugin{ModID: "b", ModName: "Pinned", PluginABI: PluginABI{Filename: "b.dll", Machine: "x64", VersionData: true, Runtimes: []string{"1.6.640.0"}}}
+	se := NativePlugin{ModID: "c", ModName: "SE Plugin", PluginABI: PluginABI{Filename: "c.dll", Machine: "x64", Legacy: true}}
+	helper := NativePlugin{ModID: "d", ModName: "Helper", PluginABI: PluginABI{Filename: "d.dll", Machine: "x64"}}
+	plugins := []NativePlugin{ae, pinned, se, helper}
+
+	findings := CheckPluginABI("1.6.1170.0", plugins)
+	if len(findings) != 2 || findings[0].ModID != "b" || findings[1].ModID != "c" {
+		t.Fatalf("expected the pinned and SE plugins to be flagged, got %+v", findings)
+	}
+	for _, f := range findings {
+		if f.Type != FindingPluginRuntimeMismatch || f.Severity != SeverityError {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+
+	// Without a known runtime, the odd one out is flagged as a warning
+	findings = CheckPluginABI("", plugins)
+	if len(findings) != 1 || findings[0].ModID != "c" || findings[0].Severity != SeverityWarning {
+		t.Errorf("expected only the SE plugin to be flagged, got %+v", findings)
+	}
+}
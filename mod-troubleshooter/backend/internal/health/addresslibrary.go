@@ -0,0 +1,111 @@
+package health
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// addressLibraryPattern matches an Address Library database, such as
+// "skse/plugins/versionlib-1-6-1170-0.bin", capturing the script extender
+// folder and the game runtime it was built for.
+var addressLibraryPattern = regexp.MustCompile(`(?:^|/)((?:skse|f4se|sfse)/plugins)/version(?:lib)?-(\d+(?:-\d+)+)\.(?:bin|csv)$`)
+
+// addressLibraryDatabase is an Address Library database shipped by a mod.
+type addressLibraryDatabase struct {
+	mod     ModIdentity
+	dir     string
+	runtime string
+}
+
+// CheckAddressLibrary flags collections whose script extender plugins
+// cannot find an Address Library database for the game runtime the
+// collection targets. Plugins look up game addresses in the database for
+// the running game, so a database for another runtime crashes the game or
+// disables the plugins; this is the most common breakage after a game
+// update. Nothing is checked when the runtime is unknown or the collection
+// ships no database, which the framework check covers.
+func CheckAddressLibrary(gameVersion string, mods []ModIdentity) []Finding {
+	if gameVersion == "" {
+		return nil
+	}
+
+	var databases []addressLibraryDatabase
+	users := make(map[string][]string)
+	for _, mod := range mods {
+		if mod.Manifest == nil {
+			continue
+		}
+		for _, f := range mod.Manifest.Files {
+			p := strings.TrimPrefix(f.Path, "data/")
+			if m := addressLibraryPattern.FindStringSubmatch(p); m != nil {
+				databases = append(databases, addressLibraryDatabase{
+					mod:     mod,
+					dir:     m[1],
+					runtime: strings.ReplaceAll(m[2], "-", "."),
+				})
+				continue
+			}
+			if f.Extension == ".dll" && inScriptExtenderDir("/"+p) {
+				dir := scriptExtenderPluginDir(p)
+				if names := users[dir]; len(names) == 0 || names[len(names)-1] != nameOf(mod) {
+					users[dir] = append(users[dir], nameOf(mod))
+				}
+			}
+		}
+	}
+
+	byDir := make(map[string][]addressLibraryDatabase)
+	for _, db := range databases {
+		byDir[db.dir] = append(byDir[db.dir], db)
+	}
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var findings []Finding
+	for _, dir := range dirs {
+		dbs := byDir[dir]
+		if len(users[dir]) == 0 || hasRuntime(dbs, gameVersion) {
+			continue
+		}
+		runtimes := make([]string, 0, len(dbs))
+		for _, db := range dbs {
+			runtimes = append(runtimes, db.runtime)
+		}
+		findings = append(findings, Finding{
+			Type:     FindingAddressLibraryMismatch,
+			Severity: SeverityError,
+			ModID:    dbs[0].mod.ModID,
+			ModName:  dbs[0].mod.ModName,
+			Message: fmt.Sprintf("The collection targets game version %s but %s only ships Address Library databases for %s; script extender plugins in %s will crash the game or fail to load until the matching database is installed",
+				gameVersion, nameOf(dbs[0].mod), strings.Join(runtimes, ", "), strings.Join(users[dir], ", ")),
+		})
+	}
+	return findings
+}
+
+// hasRuntime reports whether one of the databases is built for the runtime.
+func hasRuntime(dbs []addressLibraryDatabase, gameVersion string) bool {
+	for _, db := range dbs {
+		if SameVersion(db.runtime, gameVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptExtenderPluginDir returns the script extender plugin folder a path
+// is in, such as "skse/plugins".
+func scriptExtenderPluginDir(p string) string {
+	for _, d := range scriptExtenderDirs {
+		if strings.Contains("/"+p, "/"+d) {
+			return strings.TrimSuffix(d, "/")
+		}
+	}
+	return path.Dir(p)
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func TestCheckAddressLibrary(t *testing.T) {
+	files := func(paths ...string) *manifest.Manifest {
+		var entries []manifest.FileEntry
+		for _, p := range paths {
+			entries = append(entries, manifest.NewFileEntry(p, 10))
+		}
+		return manifest.NewManifest(entries)
+	}
+	library := ModIdentity{ModID: "1", ModName: "Address Library", Manifest: files(
+		"SKSE/Plugins/versionlib-1-6-640-0.bin",
+		"SKSE/Plugins/versionlib-1-6-1130-0.bin",
+	)}
+	plugin := ModIdentity{ModID: "2", ModName: "Engine Fixes", Manifest: files("Data/SKSE/Plugins/EngineFixes.dll")}
+
+	tests := []struct {
+		name        string
+		gameVersion string
+		mods        []ModIdentity
+		want        int
+	}{
+		{"matching runtime", "1.6.640.0", []ModIdentity{library, plugin}, 0},
+		{"matching runtime without build number", "1.6.1130", []ModIdentity{library, plugin}, 0},
+		{"other runtime", "1.6.1170.0", []ModIdentity{library, plugin}, 1},
+		{"unknown runtime", "", []ModIdentity{library, plugin}, 0},
+		{"no plugins", "1.6.1170.0", []ModIdentity{library}, 0},
+		{"no database", "1.6.1170.0", []ModIdentity{plugin}, 0},
+		{"other script extender", "1.6.1170.0", []ModIdentity{library, {ModID: "3", Manifest: files("F4SE/Plugins/Buffout4.dll")}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := CheckAddressLibrary(tt.gameVersion, tt.mods)
+			if len(findings) != tt.want {
+				t.Fatalf("expected %d findings, got %d: %+v", tt.want, len(findings), findings)
+			}
+			for _, f := range findings {
+				if f.Type != FindingAddressLibraryMismatch || f.Severity != SeverityError || f.ModID != "1" {
+					t.Errorf("unexpected finding: %+v", f)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ModBugReports are the titles of the latest bug reports filed against a
+// mod on Nexus.
+type ModBugReports struct {
+	ModID   string
+	ModName string
+	Titles  []string
+}
+
+// reportHeuristic recognizes a kind of problem in a bug report title.
+type reportHeuristic struct {
+	// kind describes the problem, such as "crash".
+	kind    string
+	pattern *regexp.Regexp
+	// other is the index of the submatch naming another mod, or 0.
+	other int
+}
+
+// reportHeuristics are checked in order; the first match labels a report.
+var reportHeuristics = []reportHeuristic{
+	{kind: "crash", pattern: regexp.MustCompile(`(?i)\b(ctds?|crash(es|ed|ing)?|freez(e|es|ing)|infinite load(ing)?)\b`)},
+	{kind: "incompatibility", pattern: regexp.MustCompile(
+		`(?i)\b(?:incompatib(?:le|ility)|conflicts?|doesn'?t work|does not work|broken)\s+with\s+(.+)$`), other: 1},
+	{kind: "save corruption", pattern: regexp.MustCompile(`(?i)\b(corrupt(ed|s|ion)?\s+saves?|saves?\s+(corrupt(ed|ion)?|bloat))\b`)},
+	{kind: "missing requirement", pattern: regexp.MustCompile(`(?i)\bmissing\s+(masters?|requirements?|dependenc(y|ies))\b`)},
+}
+
+// CheckCommunityReports turns bug report titles that look like crashes,
+// incompatibilities or broken saves into hints. Nobody has verified the
+// reports, so the hints are informational and don't lower the score.
+// Titles that match no heuristic are left out.
+func CheckCommunityReports(mods []ModBugReports) []Finding {
+	var findings []Finding
+	for _, mod := range mods {
+		for _, title := range mod.Titles {
+			title = strings.TrimSpace(title)
+			kind, other, ok := classifyReport(title)
+			if !ok {
+				continue
+			}
+			if other != "" {
+				kind = fmt.Sprintf("%s with %s", kind, other)
+			}
+			findings = append(findings, Finding{
+				Type:       FindingCommunityReport,
+				Severity:   SeverityInfo,
+				ModID:      mod.ModID,
+				ModName:    mod.ModName,
+				Unverified: true,
+				Message: fmt.Sprintf("Unverified community report of a %s in %s: %q",
+					kind, nameOf(ModIdentity{ModID: mod.ModID, ModName: mod.ModName}), title),
+			})
+		}
+	}
+	return findings
+}
+
+// classifyReport labels a bug report title by the first heuristic it
+// matches, with the other mod it names, if any.
+func classifyReport(title string) (kind, other string, ok bool) {
+	for _, h := range reportHeuristics {
+		m := h.pattern.FindStringSubmatch(title)
+		if m == nil {
+			continue
+		}
+		if h.other > 0 {
+			other = strings.Trim(strings.TrimSpace(m[h.other]), ".!?\"'")
+		}
+		return h.kind, other, true
+	}
+	return "", "", false
+}
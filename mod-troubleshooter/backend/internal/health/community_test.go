@@ -0,0 +1,50 @@
+package health
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckCommunityReports(t *testing.T) {
+	mods := []ModBugReports{{
+		ModID:   "1",
+		ModName: "Better Bodies",
+		Titles: []string{
+			"CTD when entering Whiterun",
+			"Incompatible with Immersive Armors.",
+			"Typo in MCM menu",
+			"Save corruption after uninstall",
+		},
+	}}
+
+	findings := CheckCommunityReports(mods)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+	for _, f := range findings {
+		if f.Type != FindingCommunityReport || f.Severity != SeverityInfo || !f.Unverified {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		if !strings.HasPrefix(f.Message, "Unverified community report") {
+			t.Errorf("expected the message to say the report is unverified, got %q", f.Message)
+		}
+	}
+	if !strings.Contains(findings[0].Message, "crash") {
+		t.Errorf("expected a crash hint, got %q", findings[0].Message)
+	}
+	if !strings.Contains(findings[1].Message, "incompatibility with Immersive Armors") {
+		t.Errorf("expected an incompatibility hint naming the other mod, got %q", findings[1].Message)
+	}
+	if !strings.Contains(findings[2].Message, "save corruption") {
+		t.Errorf("expected a save corruption hint, got %q", findings[2].Message)
+	}
+
+	report := NewReport(1)
+	for _, f := range findings {
+		report.Add(f)
+	}
+	report.Finalize()
+	if report.Score != 100 {
+		t.Errorf("expected unverified reports not to lower the score, got %d", report.Score)
+	}
+}
@@ -0,0 +1,173 @@
+package health
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DeckVerdict is how ready a collection is for the Steam Deck and other
+// Proton setups.
+type DeckVerdict string
+
+const (
+	// DeckReady means the collection needs nothing beyond a mod manager.
+	DeckReady DeckVerdict = "ready"
+	// DeckPlayable means the collection works, but ships Windows tools or
+	// directory casing that some setups need extra steps for.
+	DeckPlayable DeckVerdict = "playable"
+	// DeckNeedsSetup means the collection behaves differently until files
+	// are renamed or a Windows tool has been run through Proton.
+	DeckNeedsSetup DeckVerdict = "needs_setup"
+)
+
+// knownTool is a Windows tool recognized by its executable.
+type knownTool struct {
+	name string
+	// required is true for tools whose output the game needs, such as
+	// behavior engines.
+	required bool
+}
+
+// knownTools maps lowercase executable names to the tools they belong to.
+var knownTools = map[string]knownTool{
+	"generatefnisforusers.exe":              {name: "FNIS", required: true},
+	"generatefnisformodders.exe":            {name: "FNIS"},
+	"nemesis unlimited behavior engine.exe": {name: "Nemesis", required: true},
+	"pandora behaviour engine+.exe":         {name: "Pandora", required: true},
+	"bodyslide.exe":                         {name: "BodySlide"},
+	"bodyslide x64.exe":                     {name: "BodySlide"},
+	"outfitstudio.exe":                      {name: "Outfit Studio"},
+	"outfitstudio x64.exe":                  {name: "Outfit Studio"},
+	"dyndolod.exe":                          {name: "DynDOLOD"},
+	"dyndolodx64.exe":                       {name: "DynDOLOD"},
+	"texgen.exe":                            {name: "TexGen"},
+	"texgenx64.exe":                         {name: "TexGen"},
+	"sseedit.exe":                           {name: "xEdit"},
+	"tes5edit.exe":                          {name: "xEdit"},
+	"fo4edit.exe":                           {name: "xEdit"},
+	"fnvedit.exe":                           {name: "xEdit"},
+	"xedit.exe":                             {name: "xEdit"},
+}
+
+// Names of tools recognized by their files rather than a known executable.
+const (
+	ToolXEditScripts = "xEdit scripts"
+	ToolDotNet       = ".NET tool"
+	ToolExecutable   = "Windows executable"
+)
+
+// WindowsTool is a Windows-only tool a mod ships, which Linux and Steam
+// Deck players have to run through Proton or Wine.
+type WindowsTool struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	Tool    string `json:"tool"`
+	// Required is true when the game needs the tool's output.
+	Required bool `json:"required,omitempty"`
+	// Files are the tool's files as the mod spells them.
+	Files []string `json:"files"`
+}
+
+// DetectWindowsTools lists the Windows-only tools each mod ships: known
+// executables, xEdit scripts, and other executables, which are .NET tools
+// when they come with a runtime configuration.
+func DetectWindowsTools(mods []ModCasing) []WindowsTool {
+	tools := []WindowsTool{}
+	for _, mod := range mods {
+		files := manifestFiles(mod.Manifest)
+		dotnet := make(map[string]bool)
+		for _, f := range files {
+			if strings.HasSuffix(f.Path, ".exe.config") || strings.HasSuffix(f.Path, ".runtimeconfig.json") {
+				exe := strings.TrimSuffix(strings.TrimSuffix(f.Path, ".config"), ".runtimeconfig.json")
+				if !strings.HasSuffix(exe, ".exe") {
+					exe += ".exe"
+				}
+				dotnet[exe] = true
+			}
+		}
+
+		index := make(map[string]int)
+		add := func(tool knownTool, file string) {
+			i, ok := index[tool.name]
+			if !ok {
+				i = len(tools)
+				index[tool.name] = i
+				tools = append(tools, WindowsTool{ModID: mod.ModID, ModName: mod.ModName, Tool: tool.name})
+			}
+			tools[i].Required = tools[i].Required || tool.required
+			tools[i].Files = append(tools[i].Files, file)
+		}
+
+		for _, f := range files {
+			switch {
+			case f.Extension == ".pas" && strings.Contains("/"+f.Path, "/edit scripts/"):
+				add(knownTool{name: ToolXEditScripts}, f.OriginalPath)
+			case f.Extension != ".exe":
+			case knownTools[path.Base(f.Path)].name != "":
+				add(knownTools[path.Base(f.Path)], f.OriginalPath)
+			case dotnet[f.Path]:
+				add(knownTool{name: ToolDotNet}, f.OriginalPath)
+			default:
+				add(knownTool{name: ToolExecutable}, f.OriginalPath)
+			}
+		}
+	}
+	return tools
+}
+
+// CheckSteamDeck builds the Linux and Steam Deck section of a report:
+// casing that a case-sensitive file system keeps apart, the Windows tools
+// the collection ships, and a readiness verdict.
+func CheckSteamDeck(mods []ModCasing) *LinuxCompatibility {
+	l := CheckCaseSensitivity(mods)
+	l.WindowsTools = DetectWindowsTools(mods)
+	l.Reasons = []string{}
+
+	var files, directories int
+	for _, c := range l.CaseConflicts {
+		if c.Directory {
+			directories++
+		} else {
+			files++
+		}
+	}
+
+	setup := false
+	if files > 0 {
+		setup = true
+		l.Reasons = append(l.Reasons, fmt.Sprintf("%d files are spelled with different casing and will not override each other", files))
+	}
+	if n := len(l.MasterCaseMismatches); n > 0 {
+		setup = true
+		l.Reasons = append(l.Reasons, fmt.Sprintf("%d master references are spelled differently from the plugins they name", n))
+	}
+	for _, t := range l.WindowsTools {
+		if t.Required {
+			setup = true
+			l.Reasons = append(l.Reasons, fmt.Sprintf("%s from %s has to be run through Proton", t.Tool, modDisplayName(t.ModID, t.ModName)))
+		}
+	}
+	if directories > 0 {
+		l.Reasons = append(l.Reasons, fmt.Sprintf("%d directories are spelled with different casing and may be split in two", directories))
+	}
+	optional := 0
+	for _, t := range l.WindowsTools {
+		if !t.Required {
+			optional++
+		}
+	}
+	if optional > 0 {
+		l.Reasons = append(l.Reasons, fmt.Sprintf("%d optional Windows tools need Proton or Wine to run", optional))
+	}
+
+	switch {
+	case setup:
+		l.Verdict = DeckNeedsSetup
+	case len(l.Reasons) > 0:
+		l.Verdict = DeckPlayable
+	default:
+		l.Verdict = DeckReady
+	}
+	return l
+}
@@ -0,0 +1,87 @@
+package health
+
+import "testing"
+
+func TestDetectWindowsTools(t *testing.T) {
+	mods := []ModCasing{
+		{ModID: "fnis", ModName: "FNIS", Manifest: manifestOf("Tools/GenerateFNIS_for_Users/GenerateFNISforUsers.exe", "meshes/a.nif")},
+		{ModID: "scripts", Manifest: manifestOf("Edit Scripts/Cleanup.pas", "Edit Scripts/lib/mteFunctions.pas")},
+		{ModID: "dotnet", Manifest: manifestOf("Synthesis/Patcher.exe", "Synthesis/Patcher.runtimeconfig.json", "Other/Setup.exe")},
+		{ModID: "plain", Manifest: manifestOf("textures/a.dds", "Plain.esp")},
+	}
+
+	tools := DetectWindowsTools(mods)
+
+	want := []struct {
+		modID    string
+		tool     string
+		required bool
+		files    int
+	}{
+		{"fnis", "FNIS", true, 1},
+		{"scripts", ToolXEditScripts, false, 2},
+		{"dotnet", ToolDotNet, false, 1},
+		{"dotnet", ToolExecutable, false, 1},
+	}
+	if len(tools) != len(want) {
+		t.Fatalf("expected %d tools, got %+v", len(want), tools)
+	}
+	for i, w := range want {
+		got := tools[i]
+		if got.ModID != w.modID || got.Tool != w.tool || got.Required != w.required || len(got.Files) != w.files {
+			t.Errorf("tool %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
+
+func TestCheckSteamDeck_Verdict(t *testing.T) {
+	tests := []struct {
+		name string
+		mods []ModCasing
+		want DeckVerdict
+	}{
+		{
+			name: "ready",
+			mods: []ModCasing{{ModID: "a", Manifest: manifestOf("textures/a.dds")}},
+			want: DeckReady,
+		},
+		{
+			name: "split directory",
+			mods: []ModCasing{
+				{ModID: "a", Manifest: manifestOf("Textures/a.dds")},
+				{ModID: "b", Manifest: manifestOf("textures/b.dds")},
+			},
+			want: DeckPlayable,
+		},
+		{
+			name: "optional tool",
+			mods: []ModCasing{{ModID: "a", Manifest: manifestOf("CalienteTools/BodySlide/BodySlide x64.exe")}},
+			want: DeckPlayable,
+		},
+		{
+			name: "file case conflict",
+			mods: []ModCasing{
+				{ModID: "a", Manifest: manifestOf("textures/A.dds")},
+				{ModID: "b", Manifest: manifestOf("textures/a.dds")},
+			},
+			want: DeckNeedsSetup,
+		},
+		{
+			name: "behavior engine",
+			mods: []ModCasing{{ModID: "a", Manifest: manifestOf("Nemesis_Engine/Nemesis Unlimited Behavior Engine.exe")}},
+			want: DeckNeedsSetup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := CheckSteamDeck(tt.mods)
+			if l.Verdict != tt.want {
+				t.Errorf("expected %s, got %s (%v)", tt.want, l.Verdict, l.Reasons)
+			}
+			if (tt.want == DeckReady) != (len(l.Reasons) == 0) {
+				t.Errorf("unexpected reasons %v", l.Reasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package health
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// knownDLLs is the built-in allowlist of script extender plugins.
+//
+//go:embed known_dlls.json
+var knownDLLs []byte
+
+// KnownDLL is a script extender plugin known to be published by its
+// credited author.
+type KnownDLL struct {
+	// Name is the DLL filename, matched case-insensitively.
+	Name string `json:"name"`
+	// Mod is the mod that ships the DLL.
+	Mod string `json:"mod"`
+	// Versions are the known builds of the DLL. When given, a copy whose
+	// content hash matches none of them is flagged too.
+	Versions []KnownDLLVersion `json:"versions,omitempty"`
+}
+
+// KnownDLLVersion is a known build of a DLL.
+type KnownDLLVersion struct {
+	Version string `json:"version"`
+	// SHA256 is the hex-encoded content hash of the build.
+	SHA256 string `json:"sha256"`
+}
+
+// DLLAllowlist is the set of known script extender plugins. DLL mods run
+// native code with the game's privileges, so they are the main way malware
+// spreads through mod lists; unknown DLLs deserve a second look.
+type DLLAllowlist struct {
+	dlls map[string]KnownDLL
+}
+
+// DefaultDLLAllowlist returns the built-in allowlist.
+func DefaultDLLAllowlist() *DLLAllowlist {
+	var dlls []KnownDLL
+	if err := json.Unmarshal(knownDLLs, &dlls); err != nil {
+		panic(fmt.Sprintf("invalid built-in DLL allowlist: %v", err))
+	}
+	return NewDLLAllowlist(dlls)
+}
+
+// NewDLLAllowlist creates an allowlist of the given DLLs.
+func NewDLLAllowlist(dlls []KnownDLL) *DLLAllowlist {
+	l := &DLLAllowlist{dlls: make(map[string]KnownDLL, len(dlls))}
+	l.Add(dlls)
+	return l
+}
+
+// LoadDLLAllowlist returns the built-in allowlist updated with the DLLs in a
+// JSON file, in the same format as the built-in list. Entries in the file
+// replace built-in entries of the same name.
+func LoadDLLAllowlist(path string) (*DLLAllowlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read DLL allowlist: %w", err)
+	}
+	var dlls []KnownDLL
+	if err := json.Unmarshal(data, &dlls); err != nil {
+		return nil, fmt.Errorf("parse DLL allowlist: %w", err)
+	}
+	l := DefaultDLLAllowlist()
+	l.Add(dlls)
+	return l, nil
+}
+
+// Add adds DLLs to the allowlist, replacing entries of the same name.
+func (l *DLLAllowlist) Add(dlls []KnownDLL) {
+	for _, dll := range dlls {
+		if dll.Name == "" {
+			continue
+		}
+		l.dlls[strings.ToLower(dll.Name)] = dll
+	}
+}
+
+// Len returns the number of known DLLs.
+func (l *DLLAllowlist) Len() int {
+	return len(l.dlls)
+}
+
+// CheckDLLs flags script extender plugins that are not on the allowlist,
+// and known plugins whose content hash matches none of their known builds.
+// Unknown DLLs are not necessarily harmful, so they are informational.
+func (l *DLLAllowlist) CheckDLLs(mods []ModIdentity) []Finding {
+	var findings []Finding
+	for _, mod := range mods {
+		if mod.Manifest == nil {
+			continue
+		}
+		for _, f := range mod.Manifest.Files {
+			if f.Extension != ".dll" || !inScriptExtenderDir("/"+strings.TrimPrefix(f.Path, "data/")) {
+				continue
+			}
+			name := f.Filename
+			if f.OriginalPath != "" {
+				name = path.Base(strings.ReplaceAll(f.OriginalPath, "\\", "/"))
+			}
+
+			known, ok := l.dlls[strings.ToLower(f.Filename)]
+			if !ok {
+				findings = append(findings, Finding{
+					Type:     FindingUnknownDLL,
+					Severity: SeverityInfo,
+					ModID:    mod.ModID,
+					ModName:  mod.ModName,
+					Message: fmt.Sprintf("%s ships the script extender plugin %s, which is not on the list of known plugins; native code runs with full access to your system, so check that the mod comes from a trusted author",
+						nameOf(mod), name),
+				})
+				continue
+			}
+			if len(known.Versions) > 0 && manifest.HasContentHash(f) && !knownBuild(known, f.Hash) {
+				findings = append(findings, Finding{
+					Type:     FindingUnknownDLLBuild,
+					Severity: SeverityWarning,
+					ModID:    mod.ModID,
+					ModName:  mod.ModName,
+					Message: fmt.Sprintf("%s ships %s, but it matches no known build of %s; it may be a newer release or a modified copy",
+						nameOf(mod), name, known.Mod),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// knownBuild reports whether hash matches a known build of the DLL.
+func knownBuild(dll KnownDLL, hash string) bool {
+	for _, v := range dll.Versions {
+		if strings.EqualFold(v.SHA256, hash) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func TestDefaultDLLAllowlist(t *testing.T) {
+	l := DefaultDLLAllowlist()
+	if l.Len() == 0 {
+		t.Fatal("expected built-in DLLs")
+	}
+}
+
+func TestCheckDLLs(t *testing.T) {
+	l := NewDLLAllowlist([]KnownDLL{
+		{Name: "EngineFixes.dll", Mod: "SSE Engine Fixes"},
+		{Name: "PapyrusUtil.dll", Mod: "PapyrusUtil SE", Versions: []KnownDLLVersion{{Version: "4.4", SHA256: "ABC123"}}},
+	})
+
+	hashed := func(path, hash string) manifest.FileEntry {
+		f := manifest.NewFileEntry(path, 10)
+		f.Hash = hash
+		return f
+	}
+	mods := []ModIdentity{
+		{ModID: "1", ModName: "Fixes", Manifest: manifest.NewManifest([]manifest.FileEntry{
+			manifest.NewFileEntry("SKSE/Plugins/EngineFixes.dll", 10),
+			manifest.NewFileEntry("d3dx9_42.dll", 10),
+		})},
+		{ModID: "2", ModName: "Stranger", Manifest: manifest.NewManifest([]manifest.FileEntry{
+			manifest.NewFileEntry("Data/SKSE/Plugins/FreeMoney.dll", 10),
+		})},
+		{ModID: "3", ModName: "Utils", Manifest: manifest.NewManifest([]manifest.FileEntry{
+			hashed("skse/plugins/papyrusutil.dll", "abc123"),
+		})},
+		{ModID: "4", ModName: "Repack", Manifest: manifest.NewManifest([]manifest.FileEntry{
+			hashed("skse/plugins/PapyrusUtil.dll", "fff000"),
+		})},
+	}
+
+	findings := l.CheckDLLs(mods)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.Type != FindingUnknownDLL || f.ModID != "2" || f.Severity != SeverityInfo {
+		t.Errorf("expected unknown DLL caution for mod 2, got %+v", f)
+	}
+	if f := findings[1]; f.Type != FindingUnknownDLLBuild || f.ModID != "4" {
+		t.Errorf("expected unknown build for mod 4, got %+v", f)
+	}
+}
+
+func TestLoadDLLAllowlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlls.json")
+	os.WriteFile(path, []byte(`[{"name": "FreeMoney.dll", "mod": "Free Money"}]`), 0644)
+
+	l, err := LoadDLLAllowlist(path)
+	if err != nil {
+		t.Fatalf("LoadDLLAllowlist: %v", err)
+	}
+	if l.Len() != DefaultDLLAllowlist().Len()+1 {
+		t.Errorf("expected the file to extend the built-in list, got %d DLLs", l.Len())
+	}
+	mods := []ModIdentity{{ModID: "2", Manifest: manifest.NewManifest([]manifest.FileEntry{
+		manifest.NewFileEntry("SKSE/Plugins/FreeMoney.dll", 10),
+	})}}
+	if findings := l.CheckDLLs(mods); len(findings) != 0 {
+		t.Errorf("expected listed DLL to pass, got %+v", findings)
+	}
+
+	os.WriteFile(path, []byte(`{`), 0644)
+	if _, err := LoadDLLAllowlist(path); err == nil {
+		t.Error("expected an error for an invalid file")
+	}
+}
@@ -0,0 +1,111 @@
+package health
+
+import (
+	"fmt"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+const (
+	// sameModSimilarity is the manifest similarity above which two files of
+	// the same mod are treated as versions of each other rather than add-ons.
+	sameModSimilarity = 0.5
+	// forkSimilarity is the manifest similarity above which two different
+	// mods are treated as forks of each other.
+	forkSimilarity = 0.9
+	// minForkFiles is the smallest manifest compared for forks, so tiny
+	// patches with one or two common paths are not flagged.
+	minForkFiles = 10
+)
+
+// ModIdentity is what the duplicate detector knows about a mod file.
+type ModIdentity struct {
+	ModID   string
+	ModName string
+	// NexusModID and FileID identify the file on Nexus; zero if unknown.
+	NexusModID int
+	FileID     int
+	// Version is the file's version, if known.
+	Version string
+	// Manifest is the file listing, if it could be read.
+	Manifest *manifest.Manifest
+}
+
+// DetectDuplicates flags mods that are probably included by mistake: two
+// files of the same mod in different versions, and different mods whose
+// contents are nearly identical. Files of one mod that ship different
+// content at the same version, such as optional add-ons, are not flagged.
+func DetectDuplicates(mods []ModIdentity) []Finding {
+	prints := make([]*manifest.Fingerprint, len(mods))
+	for i, mod := range mods {
+		prints[i] = manifest.NewFingerprint(mod.Manifest)
+	}
+
+	var findings []Finding
+	for i := range mods {
+		for j := i + 1; j < len(mods); j++ {
+			a, b := mods[i], mods[j]
+			switch {
+			case a.NexusModID > 0 && a.NexusModID == b.NexusModID:
+				if a.FileID == b.FileID {
+					continue
+				}
+				versionsDiffer := a.Version != "" && b.Version != "" && a.Version != b.Version
+				if !versionsDiffer && similarity(prints[i], prints[j]) < sameModSimilarity {
+					continue
+				}
+				findings = append(findings, Finding{
+					Type:     FindingDuplicateMod,
+					Severity: SeverityWarning,
+					ModID:    b.ModID,
+					ModName:  b.ModName,
+					Message: fmt.Sprintf("%s is included more than once (%s and %s); the collection probably only needs one",
+						nameOf(a), versionLabel(a), versionLabel(b)),
+				})
+			case prints[i].Len() >= minForkFiles && prints[j].Len() >= minForkFiles:
+				score := similarity(prints[i], prints[j])
+				if score < forkSimilarity {
+					continue
+				}
+				findings = append(findings, Finding{
+					Type:     FindingProbableFork,
+					Severity: SeverityWarning,
+					ModID:    b.ModID,
+					ModName:  b.ModName,
+					Message: fmt.Sprintf("%s and %s share %.0f%% of their files and are probably forks of the same mod",
+						nameOf(a), nameOf(b), score*100),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// similarity compares two fingerprints, skipping pairs whose sizes differ
+// too much to ever reach sameModSimilarity.
+func similarity(a, b *manifest.Fingerprint) float64 {
+	small, large := a.Len(), b.Len()
+	if small > large {
+		small, large = large, small
+	}
+	if large == 0 || float64(small)/float64(large) < sameModSimilarity {
+		return 0
+	}
+	return a.Compare(b).Score
+}
+
+// nameOf returns the best human-readable name for a mod.
+func nameOf(mod ModIdentity) string {
+	if mod.ModName != "" {
+		return mod.ModName
+	}
+	return mod.ModID
+}
+
+// versionLabel describes a mod file by version, falling back to its file ID.
+func versionLabel(mod ModIdentity) string {
+	if mod.Version != "" {
+		return "version " + mod.Version
+	}
+	return fmt.Sprintf("file %d", mod.FileID)
+}
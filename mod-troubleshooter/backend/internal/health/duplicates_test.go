@@ -0,0 +1,90 @@
+package health
+
+import (
+	"fmt"
+	"testing"
+)
+
+func numberedPaths(prefix string, n int) []string {
+	paths := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		paths = append(paths, fmt.Sprintf("%s/file%02d.nif", prefix, i))
+	}
+	return paths
+}
+
+func TestDetectDuplicates(t *testing.T) {
+	meshes := numberedPaths("meshes/armor", 20)
+
+	tests := []struct {
+		name string
+		mods []ModIdentity
+		want []FindingType
+	}{
+		{
+			"two versions of the same mod",
+			[]ModIdentity{
+				{ModID: "1", ModName: "SkyUI", NexusModID: 12604, FileID: 1, Version: "5.1", Manifest: manifestOf("SkyUI_SE.esp")},
+				{ModID: "2", ModName: "SkyUI", NexusModID: 12604, FileID: 2, Version: "5.2", Manifest: manifestOf("SkyUI_SE.esp")},
+			},
+			[]FindingType{FindingDuplicateMod},
+		},
+		{
+			"same mod without versions but same contents",
+			[]ModIdentity{
+				{ModID: "1", NexusModID: 10, FileID: 1, Manifest: manifestOf(meshes...)},
+				{ModID: "2", NexusModID: 10, FileID: 2, Manifest: manifestOf(meshes...)},
+			},
+			[]FindingType{FindingDuplicateMod},
+		},
+		{
+			"main and optional file",
+			[]ModIdentity{
+				{ModID: "1", NexusModID: 10, FileID: 1, Version: "1.0", Manifest: manifestOf(meshes...)},
+				{ModID: "2", NexusModID: 10, FileID: 2, Version: "1.0", Manifest: manifestOf("Optional Patch.esp")},
+			},
+			nil,
+		},
+		{
+			"fork with nearly identical contents",
+			[]ModIdentity{
+				{ModID: "1", ModName: "Original", NexusModID: 10, FileID: 1, Manifest: manifestOf(meshes...)},
+				{ModID: "2", ModName: "Fork", NexusModID: 20, FileID: 2, Manifest: manifestOf(append(meshes, "fork.esp")...)},
+			},
+			[]FindingType{FindingProbableFork},
+		},
+		{
+			"small mods sharing paths",
+			[]ModIdentity{
+				{ModID: "1", NexusModID: 10, FileID: 1, Manifest: manifestOf("textures/sky.dds")},
+				{ModID: "2", NexusModID: 20, FileID: 2, Manifest: manifestOf("textures/sky.dds")},
+			},
+			nil,
+		},
+		{
+			"unrelated mods",
+			[]ModIdentity{
+				{ModID: "1", NexusModID: 10, FileID: 1, Manifest: manifestOf(meshes...)},
+				{ModID: "2", NexusModID: 20, FileID: 2, Manifest: manifestOf(numberedPaths("textures/armor", 20)...)},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := DetectDuplicates(tt.mods)
+			if len(findings) != len(tt.want) {
+				t.Fatalf("expected %d findings, got %+v", len(tt.want), findings)
+			}
+			for i, f := range findings {
+				if f.Type != tt.want[i] {
+					t.Errorf("expected %s, got %s", tt.want[i], f.Type)
+				}
+				if f.Severity != SeverityWarning {
+					t.Errorf("expected warning severity, got %s", f.Severity)
+				}
+			}
+		})
+	}
+}
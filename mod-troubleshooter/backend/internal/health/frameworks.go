@@ -0,0 +1,394 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Framework is a library mod that other mods build on. Mods using a
+// framework often work without declaring it as a plugin master, so a
+// missing framework is only noticed in game.
+type Framework struct {
+	// Name is the framework's display name.
+	Name string
+	// Files are paths, relative to Data, whose presence shows a mod provides
+	// the framework. A path ending in "-" matches any file with that prefix.
+	Files []string
+	// Masters are the framework's plugins, matched case-insensitively.
+	Masters []string
+	// Scripts are the lowercase names of the framework's scripts. A compiled
+	// script naming one of them uses the framework.
+	Scripts []string
+	// Uses are paths, relative to Data, that only mods using the framework
+	// install, such as configuration folders it reads.
+	Uses []string
+	// DLLStrings are lowercase strings, with forward slashes, whose presence
+	// in a script extender plugin shows the plugin needs the framework.
+	DLLStrings []string
+}
+
+// DefaultFrameworks are the frameworks checked by default.
+var DefaultFrameworks = []Framework{
+	{
+		Name:    "SkyUI",
+		Files:   []string{"scripts/ski_configbase.pex"},
+		Masters: []string{"SkyUI_SE.esp", "SkyUI.esp"},
+		Scripts: []string{"ski_configbase", "ski_playerloadgamealias"},
+	},
+	{
+		Name:    "MCM Helper",
+		Files:   []string{"scripts/mcm_configbase.pex", "skse/plugins/mcmhelper.dll"},
+		Scripts: []string{"mcm_configbase"},
+		Uses:    []string{"mcm/config/"},
+	},
+	{
+		Name:    "PapyrusUtil",
+		Files:   []string{"scripts/papyrusutil.pex", "skse/plugins/papyrusutil.dll"},
+		Scripts: []string{"papyrusutil", "storageutil", "jsonutil", "miscutil"},
+	},
+	{
+		Name:       "Address Library",
+		Files:      []string{"skse/plugins/version-", "skse/plugins/versionlib-", "f4se/plugins/version-"},
+		DLLStrings: []string{"skse/plugins/version-", "skse/plugins/versionlib-", "f4se/plugins/version-"},
+	},
+}
+
+// ModReferences is what the framework check knows about a mod file.
+type ModReferences struct {
+	ModID   string
+	ModName string
+	// Manifest is the file listing, if it could be read.
+	Manifest *manifest.Manifest
+	// Masters are the masters of the mod's plugins, if their headers were read.
+	Masters []string
+	// References are what the mod's scripts and native plugins refer to, if
+	// its archive was scanned.
+	References *References
+}
+
+// References are the frameworks a mod's archive refers to from its code.
+type References struct {
+	// Scripts are the lowercase names compiled scripts refer to, including
+	// the scripts they extend and call.
+	Scripts []string `json:"scripts"`
+	// DLLFrameworks are the frameworks named by strings in script extender
+	// plugins.
+	DLLFrameworks []string `json:"dllFrameworks"`
+	// Plugins are the PE headers of the mod's SKSE plugins.
+	Plugins []PluginABI `json:"plugins,omitempty"`
+}
+
+// CheckFrameworks flags mods that use a framework no mod in the collection
+// provides. Usage is detected from plugin masters, compiled scripts,
+// native plugins and configuration files, so a missing framework is found
+// even when no plugin master is missing. Masters are certain, so they are
+// errors; the other signals are warnings.
+func CheckFrameworks(mods []ModReferences, frameworks []Framework) []Finding {
+	provided := make([]bool, len(frameworks))
+	for i, fw := range frameworks {
+		for _, mod := range mods {
+			if providesFramework(mod, fw) {
+				provided[i] = true
+				break
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, mod := range mods {
+		for i, fw := range frameworks {
+			if provided[i] {
+				continue
+			}
+			reason, certain := usesFramework(mod, fw)
+			if reason == "" {
+				continue
+			}
+			severity := SeverityWarning
+			if certain {
+				severity = SeverityError
+			}
+			findings = append(findings, Finding{
+				Type:     FindingMissingFramework,
+				Severity: severity,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message: fmt.Sprintf("%s %s, but no mod in the collection provides %s; add it or the mod will not work in game",
+					nameOf(ModIdentity{ModID: mod.ModID, ModName: mod.ModName}), reason, fw.Name),
+			})
+		}
+	}
+	return findings
+}
+
+// providesFramework reports whether a mod installs the framework.
+func providesFramework(mod ModReferences, fw Framework) bool {
+	if mod.Manifest == nil {
+		return false
+	}
+	for _, f := range mod.Manifest.Files {
+		p := "/" + strings.TrimPrefix(f.Path, "data/")
+		if matchesAny(p, fw.Files) {
+			return true
+		}
+		if f.Type == manifest.FileTypePlugin {
+			for _, master := range fw.Masters {
+				if strings.EqualFold(f.Filename, master) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// usesFramework describes how a mod uses the framework, or returns "" if it
+// does not. certain is set when the mod cannot load without it.
+func usesFramework(mod ModReferences, fw Framework) (reason string, certain bool) {
+	for _, master := range mod.Masters {
+		for _, m := range fw.Masters {
+			if strings.EqualFold(master, m) {
+				return "has a plugin requiring " + m, true
+			}
+		}
+	}
+	if mod.References != nil {
+		for _, script := range mod.References.Scripts {
+			for _, s := range fw.Scripts {
+				if script == s {
+					return "has scripts using " + s, false
+				}
+			}
+		}
+		for _, name := range mod.References.DLLFrameworks {
+			if name == fw.Name {
+				return "has a script extender plugin that loads " + fw.Name + " data", false
+			}
+		}
+	}
+	if mod.Manifest != nil && len(fw.Uses) > 0 {
+		for _, f := range mod.Manifest.Files {
+			if matchesAny("/"+strings.TrimPrefix(f.Path, "data/"), fw.Uses) {
+				return "installs " + f.Path, false
+			}
+		}
+	}
+	return "", false
+}
+
+// matchesAny reports whether a slash-prefixed path is one of paths, or in
+// one of them when it ends in "/" or "-".
+func matchesAny(p string, paths []string) bool {
+	for _, want := range paths {
+		if strings.HasSuffix(want, "/") || strings.HasSuffix(want, "-") {
+			if strings.Contains(p, "/"+want) {
+				return true
+			}
+		} else if strings.HasSuffix(p, "/"+want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits on what ScanReferences reads from a single file.
+const (
+	maxScriptSize = 16 << 20
+	maxDLLSize    = 64 << 20
+)
+
+// ScanReferences reads the compiled scripts and script extender plugins in
+// a mod archive for references to frameworks, and the PE headers of its SKSE
+// plugins, opening it with password if it is encrypted. Files that cannot
+// be parsed are skipped.
+func ScanReferences(ctx context.Context, archivePath, password string, frameworks []Framework) (*References, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
+	if err != nil {
+		return nil, fmt.Errorf("identify archive: %w", err)
+	}
+
+	extractor, ok := format.(archiver.Extractor)
+	if !ok {
+		return nil, fmt.Errorf("format does not support extraction")
+	}
+
+	scripts := make(map[string]bool)
+	dllFrameworks := make(map[string]bool)
+	var plugins []PluginABI
+	err = extractor.Extract(ctx, input, func(ctx context.Context, f archiver.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if f.IsDir() {
+			return nil
+		}
+
+		name := strings.ToLower(strings.ReplaceAll(f.NameInArchive, "\\", "/"))
+		switch {
+		case path.Ext(name) == ".pex" && f.Size() <= maxScriptSize:
+			rc, err := f.Open()
+			if err != nil {
+				return nil
+			}
+			defer rc.Close()
+
+			names, err := ScriptReferences(rc)
+			if err != nil {
+				return nil
+			}
+			for _, n := range names {
+				scripts[n] = true
+			}
+		case path.Ext(name) == ".dll" && inScriptExtenderDir("/"+name) && f.Size() <= maxDLLSize:
+			rc, err := f.Open()
+			if err != nil {
+				return nil
+			}
+			defer rc.Close()
+
+			data, err := io.ReadAll(io.LimitReader(rc, maxDLLSize))
+			if err != nil {
+				return nil
+			}
+			for _, fw := range frameworks {
+				if containsDLLString(data, fw.DLLStrings) {
+					dllFrameworks[fw.Name] = true
+				}
+			}
+			if strings.Contains("/"+name, "/skse/plugins/") {
+				if abi, err := ParsePluginABI(path.Base(strings.ReplaceAll(f.NameInArchive, "\\", "/")), data); err == nil {
+					plugins = append(plugins, *abi)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan archive: %w", err)
+	}
+
+	return &References{Scripts: sortedKeys(scripts), DLLFrameworks: sortedKeys(dllFrameworks), Plugins: plugins}, nil
+}
+
+// containsDLLString reports whether data contains one of the strings, as
+// ASCII or UTF-16, ignoring ASCII case and slash direction.
+func containsDLLString(data []byte, strs []string) bool {
+	if len(strs) == 0 {
+		return false
+	}
+	lower := make([]byte, len(data))
+	for i, b := range data {
+		switch {
+		case b >= 'A' && b <= 'Z':
+			b += 'a' - 'A'
+		case b == '\\':
+			b = '/'
+		}
+		lower[i] = b
+	}
+	for _, s := range strs {
+		if bytes.Contains(lower, []byte(s)) {
+			return true
+		}
+		wide := make([]byte, 0, 2*len(s))
+		for i := 0; i < len(s); i++ {
+			wide = append(wide, s[i], 0)
+		}
+		if bytes.Contains(lower, wide) {
+			return true
+		}
+	}
+	return false
+}
+
+// errNotScript is returned for data that is not a compiled Papyrus script.
+var errNotScript = errors.New("not a compiled Papyrus script")
+
+// pexMagic starts every compiled Papyrus script. Skyrim writes it and the
+// rest of the file big-endian, Fallout 4 little-endian.
+const pexMagic = 0xFA57C0DE
+
+// ScriptReferences reads the string table of a compiled Papyrus script and
+// returns its strings in lowercase. The table holds every identifier the
+// script uses, including the names of the scripts it extends and calls.
+func ScriptReferences(r io.Reader) ([]string, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, errNotScript
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.BigEndian.Uint32(magic[:]) == pexMagic:
+		order = binary.BigEndian
+	case binary.LittleEndian.Uint32(magic[:]) == pexMagic:
+		order = binary.LittleEndian
+	default:
+		return nil, errNotScript
+	}
+
+	// Major and minor version, game ID and compilation time
+	if _, err := io.CopyN(io.Discard, r, 1+1+2+8); err != nil {
+		return nil, fmt.Errorf("read script header: %w", err)
+	}
+	// Source file, user and machine names
+	for range 3 {
+		if _, err := readPexString(r, order); err != nil {
+			return nil, fmt.Errorf("read script header: %w", err)
+		}
+	}
+
+	var count uint16
+	if err := binary.Read(r, order, &count); err != nil {
+		return nil, fmt.Errorf("read script strings: %w", err)
+	}
+	strs := make([]string, 0, count)
+	for range count {
+		s, err := readPexString(r, order)
+		if err != nil {
+			return nil, fmt.Errorf("read script strings: %w", err)
+		}
+		strs = append(strs, strings.ToLower(s))
+	}
+	return strs, nil
+}
+
+// readPexString reads a string prefixed with its 16-bit length.
+func readPexString(r io.Reader, order binary.ByteOrder) (string, error) {
+	var n uint16
+	if err := binary.Read(r, order, &n); err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// sortedKeys returns the keys of a set in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// buildPex builds a compiled script header and string table.
+func buildPex(order binary.ByteOrder, strs ...string) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, order, uint32(pexMagic))
+	buf.Write([]byte{3, 2})
+	binary.Write(&buf, order, uint16(1))
+	binary.Write(&buf, order, uint64(0))
+	writeString := func(s string) {
+		binary.Write(&buf, order, uint16(len(s)))
+		buf.WriteString(s)
+	}
+	for _, s := range []string{"MyScript.psc", "user", "machine"} {
+		writeString(s)
+	}
+	binary.Write(&buf, order, uint16(len(strs)))
+	for _, s := range strs {
+		writeString(s)
+	}
+	return buf.Bytes()
+}
+
+func TestScriptReferences(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		names, err := ScriptReferences(bytes.NewReader(buildPex(order, "MyScript", "SKI_ConfigBase", "OnConfigInit")))
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", order, err)
+		}
+		if len(names) != 3 || names[1] != "ski_configbase" {
+			t.Errorf("%v: unexpected strings: %v", order, names)
+		}
+	}
+
+	if _, err := ScriptReferences(bytes.NewReader([]byte("not a script"))); err == nil {
+		t.Error("expected error for data that is not a script")
+	}
+}
+
+func TestCheckFrameworks(t *testing.T) {
+	files := func(paths ...string) *manifest.Manifest {
+		var entries []manifest.FileEntry
+		for _, p := range paths {
+			entries = append(entries, manifest.NewFileEntry(p, 10))
+		}
+		return manifest.NewManifest(entries)
+	}
+
+	mods := []ModReferences{
+		{ModID: "1", ModName: "Menu Mod", Manifest: files("MenuMod.esp", "scripts/menumod_mcm.pex"),
+			References: &References{Scripts: []string{"menumod_mcm", "ski_configbase"}}},
+		{ModID: "2", ModName: "Old Menu", Manifest: files("OldMenu.esp"), Masters: []string{"Skyrim.esm", "SKYUI_SE.esp"}},
+		{ModID: "3", ModName: "Settings", Manifest: files("Data/MCM/Config/Settings/config.json")},
+		{ModID: "4", ModName: "Native", Manifest: files("SKSE/Plugins/Native.dll"),
+			References: &References{DLLFrameworks: []string{"Address Library"}}},
+		{ModID: "5", ModName: "Storage", References: &References{Scripts: []string{"storageutil"}}},
+		{ModID: "6", ModName: "PapyrusUtil SE", Manifest: files("SKSE/Plugins/PapyrusUtil.dll", "scripts/StorageUtil.pex")},
+	}
+
+	findings := CheckFrameworks(mods, DefaultFrameworks)
+	want := map[string]Severity{
+		"1": SeverityWarning,
+		"2": SeverityError,
+		"3": SeverityWarning,
+		"4": SeverityWarning,
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for _, f := range findings {
+		if f.Type != FindingMissingFramework {
+			t.Errorf("unexpected finding type %s", f.Type)
+		}
+		if severity, ok := want[f.ModID]; !ok || f.Severity != severity {
+			t.Errorf("unexpected finding for mod %s: %+v", f.ModID, f)
+		}
+	}
+
+	// Adding the frameworks clears the findings
+	mods = append(mods,
+		ModReferences{ModID: "7", ModName: "SkyUI", Manifest: files("SkyUI_SE.esp", "SkyUI_SE.bsa")},
+		ModReferences{ModID: "8", ModName: "MCM Helper", Manifest: files("SKSE/Plugins/MCMHelper.dll")},
+		ModReferences{ModID: "9", ModName: "Address Library", Manifest: files("SKSE/Plugins/versionlib-1-6-1170-0.bin")},
+	)
+	if findings := CheckFrameworks(mods, DefaultFrameworks); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+}
+
+func TestScanReferences(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "mod.zip")
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	zw := zip.NewWriter(out)
+	wide := []byte{}
+	for _, c := range []byte(`Data\SKSE\Plugins\versionlib-`) {
+		wide = append(wide, c, 0)
+	}
+	files := map[string][]byte{
+		"Scripts/MyMCM.pex":          buildPex(binary.BigEndian, "MyMCM", "MCM_ConfigBase"),
+		"Scripts/Broken.pex":         []byte("pex"),
+		"SKSE/Plugins/Native.dll":    append([]byte("MZ\x00\x00"), wide...),
+		"SKSE/Plugins/Unrelated.dll": []byte("MZ nothing here"),
+	}
+	for name, data := range files {
+		w, _ := zw.Create(name)
+		w.Write(data)
+	}
+	zw.Close()
+	out.Close()
+
+	refs, err := ScanReferences(context.Background(), archivePath, "", DefaultFrameworks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(refs.Scripts) != 2 || refs.Scripts[0] != "mcm_configbase" {
+		t.Errorf("unexpected scripts: %v", refs.Scripts)
+	}
+	if len(refs.DLLFrameworks) != 1 || refs.DLLFrameworks[0] != "Address Library" {
+		t.Errorf("unexpected DLL frameworks: %v", refs.DLLFrameworks)
+	}
+}
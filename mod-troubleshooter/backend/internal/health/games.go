@@ -0,0 +1,36 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModGame is the game a mod file is published for on Nexus.
+type ModGame struct {
+	ModID   string
+	ModName string
+	// Game is the mod's game domain, or "" when it matches the collection's.
+	Game string
+}
+
+// CheckGames flags mods published for another game than the collection's,
+// such as a Skyrim LE texture pack in a Skyrim SE collection. Nexus lets
+// curators add them, but they are usually picked by mistake: plugins and
+// meshes often need conversion, and the file may not install at all.
+func CheckGames(game string, mods []ModGame) []Finding {
+	var findings []Finding
+	for _, mod := range mods {
+		if mod.Game == "" || strings.EqualFold(mod.Game, game) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingGameMismatch,
+			Severity: SeverityWarning,
+			ModID:    mod.ModID,
+			ModName:  mod.ModName,
+			Message: fmt.Sprintf("%s is published for %s, not %s; it was probably added to the collection by mistake",
+				nameOf(ModIdentity{ModID: mod.ModID, ModName: mod.ModName}), mod.Game, game),
+		})
+	}
+	return findings
+}
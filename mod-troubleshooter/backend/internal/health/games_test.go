@@ -0,0 +1,20 @@
+package health
+
+import "testing"
+
+func TestCheckGames(t *testing.T) {
+	mods := []ModGame{
+		{ModID: "1", ModName: "Same Game", Game: ""},
+		{ModID: "2", ModName: "Case Only", Game: "SkyrimSpecialEdition"},
+		{ModID: "3", ModName: "LE Textures", Game: "skyrim"},
+	}
+
+	findings := CheckGames("skyrimspecialedition", mods)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	f := findings[0]
+	if f.Type != FindingGameMismatch || f.Severity != SeverityWarning || f.ModID != "3" {
+		t.Errorf("unexpected finding %+v", f)
+	}
+}
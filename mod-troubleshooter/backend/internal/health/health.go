@@ -0,0 +1,190 @@
+// Package health summarizes collection-level problems into a scored report.
+package health
+
+import "sort"
+
+// Severity indicates how serious a finding is.
+type Severity string
+
+const (
+	// SeverityError indicates the collection will not install or run correctly.
+	SeverityError Severity = "error"
+	// SeverityWarning indicates a likely problem worth reviewing.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo is informational only.
+	SeverityInfo Severity = "info"
+)
+
+// FindingType identifies what kind of problem a finding describes.
+type FindingType string
+
+const (
+	// FindingDownloadFailed indicates a mod file could not be downloaded or read.
+	FindingDownloadFailed FindingType = "download_failed"
+	// FindingUnavailableMod indicates a mod file was deleted or hidden on Nexus,
+	// so the collection cannot be installed as published.
+	FindingUnavailableMod FindingType = "unavailable_mod"
+	// FindingEmptyArchive indicates a mod archive contains no files.
+	FindingEmptyArchive FindingType = "empty_archive"
+	// FindingUnsupportedArchive indicates a mod archive could not be fully read,
+	// so the analysis of that mod is partial.
+	FindingUnsupportedArchive FindingType = "unsupported_archive"
+	// FindingDuplicateMod indicates two files of the same mod in different versions.
+	FindingDuplicateMod FindingType = "duplicate_mod"
+	// FindingProbableFork indicates two different mods with nearly identical contents.
+	FindingProbableFork FindingType = "probable_fork"
+	// FindingVersionMismatch indicates Nexus reports a different version for
+	// a file than the collection pinned, so the file was probably re-uploaded.
+	FindingVersionMismatch FindingType = "version_mismatch"
+	// FindingHeaderVersionMismatch indicates a plugin header declares a
+	// different version than its mod file.
+	FindingHeaderVersionMismatch FindingType = "header_version_mismatch"
+	// FindingGameMismatch indicates a mod published for another game than
+	// the collection's.
+	FindingGameMismatch FindingType = "game_mismatch"
+	// FindingUnknownDLL indicates a script extender plugin that is not on
+	// the allowlist of known plugins.
+	FindingUnknownDLL FindingType = "unknown_dll"
+	// FindingUnknownDLLBuild indicates a known script extender plugin whose
+	// content matches none of its known builds.
+	FindingUnknownDLLBuild FindingType = "unknown_dll_build"
+	// FindingMissingFramework indicates a mod uses a framework, such as
+	// SkyUI or PapyrusUtil, that no mod in the collection provides.
+	FindingMissingFramework FindingType = "missing_framework"
+	// FindingAddressLibraryMismatch indicates the collection ships Address
+	// Library databases, but none for the game runtime it targets.
+	FindingAddressLibraryMismatch FindingType = "address_library_mismatch"
+	// FindingPluginRuntimeMismatch indicates a script extender plugin built
+	// for a different game runtime than the collection targets.
+	FindingPluginRuntimeMismatch FindingType = "plugin_runtime_mismatch"
+	// FindingOrphanedAssets indicates assets nothing in the collection
+	// appears to use, which only take up space.
+	FindingOrphanedAssets FindingType = "orphaned_assets"
+	// FindingCommunityReport indicates a bug report on Nexus that looks like
+	// a crash or incompatibility. It has not been verified.
+	FindingCommunityReport FindingType = "community_report"
+)
+
+// Rating is an overall verdict derived from the score.
+type Rating string
+
+const (
+	RatingHealthy        Rating = "healthy"
+	RatingNeedsAttention Rating = "needs_attention"
+	RatingBroken         Rating = "broken"
+)
+
+// Score penalties per finding severity.
+const (
+	errorPenalty   = 15
+	warningPenalty = 5
+	infoPenalty    = 0
+)
+
+// Finding is a single health problem.
+type Finding struct {
+	// Type identifies what kind of problem this is.
+	Type FindingType `json:"type"`
+	// Severity indicates how serious the problem is.
+	Severity Severity `json:"severity"`
+	// ModID is the affected mod, if the finding is mod-specific.
+	ModID string `json:"modId,omitempty"`
+	// ModName is the display name of the affected mod.
+	ModName string `json:"modName,omitempty"`
+	// Message is a human-readable description.
+	Message string `json:"message"`
+	// Unverified is true for findings taken from community reports that
+	// nobody has confirmed.
+	Unverified bool `json:"unverified,omitempty"`
+}
+
+// Report is the health summary of a collection revision.
+type Report struct {
+	// Score is 0-100, higher is healthier.
+	Score int `json:"score"`
+	// Rating is the overall verdict.
+	Rating Rating `json:"rating"`
+	// ModsTotal is the number of mod files in the collection.
+	ModsTotal int `json:"modsTotal"`
+	// ModsFailed is the number of mod files that could not be analyzed.
+	ModsFailed int `json:"modsFailed"`
+	// ErrorCount is the number of error findings.
+	ErrorCount int `json:"errorCount"`
+	// WarningCount is the number of warning findings.
+	WarningCount int `json:"warningCount"`
+	// Findings lists all problems, most severe first.
+	Findings []Finding `json:"findings"`
+	// Compatibility is the per-platform breakdown of the mods that could be inspected.
+	Compatibility *Compatibility `json:"compatibility,omitempty"`
+	// Linux is the readiness for Linux and the Steam Deck: what behaves
+	// differently on case-sensitive file systems and which Windows tools
+	// the collection ships.
+	Linux *LinuxCompatibility `json:"linux,omitempty"`
+	// NativePlugins are the SKSE plugins whose archives were read, with the
+	// game runtimes they are built for.
+	NativePlugins []NativePlugin `json:"nativePlugins,omitempty"`
+	// DeadWeight is the assets nothing in the collection appears to use,
+	// with their total size.
+	DeadWeight *DeadWeight `json:"deadWeight,omitempty"`
+}
+
+// NewReport creates an empty report for a collection with the given number of mods.
+func NewReport(modsTotal int) *Report {
+	return &Report{
+		ModsTotal: modsTotal,
+		Findings:  []Finding{},
+	}
+}
+
+// Add records a finding.
+func (r *Report) Add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+// Finalize sorts findings and computes counts, score and rating.
+// Call it once after all findings have been added.
+func (r *Report) Finalize() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return severityRank(r.Findings[i].Severity) < severityRank(r.Findings[j].Severity)
+	})
+
+	r.ErrorCount, r.WarningCount = 0, 0
+	score := 100
+	for _, f := range r.Findings {
+		switch f.Severity {
+		case SeverityError:
+			r.ErrorCount++
+			score -= errorPenalty
+		case SeverityWarning:
+			r.WarningCount++
+			score -= warningPenalty
+		default:
+			score -= infoPenalty
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+	r.Score = score
+
+	switch {
+	case score < 50:
+		r.Rating = RatingBroken
+	case score < 80 || r.ErrorCount > 0:
+		r.Rating = RatingNeedsAttention
+	default:
+		r.Rating = RatingHealthy
+	}
+}
+
+// severityRank orders severities from most to least serious.
+func severityRank(s Severity) int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
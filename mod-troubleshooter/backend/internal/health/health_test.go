@@ -0,0 +1,51 @@
+package health
+
+import "testing"
+
+func TestReport_Finalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		severities []Severity
+		wantScore  int
+		wantRating Rating
+	}{
+		{"no findings", nil, 100, RatingHealthy},
+		{"info only", []Severity{SeverityInfo, SeverityInfo}, 100, RatingHealthy},
+		{"one warning", []Severity{SeverityWarning}, 95, RatingHealthy},
+		{"one error", []Severity{SeverityError}, 85, RatingNeedsAttention},
+		{"many errors", []Severity{SeverityError, SeverityError, SeverityError, SeverityError}, 40, RatingBroken},
+		{"floored at zero", []Severity{SeverityError, SeverityError, SeverityError, SeverityError, SeverityError, SeverityError, SeverityError}, 0, RatingBroken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReport(10)
+			for _, s := range tt.severities {
+				r.Add(Finding{Type: FindingDownloadFailed, Severity: s, Message: "x"})
+			}
+			r.Finalize()
+
+			if r.Score != tt.wantScore {
+				t.Errorf("expected score %d, got %d", tt.wantScore, r.Score)
+			}
+			if r.Rating != tt.wantRating {
+				t.Errorf("expected rating %s, got %s", tt.wantRating, r.Rating)
+			}
+		})
+	}
+}
+
+func TestReport_FinalizeSortsAndCounts(t *testing.T) {
+	r := NewReport(3)
+	r.Add(Finding{Severity: SeverityInfo, Message: "info"})
+	r.Add(Finding{Severity: SeverityWarning, Message: "warning"})
+	r.Add(Finding{Severity: SeverityError, Message: "error"})
+	r.Finalize()
+
+	if r.Findings[0].Severity != SeverityError || r.Findings[2].Severity != SeverityInfo {
+		t.Errorf("expected findings sorted by severity, got %+v", r.Findings)
+	}
+	if r.ErrorCount != 1 || r.WarningCount != 1 {
+		t.Errorf("expected 1 error and 1 warning, got %d and %d", r.ErrorCount, r.WarningCount)
+	}
+}
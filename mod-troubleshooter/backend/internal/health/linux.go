@@ -0,0 +1,203 @@
+package health
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// ModCasing is what a mod names, spelled as the mod spells it.
+type ModCasing struct {
+	ModID   string
+	ModName string
+	// Manifest is the file listing, if it could be read.
+	Manifest *manifest.Manifest
+	// Plugins are the mod's plugins with the masters their headers
+	// reference, if headers were read.
+	Plugins []PluginMasters
+}
+
+// PluginMasters is a plugin and the masters its header references.
+type PluginMasters struct {
+	Filename string
+	Masters  []string
+}
+
+// CaseSpelling is one way a path is spelled, with the mods spelling it so.
+type CaseSpelling struct {
+	Name string   `json:"name"`
+	Mods []string `json:"mods"`
+}
+
+// CaseConflict is a file or directory that mods spell with different
+// casing. Windows treats the spellings as one path; a case-sensitive file
+// system keeps them apart, so files stop overriding each other and a
+// directory splits in two.
+type CaseConflict struct {
+	// Path is the normalized path.
+	Path string `json:"path"`
+	// Directory is true when the path is a directory.
+	Directory bool `json:"directory,omitempty"`
+	// Spellings are the different spellings of the last path element.
+	Spellings []CaseSpelling `json:"spellings"`
+}
+
+// MasterCaseMismatch is a plugin master reference whose casing differs
+// from the plugin the collection ships.
+type MasterCaseMismatch struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// Plugin is the plugin with the reference.
+	Plugin string `json:"plugin"`
+	// Master is the master as the header spells it.
+	Master string `json:"master"`
+	// Filename is the master as the collection ships it.
+	Filename string `json:"filename"`
+}
+
+// LinuxCompatibility is how a collection behaves on Linux and the Steam
+// Deck with Proton, where the file system is case-sensitive and Windows
+// tools do not run natively.
+type LinuxCompatibility struct {
+	// Verdict is the collection's readiness, explained by Reasons.
+	Verdict DeckVerdict `json:"verdict,omitempty"`
+	Reasons []string    `json:"reasons,omitempty"`
+	// Compatible is true when nothing depends on case-insensitive lookups.
+	Compatible           bool                 `json:"compatible"`
+	CaseConflicts        []CaseConflict       `json:"caseConflicts"`
+	MasterCaseMismatches []MasterCaseMismatch `json:"masterCaseMismatches"`
+	WindowsTools         []WindowsTool        `json:"windowsTools,omitempty"`
+}
+
+// caseEntry collects the spellings of one normalized path.
+type caseEntry struct {
+	directory bool
+	spellings map[string][]string
+	order     []string
+}
+
+// CheckCaseSensitivity finds paths and master references that only match
+// case-insensitively. Files packed in BSA or BA2 archives are skipped, as
+// the game looks them up without regard to case on every platform.
+func CheckCaseSensitivity(mods []ModCasing) *LinuxCompatibility {
+	l := &LinuxCompatibility{
+		CaseConflicts:        []CaseConflict{},
+		MasterCaseMismatches: []MasterCaseMismatch{},
+	}
+
+	entries := make(map[string]*caseEntry)
+	shipped := make(map[string]string)
+	for _, mod := range mods {
+		name := modDisplayName(mod.ModID, mod.ModName)
+		seen := make(map[string]bool)
+		for _, f := range manifestFiles(mod.Manifest) {
+			elems := pathElements(f.OriginalPath)
+			for i, elem := range elems {
+				key := strings.ToLower(strings.Join(elems[:i+1], "/"))
+				e := entries[key]
+				if e == nil {
+					e = &caseEntry{spellings: make(map[string][]string)}
+					entries[key] = e
+				}
+				e.directory = e.directory || i < len(elems)-1
+				if seen[key+"\x00"+elem] {
+					continue
+				}
+				seen[key+"\x00"+elem] = true
+				if _, ok := e.spellings[elem]; !ok {
+					e.order = append(e.order, elem)
+				}
+				e.spellings[elem] = append(e.spellings[elem], name)
+			}
+			if f.Type == manifest.FileTypePlugin && len(elems) > 0 {
+				addShipped(shipped, elems[len(elems)-1])
+			}
+		}
+		for _, p := range mod.Plugins {
+			addShipped(shipped, p.Filename)
+		}
+	}
+
+	for key, e := range entries {
+		if len(e.order) < 2 {
+			continue
+		}
+		c := CaseConflict{Path: key, Directory: e.directory}
+		for _, elem := range e.order {
+			c.Spellings = append(c.Spellings, CaseSpelling{Name: elem, Mods: e.spellings[elem]})
+		}
+		l.CaseConflicts = append(l.CaseConflicts, c)
+	}
+	sort.Slice(l.CaseConflicts, func(i, j int) bool {
+		return l.CaseConflicts[i].Path < l.CaseConflicts[j].Path
+	})
+
+	for _, mod := range mods {
+		for _, p := range mod.Plugins {
+			for _, master := range p.Masters {
+				filename, ok := shipped[strings.ToLower(master)]
+				if !ok || filename == master {
+					continue
+				}
+				l.MasterCaseMismatches = append(l.MasterCaseMismatches, MasterCaseMismatch{
+					ModID:    mod.ModID,
+					ModName:  mod.ModName,
+					Plugin:   p.Filename,
+					Master:   master,
+					Filename: filename,
+				})
+			}
+		}
+	}
+
+	l.Compatible = len(l.CaseConflicts) == 0 && len(l.MasterCaseMismatches) == 0
+	return l
+}
+
+// manifestFiles returns the loose files of a manifest.
+func manifestFiles(m *manifest.Manifest) []manifest.FileEntry {
+	if m == nil {
+		return nil
+	}
+	files := make([]manifest.FileEntry, 0, len(m.Files))
+	for _, f := range m.Files {
+		if f.Archive == "" && f.OriginalPath != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// pathElements splits an archive path into its elements, without a
+// leading Data folder, which is where the files are installed to anyway.
+func pathElements(p string) []string {
+	p = strings.Trim(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+	if p == "" || p == "." {
+		return nil
+	}
+	elems := strings.Split(p, "/")
+	if len(elems) > 1 && strings.EqualFold(elems[0], "data") {
+		elems = elems[1:]
+	}
+	return elems
+}
+
+// addShipped records the first spelling of a plugin the collection ships.
+func addShipped(shipped map[string]string, filename string) {
+	if filename == "" {
+		return
+	}
+	if _, ok := shipped[strings.ToLower(filename)]; !ok {
+		shipped[strings.ToLower(filename)] = filename
+	}
+}
+
+// modDisplayName returns a mod's name, or its ID if it has none.
+func modDisplayName(modID, modName string) string {
+	if modName != "" {
+		return modName
+	}
+	return modID
+}
@@ -0,0 +1,60 @@
+package health
+
+import "testing"
+
+func TestCheckCaseSensitivity(t *testing.T) {
+	mods := []ModCasing{
+		{
+			ModID:    "a",
+			ModName:  "Armor",
+			Manifest: manifestOf("Data/Textures/Armor/iron.dds", "Armor.esp"),
+			Plugins:  []PluginMasters{{Filename: "Armor.esp", Masters: []string{"Skyrim.esm"}}},
+		},
+		{
+			ModID:    "b",
+			ModName:  "Armor Fix",
+			Manifest: manifestOf("textures/armor/Iron.dds", "ArmorFix.esp"),
+			Plugins:  []PluginMasters{{Filename: "ArmorFix.esp", Masters: []string{"Skyrim.esm", "armor.esp"}}},
+		},
+	}
+
+	l := CheckCaseSensitivity(mods)
+
+	if l.Compatible {
+		t.Error("expected the collection not to be compatible")
+	}
+	want := map[string]bool{"textures": true, "textures/armor": true, "textures/armor/iron.dds": false}
+	if len(l.CaseConflicts) != len(want) {
+		t.Fatalf("expected %d case conflicts, got %+v", len(want), l.CaseConflicts)
+	}
+	for _, c := range l.CaseConflicts {
+		directory, ok := want[c.Path]
+		if !ok || c.Directory != directory {
+			t.Errorf("unexpected conflict %+v", c)
+		}
+		if len(c.Spellings) != 2 || c.Spellings[0].Mods[0] != "Armor" || c.Spellings[1].Mods[0] != "Armor Fix" {
+			t.Errorf("unexpected spellings for %s: %+v", c.Path, c.Spellings)
+		}
+	}
+
+	// Skyrim.esm is not shipped by the collection, so only armor.esp is checked
+	if len(l.MasterCaseMismatches) != 1 {
+		t.Fatalf("expected 1 master mismatch, got %+v", l.MasterCaseMismatches)
+	}
+	if m := l.MasterCaseMismatches[0]; m.ModID != "b" || m.Master != "armor.esp" || m.Filename != "Armor.esp" {
+		t.Errorf("unexpected master mismatch %+v", m)
+	}
+}
+
+func TestCheckCaseSensitivity_Consistent(t *testing.T) {
+	mods := []ModCasing{
+		{ModID: "a", Manifest: manifestOf("textures/a.dds", "A.esp")},
+		{ModID: "b", Manifest: manifestOf("Data/textures/b.dds"), Plugins: []PluginMasters{{Filename: "B.esp", Masters: []string{"A.esp"}}}},
+	}
+
+	l := CheckCaseSensitivity(mods)
+
+	if !l.Compatible || len(l.CaseConflicts) != 0 || len(l.MasterCaseMismatches) != 0 {
+		t.Errorf("expected a compatible collection, got %+v", l)
+	}
+}
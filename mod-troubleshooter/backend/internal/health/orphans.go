@@ -0,0 +1,228 @@
+package health
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// maxOrphanExamples limits how many files are listed per group of orphans.
+const maxOrphanExamples = 5
+
+// OrphanKind is why assets appear to be unused.
+type OrphanKind string
+
+const (
+	// OrphanFaceGen is FaceGen data for NPCs of a plugin the collection
+	// does not include.
+	OrphanFaceGen OrphanKind = "facegen"
+	// OrphanVoice is voice files for a plugin the collection does not include.
+	OrphanVoice OrphanKind = "voice"
+	// OrphanPackedCopy is a file packed in a BSA or BA2 that a loose file of
+	// the same path always overrides, so the packed copy is never loaded.
+	OrphanPackedCopy OrphanKind = "packed_copy"
+)
+
+// pluginAssetDirs are directories whose subdirectories are named after the
+// plugin the assets belong to.
+var pluginAssetDirs = []struct {
+	dir  string
+	kind OrphanKind
+}{
+	{"meshes/actors/character/facegendata/facegeom/", OrphanFaceGen},
+	{"textures/actors/character/facegendata/facetint/", OrphanFaceGen},
+	{"sound/voice/", OrphanVoice},
+}
+
+// OrphanedAssets are files of one mod that nothing in the collection
+// appears to use, for the same reason.
+type OrphanedAssets struct {
+	ModID   string     `json:"modId"`
+	ModName string     `json:"modName"`
+	Kind    OrphanKind `json:"kind"`
+	// Plugin is the plugin FaceGen and voice files belong to.
+	Plugin string `json:"plugin,omitempty"`
+	// Archive is the BSA or BA2 holding packed copies.
+	Archive string `json:"archive,omitempty"`
+	Files   int    `json:"files"`
+	Size    int64  `json:"size"`
+	// Examples are some of the files, as the mod spells them.
+	Examples []string `json:"examples"`
+}
+
+// DeadWeight is the assets a collection ships that nothing appears to use.
+// It is a heuristic: assets can be referenced in ways the analysis cannot
+// see, such as from plugin records.
+type DeadWeight struct {
+	Files  int              `json:"files"`
+	Size   int64            `json:"size"`
+	Groups []OrphanedAssets `json:"groups"`
+}
+
+// ModAssets is a mod's file listing for the dead weight check.
+type ModAssets struct {
+	ModID    string
+	ModName  string
+	Manifest *manifest.Manifest
+}
+
+// FindDeadWeight finds assets nothing in the collection appears to use:
+// FaceGen and voice files filed under a plugin that is neither in the
+// collection nor part of the game, and files packed in archives that a
+// loose copy overrides. Plugin assets are only checked when every mod's
+// file listing was read, since a missing listing may hold the plugin.
+func FindDeadWeight(game string, mods []ModAssets, complete bool) *DeadWeight {
+	plugins := make(map[string]bool)
+	loose := make(map[string]bool)
+	for _, mod := range mods {
+		if mod.Manifest == nil {
+			continue
+		}
+		for _, f := range mod.Manifest.Files {
+			if f.Type == manifest.FileTypePlugin {
+				plugins[f.Filename] = true
+			}
+			if f.Archive == "" {
+				loose[assetPath(f.Path)] = true
+			}
+		}
+	}
+
+	dw := &DeadWeight{Groups: []OrphanedAssets{}}
+	for _, mod := range mods {
+		if mod.Manifest == nil {
+			continue
+		}
+		groups := make(map[string]*OrphanedAssets)
+		var order []string
+		add := func(key string, g OrphanedAssets, f manifest.FileEntry) {
+			existing, ok := groups[key]
+			if !ok {
+				g.ModID, g.ModName, g.Examples = mod.ModID, mod.ModName, []string{}
+				existing = &g
+				groups[key] = existing
+				order = append(order, key)
+			}
+			existing.Files++
+			existing.Size += f.Size
+			if len(existing.Examples) < maxOrphanExamples {
+				name := f.OriginalPath
+				if name == "" {
+					name = f.Path
+				}
+				existing.Examples = append(existing.Examples, name)
+			}
+		}
+
+		for _, f := range mod.Manifest.Files {
+			p := assetPath(f.Path)
+			if f.Archive != "" {
+				if loose[p] {
+					add("packed\x00"+f.Archive, OrphanedAssets{Kind: OrphanPackedCopy, Archive: f.Archive}, f)
+				}
+				continue
+			}
+			if !complete {
+				continue
+			}
+			kind, plugin := pluginAsset(p)
+			if plugin == "" || plugins[plugin] || loadorder.IsBasePlugin(game, plugin) || loadorder.IsCreationClubPlugin(plugin) {
+				continue
+			}
+			add(string(kind)+"\x00"+plugin, OrphanedAssets{Kind: kind, Plugin: originalPluginName(f, plugin)}, f)
+		}
+
+		for _, key := range order {
+			g := groups[key]
+			dw.Files += g.Files
+			dw.Size += g.Size
+			dw.Groups = append(dw.Groups, *g)
+		}
+	}
+
+	sort.SliceStable(dw.Groups, func(i, j int) bool {
+		return dw.Groups[i].Size > dw.Groups[j].Size
+	})
+	return dw
+}
+
+// assetPath returns a normalized path without a leading Data folder.
+func assetPath(p string) string {
+	return strings.TrimPrefix(p, "data/")
+}
+
+// pluginAsset returns the kind and lowercase plugin of an asset filed
+// under a plugin, or "" if it is not one.
+func pluginAsset(p string) (OrphanKind, string) {
+	for _, d := range pluginAssetDirs {
+		i := strings.Index("/"+p, "/"+d.dir)
+		if i < 0 {
+			continue
+		}
+		rest := p[i+len(d.dir):]
+		plugin, _, ok := strings.Cut(rest, "/")
+		if !ok {
+			return "", ""
+		}
+		switch path.Ext(plugin) {
+		case ".esp", ".esm", ".esl":
+			return d.kind, plugin
+		}
+		return "", ""
+	}
+	return "", ""
+}
+
+// originalPluginName returns a plugin folder name as the mod spells it.
+func originalPluginName(f manifest.FileEntry, plugin string) string {
+	for _, elem := range strings.Split(strings.ReplaceAll(f.OriginalPath, "\\", "/"), "/") {
+		if strings.EqualFold(elem, plugin) {
+			return elem
+		}
+	}
+	return plugin
+}
+
+// DeadWeightFindings describes the dead weight as informational findings,
+// one per group.
+func DeadWeightFindings(dw *DeadWeight) []Finding {
+	var findings []Finding
+	for _, g := range dw.Groups {
+		name := nameOf(ModIdentity{ModID: g.ModID, ModName: g.ModName})
+		var message string
+		switch g.Kind {
+		case OrphanFaceGen:
+			message = fmt.Sprintf("%s ships %d FaceGen files (%s) for %s, which is not in the collection", name, g.Files, formatSize(g.Size), g.Plugin)
+		case OrphanVoice:
+			message = fmt.Sprintf("%s ships %d voice files (%s) for %s, which is not in the collection", name, g.Files, formatSize(g.Size), g.Plugin)
+		case OrphanPackedCopy:
+			message = fmt.Sprintf("%s packs %d files (%s) in %s that loose files of the same path override, so the packed copies are never loaded", name, g.Files, formatSize(g.Size), g.Archive)
+		}
+		findings = append(findings, Finding{
+			Type:     FindingOrphanedAssets,
+			Severity: SeverityInfo,
+			ModID:    g.ModID,
+			ModName:  g.ModName,
+			Message:  message,
+		})
+	}
+	return findings
+}
+
+// formatSize formats a byte count for messages.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func TestFindDeadWeight(t *testing.T) {
+	packed := manifest.NewFileEntry("textures/armor/iron.dds", 4096)
+	packed.Archive = "Armor - Textures.bsa"
+	armor := manifestOf("Armor.esp", "Armor - Textures.bsa", "textures/armor/steel.dds")
+	armor.Files = append(armor.Files, packed)
+
+	mods := []ModAssets{
+		{ModID: "a", ModName: "Armor", Manifest: armor},
+		{ModID: "b", ModName: "Armor Fix", Manifest: manifestOf("Data/Textures/Armor/Iron.dds")},
+		{ModID: "c", ModName: "NPC Overhaul", Manifest: manifestOf(
+			"meshes/actors/character/FaceGenData/FaceGeom/Removed.esp/00000D62.nif",
+			"textures/actors/character/facegendata/facetint/Removed.esp/00000D62.dds",
+			"meshes/actors/character/facegendata/facegeom/Skyrim.esm/00013BBD.nif",
+			"meshes/actors/character/facegendata/facegeom/Armor.esp/00000800.nif",
+			"sound/voice/ccbgssse001-fish.esm/maleyoung/line.fuz",
+		)},
+	}
+
+	dw := FindDeadWeight("skyrimspecialedition", mods, true)
+
+	if len(dw.Groups) != 2 {
+		t.Fatalf("expected 2 groups of dead weight, got %+v", dw.Groups)
+	}
+	byKind := make(map[OrphanKind]OrphanedAssets)
+	for _, g := range dw.Groups {
+		byKind[g.Kind] = g
+	}
+	if g := byKind[OrphanPackedCopy]; g.ModID != "a" || g.Files != 1 || g.Size != 4096 || g.Archive != "Armor - Textures.bsa" {
+		t.Errorf("unexpected packed copies %+v", g)
+	}
+	if g := byKind[OrphanFaceGen]; g.ModID != "c" || g.Plugin != "Removed.esp" || g.Files != 2 || g.Size != 2 {
+		t.Errorf("unexpected orphaned FaceGen %+v", g)
+	}
+	if dw.Files != 3 || dw.Size != 4098 {
+		t.Errorf("expected 3 files of 4098 bytes, got %d files of %d bytes", dw.Files, dw.Size)
+	}
+	if findings := DeadWeightFindings(dw); len(findings) != 2 || findings[0].Severity != SeverityInfo {
+		t.Errorf("expected 2 informational findings, got %+v", findings)
+	}
+
+	// Without every listing, a missing mod could ship the plugin
+	if dw := FindDeadWeight("skyrimspecialedition", mods, false); len(dw.Groups) != 1 || dw.Groups[0].Kind != OrphanPackedCopy {
+		t.Errorf("expected only packed copies with incomplete listings, got %+v", dw.Groups)
+	}
+}
@@ -0,0 +1,156 @@
+package health
+
+import (
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Platform is a game edition with its own modding restrictions.
+type Platform string
+
+const (
+	// PlatformSteam is the Steam PC edition, which supports all mods.
+	PlatformSteam Platform = "pc_steam"
+	// PlatformGamePass is the PC Game Pass edition, where script extenders are unavailable.
+	PlatformGamePass Platform = "pc_gamepass"
+	// PlatformXbox allows plugins and assets but no native code.
+	PlatformXbox Platform = "xbox"
+	// PlatformPlayStation allows plugins only, with no external assets.
+	PlatformPlayStation Platform = "playstation"
+)
+
+// Platforms lists every platform in display order.
+var Platforms = []Platform{PlatformSteam, PlatformGamePass, PlatformXbox, PlatformPlayStation}
+
+// Reasons a mod is unavailable on a platform.
+const (
+	ReasonScriptExtender = "requires a script extender"
+	ReasonNativeDLL      = "contains native DLLs"
+	ReasonLooseAssets    = "contains assets beyond plugins"
+)
+
+// scriptExtenderDirs are the plugin folders of the script extenders.
+var scriptExtenderDirs = []string{"skse/plugins/", "f4se/plugins/", "sfse/plugins/", "nvse/plugins/", "fose/plugins/", "obse/plugins/"}
+
+// ModTraits are the platform-relevant properties of a mod's files.
+type ModTraits struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// ScriptExtender is true when the mod ships script extender plugins.
+	ScriptExtender bool `json:"scriptExtender"`
+	// NativeDLL is true when the mod ships other native code, such as ENB or engine hooks.
+	NativeDLL bool `json:"nativeDll"`
+	// Plugins is true when the mod ships game plugins.
+	Plugins bool `json:"plugins"`
+	// Assets is true when the mod ships meshes, textures, scripts or other assets.
+	Assets bool `json:"assets"`
+}
+
+// TraitsFromManifest inspects a mod's files.
+func TraitsFromManifest(modID, modName string, m *manifest.Manifest) ModTraits {
+	traits := ModTraits{ModID: modID, ModName: modName}
+	if m == nil {
+		return traits
+	}
+
+	for _, f := range m.Files {
+		path := "/" + strings.TrimPrefix(f.Path, "data/")
+		switch {
+		case f.Extension == ".dll" && inScriptExtenderDir(path):
+			traits.ScriptExtender = true
+		case f.Extension == ".dll" || f.Extension == ".asi":
+			traits.NativeDLL = true
+		case f.Type == manifest.FileTypePlugin:
+			traits.Plugins = true
+		case f.Type != manifest.FileTypeOther:
+			traits.Assets = true
+		}
+	}
+	return traits
+}
+
+// inScriptExtenderDir reports whether a slash-prefixed path is in a script extender plugin folder.
+func inScriptExtenderDir(path string) bool {
+	for _, dir := range scriptExtenderDirs {
+		if strings.Contains(path, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unavailable returns why a mod cannot be used on a platform, or nil if it can.
+func (t ModTraits) Unavailable(p Platform) []string {
+	var reasons []string
+	if p == PlatformSteam {
+		return nil
+	}
+	if t.ScriptExtender {
+		reasons = append(reasons, ReasonScriptExtender)
+	}
+	if p == PlatformGamePass {
+		return reasons
+	}
+	if t.NativeDLL {
+		reasons = append(reasons, ReasonNativeDLL)
+	}
+	if p == PlatformPlayStation && t.Assets {
+		reasons = append(reasons, ReasonLooseAssets)
+	}
+	return reasons
+}
+
+// IncompatibleMod is a mod that cannot be used on a platform.
+type IncompatibleMod struct {
+	ModID   string   `json:"modId"`
+	ModName string   `json:"modName"`
+	Reasons []string `json:"reasons"`
+}
+
+// PlatformCompatibility summarizes a collection's compatibility with one platform.
+type PlatformCompatibility struct {
+	Platform     Platform          `json:"platform"`
+	Compatible   int               `json:"compatible"`
+	Incompatible []IncompatibleMod `json:"incompatible"`
+}
+
+// Compatibility is the per-platform breakdown of a collection.
+type Compatibility struct {
+	// AchievementsSafe is true when no mod ships plugins, which disable achievements.
+	AchievementsSafe bool `json:"achievementsSafe"`
+	// PluginMods is the number of mods that ship plugins.
+	PluginMods int                     `json:"pluginMods"`
+	Platforms  []PlatformCompatibility `json:"platforms"`
+}
+
+// ClassifyPlatforms builds the platform breakdown for a set of mods.
+func ClassifyPlatforms(mods []ModTraits) *Compatibility {
+	c := &Compatibility{Platforms: make([]PlatformCompatibility, 0, len(Platforms))}
+
+	for _, mod := range mods {
+		if mod.Plugins {
+			c.PluginMods++
+		}
+	}
+	c.AchievementsSafe = c.PluginMods == 0
+
+	for _, p := range Platforms {
+		pc := PlatformCompatibility{Platform: p, Incompatible: []IncompatibleMod{}}
+		for _, mod := range mods {
+			reasons := mod.Unavailable(p)
+			if len(reasons) == 0 {
+				pc.Compatible++
+				continue
+			}
+			pc.Incompatible = append(pc.Incompatible, IncompatibleMod{
+				ModID:   mod.ModID,
+				ModName: mod.ModName,
+				Reasons: reasons,
+			})
+		}
+		c.Platforms = append(c.Platforms, pc)
+	}
+
+	return c
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func manifestOf(paths ...string) *manifest.Manifest {
+	entries := make([]manifest.FileEntry, 0, len(paths))
+	for _, p := range paths {
+		entries = append(entries, manifest.NewFileEntry(p, 1))
+	}
+	return manifest.NewManifest(entries)
+}
+
+func TestTraitsFromManifest(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  ModTraits
+	}{
+		{"plugin only", []string{"MyMod.esp", "readme.txt"}, ModTraits{Plugins: true}},
+		{"skse plugin", []string{"SKSE/Plugins/po3_Tweaks.dll", "SKSE/Plugins/po3_Tweaks.ini"}, ModTraits{ScriptExtender: true}},
+		{"data prefix", []string{"Data/SKSE/Plugins/EngineFixes.dll"}, ModTraits{ScriptExtender: true}},
+		{"enb binary", []string{"d3d11.dll", "enbseries.ini"}, ModTraits{NativeDLL: true}},
+		{"textures", []string{"textures/armor/iron.dds", "Iron.esp"}, ModTraits{Plugins: true, Assets: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TraitsFromManifest("", "", manifestOf(tt.paths...))
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClassifyPlatforms(t *testing.T) {
+	mods := []ModTraits{
+		{ModID: "plugin", Plugins: true},
+		{ModID: "assets", Plugins: true, Assets: true},
+		{ModID: "skse", ScriptExtender: true},
+		{ModID: "enb", NativeDLL: true},
+	}
+
+	c := ClassifyPlatforms(mods)
+
+	if c.AchievementsSafe || c.PluginMods != 2 {
+		t.Errorf("expected 2 plugin mods and not achievements safe, got %+v", c)
+	}
+
+	want := map[Platform]int{
+		PlatformSteam:       4,
+		PlatformGamePass:    3,
+		PlatformXbox:        2,
+		PlatformPlayStation: 1,
+	}
+	for _, pc := range c.Platforms {
+		if pc.Compatible != want[pc.Platform] {
+			t.Errorf("expected %d compatible mods on %s, got %d", want[pc.Platform], pc.Platform, pc.Compatible)
+		}
+		if pc.Compatible+len(pc.Incompatible) != len(mods) {
+			t.Errorf("expected every mod classified on %s", pc.Platform)
+		}
+	}
+
+	if !ClassifyPlatforms([]ModTraits{{Assets: true}}).AchievementsSafe {
+		t.Error("expected asset-only collection to be achievements safe")
+	}
+}
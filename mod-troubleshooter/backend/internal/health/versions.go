@@ -0,0 +1,102 @@
+package health
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// headerVersionPattern finds a version declared in a plugin description,
+// such as "Version: 1.2.3" or "v1.2".
+var headerVersionPattern = regexp.MustCompile(`(?i)(?:\bversion\b\s*[:=]?\s*v?|\bv)(\d+(?:\.\d+)*[a-z]?)\b`)
+
+// ModVersions is what the version check knows about a mod file.
+type ModVersions struct {
+	ModID   string
+	ModName string
+	// PinnedVersion is the version the collection recorded for the file.
+	PinnedVersion string
+	// FileVersion is the version Nexus currently reports for the file.
+	FileVersion string
+	// Plugins are the parsed plugin headers in the file, if they were read.
+	Plugins []PluginDescription
+}
+
+// PluginDescription is the description a plugin header carries.
+type PluginDescription struct {
+	Filename    string
+	Description string
+}
+
+// HeaderVersion returns the version declared in a plugin header
+// description, or "" if it declares none.
+func HeaderVersion(description string) string {
+	m := headerVersionPattern.FindStringSubmatch(description)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// CheckVersions flags mod files whose versions disagree. A pinned version
+// that differs from the one Nexus reports means the author re-uploaded the
+// file under the same file ID, so players may get different content than
+// the curator tested. A plugin header declaring another version usually
+// means the author forgot to update it, and is informational only.
+func CheckVersions(mods []ModVersions) []Finding {
+	var findings []Finding
+	for _, mod := range mods {
+		if mod.PinnedVersion != "" && mod.FileVersion != "" && !SameVersion(mod.PinnedVersion, mod.FileVersion) {
+			findings = append(findings, Finding{
+				Type:     FindingVersionMismatch,
+				Severity: SeverityWarning,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message: fmt.Sprintf("The collection pins %s at version %s but Nexus now reports version %s; the file was probably re-uploaded and may differ from what the curator tested",
+					nameOfVersions(mod), mod.PinnedVersion, mod.FileVersion),
+			})
+		}
+
+		fileVersion := mod.FileVersion
+		if fileVersion == "" {
+			fileVersion = mod.PinnedVersion
+		}
+		if fileVersion == "" {
+			continue
+		}
+		for _, p := range mod.Plugins {
+			declared := HeaderVersion(p.Description)
+			if declared == "" || SameVersion(declared, fileVersion) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Type:     FindingHeaderVersionMismatch,
+				Severity: SeverityInfo,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message: fmt.Sprintf("%s declares version %s in its header but %s is version %s; the header was probably not updated",
+					p.Filename, declared, nameOfVersions(mod), fileVersion),
+			})
+		}
+	}
+	return findings
+}
+
+// SameVersion reports whether two version strings name the same version,
+// ignoring case, a leading "v" and trailing ".0" components.
+func SameVersion(a, b string) bool {
+	return normalizeVersion(a) == normalizeVersion(b)
+}
+
+func normalizeVersion(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	v = strings.TrimPrefix(v, "v")
+	for strings.HasSuffix(v, ".0") {
+		v = strings.TrimSuffix(v, ".0")
+	}
+	return v
+}
+
+func nameOfVersions(mod ModVersions) string {
+	return nameOf(ModIdentity{ModID: mod.ModID, ModName: mod.ModName})
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeaderVersion(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"Version: 1.2.3", "1.2.3"},
+		{"Adds new armors. v2.1", "2.1"},
+		{"version=4", "4"},
+		{"Beta VERSION 0.9b", "0.9b"},
+		{"Adds new armors.", ""},
+		{"Overhaul by Voices", ""},
+	}
+
+	for _, tt := range tests {
+		if got := HeaderVersion(tt.description); got != tt.want {
+			t.Errorf("HeaderVersion(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestSameVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2", "1.2", true},
+		{"1.2", "v1.2.0", true},
+		{"1.10", "1.1", false},
+		{"2.0.1", "2.0", false},
+		{"1.0A", "1.0a", true},
+	}
+
+	for _, tt := range tests {
+		if got := SameVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameVersion(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		mods []ModVersions
+		want []FindingType
+	}{
+		{
+			"versions agree",
+			[]ModVersions{{ModID: "1", PinnedVersion: "1.2", FileVersion: "1.2",
+				Plugins: []PluginDescription{{Filename: "a.esp", Description: "Version 1.2"}}}},
+			nil,
+		},
+		{
+			"re-uploaded under the same file ID",
+			[]ModVersions{{ModID: "1", PinnedVersion: "1.2", FileVersion: "1.3"}},
+			[]FindingType{FindingVersionMismatch},
+		},
+		{
+			"header not bumped",
+			[]ModVersions{{ModID: "1", PinnedVersion: "1.3", FileVersion: "1.3",
+				Plugins: []PluginDescription{{Filename: "a.esp", Description: "Version 1.2"}}}},
+			[]FindingType{FindingHeaderVersionMismatch},
+		},
+		{
+			"header compared to pinned version when Nexus has none",
+			[]ModVersions{{ModID: "1", PinnedVersion: "1.3",
+				Plugins: []PluginDescription{{Filename: "a.esp", Description: "v1.2"}}}},
+			[]FindingType{FindingHeaderVersionMismatch},
+		},
+		{
+			"unknown versions are not compared",
+			[]ModVersions{{ModID: "1", FileVersion: "1.3"}, {ModID: "2",
+				Plugins: []PluginDescription{{Filename: "b.esp", Description: "v1.2"}}}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []FindingType
+			for _, f := range CheckVersions(tt.mods) {
+				got = append(got, f.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
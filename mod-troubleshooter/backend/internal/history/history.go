@@ -0,0 +1,273 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/bundle"
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound is returned when a history entry does not exist.
+var ErrNotFound = errors.New("history entry not found")
+
+// Source identifies where a history entry came from.
+type Source string
+
+const (
+	// SourceImport marks an entry loaded from an exported bundle.
+	SourceImport Source = "import"
+	// SourceBatch marks an entry stored by a batch analysis.
+	SourceBatch Source = "batch"
+	// SourceWatch marks an entry stored for a new revision of a watched
+	// collection.
+	SourceWatch Source = "watch"
+)
+
+// Config holds configuration for the history store.
+type Config struct {
+	// DBPath is the path to the SQLite database file.
+	DBPath string
+}
+
+// Entry summarizes a stored analysis without its full results.
+type Entry struct {
+	// ID is the unique identifier of the entry.
+	ID int64 `json:"id"`
+	// Source is where the entry came from.
+	Source Source `json:"source"`
+	// Slug is the collection slug.
+	Slug string `json:"slug"`
+	// Revision is the collection revision number.
+	Revision int `json:"revision"`
+	// CollectionName is the display name of the collection, if known.
+	CollectionName string `json:"collectionName,omitempty"`
+	// GameDomain is the Nexus game domain, if known.
+	GameDomain string `json:"gameDomain,omitempty"`
+	// ConflictCount is the total number of file conflicts.
+	ConflictCount int `json:"conflictCount"`
+	// IssueCount is the total number of load order issues.
+	IssueCount int `json:"issueCount"`
+	// CreatedAt is when the analysis was originally produced.
+	CreatedAt time.Time `json:"createdAt"`
+	// StoredAt is when the entry was added to this store.
+	StoredAt time.Time `json:"storedAt"`
+}
+
+// Record is a history entry together with its full analysis bundle and notes.
+type Record struct {
+	Entry
+	Bundle *bundle.Bundle `json:"bundle"`
+	Notes  []Note         `json:"notes"`
+}
+
+// Store provides SQLite-backed storage of past analysis results.
+type Store struct {
+	db *sql.DB
+}
+
+// New creates a new history store with the given configuration.
+func New(cfg Config) (*Store, error) {
+	// Ensure the directory exists
+	dir := filepath.Dir(cfg.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize schema: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// initSchema creates the necessary tables.
+func initSchema(db *sql.DB) error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS analysis_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source TEXT NOT NULL,
+			slug TEXT NOT NULL,
+			revision INTEGER NOT NULL,
+			collection_name TEXT NOT NULL DEFAULT '',
+			game_domain TEXT NOT NULL DEFAULT '',
+			conflict_count INTEGER NOT NULL DEFAULT 0,
+			issue_count INTEGER NOT NULL DEFAULT 0,
+			data TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			stored_at INTEGER NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_analysis_history_slug ON analysis_history(slug, revision);
+
+		CREATE TABLE IF NOT EXISTS analysis_notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entry_id INTEGER NOT NULL,
+			target TEXT NOT NULL,
+			finding_index INTEGER NOT NULL,
+			subject TEXT NOT NULL DEFAULT '',
+			author TEXT NOT NULL DEFAULT '',
+			text TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_analysis_notes_entry ON analysis_notes(entry_id);
+	`
+	_, err := db.Exec(schema)
+	return err
+}
+
+// Add stores a bundle and returns the new entry.
+func (s *Store) Add(ctx context.Context, source Source, b *bundle.Bundle) (*Entry, error) {
+	if b == nil {
+		return nil, bundle.ErrNilBundle
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("marshal bundle: %w", err)
+	}
+
+	entry := Entry{
+		Source:         source,
+		Slug:           b.Metadata.Slug,
+		Revision:       b.Metadata.Revision,
+		CollectionName: b.Metadata.CollectionName,
+		GameDomain:     b.Metadata.GameDomain,
+		CreatedAt:      b.Metadata.CreatedAt,
+		StoredAt:       time.Now(),
+	}
+	if b.Conflicts != nil {
+		entry.ConflictCount = b.Conflicts.Stats.TotalConflicts
+	}
+	if b.LoadOrder != nil {
+		entry.IssueCount = b.LoadOrder.Stats.TotalIssues
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = entry.StoredAt
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO analysis_history (source, slug, revision, collection_name, game_domain,
+			conflict_count, issue_count, data, created_at, stored_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, string(entry.Source), entry.Slug, entry.Revision, entry.CollectionName, entry.GameDomain,
+		entry.ConflictCount, entry.IssueCount, string(data), entry.CreatedAt.UnixMilli(), entry.StoredAt.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("insert history entry: %w", err)
+	}
+
+	entry.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read history entry id: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// List returns all entries, most recently stored first.
+func (s *Store) List(ctx context.Context) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, source, slug, revision, collection_name, game_domain,
+			conflict_count, issue_count, created_at, stored_at
+		FROM analysis_history ORDER BY stored_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var source string
+		var createdAt, storedAt int64
+		if err := rows.Scan(&e.ID, &source, &e.Slug, &e.Revision, &e.CollectionName, &e.GameDomain,
+			&e.ConflictCount, &e.IssueCount, &createdAt, &storedAt); err != nil {
+			return nil, fmt.Errorf("scan history entry: %w", err)
+		}
+		e.Source = Source(source)
+		e.CreatedAt = time.UnixMilli(createdAt).UTC()
+		e.StoredAt = time.UnixMilli(storedAt).UTC()
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Get returns a single entry with its full bundle.
+func (s *Store) Get(ctx context.Context, id int64) (*Record, error) {
+	var rec Record
+	var source, data string
+	var createdAt, storedAt int64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, source, slug, revision, collection_name, game_domain,
+			conflict_count, issue_count, data, created_at, stored_at
+		FROM analysis_history WHERE id = ?
+	`, id).Scan(&rec.ID, &source, &rec.Slug, &rec.Revision, &rec.CollectionName, &rec.GameDomain,
+		&rec.ConflictCount, &rec.IssueCount, &data, &createdAt, &storedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query history entry: %w", err)
+	}
+
+	rec.Source = Source(source)
+	rec.CreatedAt = time.UnixMilli(createdAt).UTC()
+	rec.StoredAt = time.UnixMilli(storedAt).UTC()
+
+	rec.Bundle = &bundle.Bundle{}
+	if err := json.Unmarshal([]byte(data), rec.Bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal history data: %w", err)
+	}
+
+	rec.Notes, err = s.Notes(ctx, rec.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+// Delete removes an entry and its notes from the store.
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM analysis_history WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete history entry: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM analysis_notes WHERE entry_id = ?", id); err != nil {
+		return fmt.Errorf("delete history notes: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
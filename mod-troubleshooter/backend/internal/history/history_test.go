@@ -0,0 +1,203 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/bundle"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(Config{DBPath: filepath.Join(t.TempDir(), "history.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore_AddGetList(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	b := &bundle.Bundle{
+		Metadata: bundle.Metadata{
+			FormatVersion:  1,
+			Slug:           "abc123",
+			Revision:       2,
+			CollectionName: "Test Collection",
+			CreatedAt:      created,
+		},
+		Conflicts: &conflict.AnalysisResult{Stats: conflict.Stats{TotalConflicts: 4}},
+		LoadOrder: &loadorder.AnalysisResult{Stats: loadorder.Stats{TotalIssues: 1}},
+	}
+
+	entry, err := s.Add(ctx, SourceImport, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.ID == 0 {
+		t.Error("expected non-zero ID")
+	}
+	if entry.ConflictCount != 4 || entry.IssueCount != 1 {
+		t.Errorf("expected counts 4/1, got %d/%d", entry.ConflictCount, entry.IssueCount)
+	}
+
+	if _, err := s.Add(ctx, SourceImport, &bundle.Bundle{Metadata: bundle.Metadata{Slug: "second"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Slug != "second" {
+		t.Errorf("expected most recent entry first, got %s", entries[0].Slug)
+	}
+
+	rec, err := s.Get(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Source != SourceImport || rec.CollectionName != "Test Collection" {
+		t.Errorf("unexpected entry: %+v", rec.Entry)
+	}
+	if !rec.CreatedAt.Equal(created) {
+		t.Errorf("expected created at %v, got %v", created, rec.CreatedAt)
+	}
+	if rec.Bundle == nil || rec.Bundle.Conflicts == nil || rec.Bundle.Conflicts.Stats.TotalConflicts != 4 {
+		t.Errorf("expected bundle to round trip, got %+v", rec.Bundle)
+	}
+}
+
+func TestStore_NotFound(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, 42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.Delete(ctx, 42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Add(ctx, SourceImport, nil); !errors.Is(err, bundle.ErrNilBundle) {
+		t.Errorf("expected ErrNilBundle, got %v", err)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	entry, err := s.Add(ctx, SourceImport, &bundle.Bundle{Metadata: bundle.Metadata{Slug: "abc"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Delete(ctx, entry.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Get(ctx, entry.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestStore_Notes(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	entry, err := s.Add(ctx, SourceImport, &bundle.Bundle{
+		Metadata:  bundle.Metadata{Slug: "abc"},
+		Conflicts: &conflict.AnalysisResult{Conflicts: []conflict.Conflict{{Path: "a.esp"}, {Path: "textures/b.dds"}}},
+		LoadOrder: &loadorder.AnalysisResult{Issues: []loadorder.Issue{{Plugin: "c.esp"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	note, err := s.AddNote(ctx, entry.ID, NoteConflict, 1, " alice ", "Tried reordering, no change")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note.Subject != "textures/b.dds" || note.Author != "alice" {
+		t.Errorf("unexpected note: %+v", note)
+	}
+	if _, err := s.AddNote(ctx, entry.ID, NoteIssue, 0, "", "Master is in the optional pack"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, err := s.Get(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rec.Notes) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(rec.Notes))
+	}
+	if rec.Notes[0].ID != note.ID || rec.Notes[1].Target != NoteIssue || rec.Notes[1].Subject != "c.esp" {
+		t.Errorf("unexpected notes: %+v", rec.Notes)
+	}
+
+	if err := s.DeleteNote(ctx, entry.ID, note.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.DeleteNote(ctx, entry.ID, note.ID); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+
+	// Deleting the entry removes its remaining notes
+	if err := s.Delete(ctx, entry.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notes, err := s.Notes(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("expected notes to be deleted with the entry, got %d", len(notes))
+	}
+}
+
+func TestStore_AddNoteErrors(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	entry, err := s.Add(ctx, SourceImport, &bundle.Bundle{
+		Metadata:  bundle.Metadata{Slug: "abc"},
+		Conflicts: &conflict.AnalysisResult{Conflicts: []conflict.Conflict{{Path: "a.esp"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		entryID  int64
+		target   NoteTarget
+		index    int
+		text     string
+		expected error
+	}{
+		{"empty text", entry.ID, NoteConflict, 0, "  ", ErrEmptyNote},
+		{"missing entry", 42, NoteConflict, 0, "note", ErrNotFound},
+		{"index out of range", entry.ID, NoteConflict, 1, "note", ErrFindingNotFound},
+		{"negative index", entry.ID, NoteConflict, -1, "note", ErrFindingNotFound},
+		{"no load order", entry.ID, NoteIssue, 0, "note", ErrFindingNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.AddNote(ctx, tt.entryID, tt.target, tt.index, "", tt.text); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned when working with notes.
+var (
+	ErrNoteNotFound    = errors.New("note not found")
+	ErrFindingNotFound = errors.New("finding not found")
+	ErrEmptyNote       = errors.New("note text is required")
+)
+
+// NoteTarget identifies the kind of finding a note is attached to.
+type NoteTarget string
+
+const (
+	// NoteConflict attaches a note to a file conflict.
+	NoteConflict NoteTarget = "conflict"
+	// NoteIssue attaches a note to a load order issue.
+	NoteIssue NoteTarget = "issue"
+)
+
+// Note is free text attached to a finding of a stored analysis.
+type Note struct {
+	// ID is the unique identifier of the note.
+	ID int64 `json:"id"`
+	// EntryID is the history entry the note belongs to.
+	EntryID int64 `json:"entryId"`
+	// Target is the kind of finding the note is attached to.
+	Target NoteTarget `json:"target"`
+	// Index is the position of the finding in the stored results.
+	Index int `json:"index"`
+	// Subject is the conflict path or issue plugin, for display without the bundle.
+	Subject string `json:"subject"`
+	// Author names who wrote the note, if given.
+	Author string `json:"author,omitempty"`
+	// Text is the note content.
+	Text string `json:"text"`
+	// CreatedAt is when the note was added.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddNote attaches a note to the conflict or load order issue at index in a
+// stored analysis. Stored bundles never change, so the index keeps pointing at
+// the same finding.
+func (s *Store) AddNote(ctx context.Context, entryID int64, target NoteTarget, index int, author, text string) (*Note, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyNote
+	}
+
+	rec, err := s.Get(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := Note{
+		EntryID:   entryID,
+		Target:    target,
+		Index:     index,
+		Author:    strings.TrimSpace(author),
+		Text:      text,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	switch target {
+	case NoteConflict:
+		if rec.Bundle.Conflicts == nil || index < 0 || index >= len(rec.Bundle.Conflicts.Conflicts) {
+			return nil, ErrFindingNotFound
+		}
+		note.Subject = rec.Bundle.Conflicts.Conflicts[index].Path
+	case NoteIssue:
+		if rec.Bundle.LoadOrder == nil || index < 0 || index >= len(rec.Bundle.LoadOrder.Issues) {
+			return nil, ErrFindingNotFound
+		}
+		note.Subject = rec.Bundle.LoadOrder.Issues[index].Plugin
+	default:
+		return nil, fmt.Errorf("unknown note target %q", target)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO analysis_notes (entry_id, target, finding_index, subject, author, text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, note.EntryID, string(note.Target), note.Index, note.Subject, note.Author, note.Text, note.CreatedAt.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("insert note: %w", err)
+	}
+
+	note.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read note id: %w", err)
+	}
+
+	return &note, nil
+}
+
+// Notes returns the notes of a history entry, oldest first.
+func (s *Store) Notes(ctx context.Context, entryID int64) ([]Note, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, entry_id, target, finding_index, subject, author, text, created_at
+		FROM analysis_notes WHERE entry_id = ? ORDER BY created_at, id
+	`, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("query notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []Note{}
+	for rows.Next() {
+		var n Note
+		var target string
+		var createdAt int64
+		if err := rows.Scan(&n.ID, &n.EntryID, &target, &n.Index, &n.Subject, &n.Author, &n.Text, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan note: %w", err)
+		}
+		n.Target = NoteTarget(target)
+		n.CreatedAt = time.UnixMilli(createdAt).UTC()
+		notes = append(notes, n)
+	}
+
+	return notes, rows.Err()
+}
+
+// DeleteNote removes a note from a history entry.
+func (s *Store) DeleteNote(ctx context.Context, entryID, noteID int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM analysis_notes WHERE id = ? AND entry_id = ?", noteID, entryID)
+	if err != nil {
+		return fmt.Errorf("delete note: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNoteNotFound
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+// Package idle pauses background work when the server is left alone, so
+// it stays out of the way of a game running on the same machine, and
+// wakes it again on the next request.
+package idle
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bounds of how often the monitor checks whether the server is idle.
+const (
+	minCheckInterval = time.Second
+	maxCheckInterval = time.Minute
+)
+
+// Reason is why the server is paused.
+type Reason string
+
+const (
+	// ReasonIdle means nothing happened for the idle timeout.
+	ReasonIdle Reason = "idle"
+	// ReasonManual means the server was paused on request.
+	ReasonManual Reason = "manual"
+)
+
+// Hook is background work the monitor suspends while the server is paused.
+type Hook struct {
+	// Name identifies the work in logs.
+	Name string
+	// Pause suspends the work, freeing what it holds. It should not wait
+	// for work in progress.
+	Pause func()
+	// Resume restarts the work (optional).
+	Resume func()
+}
+
+// Config holds configuration for the Monitor.
+type Config struct {
+	// Timeout pauses the server after this long without requests. Zero
+	// only pauses on request.
+	Timeout time.Duration
+	// Hooks are the background work to suspend while paused.
+	Hooks []Hook
+	// Busy reports work that keeps the server from pausing when idle,
+	// such as running jobs (optional).
+	Busy func() bool
+	// Quiet reports requests that neither count as activity nor wake the
+	// server, such as health checks (optional).
+	Quiet func(r *http.Request) bool
+}
+
+// Status is the state of the monitor.
+type Status struct {
+	// Paused is true while background work is suspended.
+	Paused bool `json:"paused"`
+	// Reason is why the server is paused, if it is.
+	Reason Reason `json:"reason,omitempty"`
+	// PausedAt is when the server was paused, if it is.
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+	// LastActivity is when the last request finished, or the monitor started.
+	LastActivity time.Time `json:"lastActivity"`
+	// IdleTimeout is the idle timeout in seconds, 0 if there is none.
+	IdleTimeout int `json:"idleTimeout"`
+}
+
+// Monitor tracks requests and pauses background work once they stop.
+type Monitor struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	hooks    []Hook
+	busy     func() bool
+	quiet    func(r *http.Request) bool
+	active   int
+	last     time.Time
+	paused   bool
+	reason   Reason
+	pausedAt time.Time
+	now      func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates a monitor and, with a timeout, starts watching for idleness.
+func New(cfg Config) *Monitor {
+	m := &Monitor{
+		timeout: cfg.Timeout,
+		hooks:   cfg.Hooks,
+		busy:    cfg.Busy,
+		quiet:   cfg.Quiet,
+		now:     time.Now,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	m.last = m.now()
+
+	if m.timeout <= 0 {
+		close(m.done)
+		return m
+	}
+	go m.watch()
+	return m
+}
+
+// Handler wraps next so every request counts as activity, and wakes the
+// server first if it is paused.
+func (m *Monitor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.quiet != nil && m.quiet(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.begin()
+		defer m.end()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Pause suspends background work until Resume or the next request.
+func (m *Monitor) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pauseLocked(ReasonManual)
+}
+
+// Resume restarts background work.
+func (m *Monitor) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resumeLocked()
+}
+
+// Status returns the state of the monitor.
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := Status{
+		Paused:       m.paused,
+		LastActivity: m.last.UTC(),
+		IdleTimeout:  int(m.timeout / time.Second),
+	}
+	if m.paused {
+		pausedAt := m.pausedAt.UTC()
+		status.Reason = m.reason
+		status.PausedAt = &pausedAt
+	}
+	return status
+}
+
+// Close stops watching for idleness. It does not resume paused work.
+func (m *Monitor) Close() {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
+
+// begin records the start of a request, waking the server if paused.
+func (m *Monitor) begin() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active++
+	m.resumeLocked()
+}
+
+// end records the end of a request.
+func (m *Monitor) end() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active--
+	m.last = m.now()
+}
+
+// watch pauses the server whenever it has been idle for the timeout.
+func (m *Monitor) watch() {
+	defer close(m.done)
+	ticker := time.NewTicker(min(max(m.timeout/10, minCheckInterval), maxCheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// check pauses the server if nothing has happened for the timeout.
+func (m *Monitor) check() {
+	// Busy may take locks of its own, so it is asked before taking m.mu
+	busy := m.busy != nil && m.busy()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if busy {
+		// Background work counts as activity, so the timeout starts over
+		// once it finishes
+		m.last = m.now()
+		return
+	}
+	if m.paused || m.active > 0 || m.now().Sub(m.last) < m.timeout {
+		return
+	}
+	m.pauseLocked(ReasonIdle)
+}
+
+// pauseLocked suspends every hook. The caller holds m.mu.
+func (m *Monitor) pauseLocked(reason Reason) {
+	if m.paused {
+		return
+	}
+	m.paused = true
+	m.reason = reason
+	m.pausedAt = m.now()
+	names := make([]string, 0, len(m.hooks))
+	for _, h := range m.hooks {
+		h.Pause()
+		names = append(names, h.Name)
+	}
+	log.Printf("Paused background work (%s): %s", reason, strings.Join(names, ", "))
+}
+
+// resumeLocked restarts every hook. The caller holds m.mu.
+func (m *Monitor) resumeLocked() {
+	if !m.paused {
+		return
+	}
+	m.paused = false
+	m.reason = ""
+	for _, h := range m.hooks {
+		if h.Resume != nil {
+			h.Resume()
+		}
+	}
+	log.Println("Resumed background work")
+}
@@ -0,0 +1,124 @@
+package idle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recorder is a hook that counts its calls.
+type recorder struct {
+	pauses, resumes int
+}
+
+func (r *recorder) hook() Hook {
+	return Hook{
+		Name:   "test",
+		Pause:  func() { r.pauses++ },
+		Resume: func() { r.resumes++ },
+	}
+}
+
+func newTestMonitor(t *testing.T, cfg Config) (*Monitor, *time.Time) {
+	t.Helper()
+	m := New(cfg)
+	t.Cleanup(m.Close)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.mu.Lock()
+	m.now = func() time.Time { return now }
+	m.last = now
+	m.mu.Unlock()
+	return m, &now
+}
+
+func TestMonitor_PausesWhenIdle(t *testing.T) {
+	var rec recorder
+	m, now := newTestMonitor(t, Config{Timeout: time.Hour, Hooks: []Hook{rec.hook()}})
+
+	*now = now.Add(59 * time.Minute)
+	m.check()
+	if m.Status().Paused {
+		t.Fatal("expected no pause before the timeout")
+	}
+
+	*now = now.Add(time.Minute)
+	m.check()
+	status := m.Status()
+	if !status.Paused || status.Reason != ReasonIdle || rec.pauses != 1 {
+		t.Fatalf("expected an idle pause, got %+v with %d pauses", status, rec.pauses)
+	}
+
+	// The next request wakes the server before it is served
+	var pausedDuringRequest bool
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pausedDuringRequest = m.Status().Paused
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/games", nil))
+	if pausedDuringRequest || rec.resumes != 1 {
+		t.Errorf("expected the request to resume the server, got %d resumes", rec.resumes)
+	}
+	if got := m.Status().LastActivity; !got.Equal(*now) {
+		t.Errorf("expected the request to count as activity, last activity %v", got)
+	}
+}
+
+func TestMonitor_StaysAwake(t *testing.T) {
+	var rec recorder
+	busy := true
+	m, now := newTestMonitor(t, Config{
+		Timeout: time.Minute,
+		Hooks:   []Hook{rec.hook()},
+		Busy:    func() bool { return busy },
+		Quiet:   func(r *http.Request) bool { return r.URL.Path == "/api/health" },
+	})
+
+	// Running work keeps the server awake
+	*now = now.Add(time.Hour)
+	m.check()
+	if m.Status().Paused {
+		t.Fatal("expected no pause while busy")
+	}
+
+	// So does a request in progress
+	busy = false
+	*now = now.Add(time.Hour)
+	m.begin()
+	m.check()
+	if m.Status().Paused {
+		t.Fatal("expected no pause during a request")
+	}
+	m.end()
+
+	// Quiet requests neither count as activity nor wake the server
+	*now = now.Add(time.Hour)
+	m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	m.check()
+	if !m.Status().Paused || rec.pauses != 1 {
+		t.Fatalf("expected a pause once idle, got %d pauses", rec.pauses)
+	}
+	m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	if !m.Status().Paused {
+		t.Error("expected a quiet request not to wake the server")
+	}
+}
+
+func TestMonitor_ManualPause(t *testing.T) {
+	var rec recorder
+	m, _ := newTestMonitor(t, Config{Hooks: []Hook{rec.hook()}})
+
+	m.Pause()
+	m.Pause()
+	status := m.Status()
+	if !status.Paused || status.Reason != ReasonManual || status.PausedAt == nil || rec.pauses != 1 {
+		t.Fatalf("expected one manual pause, got %+v with %d pauses", status, rec.pauses)
+	}
+
+	m.Resume()
+	m.Resume()
+	if m.Status().Paused || rec.resumes != 1 {
+		t.Errorf("expected one resume, got %d", rec.resumes)
+	}
+}
@@ -0,0 +1,473 @@
+// Package jobs runs long analyses in the background, so requests can hand
+// work off and return at once while clients poll for the result.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Default limits for the queue.
+const (
+	DefaultWorkers        = 2
+	DefaultQueueSize      = 16
+	DefaultOwnerQueueSize = 4
+	DefaultTTL            = time.Hour
+)
+
+// Common errors returned by the queue.
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrQueueFull = errors.New("job queue is full")
+	ErrClosed    = errors.New("job queue is closed")
+	// ErrInterrupted is the error of a job the server stopped running
+	// without finishing, such as by crashing.
+	ErrInterrupted = errors.New("job was interrupted by a server restart")
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	// StatusQueued means the job waits for a free worker.
+	StatusQueued Status = "queued"
+	// StatusRunning means a worker is running the job.
+	StatusRunning Status = "running"
+	// StatusSucceeded means the job finished and has a result.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means the job finished with an error.
+	StatusFailed Status = "failed"
+)
+
+// Done reports whether a job in this state has finished.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Func is the work of a job. The context is cancelled when the queue
+// closes, and carries the job for SetProgress.
+type Func func(ctx context.Context) (interface{}, error)
+
+// Progress is how far a running job has got.
+type Progress struct {
+	// Stage names what the job is doing, such as "downloading".
+	Stage string `json:"stage"`
+	// Done is the number of items finished so far out of Total.
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// Current is the item being processed, if any.
+	Current string `json:"current,omitempty"`
+	// Step names the part of the stage under way, such as the analyzer
+	// running while analyzing.
+	Step string `json:"step,omitempty"`
+	// Bytes is how much of Current has been downloaded out of BytesTotal,
+	// while downloading. BytesTotal is -1 if the size is unknown.
+	Bytes      int64 `json:"bytes,omitempty"`
+	BytesTotal int64 `json:"bytesTotal,omitempty"`
+}
+
+type progressKey struct{}
+
+// SetProgress records the progress of the job whose Func was given ctx.
+// It does nothing outside a job.
+func SetProgress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		fn(p)
+	}
+}
+
+// Store persists job snapshots, so jobs can still be polled after the
+// server restarts. *cache.Cache implements it.
+type Store interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
+
+// Job is a snapshot of a queued, running or finished job.
+type Job struct {
+	// ID identifies the job.
+	ID string `json:"id"`
+	// Kind names what the job does, such as "conflicts".
+	Kind string `json:"kind"`
+	// Priority decides when the job runs relative to other waiting jobs.
+	Priority Priority `json:"priority"`
+	// Status is the state of the job.
+	Status Status `json:"status"`
+	// CreatedAt is when the job was submitted.
+	CreatedAt time.Time `json:"createdAt"`
+	// StartedAt is when a worker picked the job up, if one has.
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	// FinishedAt is when the job finished, if it has.
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Progress is how far a running job has got, if it reported it.
+	Progress *Progress `json:"progress,omitempty"`
+	// Result is the outcome of a succeeded job.
+	Result interface{} `json:"result,omitempty"`
+	// Error describes why a failed job failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Config holds configuration for the queue.
+type Config struct {
+	// Workers is how many jobs run at once.
+	Workers int
+	// QueueSize bounds how many jobs may wait for a worker. Submitting
+	// beyond it fails with ErrQueueFull, pushing back on clients instead
+	// of piling up work.
+	QueueSize int
+	// OwnerQueueSize bounds how many jobs one owner may have waiting, so a
+	// single client cannot fill the queue on a shared instance.
+	OwnerQueueSize int
+	// TTL is how long finished jobs are kept for polling.
+	TTL time.Duration
+	// Store persists jobs when they are submitted, start and finish
+	// (optional). Progress is only kept in memory.
+	Store Store
+}
+
+// Options are how a job is scheduled.
+type Options struct {
+	// Priority decides when the job runs (default, and for unknown
+	// priorities: PriorityNormal).
+	Priority Priority
+	// Owner identifies who submitted the job, such as a client address.
+	// Owners take turns among jobs of the same priority, and each may
+	// only have OwnerQueueSize jobs waiting. Jobs without an owner share
+	// one turn and are not limited per owner.
+	Owner string
+}
+
+// entry is a job with the work it runs.
+type entry struct {
+	job   Job
+	owner string
+	fn    Func
+	// changed is closed, and replaced, whenever the job changes. It is
+	// closed for good once the job finishes.
+	changed chan struct{}
+	// stored is closed once the queued job has been persisted, so later
+	// snapshots are not overwritten by it
+	stored chan struct{}
+}
+
+// notify wakes everyone watching the job. The caller holds q.mu.
+func (e *entry) notify() {
+	close(e.changed)
+	if !e.job.Status.Done() {
+		e.changed = make(chan struct{})
+	}
+}
+
+// Queue runs jobs on a fixed number of workers. Waiting jobs run in order
+// of priority, taking turns between owners.
+type Queue struct {
+	mu             sync.Mutex
+	jobs           map[string]*entry
+	pending        *scheduler
+	ready          *sync.Cond
+	queueSize      int
+	ownerQueueSize int
+	ttl            time.Duration
+	store          Store
+	running        int
+	paused         bool
+	closed         bool
+	now            func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a queue and starts its workers.
+func New(cfg Config) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.OwnerQueueSize <= 0 {
+		cfg.OwnerQueueSize = DefaultOwnerQueueSize
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		jobs:           make(map[string]*entry),
+		pending:        newScheduler(),
+		queueSize:      cfg.QueueSize,
+		ownerQueueSize: cfg.OwnerQueueSize,
+		ttl:            cfg.TTL,
+		store:          cfg.Store,
+		now:            time.Now,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	q.ready = sync.NewCond(&q.mu)
+	for i := 0; i < cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues a job with the default options and returns it. It fails
+// with ErrQueueFull when every slot of the queue is taken.
+func (q *Queue) Submit(kind string, fn Func) (Job, error) {
+	return q.SubmitWith(kind, Options{}, fn)
+}
+
+// SubmitWith queues a job with the given options and returns it. It fails
+// with ErrQueueFull when every slot of the queue, or every slot the owner
+// may take, is taken.
+func (q *Queue) SubmitWith(kind string, opts Options, fn Func) (Job, error) {
+	switch opts.Priority {
+	case PriorityHigh, PriorityLow:
+	default:
+		opts.Priority = PriorityNormal
+	}
+	e := &entry{
+		job: Job{
+			ID:        newID(),
+			Kind:      kind,
+			Priority:  opts.Priority,
+			Status:    StatusQueued,
+			CreatedAt: q.now().UTC(),
+		},
+		owner:   opts.Owner,
+		fn:      fn,
+		changed: make(chan struct{}),
+		stored:  make(chan struct{}),
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return Job{}, ErrClosed
+	}
+	q.prune()
+
+	if q.pending.len() >= q.queueSize {
+		q.mu.Unlock()
+		return Job{}, ErrQueueFull
+	}
+	if e.owner != "" && q.pending.waiting(e.owner) >= q.ownerQueueSize {
+		q.mu.Unlock()
+		return Job{}, ErrQueueFull
+	}
+	q.pending.push(e)
+	q.jobs[e.job.ID] = e
+	job := e.job
+	q.ready.Signal()
+	q.mu.Unlock()
+
+	// Only accepted jobs are stored; workers wait for this before storing
+	// their own snapshots
+	q.persist(job)
+	close(e.stored)
+	return job, nil
+}
+
+// Get returns a snapshot of a job. Jobs no longer in memory, such as
+// those submitted before a restart, are looked up in the store; one that
+// never finished is reported as failed with ErrInterrupted.
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	q.prune()
+	e, ok := q.jobs[id]
+	var job Job
+	if ok {
+		job = e.job
+	}
+	q.mu.Unlock()
+
+	if ok {
+		return job, nil
+	}
+	if q.store == nil || id == "" {
+		return Job{}, ErrNotFound
+	}
+	if err := q.store.Get(context.Background(), storeKey(id), &job); err != nil {
+		return Job{}, ErrNotFound
+	}
+	if !job.Status.Done() {
+		job.Status = StatusFailed
+		job.Error = ErrInterrupted.Error()
+		job.Progress = nil
+	}
+	return job, nil
+}
+
+// Watch returns a snapshot of a job, like Get, and a channel closed when
+// the job next changes. The channel is nil once the job has finished,
+// since it will not change again.
+func (q *Queue) Watch(id string) (Job, <-chan struct{}, error) {
+	q.mu.Lock()
+	q.prune()
+	if e, ok := q.jobs[id]; ok {
+		defer q.mu.Unlock()
+		if e.job.Status.Done() {
+			return e.job, nil, nil
+		}
+		return e.job, e.changed, nil
+	}
+	q.mu.Unlock()
+
+	job, err := q.Get(id)
+	return job, nil, err
+}
+
+// Pending returns the number of jobs waiting for a worker.
+func (q *Queue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending.len()
+}
+
+// Running returns the number of jobs being run.
+func (q *Queue) Running() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running
+}
+
+// Pause stops workers from starting jobs until Resume. Running jobs carry
+// on, and jobs can still be submitted; they wait.
+func (q *Queue) Pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = true
+}
+
+// Resume lets workers start jobs again after Pause.
+func (q *Queue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = false
+	q.ready.Broadcast()
+}
+
+// Close stops accepting jobs, cancels the running ones and waits for the
+// workers to exit. Jobs still waiting are failed.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	q.ready.Broadcast()
+	q.mu.Unlock()
+
+	q.cancel()
+	q.wg.Wait()
+}
+
+// work runs jobs until the queue closes.
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		for (q.pending.len() == 0 || q.paused) && !q.closed {
+			q.ready.Wait()
+		}
+		e := q.pending.pop()
+		if e == nil {
+			q.mu.Unlock()
+			return
+		}
+		if q.closed {
+			q.mu.Unlock()
+			q.finish(e, nil, ErrClosed)
+			continue
+		}
+
+		started := q.now().UTC()
+		e.job.Status = StatusRunning
+		e.job.StartedAt = &started
+		e.notify()
+		q.running++
+		job := e.job
+		q.mu.Unlock()
+		<-e.stored
+		q.persist(job)
+
+		ctx := context.WithValue(q.ctx, progressKey{}, func(p Progress) {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			if !e.job.Status.Done() {
+				e.job.Progress = &p
+				e.notify()
+			}
+		})
+		result, err := e.fn(ctx)
+		q.finish(e, result, err)
+
+		q.mu.Lock()
+		q.running--
+		q.mu.Unlock()
+	}
+}
+
+// finish records the outcome of a job.
+func (q *Queue) finish(e *entry, result interface{}, err error) {
+	q.mu.Lock()
+	finished := q.now().UTC()
+	e.job.FinishedAt = &finished
+	e.job.Progress = nil
+	e.fn = nil
+	if err != nil {
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+	} else {
+		e.job.Status = StatusSucceeded
+		e.job.Result = result
+	}
+	e.notify()
+	job := e.job
+	q.mu.Unlock()
+
+	<-e.stored
+	q.persist(job)
+}
+
+// persist saves a snapshot of a job to the store, if there is one. It is
+// called without q.mu held, since results can take a while to encode.
+func (q *Queue) persist(job Job) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.SetWithTTL(context.Background(), storeKey(job.ID), job, q.ttl); err != nil {
+		log.Printf("Error persisting job %s: %v", job.ID, err)
+	}
+}
+
+// storeKey is the key a job is persisted under.
+func storeKey(id string) string {
+	return "job:" + id
+}
+
+// prune drops finished jobs older than the TTL. The caller holds q.mu.
+func (q *Queue) prune() {
+	cutoff := q.now().Add(-q.ttl)
+	for id, e := range q.jobs {
+		if e.job.FinishedAt != nil && e.job.FinishedAt.Before(cutoff) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// newID returns a random job ID.
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,382 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls a job until it finishes.
+func waitFor(t *testing.T, q *Queue, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Status.Done() {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestQueue_SubmitAndGet(t *testing.T) {
+	q := New(Config{})
+	defer q.Close()
+
+	ok, err := q.Submit("test", func(ctx context.Context) (interface{}, error) { return 42, nil })
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if ok.Status != StatusQueued || ok.Kind != "test" || ok.ID == "" {
+		t.Errorf("unexpected submitted job %+v", ok)
+	}
+	failed, err := q.Submit("test", func(ctx context.Context) (interface{}, error) { return nil, errors.New("boom") })
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if job := waitFor(t, q, ok.ID); job.Status != StatusSucceeded || job.Result != 42 || job.StartedAt == nil || job.FinishedAt == nil {
+		t.Errorf("unexpected succeeded job %+v", job)
+	}
+	if job := waitFor(t, q, failed.ID); job.Status != StatusFailed || job.Error != "boom" {
+		t.Errorf("unexpected failed job %+v", job)
+	}
+	if _, err := q.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQueue_Full(t *testing.T) {
+	q := New(Config{Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	block := func(ctx context.Context) (interface{}, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil, nil
+	}
+
+	if _, err := q.Submit("test", block); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-started // the worker holds the first job, so the next one waits
+	if _, err := q.Submit("test", block); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := q.Submit("test", block); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(release)
+	q.Close()
+	if _, err := q.Submit("test", block); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestQueue_PrunesFinishedJobs(t *testing.T) {
+	q := New(Config{TTL: time.Minute})
+	defer q.Close()
+
+	job, err := q.Submit("test", func(ctx context.Context) (interface{}, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, q, job.ID)
+
+	q.mu.Lock()
+	q.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	q.mu.Unlock()
+	if _, err := q.Get(job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the finished job to expire, got %v", err)
+	}
+}
+
+func TestQueue_Progress(t *testing.T) {
+	q := New(Config{})
+	defer q.Close()
+
+	reported := make(chan struct{})
+	release := make(chan struct{})
+	job, err := q.Submit("test", func(ctx context.Context) (interface{}, error) {
+		SetProgress(ctx, Progress{Stage: "downloading", Done: 1, Total: 3, Current: "Mod B"})
+		close(reported)
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	<-reported
+	running, err := q.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := Progress{Stage: "downloading", Done: 1, Total: 3, Current: "Mod B"}
+	if running.Status != StatusRunning || running.Progress == nil || *running.Progress != want {
+		t.Errorf("unexpected running job %+v", running)
+	}
+
+	close(release)
+	if done := waitFor(t, q, job.ID); done.Progress != nil {
+		t.Errorf("expected no progress once finished, got %+v", done.Progress)
+	}
+
+	// Outside a job it does nothing
+	SetProgress(context.Background(), want)
+}
+
+func TestQueue_Watch(t *testing.T) {
+	q := New(Config{})
+	defer q.Close()
+
+	step := make(chan struct{})
+	job, err := q.Submit("test", func(ctx context.Context) (interface{}, error) {
+		<-step
+		SetProgress(ctx, Progress{Stage: "extracting", Done: 1, Total: 2})
+		<-step
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	// Wait for the job to start
+	var changed <-chan struct{}
+	for {
+		job, changed, err = q.Watch(job.ID)
+		if err != nil {
+			t.Fatalf("Watch() error = %v", err)
+		}
+		if job.Status == StatusRunning {
+			break
+		}
+		<-changed
+	}
+
+	step <- struct{}{}
+	<-changed
+	job, changed, _ = q.Watch(job.ID)
+	if job.Progress == nil || job.Progress.Stage != "extracting" {
+		t.Errorf("expected the reported progress, got %+v", job.Progress)
+	}
+
+	step <- struct{}{}
+	<-changed
+	job, changed, _ = q.Watch(job.ID)
+	if job.Status != StatusSucceeded || job.Result != "ok" || changed != nil {
+		t.Errorf("expected a finished job with no more changes, got %+v", job)
+	}
+
+	if _, _, err := q.Watch("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQueue_Pause(t *testing.T) {
+	q := New(Config{Workers: 1})
+	defer q.Close()
+
+	q.Pause()
+	ran := make(chan struct{})
+	release := make(chan struct{})
+	job, err := q.Submit("test", func(ctx context.Context) (interface{}, error) {
+		close(ran)
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	select {
+	case <-ran:
+		t.Fatal("expected no job to start while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if q.Pending() != 1 || q.Running() != 0 {
+		t.Errorf("expected 1 waiting job, got %d waiting and %d running", q.Pending(), q.Running())
+	}
+
+	q.Resume()
+	<-ran
+	if q.Running() != 1 {
+		t.Errorf("expected 1 running job, got %d", q.Running())
+	}
+	close(release)
+	waitFor(t, q, job.ID)
+}
+
+// memoryStore is a Store keeping JSON snapshots in memory, as the cache would.
+type memoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string, dest interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return errors.New("not found")
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (s *memoryStore) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = data
+	return nil
+}
+
+func TestQueue_Store(t *testing.T) {
+	store := &memoryStore{data: make(map[string][]byte)}
+
+	q := New(Config{Store: store})
+	job, err := q.Submit("test", func(ctx context.Context) (interface{}, error) { return "done", nil })
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, q, job.ID)
+	q.Close()
+
+	// A job that was running when the server went away
+	started := time.Now().UTC()
+	store.SetWithTTL(context.Background(), storeKey("lost"), Job{ID: "lost", Kind: "test", Status: StatusRunning, StartedAt: &started}, time.Hour)
+
+	restarted := New(Config{Store: store})
+	defer restarted.Close()
+
+	finished, err := restarted.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if finished.Status != StatusSucceeded || finished.Result != "done" || finished.FinishedAt == nil {
+		t.Errorf("unexpected restored job %+v", finished)
+	}
+
+	lost, err := restarted.Get("lost")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if lost.Status != StatusFailed || lost.Error != ErrInterrupted.Error() {
+		t.Errorf("expected the unfinished job to be interrupted, got %+v", lost)
+	}
+
+	if _, err := restarted.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQueue_StoresOnlyAcceptedJobs(t *testing.T) {
+	store := &memoryStore{data: make(map[string][]byte)}
+	q := New(Config{Workers: 1, QueueSize: 1, Store: store})
+	q.Pause()
+	defer q.Close()
+
+	fn := func(ctx context.Context) (interface{}, error) { return nil, nil }
+	accepted, err := q.Submit("test", fn)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := q.Submit("test", fn); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.data) != 1 {
+		t.Errorf("expected only the accepted job to be stored, got %d records", len(store.data))
+	}
+	if _, ok := store.data[storeKey(accepted.ID)]; !ok {
+		t.Errorf("expected the accepted job to be stored")
+	}
+}
+
+func TestQueue_PriorityAndFairness(t *testing.T) {
+	q := New(Config{Workers: 1, QueueSize: 10, OwnerQueueSize: 3})
+	defer q.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocker, err := q.Submit("test", func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-started // the only worker is busy, so everything below waits
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) Func {
+		return func(ctx context.Context) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil, nil
+		}
+	}
+	submitted := []struct {
+		name string
+		opts Options
+	}{
+		{"scan", Options{Priority: PriorityLow, Owner: "alice"}},
+		{"a1", Options{Owner: "alice"}},
+		{"a2", Options{Owner: "alice"}},
+		{"b1", Options{Owner: "bob"}},
+		{"quick", Options{Priority: PriorityHigh, Owner: "carol"}},
+	}
+	var ids []string
+	for _, s := range submitted {
+		job, err := q.SubmitWith("test", s.opts, record(s.name))
+		if err != nil {
+			t.Fatalf("SubmitWith(%s) error = %v", s.name, err)
+		}
+		if want := s.opts.Priority; want != "" && job.Priority != want {
+			t.Errorf("job %s priority = %s, want %s", s.name, job.Priority, want)
+		}
+		ids = append(ids, job.ID)
+	}
+	// Alice has used her share of the queue
+	if _, err := q.SubmitWith("test", Options{Owner: "alice"}, record("a3")); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull for a fourth waiting job, got %v", err)
+	}
+	if got := q.Pending(); got != len(submitted) {
+		t.Errorf("Pending() = %d, want %d", got, len(submitted))
+	}
+
+	close(release)
+	waitFor(t, q, blocker.ID)
+	for _, id := range ids {
+		waitFor(t, q, id)
+	}
+
+	// High priority first, then owners take turns, low priority last
+	want := []string{"quick", "a1", "b1", "a2", "scan"}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("jobs ran in order %v, want %v", order, want)
+	}
+}
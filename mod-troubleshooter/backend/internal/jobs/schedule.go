@@ -0,0 +1,91 @@
+package jobs
+
+// Priority decides which waiting jobs run first.
+type Priority string
+
+const (
+	// PriorityHigh is for interactive requests, such as a few mods a user
+	// is looking at. They run before any waiting job of lower priority.
+	PriorityHigh Priority = "high"
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = "normal"
+	// PriorityLow is for long scans, such as whole collections, that can
+	// wait for quicker work.
+	PriorityLow Priority = "low"
+)
+
+// priorities lists the priorities, highest first.
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// lane holds the waiting jobs of one priority. Owners take turns, so one
+// client queueing many jobs does not hold up everyone else's.
+type lane struct {
+	// turns lists the owners with waiting jobs, whose turn is next first.
+	turns []string
+	jobs  map[string][]*entry
+}
+
+// scheduler orders waiting jobs by priority, then round-robin between
+// owners, then by submission. The caller holds the queue's lock.
+type scheduler struct {
+	lanes   map[Priority]*lane
+	size    int
+	byOwner map[string]int
+}
+
+func newScheduler() *scheduler {
+	s := &scheduler{lanes: make(map[Priority]*lane), byOwner: make(map[string]int)}
+	for _, p := range priorities {
+		s.lanes[p] = &lane{jobs: make(map[string][]*entry)}
+	}
+	return s
+}
+
+// push adds a waiting job.
+func (s *scheduler) push(e *entry) {
+	l := s.lanes[e.job.Priority]
+	if len(l.jobs[e.owner]) == 0 {
+		l.turns = append(l.turns, e.owner)
+	}
+	l.jobs[e.owner] = append(l.jobs[e.owner], e)
+	s.size++
+	s.byOwner[e.owner]++
+}
+
+// pop removes and returns the job to run next, or nil if none is waiting.
+func (s *scheduler) pop() *entry {
+	for _, p := range priorities {
+		l := s.lanes[p]
+		if len(l.turns) == 0 {
+			continue
+		}
+		owner := l.turns[0]
+		l.turns = l.turns[1:]
+		waiting := l.jobs[owner]
+		e := waiting[0]
+		if len(waiting) > 1 {
+			l.jobs[owner] = waiting[1:]
+			// The owner's next job waits for every other owner's turn
+			l.turns = append(l.turns, owner)
+		} else {
+			delete(l.jobs, owner)
+		}
+
+		s.size--
+		if s.byOwner[owner]--; s.byOwner[owner] == 0 {
+			delete(s.byOwner, owner)
+		}
+		return e
+	}
+	return nil
+}
+
+// len returns the number of waiting jobs.
+func (s *scheduler) len() int {
+	return s.size
+}
+
+// waiting returns the number of jobs an owner has waiting.
+func (s *scheduler) waiting(owner string) int {
+	return s.byOwner[owner]
+}
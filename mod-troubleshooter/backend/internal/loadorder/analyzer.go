@@ -24,6 +24,14 @@ func NewAnalyzer() *Analyzer {
 // Analyze performs load order analysis on the given plugins.
 // The plugins should be in their intended load order (index 0 loads first).
 func (a *Analyzer) Analyze(ctx context.Context, plugins []PluginFile) (*AnalysisResult, error) {
+	return a.AnalyzeGame(ctx, "", plugins)
+}
+
+// AnalyzeGame performs load order analysis for a game, identified by its Nexus
+// domain. The game selects the base game plugins that may be left out of the
+// load order and the Creation Club catalog used to explain missing masters;
+// an empty game searches every game's lists.
+func (a *Analyzer) AnalyzeGame(ctx context.Context, game string, plugins []PluginFile) (*AnalysisResult, error) {
 	result := &AnalysisResult{
 		Plugins:         make([]PluginInfo, 0, len(plugins)),
 		Issues:          make([]Issue, 0),
@@ -45,6 +53,7 @@ func (a *Analyzer) Analyze(ctx context.Context, plugins []PluginFile) (*Analysis
 			Filename: pf.Filename,
 			Index:    i,
 			Masters:  []string{},
+			BaseGame: IsBasePlugin(game, pf.Filename),
 		}
 
 		// Use pre-parsed header if available
@@ -72,6 +81,17 @@ func (a *Analyzer) Analyze(ctx context.Context, plugins []PluginFile) (*Analysis
 		}
 	}
 
+	// Find circular dependencies first, so plugins in one are reported once
+	// for it rather than as loading in the wrong order.
+	// Map of lowercase plugin filename to the circular dependency it is in
+	cycleOf := make(map[string]int)
+	cycles := masterCycles(result.Plugins)
+	for i, cycle := range cycles {
+		for _, name := range cycle {
+			cycleOf[strings.ToLower(name)] = i
+		}
+	}
+
 	// Second pass: detect issues
 	for i := range result.Plugins {
 		if ctx.Err() != nil {
@@ -79,7 +99,7 @@ func (a *Analyzer) Analyze(ctx context.Context, plugins []PluginFile) (*Analysis
 		}
 
 		info := &result.Plugins[i]
-		issues := a.detectIssuesForPlugin(info, pluginIndex)
+		issues := a.detectIssuesForPlugin(info, pluginIndex, cycleOf, game)
 
 		for _, issue := range issues {
 			result.Issues = append(result.Issues, issue)
@@ -88,8 +108,19 @@ func (a *Analyzer) Analyze(ctx context.Context, plugins []PluginFile) (*Analysis
 		}
 	}
 
+	// Plugins that require each other cannot all load after their masters
+	for _, issue := range circularDependencyIssues(cycles, pluginInfoMap) {
+		result.Issues = append(result.Issues, issue)
+		for _, name := range issue.Plugins {
+			info := pluginInfoMap[strings.ToLower(name)]
+			info.HasIssues = true
+			info.IssueCount++
+		}
+	}
+	result.SuggestedOrder = suggestOrder(result.Plugins)
+
 	// Calculate stats
-	result.Stats = a.calculateStats(result)
+	result.Stats = a.calculateStats(result, game)
 
 	return result, nil
 }
@@ -143,8 +174,10 @@ func (a *Analyzer) AnalyzeFromHeaders(ctx context.Context, headers []*plugin.Plu
 	return a.Analyze(ctx, plugins)
 }
 
-// detectIssuesForPlugin checks for issues with a single plugin.
-func (a *Analyzer) detectIssuesForPlugin(info *PluginInfo, pluginIndex map[string]int) []Issue {
+// detectIssuesForPlugin checks for issues with a single plugin. Masters in
+// the same circular dependency as the plugin are not reported as loading in
+// the wrong order, since no order could fix that.
+func (a *Analyzer) detectIssuesForPlugin(info *PluginInfo, pluginIndex map[string]int, cycleOf map[string]int, game string) []Issue {
 	var issues []Issue
 
 	for _, master := range info.Masters {
@@ -152,6 +185,24 @@ func (a *Analyzer) detectIssuesForPlugin(info *PluginInfo, pluginIndex map[strin
 		masterIdx, exists := pluginIndex[masterLower]
 
 		if !exists {
+			// Base game plugins ship with the game, so collections leave them out
+			if IsBasePlugin(game, master) {
+				continue
+			}
+
+			// Creation Club masters need the content to be owned, not a mod
+			if item, ok := LookupCreationClub(game, master); ok {
+				issues = append(issues, Issue{
+					Type:          IssueMissingCreationClub,
+					Severity:      SeverityError,
+					Plugin:        info.Filename,
+					RelatedPlugin: master,
+					Message:       creationClubMessage(master, item),
+					Index:         info.Index,
+				})
+				continue
+			}
+
 			// Missing master
 			issues = append(issues, Issue{
 				Type:          IssueMissingMaster,
@@ -161,7 +212,7 @@ func (a *Analyzer) detectIssuesForPlugin(info *PluginInfo, pluginIndex map[strin
 				Message:       fmt.Sprintf("Missing required master: %s", master),
 				Index:         info.Index,
 			})
-		} else if masterIdx > info.Index {
+		} else if masterIdx > info.Index && !sameCycle(cycleOf, info.Filename, master) {
 			// Master loads after this plugin (wrong order)
 			issues = append(issues, Issue{
 				Type:          IssueWrongOrder,
@@ -177,16 +228,60 @@ func (a *Analyzer) detectIssuesForPlugin(info *PluginInfo, pluginIndex map[strin
 	return issues
 }
 
+// circularDependencyIssues reports each circular dependency once, against
+// the first of its plugins to load.
+func circularDependencyIssues(cycles [][]string, pluginInfoMap map[string]*PluginInfo) []Issue {
+	issues := make([]Issue, 0, len(cycles))
+	for _, cycle := range cycles {
+		first := pluginInfoMap[strings.ToLower(cycle[0])]
+		issue := Issue{
+			Type:     IssueCircularDependency,
+			Severity: SeverityCritical,
+			Plugin:   cycle[0],
+			Plugins:  cycle,
+			Index:    first.Index,
+		}
+		if len(cycle) == 1 {
+			issue.Message = fmt.Sprintf("%s lists itself as a master, so it can never load after its masters", cycle[0])
+		} else {
+			issue.RelatedPlugin = cycle[1]
+			issue.Message = fmt.Sprintf("Circular master dependency between %s: each needs another to load first, so no load order works", strings.Join(cycle, ", "))
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// sameCycle reports whether two plugins are in the same circular dependency.
+func sameCycle(cycleOf map[string]int, a, b string) bool {
+	ca, ok := cycleOf[strings.ToLower(a)]
+	if !ok {
+		return false
+	}
+	cb, ok := cycleOf[strings.ToLower(b)]
+	return ok && ca == cb
+}
+
 // calculateStats computes summary statistics from the analysis result.
-func (a *Analyzer) calculateStats(result *AnalysisResult) Stats {
+func (a *Analyzer) calculateStats(result *AnalysisResult, game string) Stats {
 	stats := Stats{
 		TotalPlugins: len(result.Plugins),
 		TotalIssues:  len(result.Issues),
 	}
 
 	pluginsWithIssues := make(map[string]bool)
+	basePlugins := make(map[string]bool)
 
 	for _, p := range result.Plugins {
+		if p.BaseGame {
+			basePlugins[strings.ToLower(p.Filename)] = true
+		}
+		for _, master := range p.Masters {
+			if IsBasePlugin(game, master) {
+				basePlugins[strings.ToLower(master)] = true
+			}
+		}
+
 		switch p.Type {
 		case plugin.PluginTypeESM:
 			stats.ESMCount++
@@ -199,6 +294,8 @@ func (a *Analyzer) calculateStats(result *AnalysisResult) Stats {
 
 	for _, issue := range result.Issues {
 		switch issue.Severity {
+		case SeverityCritical:
+			stats.CriticalCount++
 		case SeverityError:
 			stats.ErrorCount++
 		case SeverityWarning:
@@ -208,14 +305,22 @@ func (a *Analyzer) calculateStats(result *AnalysisResult) Stats {
 		switch issue.Type {
 		case IssueMissingMaster:
 			stats.MissingMasters++
+		case IssueMissingCreationClub:
+			stats.MissingCreationClub++
 		case IssueWrongOrder:
 			stats.WrongOrderCount++
+		case IssueCircularDependency:
+			stats.CircularDependencies++
 		}
 
 		pluginsWithIssues[strings.ToLower(issue.Plugin)] = true
+		for _, name := range issue.Plugins {
+			pluginsWithIssues[strings.ToLower(name)] = true
+		}
 	}
 
 	stats.PluginsWithIssues = len(pluginsWithIssues)
+	stats.BaseGamePlugins = len(basePlugins)
 
 	return stats
 }
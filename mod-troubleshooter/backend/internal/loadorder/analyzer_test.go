@@ -2,6 +2,8 @@ package loadorder
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/mod-troubleshooter/backend/internal/plugin"
@@ -112,6 +114,50 @@ func TestAnalyzer_Analyze_MissingMaster(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_AnalyzeGame_MissingCreationClub(t *testing.T) {
+	analyzer := NewAnalyzer()
+	ctx := context.Background()
+
+	plugins := []PluginFile{
+		{
+			Filename: "MyMod.esp",
+			Header: &plugin.PluginHeader{
+				Filename: "MyMod.esp",
+				Type:     plugin.PluginTypeESP,
+				Masters:  []plugin.Master{{Filename: "ccBGSSSE001-Fish.esm"}, {Filename: "MissingMod.esm"}},
+			},
+		},
+	}
+
+	result, err := analyzer.AnalyzeGame(ctx, GameSkyrimSE, plugins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(result.Issues))
+	}
+
+	issue := result.Issues[0]
+	if issue.Type != IssueMissingCreationClub {
+		t.Errorf("expected issue type %s, got %s", IssueMissingCreationClub, issue.Type)
+	}
+	if issue.RelatedPlugin != "ccBGSSSE001-Fish.esm" {
+		t.Errorf("expected related plugin ccBGSSSE001-Fish.esm, got %s", issue.RelatedPlugin)
+	}
+	if !strings.Contains(issue.Message, "Anniversary Edition") || !strings.Contains(issue.Message, "Fishing") {
+		t.Errorf("expected message to name the Creation Club item, got %q", issue.Message)
+	}
+	if result.Issues[1].Type != IssueMissingMaster {
+		t.Errorf("expected issue type %s, got %s", IssueMissingMaster, result.Issues[1].Type)
+	}
+
+	if result.Stats.MissingCreationClub != 1 || result.Stats.MissingMasters != 1 {
+		t.Errorf("expected 1 missing CC master and 1 missing master, got %d and %d",
+			result.Stats.MissingCreationClub, result.Stats.MissingMasters)
+	}
+}
+
 func TestAnalyzer_Analyze_WrongOrder(t *testing.T) {
 	analyzer := NewAnalyzer()
 	ctx := context.Background()
@@ -423,3 +469,102 @@ func TestDetermineTypeFromFilename(t *testing.T) {
 		})
 	}
 }
+
+// withMasters builds a plugin whose type follows its extension.
+func withMasters(filename string, masters ...string) PluginFile {
+	header := &plugin.PluginHeader{Filename: filename, Type: determineTypeFromFilename(filename)}
+	for _, m := range masters {
+		header.Masters = append(header.Masters, plugin.Master{Filename: m})
+	}
+	return PluginFile{Filename: filename, Header: header}
+}
+
+func TestAnalyzer_Analyze_SuggestedOrder(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	plugins := []PluginFile{
+		withMasters("Patch.esp", "MyMod.esp", "Lib.esm"),
+		withMasters("MyMod.esp", "Skyrim.esm"),
+		withMasters("Light.esl", "Skyrim.esm"),
+		withMasters("Skyrim.esm"),
+		withMasters("Lib.esm", "Skyrim.esm"),
+		// An ESM needing an ESP still loads after it
+		withMasters("Odd.esm", "Other.esp"),
+		withMasters("Other.esp"),
+	}
+
+	result, err := analyzer.Analyze(context.Background(), plugins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"Skyrim.esm", "Lib.esm", "Light.esl", "MyMod.esp", "Patch.esp", "Other.esp", "Odd.esm"}
+	if !reflect.DeepEqual(result.SuggestedOrder, want) {
+		t.Errorf("expected order %v, got %v", want, result.SuggestedOrder)
+	}
+	if result.Stats.CircularDependencies != 0 {
+		t.Errorf("expected no circular dependencies, got %d", result.Stats.CircularDependencies)
+	}
+}
+
+func TestAnalyzer_Analyze_CircularDependency(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	plugins := []PluginFile{
+		withMasters("Skyrim.esm"),
+		withMasters("A.esp", "Skyrim.esm", "C.esp"),
+		withMasters("B.esp", "A.esp"),
+		withMasters("C.esp", "B.esp"),
+		withMasters("D.esp", "A.esp", "E.esp"),
+		withMasters("E.esp", "Skyrim.esm"),
+		withMasters("Self.esp", "Self.esp"),
+	}
+
+	result, err := analyzer.Analyze(context.Background(), plugins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Plugins in or after a cycle come last, in load order
+	want := []string{"Skyrim.esm", "E.esp", "A.esp", "B.esp", "C.esp", "D.esp", "Self.esp"}
+	if !reflect.DeepEqual(result.SuggestedOrder, want) {
+		t.Errorf("expected order %v, got %v", want, result.SuggestedOrder)
+	}
+
+	var cycles, wrongOrder []Issue
+	for _, issue := range result.Issues {
+		switch issue.Type {
+		case IssueCircularDependency:
+			cycles = append(cycles, issue)
+		case IssueWrongOrder:
+			wrongOrder = append(wrongOrder, issue)
+		}
+	}
+	if len(cycles) != 2 || result.Stats.CircularDependencies != 2 || result.Stats.CriticalCount != 2 {
+		t.Fatalf("expected two circular dependencies, got %+v", cycles)
+	}
+	cycle := cycles[0]
+	if cycle.Severity != SeverityCritical || cycle.Plugin != "A.esp" ||
+		!reflect.DeepEqual(cycle.Plugins, []string{"A.esp", "B.esp", "C.esp"}) ||
+		!strings.Contains(cycle.Message, "A.esp, B.esp, C.esp") {
+		t.Errorf("unexpected circular dependency %+v", cycle)
+	}
+	if !reflect.DeepEqual(cycles[1].Plugins, []string{"Self.esp"}) {
+		t.Errorf("expected Self.esp to be its own cycle, got %+v", cycles[1])
+	}
+
+	// Only D.esp, outside the cycle, loads before a master
+	if len(wrongOrder) != 1 || wrongOrder[0].Plugin != "D.esp" || wrongOrder[0].RelatedPlugin != "E.esp" {
+		t.Errorf("expected one wrong order issue for D.esp, got %+v", wrongOrder)
+	}
+
+	// Every plugin in a cycle has an issue
+	if result.Stats.PluginsWithIssues != 5 {
+		t.Errorf("expected 5 plugins with issues, got %d", result.Stats.PluginsWithIssues)
+	}
+	for _, p := range result.Plugins[1:4] {
+		if !p.HasIssues {
+			t.Errorf("expected %s to have issues", p.Filename)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package loadorder
+
+import "strings"
+
+// Nexus game domains with a list of base game plugins.
+const (
+	GameSkyrim   = "skyrim"
+	GameNewVegas = "newvegas"
+)
+
+// basePlugins maps each game to the plugins that ship with it, including
+// its official DLC, by lowercase filename. Collections never include them,
+// since every player already has them.
+var basePlugins = map[string]map[string]bool{
+	GameSkyrim: {
+		"skyrim.esm": true, "update.esm": true, "dawnguard.esm": true,
+		"hearthfires.esm": true, "dragonborn.esm": true,
+	},
+	GameSkyrimSE: {
+		"skyrim.esm": true, "update.esm": true, "dawnguard.esm": true,
+		"hearthfires.esm": true, "dragonborn.esm": true,
+		// Shipped since 1.6 alongside the Creation Club plugins
+		"_resourcepack.esl": true,
+	},
+	GameFallout4: {
+		"fallout4.esm": true, "dlcrobot.esm": true, "dlcworkshop01.esm": true,
+		"dlccoast.esm": true, "dlcworkshop02.esm": true, "dlcworkshop03.esm": true,
+		"dlcnukaworld.esm": true, "dlcultrahighresolution.esm": true,
+	},
+	GameNewVegas: {
+		"falloutnv.esm": true, "deadmoney.esm": true, "honesthearts.esm": true,
+		"oldworldblues.esm": true, "lonesomeroad.esm": true, "gunrunnersarsenal.esm": true,
+		"classicpack.esm": true, "mercenarypack.esm": true, "tribalpack.esm": true,
+		"caravanpack.esm": true,
+	},
+}
+
+// IsBasePlugin reports whether a plugin ships with a game, identified by
+// its Nexus domain. With an empty game every game's list is searched.
+// Creation Club plugins are not base plugins; they come with content the
+// player has to own, see LookupCreationClub.
+func IsBasePlugin(game, filename string) bool {
+	lower := strings.ToLower(filename)
+	if game != "" {
+		return basePlugins[game][lower]
+	}
+	for _, plugins := range basePlugins {
+		if plugins[lower] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package loadorder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+func TestIsBasePlugin(t *testing.T) {
+	tests := []struct {
+		name     string
+		game     string
+		filename string
+		expected bool
+	}{
+		{"skyrim se", GameSkyrimSE, "Skyrim.esm", true},
+		{"dlc", GameSkyrimSE, "Dawnguard.esm", true},
+		{"any game", "", "DLCCoast.esm", true},
+		{"other game", GameFallout4, "Skyrim.esm", false},
+		{"le has no resource pack", GameSkyrim, "_ResourcePack.esl", false},
+		{"creation club", GameSkyrimSE, "ccBGSSSE001-Fish.esm", false},
+		{"mod", GameSkyrimSE, "MyMod.esp", false},
+		{"unlisted game", "stardewvalley", "Skyrim.esm", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBasePlugin(tt.game, tt.filename); got != tt.expected {
+				t.Errorf("IsBasePlugin(%q, %q) = %v, want %v", tt.game, tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_OmittedBaseMasters(t *testing.T) {
+	plugins := []PluginFile{
+		{
+			Filename: "Update.esm",
+			Header: &plugin.PluginHeader{
+				Filename: "Update.esm",
+				Type:     plugin.PluginTypeESM,
+				Masters:  []plugin.Master{{Filename: "Skyrim.esm"}},
+			},
+		},
+		{
+			Filename: "MyMod.esp",
+			Header: &plugin.PluginHeader{
+				Filename: "MyMod.esp",
+				Type:     plugin.PluginTypeESP,
+				Masters:  []plugin.Master{{Filename: "Skyrim.esm"}, {Filename: "Dragonborn.esm"}, {Filename: "Missing.esm"}},
+			},
+		},
+	}
+
+	result, err := NewAnalyzer().AnalyzeGame(context.Background(), GameSkyrimSE, plugins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Issues) != 1 || result.Issues[0].RelatedPlugin != "Missing.esm" {
+		t.Errorf("expected only Missing.esm to be missing, got %+v", result.Issues)
+	}
+	if result.Stats.MissingMasters != 1 {
+		t.Errorf("expected 1 missing master, got %d", result.Stats.MissingMasters)
+	}
+	if result.Stats.BaseGamePlugins != 3 {
+		t.Errorf("expected 3 base game plugins, got %d", result.Stats.BaseGamePlugins)
+	}
+	if !result.Plugins[0].BaseGame || result.Plugins[1].BaseGame {
+		t.Errorf("expected only Update.esm to be marked as base game, got %+v", result.Plugins)
+	}
+
+	// Other games' base plugins are still missing masters
+	result, err = NewAnalyzer().AnalyzeGame(context.Background(), GameFallout4, plugins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stats.MissingMasters != 4 {
+		t.Errorf("expected 4 missing masters for Fallout 4, got %d", result.Stats.MissingMasters)
+	}
+}
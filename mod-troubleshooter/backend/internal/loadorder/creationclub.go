@@ -0,0 +1,109 @@
+package loadorder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Nexus game domains with a Creation Club catalog.
+const (
+	GameSkyrimSE = "skyrimspecialedition"
+	GameFallout4 = "fallout4"
+)
+
+// creationClub maps each game to its known Creation Club plugins, keyed by
+// lowercase filename, with the name of the Creation Club item.
+var creationClub = map[string]map[string]string{
+	GameSkyrimSE: {
+		"ccqdrsse001-survivalmode.esl":      "Survival Mode",
+		"ccbgssse001-fish.esm":              "Fishing",
+		"ccbgssse025-advdsgs.esm":           "Saints & Seducers",
+		"ccbgssse037-curios.esl":            "Rare Curios",
+		"ccasvsse001-almsivi.esm":           "Ghosts of the Tribunal",
+		"ccbgssse002-exoticarrows.esl":      "Arcane Archer Pack",
+		"ccbgssse003-zombies.esl":           "Dead Man's Dread",
+		"ccbgssse004-ruinsedge.esl":         "Ruin's Edge",
+		"ccbgssse005-goldbrand.esl":         "Goldbrand",
+		"ccbgssse006-stendarshammer.esl":    "Stendarr's Hammer",
+		"ccbgssse007-chrysamere.esl":        "Chrysamere",
+		"ccbgssse016-umbra.esm":             "Umbra",
+		"ccbgssse018-shadowrend.esl":        "Shadowrend",
+		"ccbgssse019-staffofsheogorath.esl": "Staff of Sheogorath",
+		"ccbgssse020-graycowl.esl":          "Gray Cowl of Nocturnal",
+		"ccbgssse034-mntuni.esl":            "Wild Horses",
+		"ccbgssse035-petnhound.esl":         "Nix-Hound",
+		"ccbgssse038-bowofshadows.esl":      "Bow of Shadows",
+		"ccbgssse040-advobgobs.esl":         "Goblins",
+		"ccbgssse041-netchleather.esl":      "Netch Leather Armor",
+		"ccbgssse067-daedinv.esm":           "The Cause",
+		"ccbgssse069-contest.esl":           "The Contest",
+		"cceejsse001-hstead.esm":            "Tundra Homestead",
+		"cceejsse005-cave.esm":              "Gallows Hall",
+		"ccedhsse001-norjewel.esl":          "Nordic Jewelry",
+		"ccffbsse002-crossbowpack.esl":      "Elite Crossbows",
+		"ccfsvsse001-backpacks.esl":         "Backpacks",
+		"ccpewsse002-armsofchaos.esl":       "Arms of Chaos",
+		"cctwbsse001-puzzledungeon.esm":     "Forgotten Seasons",
+		"ccvsvsse002-pets.esl":              "Pets of Skyrim",
+		"ccvsvsse004-beafarmer.esl":         "Farming",
+	},
+	GameFallout4: {
+		"ccbgsfo4044-hellfirepowerarmor.esl":  "Hellfire Power Armor",
+		"ccbgsfo4046-tescan.esl":              "Tesla Cannon",
+		"ccbgsfo4096-as_enclave.esl":          "Enclave Remnants",
+		"ccbgsfo4115-x02.esl":                 "X-02 Power Armor",
+		"ccbgsfo4116-heavyflamer.esl":         "Heavy Incinerator",
+		"ccfrsfo4001-handmadeshotgun.esl":     "Handmade Shotgun",
+		"cckgjfo4001-bastion.esl":             "Bastion Power Armor",
+		"ccrzrfo4001-tunnelsnakes.esm":        "Tunnel Snakes Rule!",
+		"ccswkfo4001-astronautpowerarmor.esm": "Captain Cosmos",
+	},
+}
+
+// CreationClubItem describes a plugin that belongs to Creation Club content.
+type CreationClubItem struct {
+	// Name is the Creation Club item name, empty if the plugin is not in the catalog.
+	Name string
+	// Game is the game domain of the catalog the plugin was found in, if any.
+	Game string
+}
+
+// IsCreationClubPlugin reports whether a filename follows the Creation Club
+// naming scheme (cc*.esm or cc*.esl).
+func IsCreationClubPlugin(filename string) bool {
+	lower := strings.ToLower(filename)
+	return strings.HasPrefix(lower, "cc") && (strings.HasSuffix(lower, ".esm") || strings.HasSuffix(lower, ".esl"))
+}
+
+// LookupCreationClub resolves a plugin against the Creation Club catalog of a
+// game. With an empty game every catalog is searched. Plugins that follow the
+// Creation Club naming scheme but are not catalogued are still reported, with
+// an empty name.
+func LookupCreationClub(game, filename string) (CreationClubItem, bool) {
+	if !IsCreationClubPlugin(filename) {
+		return CreationClubItem{}, false
+	}
+
+	lower := strings.ToLower(filename)
+	if game != "" {
+		return CreationClubItem{Name: creationClub[game][lower], Game: game}, true
+	}
+	for g, catalog := range creationClub {
+		if name, ok := catalog[lower]; ok {
+			return CreationClubItem{Name: name, Game: g}, true
+		}
+	}
+	return CreationClubItem{}, true
+}
+
+// creationClubMessage describes a missing Creation Club master.
+func creationClubMessage(master string, item CreationClubItem) string {
+	requires := "Creation Club item"
+	if item.Name != "" {
+		requires = fmt.Sprintf("Creation Club item %q", item.Name)
+	}
+	if item.Game == GameSkyrimSE {
+		requires = "Anniversary Edition or the " + requires
+	}
+	return fmt.Sprintf("Missing Creation Club master %s: requires %s", master, requires)
+}
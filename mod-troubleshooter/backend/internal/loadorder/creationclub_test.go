@@ -0,0 +1,52 @@
+package loadorder
+
+import "testing"
+
+func TestLookupCreationClub(t *testing.T) {
+	tests := []struct {
+		name     string
+		game     string
+		filename string
+		found    bool
+		item     CreationClubItem
+	}{
+		{"catalogued", GameSkyrimSE, "ccBGSSSE001-Fish.esm", true, CreationClubItem{Name: "Fishing", Game: GameSkyrimSE}},
+		{"any game", "", "ccBGSFO4046-TesCan.esl", true, CreationClubItem{Name: "Tesla Cannon", Game: GameFallout4}},
+		{"not catalogued", GameFallout4, "ccXYZFO4999-Unknown.esl", true, CreationClubItem{Game: GameFallout4}},
+		{"other game catalogue", GameFallout4, "ccBGSSSE001-Fish.esm", true, CreationClubItem{Game: GameFallout4}},
+		{"esp", GameSkyrimSE, "ccMod.esp", false, CreationClubItem{}},
+		{"no prefix", GameSkyrimSE, "Skyrim.esm", false, CreationClubItem{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, found := LookupCreationClub(tt.game, tt.filename)
+			if found != tt.found {
+				t.Errorf("expected found %v, got %v", tt.found, found)
+			}
+			if item != tt.item {
+				t.Errorf("expected %+v, got %+v", tt.item, item)
+			}
+		})
+	}
+}
+
+func TestCreationClubMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     CreationClubItem
+		expected string
+	}{
+		{"skyrim", CreationClubItem{Name: "Fishing", Game: GameSkyrimSE}, `Missing Creation Club master cc.esm: requires Anniversary Edition or the Creation Club item "Fishing"`},
+		{"fallout", CreationClubItem{Name: "Tesla Cannon", Game: GameFallout4}, `Missing Creation Club master cc.esm: requires Creation Club item "Tesla Cannon"`},
+		{"unknown", CreationClubItem{}, "Missing Creation Club master cc.esm: requires Creation Club item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := creationClubMessage("cc.esm", tt.item); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
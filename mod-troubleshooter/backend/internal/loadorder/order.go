@@ -0,0 +1,218 @@
+package loadorder
+
+import (
+	"container/heap"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// typeRank orders plugin types the way the game loads them: ESMs, then
+// ESLs, then ESPs.
+func typeRank(t plugin.PluginType) int {
+	switch t {
+	case plugin.PluginTypeESM:
+		return 0
+	case plugin.PluginTypeESL:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// orderNode is a plugin in the dependency graph.
+type orderNode struct {
+	name  string
+	rank  int
+	index int
+	// dependents are the nodes listing this one as a master
+	dependents []int
+	// pending is the number of masters not yet placed
+	pending int
+}
+
+// orderGraph is the master dependency graph of a load order.
+type orderGraph struct {
+	nodes []*orderNode
+	// masters are the masters of each node that are in the load order
+	masters [][]int
+}
+
+// newOrderGraph builds the dependency graph of plugins. Masters missing
+// from the load order are left out, as are later copies of duplicated
+// plugins.
+func newOrderGraph(plugins []PluginInfo) *orderGraph {
+	nodes := make([]*orderNode, 0, len(plugins))
+	byName := make(map[string]int)
+	for _, p := range plugins {
+		lower := strings.ToLower(p.Filename)
+		if _, dup := byName[lower]; dup {
+			continue
+		}
+		byName[lower] = len(nodes)
+		nodes = append(nodes, &orderNode{name: p.Filename, rank: typeRank(p.Type), index: p.Index})
+	}
+
+	masters := make([][]int, len(nodes))
+	for _, p := range plugins {
+		n := byName[strings.ToLower(p.Filename)]
+		if nodes[n].index != p.Index {
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, master := range p.Masters {
+			m, ok := byName[strings.ToLower(master)]
+			if !ok || seen[m] {
+				continue
+			}
+			seen[m] = true
+			masters[n] = append(masters[n], m)
+			nodes[m].dependents = append(nodes[m].dependents, n)
+			nodes[n].pending++
+		}
+	}
+	return &orderGraph{nodes: nodes, masters: masters}
+}
+
+// suggestOrder sorts plugins so every master loads before the plugins that
+// need it, ESMs before ESLs before ESPs wherever the masters allow, and
+// otherwise keeping the current order. Plugins in master cycles, and those
+// that need them, cannot be sorted; they are placed at the end.
+func suggestOrder(plugins []PluginInfo) []string {
+	nodes := newOrderGraph(plugins).nodes
+
+	// Kahn's algorithm, always placing the first ready plugin by type and
+	// then current position, so the sort is stable
+	ready := &orderQueue{nodes: nodes}
+	for i, node := range nodes {
+		if node.pending == 0 {
+			heap.Push(ready, i)
+		}
+	}
+	order := make([]string, 0, len(nodes))
+	placed := make([]bool, len(nodes))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		placed[i] = true
+		order = append(order, nodes[i].name)
+		for _, d := range nodes[i].dependents {
+			nodes[d].pending--
+			if nodes[d].pending == 0 {
+				heap.Push(ready, d)
+			}
+		}
+	}
+
+	// What is left is in cycles, or depends on one
+	var left []int
+	for i := range nodes {
+		if !placed[i] {
+			left = append(left, i)
+		}
+	}
+	sort.Slice(left, func(a, b int) bool { return ready.less(left[a], left[b]) })
+	for _, i := range left {
+		order = append(order, nodes[i].name)
+	}
+	return order
+}
+
+// masterCycles returns the groups of plugins that require each other as
+// masters, directly or through other plugins, including plugins listing
+// themselves. Each cycle is in load order, and the cycles are ordered by
+// their first plugin.
+func masterCycles(plugins []PluginInfo) [][]string {
+	graph := newOrderGraph(plugins)
+	nodes, masters := graph.nodes, graph.masters
+
+	// Tarjan's algorithm finds the strongly connected components
+	index := make(map[int]int)
+	low := make(map[int]int)
+	onStack := make(map[int]bool)
+	var stack []int
+	var components [][]int
+	next := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range masters[v] {
+			if _, seen := index[w]; !seen {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			var component []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+	for v := range nodes {
+		if _, seen := index[v]; !seen {
+			visit(v)
+		}
+	}
+
+	var cycles [][]int
+	for _, component := range components {
+		if len(component) == 1 && !slices.Contains(masters[component[0]], component[0]) {
+			continue
+		}
+		sort.Slice(component, func(a, b int) bool { return nodes[component[a]].index < nodes[component[b]].index })
+		cycles = append(cycles, component)
+	}
+	sort.Slice(cycles, func(a, b int) bool { return nodes[cycles[a][0]].index < nodes[cycles[b][0]].index })
+
+	names := make([][]string, len(cycles))
+	for i, cycle := range cycles {
+		for _, n := range cycle {
+			names[i] = append(names[i], nodes[n].name)
+		}
+	}
+	return names
+}
+
+// orderQueue is a heap of node indexes, the first by type and then by
+// load order position on top.
+type orderQueue struct {
+	nodes []*orderNode
+	items []int
+}
+
+func (q *orderQueue) less(a, b int) bool {
+	if q.nodes[a].rank != q.nodes[b].rank {
+		return q.nodes[a].rank < q.nodes[b].rank
+	}
+	return q.nodes[a].index < q.nodes[b].index
+}
+
+func (q *orderQueue) Len() int           { return len(q.items) }
+func (q *orderQueue) Less(i, j int) bool { return q.less(q.items[i], q.items[j]) }
+func (q *orderQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *orderQueue) Push(x interface{}) { q.items = append(q.items, x.(int)) }
+
+func (q *orderQueue) Pop() interface{} {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items = q.items[:n-1]
+	return item
+}
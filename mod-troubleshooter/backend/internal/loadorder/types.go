@@ -8,16 +8,26 @@ type IssueType string
 const (
 	// IssueMissingMaster indicates a plugin requires a master that is not present.
 	IssueMissingMaster IssueType = "missing_master"
+	// IssueMissingCreationClub indicates a plugin requires a Creation Club master
+	// that is not present. It comes with the Creation Club item, not a mod.
+	IssueMissingCreationClub IssueType = "missing_creation_club"
 	// IssueWrongOrder indicates a plugin loads before one of its masters.
 	IssueWrongOrder IssueType = "wrong_order"
 	// IssueDuplicatePlugin indicates the same plugin appears multiple times.
 	IssueDuplicatePlugin IssueType = "duplicate_plugin"
+	// IssueCircularDependency indicates plugins that require each other as
+	// masters, directly or through other plugins, so no load order
+	// satisfies them.
+	IssueCircularDependency IssueType = "circular_dependency"
 )
 
 // IssueSeverity represents the severity level of an issue.
 type IssueSeverity string
 
 const (
+	// SeverityCritical indicates no load order can work until the plugins
+	// are fixed.
+	SeverityCritical IssueSeverity = "critical"
 	// SeverityError indicates the issue will cause problems.
 	SeverityError IssueSeverity = "error"
 	// SeverityWarning indicates the issue may cause problems.
@@ -34,6 +44,9 @@ type Issue struct {
 	Plugin string `json:"plugin"`
 	// RelatedPlugin is the filename of the related plugin (e.g., missing master).
 	RelatedPlugin string `json:"relatedPlugin,omitempty"`
+	// Plugins are all the plugins involved, for issues about more than two
+	// (e.g., every plugin in a circular dependency).
+	Plugins []string `json:"plugins,omitempty"`
 	// Message is a human-readable description of the issue.
 	Message string `json:"message"`
 	// Index is the position in the load order where the issue occurs.
@@ -60,6 +73,8 @@ type PluginInfo struct {
 	HasIssues bool `json:"hasIssues"`
 	// IssueCount is the number of issues affecting this plugin.
 	IssueCount int `json:"issueCount"`
+	// BaseGame indicates the plugin ships with the game.
+	BaseGame bool `json:"baseGame,omitempty"`
 }
 
 // Stats contains summary statistics about the load order.
@@ -74,6 +89,8 @@ type Stats struct {
 	ESLCount int `json:"eslCount"`
 	// TotalIssues is the total number of detected issues.
 	TotalIssues int `json:"totalIssues"`
+	// CriticalCount is the number of critical-severity issues.
+	CriticalCount int `json:"criticalCount"`
 	// ErrorCount is the number of error-severity issues.
 	ErrorCount int `json:"errorCount"`
 	// WarningCount is the number of warning-severity issues.
@@ -82,8 +99,15 @@ type Stats struct {
 	PluginsWithIssues int `json:"pluginsWithIssues"`
 	// MissingMasters is the count of missing master issues.
 	MissingMasters int `json:"missingMasters"`
+	// MissingCreationClub is the count of missing Creation Club master issues.
+	MissingCreationClub int `json:"missingCreationClub"`
 	// WrongOrderCount is the count of wrong order issues.
 	WrongOrderCount int `json:"wrongOrderCount"`
+	// CircularDependencies is the count of circular dependency issues.
+	CircularDependencies int `json:"circularDependencies"`
+	// BaseGamePlugins is the number of base game plugins the load order
+	// lists or requires as masters. Those left out are not missing masters.
+	BaseGamePlugins int `json:"baseGamePlugins"`
 }
 
 // AnalysisResult contains the complete load order analysis.
@@ -97,6 +121,11 @@ type AnalysisResult struct {
 	// DependencyGraph maps plugin filenames to their masters.
 	// Used for visualization in the frontend.
 	DependencyGraph map[string][]string `json:"dependencyGraph"`
+	// SuggestedOrder is the plugins in an order that loads every master
+	// before the plugins that need it: ESMs first, then ESLs, then ESPs
+	// where their masters allow, and otherwise as they are now. Plugins in
+	// master cycles come last.
+	SuggestedOrder []string `json:"suggestedOrder"`
 }
 
 // PluginFile represents a plugin file to be analyzed.
@@ -108,4 +137,6 @@ type PluginFile struct {
 	Reader interface{}
 	// Header contains pre-parsed header information if available.
 	Header *plugin.PluginHeader
+	// FormIDs are the form IDs of the plugin's records, if they were scanned.
+	FormIDs []uint32
 }
@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// Size of the synthetic collection the benchmarks build manifests for,
+// about as large as the biggest collections on Nexus.
+const (
+	benchMods  = 300
+	benchFiles = 1_000_000
+)
+
+// benchPaths lists the archive paths of every mod in the collection.
+func benchPaths(mods, files int) [][]string {
+	perMod := files / mods
+	exts := []string{".dds", ".nif", ".pex", ".dds", ".hkx", ".wav"}
+	paths := make([][]string, mods)
+	for i := range paths {
+		paths[i] = make([]string, perMod)
+		for j := range paths[i] {
+			paths[i][j] = fmt.Sprintf("Data/Meshes/Mod%03d/Dir%02d/%05d%s", i, j%50, j, exts[j%len(exts)])
+		}
+	}
+	return paths
+}
+
+func BenchmarkNewManifest(b *testing.B) {
+	paths := benchPaths(benchMods, benchFiles)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, mod := range paths {
+			entries := make([]FileEntry, len(mod))
+			for j, p := range mod {
+				entries[j] = NewFileEntry(p, int64(j))
+			}
+			NewManifest(entries)
+		}
+	}
+}
+
+// TestBenchmarkBaselines fails when building manifests got slower than its
+// baseline, which leaves room for slower machines. It takes a while, so it
+// only runs with BENCH_GATE=1.
+func TestBenchmarkBaselines(t *testing.T) {
+	if os.Getenv("BENCH_GATE") == "" {
+		t.Skip("set BENCH_GATE=1 to check benchmark baselines")
+	}
+
+	const baseline = 4 * time.Second
+	r := testing.Benchmark(BenchmarkNewManifest)
+	got := time.Duration(r.NsPerOp())
+	t.Logf("NewManifest: %v/op, %d allocs/op (baseline %v)", got, r.AllocsPerOp(), baseline)
+	if got > baseline {
+		t.Errorf("building manifests took %v per operation, over its baseline of %v", got, baseline)
+	}
+}
@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Diff lists what changed between two manifests, such as two versions of
+// the same mod.
+type Diff struct {
+	// Added are the files only in the second manifest.
+	Added []DiffFile `json:"added"`
+	// Removed are the files only in the first manifest.
+	Removed []DiffFile `json:"removed"`
+	// Changed are the files in both whose contents differ.
+	Changed []FileChange `json:"changed"`
+	// Unchanged is the number of files in both whose contents match.
+	Unchanged int `json:"unchanged"`
+	// HashesCompared is true when both manifests carry content hashes.
+	// Otherwise changes are found by size alone, which misses edits that
+	// keep a file's size.
+	HashesCompared bool `json:"hashesCompared"`
+}
+
+// DiffFile is a file present in only one of two manifests.
+type DiffFile struct {
+	// Path is the normalized path, without archive wrapper folders.
+	Path string `json:"path"`
+	// Archive is the BSA or BA2 the file is packed in, if any.
+	Archive string `json:"archive,omitempty"`
+	Size    int64  `json:"size"`
+	// Hash is the content hash, if the manifest has one.
+	Hash string `json:"hash,omitempty"`
+}
+
+// FileChange is a file present in both manifests with different contents.
+type FileChange struct {
+	// Path is the normalized path, without archive wrapper folders.
+	Path string `json:"path"`
+	// Archive is the BSA or BA2 the file is packed in, if any.
+	Archive string `json:"archive,omitempty"`
+	OldSize int64  `json:"oldSize"`
+	NewSize int64  `json:"newSize"`
+	// OldHash and NewHash are the content hashes, if both are known.
+	OldHash string `json:"oldHash,omitempty"`
+	NewHash string `json:"newHash,omitempty"`
+}
+
+// CompareFiles lists the files added, removed and changed from a to b.
+// Files are matched by normalized path, after stripping archive wrapper
+// folders such as "mymod v1.2/", so that a renamed wrapper does not count
+// every file as changed. Files with content hashes on both sides are compared
+// by hash; others by size.
+func CompareFiles(a, b *Manifest) Diff {
+	before, after := diffFiles(a), diffFiles(b)
+	diff := Diff{
+		Added:   []DiffFile{},
+		Removed: []DiffFile{},
+		Changed: []FileChange{},
+	}
+
+	hashesA, hashesB := false, false
+	for key, f := range before {
+		hashesA = hashesA || f.Hash != ""
+		n, ok := after[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, f)
+			continue
+		}
+		if f.Hash != "" && n.Hash != "" {
+			if f.Hash == n.Hash {
+				diff.Unchanged++
+				continue
+			}
+		} else if f.Size == n.Size {
+			diff.Unchanged++
+			continue
+		}
+
+		change := FileChange{Path: f.Path, Archive: f.Archive, OldSize: f.Size, NewSize: n.Size}
+		if f.Hash != "" && n.Hash != "" {
+			change.OldHash, change.NewHash = f.Hash, n.Hash
+		}
+		diff.Changed = append(diff.Changed, change)
+	}
+	for key, f := range after {
+		hashesB = hashesB || f.Hash != ""
+		if _, ok := before[key]; !ok {
+			diff.Added = append(diff.Added, f)
+		}
+	}
+	diff.HashesCompared = hashesA && hashesB
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diffLess(diff.Added[i], diff.Added[j]) })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diffLess(diff.Removed[i], diff.Removed[j]) })
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diffLess(DiffFile{Path: diff.Changed[i].Path, Archive: diff.Changed[i].Archive},
+			DiffFile{Path: diff.Changed[j].Path, Archive: diff.Changed[j].Archive})
+	})
+	return diff
+}
+
+// diffFiles keys the files of a manifest by archive and wrapper-free path.
+// Packed files are keyed apart from loose files at the same path.
+func diffFiles(m *Manifest) map[string]DiffFile {
+	files := make(map[string]DiffFile)
+	if m == nil {
+		return files
+	}
+
+	root := wrapperRoot(m.Files)
+	for _, f := range m.Files {
+		file := DiffFile{
+			Path:    strings.TrimPrefix(f.Path, root),
+			Archive: strings.TrimPrefix(f.Archive, root),
+			Size:    f.Size,
+		}
+		if HasContentHash(f) {
+			file.Hash = f.Hash
+		}
+		files[file.Archive+"|"+file.Path] = file
+	}
+	return files
+}
+
+// diffLess orders files by path, then archive.
+func diffLess(a, b DiffFile) bool {
+	if a.Path != b.Path {
+		return a.Path < b.Path
+	}
+	return a.Archive < b.Archive
+}
@@ -0,0 +1,65 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareFiles(t *testing.T) {
+	old := []FileEntry{
+		NewFileEntry("My Mod v1.0/meshes/a.nif", 10),
+		NewFileEntry("My Mod v1.0/textures/a.dds", 20),
+		NewFileEntry("My Mod v1.0/scripts/old.pex", 5),
+	}
+	updated := []FileEntry{
+		NewFileEntry("My Mod v1.1/meshes/a.nif", 10),
+		NewFileEntry("My Mod v1.1/textures/a.dds", 24),
+		NewFileEntry("My Mod v1.1/scripts/new.pex", 7),
+	}
+
+	diff := CompareFiles(NewManifest(old), NewManifest(updated))
+	if diff.HashesCompared {
+		t.Error("expected no hash comparison without content hashes")
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("expected 1 unchanged file, got %d", diff.Unchanged)
+	}
+	if want := []DiffFile{{Path: "scripts/new.pex", Size: 7}}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("expected added %+v, got %+v", want, diff.Added)
+	}
+	if want := []DiffFile{{Path: "scripts/old.pex", Size: 5}}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("expected removed %+v, got %+v", want, diff.Removed)
+	}
+	if want := []FileChange{{Path: "textures/a.dds", OldSize: 20, NewSize: 24}}; !reflect.DeepEqual(diff.Changed, want) {
+		t.Errorf("expected changed %+v, got %+v", want, diff.Changed)
+	}
+}
+
+func TestCompareFiles_Hashes(t *testing.T) {
+	a := withHashes(entriesOf("meshes/a.nif", "meshes/b.nif"), "h1", "h2")
+	b := withHashes(entriesOf("meshes/a.nif", "meshes/b.nif"), "h1", "h3")
+
+	diff := CompareFiles(NewManifest(a), NewManifest(b))
+	if !diff.HashesCompared {
+		t.Error("expected a hash comparison")
+	}
+	// Same size, different contents
+	want := []FileChange{{Path: "meshes/b.nif", OldSize: 1, NewSize: 1, OldHash: "h2", NewHash: "h3"}}
+	if diff.Unchanged != 1 || !reflect.DeepEqual(diff.Changed, want) {
+		t.Errorf("expected %+v and 1 unchanged, got %+v and %d", want, diff.Changed, diff.Unchanged)
+	}
+}
+
+func TestCompareFiles_Packed(t *testing.T) {
+	loose := NewFileEntry("meshes/a.nif", 1)
+	packed := NewFileEntry("meshes/a.nif", 2)
+	packed.Archive = "mod.bsa"
+
+	diff := CompareFiles(NewManifest([]FileEntry{loose}), NewManifest([]FileEntry{loose, packed}))
+	if want := []DiffFile{{Path: "meshes/a.nif", Archive: "mod.bsa", Size: 2}}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("expected the packed copy to be added, got %+v", diff.Added)
+	}
+	if diff.Unchanged != 1 || len(diff.Changed) != 0 {
+		t.Errorf("expected the loose copy unchanged, got %+v", diff)
+	}
+}
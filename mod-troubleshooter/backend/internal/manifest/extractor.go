@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/mholt/archiver/v4"
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/bsa"
 )
 
 // Common errors returned by the extractor.
@@ -30,7 +34,8 @@ func NewExtractor() *Extractor {
 
 // ExtractManifest extracts the file manifest from an archive without extracting file contents.
 // This is a lightweight operation that only reads the archive directory.
-func (e *Extractor) ExtractManifest(ctx context.Context, archivePath string) (*Manifest, error) {
+// Password opens encrypted archives and may be empty.
+func (e *Extractor) ExtractManifest(ctx context.Context, archivePath, password string) (*Manifest, error) {
 	if archivePath == "" {
 		return nil, ErrNoArchivePath
 	}
@@ -48,7 +53,7 @@ func (e *Extractor) ExtractManifest(ctx context.Context, archivePath string) (*M
 	defer file.Close()
 
 	// Identify the archive format
-	format, input, err := archiver.Identify(ctx, archivePath, file)
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
 	}
@@ -88,7 +93,7 @@ func (e *Extractor) ExtractManifest(ctx context.Context, archivePath string) (*M
 
 // ExtractManifestWithHashes extracts the file manifest and computes content hashes.
 // This is more expensive as it reads file contents to compute hashes.
-func (e *Extractor) ExtractManifestWithHashes(ctx context.Context, archivePath string) (*Manifest, error) {
+func (e *Extractor) ExtractManifestWithHashes(ctx context.Context, archivePath, password string) (*Manifest, error) {
 	if archivePath == "" {
 		return nil, ErrNoArchivePath
 	}
@@ -106,7 +111,7 @@ func (e *Extractor) ExtractManifestWithHashes(ctx context.Context, archivePath s
 	defer file.Close()
 
 	// Identify the archive format
-	format, input, err := archiver.Identify(ctx, archivePath, file)
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
 	}
@@ -162,8 +167,138 @@ func (e *Extractor) ExtractManifestWithHashes(ctx context.Context, archivePath s
 	return NewManifest(entries), nil
 }
 
+// Options select what ExtractManifestWithOptions reads beyond the file list.
+type Options struct {
+	// Hashes computes content hashes.
+	Hashes bool `json:"hashes,omitempty"`
+	// Packed also lists the files packed in BSA and BA2 archives, with
+	// Archive set to the archive they came from.
+	Packed bool `json:"packed,omitempty"`
+}
+
+// ExtractManifestWithOptions extracts the file manifest, reading file
+// contents as opts requires. With no options it matches ExtractManifest.
+func (e *Extractor) ExtractManifestWithOptions(ctx context.Context, archivePath, password string, opts Options) (*Manifest, error) {
+	if !opts.Packed {
+		if opts.Hashes {
+			return e.ExtractManifestWithHashes(ctx, archivePath, password)
+		}
+		return e.ExtractManifest(ctx, archivePath, password)
+	}
+	if archivePath == "" {
+		return nil, ErrNoArchivePath
+	}
+
+	// Check if archive exists
+	if _, err := os.Stat(archivePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveNotFound, archivePath)
+	}
+
+	// Open the archive file
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	// Identify the archive format
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	// Ensure we have an extractor format
+	extractor, ok := format.(archiver.Extractor)
+	if !ok {
+		return nil, fmt.Errorf("%w: format does not support extraction", ErrUnsupportedFormat)
+	}
+
+	var entries []FileEntry
+	err = extractor.Extract(ctx, input, func(ctx context.Context, f archiver.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if f.IsDir() {
+			return nil
+		}
+
+		entry := NewFileEntry(f.NameInArchive, f.Size())
+		if !opts.Hashes && entry.Type != FileTypeBSA {
+			entries = append(entries, entry)
+			return nil
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			entries = append(entries, entry)
+			return nil
+		}
+		defer rc.Close()
+
+		// A single pass lists a BSA while hashing it
+		var r io.Reader = rc
+		hash := sha256.New()
+		if opts.Hashes {
+			r = io.TeeReader(rc, hash)
+		}
+		if entry.Type == FileTypeBSA {
+			packed, err := bsa.List(r)
+			if err == nil {
+				entries = append(entries, packedEntries(entry, packed)...)
+			}
+		}
+		if opts.Hashes {
+			if _, err := io.Copy(hash, rc); err == nil {
+				entry.Hash = hex.EncodeToString(hash.Sum(nil))
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExtractionFailed, err)
+	}
+
+	return NewManifest(dropShadowed(entries)), nil
+}
+
+// dropShadowed removes packed entries for paths the mod also ships loose or
+// in an earlier archive, since only one of them reaches the game.
+func dropShadowed(entries []FileEntry) []FileEntry {
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Archive == "" {
+			seen[entry.Path] = true
+		}
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.Archive != "" {
+			if seen[entry.Path] {
+				continue
+			}
+			seen[entry.Path] = true
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// packedEntries converts the files listed from a BSA or BA2 into entries
+// placed where the game sees them, next to the archive.
+func packedEntries(container FileEntry, packed []bsa.Entry) []FileEntry {
+	dir := path.Dir(strings.ReplaceAll(container.OriginalPath, "\\", "/"))
+	entries := make([]FileEntry, 0, len(packed))
+	for _, p := range packed {
+		entry := NewFileEntry(path.Join(dir, p.Path), p.Size)
+		entry.Archive = container.Path
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 // ExtractManifestFiltered extracts the manifest only for files matching the filter function.
-func (e *Extractor) ExtractManifestFiltered(ctx context.Context, archivePath string, filter func(FileEntry) bool) (*Manifest, error) {
+func (e *Extractor) ExtractManifestFiltered(ctx context.Context, archivePath, password string, filter func(FileEntry) bool) (*Manifest, error) {
 	if archivePath == "" {
 		return nil, ErrNoArchivePath
 	}
@@ -181,7 +316,7 @@ func (e *Extractor) ExtractManifestFiltered(ctx context.Context, archivePath str
 	defer file.Close()
 
 	// Identify the archive format
-	format, input, err := archiver.Identify(ctx, archivePath, file)
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
 	}
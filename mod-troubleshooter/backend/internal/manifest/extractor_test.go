@@ -3,6 +3,7 @@ package manifest
 import (
 	"archive/zip"
 	"context"
+	"encoding/binary"
 	"os"
 	"strings"
 	"testing"
@@ -28,7 +29,7 @@ func TestExtractor_ExtractManifest(t *testing.T) {
 	ext := NewExtractor()
 	ctx := context.Background()
 
-	manifest, err := ext.ExtractManifest(ctx, zipPath)
+	manifest, err := ext.ExtractManifest(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("ExtractManifest() error = %v", err)
 	}
@@ -65,14 +66,14 @@ func TestExtractor_ExtractManifest_Errors(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("empty path", func(t *testing.T) {
-		_, err := ext.ExtractManifest(ctx, "")
+		_, err := ext.ExtractManifest(ctx, "", "")
 		if err != ErrNoArchivePath {
 			t.Errorf("ExtractManifest() error = %v, want ErrNoArchivePath", err)
 		}
 	})
 
 	t.Run("non-existent file", func(t *testing.T) {
-		_, err := ext.ExtractManifest(ctx, "/nonexistent/archive.zip")
+		_, err := ext.ExtractManifest(ctx, "/nonexistent/archive.zip", "")
 		if err == nil || !strings.Contains(err.Error(), "not found") {
 			t.Errorf("ExtractManifest() error = %v, want error containing 'not found'", err)
 		}
@@ -87,7 +88,7 @@ func TestExtractor_ExtractManifest_Errors(t *testing.T) {
 		tmpFile.Close()
 		defer os.Remove(tmpFile.Name())
 
-		_, err = ext.ExtractManifest(ctx, tmpFile.Name())
+		_, err = ext.ExtractManifest(ctx, tmpFile.Name(), "")
 		if err == nil || !strings.Contains(err.Error(), "unsupported") {
 			t.Errorf("ExtractManifest() error = %v, want error containing 'unsupported'", err)
 		}
@@ -104,7 +105,7 @@ func TestExtractor_ExtractManifest_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err := ext.ExtractManifest(ctx, zipPath)
+	_, err := ext.ExtractManifest(ctx, zipPath, "")
 	if err == nil || !strings.Contains(err.Error(), "context canceled") {
 		t.Errorf("ExtractManifest() with cancelled context should fail, got error = %v", err)
 	}
@@ -121,7 +122,7 @@ func TestExtractor_ExtractManifestWithHashes(t *testing.T) {
 	ext := NewExtractor()
 	ctx := context.Background()
 
-	manifest, err := ext.ExtractManifestWithHashes(ctx, zipPath)
+	manifest, err := ext.ExtractManifestWithHashes(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("ExtractManifestWithHashes() error = %v", err)
 	}
@@ -152,6 +153,73 @@ func TestExtractor_ExtractManifestWithHashes(t *testing.T) {
 	}
 }
 
+// buildBA2 builds a general BA2 archive listing the given files, without
+// their data.
+func buildBA2(files map[string]uint32) []byte {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	data := []byte("BTDX")
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	data = append(data, "GNRL"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(names)))
+	data = binary.LittleEndian.AppendUint64(data, uint64(24+36*len(names)))
+	for _, name := range names {
+		record := make([]byte, 36)
+		binary.LittleEndian.PutUint32(record[28:32], files[name])
+		data = append(data, record...)
+	}
+	for _, name := range names {
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(name)))
+		data = append(data, name...)
+	}
+	return data
+}
+
+func TestExtractor_ExtractManifestWithOptions(t *testing.T) {
+	zipPath := createTestZip(t, map[string]string{
+		"Mod.ba2":          string(buildBA2(map[string]uint32{"textures\\packed.dds": 100, "meshes\\loose.nif": 50})),
+		"meshes/loose.nif": "loose",
+	})
+	defer os.Remove(zipPath)
+
+	ext := NewExtractor()
+	ctx := context.Background()
+
+	m, err := ext.ExtractManifestWithOptions(ctx, zipPath, "", Options{Packed: true})
+	if err != nil {
+		t.Fatalf("ExtractManifestWithOptions() error = %v", err)
+	}
+	if m.TotalCount != 3 {
+		t.Fatalf("TotalCount = %d, want 3 (%+v)", m.TotalCount, m.Files)
+	}
+	packed := m.GetFile("textures/packed.dds")
+	if packed == nil || packed.Archive != "mod.ba2" || packed.Size != 100 {
+		t.Errorf("expected packed texture from mod.ba2, got %+v", packed)
+	}
+	// The loose copy shadows the packed one
+	if loose := m.GetFile("meshes/loose.nif"); loose == nil || loose.Archive != "" {
+		t.Errorf("expected loose mesh, got %+v", loose)
+	}
+	if ba2 := m.GetFile("mod.ba2"); ba2 == nil || m.TotalSize != ba2.Size+5 {
+		t.Errorf("TotalSize = %d, want loose files only", m.TotalSize)
+	}
+
+	hashed, err := ext.ExtractManifestWithOptions(ctx, zipPath, "", Options{Hashes: true, Packed: true})
+	if err != nil {
+		t.Fatalf("ExtractManifestWithOptions() error = %v", err)
+	}
+	plain, err := ext.ExtractManifestWithHashes(ctx, zipPath, "")
+	if err != nil {
+		t.Fatalf("ExtractManifestWithHashes() error = %v", err)
+	}
+	if hashed.GetFile("mod.ba2").Hash != plain.GetFile("mod.ba2").Hash {
+		t.Error("expected the archive hashed as a whole while it is listed")
+	}
+}
+
 func TestExtractor_ExtractManifestFiltered(t *testing.T) {
 	zipPath := createTestZip(t, map[string]string{
 		"test.esp":          "plugin",
@@ -165,7 +233,7 @@ func TestExtractor_ExtractManifestFiltered(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("filter by type", func(t *testing.T) {
-		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, FilterByType(FileTypeMesh))
+		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, "", FilterByType(FileTypeMesh))
 		if err != nil {
 			t.Fatalf("ExtractManifestFiltered() error = %v", err)
 		}
@@ -180,7 +248,7 @@ func TestExtractor_ExtractManifestFiltered(t *testing.T) {
 	})
 
 	t.Run("filter by extension", func(t *testing.T) {
-		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, FilterByExtension(".esp"))
+		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, "", FilterByExtension(".esp"))
 		if err != nil {
 			t.Fatalf("ExtractManifestFiltered() error = %v", err)
 		}
@@ -191,7 +259,7 @@ func TestExtractor_ExtractManifestFiltered(t *testing.T) {
 	})
 
 	t.Run("filter by directory", func(t *testing.T) {
-		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, FilterByDirectory("meshes"))
+		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, "", FilterByDirectory("meshes"))
 		if err != nil {
 			t.Fatalf("ExtractManifestFiltered() error = %v", err)
 		}
@@ -202,7 +270,7 @@ func TestExtractor_ExtractManifestFiltered(t *testing.T) {
 	})
 
 	t.Run("filter by path prefix", func(t *testing.T) {
-		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, FilterByPathPrefix("textures"))
+		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, "", FilterByPathPrefix("textures"))
 		if err != nil {
 			t.Fatalf("ExtractManifestFiltered() error = %v", err)
 		}
@@ -213,7 +281,7 @@ func TestExtractor_ExtractManifestFiltered(t *testing.T) {
 	})
 
 	t.Run("nil filter extracts all", func(t *testing.T) {
-		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, nil)
+		manifest, err := ext.ExtractManifestFiltered(ctx, zipPath, "", nil)
 		if err != nil {
 			t.Fatalf("ExtractManifestFiltered() error = %v", err)
 		}
@@ -238,7 +306,7 @@ func TestExtractor_LargeArchive(t *testing.T) {
 	ext := NewExtractor()
 	ctx := context.Background()
 
-	manifest, err := ext.ExtractManifest(ctx, zipPath)
+	manifest, err := ext.ExtractManifest(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("ExtractManifest() error = %v", err)
 	}
@@ -251,7 +319,7 @@ func TestExtractor_LargeArchive(t *testing.T) {
 
 func TestExtractor_SpecialPaths(t *testing.T) {
 	zipPath := createTestZip(t, map[string]string{
-		"normal.esp":              "normal",
+		"normal.esp":             "normal",
 		"Data/with spaces.esp":   "spaces",
 		"Data/special-chars.esp": "special",
 		"深层/test.esp":            "unicode dir",
@@ -261,7 +329,7 @@ func TestExtractor_SpecialPaths(t *testing.T) {
 	ext := NewExtractor()
 	ctx := context.Background()
 
-	manifest, err := ext.ExtractManifest(ctx, zipPath)
+	manifest, err := ext.ExtractManifest(ctx, zipPath, "")
 	if err != nil {
 		t.Fatalf("ExtractManifest() error = %v", err)
 	}
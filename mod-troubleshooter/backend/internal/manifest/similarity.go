@@ -0,0 +1,130 @@
+package manifest
+
+import "strings"
+
+// dataDirs are top-level folders of a game's Data directory.
+// A single archive root folder with any other name is treated as a wrapper.
+var dataDirs = map[string]bool{
+	"meshes": true, "textures": true, "scripts": true, "interface": true,
+	"sound": true, "music": true, "seq": true, "strings": true,
+	"materials": true, "shadersfx": true, "lodsettings": true, "grass": true,
+	"video": true, "fomod": true, "skse": true, "f4se": true, "sfse": true,
+	"nvse": true, "fose": true, "obse": true, "calientetools": true,
+	"nemesis_engine": true, "tools": true, "dialogueviews": true,
+}
+
+// Similarity describes how much two manifests have in common.
+type Similarity struct {
+	// SharedPaths is the number of normalized paths present in both manifests.
+	SharedPaths int `json:"sharedPaths"`
+	// PathScore is the Jaccard index of the normalized path sets (0-1).
+	PathScore float64 `json:"pathScore"`
+	// HashesCompared is true when both manifests carry content hashes.
+	HashesCompared bool `json:"hashesCompared"`
+	// SharedHashes is the number of distinct file contents present in both manifests.
+	SharedHashes int `json:"sharedHashes"`
+	// HashScore is the Jaccard index of the content hash sets (0-1).
+	HashScore float64 `json:"hashScore"`
+	// Score is the overall similarity: the hash score when content hashes were
+	// compared, since it also matches renamed files, otherwise the path score.
+	Score float64 `json:"score"`
+}
+
+// Fingerprint is a manifest prepared for repeated similarity comparisons.
+type Fingerprint struct {
+	paths  map[string]bool
+	hashes map[string]bool
+}
+
+// NewFingerprint normalizes a manifest for comparison. Archive wrapper folders
+// and a leading Data folder are stripped so that repackaged copies of the same
+// files compare equal. Only content hashes are kept; path hashes are ignored.
+func NewFingerprint(m *Manifest) *Fingerprint {
+	fp := &Fingerprint{paths: map[string]bool{}, hashes: map[string]bool{}}
+	if m == nil {
+		return fp
+	}
+
+	root := wrapperRoot(m.Files)
+	for _, f := range m.Files {
+		fp.paths[strings.TrimPrefix(f.Path, root)] = true
+		if HasContentHash(f) {
+			fp.hashes[f.Hash] = true
+		}
+	}
+	return fp
+}
+
+// Len returns the number of distinct normalized paths.
+func (fp *Fingerprint) Len() int {
+	return len(fp.paths)
+}
+
+// Compare measures the similarity of two fingerprints.
+func (fp *Fingerprint) Compare(other *Fingerprint) Similarity {
+	var s Similarity
+	s.SharedPaths, s.PathScore = jaccard(fp.paths, other.paths)
+	s.Score = s.PathScore
+
+	if len(fp.hashes) > 0 && len(other.hashes) > 0 {
+		s.HashesCompared = true
+		s.SharedHashes, s.HashScore = jaccard(fp.hashes, other.hashes)
+		s.Score = s.HashScore
+	}
+	return s
+}
+
+// Compare measures the similarity of two manifests.
+func Compare(a, b *Manifest) Similarity {
+	return NewFingerprint(a).Compare(NewFingerprint(b))
+}
+
+// HasContentHash reports whether an entry's hash is a content hash rather
+// than the default path hash.
+func HasContentHash(f FileEntry) bool {
+	return f.Hash != "" && f.Hash != ComputePathHash(f.Path)
+}
+
+// wrapperRoot returns the folder prefix shared by every file that is not part
+// of the game's Data layout, such as "mymod v1.2/data/", or "" if there is none.
+func wrapperRoot(files []FileEntry) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	root := ""
+	for {
+		var first string
+		for i, f := range files {
+			rest := strings.TrimPrefix(f.Path, root)
+			dir, _, found := strings.Cut(rest, "/")
+			if !found || (i > 0 && dir != first) {
+				return root
+			}
+			first = dir
+		}
+		if dataDirs[first] {
+			return root
+		}
+		root += first + "/"
+	}
+}
+
+// jaccard returns the size of the intersection and the Jaccard index of two sets.
+func jaccard(a, b map[string]bool) (int, float64) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0
+	}
+	small, large := a, b
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+
+	shared := 0
+	for key := range small {
+		if large[key] {
+			shared++
+		}
+	}
+	return shared, float64(shared) / float64(len(a)+len(b)-shared)
+}
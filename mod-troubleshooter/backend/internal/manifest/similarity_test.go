@@ -0,0 +1,93 @@
+package manifest
+
+import "testing"
+
+func entriesOf(paths ...string) []FileEntry {
+	entries := make([]FileEntry, 0, len(paths))
+	for _, p := range paths {
+		entries = append(entries, NewFileEntry(p, 1))
+	}
+	return entries
+}
+
+func withHashes(entries []FileEntry, hashes ...string) []FileEntry {
+	for i := range entries {
+		entries[i].Hash = hashes[i]
+	}
+	return entries
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name           string
+		a, b           []FileEntry
+		wantShared     int
+		wantScore      float64
+		wantHashScore  float64
+		hashesCompared bool
+	}{
+		{
+			name:       "identical",
+			a:          entriesOf("meshes/a.nif", "textures/a.dds"),
+			b:          entriesOf("meshes/a.nif", "textures/a.dds"),
+			wantShared: 2,
+			wantScore:  1,
+		},
+		{
+			name:       "wrapper folders are ignored",
+			a:          entriesOf("My Mod v1.0/Data/meshes/a.nif", "My Mod v1.0/Data/textures/a.dds"),
+			b:          entriesOf("meshes/a.nif", "textures/a.dds"),
+			wantShared: 2,
+			wantScore:  1,
+		},
+		{
+			name:       "partial overlap",
+			a:          entriesOf("meshes/a.nif", "meshes/b.nif", "meshes/c.nif"),
+			b:          entriesOf("meshes/a.nif", "meshes/b.nif", "meshes/d.nif"),
+			wantShared: 2,
+			wantScore:  0.5,
+		},
+		{
+			name:       "disjoint",
+			a:          entriesOf("meshes/a.nif"),
+			b:          entriesOf("textures/a.dds"),
+			wantShared: 0,
+			wantScore:  0,
+		},
+		{
+			name:           "renamed files match by content",
+			a:              withHashes(entriesOf("meshes/a.nif", "meshes/b.nif"), "h1", "h2"),
+			b:              withHashes(entriesOf("meshes/x.nif", "meshes/y.nif"), "h1", "h2"),
+			wantShared:     0,
+			wantScore:      1,
+			wantHashScore:  1,
+			hashesCompared: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(NewManifest(tt.a), NewManifest(tt.b))
+			if got.SharedPaths != tt.wantShared {
+				t.Errorf("expected %d shared paths, got %d", tt.wantShared, got.SharedPaths)
+			}
+			if got.Score != tt.wantScore {
+				t.Errorf("expected score %v, got %v", tt.wantScore, got.Score)
+			}
+			if got.HashesCompared != tt.hashesCompared || got.HashScore != tt.wantHashScore {
+				t.Errorf("expected hash score %v (compared %v), got %+v", tt.wantHashScore, tt.hashesCompared, got)
+			}
+		})
+	}
+}
+
+func TestHasContentHash(t *testing.T) {
+	entry := NewFileEntry("meshes/a.nif", 1)
+	if HasContentHash(entry) {
+		t.Error("expected path hash not to count as a content hash")
+	}
+	entry.Hash = "abc123"
+	if !HasContentHash(entry) {
+		t.Error("expected content hash to be detected")
+	}
+}
@@ -50,13 +50,17 @@ type FileEntry struct {
 	Directory string `json:"directory"`
 	// Filename is the filename without directory.
 	Filename string `json:"filename"`
+	// Archive is the path of the BSA or BA2 the file is packed in, for files
+	// listed from inside one. Their Path is where the game sees them.
+	Archive string `json:"archive,omitempty"`
 }
 
 // Manifest represents the complete file listing from a mod archive.
 type Manifest struct {
 	// Files is the list of all files in the archive.
 	Files []FileEntry `json:"files"`
-	// TotalSize is the sum of all file sizes.
+	// TotalSize is the sum of all file sizes, not counting files packed in
+	// BSA or BA2 archives twice.
 	TotalSize int64 `json:"totalSize"`
 	// TotalCount is the number of files.
 	TotalCount int `json:"totalCount"`
@@ -149,7 +153,10 @@ func NewManifest(entries []FileEntry) *Manifest {
 	}
 
 	for _, entry := range entries {
-		m.TotalSize += entry.Size
+		// Packed files already count towards the size of their archive
+		if entry.Archive == "" {
+			m.TotalSize += entry.Size
+		}
 		m.ByType[entry.Type]++
 		if entry.Extension != "" {
 			m.ByExtension[entry.Extension]++
@@ -351,7 +351,7 @@ func TestManifest_HasFile(t *testing.T) {
 		expected bool
 	}{
 		{"data/test.esp", true},
-		{"Data/Test.esp", true}, // Case insensitive
+		{"Data/Test.esp", true},  // Case insensitive
 		{"data\\test.esp", true}, // Backslash normalization
 		{"meshes/test.nif", true},
 		{"data/missing.esp", false},
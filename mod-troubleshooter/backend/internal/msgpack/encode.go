@@ -0,0 +1,606 @@
+package msgpack
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	numberType        = reflect.TypeFor[json.Number]()
+)
+
+// encoderFunc writes a value of one type.
+type encoderFunc func(e *encoder, v reflect.Value) error
+
+// encoderCache holds the encoders of the types encoded so far.
+var encoderCache sync.Map // map[reflect.Type]encoderFunc
+
+// encode writes v as encoding/json would encode it.
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.writeNil()
+		return nil
+	}
+	return typeEncoder(v.Type())(e, v)
+}
+
+// typeEncoder returns the encoder for type t, building it on first use.
+func typeEncoder(t reflect.Type) encoderFunc {
+	if f, ok := encoderCache.Load(t); ok {
+		return f.(encoderFunc)
+	}
+
+	// Recursive types reach themselves while their encoder is being built,
+	// so a placeholder waiting for it is stored first
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	placeholder, loaded := encoderCache.LoadOrStore(t, encoderFunc(func(e *encoder, v reflect.Value) error {
+		wg.Wait()
+		return f(e, v)
+	}))
+	if loaded {
+		return placeholder.(encoderFunc)
+	}
+	f = newTypeEncoder(t, true)
+	wg.Done()
+	encoderCache.Store(t, f)
+	return f
+}
+
+// newTypeEncoder builds the encoder for type t. With allowAddr, values
+// whose pointer has a MarshalJSON or MarshalText method use it when they
+// are addressable, as encoding/json does.
+func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
+	if t.Kind() != reflect.Pointer && allowAddr {
+		if reflect.PointerTo(t).Implements(marshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+			addr, plain := newTypeEncoder(reflect.PointerTo(t), false), newTypeEncoder(t, false)
+			return func(e *encoder, v reflect.Value) error {
+				if v.CanAddr() {
+					return addr(e, v.Addr())
+				}
+				return plain(e, v)
+			}
+		}
+	}
+
+	switch {
+	case t.Implements(marshalerType):
+		return encodeMarshaler
+	case t.Implements(textMarshalerType):
+		return encodeTextMarshaler
+	case t == numberType:
+		return encodeNumber
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(e *encoder, v reflect.Value) error {
+			e.writeBool(v.Bool())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(e *encoder, v reflect.Value) error {
+			e.writeInt(v.Int())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(e *encoder, v reflect.Value) error {
+			e.writeUint(v.Uint())
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
+		return func(e *encoder, v reflect.Value) error {
+			return e.encodeFloat(v.Float(), bits)
+		}
+	case reflect.String:
+		return func(e *encoder, v reflect.Value) error {
+			e.writeString(validString(v.String()))
+			return nil
+		}
+	case reflect.Interface:
+		return func(e *encoder, v reflect.Value) error {
+			if v.IsNil() {
+				e.writeNil()
+				return nil
+			}
+			return e.encode(v.Elem())
+		}
+	case reflect.Pointer:
+		return nilable(newPointerEncoder(t))
+	case reflect.Slice:
+		if isBytes(t) {
+			return nilable(func(e *encoder, v reflect.Value) error {
+				e.writeString(base64.StdEncoding.EncodeToString(v.Bytes()))
+				return nil
+			})
+		}
+		return nilable(newArrayEncoder(t))
+	case reflect.Array:
+		return newArrayEncoder(t)
+	case reflect.Map:
+		return nilable(newMapEncoder(t))
+	case reflect.Struct:
+		return newStructEncoder(t)
+	}
+	return func(e *encoder, v reflect.Value) error {
+		return &json.UnsupportedTypeError{Type: t}
+	}
+}
+
+// nilable wraps the encoder of a pointer, slice or map type to write nil
+// values as nil.
+func nilable(enc encoderFunc) encoderFunc {
+	return func(e *encoder, v reflect.Value) error {
+		if v.IsNil() {
+			e.writeNil()
+			return nil
+		}
+		return enc(e, v)
+	}
+}
+
+func newPointerEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	return func(e *encoder, v reflect.Value) error {
+		return elem(e, v.Elem())
+	}
+}
+
+// encodeMarshaler writes the output of a MarshalJSON method.
+func encodeMarshaler(e *encoder, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		e.writeNil()
+		return nil
+	}
+	m, ok := v.Interface().(json.Marshaler)
+	if !ok {
+		e.writeNil()
+		return nil
+	}
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return &json.MarshalerError{Type: v.Type(), Err: err}
+	}
+	return e.fromJSON(data)
+}
+
+// encodeTextMarshaler writes the output of a MarshalText method as a
+// string.
+func encodeTextMarshaler(e *encoder, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		e.writeNil()
+		return nil
+	}
+	tm, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok {
+		e.writeNil()
+		return nil
+	}
+	text, err := tm.MarshalText()
+	if err != nil {
+		return &json.MarshalerError{Type: v.Type(), Err: err}
+	}
+	e.writeString(validString(string(text)))
+	return nil
+}
+
+// encodeNumber writes a json.Number as the number it holds.
+func encodeNumber(e *encoder, v reflect.Value) error {
+	n := json.Number(v.String())
+	if n == "" {
+		n = "0"
+	}
+	if !json.Valid([]byte(n)) {
+		return fmt.Errorf("msgpack: invalid number literal %q", n)
+	}
+	e.writeNumber(n)
+	return nil
+}
+
+// encodeFloat writes f as an integer if encoding/json would print it as
+// one, and as a float64 of the value it would print otherwise.
+func (e *encoder) encodeFloat(f float64, bits int) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+	if bits == 32 {
+		f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+	}
+	// encoding/json prints integral values below 1e21 without an exponent
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		switch {
+		case f >= math.MinInt64 && f < math.MaxInt64:
+			e.writeInt(int64(f))
+			return nil
+		case f > 0 && f < math.MaxUint64:
+			e.writeUint(uint64(f))
+			return nil
+		}
+	}
+	e.writeFloat(f)
+	return nil
+}
+
+func newArrayEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	return func(e *encoder, v reflect.Value) error {
+		n := v.Len()
+		e.writeArrayHeader(n)
+		for i := 0; i < n; i++ {
+			if err := elem(e, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// mapEntry is a map entry with its key as encoding/json names it.
+type mapEntry struct {
+	key   string
+	value reflect.Value
+}
+
+// newMapEncoder returns an encoder writing maps with their keys sorted, as
+// encoding/json does.
+func newMapEncoder(t reflect.Type) encoderFunc {
+	switch kt := t.Key(); kt.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !kt.Implements(textMarshalerType) {
+			return func(e *encoder, v reflect.Value) error {
+				return &json.UnsupportedTypeError{Type: t}
+			}
+		}
+	}
+
+	elem := typeEncoder(t.Elem())
+	return func(e *encoder, v reflect.Value) error {
+		entries := make([]mapEntry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return err
+			}
+			entries = append(entries, mapEntry{key: key, value: iter.Value()})
+		}
+		slices.SortFunc(entries, func(a, b mapEntry) int { return strings.Compare(a.key, b.key) })
+
+		e.writeMapHeader(len(entries))
+		for _, entry := range entries {
+			e.writeString(entry.key)
+			if err := elem(e, entry.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// mapKey returns the object key encoding/json uses for a map key.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return validString(k.String()), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		if err != nil {
+			return "", &json.MarshalerError{Type: k.Type(), Err: err}
+		}
+		return validString(string(text)), nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+// newStructEncoder returns an encoder writing structs as maps of their
+// encoded fields. The fields are counted before any is written, since the
+// header comes first.
+func newStructEncoder(t reflect.Type) encoderFunc {
+	fields := typeFields(t)
+	for i := range fields {
+		fields[i].enc = typeEncoder(typeByIndex(t, fields[i].index))
+		if fields[i].quoted {
+			fields[i].enc = encodeQuoted
+		}
+	}
+
+	return func(e *encoder, v reflect.Value) error {
+		n := 0
+		for i := range fields {
+			if fv, ok := fields[i].value(v); ok && !fields[i].omit(fv) {
+				n++
+			}
+		}
+
+		e.writeMapHeader(n)
+		for i := range fields {
+			f := &fields[i]
+			fv, ok := f.value(v)
+			if !ok || f.omit(fv) {
+				continue
+			}
+			e.writeString(f.name)
+			if err := f.enc(e, fv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// typeByIndex returns the type of the struct field at index, following
+// embedded pointers.
+func typeByIndex(t reflect.Type, index []int) reflect.Type {
+	for _, i := range index {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		t = t.Field(i).Type
+	}
+	return t
+}
+
+// encodeQuoted writes a field with the ",string" option: a string holding
+// the JSON encoding of its value.
+func encodeQuoted(e *encoder, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			e.writeNil()
+			return nil
+		}
+		v = v.Elem()
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	e.writeString(string(data))
+	return nil
+}
+
+// isBytes reports whether encoding/json encodes a slice type as base64.
+func isBytes(t reflect.Type) bool {
+	if t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	p := reflect.PointerTo(t.Elem())
+	return !p.Implements(marshalerType) && !p.Implements(textMarshalerType)
+}
+
+// validString replaces invalid UTF-8 bytes with U+FFFD, as encoding/json
+// does.
+func validString(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteRune(r)
+		i += size
+	}
+	return b.String()
+}
+
+// field is a struct field encoding/json encodes.
+type field struct {
+	name   string
+	tagged bool
+	index  []int
+	typ    reflect.Type
+
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+
+	enc encoderFunc
+}
+
+// value returns the field of struct v. It is false when the field is in an
+// embedded struct reached through a nil pointer.
+func (f *field) value(v reflect.Value) (reflect.Value, bool) {
+	for i, x := range f.index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// omit reports whether the field's options leave out value v.
+func (f *field) omit(v reflect.Value) bool {
+	if f.omitEmpty && isEmpty(v) {
+		return true
+	}
+	if f.omitZero {
+		if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+			if v.Kind() != reflect.Pointer || !v.IsNil() {
+				return z.IsZero()
+			}
+		}
+		return v.IsZero()
+	}
+	return false
+}
+
+// isEmpty reports whether omitempty leaves out v.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// typeFields returns the fields encoding/json encodes for struct type t,
+// in its order: fields of embedded structs are promoted breadth first, and
+// of several fields with one name only the shallowest, or the only tagged
+// one at that depth, is kept.
+func typeFields(t reflect.Type) []field {
+	current := []field{}
+	next := []field{{typ: t}}
+
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+	var fields []field
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(f.index), i)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					fld := field{
+						name:      name,
+						tagged:    name != "",
+						index:     index,
+						typ:       ft,
+						omitEmpty: hasOption(opts, "omitempty"),
+						omitZero:  hasOption(opts, "omitzero"),
+						quoted:    hasOption(opts, "string") && quotable(ft),
+					}
+					if fld.name == "" {
+						fld.name = sf.Name
+					}
+					fields = append(fields, fld)
+					// A name promoted from two embedded structs of one type
+					// at the same depth cancels out
+					if count[f.typ] > 1 {
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, field{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(fields, func(a, b field) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) - len(b.index)
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.index, b.index)
+	})
+
+	kept := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		dup := fields[i:j]
+		if len(dup) == 1 || len(dup[0].index) < len(dup[1].index) || dup[0].tagged != dup[1].tagged {
+			kept = append(kept, dup[0])
+		}
+		i = j
+	}
+
+	slices.SortFunc(kept, func(a, b field) int { return slices.Compare(a.index, b.index) })
+	return kept
+}
+
+// hasOption reports whether a comma-separated tag option list has name.
+func hasOption(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// quotable reports whether the ",string" option applies to type t.
+func quotable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+	return false
+}
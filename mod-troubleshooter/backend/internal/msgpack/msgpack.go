@@ -0,0 +1,228 @@
+// Package msgpack encodes values as MessagePack, a binary format with the
+// same data model as JSON that is roughly half the size for analysis results.
+//
+// Values are encoded exactly as encoding/json would encode them, so field
+// names, omitempty and custom MarshalJSON methods carry over and a decoded
+// result has the same shape as the JSON one. They are encoded directly in a
+// single pass; only the output of MarshalJSON methods goes through JSON.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// ContentType is the media type of MessagePack responses.
+const ContentType = "application/msgpack"
+
+// encoderPool keeps encoders, so their buffers are reused.
+var encoderPool = sync.Pool{New: func() interface{} { return &encoder{} }}
+
+// Marshal returns the MessagePack encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	e := encoderPool.Get().(*encoder)
+	defer func() {
+		e.buf = e.buf[:0]
+		encoderPool.Put(e)
+	}()
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.buf), nil
+}
+
+// FromJSON transcodes a JSON document to MessagePack. Object keys keep
+// their order, integers are encoded as integers and other numbers as
+// float64.
+func FromJSON(data []byte) ([]byte, error) {
+	e := &encoder{buf: make([]byte, 0, len(data))}
+	if err := e.fromJSON(data); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// encoder appends MessagePack to a buffer.
+type encoder struct {
+	buf []byte
+}
+
+// fromJSON transcodes a JSON document into the buffer.
+func (e *encoder) fromJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := e.transcode(dec); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("msgpack: trailing data after JSON value")
+	}
+	return nil
+}
+
+// maxHeaderSize is the size of a 32-bit map or array header.
+const maxHeaderSize = 5
+
+// transcode encodes the next JSON value from dec.
+func (e *encoder) transcode(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		// Containers are length prefixed, so room is left for the largest
+		// header and the elements are encoded behind it. Once they are
+		// counted, the header is written and the elements moved up to it.
+		start := len(e.buf)
+		e.buf = append(e.buf, make([]byte, maxHeaderSize)...)
+		n := 0
+		for dec.More() {
+			if v == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return fmt.Errorf("msgpack: %w", err)
+				}
+				e.writeString(key.(string))
+			}
+			if err := e.transcode(dec); err != nil {
+				return err
+			}
+			n++
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("msgpack: %w", err)
+		}
+
+		var header []byte
+		if v == '{' {
+			header = appendHeader(nil, n, 0x80, 0xde, 0xdf)
+		} else {
+			header = appendHeader(nil, n, 0x90, 0xdc, 0xdd)
+		}
+		copy(e.buf[start:], header)
+		if gap := maxHeaderSize - len(header); gap > 0 {
+			copy(e.buf[start+len(header):], e.buf[start+maxHeaderSize:])
+			e.buf = e.buf[:len(e.buf)-gap]
+		}
+	case string:
+		e.writeString(v)
+	case json.Number:
+		e.writeNumber(v)
+	case bool:
+		e.writeBool(v)
+	case nil:
+		e.writeNil()
+	}
+	return nil
+}
+
+// writeMapHeader writes the header of a map of n entries.
+func (e *encoder) writeMapHeader(n int) {
+	e.buf = appendHeader(e.buf, n, 0x80, 0xde, 0xdf)
+}
+
+// writeArrayHeader writes the header of an array of n elements.
+func (e *encoder) writeArrayHeader(n int) {
+	e.buf = appendHeader(e.buf, n, 0x90, 0xdc, 0xdd)
+}
+
+// appendHeader appends the header of a map or array of n elements, using
+// the fix, 16-bit or 32-bit form as the length needs.
+func appendHeader(buf []byte, n int, fix, code16, code32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, code32), uint32(n))
+	}
+}
+
+func (e *encoder) writeNil() {
+	e.buf = append(e.buf, 0xc0)
+}
+
+func (e *encoder) writeBool(v bool) {
+	if v {
+		e.buf = append(e.buf, 0xc3)
+	} else {
+		e.buf = append(e.buf, 0xc2)
+	}
+}
+
+func (e *encoder) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) writeNumber(n json.Number) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		e.writeInt(i)
+		return
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		e.writeUint(u)
+		return
+	}
+	// encoding/json only writes valid numbers
+	f, _ := n.Float64()
+	e.writeFloat(f)
+}
+
+func (e *encoder) writeFloat(f float64) {
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(f))
+}
+
+// writeUint writes u in the smallest form that holds it.
+func (e *encoder) writeUint(u uint64) {
+	if u <= math.MaxInt64 {
+		e.writeInt(int64(u))
+		return
+	}
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), u)
+}
+
+// writeInt writes i in the smallest form that holds it.
+func (e *encoder) writeInt(i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		e.buf = append(e.buf, byte(i))
+	case i < 0 && i >= -32:
+		e.buf = append(e.buf, byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(i))
+	case i >= math.MinInt8 && i < 0:
+		e.buf = append(e.buf, 0xd0, byte(int8(i)))
+	case i >= math.MinInt16 && i < 0:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(int16(i)))
+	case i >= math.MinInt32 && i < 0:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(int32(i)))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(i))
+	}
+}
@@ -0,0 +1,187 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	value := struct {
+		A int           `json:"a"`
+		B []interface{} `json:"b"`
+		C float64       `json:"c"`
+		D int           `json:"d"`
+		E string        `json:"e,omitempty"`
+		F map[int]int   `json:"f"`
+	}{A: 1, B: []interface{}{true, nil, "x"}, C: -1.5, D: 300, F: map[int]int{}}
+
+	got, err := Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	float := make([]byte, 8)
+	binary.BigEndian.PutUint64(float, math.Float64bits(-1.5))
+	want := []byte{0x85, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc3, 0xc0, 0xa1, 'x', 0xa1, 'c', 0xcb}
+	want = append(want, float...)
+	want = append(want, 0xa1, 'd', 0xcd, 0x01, 0x2c, 0xa1, 'f', 0x80)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal() = % x, want % x", got, want)
+	}
+}
+
+func TestMarshal_Lengths(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		header []byte
+	}{
+		{name: "str8", value: strings.Repeat("a", 40), header: []byte{0xd9, 40}},
+		{name: "str16", value: strings.Repeat("a", 300), header: []byte{0xda, 0x01, 0x2c}},
+		{name: "array16", value: make([]int, 20), header: []byte{0xdc, 0x00, 0x14}},
+		{name: "negative", value: -200, header: []byte{0xd1, 0xff, 0x38}},
+		{name: "negative fixint", value: -3, header: []byte{0xfd}},
+		{name: "uint64", value: uint64(math.MaxUint64), header: []byte{0xcf, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !bytes.HasPrefix(got, tt.header) {
+				t.Errorf("Marshal() = % x, want prefix % x", got, tt.header)
+			}
+		})
+	}
+}
+
+func TestFromJSON_Invalid(t *testing.T) {
+	for _, doc := range []string{`{"a":`, `[1] 2`, ``} {
+		if _, err := FromJSON([]byte(doc)); err == nil {
+			t.Errorf("FromJSON(%q) expected an error", doc)
+		}
+	}
+}
+
+type embedded struct {
+	ID    string `json:"id"`
+	Shown int    `json:"shown"`
+}
+
+type shadowing struct {
+	embedded
+	*Inner
+	Shown string `json:"shown"`
+}
+
+type Inner struct {
+	Depth int `json:"depth,omitempty"`
+}
+
+func TestMarshal_MatchesJSON(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	values := map[string]interface{}{
+		"embedded":     shadowing{embedded: embedded{ID: "a", Shown: 1}, Shown: "outer"},
+		"nil embedded": shadowing{Inner: &Inner{Depth: 2}},
+		"int keys":     map[int]string{10: "b", 2: "a"},
+		"bytes":        []byte("raw"),
+		"time":         when,
+		"raw":          json.RawMessage(`{"z":1,"a":[1,2,{"b":null}]}`),
+		"floats":       []interface{}{float32(0.1), 3.0, 1e21, -0.5, 1e20},
+		"nil values":   []interface{}{nil, (*Inner)(nil), []string(nil), map[string]int(nil)},
+		"invalid utf8": "a\xffb",
+		"pointers":     &struct{ P *int }{P: new(int)},
+		"number":       json.Number("12.5"),
+	}
+
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			got, err := Marshal(value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			want, err := FromJSON(data)
+			if err != nil {
+				t.Fatalf("FromJSON() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Marshal() = % x, want % x (%s)", got, want, data)
+			}
+		})
+	}
+}
+
+func TestMarshal_Unsupported(t *testing.T) {
+	for _, value := range []interface{}{math.NaN(), make(chan int), map[[2]int]int{}} {
+		if _, err := Marshal(value); err == nil {
+			t.Errorf("Marshal(%T) expected an error", value)
+		}
+	}
+}
+
+// benchmarkResult resembles a conflict analysis result.
+func benchmarkResult() interface{} {
+	type file struct {
+		ModID    string `json:"modId"`
+		ModName  string `json:"modName"`
+		Path     string `json:"path"`
+		Size     int64  `json:"size"`
+		Hash     string `json:"hash,omitempty"`
+		FileType string `json:"fileType"`
+	}
+	type conflict struct {
+		Path      string `json:"path"`
+		Severity  string `json:"severity"`
+		Winner    file   `json:"winner"`
+		Losers    []file `json:"losers"`
+		Identical bool   `json:"identical"`
+	}
+	conflicts := make([]conflict, 2000)
+	for i := range conflicts {
+		path := fmt.Sprintf("textures/armor/piece%04d.dds", i)
+		f := file{ModID: fmt.Sprint(i % 50), ModName: "Some Armor Mod", Path: path, Size: int64(i) * 4096, Hash: strings.Repeat("ab", 32), FileType: "texture"}
+		conflicts[i] = conflict{Path: path, Severity: "medium", Winner: f, Losers: []file{f, f}}
+	}
+	return map[string]interface{}{"data": map[string]interface{}{"conflicts": conflicts, "total": len(conflicts)}}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	value := benchmarkResult()
+	b.ReportAllocs()
+	var size int
+	for b.Loop() {
+		data, err := Marshal(value)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size = len(data)
+	}
+	b.ReportMetric(float64(size), "bytes")
+}
+
+// BenchmarkJSON is the JSON encoding of the same result, for comparison.
+func BenchmarkJSON(b *testing.B) {
+	value := benchmarkResult()
+	b.ReportAllocs()
+	var size int
+	for b.Loop() {
+		data, err := json.Marshal(value)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size = len(data)
+	}
+	b.ReportMetric(float64(size), "bytes")
+}
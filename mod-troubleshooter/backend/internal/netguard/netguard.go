@@ -0,0 +1,130 @@
+// Package netguard keeps requests to user-supplied URLs from reaching the
+// server's own network, such as services bound to localhost, other hosts
+// on the LAN or a cloud provider's metadata endpoint.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbidden is returned for destinations that are not public addresses.
+var ErrForbidden = errors.New("destination is not a public address")
+
+// nonPublic are ranges that IsGlobalUnicast and IsPrivate let through but
+// are not reachable on the internet: "this network", and the shared address
+// space some cloud providers serve metadata from.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// Guard decides which addresses requests may be sent to. A nil or zero
+// Guard only allows public addresses.
+type Guard struct {
+	allowed []netip.Prefix
+}
+
+// New creates a guard that also allows the given IPs and CIDR ranges, such
+// as the LAN a self-hosted notification server runs on.
+func New(allowed []string) (*Guard, error) {
+	g := &Guard{}
+	for _, entry := range allowed {
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed network %q: %w", entry, err)
+		}
+		g.allowed = append(g.allowed, prefix)
+	}
+	return g, nil
+}
+
+// Check returns an error wrapping ErrForbidden if addr may not be reached.
+func (g *Guard) Check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if g != nil {
+		for _, prefix := range g.allowed {
+			if prefix.Contains(addr) {
+				return nil
+			}
+		}
+	}
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("%w: %s", ErrForbidden, addr)
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbidden, addr)
+		}
+	}
+	return nil
+}
+
+// CheckHost checks the host of a URL before it is saved. IP addresses and
+// localhost names are checked right away; other names may resolve
+// differently by the time they are used, so they are checked when dialed.
+func (g *Guard) CheckHost(host string) error {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		if g.Check(netip.AddrFrom4([4]byte{127, 0, 0, 1})) == nil || g.Check(netip.IPv6Loopback()) == nil {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrForbidden, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.Check(addr)
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function refusing to connect to
+// addresses the guard does not allow. It sees the resolved address, so a
+// name pointing at the server's network is caught too.
+func (g *Guard) Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbidden, host)
+	}
+	return g.Check(addr)
+}
+
+// NewClient returns an HTTP client that only connects where the guard
+// allows. Proxies from the environment are not used, as they would connect
+// on the client's behalf.
+func (g *Guard) NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: g.Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// parsePrefix parses an IP or CIDR range.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,93 @@
+package netguard
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestGuard_Check(t *testing.T) {
+	guard, err := New([]string{"192.168.1.0/24", "::1"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.0.0.5", false},
+		{"172.16.0.1", false},
+		{"169.254.169.254", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"192.168.1.20", true},
+		{"192.168.2.20", false},
+		{"::1", true},
+	}
+	for _, tt := range tests {
+		err := guard.Check(netip.MustParseAddr(tt.addr))
+		if tt.allowed && err != nil {
+			t.Errorf("Check(%s) error = %v, want allowed", tt.addr, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrForbidden) {
+			t.Errorf("Check(%s) error = %v, want ErrForbidden", tt.addr, err)
+		}
+	}
+
+	var zero *Guard
+	if err := zero.Check(netip.MustParseAddr("::1")); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected a nil guard to refuse loopback, got %v", err)
+	}
+}
+
+func TestGuard_CheckHost(t *testing.T) {
+	var guard Guard
+	for _, host := range []string{"localhost", "LOCALHOST:8080", "app.localhost.", "127.0.0.1:80", "[::1]:443", "169.254.169.254"} {
+		if err := guard.CheckHost(host); !errors.Is(err, ErrForbidden) {
+			t.Errorf("CheckHost(%s) error = %v, want ErrForbidden", host, err)
+		}
+	}
+	// Names are checked once they resolve
+	for _, host := range []string{"ntfy.sh", "gotify.lan:8080", "1.1.1.1"} {
+		if err := guard.CheckHost(host); err != nil {
+			t.Errorf("CheckHost(%s) error = %v", host, err)
+		}
+	}
+
+	loopback, _ := New([]string{"127.0.0.0/8"})
+	if err := loopback.CheckHost("localhost:8080"); err != nil {
+		t.Errorf("expected an allowed loopback to allow localhost, got %v", err)
+	}
+
+	if _, err := New([]string{"not-a-network"}); err == nil {
+		t.Error("expected an error for an invalid network")
+	}
+}
+
+func TestGuard_NewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var public Guard
+	if _, err := public.NewClient(time.Second).Get(server.URL); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected the loopback server to be refused, got %v", err)
+	}
+
+	lan, _ := New([]string{"127.0.0.1"})
+	resp, err := lan.NewClient(time.Second).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected an allowed address to be reached, got %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,147 @@
+package nexus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDegraded is returned while the circuit breaker is open.
+// Errors wrapping it are *DegradedError values carrying the retry time.
+var ErrDegraded = errors.New("nexus is degraded")
+
+// Default circuit breaker settings.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 2 * time.Minute
+)
+
+// DegradedError reports that requests are rejected until RetryAt.
+type DegradedError struct {
+	RetryAt time.Time
+}
+
+// Error implements error.
+func (e *DegradedError) Error() string {
+	return fmt.Sprintf("Nexus is degraded, retry at %s", e.RetryAt.Format("15:04"))
+}
+
+// Unwrap returns ErrDegraded.
+func (e *DegradedError) Unwrap() error {
+	return ErrDegraded
+}
+
+// BreakerConfig holds configuration for a Breaker.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive server errors or rate limit
+	// responses that open the breaker. Defaults to DefaultBreakerThreshold.
+	Threshold int
+	// Cooldown is how long the breaker stays open before a probe request is
+	// let through. Defaults to DefaultBreakerCooldown.
+	Cooldown time.Duration
+}
+
+// Breaker is a circuit breaker for the Nexus API. After sustained 5xx or 429
+// responses it rejects requests immediately, so large analyses fail fast
+// instead of every mod timing out on its own. It is safe for concurrent use
+// and may be shared by several clients; a nil Breaker allows everything.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	retryAt  time.Time
+	probing  bool
+}
+
+// BreakerStatus is a snapshot of a breaker's state.
+type BreakerStatus struct {
+	// Open is true while requests are being rejected.
+	Open bool `json:"open"`
+	// RetryAt is when requests will be attempted again, if open.
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+	// ConsecutiveFailures is the current run of server errors.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+}
+
+// NewBreaker creates a circuit breaker.
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	cooldown := cfg.Cooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow returns a *DegradedError if requests should not be sent right now.
+// Once the cooldown has passed, a single probe request is allowed through;
+// its outcome closes or reopens the breaker.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.retryAt.IsZero() {
+		return nil
+	}
+	if b.now().Before(b.retryAt) || b.probing {
+		return &DegradedError{RetryAt: b.retryAt}
+	}
+	b.probing = true
+	return nil
+}
+
+// Record updates the breaker with the outcome of a request.
+// Success closes the breaker and server errors or rate limiting count as
+// failures. Other errors, such as a missing resource, leave it unchanged.
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		b.retryAt = time.Time{}
+		b.probing = false
+		return
+	}
+	if !errors.Is(err, ErrServerError) && !errors.Is(err, ErrRateLimited) {
+		// A probe that failed for another reason frees the slot for the next one
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.probing || b.failures >= b.threshold {
+		b.retryAt = b.now().Add(b.cooldown)
+		b.probing = false
+	}
+}
+
+// Status returns the current state of the breaker.
+func (b *Breaker) Status() BreakerStatus {
+	if b == nil {
+		return BreakerStatus{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{ConsecutiveFailures: b.failures}
+	if !b.retryAt.IsZero() {
+		retryAt := b.retryAt
+		status.Open = true
+		status.RetryAt = &retryAt
+	}
+	return status
+}
@@ -0,0 +1,71 @@
+package nexus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 14, 0, 0, 0, time.Local)
+	b := NewBreaker(BreakerConfig{Threshold: 3, Cooldown: 5 * time.Minute})
+	b.now = func() time.Time { return now }
+
+	serverErr := fmt.Errorf("%w: status 502", ErrServerError)
+
+	b.Record(serverErr)
+	b.Record(ErrRateLimited)
+	b.Record(ErrNotFound) // the API is responding, but that doesn't clear the run
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected breaker closed below threshold, got %v", err)
+	}
+
+	b.Record(serverErr)
+	err := b.Allow()
+	if !errors.Is(err, ErrDegraded) {
+		t.Fatalf("expected ErrDegraded, got %v", err)
+	}
+	if err.Error() != "Nexus is degraded, retry at 14:05" {
+		t.Errorf("expected retry time in message, got %q", err.Error())
+	}
+	if status := b.Status(); !status.Open || status.ConsecutiveFailures != 3 {
+		t.Errorf("expected open status, got %+v", status)
+	}
+
+	// After the cooldown a single probe is let through
+	now = now.Add(5 * time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrDegraded) {
+		t.Errorf("expected concurrent requests rejected during probe, got %v", err)
+	}
+
+	// A failed probe reopens the breaker
+	b.Record(serverErr)
+	if err := b.Allow(); !errors.Is(err, ErrDegraded) {
+		t.Errorf("expected breaker reopened, got %v", err)
+	}
+
+	// A successful probe closes it
+	now = now.Add(5 * time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	b.Record(nil)
+	if err := b.Allow(); err != nil {
+		t.Errorf("expected breaker closed, got %v", err)
+	}
+	if status := b.Status(); status.Open || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected closed status, got %+v", status)
+	}
+}
+
+func TestBreaker_Nil(t *testing.T) {
+	var b *Breaker
+	b.Record(ErrServerError)
+	if err := b.Allow(); err != nil {
+		t.Errorf("expected nil breaker to allow requests, got %v", err)
+	}
+}
@@ -7,25 +7,51 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math"
+	"math/rand/v2"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/flight"
 )
 
 // Common errors returned by the client.
 var (
-	ErrNoAPIKey       = errors.New("nexus API key is required")
-	ErrUnauthorized   = errors.New("invalid or expired API key")
-	ErrRateLimited    = errors.New("rate limit exceeded")
-	ErrNotFound       = errors.New("resource not found")
-	ErrServerError    = errors.New("nexus server error")
-	ErrGraphQLErrors  = errors.New("graphql query returned errors")
-	ErrPremiumOnly    = errors.New("this feature requires a Nexus Mods Premium account")
-	ErrForbidden      = errors.New("access forbidden")
+	ErrNoAPIKey      = errors.New("nexus API key is required")
+	ErrUnauthorized  = errors.New("invalid or expired API key")
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrNotFound      = errors.New("resource not found")
+	ErrServerError   = errors.New("nexus server error")
+	ErrGraphQLErrors = errors.New("graphql query returned errors")
+	ErrPremiumOnly   = errors.New("this feature requires a Nexus Mods Premium account")
+	ErrForbidden     = errors.New("access forbidden")
 )
 
+// RateLimitError is returned for 429 responses. It wraps ErrRateLimited and
+// carries how long the server asked us to wait, if it said.
+type RateLimitError struct {
+	// RetryAfter is the wait from the Retry-After or rate limit reset headers.
+	// Zero means the server gave no hint.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v: retry after %v", ErrRateLimited, e.RetryAfter.Round(time.Second))
+	}
+	return ErrRateLimited.Error()
+}
+
+// Unwrap returns ErrRateLimited.
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
 // ClientConfig holds configuration for the Nexus client.
 type ClientConfig struct {
 	APIKey         string
@@ -33,6 +59,9 @@ type ClientConfig struct {
 	MaxRetries     int
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
+	// Breaker rejects requests while Nexus is degraded (optional).
+	// Share one breaker between clients so it survives API key changes.
+	Breaker *Breaker
 }
 
 // Client handles communication with the Nexus Mods API.
@@ -42,6 +71,12 @@ type Client struct {
 	maxRetries     int
 	initialBackoff time.Duration
 	maxBackoff     time.Duration
+	breaker        *Breaker
+
+	// queries shares in-flight GraphQL requests between identical calls
+	queries flight.Group[json.RawMessage]
+	// reduced maps queries that hit schema drift to the variants that work
+	reduced map[string]reducedQuery
 
 	// Rate limiting state
 	mu              sync.RWMutex
@@ -50,6 +85,12 @@ type Client struct {
 	rateLimitInfo   *RateLimitInfo
 }
 
+// reducedQuery is a query with the fields Nexus no longer provides left out.
+type reducedQuery struct {
+	query   string
+	dropped []string
+}
+
 // NewClient creates a new Nexus API client with the given configuration.
 func NewClient(cfg ClientConfig) (*Client, error) {
 	if cfg.APIKey == "" {
@@ -84,12 +125,52 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 		maxRetries:      maxRetries,
 		initialBackoff:  initialBackoff,
 		maxBackoff:      maxBackoff,
+		breaker:         cfg.Breaker,
 		minRequestDelay: 100 * time.Millisecond, // ~10 requests per second max
 	}, nil
 }
 
 // Query executes a GraphQL query against the Nexus API.
 func (c *Client) Query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	_, err := c.queryReduced(ctx, query, variables, result)
+	return err
+}
+
+// queryReduced executes a query, leaving out optional fields the Nexus
+// schema no longer has, and returns the fields left out. Reduced variants
+// are remembered, so later calls go straight to them.
+func (c *Client) queryReduced(ctx context.Context, query string, variables map[string]interface{}, result interface{}) ([]string, error) {
+	c.mu.RLock()
+	reduced, ok := c.reduced[query]
+	c.mu.RUnlock()
+	if !ok {
+		reduced = reducedQuery{query: query}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.execute(ctx, reduced.query, variables, result)
+		var drift *SchemaDriftError
+		if !errors.As(err, &drift) || attempt >= maxDriftRetries {
+			return reduced.dropped, err
+		}
+		q, dropped, ok := dropFields(reduced.query, drift.Fields)
+		if !ok {
+			return reduced.dropped, err
+		}
+
+		log.Printf("Warning: Nexus no longer provides %s, retrying without them", strings.Join(dropped, ", "))
+		reduced = reducedQuery{query: q, dropped: append(append([]string(nil), reduced.dropped...), dropped...)}
+		c.mu.Lock()
+		if c.reduced == nil {
+			c.reduced = make(map[string]reducedQuery)
+		}
+		c.reduced[query] = reduced
+		c.mu.Unlock()
+	}
+}
+
+// execute sends a query and decodes its data into result.
+func (c *Client) execute(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
 	reqBody := GraphQLRequest{
 		Query:     query,
 		Variables: variables,
@@ -100,45 +181,75 @@ func (c *Client) Query(ctx context.Context, query string, variables map[string]i
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
+	// Identical concurrent queries share one request
+	data, err := c.queries.Do(ctx, string(bodyBytes), func(ctx context.Context) (json.RawMessage, error) {
+		return c.query(ctx, bodyBytes)
+	})
+	if err != nil {
+		return err
+	}
+
+	if result != nil && data != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("decode data: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// query sends a GraphQL request with retries and returns the raw response data.
+func (c *Client) query(ctx context.Context, bodyBytes []byte) (json.RawMessage, error) {
 	var lastErr error
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
-			backoff := c.calculateBackoff(attempt)
+			backoff, ok := c.retryDelay(attempt, lastErr)
+			if !ok {
+				return nil, lastErr
+			}
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return nil, ctx.Err()
 			case <-time.After(backoff):
 			}
 		}
 
+		// Fail fast while Nexus is degraded
+		if err := c.breaker.Allow(); err != nil {
+			return nil, err
+		}
+
 		// Enforce rate limiting
 		if err := c.waitForRateLimit(ctx); err != nil {
-			return err
+			return nil, err
 		}
 
 		resp, err := c.doRequest(ctx, bodyBytes)
 		if err != nil {
+			c.breaker.Record(err)
 			lastErr = err
 			// Only retry on transient errors
 			if isRetryable(err) {
 				continue
 			}
-			return err
+			return nil, err
 		}
 
 		// Parse and decode response
-		if err := c.decodeResponse(resp, result); err != nil {
+		c.breaker.Record(nil)
+		var data json.RawMessage
+		if err := c.decodeResponse(resp, &data); err != nil {
 			lastErr = err
 			if isRetryable(err) {
 				continue
 			}
-			return err
+			return nil, err
 		}
 
-		return nil
+		return data, nil
 	}
 
-	return fmt.Errorf("max retries exceeded: %w", lastErr)
+	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
 // doRequest performs the HTTP request and handles response status codes.
@@ -152,10 +263,6 @@ func (c *Client) doRequest(ctx context.Context, body []byte) (*http.Response, er
 	req.Header.Set("apikey", c.apiKey)
 	req.Header.Set("User-Agent", "ModTroubleshooter/1.0")
 
-	c.mu.Lock()
-	c.lastRequest = time.Now()
-	c.mu.Unlock()
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
@@ -173,7 +280,7 @@ func (c *Client) doRequest(ctx context.Context, body []byte) (*http.Response, er
 		return nil, ErrUnauthorized
 	case http.StatusTooManyRequests:
 		resp.Body.Close()
-		return nil, ErrRateLimited
+		return nil, &RateLimitError{RetryAfter: retryAfter(resp.Header, time.Now())}
 	case http.StatusNotFound:
 		resp.Body.Close()
 		return nil, ErrNotFound
@@ -203,6 +310,9 @@ func (c *Client) decodeResponse(resp *http.Response, result interface{}) error {
 	}
 
 	// Check for GraphQL errors
+	if drift := schemaDrift(gqlResp.Errors); drift != nil {
+		return drift
+	}
 	if len(gqlResp.Errors) > 0 {
 		return fmt.Errorf("%w: %s", ErrGraphQLErrors, gqlResp.Errors[0].Message)
 	}
@@ -221,20 +331,23 @@ func (c *Client) decodeResponse(resp *http.Response, result interface{}) error {
 	return nil
 }
 
-// waitForRateLimit ensures we don't exceed rate limits.
+// waitForRateLimit ensures we don't exceed rate limits. Each caller
+// reserves the next free slot, so concurrent requests are spaced out too.
 func (c *Client) waitForRateLimit(ctx context.Context) error {
-	c.mu.RLock()
-	lastReq := c.lastRequest
-	minDelay := c.minRequestDelay
-	c.mu.RUnlock()
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.lastRequest.Add(c.minRequestDelay)
+	if slot.Before(now) {
+		slot = now
+	}
+	c.lastRequest = slot
+	c.mu.Unlock()
 
-	elapsed := time.Since(lastReq)
-	if elapsed < minDelay {
-		waitTime := minDelay - elapsed
+	if wait := slot.Sub(now); wait > 0 {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(waitTime):
+		case <-time.After(wait):
 		}
 	}
 
@@ -250,6 +363,55 @@ func (c *Client) calculateBackoff(attempt int) time.Duration {
 	return time.Duration(backoff)
 }
 
+// retryDelay returns how long to wait before a retry attempt. A wait requested
+// by the server is honored; otherwise the exponential backoff is jittered so
+// concurrent callers don't retry in lockstep. It returns false when the server
+// asked for a longer wait than the maximum backoff, as retrying sooner would fail.
+func (c *Client) retryDelay(attempt int, lastErr error) (time.Duration, bool) {
+	var rateErr *RateLimitError
+	if errors.As(lastErr, &rateErr) && rateErr.RetryAfter > 0 {
+		if rateErr.RetryAfter > c.maxBackoff {
+			return 0, false
+		}
+		return rateErr.RetryAfter, true
+	}
+	return jitter(c.calculateBackoff(attempt)), true
+}
+
+// jitter returns a random duration between d/2 and d.
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+// retryAfter reads how long to wait after a 429 response from the Retry-After
+// header (seconds or an HTTP date), falling back to the reset time of an
+// exhausted Nexus rate limit. It returns zero if neither is present.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0)
+		}
+	}
+
+	for _, limit := range []string{"Hourly", "Daily"} {
+		if h.Get("X-RL-"+limit+"-Remaining") != "0" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, h.Get("X-RL-"+limit+"-Reset")); err == nil {
+			return max(t.Sub(now), 0)
+		}
+	}
+
+	return 0
+}
+
 // parseRateLimitHeaders extracts rate limiting info from response headers.
 func (c *Client) parseRateLimitHeaders(resp *http.Response) {
 	c.mu.Lock()
@@ -291,6 +453,11 @@ func (c *Client) GetRateLimitInfo() *RateLimitInfo {
 	return &info
 }
 
+// BreakerStatus returns the state of the client's circuit breaker.
+func (c *Client) BreakerStatus() BreakerStatus {
+	return c.breaker.Status()
+}
+
 // isRetryable returns true if the error is transient and can be retried.
 func isRetryable(err error) bool {
 	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServerError)
@@ -303,7 +470,8 @@ func (c *Client) GetCollection(ctx context.Context, slug string) (*Collection, e
 	}
 
 	var resp CollectionResponse
-	if err := c.Query(ctx, CollectionQuery, variables, &resp); err != nil {
+	missing, err := c.queryReduced(ctx, CollectionQuery, variables, &resp)
+	if err != nil {
 		return nil, err
 	}
 
@@ -311,6 +479,7 @@ func (c *Client) GetCollection(ctx context.Context, slug string) (*Collection, e
 		return nil, ErrNotFound
 	}
 
+	resp.Collection.MissingFields = missing
 	return resp.Collection, nil
 }
 
@@ -343,7 +512,8 @@ func (c *Client) GetCollectionRevisionMods(ctx context.Context, slug string, rev
 	}
 
 	var resp CollectionRevisionModsResponse
-	if err := c.Query(ctx, CollectionRevisionModsQuery, variables, &resp); err != nil {
+	missing, err := c.queryReduced(ctx, CollectionRevisionModsQuery, variables, &resp)
+	if err != nil {
 		return nil, err
 	}
 
@@ -351,9 +521,31 @@ func (c *Client) GetCollectionRevisionMods(ctx context.Context, slug string, rev
 		return nil, ErrNotFound
 	}
 
+	resp.CollectionRevision.MissingFields = missing
 	return resp.CollectionRevision, nil
 }
 
+// GetModBugReports fetches the latest bug reports filed against a mod,
+// newest first. It returns at most count reports.
+func (c *Client) GetModBugReports(ctx context.Context, gameDomain string, modID, count int) ([]BugReport, error) {
+	variables := map[string]interface{}{
+		"gameDomain": gameDomain,
+		"modId":      modID,
+		"count":      count,
+	}
+
+	var resp ModBugReportsResponse
+	if err := c.Query(ctx, ModBugReportsQuery, variables, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ModBugReports == nil {
+		return nil, ErrNotFound
+	}
+
+	return resp.ModBugReports.Nodes, nil
+}
+
 // ValidateAPIKey checks if the API key is valid by making a test query.
 func (c *Client) ValidateAPIKey(ctx context.Context) (bool, error) {
 	// Use a simple query to validate the API key
@@ -381,55 +573,87 @@ func (c *Client) GetModFileDownloadLinks(ctx context.Context, gameDomain string,
 	url := fmt.Sprintf("%s/games/%s/mods/%d/files/%d/download_link.json",
 		RESTAPIBase, gameDomain, modID, fileID)
 
+	var links []DownloadLink
+	if err := c.restGet(ctx, url, &links); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// SearchMD5 looks up which mod files have the given MD5 hash.
+// It returns an empty slice when the hash is unknown to Nexus.
+func (c *Client) SearchMD5(ctx context.Context, gameDomain, md5Hash string) ([]MD5SearchResult, error) {
+	url := fmt.Sprintf("%s/games/%s/mods/md5_search/%s.json",
+		RESTAPIBase, gameDomain, md5Hash)
+
+	var results []MD5SearchResult
+	if err := c.restGet(ctx, url, &results); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return []MD5SearchResult{}, nil
+		}
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// restGet performs a REST API GET request with rate limiting and retries.
+func (c *Client) restGet(ctx context.Context, url string, result interface{}) error {
 	var lastErr error
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
-			backoff := c.calculateBackoff(attempt)
+			backoff, ok := c.retryDelay(attempt, lastErr)
+			if !ok {
+				return lastErr
+			}
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
+				return ctx.Err()
 			case <-time.After(backoff):
 			}
 		}
 
+		// Fail fast while Nexus is degraded
+		if err := c.breaker.Allow(); err != nil {
+			return err
+		}
+
 		// Enforce rate limiting
 		if err := c.waitForRateLimit(ctx); err != nil {
-			return nil, err
+			return err
 		}
 
-		links, err := c.doRESTRequest(ctx, url)
+		err := c.doRESTRequest(ctx, url, result)
+		c.breaker.Record(err)
 		if err != nil {
 			lastErr = err
 			if isRetryable(err) {
 				continue
 			}
-			return nil, err
+			return err
 		}
 
-		return links, nil
+		return nil
 	}
 
-	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
+	return fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
-// doRESTRequest performs an HTTP GET request to the REST API.
-func (c *Client) doRESTRequest(ctx context.Context, url string) ([]DownloadLink, error) {
+// doRESTRequest performs an HTTP GET request to the REST API and decodes the JSON response.
+func (c *Client) doRESTRequest(ctx context.Context, url string, result interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("apikey", c.apiKey)
 	req.Header.Set("User-Agent", "ModTroubleshooter/1.0")
 	req.Header.Set("Accept", "application/json")
 
-	c.mu.Lock()
-	c.lastRequest = time.Now()
-	c.mu.Unlock()
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -440,25 +664,24 @@ func (c *Client) doRESTRequest(ctx context.Context, url string) ([]DownloadLink,
 	switch resp.StatusCode {
 	case http.StatusOK:
 		// Parse successful response
-		var links []DownloadLink
-		if err := json.NewDecoder(resp.Body).Decode(&links); err != nil {
-			return nil, fmt.Errorf("decode response: %w", err)
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("decode response: %w", err)
 		}
-		return links, nil
+		return nil
 	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
+		return ErrUnauthorized
 	case http.StatusForbidden:
 		// Nexus returns 403 for non-premium users trying to access download links
-		return nil, ErrPremiumOnly
+		return ErrPremiumOnly
 	case http.StatusTooManyRequests:
-		return nil, ErrRateLimited
+		return &RateLimitError{RetryAfter: retryAfter(resp.Header, time.Now())}
 	case http.StatusNotFound:
-		return nil, ErrNotFound
+		return ErrNotFound
 	default:
 		if resp.StatusCode >= 500 {
-			return nil, fmt.Errorf("%w: status %d", ErrServerError, resp.StatusCode)
+			return fmt.Errorf("%w: status %d", ErrServerError, resp.StatusCode)
 		}
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
 	}
 }
@@ -3,9 +3,12 @@ package nexus
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -248,6 +251,47 @@ func TestClient_GetCollection(t *testing.T) {
 	}
 }
 
+func TestClient_GetModBugReports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Variables["gameDomain"] != "skyrimspecialedition" || req.Variables["modId"] != float64(42) {
+			t.Errorf("unexpected variables %v", req.Variables)
+		}
+
+		resp := GraphQLResponse{
+			Data: map[string]interface{}{
+				"modBugReports": map[string]interface{}{
+					"nodes": []map[string]interface{}{
+						{"id": 7, "title": "CTD on load", "status": "new", "createdAt": "2026-01-02T00:00:00Z"},
+					},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{Transport: &testTransport{server: server}}
+
+	reports, err := client.GetModBugReports(context.Background(), "skyrimspecialedition", 42, 10)
+	if err != nil {
+		t.Fatalf("GetModBugReports failed: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Title != "CTD on load" || reports[0].Status != "new" {
+		t.Errorf("unexpected reports %+v", reports)
+	}
+}
+
 func TestClient_GetCollection_NotFound(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := GraphQLResponse{
@@ -278,6 +322,48 @@ func TestClient_GetCollection_NotFound(t *testing.T) {
 	}
 }
 
+func TestClient_SearchMD5(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/games/skyrimspecialedition/mods/md5_search/0123456789abcdef0123456789abcdef.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"mod":{"mod_id":266,"name":"Unofficial Patch","domain_name":"skyrimspecialedition","available":true},"file_details":{"file_id":1000,"name":"USSEP","file_name":"ussep.7z","md5":"0123456789abcdef0123456789abcdef"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{
+		Transport: &testTransport{server: server},
+	}
+
+	ctx := context.Background()
+	results, err := client.SearchMD5(ctx, "skyrimspecialedition", "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("SearchMD5 failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].Mod.ModID != 266 || results[0].FileDetails.FileID != 1000 {
+		t.Errorf("got mod %d file %d, want mod 266 file 1000", results[0].Mod.ModID, results[0].FileDetails.FileID)
+	}
+
+	// Unknown hashes are not an error
+	results, err = client.SearchMD5(ctx, "skyrimspecialedition", "ffffffffffffffffffffffffffffffff")
+	if err != nil {
+		t.Fatalf("SearchMD5 for unknown hash failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %d results for unknown hash, want 0", len(results))
+	}
+}
+
 func TestClient_RateLimitHeaders(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-RL-Hourly-Limit", "100")
@@ -390,6 +476,164 @@ func TestCalculateBackoff(t *testing.T) {
 	}
 }
 
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		got := jitter(4 * time.Second)
+		if got < 2*time.Second || got > 4*time.Second {
+			t.Fatalf("jitter(4s) = %v, want between 2s and 4s", got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Duration
+	}{
+		{"no headers", nil, 0},
+		{"seconds", map[string]string{"Retry-After": "7"}, 7 * time.Second},
+		{"http date", map[string]string{"Retry-After": "Mon, 01 Jan 2024 12:00:30 GMT"}, 30 * time.Second},
+		{"date in the past", map[string]string{"Retry-After": "Mon, 01 Jan 2024 11:00:00 GMT"}, 0},
+		{"hourly reset", map[string]string{
+			"X-RL-Hourly-Remaining": "0",
+			"X-RL-Hourly-Reset":     "2024-01-01T12:10:00+00:00",
+		}, 10 * time.Minute},
+		{"reset of a limit that isn't exhausted", map[string]string{
+			"X-RL-Hourly-Remaining": "5",
+			"X-RL-Hourly-Reset":     "2024-01-01T12:10:00+00:00",
+		}, 0},
+		{"daily reset", map[string]string{
+			"X-RL-Hourly-Remaining": "5",
+			"X-RL-Daily-Remaining":  "0",
+			"X-RL-Daily-Reset":      "2024-01-02T00:00:00+00:00",
+		}, 12 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			if got := retryAfter(h, now); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	client, err := NewClient(ClientConfig{APIKey: "test", MaxBackoff: time.Second})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	wait, ok := client.retryDelay(1, &RateLimitError{RetryAfter: 200 * time.Millisecond})
+	if !ok || wait != 200*time.Millisecond {
+		t.Errorf("expected server wait to be honored, got %v %v", wait, ok)
+	}
+
+	if _, ok := client.retryDelay(1, &RateLimitError{RetryAfter: time.Hour}); ok {
+		t.Error("expected no retry when the server asks for a longer wait than the max backoff")
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	client.httpClient = &http.Client{Transport: &testTransport{server: server}}
+
+	err = client.Query(context.Background(), CollectionQuery, nil, nil)
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.RetryAfter != time.Hour {
+		t.Errorf("expected RateLimitError with a 1h wait, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request, got %d", requests)
+	}
+}
+
+func TestClient_Query_SharesConcurrentRequests(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GraphQLResponse{Data: map[string]interface{}{
+			"collection": map[string]interface{}{"name": "Shared"},
+		}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{Transport: &testTransport{server: server}}
+	client.minRequestDelay = 0
+
+	var wg sync.WaitGroup
+	results := make([]CollectionResponse, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := client.Query(context.Background(), CollectionQuery, map[string]interface{}{"slug": "x"}, &results[i]); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request for identical concurrent queries, got %d", requests.Load())
+	}
+	for i, res := range results {
+		if res.Collection == nil || res.Collection.Name != "Shared" {
+			t.Errorf("caller %d: expected shared result, got %+v", i, res.Collection)
+		}
+	}
+}
+
+func TestClient_WaitForRateLimit_SpacesConcurrentRequests(t *testing.T) {
+	client, err := NewClient(ClientConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.minRequestDelay = 20 * time.Millisecond
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.waitForRateLimit(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The first request goes at once and each other waits its turn
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected concurrent requests to be spaced out, all went within %v", elapsed)
+	}
+}
+
 func TestIsRetryable(t *testing.T) {
 	tests := []struct {
 		err  error
@@ -421,3 +665,23 @@ func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.URL.Host = strings.TrimPrefix(t.server.URL, "http://")
 	return http.DefaultTransport.RoundTrip(req)
 }
+
+func TestMod_IsReupload(t *testing.T) {
+	tests := []struct {
+		name string
+		mod  Mod
+		want bool
+	}{
+		{"author uploaded", Mod{Author: "arthmoor", Uploader: &User{Name: "Arthmoor"}}, false},
+		{"reupload", Mod{Author: "Original Author", Uploader: &User{Name: "someone"}}, true},
+		{"unknown uploader", Mod{Author: "Original Author"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mod.IsReupload(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package nexus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxDriftRetries limits how many reduced variants of one query are tried.
+const maxDriftRetries = 3
+
+// optionalFields are the fields the queries can do without. When Nexus
+// stops providing one, it is left out and its value stays empty. Fields the
+// analysis depends on, such as IDs and mod status, are never left out.
+var optionalFields = map[string]bool{
+	"summary":           true,
+	"description":       true,
+	"endorsements":      true,
+	"totalDownloads":    true,
+	"avatar":            true,
+	"tileImage":         true,
+	"pictureUrl":        true,
+	"modCategory":       true,
+	"tags":              true,
+	"uploader":          true,
+	"adultContent":      true,
+	"instructions":      true,
+	"optional":          true,
+	"externalResources": true,
+	"gameVersions":      true,
+	"createdAt":         true,
+	"revisionStatus":    true,
+	"totalSize":         true,
+	"permissions":       true,
+}
+
+var (
+	// rubyUndefinedField matches graphql-ruby's message for an unknown field.
+	rubyUndefinedField = regexp.MustCompile(`Field '(\w+)' doesn't exist on type '(\w+)'`)
+	// jsUndefinedField matches graphql-js's message for an unknown field.
+	jsUndefinedField = regexp.MustCompile(`Cannot query field "(\w+)" on type "(\w+)"`)
+)
+
+// MissingField is a field a query asked for that the Nexus schema no
+// longer has.
+type MissingField struct {
+	// Name is the field name.
+	Name string
+	// Type is the type the field was queried on, if reported.
+	Type string
+	// Path is where the field is in the query, if reported, starting with
+	// the operation.
+	Path []string
+}
+
+// SchemaDriftError is a GraphQL response rejecting a query because fields
+// it asks for no longer exist, typically after a Nexus schema change.
+type SchemaDriftError struct {
+	Fields  []MissingField
+	Message string
+}
+
+func (e *SchemaDriftError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrGraphQLErrors, e.Message)
+}
+
+func (e *SchemaDriftError) Unwrap() error {
+	return ErrGraphQLErrors
+}
+
+// schemaDrift returns the error for GraphQL errors that all report unknown
+// fields, or nil if any reports something else.
+func schemaDrift(errs []GraphQLError) *SchemaDriftError {
+	if len(errs) == 0 {
+		return nil
+	}
+	drift := &SchemaDriftError{Message: errs[0].Message}
+	for _, e := range errs {
+		field, ok := missingField(e)
+		if !ok {
+			return nil
+		}
+		drift.Fields = append(drift.Fields, field)
+	}
+	return drift
+}
+
+// missingField extracts the unknown field from a GraphQL error.
+func missingField(e GraphQLError) (MissingField, bool) {
+	var field MissingField
+	if code, _ := e.Extensions["code"].(string); code == "undefinedField" {
+		field.Name, _ = e.Extensions["fieldName"].(string)
+		field.Type, _ = e.Extensions["typeName"].(string)
+	}
+	if field.Name == "" {
+		m := rubyUndefinedField.FindStringSubmatch(e.Message)
+		if m == nil {
+			m = jsUndefinedField.FindStringSubmatch(e.Message)
+		}
+		if m == nil {
+			return MissingField{}, false
+		}
+		field.Name, field.Type = m[1], m[2]
+	}
+	for _, p := range e.Path {
+		if s, ok := p.(string); ok {
+			field.Path = append(field.Path, s)
+		}
+	}
+	return field, true
+}
+
+// dropFields removes missing fields from a query. It fails if any of them
+// is not optional or cannot be found.
+func dropFields(query string, fields []MissingField) (string, []string, bool) {
+	var dropped []string
+	for _, f := range fields {
+		if !optionalFields[f.Name] {
+			return "", nil, false
+		}
+		reduced, ok := removeField(query, f)
+		if !ok {
+			return "", nil, false
+		}
+		query = reduced
+		dropped = append(dropped, f.Name)
+	}
+	return query, dropped, true
+}
+
+// removeField removes a field, with its selection set, from a query laid
+// out one field per line, as the queries in this package are. With a path
+// only the field at that path is removed, otherwise every field of that
+// name is.
+func removeField(query string, f MissingField) (string, bool) {
+	lines := strings.Split(query, "\n")
+	out := make([]string, 0, len(lines))
+	var stack []string
+	skipDepth := 0
+	removed := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		opens := strings.HasSuffix(trimmed, "{")
+		closes := trimmed == "}"
+
+		if skipDepth > 0 {
+			if opens {
+				skipDepth++
+			} else if closes {
+				skipDepth--
+			}
+			continue
+		}
+
+		name := fieldName(trimmed)
+		if name == f.Name && len(stack) > 0 && pathMatches(stack, f.Path) {
+			removed = true
+			if opens {
+				skipDepth = 1
+			}
+			continue
+		}
+
+		out = append(out, line)
+		switch {
+		case opens:
+			stack = append(stack, name)
+		case closes && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return strings.Join(out, "\n"), removed
+}
+
+// fieldName returns the name of the field on a query line.
+func fieldName(line string) string {
+	if i := strings.IndexAny(line, " ({"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// pathMatches reports whether the open fields lead to a reported path. The
+// first element of both is the operation, which is not compared, and the
+// last element of the path is the field itself.
+func pathMatches(stack, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	if len(path)-1 != len(stack) {
+		return false
+	}
+	for i := 1; i < len(stack); i++ {
+		if stack[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRemoveField(t *testing.T) {
+	// name appears on the file, the mod and its category; only the mod's goes
+	path := []string{"query CollectionRevisionMods", "collectionRevision", "modFiles", "file", "mod", "name"}
+	query, ok := removeField(CollectionRevisionModsQuery, MissingField{Name: "name", Path: path})
+	if !ok {
+		t.Fatal("expected the field to be removed")
+	}
+	if got, want := strings.Count(query, "name\n"), strings.Count(CollectionRevisionModsQuery, "name\n")-1; got != want {
+		t.Errorf("expected %d name fields left, got %d", want, got)
+	}
+
+	// Fields with a selection set go with it
+	query, ok = removeField(CollectionRevisionModsQuery, MissingField{Name: "uploader"})
+	if !ok || strings.Contains(query, "uploader") || strings.Contains(query, "memberId") {
+		t.Errorf("expected uploader and its fields to be removed:\n%s", query)
+	}
+	if strings.Count(query, "{") != strings.Count(query, "}") {
+		t.Errorf("unbalanced query:\n%s", query)
+	}
+
+	if _, ok := removeField(CollectionRevisionModsQuery, MissingField{Name: "nosuchfield"}); ok {
+		t.Error("expected an unknown field not to be removed")
+	}
+}
+
+func TestSchemaDrift(t *testing.T) {
+	errs := []GraphQLError{
+		{Message: "Field 'instructions' doesn't exist on type 'CollectionRevisionMod'"},
+		{Message: `Cannot query field "tags" on type "Mod".`},
+		{Message: "x", Extensions: map[string]interface{}{"code": "undefinedField", "fieldName": "uploader", "typeName": "Mod"}},
+	}
+	drift := schemaDrift(errs)
+	if drift == nil || len(drift.Fields) != 3 {
+		t.Fatalf("expected 3 missing fields, got %+v", drift)
+	}
+	if drift.Fields[0].Name != "instructions" || drift.Fields[1].Type != "Mod" || drift.Fields[2].Name != "uploader" {
+		t.Errorf("unexpected fields %+v", drift.Fields)
+	}
+	if !errors.Is(drift, ErrGraphQLErrors) {
+		t.Error("expected drift to be a GraphQL error")
+	}
+
+	if schemaDrift(append(errs, GraphQLError{Message: "Internal server error"})) != nil {
+		t.Error("expected no drift when another error is reported")
+	}
+}
+
+func TestClient_SchemaDriftRetry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req GraphQLRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var resp GraphQLResponse
+		if strings.Contains(req.Query, "instructions") {
+			resp.Errors = []GraphQLError{{
+				Message: "Field 'instructions' doesn't exist on type 'CollectionRevisionMod'",
+				Path:    []interface{}{"query CollectionRevisionMods", "collectionRevision", "modFiles", "instructions"},
+			}}
+		} else {
+			resp.Data = map[string]interface{}{
+				"collectionRevision": map[string]interface{}{
+					"revisionNumber": 3,
+					"modFiles":       []interface{}{map[string]interface{}{"fileId": 7}},
+				},
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{Transport: &testTransport{server: server}}
+
+	details, err := client.GetCollectionRevisionMods(context.Background(), "abc", 3)
+	if err != nil {
+		t.Fatalf("GetCollectionRevisionMods() error = %v", err)
+	}
+	if len(details.ModFiles) != 1 || len(details.MissingFields) != 1 || details.MissingFields[0] != "instructions" {
+		t.Errorf("unexpected details %+v", details)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+
+	// The reduced query is remembered
+	if _, err := client.GetCollectionRevisionMods(context.Background(), "abc", 4); err != nil {
+		t.Fatalf("GetCollectionRevisionMods() error = %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected the reduced query to be used directly, got %d requests", got)
+	}
+}
+
+func TestClient_SchemaDriftRequiredField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GraphQLResponse{Errors: []GraphQLError{{Message: "Field 'modId' doesn't exist on type 'Mod'"}}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{Transport: &testTransport{server: server}}
+
+	var drift *SchemaDriftError
+	if _, err := client.GetCollectionRevisionMods(context.Background(), "abc", 3); !errors.As(err, &drift) {
+		t.Errorf("expected a schema drift error, got %v", err)
+	}
+}
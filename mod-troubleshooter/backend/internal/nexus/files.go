@@ -0,0 +1,103 @@
+package nexus
+
+import (
+	"context"
+	"fmt"
+)
+
+// File category IDs used by the REST API.
+const (
+	FileCategoryMain     = 1
+	FileCategoryUpdate   = 2
+	FileCategoryOptional = 3
+	FileCategoryOld      = 4
+	FileCategoryMisc     = 5
+	FileCategoryDeleted  = 6
+	FileCategoryArchived = 7
+)
+
+// FileDetails is a mod file as returned by the REST API.
+type FileDetails struct {
+	FileID       int    `json:"file_id"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	// IsPrimary marks the file the mod page offers as its main download.
+	IsPrimary bool   `json:"is_primary"`
+	FileName  string `json:"file_name"`
+	SizeKB    int64  `json:"size_kb"`
+	// UploadedTimestamp is the upload time in Unix seconds.
+	UploadedTimestamp int64 `json:"uploaded_timestamp"`
+	// ContentPreviewLink is the URL of the file tree JSON, if Nexus has one.
+	ContentPreviewLink string `json:"content_preview_link"`
+}
+
+// ModFilesResponse is the file list of a mod from the REST API.
+type ModFilesResponse struct {
+	Files []FileDetails `json:"files"`
+}
+
+// GetModFile fetches the details of a single mod file.
+func (c *Client) GetModFile(ctx context.Context, gameDomain string, modID, fileID int) (*FileDetails, error) {
+	url := fmt.Sprintf("%s/games/%s/mods/%d/files/%d.json",
+		RESTAPIBase, gameDomain, modID, fileID)
+
+	var file FileDetails
+	if err := c.restGet(ctx, url, &file); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// GetModFiles fetches every file of a mod, including old and archived ones.
+func (c *Client) GetModFiles(ctx context.Context, gameDomain string, modID int) ([]FileDetails, error) {
+	url := fmt.Sprintf("%s/games/%s/mods/%d/files.json",
+		RESTAPIBase, gameDomain, modID)
+
+	var resp ModFilesResponse
+	if err := c.restGet(ctx, url, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Files, nil
+}
+
+// MainFile picks the file to use for a mod when no file is specified: the
+// primary main file, else the newest main file, else the newest file that
+// is not an old version, archived or deleted. It returns false when the
+// mod has no current files.
+func MainFile(files []FileDetails) (FileDetails, bool) {
+	var main, latest *FileDetails
+	for i := range files {
+		f := &files[i]
+		switch f.CategoryID {
+		case FileCategoryOld, FileCategoryArchived, FileCategoryDeleted:
+			continue
+		case FileCategoryMain:
+			if main == nil || preferMain(f, main) {
+				main = f
+			}
+		}
+		if latest == nil || f.UploadedTimestamp > latest.UploadedTimestamp {
+			latest = f
+		}
+	}
+
+	switch {
+	case main != nil:
+		return *main, true
+	case latest != nil:
+		return *latest, true
+	}
+	return FileDetails{}, false
+}
+
+// preferMain reports whether main file a is a better pick than b.
+func preferMain(a, b *FileDetails) bool {
+	if a.IsPrimary != b.IsPrimary {
+		return a.IsPrimary
+	}
+	return a.UploadedTimestamp > b.UploadedTimestamp
+}
@@ -0,0 +1,76 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMainFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []FileDetails
+		want   int
+		wantOK bool
+	}{
+		{"no files", nil, 0, false},
+		{"only old versions", []FileDetails{
+			{FileID: 1, CategoryID: FileCategoryOld, UploadedTimestamp: 100},
+			{FileID: 2, CategoryID: FileCategoryArchived, UploadedTimestamp: 200},
+		}, 0, false},
+		{"newest main file", []FileDetails{
+			{FileID: 1, CategoryID: FileCategoryMain, UploadedTimestamp: 100},
+			{FileID: 2, CategoryID: FileCategoryMain, UploadedTimestamp: 300},
+			{FileID: 3, CategoryID: FileCategoryUpdate, UploadedTimestamp: 400},
+		}, 2, true},
+		{"primary main file wins", []FileDetails{
+			{FileID: 1, CategoryID: FileCategoryMain, IsPrimary: true, UploadedTimestamp: 100},
+			{FileID: 2, CategoryID: FileCategoryMain, UploadedTimestamp: 300},
+		}, 1, true},
+		{"falls back to latest current file", []FileDetails{
+			{FileID: 1, CategoryID: FileCategoryOptional, UploadedTimestamp: 100},
+			{FileID: 2, CategoryID: FileCategoryMisc, UploadedTimestamp: 200},
+			{FileID: 3, CategoryID: FileCategoryOld, UploadedTimestamp: 300},
+		}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MainFile(tt.files)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok=%v, got %v", tt.wantOK, ok)
+			}
+			if got.FileID != tt.want {
+				t.Errorf("expected file %d, got %d", tt.want, got.FileID)
+			}
+		})
+	}
+}
+
+func TestClient_GetModFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/games/skyrimspecialedition/mods/266/files.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"files":[{"file_id":1000,"name":"USSEP","category_id":1,"category_name":"MAIN","is_primary":true,"file_name":"ussep.7z","uploaded_timestamp":1700000000}],"file_updates":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{
+		Transport: &testTransport{server: server},
+	}
+
+	files, err := client.GetModFiles(context.Background(), "skyrimspecialedition", 266)
+	if err != nil {
+		t.Fatalf("GetModFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].FileID != 1000 || !files[0].IsPrimary || files[0].CategoryID != FileCategoryMain {
+		t.Errorf("got %+v, want the primary main file 1000", files)
+	}
+}
@@ -0,0 +1,115 @@
+package nexus
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ModPermission is one of the permissions an author sets on a mod page, such
+// as whether others may upload the mod elsewhere or use its assets.
+type ModPermission struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// PermissionKind is a permission curators need to respect.
+type PermissionKind string
+
+const (
+	// PermissionUpload covers uploading the mod's files elsewhere.
+	PermissionUpload PermissionKind = "upload"
+	// PermissionModification covers modifying the mod and releasing the result.
+	PermissionModification PermissionKind = "modification"
+	// PermissionConversion covers converting the mod for other games.
+	PermissionConversion PermissionKind = "conversion"
+	// PermissionAssetUse covers using the mod's assets in other mods.
+	PermissionAssetUse PermissionKind = "assetUse"
+)
+
+// PermissionKinds lists the permission kinds in the order they are reported.
+var PermissionKinds = []PermissionKind{PermissionUpload, PermissionModification, PermissionConversion, PermissionAssetUse}
+
+// PermissionLevel is what a permission allows.
+type PermissionLevel string
+
+const (
+	// PermissionAllowed means no permission is needed.
+	PermissionAllowed PermissionLevel = "allowed"
+	// PermissionAsk means the author has to be asked first.
+	PermissionAsk PermissionLevel = "ask"
+	// PermissionDenied means the author does not allow it.
+	PermissionDenied PermissionLevel = "denied"
+	// PermissionUnknown means the author did not say, or Nexus did not report it.
+	PermissionUnknown PermissionLevel = "unknown"
+)
+
+// Kind returns the kind of permission, or "" for permissions curators need
+// not check, such as credits.
+func (p ModPermission) Kind() PermissionKind {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, p.Key)
+	switch {
+	case strings.HasPrefix(key, "upload"):
+		return PermissionUpload
+	case strings.HasPrefix(key, "modif"):
+		return PermissionModification
+	case strings.HasPrefix(key, "conver"):
+		return PermissionConversion
+	case strings.HasPrefix(key, "asset"):
+		return PermissionAssetUse
+	default:
+		return ""
+	}
+}
+
+// Level classifies the permission's value, which Nexus reports either as
+// a yes/no flag or as the sentence shown on the mod page.
+func (p ModPermission) Level() PermissionLevel {
+	value := strings.ToLower(strings.TrimSpace(p.Value))
+	switch value {
+	case "yes", "true", "open", "allowed":
+		return PermissionAllowed
+	case "no", "false", "closed", "denied", "disallowed":
+		return PermissionDenied
+	case "ask", "permission", "restricted":
+		return PermissionAsk
+	}
+	switch {
+	case strings.Contains(value, "not allowed"), strings.Contains(value, "not permitted"), strings.Contains(value, "cannot"):
+		return PermissionDenied
+	case strings.Contains(value, "permission"), strings.Contains(value, "ask"), strings.Contains(value, "contact"):
+		return PermissionAsk
+	case strings.Contains(value, "allowed"), strings.Contains(value, "permitted"), strings.Contains(value, "free to"):
+		return PermissionAllowed
+	default:
+		return PermissionUnknown
+	}
+}
+
+// Permission returns what the mod's author allows for one kind of
+// permission. When several permissions of a kind are set, the most
+// restrictive one applies.
+func (m *Mod) Permission(kind PermissionKind) PermissionLevel {
+	level := PermissionUnknown
+	for _, p := range m.Permissions {
+		if p.Kind() != kind {
+			continue
+		}
+		if l := p.Level(); permissionRank[l] > permissionRank[level] {
+			level = l
+		}
+	}
+	return level
+}
+
+// permissionRank orders levels from least to most restrictive.
+var permissionRank = map[PermissionLevel]int{
+	PermissionUnknown: 0,
+	PermissionAllowed: 1,
+	PermissionAsk:     2,
+	PermissionDenied:  3,
+}
@@ -0,0 +1,43 @@
+package nexus
+
+import "testing"
+
+func TestModPermission_KindLevel(t *testing.T) {
+	tests := []struct {
+		perm      ModPermission
+		wantKind  PermissionKind
+		wantLevel PermissionLevel
+	}{
+		{ModPermission{Key: "upload_permission", Value: "You are not allowed to upload this file to other sites under any circumstances"}, PermissionUpload, PermissionDenied},
+		{ModPermission{Key: "ModificationPermission", Value: "You must get permission from me before you are allowed to modify my files to improve it"}, PermissionModification, PermissionAsk},
+		{ModPermission{Key: "conversion", Value: "You are allowed to convert this file to work on other games as long as credit is given"}, PermissionConversion, PermissionAllowed},
+		{ModPermission{Key: "Asset use permission", Value: "No"}, PermissionAssetUse, PermissionDenied},
+		{ModPermission{Key: "assetUse", Value: ""}, PermissionAssetUse, PermissionUnknown},
+		{ModPermission{Key: "credits", Value: "Thanks to everyone"}, "", PermissionUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.perm.Key, func(t *testing.T) {
+			if got := tt.perm.Kind(); got != tt.wantKind {
+				t.Errorf("Kind() = %q, want %q", got, tt.wantKind)
+			}
+			if got := tt.perm.Level(); got != tt.wantLevel {
+				t.Errorf("Level() = %q, want %q", got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestMod_Permission(t *testing.T) {
+	mod := &Mod{Permissions: []ModPermission{
+		{Key: "upload", Value: "yes"},
+		{Key: "upload_to_other_sites", Value: "ask"},
+	}}
+
+	if got := mod.Permission(PermissionUpload); got != PermissionAsk {
+		t.Errorf("expected the most restrictive upload permission, got %q", got)
+	}
+	if got := mod.Permission(PermissionConversion); got != PermissionUnknown {
+		t.Errorf("expected an unreported permission to be unknown, got %q", got)
+	}
+}
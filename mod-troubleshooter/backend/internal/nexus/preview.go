@@ -0,0 +1,136 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNoContentPreview is returned when a mod file has no content preview.
+var ErrNoContentPreview = errors.New("no content preview available")
+
+// maxPreviewSize bounds the size of a content preview document.
+const maxPreviewSize = 32 << 20
+
+// PreviewNode is a file or directory in a content preview.
+type PreviewNode struct {
+	Name string `json:"name"`
+	// Path is the path within the archive.
+	Path string `json:"path"`
+	// Type is "file" or "directory".
+	Type string `json:"type"`
+	// Size is a rounded, human-readable size such as "1.5 MB".
+	Size     string        `json:"size"`
+	Children []PreviewNode `json:"children"`
+}
+
+// ContentPreview is the file tree Nexus generates for an uploaded archive.
+type ContentPreview struct {
+	Children []PreviewNode `json:"children"`
+}
+
+// PreviewFile is a file listed in a content preview.
+type PreviewFile struct {
+	Path string
+	// Size is approximate, as previews round sizes.
+	Size int64
+}
+
+// Files returns every file in the preview, depth first.
+func (p *ContentPreview) Files() []PreviewFile {
+	var files []PreviewFile
+	var walk func(nodes []PreviewNode)
+	walk = func(nodes []PreviewNode) {
+		for _, node := range nodes {
+			if node.Type == "directory" {
+				walk(node.Children)
+				continue
+			}
+			path := node.Path
+			if path == "" {
+				path = node.Name
+			}
+			files = append(files, PreviewFile{Path: path, Size: parsePreviewSize(node.Size)})
+		}
+	}
+	walk(p.Children)
+	return files
+}
+
+// GetContentPreview downloads and parses the content preview of a mod file
+// from its ContentPreviewLink. Previews are served from a Nexus CDN, so this
+// does not count against the API rate limit and the API key is not sent.
+func (c *Client) GetContentPreview(ctx context.Context, link string) (*ContentPreview, error) {
+	if link == "" {
+		return nil, ErrNoContentPreview
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "https" || !isNexusHost(u.Hostname()) {
+		return nil, fmt.Errorf("%w: unexpected preview link %q", ErrNoContentPreview, link)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ModTroubleshooter/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		// Previews are generated after upload and are missing for some files
+		return nil, ErrNoContentPreview
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetch content preview: status %d", resp.StatusCode)
+	}
+
+	var preview ContentPreview
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPreviewSize)).Decode(&preview); err != nil {
+		return nil, fmt.Errorf("decode content preview: %w", err)
+	}
+	return &preview, nil
+}
+
+// isNexusHost reports whether host belongs to Nexus Mods.
+func isNexusHost(host string) bool {
+	return host == "nexusmods.com" || strings.HasSuffix(host, ".nexusmods.com")
+}
+
+// previewUnits are the size suffixes used in content previews.
+var previewUnits = map[string]float64{
+	"b":  1,
+	"kb": 1 << 10,
+	"mb": 1 << 20,
+	"gb": 1 << 30,
+	"tb": 1 << 40,
+}
+
+// parsePreviewSize parses a preview size such as "15.49 kB" into bytes.
+// Unparseable sizes are 0.
+func parsePreviewSize(s string) int64 {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	unit, ok := previewUnits[strings.ToLower(fields[1])]
+	if !ok {
+		return 0
+	}
+	return int64(value * unit)
+}
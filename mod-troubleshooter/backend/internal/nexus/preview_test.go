@@ -0,0 +1,94 @@
+package nexus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePreviewSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512 B", 512},
+		{"15.5 kB", 15872},
+		{"2 MB", 2 << 20},
+		{"1.5 GB", 3 << 29},
+		{"", 0},
+		{"big", 0},
+		{"3 parsecs", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parsePreviewSize(tt.in); got != tt.want {
+			t.Errorf("parsePreviewSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClient_GetContentPreview(t *testing.T) {
+	var apiKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKeys = append(apiKeys, r.Header.Get("apikey"))
+		switch r.URL.Path {
+		case "/v1/games/skyrimspecialedition/mods/266/files/1000.json":
+			w.Write([]byte(`{"file_id":1000,"name":"USSEP","file_name":"ussep.7z","content_preview_link":"https://file-metadata.nexusmods.com/file/nexus-files-s3-meta/1704/266/ussep.7z.json"}`))
+		case "/file/nexus-files-s3-meta/1704/266/ussep.7z.json":
+			w.Write([]byte(`{"children":[
+				{"path":"Unofficial Skyrim Special Edition Patch.esp","name":"Unofficial Skyrim Special Edition Patch.esp","type":"file","size":"4.2 MB"},
+				{"path":"Scripts","name":"Scripts","type":"directory","children":[
+					{"path":"Scripts/ussep_fix.pex","name":"ussep_fix.pex","type":"file","size":"2 kB"}
+				]}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	client.httpClient = &http.Client{
+		Transport: &testTransport{server: server},
+	}
+
+	ctx := context.Background()
+	file, err := client.GetModFile(ctx, "skyrimspecialedition", 266, 1000)
+	if err != nil {
+		t.Fatalf("GetModFile failed: %v", err)
+	}
+
+	preview, err := client.GetContentPreview(ctx, file.ContentPreviewLink)
+	if err != nil {
+		t.Fatalf("GetContentPreview failed: %v", err)
+	}
+	files := preview.Files()
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	if files[1].Path != "Scripts/ussep_fix.pex" || files[1].Size != 2048 {
+		t.Errorf("got %+v, want Scripts/ussep_fix.pex of 2048 bytes", files[1])
+	}
+
+	// The API key is only sent to the API
+	if len(apiKeys) != 2 || apiKeys[0] != "test-api-key" || apiKeys[1] != "" {
+		t.Errorf("got API keys %q, want only the API request to carry one", apiKeys)
+	}
+
+	// Missing previews and links to other hosts are reported as no preview
+	for _, link := range []string{
+		"",
+		"https://file-metadata.nexusmods.com/file/missing.json",
+		"https://example.com/preview.json",
+		"http://file-metadata.nexusmods.com/file/nexus-files-s3-meta/1704/266/ussep.7z.json",
+	} {
+		if _, err := client.GetContentPreview(ctx, link); !errors.Is(err, ErrNoContentPreview) {
+			t.Errorf("GetContentPreview(%q) error = %v, want %v", link, err, ErrNoContentPreview)
+		}
+	}
+}
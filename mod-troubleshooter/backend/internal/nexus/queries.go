@@ -31,6 +31,8 @@ query Collection($slug: String!) {
       modFiles {
         fileId
         optional
+        instructions
+        version
         file {
           fileId
           name
@@ -46,6 +48,15 @@ query Collection($slug: String!) {
             modCategory {
               name
             }
+            tags {
+              name
+            }
+            status
+            adultContent
+            uploader {
+              name
+              memberId
+            }
           }
         }
       }
@@ -78,9 +89,14 @@ const CollectionRevisionModsQuery = `
 query CollectionRevisionMods($revision: Int, $slug: String!) {
   collectionRevision(revision: $revision, slug: $slug) {
     revisionNumber
+    gameVersions {
+      reference
+    }
     modFiles {
       fileId
       optional
+      instructions
+      version
       file {
         fileId
         name
@@ -92,12 +108,42 @@ query CollectionRevisionMods($revision: Int, $slug: String!) {
           author
           summary
           pictureUrl
+          modCategory {
+            name
+          }
+          tags {
+            name
+          }
           game {
             domainName
           }
+          status
+          adultContent
+          uploader {
+            name
+            memberId
+          }
+          permissions {
+            key
+            value
+          }
         }
       }
     }
   }
 }
 `
+
+// ModBugReportsQuery fetches the latest bug reports filed against a mod.
+const ModBugReportsQuery = `
+query ModBugReports($gameDomain: String!, $modId: Int!, $count: Int) {
+  modBugReports(gameDomain: $gameDomain, modId: $modId, count: $count, sort: { createdAt: { direction: DESC } }) {
+    nodes {
+      id
+      title
+      status
+      createdAt
+    }
+  }
+}
+`
@@ -1,6 +1,9 @@
 package nexus
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // API endpoints
 const (
@@ -22,6 +25,9 @@ type Collection struct {
 	TileImage      *Image           `json:"tileImage"`
 	Revisions      []Revision       `json:"revisions,omitempty"`
 	LatestRevision *RevisionDetails `json:"latestPublishedRevision,omitempty"`
+	// MissingFields lists fields Nexus no longer provides, which were left
+	// out of the query. The details are partial when it is set.
+	MissingFields []string `json:"missingFields,omitempty"`
 }
 
 // User represents a Nexus Mods user.
@@ -57,13 +63,40 @@ type RevisionDetails struct {
 	RevisionNumber    int                `json:"revisionNumber"`
 	ModFiles          []ModFileReference `json:"modFiles"`
 	ExternalResources []ExternalResource `json:"externalResources,omitempty"`
+	// GameVersions are the game runtimes the curator built the revision for.
+	GameVersions []GameVersion `json:"gameVersions,omitempty"`
+	// MissingFields lists fields Nexus no longer provides, which were left
+	// out of the query. The details are partial when it is set.
+	MissingFields []string `json:"missingFields,omitempty"`
+}
+
+// GameVersion is a game runtime version, such as "1.6.1170.0".
+type GameVersion struct {
+	Reference string `json:"reference"`
+}
+
+// GameVersion returns the game runtime the revision targets, or "" if the
+// curator did not record one.
+func (r *RevisionDetails) GameVersion() string {
+	for _, v := range r.GameVersions {
+		if v.Reference != "" {
+			return v.Reference
+		}
+	}
+	return ""
 }
 
 // ModFileReference is a reference to a mod file within a collection.
 type ModFileReference struct {
-	FileID   int      `json:"fileId"`
-	Optional bool     `json:"optional"`
-	File     *ModFile `json:"file"`
+	FileID   int  `json:"fileId"`
+	Optional bool `json:"optional"`
+	// Version is the file version recorded when the curator added the file.
+	// It differs from File.Version when the author later changed the file.
+	Version string `json:"version,omitempty"`
+	// Instructions are the curator's notes on installing the file, such as
+	// which installer options to pick.
+	Instructions string   `json:"instructions,omitempty"`
+	File         *ModFile `json:"file"`
 }
 
 // ModFile represents a downloadable mod file.
@@ -85,6 +118,35 @@ type Mod struct {
 	PictureURL  string       `json:"pictureUrl"`
 	ModCategory *ModCategory `json:"modCategory"`
 	Game        *Game        `json:"game"`
+	// Status is the publication status, such as "published", "hidden" or "removed".
+	Status string `json:"status"`
+	// AdultContent is true when the mod is flagged as adult content.
+	AdultContent bool `json:"adultContent"`
+	// Uploader is the account that published the mod, which may differ from the credited author.
+	Uploader *User `json:"uploader,omitempty"`
+	// Tags are the labels attached to the mod on Nexus, such as "Body Replacer".
+	Tags []ModTag `json:"tags,omitempty"`
+	// Permissions are the author's permissions for uploads, modifications,
+	// conversions and asset use, if Nexus reported them.
+	Permissions []ModPermission `json:"permissions,omitempty"`
+}
+
+// ModStatusPublished is the status of a mod that can be downloaded.
+const ModStatusPublished = "published"
+
+// IsAvailable reports whether the mod can be downloaded.
+// An empty status is treated as available, since not every query requests it.
+func (m *Mod) IsAvailable() bool {
+	return m.Status == "" || m.Status == ModStatusPublished
+}
+
+// IsReupload reports whether the mod was published by someone other than its
+// credited author, which usually requires the author's distribution permission.
+func (m *Mod) IsReupload() bool {
+	if m.Uploader == nil || m.Uploader.Name == "" || m.Author == "" {
+		return false
+	}
+	return !strings.EqualFold(strings.TrimSpace(m.Uploader.Name), strings.TrimSpace(m.Author))
 }
 
 // ModCategory represents a mod category.
@@ -92,6 +154,11 @@ type ModCategory struct {
 	Name string `json:"name"`
 }
 
+// ModTag represents a tag attached to a mod.
+type ModTag struct {
+	Name string `json:"name"`
+}
+
 // ExternalResource represents an external resource in a collection.
 type ExternalResource struct {
 	Name         string `json:"name"`
@@ -143,6 +210,22 @@ type CollectionRevisionModsResponse struct {
 	CollectionRevision *RevisionDetails `json:"collectionRevision"`
 }
 
+// BugReport is a bug report filed against a mod by its users.
+type BugReport struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Status is the author's triage state, such as "new" or "fixed".
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ModBugReportsResponse wraps the mod bug reports query response.
+type ModBugReportsResponse struct {
+	ModBugReports *struct {
+		Nodes []BugReport `json:"nodes"`
+	} `json:"modBugReports"`
+}
+
 // RateLimitInfo contains rate limiting information from API responses.
 type RateLimitInfo struct {
 	HourlyLimit     int
@@ -160,3 +243,30 @@ type DownloadLink struct {
 
 // DownloadLinksResponse wraps the download links array from the REST API.
 type DownloadLinksResponse []DownloadLink
+
+// MD5SearchResult is a mod file matching an MD5 hash, returned by the REST API.
+type MD5SearchResult struct {
+	Mod         MD5SearchMod  `json:"mod"`
+	FileDetails MD5SearchFile `json:"file_details"`
+}
+
+// MD5SearchMod is the mod a matching file belongs to.
+type MD5SearchMod struct {
+	ModID      int    `json:"mod_id"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Author     string `json:"author"`
+	DomainName string `json:"domain_name"`
+	Available  bool   `json:"available"`
+}
+
+// MD5SearchFile is the mod file whose MD5 matched.
+type MD5SearchFile struct {
+	FileID       int    `json:"file_id"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	CategoryName string `json:"category_name"`
+	FileName     string `json:"file_name"`
+	MD5          string `json:"md5"`
+	SizeKB       int64  `json:"size_kb"`
+}
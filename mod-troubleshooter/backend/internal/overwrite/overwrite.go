@@ -0,0 +1,257 @@
+// Package overwrite classifies the contents of a Mod Organizer 2 overwrite
+// folder and recommends where each file should be moved.
+package overwrite
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Category identifies what produced a file in the overwrite folder.
+type Category string
+
+const (
+	// CategoryFNIS is behavior output generated by FNIS.
+	CategoryFNIS Category = "fnis_output"
+	// CategoryNemesis is behavior output generated by Nemesis.
+	CategoryNemesis Category = "nemesis_output"
+	// CategorySKSEConfig is configuration written by SKSE plugins.
+	CategorySKSEConfig Category = "skse_config"
+	// CategoryMCMSettings is settings saved by MCM Helper.
+	CategoryMCMSettings Category = "mcm_settings"
+	// CategoryBashedPatch is a Wrye Bash bashed patch.
+	CategoryBashedPatch Category = "bashed_patch"
+	// CategoryGeneratedPatch is a plugin generated by a patching tool.
+	CategoryGeneratedPatch Category = "generated_patch"
+	// CategoryLog is a log file.
+	CategoryLog Category = "log"
+	// CategoryStray is anything not recognized.
+	CategoryStray Category = "stray"
+)
+
+// Action is what the user should do with a file.
+type Action string
+
+const (
+	// ActionMoveToMod means the file belongs in a new or existing mod.
+	ActionMoveToMod Action = "move_to_mod"
+	// ActionDelete means the file is safe to delete.
+	ActionDelete Action = "delete"
+	// ActionReview means the file should be checked manually.
+	ActionReview Action = "review"
+)
+
+// Item is a classified file.
+type Item struct {
+	// Path is the file's path relative to the overwrite folder.
+	Path string `json:"path"`
+	// Size is the file size in bytes, if known.
+	Size int64 `json:"size"`
+	// Category is what produced the file.
+	Category Category `json:"category"`
+	// Action is the recommended action.
+	Action Action `json:"action"`
+	// Target is the suggested mod to move the file into, when Action is move_to_mod.
+	Target string `json:"target,omitempty"`
+}
+
+// Group collects files that share a recommendation.
+type Group struct {
+	Category  Category `json:"category"`
+	Action    Action   `json:"action"`
+	Target    string   `json:"target,omitempty"`
+	FileCount int      `json:"fileCount"`
+	TotalSize int64    `json:"totalSize"`
+	// Recommendation explains what to do with the group.
+	Recommendation string `json:"recommendation"`
+	// Files lists the paths in the group.
+	Files []string `json:"files"`
+}
+
+// Result is the classification of an overwrite folder.
+type Result struct {
+	TotalFiles int     `json:"totalFiles"`
+	TotalSize  int64   `json:"totalSize"`
+	Groups     []Group `json:"groups"`
+	Items      []Item  `json:"items"`
+}
+
+// generatedPatches maps generated plugin filenames to their suggested output mod.
+var generatedPatches = map[string]string{
+	"synthesis.esp":      "Synthesis Output",
+	"dyndolod.esm":       "DynDOLOD Output",
+	"dyndolod.esp":       "DynDOLOD Output",
+	"occlusion.esp":      "xLODGen Output",
+	"smashed patch.esp":  "Smashed Patch",
+	"smash.override.esp": "Smashed Patch",
+}
+
+// behaviorOutputs are files written by both FNIS and Nemesis.
+var behaviorOutputs = []string{
+	"meshes/animationdatasinglefile.txt",
+	"meshes/animationsetdatasinglefile.txt",
+	"meshes/actors/character/behaviors/",
+	"meshes/actors/character/characters/",
+	"meshes/actors/character/characters female/",
+	"meshes/actors/character/_1stperson/",
+}
+
+// configExtensions are file types SKSE plugins use for settings.
+var configExtensions = map[string]bool{
+	".ini":  true,
+	".json": true,
+	".toml": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// Classify classifies the files of an overwrite folder.
+func Classify(files []manifest.FileEntry) *Result {
+	result := &Result{
+		Groups: []Group{},
+		Items:  make([]Item, 0, len(files)),
+	}
+
+	// Behavior files are shared between FNIS and Nemesis; attribute them to
+	// Nemesis only when its engine folder is present.
+	behaviorCategory := CategoryFNIS
+	for _, f := range files {
+		if strings.HasPrefix(f.Path, "nemesis_engine/") {
+			behaviorCategory = CategoryNemesis
+			break
+		}
+	}
+
+	for _, f := range files {
+		item := classifyFile(f, behaviorCategory)
+		result.Items = append(result.Items, item)
+		result.TotalFiles++
+		result.TotalSize += f.Size
+	}
+
+	result.Groups = groupItems(result.Items)
+	return result
+}
+
+// classifyFile classifies a single file.
+func classifyFile(f manifest.FileEntry, behaviorCategory Category) Item {
+	item := Item{Path: f.OriginalPath, Size: f.Size}
+	if item.Path == "" {
+		item.Path = f.Path
+	}
+
+	set := func(category Category, action Action, target string) Item {
+		item.Category = category
+		item.Action = action
+		item.Target = target
+		return item
+	}
+
+	switch {
+	case f.Extension == ".log":
+		return set(CategoryLog, ActionDelete, "")
+	case strings.HasPrefix(f.Filename, "bashed patch") && f.Type == manifest.FileTypePlugin,
+		strings.HasPrefix(f.Path, "docs/bashed patch"):
+		return set(CategoryBashedPatch, ActionMoveToMod, "Bashed Patch")
+	case generatedPatches[f.Filename] != "" && f.Directory == "":
+		return set(CategoryGeneratedPatch, ActionMoveToMod, generatedPatches[f.Filename])
+	case strings.HasPrefix(f.Path, "nemesis_engine/"):
+		return set(CategoryNemesis, ActionMoveToMod, "Nemesis Output")
+	case strings.HasPrefix(f.Path, "tools/generatefnis_for_users/"),
+		strings.HasPrefix(f.Filename, "fnis_") && strings.HasPrefix(f.Path, "meshes/"):
+		return set(CategoryFNIS, ActionMoveToMod, "FNIS Output")
+	case isBehaviorOutput(f.Path):
+		if behaviorCategory == CategoryNemesis {
+			return set(CategoryNemesis, ActionMoveToMod, "Nemesis Output")
+		}
+		return set(CategoryFNIS, ActionMoveToMod, "FNIS Output")
+	case strings.HasPrefix(f.Path, "mcm/settings/"):
+		return set(CategoryMCMSettings, ActionMoveToMod, "MCM Settings")
+	case strings.HasPrefix(f.Path, "skse/plugins/") && configExtensions[f.Extension]:
+		return set(CategorySKSEConfig, ActionMoveToMod, "SKSE Plugin Configs")
+	default:
+		return set(CategoryStray, ActionReview, "")
+	}
+}
+
+// isBehaviorOutput reports whether a path is a behavior engine output file.
+func isBehaviorOutput(path string) bool {
+	for _, prefix := range behaviorOutputs {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupItems groups items by category, action and target.
+func groupItems(items []Item) []Group {
+	type key struct {
+		category Category
+		action   Action
+		target   string
+	}
+
+	index := make(map[key]int)
+	groups := []Group{}
+	for _, item := range items {
+		k := key{item.Category, item.Action, item.Target}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, Group{
+				Category:       item.Category,
+				Action:         item.Action,
+				Target:         item.Target,
+				Recommendation: recommendation(item.Category, item.Target),
+				Files:          []string{},
+			})
+		}
+		groups[i].FileCount++
+		groups[i].TotalSize += item.Size
+		groups[i].Files = append(groups[i].Files, item.Path)
+	}
+
+	// Actionable groups first, stray files last
+	sort.SliceStable(groups, func(i, j int) bool {
+		return actionRank(groups[i].Action) < actionRank(groups[j].Action)
+	})
+	return groups
+}
+
+// actionRank orders actions for display.
+func actionRank(a Action) int {
+	switch a {
+	case ActionMoveToMod:
+		return 0
+	case ActionDelete:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// recommendation describes what to do with a group.
+func recommendation(category Category, target string) string {
+	switch category {
+	case CategoryFNIS:
+		return "Move FNIS output into a separate mod named \"" + target + "\" enabled at the end of your load order, and regenerate it whenever animation mods change."
+	case CategoryNemesis:
+		return "Move Nemesis output into a separate mod named \"" + target + "\" enabled at the end of your load order, and rerun Nemesis whenever animation mods change."
+	case CategorySKSEConfig:
+		return "Move these settings back into the SKSE plugin they belong to, or into a \"" + target + "\" mod so they survive reinstalls."
+	case CategoryMCMSettings:
+		return "Move saved MCM settings into a \"" + target + "\" mod so they persist across profiles and reinstalls."
+	case CategoryBashedPatch:
+		return "Move the bashed patch into a mod named \"" + target + "\" and rebuild it after changing plugins."
+	case CategoryGeneratedPatch:
+		return "Move this generated output into a mod named \"" + target + "\" and regenerate it after changing plugins."
+	case CategoryLog:
+		return "Log files are safe to delete."
+	default:
+		return "Unrecognized files. Check which tool or mod created them before moving or deleting."
+	}
+}
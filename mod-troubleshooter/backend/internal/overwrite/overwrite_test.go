@@ -0,0 +1,87 @@
+package overwrite
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func entries(paths ...string) []manifest.FileEntry {
+	files := make([]manifest.FileEntry, 0, len(paths))
+	for _, p := range paths {
+		files = append(files, manifest.NewFileEntry(p, 100))
+	}
+	return files
+}
+
+func TestClassify_Categories(t *testing.T) {
+	tests := []struct {
+		path     string
+		category Category
+		action   Action
+		target   string
+	}{
+		{"meshes/actors/character/behaviors/FNIS_MyMod_Behavior.hkx", CategoryFNIS, ActionMoveToMod, "FNIS Output"},
+		{"tools/GenerateFNIS_for_Users/temporary_logs/log.txt", CategoryFNIS, ActionMoveToMod, "FNIS Output"},
+		{"meshes/animationdatasinglefile.txt", CategoryFNIS, ActionMoveToMod, "FNIS Output"},
+		{"SKSE/Plugins/EngineFixes.toml", CategorySKSEConfig, ActionMoveToMod, "SKSE Plugin Configs"},
+		{"MCM/Settings/SkyUI.ini", CategoryMCMSettings, ActionMoveToMod, "MCM Settings"},
+		{"Bashed Patch, 0.esp", CategoryBashedPatch, ActionMoveToMod, "Bashed Patch"},
+		{"Docs/Bashed Patch, 0.html", CategoryBashedPatch, ActionMoveToMod, "Bashed Patch"},
+		{"Synthesis.esp", CategoryGeneratedPatch, ActionMoveToMod, "Synthesis Output"},
+		{"SKSE/Plugins/po3_Tweaks.log", CategoryLog, ActionDelete, ""},
+		{"textures/random.dds", CategoryStray, ActionReview, ""},
+		{"SKSE/Plugins/SomePlugin.dll", CategoryStray, ActionReview, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := Classify(entries(tt.path))
+			item := result.Items[0]
+			if item.Category != tt.category {
+				t.Errorf("expected category %s, got %s", tt.category, item.Category)
+			}
+			if item.Action != tt.action {
+				t.Errorf("expected action %s, got %s", tt.action, item.Action)
+			}
+			if item.Target != tt.target {
+				t.Errorf("expected target %q, got %q", tt.target, item.Target)
+			}
+		})
+	}
+}
+
+func TestClassify_NemesisOwnsBehaviorFiles(t *testing.T) {
+	result := Classify(entries(
+		"Nemesis_Engine/cache/animationdata_list",
+		"meshes/actors/character/behaviors/0_master.hkx",
+	))
+
+	for _, item := range result.Items {
+		if item.Category != CategoryNemesis {
+			t.Errorf("expected %s to be nemesis output, got %s", item.Path, item.Category)
+		}
+	}
+}
+
+func TestClassify_Groups(t *testing.T) {
+	result := Classify(entries(
+		"stray.txt",
+		"SKSE/Plugins/a.ini",
+		"SKSE/Plugins/b.json",
+		"crash.log",
+	))
+
+	if result.TotalFiles != 4 || result.TotalSize != 400 {
+		t.Errorf("expected 4 files totalling 400 bytes, got %d and %d", result.TotalFiles, result.TotalSize)
+	}
+	if len(result.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(result.Groups))
+	}
+	if result.Groups[0].Category != CategorySKSEConfig || result.Groups[0].FileCount != 2 {
+		t.Errorf("expected SKSE config group with 2 files first, got %+v", result.Groups[0])
+	}
+	if result.Groups[2].Action != ActionReview {
+		t.Errorf("expected review group last, got %+v", result.Groups[2])
+	}
+}
@@ -0,0 +1,79 @@
+package patch
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// bsdiffHeaderLen is the size of the BSDIFF40 header: the magic and three
+// lengths.
+const bsdiffHeaderLen = 32
+
+// applyBsdiff applies a BSDIFF40 patch. After the header come three bzip2
+// streams: control triples, bytes added to the old file, and new bytes.
+func applyBsdiff(old, delta []byte) ([]byte, error) {
+	if len(delta) < bsdiffHeaderLen {
+		return nil, fmt.Errorf("%w: truncated header", ErrCorrupt)
+	}
+	ctrlLen := offtin(delta[8:16])
+	diffLen := offtin(delta[16:24])
+	newSize := offtin(delta[24:32])
+	bodyLen := int64(len(delta) - bsdiffHeaderLen)
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || ctrlLen > bodyLen || diffLen > bodyLen-ctrlLen {
+		return nil, fmt.Errorf("%w: invalid header", ErrCorrupt)
+	}
+	if newSize > MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, newSize)
+	}
+
+	body := delta[bsdiffHeaderLen:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	out := make([]byte, newSize)
+	var newPos, oldPos int64
+	var triple [24]byte
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, triple[:]); err != nil {
+			return nil, fmt.Errorf("%w: control block: %v", ErrCorrupt, err)
+		}
+		add, copyLen, seek := offtin(triple[0:8]), offtin(triple[8:16]), offtin(triple[16:24])
+		if add < 0 || copyLen < 0 || newPos+add+copyLen > newSize {
+			return nil, fmt.Errorf("%w: control out of range", ErrCorrupt)
+		}
+
+		// Diff bytes are added to the old bytes at the same offset
+		if _, err := io.ReadFull(diff, out[newPos:newPos+add]); err != nil {
+			return nil, fmt.Errorf("%w: diff block: %v", ErrCorrupt, err)
+		}
+		for i := int64(0); i < add; i++ {
+			if p := oldPos + i; p >= 0 && p < int64(len(old)) {
+				out[newPos+i] += old[p]
+			}
+		}
+		newPos += add
+		oldPos += add
+
+		if _, err := io.ReadFull(extra, out[newPos:newPos+copyLen]); err != nil {
+			return nil, fmt.Errorf("%w: extra block: %v", ErrCorrupt, err)
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+	return out, nil
+}
+
+// offtin decodes a bsdiff integer: little endian magnitude with the sign
+// in the top bit.
+func offtin(b []byte) int64 {
+	v := binary.LittleEndian.Uint64(b)
+	n := int64(v &^ (1 << 63))
+	if v&(1<<63) != 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+// Package patch applies the binary patches collections ship for mod files,
+// so analyses see the content that is actually installed.
+//
+// Two formats are supported: VCDIFF (RFC 3284) as written by xdelta3,
+// without secondary compression, and bsdiff's BSDIFF40.
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// MaxSize is the largest file a patch may produce. Patched files are held
+// in memory.
+const MaxSize = 256 << 20
+
+// Common errors returned when applying patches.
+var (
+	// ErrUnsupported is returned for patch formats, or features of them,
+	// that cannot be applied.
+	ErrUnsupported = errors.New("unsupported patch")
+	// ErrCorrupt is returned for malformed patches and for patches made
+	// against a different original file.
+	ErrCorrupt = errors.New("corrupt patch")
+	// ErrTooLarge is returned for patches producing files over MaxSize.
+	ErrTooLarge = errors.New("patched file is too large")
+)
+
+// Format is a binary patch format.
+type Format string
+
+// Supported patch formats.
+const (
+	FormatXdelta Format = "xdelta"
+	FormatBsdiff Format = "bsdiff"
+)
+
+var (
+	vcdiffMagic = []byte{0xd6, 0xc3, 0xc4}
+	bsdiffMagic = []byte("BSDIFF40")
+)
+
+// File is a binary patch a collection applies to one file of a mod after
+// installing it.
+type File struct {
+	// Path is the file's path within the mod.
+	Path string `json:"path"`
+	// Data is the patch, in any supported format. It is base64 in JSON.
+	Data []byte `json:"data"`
+}
+
+// Detect returns the format of a patch, and false if it is not recognized.
+func Detect(delta []byte) (Format, bool) {
+	switch {
+	case bytes.HasPrefix(delta, vcdiffMagic):
+		return FormatXdelta, true
+	case bytes.HasPrefix(delta, bsdiffMagic):
+		return FormatBsdiff, true
+	}
+	return "", false
+}
+
+// Apply returns the result of applying delta to old. The format is
+// detected from the patch.
+func Apply(old, delta []byte) ([]byte, error) {
+	format, ok := Detect(delta)
+	if !ok {
+		return nil, fmt.Errorf("%w: unrecognized format", ErrUnsupported)
+	}
+	if format == FormatBsdiff {
+		return applyBsdiff(old, delta)
+	}
+	return applyVCDIFF(old, delta)
+}
@@ -0,0 +1,92 @@
+package patch
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/adler32"
+	"os"
+	"testing"
+)
+
+// vcdiffPatch builds a single-window VCDIFF patch over the whole of old,
+// in the layout xdelta3 writes, with an application header and checksum.
+func vcdiffPatch(old []byte, target []byte, data, insts, addrs []byte) []byte {
+	p := []byte{0xd6, 0xc3, 0xc4, 0x00, vcdAppHeader, 0x02, 'h', 'i'}
+	p = append(p, vcdSource|vcdAdler32, byte(len(old)), 0x00)
+
+	enc := []byte{byte(len(target)), 0x00, byte(len(data)), byte(len(insts)), byte(len(addrs))}
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, adler32.Checksum(target))
+	enc = append(enc, sum...)
+	enc = append(enc, data...)
+	enc = append(enc, insts...)
+	enc = append(enc, addrs...)
+
+	p = append(p, byte(len(enc)))
+	return append(p, enc...)
+}
+
+func TestApply_VCDIFF(t *testing.T) {
+	old := []byte("the quick brown fox")
+	want := []byte("the quick red fox ababab")
+
+	// COPY 10 from the source, ADD "red fox ab", then COPY 4 of the target
+	// just written, overlapping itself
+	insts := []byte{26, 11, 20 + 16}
+	addrs := []byte{0x00, 0x02}
+	delta := vcdiffPatch(old, want, []byte("red fox ab"), insts, addrs)
+
+	if format, ok := Detect(delta); !ok || format != FormatXdelta {
+		t.Errorf("Detect() = %q, %v", format, ok)
+	}
+	got, err := Apply(old, delta)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+
+	// A different original fails the checksum
+	if _, err := Apply([]byte("THE QUICK BROWN FOX"), delta); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt for the wrong original, got %v", err)
+	}
+}
+
+func TestApply_Bsdiff(t *testing.T) {
+	delta, err := os.ReadFile("testdata/fox.bsdiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply([]byte("the quick brown fox"), delta)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if string(got) != "the quick red fox jumps" {
+		t.Errorf("Apply() = %q", got)
+	}
+
+	if _, err := Apply(nil, delta[:40]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt for a truncated patch, got %v", err)
+	}
+}
+
+func TestApply_Unsupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		delta []byte
+	}{
+		{name: "unknown format", delta: []byte("PATCH")},
+		{name: "secondary compression", delta: []byte{0xd6, 0xc3, 0xc4, 0x00, vcdDecompress, 0x02}},
+		{name: "custom code table", delta: []byte{0xd6, 0xc3, 0xc4, 0x00, vcdCodeTable}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply(nil, tt.delta); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("expected ErrUnsupported, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package patch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/adler32"
+)
+
+// Header and window indicator bits. VCD_APPHEADER and VCD_ADLER32 are
+// xdelta3 extensions.
+const (
+	vcdDecompress = 0x01
+	vcdCodeTable  = 0x02
+	vcdAppHeader  = 0x04
+
+	vcdSource  = 0x01
+	vcdTarget  = 0x02
+	vcdAdler32 = 0x04
+)
+
+// Instruction types of the code table.
+const (
+	instNoop = iota
+	instAdd
+	instRun
+	instCopy
+)
+
+// Address cache sizes of the default code table.
+const (
+	nearSize = 4
+	sameSize = 3
+)
+
+// instruction is one half of a code table entry.
+type instruction struct {
+	typ  byte
+	size byte
+	mode byte
+}
+
+// defaultCodeTable is the code table of RFC 3284 section 5.6, which every
+// VCDIFF encoder uses unless it ships its own.
+var defaultCodeTable = buildCodeTable()
+
+func buildCodeTable() [256][2]instruction {
+	var table [256][2]instruction
+	i := 0
+	add := func(a, b instruction) {
+		table[i] = [2]instruction{a, b}
+		i++
+	}
+
+	add(instruction{typ: instRun}, instruction{})
+	for size := byte(0); size <= 17; size++ {
+		add(instruction{typ: instAdd, size: size}, instruction{})
+	}
+	for mode := byte(0); mode <= 8; mode++ {
+		add(instruction{typ: instCopy, mode: mode}, instruction{})
+		for size := byte(4); size <= 18; size++ {
+			add(instruction{typ: instCopy, size: size, mode: mode}, instruction{})
+		}
+	}
+	for mode := byte(0); mode <= 5; mode++ {
+		for addSize := byte(1); addSize <= 4; addSize++ {
+			for copySize := byte(4); copySize <= 6; copySize++ {
+				add(instruction{typ: instAdd, size: addSize}, instruction{typ: instCopy, size: copySize, mode: mode})
+			}
+		}
+	}
+	for mode := byte(6); mode <= 8; mode++ {
+		for addSize := byte(1); addSize <= 4; addSize++ {
+			add(instruction{typ: instAdd, size: addSize}, instruction{typ: instCopy, size: 4, mode: mode})
+		}
+	}
+	for mode := byte(0); mode <= 8; mode++ {
+		add(instruction{typ: instCopy, size: 4, mode: mode}, instruction{typ: instAdd, size: 1})
+	}
+	return table
+}
+
+// reader consumes a section of a VCDIFF patch.
+type reader struct {
+	buf []byte
+	pos int
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrCorrupt)
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+// varint reads a VCDIFF integer: base 128, most significant digit first,
+// with the top bit set on every byte but the last.
+func (r *reader) varint() (int, error) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v = v<<7 | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			if v > MaxSize*2 {
+				return 0, fmt.Errorf("%w: integer %d out of range", ErrCorrupt, v)
+			}
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: integer too long", ErrCorrupt)
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.buf)-r.pos {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrCorrupt)
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// addressCache decodes COPY addresses, which are encoded relative to
+// recent ones.
+type addressCache struct {
+	near     [nearSize]int
+	nextSlot int
+	same     [sameSize * 256]int
+}
+
+func (c *addressCache) decode(addrs *reader, here int, mode byte) (int, error) {
+	var addr int
+	switch {
+	case mode == 0: // VCD_SELF
+		v, err := addrs.varint()
+		if err != nil {
+			return 0, err
+		}
+		addr = v
+	case mode == 1: // VCD_HERE
+		v, err := addrs.varint()
+		if err != nil {
+			return 0, err
+		}
+		addr = here - v
+	case int(mode) < 2+nearSize:
+		v, err := addrs.varint()
+		if err != nil {
+			return 0, err
+		}
+		addr = c.near[mode-2] + v
+	default:
+		b, err := addrs.byte()
+		if err != nil {
+			return 0, err
+		}
+		addr = c.same[int(mode-2-nearSize)*256+int(b)]
+	}
+	if addr < 0 || addr >= here {
+		return 0, fmt.Errorf("%w: copy address %d out of range", ErrCorrupt, addr)
+	}
+
+	c.near[c.nextSlot] = addr
+	c.nextSlot = (c.nextSlot + 1) % nearSize
+	c.same[addr%(sameSize*256)] = addr
+	return addr, nil
+}
+
+// applyVCDIFF applies a VCDIFF patch window by window.
+func applyVCDIFF(old, delta []byte) ([]byte, error) {
+	r := &reader{buf: delta}
+	header, err := r.bytes(5)
+	if err != nil {
+		return nil, err
+	}
+	indicator := header[4]
+	if indicator&vcdDecompress != 0 {
+		return nil, fmt.Errorf("%w: secondary compression", ErrUnsupported)
+	}
+	if indicator&vcdCodeTable != 0 {
+		return nil, fmt.Errorf("%w: custom code table", ErrUnsupported)
+	}
+	if indicator&vcdAppHeader != 0 {
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.bytes(n); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []byte
+	for r.pos < len(r.buf) {
+		if out, err = applyWindow(r, old, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// applyWindow decodes one window, appending its target to out.
+func applyWindow(r *reader, old, out []byte) ([]byte, error) {
+	winIndicator, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	var source []byte
+	if winIndicator&(vcdSource|vcdTarget) != 0 {
+		length, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		pos, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		from := old
+		if winIndicator&vcdTarget != 0 {
+			from = out
+		}
+		if pos+length > len(from) {
+			return nil, fmt.Errorf("%w: source segment out of range", ErrCorrupt)
+		}
+		source = from[pos : pos+length]
+	}
+
+	if _, err := r.varint(); err != nil { // length of the delta encoding
+		return nil, err
+	}
+	targetLen, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if len(out)+targetLen > MaxSize {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrTooLarge, MaxSize)
+	}
+	deltaIndicator, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if deltaIndicator != 0 {
+		return nil, fmt.Errorf("%w: compressed sections", ErrUnsupported)
+	}
+
+	var lens [3]int
+	for i := range lens {
+		if lens[i], err = r.varint(); err != nil {
+			return nil, err
+		}
+	}
+	var checksum []byte
+	if winIndicator&vcdAdler32 != 0 {
+		if checksum, err = r.bytes(4); err != nil {
+			return nil, err
+		}
+	}
+	var sections [3]*reader
+	for i, n := range lens {
+		b, err := r.bytes(n)
+		if err != nil {
+			return nil, err
+		}
+		sections[i] = &reader{buf: b}
+	}
+	data, insts, addrs := sections[0], sections[1], sections[2]
+
+	// COPY addresses span the source segment followed by the target so far
+	target := make([]byte, 0, targetLen)
+	var cache addressCache
+	for insts.pos < len(insts.buf) {
+		index, err := insts.byte()
+		if err != nil {
+			return nil, err
+		}
+		for _, inst := range defaultCodeTable[index] {
+			if inst.typ == instNoop {
+				continue
+			}
+			size := int(inst.size)
+			if size == 0 {
+				if size, err = insts.varint(); err != nil {
+					return nil, err
+				}
+			}
+			if len(target)+size > targetLen {
+				return nil, fmt.Errorf("%w: window overflows its target", ErrCorrupt)
+			}
+
+			switch inst.typ {
+			case instAdd:
+				b, err := data.bytes(size)
+				if err != nil {
+					return nil, err
+				}
+				target = append(target, b...)
+			case instRun:
+				b, err := data.byte()
+				if err != nil {
+					return nil, err
+				}
+				for i := 0; i < size; i++ {
+					target = append(target, b)
+				}
+			case instCopy:
+				here := len(source) + len(target)
+				addr, err := cache.decode(addrs, here, inst.mode)
+				if err != nil {
+					return nil, err
+				}
+				// Copies from the target may overlap what they write
+				for i := 0; i < size; i++ {
+					if p := addr + i; p < len(source) {
+						target = append(target, source[p])
+					} else {
+						target = append(target, target[p-len(source)])
+					}
+				}
+			}
+		}
+	}
+
+	if len(target) != targetLen {
+		return nil, fmt.Errorf("%w: window is %d bytes, expected %d", ErrCorrupt, len(target), targetLen)
+	}
+	if checksum != nil && adler32.Checksum(target) != binary.BigEndian.Uint32(checksum) {
+		return nil, fmt.Errorf("%w: checksum mismatch, the patch is for a different file", ErrCorrupt)
+	}
+	return append(out, target...), nil
+}
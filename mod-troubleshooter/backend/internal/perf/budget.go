@@ -0,0 +1,242 @@
+package perf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mod-troubleshooter/backend/internal/archive"
+)
+
+// Tier is a rough hardware class needed to run a collection comfortably.
+type Tier string
+
+const (
+	TierPotato  Tier = "potato"
+	TierMid     Tier = "mid"
+	TierHighEnd Tier = "high_end"
+	// TierExtreme means the collection exceeds every configured profile.
+	TierExtreme Tier = "extreme"
+)
+
+// Profile describes the budget a class of hardware can handle.
+// Budgets apply to the whole collection, not to what is resident at once.
+type Profile struct {
+	Tier Tier   `json:"tier"`
+	Name string `json:"name"`
+	// MaxTextureBytes is the estimated texture memory the hardware handles comfortably.
+	MaxTextureBytes int64 `json:"maxTextureBytes"`
+	// MaxScripts is the number of compiled scripts before script lag becomes likely.
+	MaxScripts int `json:"maxScripts"`
+}
+
+// DefaultProfiles are the built-in hardware profiles, from weakest to strongest.
+var DefaultProfiles = []Profile{
+	{Tier: TierPotato, Name: "Potato (4GB VRAM)", MaxTextureBytes: 6 << 30, MaxScripts: 2000},
+	{Tier: TierMid, Name: "Mid-range (8GB VRAM)", MaxTextureBytes: 20 << 30, MaxScripts: 6000},
+	{Tier: TierHighEnd, Name: "High-end (16GB+ VRAM)", MaxTextureBytes: 60 << 30, MaxScripts: 15000},
+}
+
+// maxHeaviestMods limits how many mods are listed as the heaviest.
+const maxHeaviestMods = 10
+
+// highResSize is the texture dimension counted as high resolution.
+const highResSize = 4096
+
+// ModBudget is the performance cost of a single mod.
+type ModBudget struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// Textures is the number of loose DDS textures.
+	Textures int `json:"textures"`
+	// TextureBytes is the estimated texture memory of loose textures.
+	TextureBytes int64 `json:"textureBytes"`
+	// HighResTextures is the number of textures of 4K or more.
+	HighResTextures int `json:"highResTextures"`
+	// ArchivedBytes is the size of BSA/BA2 archives, whose textures cannot be inspected.
+	ArchivedBytes int64 `json:"archivedBytes"`
+	// Scripts is the number of compiled Papyrus scripts.
+	Scripts int `json:"scripts"`
+	// ByResolution counts textures by their largest dimension.
+	ByResolution map[string]int `json:"byResolution"`
+}
+
+// EstimatedBytes is the estimated texture memory including archived assets.
+func (m *ModBudget) EstimatedBytes() int64 {
+	return m.TextureBytes + m.ArchivedBytes
+}
+
+// ProfileVerdict is how a collection fits a hardware profile.
+type ProfileVerdict struct {
+	Profile Profile `json:"profile"`
+	Fits    bool    `json:"fits"`
+	// TextureUsage and ScriptUsage are percentages of the profile's budget.
+	TextureUsage float64 `json:"textureUsage"`
+	ScriptUsage  float64 `json:"scriptUsage"`
+}
+
+// Budget is the performance estimate for a collection.
+type Budget struct {
+	Tier            Tier             `json:"tier"`
+	Textures        int              `json:"textures"`
+	HighResTextures int              `json:"highResTextures"`
+	EstimatedBytes  int64            `json:"estimatedBytes"`
+	ArchivedBytes   int64            `json:"archivedBytes"`
+	Scripts         int              `json:"scripts"`
+	ByResolution    map[string]int   `json:"byResolution"`
+	Profiles        []ProfileVerdict `json:"profiles"`
+	HeaviestMods    []ModBudget      `json:"heaviestMods"`
+	ModsScanned     int              `json:"modsScanned"`
+}
+
+// resolutionBucket names the size class of a texture.
+func resolutionBucket(width, height int) string {
+	size := width
+	if height > size {
+		size = height
+	}
+	switch {
+	case size >= 8192:
+		return "8k"
+	case size >= 4096:
+		return "4k"
+	case size >= 2048:
+		return "2k"
+	case size >= 1024:
+		return "1k"
+	default:
+		return "512"
+	}
+}
+
+// ScanArchive reads texture headers and counts scripts in a mod archive,
+// opening it with password if it is encrypted.
+func ScanArchive(ctx context.Context, archivePath, password, modID, modName string) (*ModBudget, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
+	if err != nil {
+		return nil, fmt.Errorf("identify archive: %w", err)
+	}
+
+	extractor, ok := format.(archiver.Extractor)
+	if !ok {
+		return nil, fmt.Errorf("format does not support extraction")
+	}
+
+	mod := &ModBudget{ModID: modID, ModName: modName, ByResolution: make(map[string]int)}
+	err = extractor.Extract(ctx, input, func(ctx context.Context, f archiver.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if f.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(path.Ext(f.NameInArchive)) {
+		case ".pex":
+			mod.Scripts++
+		case ".bsa", ".ba2":
+			mod.ArchivedBytes += f.Size()
+		case ".dds":
+			rc, err := f.Open()
+			if err != nil {
+				return nil
+			}
+			defer rc.Close()
+
+			info, err := ParseDDSHeader(rc)
+			if err != nil {
+				return nil
+			}
+			mod.AddTexture(info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan archive: %w", err)
+	}
+
+	return mod, nil
+}
+
+// AddTexture records a texture in the mod's budget.
+func (m *ModBudget) AddTexture(info *DDSInfo) {
+	if m.ByResolution == nil {
+		m.ByResolution = make(map[string]int)
+	}
+	m.Textures++
+	m.TextureBytes += info.VRAMBytes()
+	m.ByResolution[resolutionBucket(info.Width, info.Height)]++
+	if info.Width >= highResSize || info.Height >= highResSize {
+		m.HighResTextures++
+	}
+}
+
+// Estimate combines mod budgets and rates them against hardware profiles.
+// Profiles must be ordered from weakest to strongest; nil uses DefaultProfiles.
+func Estimate(mods []ModBudget, profiles []Profile) *Budget {
+	if profiles == nil {
+		profiles = DefaultProfiles
+	}
+
+	b := &Budget{
+		Tier:         TierExtreme,
+		ByResolution: make(map[string]int),
+		Profiles:     make([]ProfileVerdict, 0, len(profiles)),
+		ModsScanned:  len(mods),
+	}
+
+	for _, m := range mods {
+		b.Textures += m.Textures
+		b.HighResTextures += m.HighResTextures
+		b.EstimatedBytes += m.EstimatedBytes()
+		b.ArchivedBytes += m.ArchivedBytes
+		b.Scripts += m.Scripts
+		for bucket, count := range m.ByResolution {
+			b.ByResolution[bucket] += count
+		}
+	}
+
+	tierSet := false
+	for _, p := range profiles {
+		v := ProfileVerdict{
+			Profile:      p,
+			TextureUsage: percent(b.EstimatedBytes, p.MaxTextureBytes),
+			ScriptUsage:  percent(int64(b.Scripts), int64(p.MaxScripts)),
+		}
+		v.Fits = b.EstimatedBytes <= p.MaxTextureBytes && b.Scripts <= p.MaxScripts
+		if v.Fits && !tierSet {
+			b.Tier = p.Tier
+			tierSet = true
+		}
+		b.Profiles = append(b.Profiles, v)
+	}
+
+	heaviest := append([]ModBudget{}, mods...)
+	sort.SliceStable(heaviest, func(i, j int) bool {
+		return heaviest[i].EstimatedBytes() > heaviest[j].EstimatedBytes()
+	})
+	if len(heaviest) > maxHeaviestMods {
+		heaviest = heaviest[:maxHeaviestMods]
+	}
+	b.HeaviestMods = heaviest
+
+	return b
+}
+
+// percent returns value as a percentage of limit, rounded to one decimal.
+func percent(value, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(value*1000/limit) / 10
+}
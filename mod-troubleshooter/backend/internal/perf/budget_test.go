@@ -0,0 +1,97 @@
+package perf
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEstimate_Tiers(t *testing.T) {
+	profiles := []Profile{
+		{Tier: TierPotato, MaxTextureBytes: 100, MaxScripts: 10},
+		{Tier: TierMid, MaxTextureBytes: 1000, MaxScripts: 100},
+		{Tier: TierHighEnd, MaxTextureBytes: 10000, MaxScripts: 1000},
+	}
+
+	tests := []struct {
+		name string
+		mods []ModBudget
+		want Tier
+	}{
+		{"empty", nil, TierPotato},
+		{"light", []ModBudget{{TextureBytes: 50, Scripts: 5}}, TierPotato},
+		{"texture heavy", []ModBudget{{TextureBytes: 60}, {ArchivedBytes: 60}}, TierMid},
+		{"script heavy", []ModBudget{{Scripts: 500}}, TierHighEnd},
+		{"over budget", []ModBudget{{TextureBytes: 20000}}, TierExtreme},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Estimate(tt.mods, profiles)
+			if b.Tier != tt.want {
+				t.Errorf("expected tier %s, got %s", tt.want, b.Tier)
+			}
+			if len(b.Profiles) != 3 {
+				t.Errorf("expected 3 profile verdicts, got %d", len(b.Profiles))
+			}
+		})
+	}
+}
+
+func TestEstimate_HeaviestMods(t *testing.T) {
+	var mods []ModBudget
+	for i := 0; i < 15; i++ {
+		mods = append(mods, ModBudget{ModID: string(rune('a' + i)), TextureBytes: int64(i)})
+	}
+
+	b := Estimate(mods, nil)
+	if len(b.HeaviestMods) != maxHeaviestMods {
+		t.Fatalf("expected %d heaviest mods, got %d", maxHeaviestMods, len(b.HeaviestMods))
+	}
+	if b.HeaviestMods[0].ModID != "o" {
+		t.Errorf("expected heaviest mod first, got %s", b.HeaviestMods[0].ModID)
+	}
+}
+
+func TestScanArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "mod.zip")
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	zw := zip.NewWriter(out)
+	files := map[string][]byte{
+		"textures/armor/iron.dds":   buildDDS(4096, 4096, 1, "DXT1", 0),
+		"textures/armor/iron_n.dds": buildDDS(2048, 2048, 1, "DXT5", 0),
+		"scripts/MyQuestScript.pex": []byte("pex"),
+		"scripts/MyOtherScript.pex": []byte("pex"),
+		"MyMod - Textures.bsa":      make([]byte, 1000),
+		"textures/broken.dds":       []byte("garbage"),
+	}
+	for name, data := range files {
+		w, _ := zw.Create(name)
+		w.Write(data)
+	}
+	zw.Close()
+	out.Close()
+
+	mod, err := ScanArchive(context.Background(), archivePath, "", "1", "Iron Armor")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mod.Textures != 2 || mod.HighResTextures != 1 {
+		t.Errorf("expected 2 textures with 1 high-res, got %d and %d", mod.Textures, mod.HighResTextures)
+	}
+	if mod.Scripts != 2 {
+		t.Errorf("expected 2 scripts, got %d", mod.Scripts)
+	}
+	if mod.ArchivedBytes != 1000 {
+		t.Errorf("expected 1000 archived bytes, got %d", mod.ArchivedBytes)
+	}
+	if mod.ByResolution["4k"] != 1 || mod.ByResolution["2k"] != 1 {
+		t.Errorf("unexpected resolution buckets: %v", mod.ByResolution)
+	}
+}
@@ -0,0 +1,119 @@
+// Package perf estimates the performance cost of a collection's assets.
+package perf
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrNotDDS is returned when data does not start with a DDS header.
+var ErrNotDDS = errors.New("not a DDS file")
+
+const (
+	ddsMagic      = "DDS "
+	ddsHeaderSize = 124
+	// ddsHeaderLen is the magic, header and optional DX10 extension.
+	ddsHeaderLen = 4 + ddsHeaderSize + 20
+
+	ddpfFourCC = 0x4
+)
+
+// DDSInfo is the texture information read from a DDS header.
+type DDSInfo struct {
+	Width   int
+	Height  int
+	MipMaps int
+	Format  string
+	// BitsPerPixel is the storage cost of the format; block-compressed formats use 4 or 8.
+	BitsPerPixel int
+}
+
+// VRAMBytes estimates the video memory the texture uses, including mipmaps.
+func (d *DDSInfo) VRAMBytes() int64 {
+	base := int64(d.Width) * int64(d.Height) * int64(d.BitsPerPixel) / 8
+	if d.MipMaps > 1 {
+		// A full mip chain adds a third of the base level
+		return base + base/3
+	}
+	return base
+}
+
+// ParseDDSHeader reads the header at the start of a DDS file.
+func ParseDDSHeader(r io.Reader) (*DDSInfo, error) {
+	buf := make([]byte, ddsHeaderLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrNotDDS
+	}
+	if n < 4+ddsHeaderSize || string(buf[:4]) != ddsMagic {
+		return nil, ErrNotDDS
+	}
+
+	h := buf[4:]
+	le := binary.LittleEndian
+	info := &DDSInfo{
+		Height:  int(le.Uint32(h[8:])),
+		Width:   int(le.Uint32(h[12:])),
+		MipMaps: int(le.Uint32(h[24:])),
+	}
+
+	// Pixel format starts at offset 72 within the header
+	pf := h[72:]
+	pfFlags := le.Uint32(pf[4:])
+	fourCC := string(pf[8:12])
+	rgbBitCount := int(le.Uint32(pf[12:]))
+
+	switch {
+	case pfFlags&ddpfFourCC == 0:
+		info.Format = "uncompressed"
+		info.BitsPerPixel = rgbBitCount
+	case fourCC == "DX10" && n >= ddsHeaderLen:
+		info.Format, info.BitsPerPixel = dxgiFormat(le.Uint32(buf[4+ddsHeaderSize:]))
+	default:
+		info.Format, info.BitsPerPixel = fourCCFormat(fourCC)
+	}
+
+	return info, nil
+}
+
+// fourCCFormat maps legacy FourCC codes to a format name and bits per pixel.
+func fourCCFormat(fourCC string) (string, int) {
+	switch fourCC {
+	case "DXT1", "ATI1", "BC4U", "BC4S":
+		return fourCC, 4
+	case "DXT2", "DXT3", "DXT4", "DXT5", "ATI2", "BC5U", "BC5S":
+		return fourCC, 8
+	default:
+		// Unknown codes are assumed to be 8bpp block compression
+		return fourCC, 8
+	}
+}
+
+// dxgiFormat maps DXGI formats used by DX10 headers to a format name and bits per pixel.
+func dxgiFormat(format uint32) (string, int) {
+	switch format {
+	case 70, 71, 72: // BC1
+		return "BC1", 4
+	case 79, 80, 81: // BC4
+		return "BC4", 4
+	case 73, 74, 75: // BC2
+		return "BC2", 8
+	case 76, 77, 78: // BC3
+		return "BC3", 8
+	case 82, 83, 84: // BC5
+		return "BC5", 8
+	case 94, 95, 96: // BC6H
+		return "BC6H", 8
+	case 97, 98, 99: // BC7
+		return "BC7", 8
+	case 27, 28, 29, 87, 88, 90, 91: // 8-bit RGBA/BGRA
+		return "RGBA8", 32
+	case 10: // RGBA16 float
+		return "RGBA16F", 64
+	case 2: // RGBA32 float
+		return "RGBA32F", 128
+	default:
+		return "DXGI", 32
+	}
+}
@@ -0,0 +1,74 @@
+package perf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// buildDDS encodes a DDS header. A fourCC of "" writes an uncompressed 32bpp format.
+func buildDDS(width, height, mips int, fourCC string, dxgi uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(ddsMagic)
+
+	h := make([]byte, ddsHeaderSize)
+	le := binary.LittleEndian
+	le.PutUint32(h[0:], ddsHeaderSize)
+	le.PutUint32(h[8:], uint32(height))
+	le.PutUint32(h[12:], uint32(width))
+	le.PutUint32(h[24:], uint32(mips))
+	pf := h[72:]
+	le.PutUint32(pf[0:], 32)
+	if fourCC == "" {
+		le.PutUint32(pf[4:], 0x41) // RGB | alpha
+		le.PutUint32(pf[12:], 32)
+	} else {
+		le.PutUint32(pf[4:], ddpfFourCC)
+		copy(pf[8:12], fourCC)
+	}
+	buf.Write(h)
+
+	if fourCC == "DX10" {
+		ext := make([]byte, 20)
+		le.PutUint32(ext, dxgi)
+		buf.Write(ext)
+	}
+	return buf.Bytes()
+}
+
+func TestParseDDSHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		format   string
+		bpp      int
+		wantVRAM int64
+	}{
+		{"dxt1", buildDDS(1024, 1024, 1, "DXT1", 0), "DXT1", 4, 512 * 1024},
+		{"dxt5 with mips", buildDDS(1024, 1024, 11, "DXT5", 0), "DXT5", 8, 1024*1024 + 1024*1024/3},
+		{"bc7", buildDDS(2048, 2048, 1, "DX10", 98), "BC7", 8, 4 * 1024 * 1024},
+		{"uncompressed", buildDDS(256, 256, 1, "", 0), "uncompressed", 32, 256 * 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseDDSHeader(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.Format != tt.format || info.BitsPerPixel != tt.bpp {
+				t.Errorf("expected %s at %dbpp, got %s at %dbpp", tt.format, tt.bpp, info.Format, info.BitsPerPixel)
+			}
+			if got := info.VRAMBytes(); got != tt.wantVRAM {
+				t.Errorf("expected %d bytes, got %d", tt.wantVRAM, got)
+			}
+		})
+	}
+}
+
+func TestParseDDSHeader_Invalid(t *testing.T) {
+	if _, err := ParseDDSHeader(bytes.NewReader([]byte("not a texture"))); !errors.Is(err, ErrNotDDS) {
+		t.Errorf("expected ErrNotDDS, got %v", err)
+	}
+}
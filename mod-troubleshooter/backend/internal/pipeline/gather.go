@@ -0,0 +1,746 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/patch"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// Source identifies a mod file to download and gather inputs from.
+type Source struct {
+	// ModID is a unique identifier for the mod (used for display and tracking).
+	ModID string
+	// ModName is the display name of the mod.
+	ModName string
+	// LoadOrder is the mod's position in the install order.
+	LoadOrder int
+	// Filename is the name of the mod file, used to detect loose plugins.
+	Filename string
+	// Game is the Nexus game domain.
+	Game string
+	// GameVersion is the game runtime the collection targets, if known.
+	GameVersion string
+	// ModGame is the game domain the mod is published under on Nexus, if it
+	// differs from Game. The file is looked up under it.
+	ModGame string
+	// NexusModID is the mod ID on Nexus.
+	NexusModID int
+	// FileID is the file ID on Nexus.
+	FileID int
+	// DownloadSize is the size of the mod file on Nexus in bytes, if known.
+	DownloadSize int64
+	// NexusCategory is the mod's category on Nexus, if known.
+	NexusCategory string
+	// NexusTags are the mod's tags on Nexus, if known.
+	NexusTags []string
+	// Version is the version of the mod file, if known.
+	Version string
+	// PinnedVersion is the version the collection recorded for the file, if known.
+	PinnedVersion string
+	// CuratorNote is what the collection's curator wrote about installing
+	// the file, if anything.
+	CuratorNote string
+	// Unavailable is why the file cannot be downloaded, if that is known in
+	// advance (for example, the mod is hidden). Such sources are not fetched.
+	Unavailable string
+	// Password opens the mod archive if it is encrypted (optional).
+	Password string
+	// Choices are the options the curator picked in the mod's FOMOD
+	// installer (optional). When set, the manifest lists the files the
+	// installer places instead of the archive contents.
+	Choices *fomod.Choices
+	// Patches are binary patches the collection applies to the mod's files
+	// after installing it (optional). They are applied before the files
+	// are hashed and parsed.
+	Patches []patch.File
+}
+
+// NexusGame returns the game domain to look the file up under on Nexus.
+func (s Source) NexusGame() string {
+	if s.ModGame != "" {
+		return s.ModGame
+	}
+	return s.Game
+}
+
+// Fetcher downloads mod files for the gatherer.
+type Fetcher interface {
+	// Fetch downloads the source and returns the local file path.
+	Fetch(ctx context.Context, src Source) (string, error)
+	// Release frees a path previously returned by Fetch.
+	Release(path string)
+}
+
+// Previewer lists the contents of a mod file without downloading it.
+type Previewer interface {
+	// Preview returns a manifest of the source's archive. Content hashes and
+	// exact sizes are not available this way.
+	Preview(ctx context.Context, src Source) (*manifest.Manifest, error)
+}
+
+// BugReporter looks up what users reported about a mod.
+type BugReporter interface {
+	// BugReports returns the titles of the latest bug reports filed against
+	// the source's mod, newest first.
+	BugReports(ctx context.Context, src Source) ([]string, error)
+}
+
+// ArchiveVerifier checks downloads against the checksums Nexus publishes.
+type ArchiveVerifier interface {
+	// VerifyArchive reports whether Nexus lists a file with the given MD5
+	// hash as the source's file.
+	VerifyArchive(ctx context.Context, src Source, md5Hash string) (bool, error)
+}
+
+// ArchiveReader reads archives in place of the built-in extractors, such as
+// in a sandboxed process.
+type ArchiveReader interface {
+	// Manifest lists an archive, reading file contents as opts requires.
+	Manifest(ctx context.Context, path, password string, opts manifest.Options) (*manifest.Manifest, error)
+	// PluginHeaders parses the header of every plugin in an archive, and
+	// collects the form IDs of their records if records is set.
+	PluginHeaders(ctx context.Context, path, password string, records bool) ([]loadorder.PluginFile, error)
+}
+
+// ManifestStore keeps the listings of mod files between analyses, so files
+// listed before are not downloaded again. *cache.Cache implements it.
+type ManifestStore interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
+
+// storedManifest is the listing of a mod file as kept in a ManifestStore.
+type storedManifest struct {
+	Manifest        *manifest.Manifest `json:"manifest"`
+	ArchiveVerified *bool              `json:"archiveVerified,omitempty"`
+}
+
+// GathererConfig holds configuration for the Gatherer.
+type GathererConfig struct {
+	// Fetcher downloads mod files.
+	Fetcher Fetcher
+	// Extractor is used to pull plugins out of archives.
+	// Only required when InputPluginHeaders is gathered.
+	Extractor *archive.Extractor
+	// ContentHashes includes content hashes in manifests (slower).
+	ContentHashes bool
+	// SevenZip lists archives the built-in extractor cannot read (optional).
+	SevenZip *archive.SevenZip
+	// Previewer lists archives without downloading them (optional). It is
+	// only used when manifests without content hashes are all that's needed;
+	// sources it cannot preview are downloaded as usual.
+	Previewer Previewer
+	// Sandbox reads manifests and plugins from archives instead of the
+	// built-in extractors (optional).
+	Sandbox ArchiveReader
+	// Profile selects how thoroughly archives are read (default:
+	// ProfileStandard). Quick profiles prefer previews even when other
+	// inputs are needed; deep profiles never use them.
+	Profile Profile
+	// BugReporter fetches the latest bug report titles of each mod, for
+	// community report hints (optional).
+	BugReporter BugReporter
+	// Verifier checks each download against its Nexus MD5 (optional). It
+	// is only used when content hashes are computed, as hashing the
+	// archive costs another read of it.
+	Verifier ArchiveVerifier
+	// Limiter bounds downloads, extractions, hashing and plugin parsing
+	// across every gatherer sharing it (optional).
+	Limiter *Limiter
+	// Manifests keeps the listings of Nexus files for ManifestTTL, and is
+	// consulted before downloading them (optional). Files with installer
+	// choices or patches are always downloaded, as is everything in the
+	// deep profile.
+	Manifests   ManifestStore
+	ManifestTTL time.Duration
+}
+
+// Gatherer downloads each mod once and collects every requested input from it.
+type Gatherer struct {
+	fetcher           Fetcher
+	extractor         *archive.Extractor
+	manifestExtractor *manifest.Extractor
+	parser            *plugin.Parser
+	contentHashes     bool
+	sevenZip          *archive.SevenZip
+	previewer         Previewer
+	sandbox           ArchiveReader
+	profile           Profile
+	bugReporter       BugReporter
+	verifier          ArchiveVerifier
+	limiter           *Limiter
+	manifests         ManifestStore
+	manifestTTL       time.Duration
+}
+
+// NewGatherer creates a new gatherer.
+func NewGatherer(cfg GathererConfig) *Gatherer {
+	return &Gatherer{
+		fetcher:           cfg.Fetcher,
+		extractor:         cfg.Extractor,
+		manifestExtractor: manifest.NewExtractor(),
+		parser:            plugin.NewParser(),
+		contentHashes:     cfg.ContentHashes,
+		sevenZip:          cfg.SevenZip,
+		previewer:         cfg.Previewer,
+		sandbox:           cfg.Sandbox,
+		profile:           cfg.Profile,
+		bugReporter:       cfg.BugReporter,
+		verifier:          cfg.Verifier,
+		limiter:           cfg.Limiter,
+		manifests:         cfg.Manifests,
+		manifestTTL:       cfg.ManifestTTL,
+	}
+}
+
+// Gather downloads every source and collects the requested inputs.
+// Mods are gathered by as many workers as the Limiter allows downloads at
+// once, or one at a time without a Limiter; the inputs keep the order of
+// the sources either way.
+// Failures for individual mods are recorded on the mod and do not stop the pass,
+// unless the fetcher reports ErrSourceDown.
+// The returned release function must be called once the inputs are no longer
+// needed; it frees any archives kept for InputArchives.
+func (g *Gatherer) Gather(ctx context.Context, sources []Source, need Input) (*Inputs, func(), error) {
+	in := &Inputs{Mods: make([]Mod, len(sources))}
+	for _, src := range sources {
+		if in.Game == "" {
+			in.Game = src.Game
+		}
+		if in.GameVersion == "" {
+			in.GameVersion = src.GameVersion
+		}
+	}
+
+	kept := make([]string, len(sources))
+	release := func() {
+		for _, path := range kept {
+			if path != "" {
+				g.fetcher.Release(path)
+			}
+		}
+	}
+
+	// The first error that stops the pass cancels the mods still being gathered
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var finished atomic.Int64
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < g.workers(len(sources)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				p := Progress{ModsDone: int(finished.Load()), ModsTotal: len(sources), CurrentMod: sources[i].ModName}
+				mod, path, err := g.gatherMod(ctx, sources[i], need, p)
+				if err != nil {
+					cancel(err)
+					continue
+				}
+				in.Mods[i] = mod
+				kept[i] = path
+				finished.Add(1)
+			}
+		}()
+	}
+
+feed:
+	for i := range sources {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		release()
+		return nil, func() {}, context.Cause(ctx)
+	}
+	return in, release, nil
+}
+
+// workers returns how many mods Gather works on at once.
+func (g *Gatherer) workers(mods int) int {
+	if g.limiter == nil {
+		return 1
+	}
+	return max(1, min(g.limiter.Concurrency().Downloads, mods))
+}
+
+// gatherMod downloads one source and collects the requested inputs from
+// it, reporting progress based on p. It returns the path of the download
+// if it is kept for InputArchives. It only fails when the whole pass
+// must stop: when ctx is done or the fetcher reports ErrSourceDown.
+func (g *Gatherer) gatherMod(ctx context.Context, src Source, need Input, p Progress) (Mod, string, error) {
+	if ctx.Err() != nil {
+		return Mod{}, "", ctx.Err()
+	}
+
+	mod := Mod{
+		ModID:         src.ModID,
+		ModName:       src.ModName,
+		LoadOrder:     src.LoadOrder,
+		Filename:      src.Filename,
+		NexusModID:    src.NexusModID,
+		FileID:        src.FileID,
+		DownloadSize:  src.DownloadSize,
+		ModGame:       src.ModGame,
+		NexusCategory: src.NexusCategory,
+		NexusTags:     src.NexusTags,
+		Version:       src.Version,
+		PinnedVersion: src.PinnedVersion,
+		CuratorNote:   src.CuratorNote,
+	}
+
+	if src.Unavailable != "" {
+		mod.Error = src.Unavailable
+		mod.Unavailable = true
+		return mod, "", nil
+	}
+
+	if g.bugReporter != nil && src.NexusModID > 0 {
+		titles, err := g.bugReporter.BugReports(ctx, src)
+		if err != nil {
+			log.Printf("Warning: could not fetch bug reports of mod %s: %v", src.ModID, err)
+		}
+		mod.BugReports = titles
+	}
+
+	if !wantsDownload(src, need) {
+		return mod, "", nil
+	}
+
+	p.Stage = StageDownloading
+	modCtx := reportStage(ctx, p)
+
+	modNeed := need
+	if stored, ok := g.storedManifest(ctx, src, need); ok {
+		mod.Manifest = stored.Manifest
+		mod.ArchiveVerified = stored.ArchiveVerified
+		mod.FromStore = true
+		if need == InputManifests {
+			return mod, "", nil
+		}
+		// The download is still needed for the other inputs
+		modNeed &^= InputManifests
+	}
+	if !mod.FromStore && g.usePreview(src, need) {
+		start := time.Now()
+		m, err := g.previewer.Preview(ctx, src)
+		mod.Timing.Download = time.Since(start)
+		if err == nil {
+			mod.Manifest = m
+			mod.FromPreview = true
+			if need == InputManifests {
+				return mod, "", nil
+			}
+			// The download is still needed for the other inputs
+			modNeed &^= InputManifests
+		}
+		if errors.Is(err, ErrSourceDown) {
+			return Mod{}, "", err
+		}
+		if ctx.Err() != nil {
+			return Mod{}, "", ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Warning: no content preview for mod %s, downloading: %v", src.ModID, err)
+		}
+	}
+
+	start := time.Now()
+	path, err := g.fetch(modCtx, src)
+	mod.Timing.Download += time.Since(start)
+	if errors.Is(err, ErrSourceDown) {
+		// Every remaining download would fail the same way
+		return Mod{}, "", err
+	}
+	if err != nil {
+		log.Printf("Warning: could not download mod %s: %v", src.ModID, err)
+		mod.Error = err.Error()
+		mod.Unavailable = errors.Is(err, ErrUnavailable)
+		mod.Infected = errors.Is(err, archive.ErrInfected)
+		return mod, "", nil
+	}
+
+	if g.verifier != nil && g.manifestOptions().Hashes && src.NexusModID > 0 {
+		mod.ArchiveVerified = g.verifyArchive(ctx, src, path)
+	}
+
+	p.Stage = StageExtracting
+	modCtx = reportStage(ctx, p)
+	err = g.collect(modCtx, &mod, path, src.Password, src.Choices, modNeed)
+	if len(src.Patches) > 0 && ctx.Err() == nil {
+		err = errors.Join(err, g.applyPatches(ctx, &mod, path, src.Password, src.Patches))
+	}
+	if err != nil {
+		log.Printf("Warning: could not gather inputs for mod %s: %v", src.ModID, err)
+		mod.Error = err.Error()
+	} else if modNeed.Has(InputManifests) {
+		g.storeManifest(ctx, src, &mod)
+	}
+
+	if need.Has(InputArchives) && mod.ArchivePath != "" {
+		return mod, path, nil
+	}
+	g.fetcher.Release(path)
+	return mod, "", nil
+}
+
+// fetch downloads a source once a download slot is free.
+func (g *Gatherer) fetch(ctx context.Context, src Source) (string, error) {
+	done, err := g.limiter.acquire(ctx, workDownload)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+	return g.fetcher.Fetch(ctx, src)
+}
+
+// manifestKey returns the key of a source's listing in the manifest store,
+// and false if its listing is not kept there.
+func (g *Gatherer) manifestKey(src Source) (string, bool) {
+	if g.manifests == nil || g.manifestTTL <= 0 || g.profile == ProfileDeep {
+		return "", false
+	}
+	// Installer choices and patches need the archive, and change the listing
+	if src.NexusModID <= 0 || src.FileID <= 0 || src.Choices != nil || len(src.Patches) > 0 || plugin.IsPluginFile(src.Filename) {
+		return "", false
+	}
+	return fmt.Sprintf("modmanifest:%s:%d:%d:%t", src.NexusGame(), src.NexusModID, src.FileID, g.manifestOptions().Hashes), true
+}
+
+// storedManifest returns the source's listing from the manifest store, if
+// manifests are needed and one was kept.
+func (g *Gatherer) storedManifest(ctx context.Context, src Source, need Input) (storedManifest, bool) {
+	key, ok := g.manifestKey(src)
+	if !ok || !need.Has(InputManifests) {
+		return storedManifest{}, false
+	}
+	var stored storedManifest
+	if err := g.manifests.Get(ctx, key, &stored); err != nil || stored.Manifest == nil {
+		return storedManifest{}, false
+	}
+	return stored, true
+}
+
+// storeManifest keeps the listing of a downloaded mod in the manifest store.
+func (g *Gatherer) storeManifest(ctx context.Context, src Source, mod *Mod) {
+	key, ok := g.manifestKey(src)
+	if !ok || mod.Manifest == nil || mod.FromPreview {
+		return
+	}
+	stored := storedManifest{Manifest: mod.Manifest, ArchiveVerified: mod.ArchiveVerified}
+	if err := g.manifests.SetWithTTL(ctx, key, stored, g.manifestTTL); err != nil {
+		log.Printf("Warning: could not store manifest of mod %s: %v", src.ModID, err)
+	}
+}
+
+// usePreview reports whether the source's manifest can come from a preview
+// instead of a download.
+func (g *Gatherer) usePreview(src Source, need Input) bool {
+	if g.previewer == nil || g.contentHashes || g.profile == ProfileDeep || !need.Has(InputManifests) {
+		return false
+	}
+	if need != InputManifests && g.profile != ProfileQuick {
+		return false
+	}
+	// Loose plugins have no archive to list, encrypted archives usually have
+	// no preview, and installers can't be simulated from one
+	return !plugin.IsPluginFile(src.Filename) && src.Password == "" && src.Choices == nil
+}
+
+// collect fills in the requested inputs for a single downloaded file,
+// opening it with password if it is an encrypted archive. Manifests of mods
+// with installer choices list the files the installer places.
+func (g *Gatherer) collect(ctx context.Context, mod *Mod, path, password string, choices *fomod.Choices, need Input) error {
+	// Loose plugin files are parsed directly
+	if plugin.IsPluginFile(mod.Filename) || plugin.IsPluginFile(path) {
+		if !need.Has(InputPluginHeaders) {
+			return nil
+		}
+		pf := loadorder.PluginFile{Filename: pluginFilename(mod.Filename, path)}
+		done, err := g.limiter.acquire(ctx, workParse)
+		if err != nil {
+			return err
+		}
+		defer done()
+		start := time.Now()
+		header, err := g.parser.ParseFile(ctx, path)
+		if err == nil && g.profile == ProfileDeep {
+			if pf.FormIDs, err = g.scanRecords(ctx, path); err != nil {
+				log.Printf("Warning: could not scan records of plugin %s: %v", pf.Filename, err)
+				err = nil
+			}
+		}
+		mod.Timing.Parse += time.Since(start)
+		pf.Header = header
+		mod.Plugins = []loadorder.PluginFile{pf}
+		if err != nil {
+			return fmt.Errorf("parse plugin: %w", err)
+		}
+		return nil
+	}
+
+	if !archive.IsArchive(path) {
+		return nil
+	}
+
+	var errs []error
+
+	if need.Has(InputManifests) {
+		opts := g.manifestOptions()
+		kind := workExtract
+		if opts.Hashes {
+			kind = workHash
+		}
+		done, err := g.limiter.acquire(ctx, kind)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		var m *manifest.Manifest
+		if g.sandbox != nil {
+			m, err = g.sandbox.Manifest(ctx, path, password, opts)
+		} else {
+			m, err = g.manifestExtractor.ExtractManifestWithOptions(ctx, path, password, opts)
+		}
+		if err != nil && g.sevenZip != nil && unreadableArchive(ctx, err) {
+			log.Printf("Warning: could not read archive of mod %s, listing with 7z: %v", mod.ModID, err)
+			m, err = g.listWithSevenZip(ctx, path, password)
+		}
+		mod.Timing.Extract += time.Since(start)
+		if err != nil {
+			mod.UnsupportedArchive = mod.UnsupportedArchive || unreadableArchive(ctx, err)
+			errs = append(errs, fmt.Errorf("extract manifest: %w", err))
+		} else {
+			mod.Manifest = m
+			if choices != nil {
+				if err := g.installChoices(ctx, mod, path, password, choices); err != nil {
+					errs = append(errs, fmt.Errorf("simulate installer: %w", err))
+				}
+			}
+		}
+		done()
+	}
+
+	if need.Has(InputPluginHeaders) {
+		advanceStage(ctx, StageParsing)
+		done, err := g.limiter.acquire(ctx, workParse)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		plugins, err := g.extractPlugins(ctx, path, password, &mod.Timing)
+		done()
+		if err != nil {
+			mod.UnsupportedArchive = mod.UnsupportedArchive || unreadableArchive(ctx, err)
+			errs = append(errs, fmt.Errorf("extract plugins: %w", err))
+		}
+		mod.Plugins = plugins
+	}
+
+	if need.Has(InputArchives) {
+		mod.ArchivePath = path
+		mod.Password = password
+	}
+
+	return errors.Join(errs...)
+}
+
+// verifyArchive compares a download's MD5 with the one Nexus published.
+// It returns nil when the comparison could not be made.
+func (g *Gatherer) verifyArchive(ctx context.Context, src Source, path string) *bool {
+	done, err := g.limiter.acquire(ctx, workHash)
+	if err != nil {
+		return nil
+	}
+	sum, err := fileMD5(path)
+	done()
+	if err != nil {
+		log.Printf("Warning: could not hash download of mod %s: %v", src.ModID, err)
+		return nil
+	}
+	ok, err := g.verifier.VerifyArchive(ctx, src, sum)
+	if err != nil {
+		log.Printf("Warning: could not verify download of mod %s: %v", src.ModID, err)
+		return nil
+	}
+	return &ok
+}
+
+// fileMD5 returns the hex-encoded MD5 hash of a file's contents.
+func fileMD5(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// manifestOptions returns what manifests read from archive contents.
+func (g *Gatherer) manifestOptions() manifest.Options {
+	deep := g.profile == ProfileDeep
+	return manifest.Options{Hashes: g.contentHashes || deep, Packed: deep}
+}
+
+// scanRecords collects the form IDs of the records in a plugin file,
+// leaving out its header record.
+func (g *Gatherer) scanRecords(ctx context.Context, path string) ([]uint32, error) {
+	var formIDs []uint32
+	err := g.parser.ScanFile(ctx, path, func(rec *plugin.Record) error {
+		if rec.Signature != plugin.SignatureTES4 {
+			formIDs = append(formIDs, rec.FormID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan records: %w", err)
+	}
+	return formIDs, nil
+}
+
+// listWithSevenZip builds a manifest from the external 7z listing.
+// Content hashes are not available this way.
+func (g *Gatherer) listWithSevenZip(ctx context.Context, path, password string) (*manifest.Manifest, error) {
+	files, err := g.sevenZip.List(ctx, path, password)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]manifest.FileEntry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, manifest.NewFileEntry(f.Name, f.Size))
+	}
+	return manifest.NewManifest(entries), nil
+}
+
+// unreadableArchive reports whether err means the archive format or one of its
+// compression methods is not supported, as opposed to a cancellation.
+func unreadableArchive(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, manifest.ErrUnsupportedFormat) ||
+		errors.Is(err, manifest.ErrExtractionFailed) ||
+		errors.Is(err, archive.ErrUnsupportedFormat) ||
+		errors.Is(err, archive.ErrExtractionFailed)
+}
+
+// extractPlugins extracts and parses all plugin files in an archive, adding
+// the time spent to timing.
+func (g *Gatherer) extractPlugins(ctx context.Context, archivePath, password string, timing *Timing) ([]loadorder.PluginFile, error) {
+	if g.sandbox != nil {
+		// The sandbox parses while it streams, so all of it counts as extraction
+		start := time.Now()
+		plugins, err := g.sandbox.PluginHeaders(ctx, archivePath, password, g.profile == ProfileDeep)
+		timing.Extract += time.Since(start)
+		return plugins, err
+	}
+	if g.extractor == nil {
+		return nil, errors.New("no extractor configured")
+	}
+
+	start := time.Now()
+	files, err := g.extractor.ListFiles(ctx, archivePath, password)
+	if err != nil {
+		timing.Extract += time.Since(start)
+		return nil, err
+	}
+
+	// Find all plugin files
+	var pluginPaths []string
+	for _, f := range files {
+		if plugin.IsPluginFile(f) {
+			pluginPaths = append(pluginPaths, f)
+		}
+	}
+
+	if len(pluginPaths) == 0 {
+		timing.Extract += time.Since(start)
+		return nil, nil
+	}
+
+	extractResult, err := g.extractor.ExtractPaths(ctx, archivePath, password, pluginPaths)
+	timing.Extract += time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	defer g.extractor.Cleanup(extractResult.OutputDir)
+
+	start = time.Now()
+	defer func() { timing.Parse += time.Since(start) }()
+
+	// Parse each plugin
+	var pluginFiles []loadorder.PluginFile
+	for _, extractedFile := range extractResult.Files {
+		extractedPath := filepath.Join(extractResult.OutputDir, extractedFile)
+		filename := filepath.Base(extractedFile)
+
+		pf := loadorder.PluginFile{
+			Filename: filename,
+		}
+
+		header, err := g.parser.ParseFile(ctx, extractedPath)
+		if err != nil {
+			log.Printf("Warning: could not parse plugin %s: %v", filename, err)
+		} else {
+			pf.Header = header
+		}
+		if err == nil && g.profile == ProfileDeep {
+			if pf.FormIDs, err = g.scanRecords(ctx, extractedPath); err != nil {
+				log.Printf("Warning: could not scan records of plugin %s: %v", filename, err)
+			}
+		}
+
+		pluginFiles = append(pluginFiles, pf)
+	}
+
+	return pluginFiles, nil
+}
+
+// pluginFilename prefers the mod file name over the downloaded file name.
+func pluginFilename(filename, path string) string {
+	if plugin.IsPluginFile(filename) {
+		return filename
+	}
+	return filepath.Base(path)
+}
+
+// wantsDownload reports whether a source can provide any of the needed inputs.
+// Sources without a filename are always downloaded and detected by content.
+func wantsDownload(src Source, need Input) bool {
+	if src.Filename == "" {
+		return true
+	}
+	if plugin.IsPluginFile(src.Filename) {
+		return need.Has(InputPluginHeaders)
+	}
+	return archive.IsArchiveFilename(strings.ToLower(src.Filename))
+}
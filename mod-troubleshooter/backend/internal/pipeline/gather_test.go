@@ -0,0 +1,845 @@
+package pipeline
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// fakeFetcher serves files from a map of mod ID to local path.
+type fakeFetcher struct {
+	paths    map[string]string
+	fetched  []string
+	released []string
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	f.fetched = append(f.fetched, src.ModID)
+	path, ok := f.paths[src.ModID]
+	if !ok {
+		return "", errors.New("no download links available")
+	}
+	return path, nil
+}
+
+func (f *fakeFetcher) Release(path string) {
+	f.released = append(f.released, path)
+}
+
+func createZip(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for fileName, content := range files {
+		w, err := zw.Create(fileName)
+		if err != nil {
+			t.Fatalf("failed to add zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to finalize zip: %v", err)
+	}
+	return path
+}
+
+func TestGatherer_Manifests(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+		"b": createZip(t, dir, "b.zip", map[string]string{"textures/x.dds": "two", "meshes/y.nif": "three"}),
+	}}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	sources := []Source{
+		{ModID: "a", LoadOrder: 0, Filename: "a.zip"},
+		{ModID: "loose", LoadOrder: 1, Filename: "Loose.esp"},
+		{ModID: "missing", LoadOrder: 2, Filename: "missing.7z"},
+		{ModID: "b", LoadOrder: 3, Filename: "b.zip"},
+	}
+
+	in, release, err := g.Gather(context.Background(), sources, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if len(in.Mods) != 4 {
+		t.Fatalf("expected 4 mods, got %d", len(in.Mods))
+	}
+	for _, id := range fetcher.fetched {
+		if id == "loose" {
+			t.Error("expected loose plugin not to be downloaded when only manifests are needed")
+		}
+	}
+	if in.Mods[0].Manifest == nil || in.Mods[0].Manifest.TotalCount != 1 {
+		t.Errorf("expected manifest with 1 file for mod a, got %+v", in.Mods[0].Manifest)
+	}
+	if in.Mods[3].Manifest == nil || in.Mods[3].Manifest.TotalCount != 2 {
+		t.Errorf("expected manifest with 2 files for mod b, got %+v", in.Mods[3].Manifest)
+	}
+	if in.Mods[2].Error == "" {
+		t.Error("expected download error to be recorded for missing mod")
+	}
+	if in.Mods[0].ArchivePath != "" {
+		t.Error("expected archive path to be empty when archives are not requested")
+	}
+	if len(fetcher.released) != 2 {
+		t.Errorf("expected 2 downloads released, got %d", len(fetcher.released))
+	}
+
+	if got := ModManifests(in); len(got) != 2 {
+		t.Errorf("expected 2 mod manifests, got %d", len(got))
+	}
+}
+
+func TestGatherer_KeepsArchivesUntilRelease(t *testing.T) {
+	dir := t.TempDir()
+	path := createZip(t, dir, "a.zip", map[string]string{"readme.txt": "hi"})
+	fetcher := &fakeFetcher{paths: map[string]string{"a": path}}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", Filename: "a.zip"}}, InputManifests|InputArchives)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if in.Mods[0].ArchivePath != path {
+		t.Errorf("expected archive path %s, got %s", path, in.Mods[0].ArchivePath)
+	}
+	if len(fetcher.released) != 0 {
+		t.Error("expected archive to be kept until release")
+	}
+
+	release()
+	if len(fetcher.released) != 1 {
+		t.Errorf("expected archive released, got %d releases", len(fetcher.released))
+	}
+}
+
+func TestGatherer_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g := NewGatherer(GathererConfig{Fetcher: &fakeFetcher{}})
+	if _, _, err := g.Gather(ctx, []Source{{ModID: "a"}}, InputManifests); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGatherer_Progress(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+	}}
+
+	var got []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) { got = append(got, p) })
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	sources := []Source{
+		{ModID: "loose", ModName: "Loose", Filename: "Loose.esp"},
+		{ModID: "a", ModName: "Armors", Filename: "a.zip"},
+	}
+	_, release, err := g.Gather(ctx, sources, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	want := []Progress{
+		{Stage: StageDownloading, ModsDone: 1, ModsTotal: 2, CurrentMod: "Armors"},
+		{Stage: StageExtracting, ModsDone: 1, ModsTotal: 2, CurrentMod: "Armors"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected progress %v, got %v", want, got)
+	}
+}
+
+// reportingFetcher reports the bytes of its downloads, as a real one would.
+type reportingFetcher struct {
+	fakeFetcher
+}
+
+func (f *reportingFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	if report := DownloadProgress(ctx); report != nil {
+		report(1, 3)
+		report(2, 3) // throttled
+		report(3, 3)
+	}
+	return f.fakeFetcher.Fetch(ctx, src)
+}
+
+func TestGatherer_DownloadProgress(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &reportingFetcher{fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+	}}}
+
+	var got []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) { got = append(got, p) })
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	_, release, err := g.Gather(ctx, []Source{{ModID: "a", ModName: "Armors", Filename: "a.zip"}}, InputManifests|InputPluginHeaders)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	want := []Progress{
+		{Stage: StageDownloading, ModsTotal: 1, CurrentMod: "Armors"},
+		{Stage: StageDownloading, ModsTotal: 1, CurrentMod: "Armors", BytesDone: 1, BytesTotal: 3},
+		{Stage: StageDownloading, ModsTotal: 1, CurrentMod: "Armors", BytesDone: 3, BytesTotal: 3},
+		{Stage: StageExtracting, ModsTotal: 1, CurrentMod: "Armors"},
+		{Stage: StageParsing, ModsTotal: 1, CurrentMod: "Armors"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected progress %v, got %v", want, got)
+	}
+
+	if DownloadProgress(context.Background()) != nil {
+		t.Error("expected no download progress without a progress func")
+	}
+}
+
+// unavailableFetcher reports every file as deleted on Nexus.
+type unavailableFetcher struct{}
+
+func (unavailableFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	return "", fmt.Errorf("%w: resource not found", ErrUnavailable)
+}
+
+func (unavailableFetcher) Release(path string) {}
+
+func TestGatherer_Unavailable(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "hidden", Unavailable: "mod is hidden on Nexus"}}, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if len(fetcher.fetched) != 0 {
+		t.Errorf("expected unavailable source not to be fetched, got %v", fetcher.fetched)
+	}
+	if !in.Mods[0].Unavailable || in.Mods[0].Error != "mod is hidden on Nexus" {
+		t.Errorf("expected mod marked unavailable, got %+v", in.Mods[0])
+	}
+
+	g = NewGatherer(GathererConfig{Fetcher: unavailableFetcher{}})
+	in, release, err = g.Gather(context.Background(), []Source{{ModID: "deleted", Filename: "deleted.7z"}}, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if !in.Mods[0].Unavailable {
+		t.Errorf("expected fetch error to mark mod unavailable, got %+v", in.Mods[0])
+	}
+}
+
+// fakeSandbox answers every archive with a fixed listing and plugin.
+type fakeSandbox struct {
+	opts    []manifest.Options
+	records []bool
+}
+
+func (s *fakeSandbox) Manifest(ctx context.Context, path, password string, opts manifest.Options) (*manifest.Manifest, error) {
+	s.opts = append(s.opts, opts)
+	return manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("sandboxed.esp", 1)}), nil
+}
+
+func (s *fakeSandbox) PluginHeaders(ctx context.Context, path, password string, records bool) ([]loadorder.PluginFile, error) {
+	s.records = append(s.records, records)
+	return []loadorder.PluginFile{{Filename: "sandboxed.esp"}}, nil
+}
+
+func TestGatherer_Sandbox(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"a.esp": "x"}),
+	}}
+	sandbox := &fakeSandbox{}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Sandbox: sandbox, ContentHashes: true})
+
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", Filename: "a.zip"}}, InputManifests|InputPluginHeaders)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	mod := in.Mods[0]
+	if mod.Manifest == nil || mod.Manifest.Files[0].Path != "sandboxed.esp" || len(mod.Plugins) != 1 || mod.Plugins[0].Filename != "sandboxed.esp" {
+		t.Errorf("expected inputs read by the sandbox, got %+v", mod)
+	}
+	if len(sandbox.opts) != 1 || sandbox.opts[0] != (manifest.Options{Hashes: true}) {
+		t.Errorf("expected content hashes to be requested, got %v", sandbox.opts)
+	}
+	if len(sandbox.records) != 1 || sandbox.records[0] {
+		t.Errorf("expected plugin records not to be scanned, got %v", sandbox.records)
+	}
+}
+
+// infectedFetcher reports every download as flagged by the virus scanner.
+type infectedFetcher struct{}
+
+func (infectedFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	return "", fmt.Errorf("download: %w: Eicar-Test-Signature in %s", archive.ErrInfected, src.Filename)
+}
+
+func (infectedFetcher) Release(path string) {}
+
+func TestGatherer_Infected(t *testing.T) {
+	g := NewGatherer(GathererConfig{Fetcher: infectedFetcher{}})
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", ModName: "Free Gold", Filename: "a.7z"}}, InputManifests)
+	if err != nil {
+		t.Fatalf("expected the run to continue, got %v", err)
+	}
+	release()
+
+	if !in.Mods[0].Infected || in.Mods[0].Unavailable {
+		t.Errorf("expected mod marked infected, got %+v", in.Mods[0])
+	}
+	warnings := in.Warnings()
+	if len(warnings) != 1 || warnings[0].Type != WarningInfected || warnings[0].ModID != "a" {
+		t.Errorf("expected infected warning, got %+v", warnings)
+	}
+}
+
+type downFetcher struct{ calls int }
+
+func (f *downFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	f.calls++
+	return "", fmt.Errorf("%w: nexus is degraded", ErrSourceDown)
+}
+
+func (f *downFetcher) Release(path string) {}
+
+func TestGatherer_SourceDown(t *testing.T) {
+	fetcher := &downFetcher{}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	sources := []Source{{ModID: "a", Filename: "a.7z"}, {ModID: "b", Filename: "b.7z"}}
+
+	_, release, err := g.Gather(context.Background(), sources, InputManifests)
+	release()
+	if !errors.Is(err, ErrSourceDown) {
+		t.Errorf("expected ErrSourceDown, got %v", err)
+	}
+	if fetcher.calls != 1 {
+		t.Errorf("expected gather to stop after the first failure, got %d fetches", fetcher.calls)
+	}
+}
+
+// heldFetcher holds every download until release is closed.
+type heldFetcher struct {
+	started chan string
+	release chan struct{}
+}
+
+func (f *heldFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	f.started <- src.ModID
+	<-f.release
+	return "", errors.New("no download links available")
+}
+
+func (f *heldFetcher) Release(path string) {}
+
+func TestGatherer_Concurrent(t *testing.T) {
+	fetcher := &heldFetcher{started: make(chan string), release: make(chan struct{})}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Limiter: NewLimiter(Concurrency{Downloads: 3})})
+	sources := []Source{
+		{ModID: "a", Filename: "a.7z"},
+		{ModID: "b", Filename: "b.7z"},
+		{ModID: "c", Filename: "c.7z"},
+		{ModID: "d", Filename: "d.7z"},
+	}
+
+	type result struct {
+		in  *Inputs
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		in, release, err := g.Gather(context.Background(), sources, InputManifests)
+		release()
+		done <- result{in, err}
+	}()
+
+	// Three downloads run at once; the fourth waits for a free worker
+	for i := 0; i < 3; i++ {
+		<-fetcher.started
+	}
+	select {
+	case id := <-fetcher.started:
+		t.Fatalf("expected at most 3 downloads at once, %s started too", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(fetcher.release)
+	<-fetcher.started
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("unexpected error: %v", r.err)
+	}
+	for i, mod := range r.in.Mods {
+		if mod.ModID != sources[i].ModID {
+			t.Errorf("expected mod %d to be %s, got %s", i, sources[i].ModID, mod.ModID)
+		}
+		// Each failure is recorded on its mod without stopping the others
+		if mod.Error == "" {
+			t.Errorf("expected mod %s to record its download error", mod.ModID)
+		}
+	}
+}
+
+// createBroken7z writes a file with a 7z signature that no extractor can read.
+func createBroken7z(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := append([]byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C, 0, 4}, make([]byte, 64)...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	return path
+}
+
+func TestGatherer_UnsupportedArchive(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"ok":  createZip(t, dir, "ok.zip", map[string]string{"a.esp": "x"}),
+		"bad": createBroken7z(t, dir, "bad.7z"),
+	}}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+	sources := []Source{
+		{ModID: "ok", ModName: "Fine Mod", Filename: "ok.zip"},
+		{ModID: "bad", ModName: "Odd Mod", Filename: "bad.7z"},
+	}
+
+	in, release, err := g.Gather(context.Background(), sources, InputManifests)
+	if err != nil {
+		t.Fatalf("expected the run to continue, got %v", err)
+	}
+	release()
+
+	if in.Mods[0].UnsupportedArchive || in.Mods[0].Manifest == nil {
+		t.Errorf("expected readable archive to be gathered, got %+v", in.Mods[0])
+	}
+	if !in.Mods[1].UnsupportedArchive || in.Mods[1].Error == "" {
+		t.Errorf("expected unreadable archive to be flagged, got %+v", in.Mods[1])
+	}
+
+	warnings := in.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Type != WarningUnsupportedArchive || warnings[0].ModID != "bad" {
+		t.Errorf("unexpected warning: %+v", warnings[0])
+	}
+	if warnings[0].Message != "Unsupported archive Odd Mod, analysis partial" {
+		t.Errorf("unexpected warning message: %q", warnings[0].Message)
+	}
+}
+
+func TestGatherer_SevenZipFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake 7z binary")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "7z")
+	listing := "----------\nPath = Data\\Odd.esp\nSize = 10\n\nPath = textures\\odd.dds\nSize = 20\n"
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat <<'EOF'\n"+listing+"EOF\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	sz, err := archive.NewSevenZip(script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fetcher := &fakeFetcher{paths: map[string]string{"bad": createBroken7z(t, dir, "bad.7z")}}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, SevenZip: sz})
+
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "bad", Filename: "bad.7z"}}, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	mod := in.Mods[0]
+	if mod.UnsupportedArchive || mod.Error != "" {
+		t.Errorf("expected fallback listing to succeed, got %+v", mod)
+	}
+	if mod.Manifest == nil || mod.Manifest.TotalCount != 2 {
+		t.Fatalf("expected manifest with 2 files, got %+v", mod.Manifest)
+	}
+	if len(in.Warnings()) != 0 {
+		t.Errorf("expected no warnings, got %+v", in.Warnings())
+	}
+}
+
+// fakePreviewer serves manifests for the mods it has previews of.
+type fakePreviewer struct {
+	previews map[string][]string
+}
+
+func (p *fakePreviewer) Preview(ctx context.Context, src Source) (*manifest.Manifest, error) {
+	paths, ok := p.previews[src.ModID]
+	if !ok {
+		return nil, errors.New("no content preview available")
+	}
+	entries := make([]manifest.FileEntry, 0, len(paths))
+	for _, path := range paths {
+		entries = append(entries, manifest.NewFileEntry(path, 1))
+	}
+	return manifest.NewManifest(entries), nil
+}
+
+// memoryStore is a ManifestStore that keeps JSON in memory, like the cache.
+type memoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string, dest interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return errors.New("not found")
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (s *memoryStore) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = data
+	return nil
+}
+
+func TestGatherer_ManifestStore(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+		"b": createZip(t, dir, "b.zip", map[string]string{"meshes/y.nif": "two"}),
+	}}
+	store := &memoryStore{data: make(map[string][]byte)}
+	sources := []Source{
+		{ModID: "a", Filename: "a.zip", Game: "skyrimspecialedition", NexusModID: 1, FileID: 10},
+		// Without a file ID the listing cannot be told apart from other files
+		{ModID: "b", Filename: "b.zip", Game: "skyrimspecialedition", NexusModID: 2},
+	}
+	gather := func(cfg GathererConfig, need Input) *Inputs {
+		t.Helper()
+		fetcher.fetched = nil
+		in, release, err := NewGatherer(cfg).Gather(context.Background(), sources, need)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release()
+		return in
+	}
+	cfg := GathererConfig{Fetcher: fetcher, Manifests: store, ManifestTTL: time.Hour}
+
+	gather(cfg, InputManifests)
+	if len(fetcher.fetched) != 2 {
+		t.Fatalf("expected both mods downloaded the first time, got %v", fetcher.fetched)
+	}
+	if _, ok := store.data["modmanifest:skyrimspecialedition:1:10:false"]; !ok || len(store.data) != 1 {
+		t.Fatalf("expected only mod a's listing stored, got %d entries", len(store.data))
+	}
+
+	in := gather(cfg, InputManifests)
+	if len(fetcher.fetched) != 1 || fetcher.fetched[0] != "b" {
+		t.Errorf("expected only mod b downloaded again, got %v", fetcher.fetched)
+	}
+	if mod := in.Mods[0]; !mod.FromStore || mod.Manifest == nil || mod.Manifest.TotalCount != 1 {
+		t.Errorf("expected mod a listed from the store, got %+v", mod)
+	}
+
+	// Listings with content hashes are kept apart from those without
+	cfg.ContentHashes = true
+	gather(cfg, InputManifests)
+	if len(fetcher.fetched) != 2 {
+		t.Errorf("expected a download for content hashes, got %v", fetcher.fetched)
+	}
+
+	// Other inputs still need the download
+	cfg.ContentHashes = false
+	in = gather(cfg, InputManifests|InputPluginHeaders)
+	if len(fetcher.fetched) != 2 || !in.Mods[0].FromStore {
+		t.Errorf("expected both downloaded for plugins with a's listing stored, got %v", fetcher.fetched)
+	}
+}
+
+func TestGatherer_Previews(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+		"b": createZip(t, dir, "b.zip", map[string]string{"meshes/y.nif": "two"}),
+	}}
+	previewer := &fakePreviewer{previews: map[string][]string{
+		"a": {"textures/x.dds", "textures/z.dds"},
+	}}
+	sources := []Source{
+		{ModID: "a", Filename: "a.zip"},
+		{ModID: "b", Filename: "b.zip"},
+	}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Previewer: previewer})
+	in, release, err := g.Gather(context.Background(), sources, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if len(fetcher.fetched) != 1 || fetcher.fetched[0] != "b" {
+		t.Errorf("expected only the mod without a preview to be downloaded, got %v", fetcher.fetched)
+	}
+	if !in.Mods[0].FromPreview || in.Mods[0].Manifest.TotalCount != 2 {
+		t.Errorf("expected mod a listed from its preview, got %+v", in.Mods[0])
+	}
+	if in.Mods[1].FromPreview || in.Mods[1].Manifest == nil || in.Mods[1].Manifest.TotalCount != 1 {
+		t.Errorf("expected mod b listed from its archive, got %+v", in.Mods[1])
+	}
+
+	// Previews have no content hashes or plugins, so those inputs download
+	fetcher.fetched = nil
+	g = NewGatherer(GathererConfig{Fetcher: fetcher, Previewer: previewer, ContentHashes: true})
+	if _, release, err = g.Gather(context.Background(), sources, InputManifests); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	g = NewGatherer(GathererConfig{Fetcher: fetcher, Previewer: previewer})
+	if _, release, err = g.Gather(context.Background(), sources, InputManifests|InputPluginHeaders); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if len(fetcher.fetched) != 4 {
+		t.Errorf("expected every mod downloaded when previews cannot be used, got %v", fetcher.fetched)
+	}
+}
+
+// writePlugin writes a plugin with a single master and the given records.
+func writePlugin(t *testing.T, dir, name string, formIDs ...uint32) string {
+	t.Helper()
+	mast := append([]byte("MAST"), 0, 0)
+	mast = append(mast, "Skyrim.esm\x00"...)
+	binary.LittleEndian.PutUint16(mast[4:6], uint16(len(mast)-6))
+	data := append([]byte("TES4"), make([]byte, 20)...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(mast)))
+	data = append(data, mast...)
+	for _, id := range formIDs {
+		record := append([]byte("NPC_"), make([]byte, 20)...)
+		binary.LittleEndian.PutUint32(record[12:16], id)
+		data = append(data, record...)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return path
+}
+
+func TestGatherer_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+		"b": writePlugin(t, dir, "b.esp", 0x00012E46, 0x01000800),
+	}}
+	previewer := &fakePreviewer{previews: map[string][]string{
+		"a": {"textures/x.dds", "textures/z.dds"},
+	}}
+	sources := []Source{
+		{ModID: "a", Filename: "a.zip"},
+		{ModID: "b", Filename: "b.esp"},
+	}
+
+	extractor, err := archive.NewExtractor(archive.ExtractorConfig{TempDir: dir})
+	if err != nil {
+		t.Fatalf("failed to create extractor: %v", err)
+	}
+
+	// Quick analyses take manifests from previews, downloading for the rest
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Previewer: previewer, Extractor: extractor, Profile: ProfileQuick})
+	in, release, err := g.Gather(context.Background(), sources, InputManifests|InputPluginHeaders)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if !in.Mods[0].FromPreview || in.Mods[0].Manifest.TotalCount != 2 || len(fetcher.fetched) != 2 {
+		t.Errorf("expected manifest from the preview and both mods downloaded, got %+v (fetched %v)", in.Mods[0], fetcher.fetched)
+	}
+	if plugins := in.Mods[1].Plugins; len(plugins) != 1 || plugins[0].Header == nil || plugins[0].FormIDs != nil {
+		t.Errorf("expected the plugin header only, got %+v", plugins)
+	}
+
+	// Deep analyses never use previews, and scan plugin records
+	g = NewGatherer(GathererConfig{Fetcher: fetcher, Previewer: previewer, Extractor: extractor, Profile: ProfileDeep})
+	in, release, err = g.Gather(context.Background(), sources, ProfileDeep.Inputs(InputManifests))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if m := in.Mods[0].Manifest; in.Mods[0].FromPreview || m == nil || !manifest.HasContentHash(m.Files[0]) {
+		t.Errorf("expected a hashed manifest from the archive, got %+v", in.Mods[0])
+	}
+	if plugins := in.Mods[1].Plugins; len(plugins) != 1 || len(plugins[0].FormIDs) != 2 || plugins[0].FormIDs[0] != 0x00012E46 {
+		t.Errorf("expected the plugin's records scanned, got %+v", plugins)
+	}
+
+	sandbox := &fakeSandbox{}
+	g = NewGatherer(GathererConfig{Fetcher: fetcher, Sandbox: sandbox, Profile: ProfileDeep})
+	if _, release, err = g.Gather(context.Background(), sources[:1], ProfileDeep.Inputs(InputManifests)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if len(sandbox.opts) != 1 || sandbox.opts[0] != (manifest.Options{Hashes: true, Packed: true}) || len(sandbox.records) != 1 || !sandbox.records[0] {
+		t.Errorf("expected the sandbox asked for a deep read, got %v and %v", sandbox.opts, sandbox.records)
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		want Profile
+	}{
+		{"", ProfileStandard},
+		{"quick", ProfileQuick},
+		{" Deep ", ProfileDeep},
+	}
+	for _, tt := range tests {
+		if got, err := ParseProfile(tt.name); err != nil || got != tt.want {
+			t.Errorf("ParseProfile(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := ParseProfile("thorough"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+}
+
+// fakeBugReporter serves bug report titles by Nexus mod ID.
+type fakeBugReporter map[int][]string
+
+func (r fakeBugReporter) BugReports(ctx context.Context, src Source) ([]string, error) {
+	return r[src.NexusModID], nil
+}
+
+func TestGatherer_BugReports(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+	}}
+	reporter := fakeBugReporter{1: {"CTD when opening the map", "Typo in readme"}}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, BugReporter: reporter})
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", ModName: "Maps", Filename: "a.zip", NexusModID: 1}}, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if len(in.Mods[0].BugReports) != 2 {
+		t.Fatalf("expected 2 bug reports, got %v", in.Mods[0].BugReports)
+	}
+
+	report, err := NewHealthStage().AnalyzeHealth(context.Background(), in)
+	if err != nil {
+		t.Fatalf("AnalyzeHealth() error = %v", err)
+	}
+	var hints int
+	for _, f := range report.Findings {
+		if f.Type == health.FindingCommunityReport {
+			hints++
+		}
+	}
+	if hints != 1 {
+		t.Errorf("expected 1 community report hint, got %+v", report.Findings)
+	}
+}
+
+// fakeVerifier accepts the MD5 hashes it lists for each Nexus mod ID.
+type fakeVerifier map[int]string
+
+func (v fakeVerifier) VerifyArchive(ctx context.Context, src Source, md5Hash string) (bool, error) {
+	return v[src.NexusModID] == md5Hash, nil
+}
+
+func TestGatherer_VerifyArchive(t *testing.T) {
+	dir := t.TempDir()
+	good := createZip(t, dir, "good.zip", map[string]string{"textures/x.dds": "one"})
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"good": good,
+		"bad":  createZip(t, dir, "bad.zip", map[string]string{"textures/y.dds": "two"}),
+	}}
+	sum, err := fileMD5(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := fakeVerifier{1: sum, 2: "d41d8cd98f00b204e9800998ecf8427e"}
+	sources := []Source{
+		{ModID: "good", Filename: "good.zip", NexusModID: 1},
+		{ModID: "bad", Filename: "bad.zip", NexusModID: 2},
+	}
+
+	// Downloads are only verified when content hashes are computed
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Verifier: verifier})
+	in, release, err := g.Gather(context.Background(), sources, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if in.Mods[0].ArchiveVerified != nil {
+		t.Errorf("expected no verification without content hashes")
+	}
+
+	g = NewGatherer(GathererConfig{Fetcher: fetcher, Verifier: verifier, ContentHashes: true})
+	in, release, err = g.Gather(context.Background(), sources, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	result, err := NewConflictStage().AnalyzeConflicts(context.Background(), in)
+	if err != nil {
+		t.Fatalf("AnalyzeConflicts() error = %v", err)
+	}
+	want := map[string]bool{"good": true, "bad": false}
+	for _, summary := range result.ModSummaries {
+		if summary.ArchiveVerified == nil || *summary.ArchiveVerified != want[summary.ModID] {
+			t.Errorf("mod %s: expected verified %v, got %v", summary.ModID, want[summary.ModID], summary.ArchiveVerified)
+		}
+	}
+	if len(result.ModSummaries) != 2 {
+		t.Errorf("expected 2 mod summaries, got %d", len(result.ModSummaries))
+	}
+}
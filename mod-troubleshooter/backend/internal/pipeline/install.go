@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// installChoices replaces the manifest of a mod with a FOMOD installer by
+// the files the installer places with the given choices, so files of
+// options that were never picked don't show up as conflicts. Mods without
+// an installer keep their archive listing.
+func (g *Gatherer) installChoices(ctx context.Context, mod *Mod, archivePath, password string, choices *fomod.Choices) error {
+	root, configPath, ok := fomodRoot(mod.Manifest)
+	if !ok {
+		return nil
+	}
+	if g.extractor == nil {
+		return errors.New("no extractor configured")
+	}
+
+	data, err := g.extractor.ReadFile(ctx, archivePath, password, configPath, fomod.MaxXMLSize)
+	if err != nil {
+		return fmt.Errorf("read installer: %w", err)
+	}
+	config, _, err := fomod.ParseModuleConfigLenient(data)
+	if err != nil {
+		return fmt.Errorf("parse installer: %w", err)
+	}
+
+	mod.Manifest, mod.Optional = installedManifest(mod.Manifest, root, fomod.Simulate(config, choices))
+	mod.Installed = true
+	return nil
+}
+
+// fomodRoot finds the installer in an archive listing. It returns the
+// directory installer paths are relative to, with a trailing slash unless
+// it is the archive root, and the archive path of ModuleConfig.xml.
+func fomodRoot(m *manifest.Manifest) (root, configPath string, ok bool) {
+	const config = "fomod/moduleconfig.xml"
+	for _, entry := range m.Files {
+		if entry.Archive != "" || (entry.Path != config && !strings.HasSuffix(entry.Path, "/"+config)) {
+			continue
+		}
+		// The shallowest installer wins over ones nested in sub-packages
+		r := strings.TrimSuffix(entry.Path, config)
+		if !ok || len(r) < len(root) {
+			root, configPath, ok = r, entry.OriginalPath, true
+		}
+	}
+	return root, configPath, ok
+}
+
+// installedManifest lists the files of an archive where an installation
+// places them. Files the installation leaves out are dropped, as is the
+// installer itself; a destination written more than once keeps the file
+// copied there last, which follows the installer's priorities rather than
+// the archive order.
+//
+// It also returns the paths only some choices install, with the choice of
+// the copy that wins. A path any unconditional copy lands on is left out.
+func installedManifest(m *manifest.Manifest, root string, inst *fomod.Installation) (*manifest.Manifest, map[string]*fomod.Condition) {
+	var entries []manifest.FileEntry
+	var conds []*fomod.Condition
+	type slot struct{ index, order int }
+	slots := make(map[string]slot)
+	add := func(entry manifest.FileEntry, p fomod.Placement) {
+		key := entry.Archive + "\x00" + entry.Path
+		if s, ok := slots[key]; ok {
+			if conds[s.index] != nil && p.Condition == nil {
+				conds[s.index] = nil
+			}
+			if p.Order >= s.order {
+				entries[s.index] = entry
+				if conds[s.index] != nil {
+					conds[s.index] = p.Condition
+				}
+				slots[key] = slot{s.index, p.Order}
+			}
+			return
+		}
+		slots[key] = slot{len(entries), p.Order}
+		entries = append(entries, entry)
+		conds = append(conds, p.Condition)
+	}
+
+	for _, entry := range m.Files {
+		if entry.Archive != "" {
+			// Packed files move with the archive they are packed in
+			container, ok := installerPath(entry.Archive, root)
+			if !ok {
+				continue
+			}
+			inner := strings.TrimPrefix(entry.Path, dirPrefix(entry.Archive))
+			for _, p := range inst.Placements(container) {
+				moved := relocate(entry, path.Join(path.Dir(p.Destination), inner))
+				moved.Archive = manifest.NormalizePath(p.Destination)
+				add(moved, p)
+			}
+			continue
+		}
+
+		rel, ok := installerPath(entry.Path, root)
+		if !ok || strings.HasPrefix(rel, "fomod/") {
+			continue
+		}
+		for _, p := range inst.Placements(rel) {
+			add(relocate(entry, p.Destination), p)
+		}
+	}
+
+	// A path is only optional if every copy of it is, loose or packed
+	optional := make(map[string]*fomod.Condition)
+	always := make(map[string]bool)
+	for i, entry := range entries {
+		switch {
+		case conds[i] == nil:
+			always[entry.Path] = true
+		case optional[entry.Path] == nil:
+			optional[entry.Path] = conds[i]
+		}
+	}
+	for p := range always {
+		delete(optional, p)
+	}
+	if len(optional) == 0 {
+		optional = nil
+	}
+	return manifest.NewManifest(entries), optional
+}
+
+// installerPath returns an archive path relative to the installer root, and
+// false if it lies outside it.
+func installerPath(p, root string) (string, bool) {
+	if !strings.HasPrefix(p, root) {
+		return "", false
+	}
+	return strings.TrimPrefix(p, root), true
+}
+
+// dirPrefix returns the directory of a normalized path with a trailing
+// slash, or "" for files at the root.
+func dirPrefix(p string) string {
+	if dir := path.Dir(p); dir != "." {
+		return dir + "/"
+	}
+	return ""
+}
+
+// relocate moves a manifest entry to a new path, keeping its content hash
+// if it has one.
+func relocate(entry manifest.FileEntry, dest string) manifest.FileEntry {
+	moved := manifest.NewFileEntry(dest, entry.Size)
+	if entry.Hash != manifest.ComputePathHash(entry.Path) {
+		moved.Hash = entry.Hash
+	}
+	moved.Archive = entry.Archive
+	return moved
+}
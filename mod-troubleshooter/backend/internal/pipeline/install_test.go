@@ -0,0 +1,202 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+const choicesModuleConfig = `<config>
+  <moduleName>Choices</moduleName>
+  <installSteps order="Explicit">
+    <installStep name="Main">
+      <optionalFileGroups>
+        <group name="Style" type="SelectExactlyOne">
+          <plugins>
+            <plugin name="Dark">
+              <description/>
+              <files><folder source="dark" destination=""/></files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+            <plugin name="Light">
+              <description/>
+              <files><folder source="light" destination=""/></files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+</config>`
+
+func TestGatherer_InstallerChoices(t *testing.T) {
+	dir := t.TempDir()
+	extractor, err := archive.NewExtractor(archive.ExtractorConfig{TempDir: dir})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{
+			"Mod/fomod/ModuleConfig.xml":  choicesModuleConfig,
+			"Mod/dark/interface/menu.swf": "dark",
+			"Mod/light/interface/map.swf": "light",
+		}),
+	}}
+
+	choices := &fomod.Choices{Type: "fomod", Options: []fomod.StepChoice{{
+		Name:   "Main",
+		Groups: []fomod.GroupChoice{{Name: "Style", Choices: []fomod.OptionChoice{{Name: "Dark", Idx: 0}}}},
+	}}}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Extractor: extractor})
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", Filename: "a.zip", Choices: choices}}, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	mod := in.Mods[0]
+	if mod.Error != "" {
+		t.Fatalf("unexpected mod error: %s", mod.Error)
+	}
+	if !mod.Installed {
+		t.Error("expected the manifest to come from the installer")
+	}
+	if mod.Manifest.TotalCount != 1 || !mod.Manifest.HasFile("interface/menu.swf") {
+		t.Errorf("expected only the dark style's files, got %+v", mod.Manifest.Files)
+	}
+}
+
+func TestGatherer_InstallerChoicesWithoutInstaller(t *testing.T) {
+	dir := t.TempDir()
+	extractor, err := archive.NewExtractor(archive.ExtractorConfig{TempDir: dir})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"textures/x.dds": "one"}),
+	}}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Extractor: extractor})
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", Filename: "a.zip", Choices: &fomod.Choices{}}}, InputManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if mod := in.Mods[0]; mod.Installed || mod.Manifest.TotalCount != 1 {
+		t.Errorf("expected the archive listing to be kept, got %+v", mod)
+	}
+}
+
+func TestInstalledManifest_Priority(t *testing.T) {
+	config, err := fomod.ParseModuleConfigFromReader(strings.NewReader(`<config>
+  <moduleName>Priority</moduleName>
+  <requiredInstallFiles>
+    <folder source="patched" destination="" priority="1"/>
+    <folder source="base" destination=""/>
+  </requiredInstallFiles>
+</config>`))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+	m := manifest.NewManifest([]manifest.FileEntry{
+		manifest.NewFileEntry("fomod/ModuleConfig.xml", 10),
+		manifest.NewFileEntry("patched/a.esp", 2),
+		manifest.NewFileEntry("base/a.esp", 1),
+	})
+
+	got, optional := installedManifest(m, "", fomod.Simulate(config, nil))
+	if optional != nil {
+		t.Errorf("expected required files not to be optional, got %v", optional)
+	}
+	if got.TotalCount != 1 || got.Files[0].Path != "a.esp" || got.Files[0].Size != 2 {
+		t.Errorf("expected the higher priority a.esp to win, got %+v", got.Files)
+	}
+}
+
+func TestInstalledManifest_Optional(t *testing.T) {
+	config, err := fomod.ParseModuleConfigFromReader(strings.NewReader(`<config>
+  <moduleName>Optional</moduleName>
+  <requiredInstallFiles><folder source="base" destination=""/></requiredInstallFiles>
+  <installSteps order="Explicit">
+    <installStep name="Textures">
+      <optionalFileGroups>
+        <group name="Resolution" type="SelectAny">
+          <plugins>
+            <plugin name="4K">
+              <description/>
+              <files><folder source="4k" destination="" priority="1"/></files>
+              <typeDescriptor><type name="Optional"/></typeDescriptor>
+            </plugin>
+          </plugins>
+        </group>
+      </optionalFileGroups>
+    </installStep>
+  </installSteps>
+</config>`))
+	if err != nil {
+		t.Fatalf("ParseModuleConfigFromReader() error = %v", err)
+	}
+	m := manifest.NewManifest([]manifest.FileEntry{
+		manifest.NewFileEntry("fomod/ModuleConfig.xml", 10),
+		manifest.NewFileEntry("base/textures/a.dds", 1),
+		manifest.NewFileEntry("4k/textures/a.dds", 4),
+		manifest.NewFileEntry("4k/textures/b.dds", 4),
+	})
+	choices := &fomod.Choices{Options: []fomod.StepChoice{{
+		Name:   "Textures",
+		Groups: []fomod.GroupChoice{{Name: "Resolution", Choices: []fomod.OptionChoice{{Name: "4K"}}}},
+	}}}
+
+	_, optional := installedManifest(m, "", fomod.Simulate(config, choices))
+	// a.dds is installed either way; only its content depends on the choice
+	if _, ok := optional["textures/a.dds"]; ok {
+		t.Error("expected a file the base also installs not to be optional")
+	}
+	cond := optional["textures/b.dds"]
+	if cond == nil || len(cond.Options) != 1 || cond.Options[0].Option != "4K" {
+		t.Errorf("expected b.dds to depend on the 4K option, got %+v", cond)
+	}
+}
+
+func TestConflictStage_MarksOptional(t *testing.T) {
+	cond := &fomod.Condition{Options: []fomod.OptionRef{{Step: "Textures", Group: "Resolution", Option: "4K"}}}
+	in := &Inputs{Mods: []Mod{
+		{
+			ModID:    "base",
+			Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/a.dds", 1), manifest.NewFileEntry("meshes/a.nif", 1)}),
+		},
+		{
+			ModID:    "hd",
+			Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/a.dds", 4), manifest.NewFileEntry("meshes/a.nif", 2)}),
+			Optional: map[string]*fomod.Condition{"textures/a.dds": cond},
+		},
+	}}
+
+	result, err := NewConflictStage().AnalyzeConflicts(context.Background(), in)
+	if err != nil {
+		t.Fatalf("AnalyzeConflicts() error = %v", err)
+	}
+	if len(result.Conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %d", len(result.Conflicts))
+	}
+	for _, c := range result.Conflicts {
+		optional := c.Path == "textures/a.dds"
+		if c.OptionalDependent != optional {
+			t.Errorf("%s: OptionalDependent = %v, want %v", c.Path, c.OptionalDependent, optional)
+		}
+		if optional && (c.Winner == nil || c.Winner.ModID != "hd" || c.Winner.Condition != cond) {
+			t.Errorf("%s: expected the winner to carry the 4K condition, got %+v", c.Path, c.Winner)
+		}
+		for _, loser := range c.Losers {
+			if loser.Condition != nil {
+				t.Errorf("%s: expected no condition on %s", c.Path, loser.ModID)
+			}
+		}
+	}
+}
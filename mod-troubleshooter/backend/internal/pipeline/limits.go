@@ -0,0 +1,202 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// MaxConcurrency bounds every field of a Concurrency.
+const MaxConcurrency = 64
+
+// DefaultDownloads is how many mod files are downloaded at once by default.
+const DefaultDownloads = 4
+
+// Concurrency is how many of each kind of gathering work may run at once,
+// across every analysis sharing a Limiter. The best values differ widely
+// between machines: a NAS wants few extractions, a desktop many.
+type Concurrency struct {
+	// Downloads bounds mod file downloads. It is also how many mods each
+	// Gather works on at once.
+	Downloads int `json:"downloads"`
+	// Extractions bounds archive listings and installer simulations.
+	Extractions int `json:"extractions"`
+	// Hashes bounds archive listings that hash file contents, and checks
+	// of downloads against their Nexus checksums.
+	Hashes int `json:"hashes"`
+	// PluginParses bounds plugin extraction and header parsing.
+	PluginParses int `json:"pluginParses"`
+}
+
+// DefaultConcurrency returns the concurrency used for fields left at zero:
+// DefaultDownloads downloads, and one of each other kind of work per CPU.
+func DefaultConcurrency() Concurrency {
+	cpus := runtime.NumCPU()
+	return Concurrency{
+		Downloads:    DefaultDownloads,
+		Extractions:  cpus,
+		Hashes:       cpus,
+		PluginParses: cpus,
+	}
+}
+
+// Validate checks that every field is between 0 (the default) and
+// MaxConcurrency.
+func (c Concurrency) Validate() error {
+	for _, f := range c.fields() {
+		if f.value < 0 || f.value > MaxConcurrency {
+			return fmt.Errorf("%s concurrency must be between 0 and %d", f.name, MaxConcurrency)
+		}
+	}
+	return nil
+}
+
+// WithDefaults returns c with zero fields taken from defaults.
+func (c Concurrency) WithDefaults(defaults Concurrency) Concurrency {
+	if c.Downloads <= 0 {
+		c.Downloads = defaults.Downloads
+	}
+	if c.Extractions <= 0 {
+		c.Extractions = defaults.Extractions
+	}
+	if c.Hashes <= 0 {
+		c.Hashes = defaults.Hashes
+	}
+	if c.PluginParses <= 0 {
+		c.PluginParses = defaults.PluginParses
+	}
+	return c
+}
+
+func (c Concurrency) fields() []struct {
+	name  string
+	value int
+} {
+	return []struct {
+		name  string
+		value int
+	}{
+		{"download", c.Downloads},
+		{"extraction", c.Extractions},
+		{"hash", c.Hashes},
+		{"plugin parse", c.PluginParses},
+	}
+}
+
+// work is a kind of work a Limiter bounds.
+type work int
+
+const (
+	workDownload work = iota
+	workExtract
+	workHash
+	workParse
+	workKinds
+)
+
+// Limiter bounds the gathering work of every analysis it is shared by.
+// Its limits can be changed while analyses run. A nil *Limiter does not
+// limit anything.
+type Limiter struct {
+	mu     sync.Mutex
+	limits Concurrency
+	slots  [workKinds]*semaphore
+}
+
+// NewLimiter creates a limiter for the given concurrency, with zero fields
+// taken from DefaultConcurrency.
+func NewLimiter(c Concurrency) *Limiter {
+	l := &Limiter{}
+	for i := range l.slots {
+		l.slots[i] = newSemaphore()
+	}
+	l.Set(c)
+	return l
+}
+
+// Concurrency returns the current limits.
+func (l *Limiter) Concurrency() Concurrency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// Set changes the limits, with zero fields taken from DefaultConcurrency.
+// Work already running is not interrupted when a limit is lowered.
+func (l *Limiter) Set(c Concurrency) {
+	c = c.WithDefaults(DefaultConcurrency())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = c
+	l.slots[workDownload].setLimit(c.Downloads)
+	l.slots[workExtract].setLimit(c.Extractions)
+	l.slots[workHash].setLimit(c.Hashes)
+	l.slots[workParse].setLimit(c.PluginParses)
+}
+
+// acquire waits for a slot for a kind of work and returns the function
+// that frees it. It fails if ctx is done first.
+func (l *Limiter) acquire(ctx context.Context, kind work) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	s := l.slots[kind]
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return s.release, nil
+}
+
+// semaphore is a counting semaphore whose limit can change.
+type semaphore struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+	// wake is closed, and replaced, whenever a slot may have come free
+	wake chan struct{}
+}
+
+func newSemaphore() *semaphore {
+	return &semaphore{wake: make(chan struct{})}
+}
+
+func (s *semaphore) acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if s.used < s.limit {
+			s.used++
+			s.mu.Unlock()
+			return nil
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used--
+	s.broadcast()
+}
+
+func (s *semaphore) setLimit(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = n
+	s.broadcast()
+}
+
+// broadcast wakes every waiter. The caller holds s.mu.
+func (s *semaphore) broadcast() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
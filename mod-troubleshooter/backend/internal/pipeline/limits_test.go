@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrency_Validate(t *testing.T) {
+	if err := (Concurrency{}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v for defaults", err)
+	}
+	if err := (Concurrency{Downloads: 2, PluginParses: MaxConcurrency}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (Concurrency{Hashes: -1}).Validate(); err == nil {
+		t.Error("Validate() should fail for a negative limit")
+	}
+	if err := (Concurrency{Extractions: MaxConcurrency + 1}).Validate(); err == nil {
+		t.Error("Validate() should fail for a limit above MaxConcurrency")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(Concurrency{Downloads: 1})
+	defaults := DefaultConcurrency()
+	if got := l.Concurrency(); got.Downloads != 1 || got.Extractions != defaults.Extractions {
+		t.Errorf("Concurrency() = %+v, want 1 download and default extractions", got)
+	}
+
+	done, err := l.acquire(context.Background(), workDownload)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	// The only slot is taken, so the next download waits
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, workDownload); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second download to wait, got %v", err)
+	}
+	// Other kinds of work have their own slots
+	if done, err := l.acquire(context.Background(), workExtract); err != nil {
+		t.Errorf("acquire() error = %v", err)
+	} else {
+		done()
+	}
+
+	// Raising the limit lets a waiting download through
+	acquired := make(chan error)
+	go func() {
+		_, err := l.acquire(context.Background(), workDownload)
+		acquired <- err
+	}()
+	l.Set(Concurrency{Downloads: 2})
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("acquire() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("raising the limit did not wake the waiting download")
+	}
+	done()
+
+	// A nil limiter does not limit
+	var none *Limiter
+	if done, err := none.acquire(context.Background(), workHash); err != nil {
+		t.Errorf("acquire() error = %v", err)
+	} else {
+		done()
+	}
+}
+
+// countingFetcher records how many fetches run at once.
+type countingFetcher struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	f.mu.Lock()
+	f.running++
+	f.max = max(f.max, f.running)
+	f.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+	return "", fmt.Errorf("%w: gone", ErrUnavailable)
+}
+
+func (f *countingFetcher) Release(path string) {}
+
+func TestGatherer_Limiter(t *testing.T) {
+	fetcher := &countingFetcher{}
+	limiter := NewLimiter(Concurrency{Downloads: 1})
+	sources := []Source{{ModID: "a"}, {ModID: "b"}, {ModID: "c"}}
+
+	// Gatherers sharing the limiter share its download slot
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewGatherer(GathererConfig{Fetcher: fetcher, Limiter: limiter})
+			if _, release, err := g.Gather(context.Background(), sources, InputManifests); err != nil {
+				t.Errorf("Gather() error = %v", err)
+			} else {
+				release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if fetcher.max != 1 {
+		t.Errorf("%d downloads ran at once, want 1", fetcher.max)
+	}
+}
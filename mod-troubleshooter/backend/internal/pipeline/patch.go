@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/patch"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// applyPatches applies the binary patches a collection ships for a mod's
+// files, so its manifest sizes and hashes and its plugin headers describe
+// the patched files. filePath is the download, an archive unless the mod is
+// a loose plugin. Patches that cannot be applied leave the file as
+// downloaded and are reported in the returned error.
+func (g *Gatherer) applyPatches(ctx context.Context, mod *Mod, filePath, password string, patches []patch.File) error {
+	var errs []error
+	for _, p := range patches {
+		target := manifest.NormalizePath(p.Path)
+		data, err := g.patchedFile(ctx, mod, filePath, password, target, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("patch %s: %w", p.Path, err))
+			continue
+		}
+
+		if mod.Manifest != nil {
+			updatePatchedEntry(mod.Manifest, target, data)
+		}
+		g.reparsePlugin(ctx, mod, path.Base(target), data)
+		mod.Patched = append(mod.Patched, p.Path)
+	}
+	return errors.Join(errs...)
+}
+
+// patchedFile reads the file a patch targets and returns it patched.
+func (g *Gatherer) patchedFile(ctx context.Context, mod *Mod, filePath, password, target string, p patch.File) ([]byte, error) {
+	if plugin.IsPluginFile(mod.Filename) || plugin.IsPluginFile(filePath) {
+		// The download is the plugin itself
+		if !strings.EqualFold(path.Base(target), pluginFilename(mod.Filename, filePath)) {
+			return nil, errors.New("file is not in the mod")
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return patch.Apply(data, p.Data)
+	}
+
+	name := p.Path
+	if mod.Manifest != nil {
+		entry, ok := findEntry(mod.Manifest, target)
+		if !ok {
+			return nil, errors.New("file is not in the mod")
+		}
+		if mod.Installed {
+			// Installed manifests list destinations, which may come from
+			// anywhere in the archive
+			return nil, errors.New("file is placed by an installer")
+		}
+		name = entry.OriginalPath
+	}
+	if g.extractor == nil {
+		return nil, errors.New("no extractor configured")
+	}
+
+	data, err := g.extractor.ReadFile(ctx, filePath, password, name, patch.MaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return patch.Apply(data, p.Data)
+}
+
+// findEntry returns the loose file at a normalized path in a manifest.
+func findEntry(m *manifest.Manifest, target string) (manifest.FileEntry, bool) {
+	for _, entry := range m.Files {
+		if entry.Archive == "" && entry.Path == target {
+			return entry, true
+		}
+	}
+	return manifest.FileEntry{}, false
+}
+
+// updatePatchedEntry records a patched file's new size, and its new content
+// hash if the manifest has content hashes, then refreshes the totals.
+func updatePatchedEntry(m *manifest.Manifest, target string, data []byte) {
+	for i, entry := range m.Files {
+		if entry.Archive != "" || entry.Path != target {
+			continue
+		}
+		if manifest.HasContentHash(entry) {
+			sum := sha256.Sum256(data)
+			entry.Hash = hex.EncodeToString(sum[:])
+		}
+		entry.Size = int64(len(data))
+		m.Files[i] = entry
+	}
+	*m = *manifest.NewManifest(m.Files)
+}
+
+// reparsePlugin parses the header of a patched plugin again, in case the
+// patch changed its masters or flags.
+func (g *Gatherer) reparsePlugin(ctx context.Context, mod *Mod, filename string, data []byte) {
+	for i, pf := range mod.Plugins {
+		if !strings.EqualFold(pf.Filename, filename) {
+			continue
+		}
+		header, err := g.parser.Parse(ctx, bytes.NewReader(data), pf.Filename)
+		if err != nil {
+			log.Printf("Warning: could not parse patched plugin %s: %v", pf.Filename, err)
+			continue
+		}
+		mod.Plugins[i].Header = header
+		if g.profile == ProfileDeep {
+			mod.Plugins[i].FormIDs = nil
+			err := g.parser.ScanBytes(ctx, data, func(rec *plugin.Record) error {
+				if rec.Signature != plugin.SignatureTES4 {
+					mod.Plugins[i].FormIDs = append(mod.Plugins[i].FormIDs, rec.FormID)
+				}
+				return nil
+			})
+			if err != nil {
+				log.Printf("Warning: could not scan records of patched plugin %s: %v", pf.Filename, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/patch"
+)
+
+// addPatch returns a VCDIFF patch that replaces a file of oldLen bytes with
+// content.
+func addPatch(oldLen int, content string) []byte {
+	p := []byte{0xd6, 0xc3, 0xc4, 0x00, 0x00}
+	// One window over the whole source: a single ADD of the new content
+	enc := []byte{byte(len(content)), 0x00, byte(len(content)), 0x01, 0x00}
+	enc = append(enc, content...)
+	enc = append(enc, byte(1+len(content)))
+	p = append(p, 0x01, byte(oldLen), 0x00, byte(len(enc)))
+	return append(p, enc...)
+}
+
+func TestGatherer_Patches(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{
+			"textures/x.dds": "original",
+			"meshes/y.nif":   "mesh",
+		}),
+	}}
+	extractor, err := archive.NewExtractor(archive.ExtractorConfig{TempDir: dir})
+	if err != nil {
+		t.Fatalf("NewExtractor() error = %v", err)
+	}
+
+	g := NewGatherer(GathererConfig{Fetcher: fetcher, Extractor: extractor, ContentHashes: true})
+	sources := []Source{{ModID: "a", Filename: "a.zip", Patches: []patch.File{
+		{Path: "Textures\\x.dds", Data: addPatch(len("original"), "patched file")},
+		{Path: "textures/missing.dds", Data: addPatch(1, "x")},
+	}}}
+	in, release, err := g.Gather(context.Background(), sources, InputManifests)
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	release()
+
+	mod := in.Mods[0]
+	if len(mod.Patched) != 1 || mod.Patched[0] != "Textures\\x.dds" {
+		t.Errorf("expected the texture to be patched, got %v", mod.Patched)
+	}
+	if !strings.Contains(mod.Error, "textures/missing.dds") {
+		t.Errorf("expected the missing file's patch to be reported, got %q", mod.Error)
+	}
+	entry, ok := findEntry(mod.Manifest, "textures/x.dds")
+	if !ok || entry.Size != int64(len("patched file")) {
+		t.Errorf("expected the patched size, got %+v", entry)
+	}
+	if mod.Manifest.TotalSize != int64(len("patched file")+len("mesh")) {
+		t.Errorf("expected the total to count the patched size, got %d", mod.Manifest.TotalSize)
+	}
+}
@@ -0,0 +1,300 @@
+// Package pipeline runs analyzers as stages over data gathered from a
+// single pass over mod downloads.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Common errors returned by the pipeline.
+var (
+	ErrUnknownAnalyzer   = errors.New("unknown analyzer")
+	ErrDuplicateAnalyzer = errors.New("analyzer already registered")
+	// ErrUnavailable is wrapped by fetchers when a mod file was deleted or hidden by its author.
+	ErrUnavailable = errors.New("mod file is no longer available")
+	// ErrSourceDown is wrapped by fetchers when no download can succeed for now,
+	// such as while the Nexus API is degraded. It aborts the gather pass.
+	ErrSourceDown = errors.New("mod source is unavailable")
+)
+
+// Input declares a kind of per-mod data an analyzer needs.
+type Input uint8
+
+const (
+	// InputManifests requests the file listing of each mod archive.
+	InputManifests Input = 1 << iota
+	// InputPluginHeaders requests parsed headers for each plugin in a mod.
+	InputPluginHeaders
+	// InputArchives requests access to the downloaded archive itself.
+	InputArchives
+)
+
+// Has reports whether all inputs in other are included in i.
+func (i Input) Has(other Input) bool {
+	return i&other == other
+}
+
+// Mod is the data gathered for a single mod file.
+type Mod struct {
+	// ModID is a unique identifier for the mod (used for display and tracking).
+	ModID string `json:"modId"`
+	// ModName is the display name of the mod.
+	ModName string `json:"modName"`
+	// LoadOrder is the mod's position in the install order.
+	LoadOrder int `json:"loadOrder"`
+	// Filename is the name of the downloaded file.
+	Filename string `json:"filename"`
+	// NexusModID is the mod ID on Nexus, if known.
+	NexusModID int `json:"nexusModId,omitempty"`
+	// FileID is the file ID on Nexus, if known.
+	FileID int `json:"fileId,omitempty"`
+	// DownloadSize is the size of the mod file on Nexus in bytes, if known.
+	DownloadSize int64 `json:"downloadSize,omitempty"`
+	// ModGame is the game domain the mod is published under, if it differs
+	// from the collection's.
+	ModGame string `json:"modGame,omitempty"`
+	// NexusCategory is the mod's category on Nexus, if known.
+	NexusCategory string `json:"nexusCategory,omitempty"`
+	// NexusTags are the mod's tags on Nexus, if known.
+	NexusTags []string `json:"nexusTags,omitempty"`
+	// Version is the version of the mod file, if known.
+	Version string `json:"version,omitempty"`
+	// PinnedVersion is the version the collection recorded for the file, if known.
+	PinnedVersion string `json:"pinnedVersion,omitempty"`
+	// CuratorNote is what the collection's curator wrote about installing
+	// the file, if anything.
+	CuratorNote string `json:"curatorNote,omitempty"`
+	// Manifest is the archive file listing, when InputManifests was requested.
+	Manifest *manifest.Manifest `json:"manifest,omitempty"`
+	// Plugins are the plugins found in the mod, when InputPluginHeaders was requested.
+	Plugins []loadorder.PluginFile `json:"plugins,omitempty"`
+	// ArchivePath is the local path of the download, when InputArchives was requested.
+	// It is only valid while the pipeline is running.
+	ArchivePath string `json:"-"`
+	// Password opens the archive at ArchivePath if it is encrypted.
+	Password string `json:"-"`
+	// Error describes why data could not be gathered for this mod, if anything failed.
+	Error string `json:"error,omitempty"`
+	// Unavailable is true when the mod file was deleted or hidden on Nexus.
+	Unavailable bool `json:"unavailable,omitempty"`
+	// UnsupportedArchive is true when the archive could not be fully read,
+	// so results involving this mod are partial.
+	UnsupportedArchive bool `json:"unsupportedArchive,omitempty"`
+	// Infected is true when the virus scanner flagged the download, which
+	// was quarantined instead of analyzed.
+	Infected bool `json:"infected,omitempty"`
+	// FromPreview is true when the manifest came from the file's content
+	// preview on Nexus rather than the archive, so it has no content hashes
+	// and sizes are approximate.
+	FromPreview bool `json:"fromPreview,omitempty"`
+	// FromStore is true when the manifest was listed by an earlier analysis
+	// and kept in the gatherer's manifest store, rather than downloaded.
+	FromStore bool `json:"fromStore,omitempty"`
+	// Installed is true when the manifest lists the files the mod's FOMOD
+	// installer places with the curator's choices, rather than everything
+	// in the archive.
+	Installed bool `json:"installed,omitempty"`
+	// Optional holds the installed files that only the curator's choices
+	// install, by path, with the choice each depends on. Files placed
+	// whatever the choices are left out.
+	Optional map[string]*fomod.Condition `json:"optional,omitempty"`
+	// Patched lists the files the collection's binary patches were applied
+	// to before they were hashed and parsed.
+	Patched []string `json:"patched,omitempty"`
+	// ArchiveVerified is whether the download's MD5 matches the one Nexus
+	// published for the file. It is only checked when content hashes are
+	// computed, and nil when it was not checked.
+	ArchiveVerified *bool `json:"archiveVerified,omitempty"`
+	// BugReports are the titles of the latest bug reports filed against the
+	// mod on Nexus, when the gatherer was asked for them.
+	BugReports []string `json:"bugReports,omitempty"`
+	// Timing is how long gathering the mod took, by phase.
+	Timing Timing `json:"-"`
+}
+
+// WarningType identifies why an analysis is partial.
+type WarningType string
+
+const (
+	// WarningUnsupportedArchive indicates a mod archive could not be fully read.
+	WarningUnsupportedArchive WarningType = "unsupported_archive"
+	// WarningInfected indicates the virus scanner flagged a mod download.
+	WarningInfected WarningType = "infected"
+)
+
+// Warning describes a mod whose data is incomplete, making results partial.
+type Warning struct {
+	// Type identifies why the analysis is partial.
+	Type WarningType `json:"type"`
+	// ModID is the affected mod.
+	ModID string `json:"modId"`
+	// ModName is the display name of the affected mod.
+	ModName string `json:"modName,omitempty"`
+	// Message is a human-readable description of the warning.
+	Message string `json:"message"`
+}
+
+// ModNote is a collection curator's note on one mod, such as which
+// installer options to choose.
+type ModNote struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName,omitempty"`
+	Note    string `json:"note"`
+}
+
+// Inputs is the data passed to every analyzer in a run.
+type Inputs struct {
+	// Mods are the gathered mods in install order.
+	Mods []Mod
+	// Game is the Nexus game domain of the mods, if known.
+	Game string
+	// GameVersion is the game runtime the mods target, if known.
+	GameVersion string
+}
+
+// Warnings returns a warning for every mod whose data is incomplete.
+func (in *Inputs) Warnings() []Warning {
+	var warnings []Warning
+	for _, mod := range in.Mods {
+		if mod.UnsupportedArchive {
+			warnings = append(warnings, Warning{
+				Type:    WarningUnsupportedArchive,
+				ModID:   mod.ModID,
+				ModName: mod.ModName,
+				Message: fmt.Sprintf("Unsupported archive %s, analysis partial", displayName(mod)),
+			})
+		}
+		if mod.Infected {
+			warnings = append(warnings, Warning{
+				Type:    WarningInfected,
+				ModID:   mod.ModID,
+				ModName: mod.ModName,
+				Message: fmt.Sprintf("Virus scan flagged the download of %s; it was quarantined and not analyzed", displayName(mod)),
+			})
+		}
+	}
+	return warnings
+}
+
+// Notes returns the curator's notes on the gathered mods, in install order.
+func (in *Inputs) Notes() []ModNote {
+	var notes []ModNote
+	for _, mod := range in.Mods {
+		if note := strings.TrimSpace(mod.CuratorNote); note != "" {
+			notes = append(notes, ModNote{ModID: mod.ModID, ModName: mod.ModName, Note: note})
+		}
+	}
+	return notes
+}
+
+// Analyzer is a single pipeline stage.
+type Analyzer interface {
+	// Name is the unique identifier used to request this analyzer.
+	Name() string
+	// Inputs declares which per-mod data the analyzer reads.
+	Inputs() Input
+	// Analyze produces the analyzer's result from the gathered inputs.
+	Analyze(ctx context.Context, in *Inputs) (interface{}, error)
+}
+
+// Result is the outcome of a single analyzer.
+type Result struct {
+	// Data is the analyzer's result, or nil if it failed.
+	Data interface{} `json:"data,omitempty"`
+	// Error describes why the analyzer failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Pipeline holds a set of registered analyzers.
+type Pipeline struct {
+	analyzers map[string]Analyzer
+	order     []string
+}
+
+// New creates a pipeline with the given analyzers registered.
+func New(analyzers ...Analyzer) (*Pipeline, error) {
+	p := &Pipeline{
+		analyzers: make(map[string]Analyzer),
+	}
+	for _, a := range analyzers {
+		if err := p.Register(a); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Register adds an analyzer to the pipeline.
+func (p *Pipeline) Register(a Analyzer) error {
+	name := a.Name()
+	if _, exists := p.analyzers[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateAnalyzer, name)
+	}
+	p.analyzers[name] = a
+	p.order = append(p.order, name)
+	return nil
+}
+
+// Names returns the registered analyzer names in registration order.
+func (p *Pipeline) Names() []string {
+	names := make([]string, len(p.order))
+	copy(names, p.order)
+	return names
+}
+
+// Requires returns the union of inputs needed by the named analyzers.
+func (p *Pipeline) Requires(names []string) (Input, error) {
+	var inputs Input
+	for _, name := range names {
+		a, ok := p.analyzers[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownAnalyzer, name)
+		}
+		inputs |= a.Inputs()
+	}
+	return inputs, nil
+}
+
+// Run executes the named analyzers against the gathered inputs.
+// A failing analyzer is reported in its Result and does not stop the others.
+func (p *Pipeline) Run(ctx context.Context, names []string, in *Inputs) (map[string]Result, error) {
+	if _, err := p.Requires(names); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]Result, len(names))
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if _, done := results[name]; done {
+			continue
+		}
+
+		ReportAnalyzing(ctx, in, name)
+		data, err := p.analyzers[name].Analyze(ctx, in)
+		if err != nil {
+			results[name] = Result{Error: err.Error()}
+			continue
+		}
+		results[name] = Result{Data: data}
+	}
+
+	return results, nil
+}
+
+// RunOne executes a single analyzer and returns its result directly.
+func (p *Pipeline) RunOne(ctx context.Context, name string, in *Inputs) (interface{}, error) {
+	a, ok := p.analyzers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAnalyzer, name)
+	}
+	return a.Analyze(ctx, in)
+}
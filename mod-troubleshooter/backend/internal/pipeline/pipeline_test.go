@@ -0,0 +1,256 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+type fakeAnalyzer struct {
+	name   string
+	inputs Input
+	err    error
+	calls  int
+}
+
+func (f *fakeAnalyzer) Name() string  { return f.name }
+func (f *fakeAnalyzer) Inputs() Input { return f.inputs }
+func (f *fakeAnalyzer) Analyze(ctx context.Context, in *Inputs) (interface{}, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return len(in.Mods), nil
+}
+
+func TestInput_Has(t *testing.T) {
+	both := InputManifests | InputArchives
+	if !both.Has(InputManifests) || !both.Has(InputArchives) {
+		t.Error("expected combined input to include its parts")
+	}
+	if both.Has(InputPluginHeaders) {
+		t.Error("expected combined input not to include plugin headers")
+	}
+	if InputManifests.Has(both) {
+		t.Error("expected single input not to include a superset")
+	}
+}
+
+func TestPipeline_Register(t *testing.T) {
+	p, err := New(&fakeAnalyzer{name: "a"}, &fakeAnalyzer{name: "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := p.Register(&fakeAnalyzer{name: "a"}); !errors.Is(err, ErrDuplicateAnalyzer) {
+		t.Errorf("expected ErrDuplicateAnalyzer, got %v", err)
+	}
+
+	names := p.Names()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("expected [a b], got %v", names)
+	}
+}
+
+func TestPipeline_Requires(t *testing.T) {
+	p, _ := New(
+		&fakeAnalyzer{name: "a", inputs: InputManifests},
+		&fakeAnalyzer{name: "b", inputs: InputPluginHeaders},
+		&fakeAnalyzer{name: "c", inputs: InputManifests | InputArchives},
+	)
+
+	need, err := p.Requires([]string{"a", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if need != InputManifests|InputArchives {
+		t.Errorf("expected manifests|archives, got %b", need)
+	}
+
+	if _, err := p.Requires([]string{"a", "missing"}); !errors.Is(err, ErrUnknownAnalyzer) {
+		t.Errorf("expected ErrUnknownAnalyzer, got %v", err)
+	}
+}
+
+func TestPipeline_Run(t *testing.T) {
+	ok := &fakeAnalyzer{name: "ok"}
+	failing := &fakeAnalyzer{name: "failing", err: errors.New("boom")}
+	p, _ := New(ok, failing)
+
+	in := &Inputs{Mods: []Mod{{ModID: "1"}, {ModID: "2"}}}
+	results, err := p.Run(context.Background(), []string{"ok", "failing", "ok"}, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ok.calls != 1 {
+		t.Errorf("expected analyzer to run once, ran %d times", ok.calls)
+	}
+	if results["ok"].Data != 2 {
+		t.Errorf("expected ok result 2, got %v", results["ok"].Data)
+	}
+	if results["failing"].Error != "boom" {
+		t.Errorf("expected failing error, got %+v", results["failing"])
+	}
+
+	if _, err := p.Run(context.Background(), []string{"missing"}, in); !errors.Is(err, ErrUnknownAnalyzer) {
+		t.Errorf("expected ErrUnknownAnalyzer, got %v", err)
+	}
+}
+
+func TestConflictStage(t *testing.T) {
+	stage := NewConflictStage()
+	if stage.Name() != NameConflicts || stage.Inputs() != InputManifests {
+		t.Errorf("unexpected stage declaration: %s %b", stage.Name(), stage.Inputs())
+	}
+
+	in := &Inputs{Mods: []Mod{
+		{ModID: "a", ModName: "A", LoadOrder: 0, Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/x.dds", 10)})},
+		{ModID: "failed", LoadOrder: 1, Error: "download failed"},
+		{ModID: "b", ModName: "B", LoadOrder: 2, Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/x.dds", 20)})},
+	}}
+
+	if got := ModManifests(in); len(got) != 2 || got[1].LoadOrder != 2 {
+		t.Errorf("expected 2 manifests keeping load order, got %+v", got)
+	}
+
+	result, err := stage.AnalyzeConflicts(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stats.TotalConflicts != 1 {
+		t.Errorf("expected 1 conflict, got %d", result.Stats.TotalConflicts)
+	}
+
+	single := &Inputs{Mods: in.Mods[:1]}
+	result, err = stage.AnalyzeConflicts(context.Background(), single)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Conflicts == nil || len(result.Conflicts) != 0 {
+		t.Errorf("expected empty conflicts for a single mod, got %v", result.Conflicts)
+	}
+	if result.RecordConflicts != nil {
+		t.Errorf("expected no record conflicts without scanned plugins, got %v", result.RecordConflicts)
+	}
+
+	// Scanned plugins add record conflicts
+	header := &plugin.PluginHeader{Masters: []plugin.Master{{Filename: "Skyrim.esm"}}}
+	in.Mods[0].Plugins = []loadorder.PluginFile{{Filename: "A.esp", Header: header, FormIDs: []uint32{0x00012E46}}}
+	in.Mods[2].Plugins = []loadorder.PluginFile{{Filename: "B.esp", Header: header, FormIDs: []uint32{0x00012E46}}}
+	result, err = stage.AnalyzeConflicts(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.RecordConflicts) != 1 || result.RecordConflicts[0].Winner != "B.esp" {
+		t.Errorf("expected B.esp to win a record conflict, got %+v", result.RecordConflicts)
+	}
+}
+
+func TestLoadOrderStage(t *testing.T) {
+	stage := NewLoadOrderStage()
+	if stage.Name() != NameLoadOrder || stage.Inputs() != InputPluginHeaders {
+		t.Errorf("unexpected stage declaration: %s %b", stage.Name(), stage.Inputs())
+	}
+
+	in := &Inputs{Mods: []Mod{
+		{ModID: "a", Plugins: []loadorder.PluginFile{{Filename: "A.esm"}}},
+		{ModID: "b"},
+		{ModID: "c", Plugins: []loadorder.PluginFile{{Filename: "B.esp"}, {Filename: "C.esp"}}},
+	}}
+
+	plugins := PluginFiles(in)
+	if len(plugins) != 3 || plugins[0].Filename != "A.esm" || plugins[2].Filename != "C.esp" {
+		t.Errorf("expected plugins flattened in order, got %+v", plugins)
+	}
+
+	result, err := stage.AnalyzeLoadOrder(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stats.TotalPlugins != 3 {
+		t.Errorf("expected 3 plugins, got %d", result.Stats.TotalPlugins)
+	}
+}
+
+func TestHealthStage(t *testing.T) {
+	stage := NewHealthStage()
+	if stage.Name() != NameHealth || stage.Inputs() != InputManifests {
+		t.Errorf("unexpected stage declaration: %s %b", stage.Name(), stage.Inputs())
+	}
+
+	in := &Inputs{Mods: []Mod{
+		{ModID: "a", ModName: "A", Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/x.dds", 10)})},
+		{ModID: "empty", ModName: "Empty", Manifest: manifest.NewManifest(nil)},
+		{ModID: "failed", Filename: "failed.7z", Error: "no download links available"},
+	}}
+
+	report, err := stage.AnalyzeHealth(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.ModsTotal != 3 || report.ModsFailed != 1 {
+		t.Errorf("expected 3 mods with 1 failed, got %d and %d", report.ModsTotal, report.ModsFailed)
+	}
+	if len(report.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", report.Findings)
+	}
+	if report.Findings[0].Type != health.FindingDownloadFailed || report.Findings[0].ModID != "failed" {
+		t.Errorf("expected download failure first, got %+v", report.Findings[0])
+	}
+	if report.Findings[1].Type != health.FindingEmptyArchive {
+		t.Errorf("expected empty archive finding, got %+v", report.Findings[1])
+	}
+	if report.Score != 80 {
+		t.Errorf("expected score 80, got %d", report.Score)
+	}
+	if report.Compatibility == nil || report.Compatibility.Platforms[0].Compatible != 2 {
+		t.Errorf("expected compatibility for the 2 inspected mods, got %+v", report.Compatibility)
+	}
+}
+
+func TestHealthStage_GameMismatch(t *testing.T) {
+	in := &Inputs{Game: "skyrimspecialedition", Mods: []Mod{
+		{ModID: "le", ModName: "LE Textures", ModGame: "skyrim", Manifest: manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("textures/x.dds", 10)})},
+		{ModID: "hidden", ModName: "Hidden LE Mod", ModGame: "skyrim", Error: "mod is hidden on Nexus", Unavailable: true},
+	}}
+
+	report, err := NewHealthStage().AnalyzeHealth(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mismatched []string
+	for _, f := range report.Findings {
+		if f.Type == health.FindingGameMismatch {
+			mismatched = append(mismatched, f.ModID)
+		}
+	}
+	if len(mismatched) != 2 {
+		t.Errorf("expected both LE mods flagged, got %v", mismatched)
+	}
+}
+
+func TestHealthStage_UnavailableMod(t *testing.T) {
+	in := &Inputs{Mods: []Mod{
+		{ModID: "hidden", ModName: "Hidden Mod", Error: "mod is hidden on Nexus", Unavailable: true},
+	}}
+
+	report, err := NewHealthStage().AnalyzeHealth(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.ModsFailed != 1 || len(report.Findings) != 1 {
+		t.Fatalf("expected 1 failed mod with 1 finding, got %+v", report)
+	}
+	if f := report.Findings[0]; f.Type != health.FindingUnavailableMod || f.Severity != health.SeverityError {
+		t.Errorf("expected unavailable mod error, got %+v", f)
+	}
+}
@@ -0,0 +1,53 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownProfile is returned for a profile name that is not defined.
+var ErrUnknownProfile = errors.New("unknown analysis profile")
+
+// Profile trades analysis speed for depth.
+type Profile string
+
+const (
+	// ProfileQuick lists archives from their content preview where one
+	// exists, downloading only what previews cannot provide. Plugins are
+	// read for their headers only.
+	ProfileQuick Profile = "quick"
+	// ProfileStandard downloads and lists every archive.
+	ProfileStandard Profile = "standard"
+	// ProfileDeep also hashes file contents, lists the files packed in BSA
+	// and BA2 archives and scans plugin records for overrides.
+	ProfileDeep Profile = "deep"
+)
+
+// Profiles lists the defined profiles from fastest to most thorough.
+var Profiles = []Profile{ProfileQuick, ProfileStandard, ProfileDeep}
+
+// ParseProfile returns the profile with the given name. An empty name is
+// the standard profile.
+func ParseProfile(name string) (Profile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ProfileStandard, nil
+	}
+	for _, p := range Profiles {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+}
+
+// Inputs returns the inputs to gather under the profile for analyzers that
+// need need. Deep analyses scan plugins alongside manifests so record
+// conflicts can be reported with file conflicts.
+func (p Profile) Inputs(need Input) Input {
+	if p == ProfileDeep && need.Has(InputManifests) {
+		return need | InputPluginHeaders
+	}
+	return need
+}
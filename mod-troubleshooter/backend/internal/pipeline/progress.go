@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+)
+
+// downloadReportInterval is the least time between two reports of the
+// bytes of one download.
+const downloadReportInterval = 250 * time.Millisecond
+
+// Stage is the part of an analysis a progress report refers to.
+type Stage int
+
+const (
+	// StageDownloading is reported before a mod file is downloaded or previewed.
+	StageDownloading Stage = iota + 1
+	// StageExtracting is reported before inputs are collected from a download.
+	StageExtracting
+	// StageParsing is reported before the plugins of a download are parsed.
+	StageParsing
+	// StageAnalyzing is reported once every mod is gathered and the analyzers run.
+	StageAnalyzing
+)
+
+// String returns the lowercase name of the stage, such as "downloading".
+func (s Stage) String() string {
+	switch s {
+	case StageDownloading:
+		return "downloading"
+	case StageExtracting:
+		return "extracting"
+	case StageParsing:
+		return "parsing"
+	case StageAnalyzing:
+		return "analyzing"
+	}
+	return "unknown"
+}
+
+// Progress reports how far an analysis has got.
+type Progress struct {
+	Stage Stage
+	// ModsDone is the number of mods finished so far out of ModsTotal.
+	ModsDone  int
+	ModsTotal int
+	// CurrentMod is the display name of the mod being processed, if any.
+	CurrentMod string
+	// BytesDone is how much of CurrentMod's file has been downloaded out of
+	// BytesTotal, while downloading. BytesTotal is -1 if the size is unknown.
+	BytesDone  int64
+	BytesTotal int64
+	// Analyzer is the analyzer running, while analyzing.
+	Analyzer string
+}
+
+// ProgressFunc receives progress reports. Mods are gathered concurrently,
+// so it may be called from several goroutines at once, and it should
+// return quickly.
+type ProgressFunc func(Progress)
+
+type (
+	progressKey struct{}
+	currentKey  struct{}
+)
+
+// WithProgress returns a context that delivers the progress of analyses
+// run with it to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress delivers p to the ProgressFunc carried by ctx, if any.
+func ReportProgress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(p)
+	}
+}
+
+// ReportAnalyzing reports that the analyzer named name has started on in.
+func ReportAnalyzing(ctx context.Context, in *Inputs, name string) {
+	ReportProgress(ctx, Progress{Stage: StageAnalyzing, ModsDone: len(in.Mods), ModsTotal: len(in.Mods), Analyzer: name})
+}
+
+// reportStage delivers p, the progress of the mod being gathered, and
+// returns a context that remembers it for the reports made further down,
+// such as by DownloadProgress.
+func reportStage(ctx context.Context, p Progress) context.Context {
+	if _, ok := ctx.Value(progressKey{}).(ProgressFunc); !ok {
+		return ctx
+	}
+	ReportProgress(ctx, p)
+	return context.WithValue(ctx, currentKey{}, p)
+}
+
+// advanceStage reports that the mod being gathered with ctx has moved on
+// to stage.
+func advanceStage(ctx context.Context, stage Stage) {
+	if p, ok := ctx.Value(currentKey{}).(Progress); ok {
+		p.Stage = stage
+		ReportProgress(ctx, p)
+	}
+}
+
+// DownloadProgress returns a callback for Fetchers to report the bytes
+// downloaded of the mod file they fetch with ctx. Reports are at most one
+// per downloadReportInterval, besides the last. It returns nil when no one
+// is told of the progress.
+func DownloadProgress(ctx context.Context) func(downloaded, total int64) {
+	p, ok := ctx.Value(currentKey{}).(Progress)
+	if !ok {
+		return nil
+	}
+	var last time.Time
+	return func(downloaded, total int64) {
+		finished := total > 0 && downloaded >= total
+		if !finished && time.Since(last) < downloadReportInterval {
+			return
+		}
+		last = time.Now()
+		p.BytesDone, p.BytesTotal = downloaded, total
+		ReportProgress(ctx, p)
+	}
+}
@@ -0,0 +1,255 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/flight"
+)
+
+// Default limits for download sessions.
+const (
+	DefaultSessionTTL  = 15 * time.Minute
+	DefaultMaxSessions = 2
+)
+
+// SessionsConfig holds configuration for Sessions.
+type SessionsConfig struct {
+	// TTL is how long an idle session keeps its downloads.
+	TTL time.Duration
+	// MaxSessions bounds how many revisions keep downloads on disk at once.
+	MaxSessions int
+}
+
+// Sessions keeps the downloads of recently analyzed collection revisions,
+// so analyzing the same revision again reuses files still on disk.
+type Sessions struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	maxSessions int
+	sessions    map[string]*Session
+	now         func() time.Time
+}
+
+// NewSessions creates a new session store.
+func NewSessions(cfg SessionsConfig) *Sessions {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultSessionTTL
+	}
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = DefaultMaxSessions
+	}
+	return &Sessions{
+		ttl:         cfg.TTL,
+		maxSessions: cfg.MaxSessions,
+		sessions:    make(map[string]*Session),
+		now:         time.Now,
+	}
+}
+
+// Acquire returns the session for a collection revision, creating it if needed.
+// Callers must call Done on the session when they finish using it.
+// A nil Sessions returns a nil session, which fetches without reuse.
+func (s *Sessions) Acquire(slug string, revision int) *Session {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%d", slug, revision)
+	session, ok := s.sessions[key]
+	if !ok {
+		session = &Session{files: make(map[string]sessionFile), now: s.now}
+		s.sessions[key] = session
+	}
+	session.acquire()
+
+	s.evictLocked()
+	return session
+}
+
+// Close releases the downloads of every idle session.
+func (s *Sessions) Close() {
+	s.ReleaseIdle()
+}
+
+// ReleaseIdle releases the downloads of every session not in use, such as
+// when the server is paused. The store stays usable.
+func (s *Sessions) ReleaseIdle() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, session := range s.sessions {
+		if session.idle() {
+			session.releaseAll()
+			delete(s.sessions, key)
+		}
+	}
+}
+
+// evictLocked releases expired idle sessions, then the least recently used
+// idle sessions until the store is within its size limit.
+func (s *Sessions) evictLocked() {
+	now := s.now()
+	for key, session := range s.sessions {
+		if session.idle() && now.Sub(session.lastUsedAt()) > s.ttl {
+			session.releaseAll()
+			delete(s.sessions, key)
+		}
+	}
+
+	if len(s.sessions) <= s.maxSessions {
+		return
+	}
+
+	keys := make([]string, 0, len(s.sessions))
+	for key, session := range s.sessions {
+		if session.idle() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.sessions[keys[i]].lastUsedAt().Before(s.sessions[keys[j]].lastUsedAt())
+	})
+
+	for _, key := range keys {
+		if len(s.sessions) <= s.maxSessions {
+			break
+		}
+		s.sessions[key].releaseAll()
+		delete(s.sessions, key)
+	}
+}
+
+// Session holds the downloads of a single collection revision.
+type Session struct {
+	mu       sync.Mutex
+	refs     int
+	lastUsed time.Time
+	files    map[string]sessionFile
+	now      func() time.Time
+
+	// fetches shares in-flight downloads between concurrent analyses
+	fetches flight.Group[string]
+}
+
+// sessionFile is a download owned by a session.
+type sessionFile struct {
+	path    string
+	fetcher Fetcher
+}
+
+// Done marks the caller as finished with the session.
+// The session's downloads are kept until it expires or is evicted.
+func (s *Session) Done() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs--
+	s.lastUsed = s.now()
+}
+
+// Fetcher wraps f so downloads are shared through the session.
+// Files fetched through the wrapper are owned by the session and are not
+// freed by Release. A nil session returns f unchanged.
+func (s *Session) Fetcher(f Fetcher) Fetcher {
+	if s == nil {
+		return f
+	}
+	return &sessionFetcher{session: s, inner: f}
+}
+
+func (s *Session) acquire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs++
+	s.lastUsed = s.now()
+}
+
+func (s *Session) idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refs <= 0
+}
+
+func (s *Session) lastUsedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUsed
+}
+
+// lookup returns a previously downloaded path that is still on disk.
+func (s *Session) lookup(modID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.files[modID]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(file.path); err != nil {
+		delete(s.files, modID)
+		return "", false
+	}
+	return file.path, true
+}
+
+// store records a download, returning the path to use.
+// If another request stored the same mod first, the duplicate is freed.
+func (s *Session) store(modID, path string, f Fetcher) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.files[modID]; ok && existing.path != path {
+		f.Release(path)
+		return existing.path
+	}
+	s.files[modID] = sessionFile{path: path, fetcher: f}
+	return path
+}
+
+func (s *Session) releaseAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, file := range s.files {
+		file.fetcher.Release(file.path)
+	}
+	s.files = make(map[string]sessionFile)
+}
+
+// sessionFetcher serves downloads from a session before falling back to its inner fetcher.
+type sessionFetcher struct {
+	session *Session
+	inner   Fetcher
+}
+
+// Fetch implements Fetcher.
+func (f *sessionFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	if path, ok := f.session.lookup(src.ModID); ok {
+		return path, nil
+	}
+
+	return f.session.fetches.Do(ctx, src.ModID, func(ctx context.Context) (string, error) {
+		path, err := f.inner.Fetch(ctx, src)
+		if err != nil {
+			return "", err
+		}
+		return f.session.store(src.ModID, path, f.inner), nil
+	})
+}
+
+// Release implements Fetcher. Session files are freed when the session expires.
+func (f *sessionFetcher) Release(path string) {}
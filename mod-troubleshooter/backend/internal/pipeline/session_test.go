@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessions_ReusesDownloads(t *testing.T) {
+	dir := t.TempDir()
+	path := createZip(t, dir, "a.zip", map[string]string{"readme.txt": "hi"})
+	fetcher := &fakeFetcher{paths: map[string]string{"a": path}}
+	sessions := NewSessions(SessionsConfig{})
+
+	for i := 0; i < 2; i++ {
+		session := sessions.Acquire("collection", 1)
+		g := NewGatherer(GathererConfig{Fetcher: session.Fetcher(fetcher)})
+		_, release, err := g.Gather(context.Background(), []Source{{ModID: "a", Filename: "a.zip"}}, InputManifests)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release()
+		session.Done()
+	}
+
+	if len(fetcher.fetched) != 1 {
+		t.Errorf("expected 1 download across both passes, got %d", len(fetcher.fetched))
+	}
+	if len(fetcher.released) != 0 {
+		t.Errorf("expected session to keep its download, got %d releases", len(fetcher.released))
+	}
+
+	sessions.Close()
+	if len(fetcher.released) != 1 {
+		t.Errorf("expected download released on close, got %d releases", len(fetcher.released))
+	}
+}
+
+func TestSessions_RefetchesMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := createZip(t, dir, "a.zip", map[string]string{"readme.txt": "hi"})
+	fetcher := &fakeFetcher{paths: map[string]string{"a": path}}
+	session := NewSessions(SessionsConfig{}).Acquire("collection", 1)
+	defer session.Done()
+
+	f := session.Fetcher(fetcher)
+	f.Fetch(context.Background(), Source{ModID: "a"})
+	os.Remove(path)
+	f.Fetch(context.Background(), Source{ModID: "a"})
+
+	if len(fetcher.fetched) != 2 {
+		t.Errorf("expected missing file to be fetched again, got %d fetches", len(fetcher.fetched))
+	}
+}
+
+func TestSessions_Eviction(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &fakeFetcher{paths: map[string]string{
+		"a": createZip(t, dir, "a.zip", map[string]string{"a.txt": "a"}),
+		"b": createZip(t, dir, "b.zip", map[string]string{"b.txt": "b"}),
+	}}
+
+	now := time.Now()
+	sessions := NewSessions(SessionsConfig{TTL: time.Minute, MaxSessions: 1})
+	sessions.now = func() time.Time { return now }
+
+	first := sessions.Acquire("first", 1)
+	first.Fetcher(fetcher).Fetch(context.Background(), Source{ModID: "a"})
+
+	// A session in use is never evicted
+	second := sessions.Acquire("second", 1)
+	if len(fetcher.released) != 0 {
+		t.Fatalf("expected active session to be kept, got %d releases", len(fetcher.released))
+	}
+	second.Fetcher(fetcher).Fetch(context.Background(), Source{ModID: "b"})
+	second.Done()
+
+	// Going over the limit evicts the least recently used idle session
+	first.Done()
+	now = now.Add(time.Second)
+	sessions.Acquire("third", 1).Done()
+	if len(fetcher.released) != 2 {
+		t.Errorf("expected idle sessions evicted over the limit, got %d releases", len(fetcher.released))
+	}
+
+	// Idle sessions expire after the TTL
+	sessions = NewSessions(SessionsConfig{TTL: time.Minute})
+	sessions.now = func() time.Time { return now }
+	expiring := sessions.Acquire("expiring", 1)
+	expiring.Fetcher(fetcher).Fetch(context.Background(), Source{ModID: "a"})
+	expiring.Done()
+
+	now = now.Add(2 * time.Minute)
+	sessions.Acquire("other", 1).Done()
+	if got := fetcher.released[len(fetcher.released)-1]; got != filepath.Join(dir, "a.zip") {
+		t.Errorf("expected expired session download released, got %s", got)
+	}
+}
+
+// blockingFetcher holds every fetch until release is closed.
+type blockingFetcher struct {
+	*fakeFetcher
+	mu      sync.Mutex
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	f.mu.Lock()
+	path, err := f.fakeFetcher.Fetch(ctx, src)
+	f.mu.Unlock()
+	f.started <- struct{}{}
+	<-f.release
+	return path, err
+}
+
+func TestSessions_SharesConcurrentDownloads(t *testing.T) {
+	dir := t.TempDir()
+	path := createZip(t, dir, "a.zip", map[string]string{"readme.txt": "hi"})
+	fetcher := &blockingFetcher{
+		fakeFetcher: &fakeFetcher{paths: map[string]string{"a": path}},
+		started:     make(chan struct{}, 2),
+		release:     make(chan struct{}),
+	}
+	session := NewSessions(SessionsConfig{}).Acquire("collection", 1)
+	defer session.Done()
+
+	f := session.Fetcher(fetcher)
+	var wg sync.WaitGroup
+	paths := make([]string, 2)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], _ = f.Fetch(context.Background(), Source{ModID: "a"})
+		}(i)
+	}
+
+	<-fetcher.started
+	time.Sleep(20 * time.Millisecond)
+	close(fetcher.release)
+	wg.Wait()
+
+	if len(fetcher.fetched) != 1 {
+		t.Errorf("expected concurrent fetches to share one download, got %d", len(fetcher.fetched))
+	}
+	if paths[0] != path || paths[1] != path {
+		t.Errorf("expected both callers to get %s, got %v", path, paths)
+	}
+}
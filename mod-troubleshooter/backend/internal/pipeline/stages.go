@@ -0,0 +1,585 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/perf"
+)
+
+// Names of the built-in analyzers.
+const (
+	NameConflicts   = "conflicts"
+	NameLoadOrder   = "loadorder"
+	NameFomod       = "fomod"
+	NameHealth      = "health"
+	NamePerformance = "performance"
+)
+
+// ModManifests converts gathered mods into conflict analysis input.
+// Mods without a manifest (failed downloads, loose plugins) are skipped.
+func ModManifests(in *Inputs) []conflict.ModManifest {
+	manifests := make([]conflict.ModManifest, 0, len(in.Mods))
+	for _, mod := range in.Mods {
+		if mod.Manifest == nil {
+			continue
+		}
+		manifests = append(manifests, conflict.ModManifest{
+			ModID:      mod.ModID,
+			ModName:    mod.ModName,
+			Manifest:   mod.Manifest,
+			LoadOrder:  mod.LoadOrder,
+			Game:       in.Game,
+			NexusModID: mod.NexusModID,
+			FileID:     mod.FileID,
+
+			NexusCategory: mod.NexusCategory,
+			NexusTags:     mod.NexusTags,
+		})
+	}
+	return manifests
+}
+
+// PluginFiles flattens the plugins of all gathered mods in install order.
+func PluginFiles(in *Inputs) []loadorder.PluginFile {
+	var plugins []loadorder.PluginFile
+	for _, mod := range in.Mods {
+		plugins = append(plugins, mod.Plugins...)
+	}
+	return plugins
+}
+
+// ConflictStage detects file conflicts between mod archives.
+type ConflictStage struct {
+	analyzer *conflict.Analyzer
+	pairs    conflict.PairCache
+}
+
+// NewConflictStage creates a conflict analysis stage.
+func NewConflictStage() *ConflictStage {
+	return &ConflictStage{analyzer: conflict.NewAnalyzer()}
+}
+
+// NewConflictStageWithCache creates a conflict analysis stage that reuses
+// cached mod pair overlaps between runs. A nil cache disables caching.
+func NewConflictStageWithCache(pairs conflict.PairCache) *ConflictStage {
+	return &ConflictStage{analyzer: conflict.NewAnalyzer(), pairs: pairs}
+}
+
+// Name implements Analyzer.
+func (s *ConflictStage) Name() string { return NameConflicts }
+
+// Inputs implements Analyzer.
+func (s *ConflictStage) Inputs() Input { return InputManifests }
+
+// Analyze implements Analyzer.
+func (s *ConflictStage) Analyze(ctx context.Context, in *Inputs) (interface{}, error) {
+	return s.AnalyzeConflicts(ctx, in)
+}
+
+// AnalyzeConflicts runs conflict analysis and returns the typed result.
+// Record conflicts are included when plugin records were scanned.
+func (s *ConflictStage) AnalyzeConflicts(ctx context.Context, in *Inputs) (*conflict.AnalysisResult, error) {
+	manifests := ModManifests(in)
+	var result *conflict.AnalysisResult
+	if len(manifests) < 2 {
+		// Not enough mods for conflict analysis, return empty result
+		result = &conflict.AnalysisResult{
+			Conflicts:    []conflict.Conflict{},
+			ModSummaries: []conflict.ModConflictSummary{},
+			FileToMods:   make(map[string][]string),
+			Groups:       []conflict.ConflictGroup{},
+			Stats:        conflict.Stats{ByFileType: make(map[manifest.FileType]int), ModsAnalyzed: len(manifests)},
+		}
+	} else {
+		var err error
+		if result, err = s.analyzer.AnalyzeCached(ctx, manifests, s.pairs); err != nil {
+			return nil, err
+		}
+	}
+
+	if plugins := RecordPlugins(in); len(plugins) > 0 {
+		result.RecordConflicts = conflict.RecordConflicts(plugins)
+	}
+	markVerified(result, in)
+	markOptional(result, in)
+	return result, nil
+}
+
+// markVerified copies each mod's download verification to its summary.
+func markVerified(result *conflict.AnalysisResult, in *Inputs) {
+	verified := make(map[string]*bool)
+	for _, mod := range in.Mods {
+		if mod.ArchiveVerified != nil {
+			verified[mod.ModID] = mod.ArchiveVerified
+		}
+	}
+	for i, summary := range result.ModSummaries {
+		result.ModSummaries[i].ArchiveVerified = verified[summary.ModID]
+	}
+}
+
+// markOptional marks the conflicts involving files a mod's installer only
+// places with some choices, and notes the choice on those files.
+func markOptional(result *conflict.AnalysisResult, in *Inputs) {
+	optional := make(map[string]map[string]*fomod.Condition)
+	for _, mod := range in.Mods {
+		if len(mod.Optional) > 0 {
+			optional[mod.ModID] = mod.Optional
+		}
+	}
+	if len(optional) == 0 {
+		return
+	}
+	for i := range result.Conflicts {
+		c := &result.Conflicts[i]
+		mark := func(f *conflict.ModFile) {
+			if cond := optional[f.ModID][c.Path]; cond != nil {
+				f.Condition = cond
+				c.OptionalDependent = true
+			}
+		}
+		for j := range c.Sources {
+			mark(&c.Sources[j])
+		}
+		if c.Winner != nil {
+			mark(c.Winner)
+		}
+		for j := range c.Losers {
+			mark(&c.Losers[j])
+		}
+	}
+}
+
+// RecordPlugins collects the plugins whose records were scanned, in install
+// order.
+func RecordPlugins(in *Inputs) []conflict.RecordPlugin {
+	var plugins []conflict.RecordPlugin
+	for _, mod := range in.Mods {
+		for _, pf := range mod.Plugins {
+			if pf.Header == nil || pf.FormIDs == nil {
+				continue
+			}
+			masters := make([]string, len(pf.Header.Masters))
+			for i, m := range pf.Header.Masters {
+				masters[i] = m.Filename
+			}
+			plugins = append(plugins, conflict.RecordPlugin{
+				ModID:   mod.ModID,
+				ModName: mod.ModName,
+				Plugin:  pf.Filename,
+				Masters: masters,
+				FormIDs: pf.FormIDs,
+			})
+		}
+	}
+	return plugins
+}
+
+// LoadOrderStage checks plugin masters and load order.
+type LoadOrderStage struct {
+	analyzer *loadorder.Analyzer
+}
+
+// NewLoadOrderStage creates a load order analysis stage.
+func NewLoadOrderStage() *LoadOrderStage {
+	return &LoadOrderStage{analyzer: loadorder.NewAnalyzer()}
+}
+
+// Name implements Analyzer.
+func (s *LoadOrderStage) Name() string { return NameLoadOrder }
+
+// Inputs implements Analyzer.
+func (s *LoadOrderStage) Inputs() Input { return InputPluginHeaders }
+
+// Analyze implements Analyzer.
+func (s *LoadOrderStage) Analyze(ctx context.Context, in *Inputs) (interface{}, error) {
+	return s.AnalyzeLoadOrder(ctx, in)
+}
+
+// AnalyzeLoadOrder runs load order analysis and returns the typed result.
+func (s *LoadOrderStage) AnalyzeLoadOrder(ctx context.Context, in *Inputs) (*loadorder.AnalysisResult, error) {
+	return s.analyzer.AnalyzeGame(ctx, in.Game, PluginFiles(in))
+}
+
+// FomodResult is the FOMOD installer found in a single mod, if any.
+type FomodResult struct {
+	ModID    string           `json:"modId"`
+	ModName  string           `json:"modName"`
+	HasFomod bool             `json:"hasFomod"`
+	Data     *fomod.FomodData `json:"data,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// FomodStage parses FOMOD installers from mod archives.
+type FomodStage struct {
+	extractor *archive.Extractor
+}
+
+// NewFomodStage creates a FOMOD analysis stage.
+func NewFomodStage(extractor *archive.Extractor) *FomodStage {
+	return &FomodStage{extractor: extractor}
+}
+
+// Name implements Analyzer.
+func (s *FomodStage) Name() string { return NameFomod }
+
+// Inputs implements Analyzer.
+func (s *FomodStage) Inputs() Input { return InputArchives }
+
+// Analyze implements Analyzer.
+// Only mods that have a FOMOD installer or failed to parse are included.
+func (s *FomodStage) Analyze(ctx context.Context, in *Inputs) (interface{}, error) {
+	results := []FomodResult{}
+	for _, mod := range in.Mods {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if mod.ArchivePath == "" {
+			continue
+		}
+
+		data, err := FomodFromArchive(ctx, s.extractor, mod.ArchivePath, mod.Password)
+		if err != nil {
+			log.Printf("Warning: could not analyze FOMOD for mod %s: %v", mod.ModID, err)
+			results = append(results, FomodResult{ModID: mod.ModID, ModName: mod.ModName, Error: err.Error()})
+			continue
+		}
+		if data == nil {
+			continue
+		}
+
+		results = append(results, FomodResult{
+			ModID:    mod.ModID,
+			ModName:  mod.ModName,
+			HasFomod: true,
+			Data:     data,
+		})
+	}
+	return results, nil
+}
+
+// FomodFromArchive extracts and parses the FOMOD installer from an archive,
+// opening it with password if it is encrypted.
+// It returns nil data and no error when the archive has no usable FOMOD.
+func FomodFromArchive(ctx context.Context, extractor *archive.Extractor, archivePath, password string) (*fomod.FomodData, error) {
+	hasFomod, err := extractor.HasFomod(ctx, archivePath, password)
+	if err != nil {
+		return nil, fmt.Errorf("inspect archive: %w", err)
+	}
+	if !hasFomod {
+		return nil, nil
+	}
+
+	extractResult, err := extractor.ExtractFomod(ctx, archivePath, password)
+	if err != nil {
+		return nil, fmt.Errorf("extract fomod: %w", err)
+	}
+	defer extractor.Cleanup(extractResult.OutputDir)
+
+	parser, err := fomod.NewParser(extractResult.OutputDir)
+	if err != nil {
+		if errors.Is(err, fomod.ErrNoFomodDir) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("create fomod parser: %w", err)
+	}
+
+	// Lenient parsing keeps installers with minor mistakes usable, with
+	// diagnostics describing them
+	data, err := parser.ParseLenient()
+	if err != nil {
+		if errors.Is(err, fomod.ErrNoModuleConfig) {
+			// Has fomod directory but no ModuleConfig.xml
+			return nil, nil
+		}
+		return nil, fmt.Errorf("parse fomod: %w", err)
+	}
+
+	return data, nil
+}
+
+// PerformanceStage estimates texture memory and script load against hardware profiles.
+type PerformanceStage struct {
+	profiles []perf.Profile
+}
+
+// NewPerformanceStage creates a performance budget stage.
+// Profiles must be ordered from weakest to strongest; nil uses perf.DefaultProfiles.
+func NewPerformanceStage(profiles []perf.Profile) *PerformanceStage {
+	return &PerformanceStage{profiles: profiles}
+}
+
+// Name implements Analyzer.
+func (s *PerformanceStage) Name() string { return NamePerformance }
+
+// Inputs implements Analyzer.
+func (s *PerformanceStage) Inputs() Input { return InputArchives }
+
+// Analyze implements Analyzer.
+func (s *PerformanceStage) Analyze(ctx context.Context, in *Inputs) (interface{}, error) {
+	return s.AnalyzePerformance(ctx, in)
+}
+
+// AnalyzePerformance scans every kept archive and returns the typed budget.
+func (s *PerformanceStage) AnalyzePerformance(ctx context.Context, in *Inputs) (*perf.Budget, error) {
+	var mods []perf.ModBudget
+	for _, mod := range in.Mods {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if mod.ArchivePath == "" {
+			continue
+		}
+
+		budget, err := perf.ScanArchive(ctx, mod.ArchivePath, mod.Password, mod.ModID, mod.ModName)
+		if err != nil {
+			log.Printf("Warning: could not scan assets for mod %s: %v", mod.ModID, err)
+			continue
+		}
+		mods = append(mods, *budget)
+	}
+	return perf.Estimate(mods, s.profiles), nil
+}
+
+// HealthStage summarizes collection-level problems into a scored report.
+type HealthStage struct {
+	dlls *health.DLLAllowlist
+}
+
+// NewHealthStage creates a collection health stage with the built-in DLL allowlist.
+func NewHealthStage() *HealthStage {
+	return &HealthStage{dlls: health.DefaultDLLAllowlist()}
+}
+
+// NewHealthStageWithDLLAllowlist creates a collection health stage that
+// checks script extender plugins against a custom allowlist.
+func NewHealthStageWithDLLAllowlist(dlls *health.DLLAllowlist) *HealthStage {
+	return &HealthStage{dlls: dlls}
+}
+
+// Name implements Analyzer.
+func (s *HealthStage) Name() string { return NameHealth }
+
+// Inputs implements Analyzer.
+func (s *HealthStage) Inputs() Input { return InputManifests }
+
+// Analyze implements Analyzer.
+func (s *HealthStage) Analyze(ctx context.Context, in *Inputs) (interface{}, error) {
+	return s.AnalyzeHealth(ctx, in)
+}
+
+// AnalyzeHealth builds the health report and returns the typed result.
+func (s *HealthStage) AnalyzeHealth(ctx context.Context, in *Inputs) (*health.Report, error) {
+	report := health.NewReport(len(in.Mods))
+	var traits []health.ModTraits
+	var identities []health.ModIdentity
+	var versions []health.ModVersions
+	var games []health.ModGame
+	var references []health.ModReferences
+	var bugReports []health.ModBugReports
+	var casings []health.ModCasing
+	var assets []health.ModAssets
+
+	for _, mod := range in.Mods {
+		if len(mod.BugReports) > 0 {
+			bugReports = append(bugReports, health.ModBugReports{ModID: mod.ModID, ModName: mod.ModName, Titles: mod.BugReports})
+		}
+		if mod.ModGame != "" {
+			games = append(games, health.ModGame{ModID: mod.ModID, ModName: mod.ModName, Game: mod.ModGame})
+		}
+
+		if mod.Unavailable {
+			report.ModsFailed++
+			report.Add(health.Finding{
+				Type:     health.FindingUnavailableMod,
+				Severity: health.SeverityError,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message:  fmt.Sprintf("%s was deleted or hidden on Nexus; the collection cannot be installed until it is replaced", displayName(mod)),
+			})
+			continue
+		}
+
+		if mod.UnsupportedArchive {
+			report.Add(health.Finding{
+				Type:     health.FindingUnsupportedArchive,
+				Severity: health.SeverityWarning,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message:  fmt.Sprintf("Unsupported archive %s, analysis partial: %s", displayName(mod), mod.Error),
+			})
+			if mod.Manifest == nil {
+				report.ModsFailed++
+				continue
+			}
+		} else if mod.Error != "" {
+			report.ModsFailed++
+			report.Add(health.Finding{
+				Type:     health.FindingDownloadFailed,
+				Severity: health.SeverityError,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message:  fmt.Sprintf("Could not download or read %s: %s", displayName(mod), mod.Error),
+			})
+			continue
+		}
+
+		if mod.Manifest != nil && mod.Manifest.TotalCount == 0 {
+			report.Add(health.Finding{
+				Type:     health.FindingEmptyArchive,
+				Severity: health.SeverityWarning,
+				ModID:    mod.ModID,
+				ModName:  mod.ModName,
+				Message:  fmt.Sprintf("%s contains no files", displayName(mod)),
+			})
+		}
+
+		if mod.Manifest != nil {
+			traits = append(traits, health.TraitsFromManifest(mod.ModID, mod.ModName, mod.Manifest))
+		}
+
+		identities = append(identities, health.ModIdentity{
+			ModID:      mod.ModID,
+			ModName:    mod.ModName,
+			NexusModID: mod.NexusModID,
+			FileID:     mod.FileID,
+			Version:    mod.Version,
+			Manifest:   mod.Manifest,
+		})
+		versions = append(versions, modVersions(mod))
+		references = append(references, modReferences(ctx, mod))
+		casings = append(casings, modCasing(mod))
+		assets = append(assets, health.ModAssets{ModID: mod.ModID, ModName: mod.ModName, Manifest: mod.Manifest})
+	}
+
+	for _, f := range health.DetectDuplicates(identities) {
+		report.Add(f)
+	}
+	for _, f := range health.CheckVersions(versions) {
+		report.Add(f)
+	}
+	for _, f := range health.CheckGames(in.Game, games) {
+		report.Add(f)
+	}
+	if s.dlls != nil {
+		for _, f := range s.dlls.CheckDLLs(identities) {
+			report.Add(f)
+		}
+	}
+	for _, f := range health.CheckFrameworks(references, health.DefaultFrameworks) {
+		report.Add(f)
+	}
+	for _, f := range health.CheckAddressLibrary(in.GameVersion, identities) {
+		report.Add(f)
+	}
+	for _, f := range health.CheckCommunityReports(bugReports) {
+		report.Add(f)
+	}
+	for _, r := range references {
+		if r.References == nil {
+			continue
+		}
+		for _, p := range r.References.Plugins {
+			report.NativePlugins = append(report.NativePlugins, health.NativePlugin{ModID: r.ModID, ModName: r.ModName, PluginABI: p})
+		}
+	}
+	for _, f := range health.CheckPluginABI(in.GameVersion, report.NativePlugins) {
+		report.Add(f)
+	}
+
+	complete := len(assets) == len(in.Mods)
+	for _, a := range assets {
+		complete = complete && a.Manifest != nil
+	}
+	report.DeadWeight = health.FindDeadWeight(in.Game, assets, complete)
+	for _, f := range health.DeadWeightFindings(report.DeadWeight) {
+		report.Add(f)
+	}
+
+	report.Compatibility = health.ClassifyPlatforms(traits)
+	report.Linux = health.CheckSteamDeck(casings)
+	report.Finalize()
+	return report, nil
+}
+
+// modVersions collects the versions recorded for a mod. Plugin headers are
+// only compared when another stage in the run gathered them.
+func modVersions(mod Mod) health.ModVersions {
+	v := health.ModVersions{
+		ModID:         mod.ModID,
+		ModName:       mod.ModName,
+		PinnedVersion: mod.PinnedVersion,
+		FileVersion:   mod.Version,
+	}
+	for _, pf := range mod.Plugins {
+		if pf.Header == nil || pf.Header.Description == "" {
+			continue
+		}
+		v.Plugins = append(v.Plugins, health.PluginDescription{
+			Filename:    pf.Filename,
+			Description: pf.Header.Description,
+		})
+	}
+	return v
+}
+
+// modReferences collects what a mod refers to for the framework check.
+// Plugin masters are only known when another stage gathered plugin
+// headers, and scripts only when another stage kept the archive.
+func modReferences(ctx context.Context, mod Mod) health.ModReferences {
+	r := health.ModReferences{ModID: mod.ModID, ModName: mod.ModName, Manifest: mod.Manifest}
+	for _, pf := range mod.Plugins {
+		if pf.Header == nil {
+			continue
+		}
+		for _, m := range pf.Header.Masters {
+			r.Masters = append(r.Masters, m.Filename)
+		}
+	}
+	if mod.ArchivePath != "" {
+		refs, err := health.ScanReferences(ctx, mod.ArchivePath, mod.Password, health.DefaultFrameworks)
+		if err != nil {
+			log.Printf("Warning: could not scan scripts for mod %s: %v", mod.ModID, err)
+		} else {
+			r.References = refs
+		}
+	}
+	return r
+}
+
+// modCasing collects the paths and master references of a mod for the
+// Linux and Steam Deck checks. Masters are only known when another stage
+// gathered plugin headers.
+func modCasing(mod Mod) health.ModCasing {
+	c := health.ModCasing{ModID: mod.ModID, ModName: mod.ModName, Manifest: mod.Manifest}
+	for _, pf := range mod.Plugins {
+		p := health.PluginMasters{Filename: pf.Filename}
+		if pf.Header != nil {
+			for _, m := range pf.Header.Masters {
+				p.Masters = append(p.Masters, m.Filename)
+			}
+		}
+		c.Plugins = append(c.Plugins, p)
+	}
+	return c
+}
+
+// displayName returns the best human-readable name for a mod.
+func displayName(mod Mod) string {
+	if mod.ModName != "" {
+		return mod.ModName
+	}
+	if mod.Filename != "" {
+		return mod.Filename
+	}
+	return mod.ModID
+}
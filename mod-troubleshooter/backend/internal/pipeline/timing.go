@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/archive"
+)
+
+// DefaultSlowestMods is how many mods Timings lists by default.
+const DefaultSlowestMods = 20
+
+// Timing is how long gathering a single mod took, by phase.
+type Timing struct {
+	// Download is the time spent fetching the mod file. Downloads reused from
+	// an earlier analysis of the same revision take close to no time.
+	Download time.Duration
+	// Extract is the time spent listing the archive and extracting plugins.
+	Extract time.Duration
+	// Parse is the time spent parsing plugin headers.
+	Parse time.Duration
+}
+
+// Total returns the time spent on the mod across all phases.
+func (t Timing) Total() time.Duration {
+	return t.Download + t.Extract + t.Parse
+}
+
+// Phases of gathering, as reported in Timings.Bottleneck.
+const (
+	PhaseDownload = "download"
+	PhaseExtract  = "extract"
+	PhaseParse    = "parse"
+	PhaseAnalyze  = "analyze"
+)
+
+// ModTiming is the time spent gathering a single mod, in milliseconds.
+type ModTiming struct {
+	ModID      string `json:"modId"`
+	ModName    string `json:"modName,omitempty"`
+	DownloadMs int64  `json:"downloadMs"`
+	ExtractMs  int64  `json:"extractMs"`
+	ParseMs    int64  `json:"parseMs"`
+	TotalMs    int64  `json:"totalMs"`
+}
+
+// Timings breaks down where the time of an analysis went, in milliseconds.
+type Timings struct {
+	// TotalMs is the wall time of the whole analysis.
+	TotalMs int64 `json:"totalMs"`
+	// DownloadMs, ExtractMs and ParseMs are summed over all mods.
+	DownloadMs int64 `json:"downloadMs"`
+	ExtractMs  int64 `json:"extractMs"`
+	ParseMs    int64 `json:"parseMs"`
+	// AnalyzeMs is the time the analyzers took once all mods were gathered.
+	AnalyzeMs int64 `json:"analyzeMs"`
+	// Bottleneck is the phase that took the most time.
+	Bottleneck string `json:"bottleneck"`
+	// Slowest are the mods that took longest to gather, slowest first.
+	Slowest []ModTiming `json:"slowest"`
+	// Mirrors is how each download mirror fared during the analysis. Callers
+	// that track mirror health fill it in.
+	Mirrors []archive.MirrorStats `json:"mirrors,omitempty"`
+}
+
+// Timings summarizes the per-mod timings, listing at most limit of the
+// slowest mods. Callers fill in TotalMs and AnalyzeMs.
+func (in *Inputs) Timings(limit int) *Timings {
+	t := &Timings{Slowest: []ModTiming{}}
+	mods := make([]ModTiming, 0, len(in.Mods))
+	for _, mod := range in.Mods {
+		mt := ModTiming{
+			ModID:      mod.ModID,
+			ModName:    mod.ModName,
+			DownloadMs: mod.Timing.Download.Milliseconds(),
+			ExtractMs:  mod.Timing.Extract.Milliseconds(),
+			ParseMs:    mod.Timing.Parse.Milliseconds(),
+			TotalMs:    mod.Timing.Total().Milliseconds(),
+		}
+		t.DownloadMs += mt.DownloadMs
+		t.ExtractMs += mt.ExtractMs
+		t.ParseMs += mt.ParseMs
+		if mod.Timing.Total() > 0 {
+			mods = append(mods, mt)
+		}
+	}
+
+	sort.SliceStable(mods, func(i, j int) bool {
+		return mods[i].TotalMs > mods[j].TotalMs
+	})
+	if len(mods) > limit {
+		mods = mods[:limit]
+	}
+	t.Slowest = append(t.Slowest, mods...)
+
+	return t
+}
+
+// SetAnalyze records the analyzer and total times and picks the bottleneck.
+func (t *Timings) SetAnalyze(analyze, total time.Duration) {
+	t.AnalyzeMs = analyze.Milliseconds()
+	t.TotalMs = total.Milliseconds()
+
+	t.Bottleneck = PhaseDownload
+	longest := t.DownloadMs
+	for _, phase := range []struct {
+		name string
+		ms   int64
+	}{
+		{PhaseExtract, t.ExtractMs},
+		{PhaseParse, t.ParseMs},
+		{PhaseAnalyze, t.AnalyzeMs},
+	} {
+		if phase.ms > longest {
+			t.Bottleneck, longest = phase.name, phase.ms
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInputs_Timings(t *testing.T) {
+	in := &Inputs{Mods: []Mod{
+		{ModID: "a", Timing: Timing{Download: 2 * time.Second, Extract: 500 * time.Millisecond}},
+		{ModID: "b", Timing: Timing{Download: 10 * time.Second, Parse: time.Second}},
+		{ModID: "hidden", Unavailable: true},
+		{ModID: "c", Timing: Timing{Extract: 3 * time.Second}},
+	}}
+
+	timings := in.Timings(2)
+	timings.SetAnalyze(time.Second, 20*time.Second)
+
+	if timings.DownloadMs != 12000 || timings.ExtractMs != 3500 || timings.ParseMs != 1000 {
+		t.Errorf("expected phase totals 12000/3500/1000, got %d/%d/%d", timings.DownloadMs, timings.ExtractMs, timings.ParseMs)
+	}
+	if timings.AnalyzeMs != 1000 || timings.TotalMs != 20000 {
+		t.Errorf("expected analyze 1000 and total 20000, got %d and %d", timings.AnalyzeMs, timings.TotalMs)
+	}
+	if timings.Bottleneck != PhaseDownload {
+		t.Errorf("expected download bottleneck, got %s", timings.Bottleneck)
+	}
+
+	if len(timings.Slowest) != 2 {
+		t.Fatalf("expected 2 slowest mods, got %d", len(timings.Slowest))
+	}
+	if timings.Slowest[0].ModID != "b" || timings.Slowest[0].TotalMs != 11000 {
+		t.Errorf("expected b to be slowest with 11000ms, got %+v", timings.Slowest[0])
+	}
+	if timings.Slowest[1].ModID != "c" {
+		t.Errorf("expected c to be second slowest, got %s", timings.Slowest[1].ModID)
+	}
+}
+
+func TestTimings_Bottleneck(t *testing.T) {
+	timings := (&Inputs{Mods: []Mod{{ModID: "a", Timing: Timing{Download: time.Millisecond, Extract: 5 * time.Millisecond}}}}).Timings(DefaultSlowestMods)
+	timings.SetAnalyze(time.Second, time.Second)
+	if timings.Bottleneck != PhaseAnalyze {
+		t.Errorf("expected analyze bottleneck, got %s", timings.Bottleneck)
+	}
+
+	empty := (&Inputs{}).Timings(DefaultSlowestMods)
+	if empty.Slowest == nil || len(empty.Slowest) != 0 {
+		t.Errorf("expected empty slowest list, got %v", empty.Slowest)
+	}
+}
+
+// slowFetcher delays every fetch.
+type slowFetcher struct {
+	fakeFetcher
+	delay time.Duration
+}
+
+func (f *slowFetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	time.Sleep(f.delay)
+	return f.fakeFetcher.Fetch(ctx, src)
+}
+
+func TestGatherer_RecordsTiming(t *testing.T) {
+	dir := t.TempDir()
+	fetcher := &slowFetcher{
+		fakeFetcher: fakeFetcher{paths: map[string]string{
+			"a": createZip(t, dir, "a.zip", map[string]string{"meshes/a.nif": "a"}),
+		}},
+		delay: 20 * time.Millisecond,
+	}
+	g := NewGatherer(GathererConfig{Fetcher: fetcher})
+
+	in, release, err := g.Gather(context.Background(), []Source{{ModID: "a", Filename: "a.zip"}}, InputManifests)
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	defer release()
+
+	timing := in.Mods[0].Timing
+	if timing.Download < 20*time.Millisecond {
+		t.Errorf("expected download time of at least 20ms, got %v", timing.Download)
+	}
+	if timing.Extract <= 0 {
+		t.Errorf("expected extract time to be recorded, got %v", timing.Extract)
+	}
+}
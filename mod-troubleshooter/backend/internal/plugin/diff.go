@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeStatus describes how a plugin changed between two versions of a mod.
+type ChangeStatus string
+
+const (
+	// ChangeAdded indicates the plugin only exists in the new version.
+	ChangeAdded ChangeStatus = "added"
+	// ChangeRemoved indicates the plugin only exists in the old version.
+	ChangeRemoved ChangeStatus = "removed"
+	// ChangeModified indicates the plugin header changed.
+	ChangeModified ChangeStatus = "modified"
+	// ChangeUnchanged indicates the compared header metadata is identical.
+	ChangeUnchanged ChangeStatus = "unchanged"
+	// ChangeUnknown indicates the plugin exists in both versions but a header
+	// could not be parsed, so only its presence is known.
+	ChangeUnknown ChangeStatus = "unknown"
+)
+
+// HeaderDiff describes the header changes of a plugin between two versions.
+type HeaderDiff struct {
+	// Filename is the plugin filename.
+	Filename string `json:"filename"`
+	// Status is how the plugin changed.
+	Status ChangeStatus `json:"status"`
+	// MastersAdded are masters only the new version depends on.
+	MastersAdded []string `json:"mastersAdded,omitempty"`
+	// MastersRemoved are masters only the old version depends on.
+	MastersRemoved []string `json:"mastersRemoved,omitempty"`
+	// RecordsBefore is the record count of the old version.
+	RecordsBefore uint32 `json:"recordsBefore"`
+	// RecordsAfter is the record count of the new version.
+	RecordsAfter uint32 `json:"recordsAfter"`
+	// LightBefore is whether the old version has the ESL flag.
+	LightBefore bool `json:"lightBefore"`
+	// LightAfter is whether the new version has the ESL flag.
+	LightAfter bool `json:"lightAfter"`
+	// Compacted is true when the new version's FormIDs were renumbered, either
+	// by compaction or by toggling the ESL flag.
+	Compacted bool `json:"compacted"`
+	// SaveBreaking is true when the change can break saves made with the old version.
+	SaveBreaking bool `json:"saveBreaking"`
+	// Reasons explains why the change is save-breaking.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// DiffHeaders compares two versions of a plugin header. Either header may be
+// nil when the plugin was added or removed.
+func DiffHeaders(before, after *PluginHeader) HeaderDiff {
+	switch {
+	case before == nil && after == nil:
+		return HeaderDiff{}
+	case before == nil:
+		return HeaderDiff{
+			Filename:     after.Filename,
+			Status:       ChangeAdded,
+			RecordsAfter: after.NumRecords,
+			LightAfter:   after.Flags.IsLight,
+		}
+	case after == nil:
+		return HeaderDiff{
+			Filename:      before.Filename,
+			Status:        ChangeRemoved,
+			RecordsBefore: before.NumRecords,
+			LightBefore:   before.Flags.IsLight,
+			SaveBreaking:  true,
+			Reasons:       []string{"Plugin removed"},
+		}
+	}
+
+	diff := HeaderDiff{
+		Filename:      after.Filename,
+		Status:        ChangeUnchanged,
+		RecordsBefore: before.NumRecords,
+		RecordsAfter:  after.NumRecords,
+		LightBefore:   before.Flags.IsLight,
+		LightAfter:    after.Flags.IsLight,
+	}
+	diff.MastersAdded = missingMasters(after.Masters, before.Masters)
+	diff.MastersRemoved = missingMasters(before.Masters, after.Masters)
+
+	if len(diff.MastersRemoved) > 0 {
+		// Records that depended on the master are gone from saves that used them
+		diff.SaveBreaking = true
+		diff.Reasons = append(diff.Reasons, fmt.Sprintf("Removed masters: %s", strings.Join(diff.MastersRemoved, ", ")))
+	}
+	if diff.LightBefore != diff.LightAfter {
+		// Light plugins use a different FormID space, so existing references no longer resolve
+		diff.Compacted = true
+		diff.SaveBreaking = true
+		diff.Reasons = append(diff.Reasons, "ESL flag changed, which renumbers the plugin's FormIDs")
+	} else if after.NextObjectID != 0 && after.NextObjectID < before.NextObjectID {
+		// The editor only hands out lower FormIDs again after compacting them
+		diff.Compacted = true
+		diff.SaveBreaking = true
+		diff.Reasons = append(diff.Reasons, "FormIDs were compacted, which renumbers the plugin's records")
+	}
+
+	if len(diff.MastersAdded) > 0 || len(diff.MastersRemoved) > 0 ||
+		diff.RecordsBefore != diff.RecordsAfter || diff.Compacted {
+		diff.Status = ChangeModified
+	}
+
+	return diff
+}
+
+// missingMasters returns the masters in a that are not in b, compared case-insensitively.
+func missingMasters(a, b []Master) []string {
+	present := make(map[string]bool, len(b))
+	for _, m := range b {
+		present[strings.ToLower(m.Filename)] = true
+	}
+
+	var missing []string
+	for _, m := range a {
+		if !present[strings.ToLower(m.Filename)] {
+			missing = append(missing, m.Filename)
+		}
+	}
+	return missing
+}
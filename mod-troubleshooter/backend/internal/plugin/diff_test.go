@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffHeaders(t *testing.T) {
+	base := &PluginHeader{
+		Filename:   "MyMod.esp",
+		Masters:    []Master{{Filename: "Skyrim.esm"}, {Filename: "Dawnguard.esm"}},
+		NumRecords: 100,
+	}
+
+	tests := []struct {
+		name           string
+		before         *PluginHeader
+		after          *PluginHeader
+		status         ChangeStatus
+		mastersAdded   []string
+		mastersRemoved []string
+		saveBreaking   bool
+	}{
+		{"unchanged", base, &PluginHeader{Filename: "MyMod.esp", Masters: []Master{{Filename: "SKYRIM.ESM"}, {Filename: "Dawnguard.esm"}}, NumRecords: 100}, ChangeUnchanged, nil, nil, false},
+		{"records", base, &PluginHeader{Filename: "MyMod.esp", Masters: base.Masters, NumRecords: 120}, ChangeModified, nil, nil, false},
+		{"master added", base, &PluginHeader{Filename: "MyMod.esp", Masters: append([]Master{{Filename: "Update.esm"}}, base.Masters...), NumRecords: 100}, ChangeModified, []string{"Update.esm"}, nil, false},
+		{"master removed", base, &PluginHeader{Filename: "MyMod.esp", Masters: []Master{{Filename: "Skyrim.esm"}}, NumRecords: 100}, ChangeModified, nil, []string{"Dawnguard.esm"}, true},
+		{"esl flagged", base, &PluginHeader{Filename: "MyMod.esp", Masters: base.Masters, NumRecords: 100, Flags: PluginFlags{IsLight: true}}, ChangeModified, nil, nil, true},
+		{"added", nil, base, ChangeAdded, nil, nil, false},
+		{"compacted", &PluginHeader{Filename: "MyMod.esp", Masters: base.Masters, NumRecords: 100, NextObjectID: 0x1F00}, &PluginHeader{Filename: "MyMod.esp", Masters: base.Masters, NumRecords: 100, NextObjectID: 0x0900}, ChangeModified, nil, nil, true},
+		{"new records", &PluginHeader{Filename: "MyMod.esp", Masters: base.Masters, NumRecords: 100, NextObjectID: 0x0900}, &PluginHeader{Filename: "MyMod.esp", Masters: base.Masters, NumRecords: 100, NextObjectID: 0x0A00}, ChangeUnchanged, nil, nil, false},
+		{"removed", base, nil, ChangeRemoved, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffHeaders(tt.before, tt.after)
+			if diff.Filename != "MyMod.esp" {
+				t.Errorf("expected filename MyMod.esp, got %q", diff.Filename)
+			}
+			if diff.Status != tt.status {
+				t.Errorf("expected status %s, got %s", tt.status, diff.Status)
+			}
+			if !reflect.DeepEqual(diff.MastersAdded, tt.mastersAdded) {
+				t.Errorf("expected masters added %v, got %v", tt.mastersAdded, diff.MastersAdded)
+			}
+			if !reflect.DeepEqual(diff.MastersRemoved, tt.mastersRemoved) {
+				t.Errorf("expected masters removed %v, got %v", tt.mastersRemoved, diff.MastersRemoved)
+			}
+			if diff.SaveBreaking != tt.saveBreaking {
+				t.Errorf("expected save breaking %v, got %v", tt.saveBreaking, diff.SaveBreaking)
+			}
+			if diff.SaveBreaking && len(diff.Reasons) == 0 {
+				t.Error("expected reasons for a save-breaking change")
+			}
+		})
+	}
+}
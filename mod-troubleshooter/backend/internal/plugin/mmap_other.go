@@ -0,0 +1,13 @@
+//go:build !unix
+
+package plugin
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is not supported on this platform; callers fall back to reading.
+func mapFile(file *os.File) ([]byte, func(), error) {
+	return nil, nil, errors.New("memory mapping not supported")
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// mapFile memory-maps a file read-only. The returned function unmaps it.
+func mapFile(file *os.File) ([]byte, func(), error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 || int64(int(size)) != size {
+		return nil, nil, errors.New("file size cannot be mapped")
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
@@ -91,13 +91,13 @@ func (p *Parser) Parse(ctx context.Context, r io.Reader, filename string) (*Plug
 
 // recordHeader represents the header portion of a record.
 type recordHeader struct {
-	signature string
-	dataSize  uint32
-	flags     uint32
-	formID    uint32
-	timestamp uint32 // or version control info
+	signature   string
+	dataSize    uint32
+	flags       uint32
+	formID      uint32
+	timestamp   uint32 // or version control info
 	formVersion uint16
-	unknown   uint16
+	unknown     uint16
 }
 
 // readRecordHeader reads the fixed-size record header.
@@ -170,6 +170,7 @@ func (p *Parser) parseSubrecords(data []byte, header *PluginHeader) error {
 			// HEDR is 12 bytes: float32 version, uint32 numRecords, uint32 nextObjectID
 			if len(subData) >= 12 {
 				header.NumRecords = binary.LittleEndian.Uint32(subData[4:8])
+				header.NextObjectID = binary.LittleEndian.Uint32(subData[8:12])
 			}
 
 		case SignatureCNAM:
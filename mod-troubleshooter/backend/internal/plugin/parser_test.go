@@ -120,6 +120,10 @@ func TestParser_Parse_ESP(t *testing.T) {
 	if header.NumRecords != 100 {
 		t.Errorf("expected 100 records, got %d", header.NumRecords)
 	}
+
+	if header.NextObjectID != 1 {
+		t.Errorf("expected next object ID 1, got %d", header.NextObjectID)
+	}
 }
 
 func TestParser_Parse_ESM(t *testing.T) {
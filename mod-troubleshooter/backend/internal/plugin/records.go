@@ -0,0 +1,186 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrStopScan can be returned by a RecordFunc to end a scan early without error.
+var ErrStopScan = errors.New("stop scan")
+
+// SignatureGRUP is the signature of a record group header.
+const SignatureGRUP = "GRUP"
+
+// FlagCompressed indicates a record's data is zlib-compressed.
+const FlagCompressed uint32 = 0x00040000
+
+// recordHeaderSize is the size of record and group headers (Skyrim+).
+const recordHeaderSize = 24
+
+// scanCheckInterval is how many records are scanned between context checks.
+const scanCheckInterval = 4096
+
+// Record is a single record found while scanning a plugin.
+type Record struct {
+	// Signature is the record type, such as "NPC_" or "WEAP".
+	Signature string
+	// Flags are the record flags.
+	Flags uint32
+	// FormID is the record's form ID, with the load order index of its owning plugin.
+	FormID uint32
+	// Offset is the position of the record header in the file.
+	Offset int64
+	// Data is the raw record data, compressed if IsCompressed reports true.
+	// It is only valid until the RecordFunc returns.
+	Data []byte
+}
+
+// IsCompressed reports whether the record data is zlib-compressed.
+func (r *Record) IsCompressed() bool {
+	return r.Flags&FlagCompressed != 0
+}
+
+// RecordFunc is called for each record in a scan.
+type RecordFunc func(rec *Record) error
+
+// ScanFile calls fn for every record in a plugin file, including the TES4 header.
+// Groups are walked, not reported. The file is memory-mapped where supported, so
+// full scans of master-sized plugins don't copy record data; otherwise it is read
+// sequentially through a reusable buffer.
+func (p *Parser) ScanFile(ctx context.Context, filePath string, fn RecordFunc) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("open plugin file: %w", err)
+	}
+	defer file.Close()
+
+	data, unmap, err := mapFile(file)
+	if err == nil {
+		defer unmap()
+		return p.ScanBytes(ctx, data, fn)
+	}
+
+	return p.ScanReader(ctx, bufio.NewReaderSize(file, 1<<20), fn)
+}
+
+// ScanBytes calls fn for every record in an in-memory plugin.
+func (p *Parser) ScanBytes(ctx context.Context, data []byte, fn RecordFunc) error {
+	var offset int64
+	var rec Record
+
+	for count := 0; offset < int64(len(data)); count++ {
+		if count%scanCheckInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if int64(len(data))-offset < recordHeaderSize {
+			return fmt.Errorf("%w: record header at offset %d", ErrTruncatedFile, offset)
+		}
+		header := data[offset : offset+recordHeaderSize]
+		if err := p.readScanHeader(header, offset, &rec); err != nil {
+			return err
+		}
+		offset += recordHeaderSize
+
+		// Group contents follow their header and are walked like top-level records
+		if rec.Signature == SignatureGRUP {
+			continue
+		}
+
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if int64(len(data))-offset < size {
+			return fmt.Errorf("%w: %s record at offset %d", ErrTruncatedFile, rec.Signature, rec.Offset)
+		}
+		rec.Data = data[offset : offset+size]
+		offset += size
+
+		if err := fn(&rec); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if offset == 0 {
+		return fmt.Errorf("%w: empty file", ErrTruncatedFile)
+	}
+	return nil
+}
+
+// ScanReader calls fn for every record read from r.
+// Record data is read into a buffer that is reused between records.
+func (p *Parser) ScanReader(ctx context.Context, r io.Reader, fn RecordFunc) error {
+	var offset int64
+	var rec Record
+	var header [recordHeaderSize]byte
+	var buf []byte
+
+	for count := 0; ; count++ {
+		if count%scanCheckInterval == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) && offset > 0 {
+				return nil
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("%w: record header at offset %d", ErrTruncatedFile, offset)
+			}
+			return fmt.Errorf("read record header: %w", err)
+		}
+		if err := p.readScanHeader(header[:], offset, &rec); err != nil {
+			return err
+		}
+		offset += recordHeaderSize
+
+		if rec.Signature == SignatureGRUP {
+			continue
+		}
+
+		size := int(binary.LittleEndian.Uint32(header[4:8]))
+		if cap(buf) < size {
+			buf = make([]byte, size)
+		}
+		rec.Data = buf[:size]
+		if _, err := io.ReadFull(r, rec.Data); err != nil {
+			return fmt.Errorf("%w: %s record at offset %d", ErrTruncatedFile, rec.Signature, rec.Offset)
+		}
+		offset += int64(size)
+
+		if err := fn(&rec); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// readScanHeader decodes a record or group header into rec.
+// The first record of a plugin must be its TES4 header.
+func (p *Parser) readScanHeader(header []byte, offset int64, rec *Record) error {
+	signature := string(header[0:4])
+	for _, c := range signature {
+		if c < 32 || c > 126 {
+			return fmt.Errorf("%w: invalid characters in signature at offset %d", ErrNotPlugin, offset)
+		}
+	}
+	if offset == 0 && signature != SignatureTES4 {
+		return fmt.Errorf("%w: expected TES4, got %s", ErrInvalidSignature, signature)
+	}
+
+	*rec = Record{
+		Signature: signature,
+		Flags:     binary.LittleEndian.Uint32(header[8:12]),
+		FormID:    binary.LittleEndian.Uint32(header[12:16]),
+		Offset:    offset,
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeRecordHeader writes a 24-byte record or group header.
+func writeRecordHeader(buf *bytes.Buffer, signature string, size, flags, formID uint32) {
+	buf.WriteString(signature)
+	binary.Write(buf, binary.LittleEndian, size)
+	binary.Write(buf, binary.LittleEndian, flags)
+	binary.Write(buf, binary.LittleEndian, formID)
+	buf.Write(make([]byte, 8))
+}
+
+// createTestRecords builds a plugin with one group holding two records.
+func createTestRecords(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.Write(createTestPlugin(t, testPluginOptions{}))
+	writeRecordHeader(&buf, SignatureGRUP, recordHeaderSize*3+7, 0, 0)
+	writeRecordHeader(&buf, "WEAP", 4, 0, 0x00000800)
+	buf.WriteString("weap")
+	writeRecordHeader(&buf, "NPC_", 3, FlagCompressed, 0x01000801)
+	buf.WriteString("npc")
+	return buf.Bytes()
+}
+
+type scannedRecord struct {
+	Signature  string
+	FormID     uint32
+	Compressed bool
+	Data       string
+}
+
+func collectRecords(recs *[]scannedRecord) RecordFunc {
+	return func(rec *Record) error {
+		*recs = append(*recs, scannedRecord{rec.Signature, rec.FormID, rec.IsCompressed(), string(rec.Data)})
+		return nil
+	}
+}
+
+func TestParser_Scan(t *testing.T) {
+	parser := NewParser()
+	ctx := context.Background()
+	data := createTestRecords(t)
+
+	path := filepath.Join(t.TempDir(), "Test.esm")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+
+	var mapped, streamed []scannedRecord
+	if err := parser.ScanFile(ctx, path, collectRecords(&mapped)); err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if err := parser.ScanReader(ctx, bytes.NewReader(data), collectRecords(&streamed)); err != nil {
+		t.Fatalf("ScanReader() error = %v", err)
+	}
+
+	if len(mapped) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(mapped))
+	}
+	if mapped[0].Signature != SignatureTES4 {
+		t.Errorf("expected TES4 first, got %s", mapped[0].Signature)
+	}
+	want := scannedRecord{"NPC_", 0x01000801, true, "npc"}
+	if mapped[2] != want {
+		t.Errorf("expected %+v, got %+v", want, mapped[2])
+	}
+	if !reflect.DeepEqual(mapped, streamed) {
+		t.Errorf("expected mapped and streamed scans to match, got %+v and %+v", mapped, streamed)
+	}
+}
+
+func TestParser_Scan_Stop(t *testing.T) {
+	parser := NewParser()
+	data := createTestRecords(t)
+
+	count := 0
+	err := parser.ScanBytes(context.Background(), data, func(rec *Record) error {
+		count++
+		if rec.Signature == "WEAP" {
+			return ErrStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected no error when stopping, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected scan to stop after 2 records, got %d", count)
+	}
+}
+
+func TestParser_Scan_Errors(t *testing.T) {
+	parser := NewParser()
+	ctx := context.Background()
+	data := createTestRecords(t)
+	noop := func(*Record) error { return nil }
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrTruncatedFile},
+		{"truncated record", data[:len(data)-1], ErrTruncatedFile},
+		{"not a plugin", append([]byte("WEAP"), data[4:]...), ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parser.ScanBytes(ctx, tt.data, noop); !errors.Is(err, tt.want) {
+				t.Errorf("ScanBytes() error = %v, want %v", err, tt.want)
+			}
+			if err := parser.ScanReader(ctx, bytes.NewReader(tt.data), noop); !errors.Is(err, tt.want) {
+				t.Errorf("ScanReader() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
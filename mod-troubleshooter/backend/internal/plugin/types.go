@@ -48,6 +48,8 @@ type PluginHeader struct {
 	FormVersion uint16 `json:"formVersion"`
 	// NumRecords is the number of records in the file (if available).
 	NumRecords uint32 `json:"numRecords,omitempty"`
+	// NextObjectID is the next FormID the editor would assign (if available).
+	NextObjectID uint32 `json:"nextObjectId,omitempty"`
 }
 
 // Record flag constants for the TES4 record.
@@ -0,0 +1,130 @@
+// Package proxy makes requests forwarded by a trusted reverse proxy, such as
+// nginx or Caddy, look like they came straight from the client.
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver rewrites requests using X-Forwarded-* headers set by trusted proxies.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver creates a resolver trusting the given IPs and CIDR ranges.
+// With no trusted proxies, forwarded headers are always ignored.
+func NewResolver(trusted []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, entry := range trusted {
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+	return r, nil
+}
+
+// Handler wraps next so that, for requests from a trusted proxy, RemoteAddr
+// is the client address from X-Forwarded-For, Host is X-Forwarded-Host and
+// URL.Scheme is X-Forwarded-Proto. Headers from other peers are ignored, so
+// clients can't spoof them.
+func (res *Resolver) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(res.trusted) > 0 && res.isTrusted(remoteIP(r.RemoteAddr)) {
+			r = res.resolve(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resolve returns a copy of r with the forwarded client details applied.
+func (res *Resolver) resolve(r *http.Request) *http.Request {
+	r = r.Clone(r.Context())
+
+	if client, ok := res.clientIP(r.Header.Values("X-Forwarded-For")); ok {
+		r.RemoteAddr = net.JoinHostPort(client.String(), "0")
+	}
+	if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+		r.Host = host
+	}
+	switch proto := strings.ToLower(firstValue(r.Header.Get("X-Forwarded-Proto"))); proto {
+	case "http", "https":
+		r.URL.Scheme = proto
+	}
+
+	return r
+}
+
+// clientIP walks X-Forwarded-For from the nearest hop back, returning the
+// first address that isn't a trusted proxy. Addresses before it were supplied
+// by the client and can't be trusted.
+func (res *Resolver) clientIP(headers []string) (netip.Addr, bool) {
+	var hops []string
+	for _, h := range headers {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	var last netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		last = addr.Unmap()
+		if !res.isTrusted(last) {
+			return last, true
+		}
+	}
+	// Every hop was a trusted proxy; the furthest one is the best we know
+	return last, last.IsValid()
+}
+
+func (res *Resolver) isTrusted(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range res.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP parses the IP of a host:port remote address.
+func remoteIP(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// parsePrefix parses an IP or CIDR range.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// firstValue returns the first entry of a comma-separated header value.
+func firstValue(v string) string {
+	first, _, _ := strings.Cut(v, ",")
+	return strings.TrimSpace(first)
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolver_Handler(t *testing.T) {
+	res, err := NewResolver([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatalf("NewResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		wantAddr   string
+		wantHost   string
+		wantScheme string
+	}{
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.1.2.3:5000",
+			headers: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7",
+				"X-Forwarded-Host":  "mods.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+			wantAddr:   "203.0.113.7:0",
+			wantHost:   "mods.example.com",
+			wantScheme: "https",
+		},
+		{
+			name:       "spoofed hops before the proxy are skipped",
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.7, 10.9.9.9"},
+			wantAddr:   "203.0.113.7:0",
+			wantHost:   "backend",
+		},
+		{
+			name:       "untrusted peer",
+			remoteAddr: "198.51.100.1:5000",
+			headers: map[string]string{
+				"X-Forwarded-For":  "203.0.113.7",
+				"X-Forwarded-Host": "evil.example.com",
+			},
+			wantAddr: "198.51.100.1:5000",
+			wantHost: "backend",
+		},
+		{
+			name:       "ipv6 proxy with unknown scheme",
+			remoteAddr: "[::1]:5000",
+			headers: map[string]string{
+				"X-Forwarded-For":   "2001:db8::1",
+				"X-Forwarded-Proto": "gopher",
+			},
+			wantAddr: "[2001:db8::1]:0",
+			wantHost: "backend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			handler := res.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			req.Host = "backend"
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got.RemoteAddr != tt.wantAddr {
+				t.Errorf("RemoteAddr = %q, want %q", got.RemoteAddr, tt.wantAddr)
+			}
+			if got.Host != tt.wantHost {
+				t.Errorf("Host = %q, want %q", got.Host, tt.wantHost)
+			}
+			if got.URL.Scheme != tt.wantScheme {
+				t.Errorf("Scheme = %q, want %q", got.URL.Scheme, tt.wantScheme)
+			}
+		})
+	}
+}
+
+func TestNewResolver_Invalid(t *testing.T) {
+	if _, err := NewResolver([]string{"not-an-ip"}); err == nil {
+		t.Error("expected error for invalid proxy address")
+	}
+}
@@ -0,0 +1,129 @@
+package resolve
+
+import "time"
+
+// Plan is the consolidated outcome of a resolution session.
+type Plan struct {
+	SessionID   string    `json:"sessionId"`
+	Slug        string    `json:"slug,omitempty"`
+	Revision    int       `json:"revision,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// ModOrder lists the install order changes that make the chosen winners
+	// win by load order.
+	ModOrder []OrderRule `json:"modOrder"`
+	// Overrides lists the conflicts whose chosen winner differs from the load
+	// order winner.
+	Overrides []Override `json:"overrides"`
+	// Decisions are the decisions made during the session, in order.
+	Decisions []Decision `json:"decisions"`
+	// Stats summarizes the session.
+	Stats Stats `json:"stats"`
+}
+
+// OrderRule asks for one mod to be installed after another.
+type OrderRule struct {
+	// Mod is the mod to move later.
+	Mod string `json:"mod"`
+	// ModName is the display name of Mod.
+	ModName string `json:"modName"`
+	// After is the mod it must be installed after.
+	After string `json:"after"`
+	// AfterName is the display name of After.
+	AfterName string `json:"afterName"`
+	// Paths are the conflicts the rule resolves.
+	Paths []string `json:"paths"`
+	// Contradicted is true when another conflict needs After to keep winning
+	// over Mod, so reordering cannot satisfy both and files must be hidden instead.
+	Contradicted bool `json:"contradicted"`
+}
+
+// Override is a conflict whose chosen winner does not win by load order.
+type Override struct {
+	// Path is the conflicting file.
+	Path string `json:"path"`
+	// Winner is the mod whose file should be used.
+	Winner string `json:"winner"`
+	// Status is how the winner was chosen.
+	Status Status `json:"status"`
+	// Hide lists the mods loading after the winner, whose copies of the file
+	// must be hidden if the install order is left unchanged.
+	Hide []string `json:"hide"`
+}
+
+// Plan builds the resolution plan of a session from its current state.
+func (m *Manager) Plan(id string) (*Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.getLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	st := s.state()
+
+	plan := &Plan{
+		SessionID:   st.ID,
+		Slug:        st.Slug,
+		Revision:    st.Revision,
+		GeneratedAt: m.now().UTC(),
+		ModOrder:    []OrderRule{},
+		Overrides:   []Override{},
+		Decisions:   append([]Decision{}, s.decisions...),
+		Stats:       st.Stats,
+	}
+
+	rules := make(map[[2]string]*OrderRule)
+	var order [][2]string
+	// kept records the winners that already beat an earlier mod by load order
+	kept := make(map[[2]string]bool)
+	for _, res := range st.Resolutions {
+		if res.Winner == res.DefaultWinner {
+			for _, src := range res.Sources {
+				if src.ModID == res.Winner {
+					break
+				}
+				kept[[2]string{res.Winner, src.ModID}] = true
+			}
+			continue
+		}
+
+		override := Override{Path: res.Path, Winner: res.Winner, Status: res.Status, Hide: []string{}}
+		names := make(map[string]string, len(res.Sources))
+		winnerSeen := false
+		for _, src := range res.Sources {
+			names[src.ModID] = src.ModName
+			if src.ModID == res.Winner {
+				winnerSeen = true
+				continue
+			}
+			if winnerSeen {
+				override.Hide = append(override.Hide, src.ModID)
+			}
+		}
+		plan.Overrides = append(plan.Overrides, override)
+
+		for _, loser := range override.Hide {
+			key := [2]string{res.Winner, loser}
+			rule, ok := rules[key]
+			if !ok {
+				rule = &OrderRule{
+					Mod:       res.Winner,
+					ModName:   names[res.Winner],
+					After:     loser,
+					AfterName: names[loser],
+				}
+				rules[key] = rule
+				order = append(order, key)
+			}
+			rule.Paths = append(rule.Paths, res.Path)
+		}
+	}
+
+	for _, key := range order {
+		rule := rules[key]
+		rule.Contradicted = kept[[2]string{key[1], key[0]}]
+		plan.ModOrder = append(plan.ModOrder, *rule)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,422 @@
+// Package resolve tracks interactive conflict resolution sessions, where the
+// winner of each conflict is chosen in turn and undecided conflicts between
+// the same mods follow the choices already made.
+package resolve
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+// Default limits for resolution sessions.
+const (
+	DefaultTTL         = 2 * time.Hour
+	DefaultMaxSessions = 100
+)
+
+// Errors returned by the session manager.
+var (
+	ErrNotFound        = errors.New("resolution session not found")
+	ErrUnknownConflict = errors.New("conflict not found in session")
+	ErrUnknownMod      = errors.New("mod does not provide the conflicting file")
+)
+
+// Status describes how the winner of a conflict was determined.
+type Status string
+
+const (
+	// StatusDefault indicates the load order winner, with no decision involved.
+	StatusDefault Status = "default"
+	// StatusAccepted indicates the load order winner was confirmed.
+	StatusAccepted Status = "accepted"
+	// StatusOverridden indicates a different winner was chosen.
+	StatusOverridden Status = "overridden"
+	// StatusDerived indicates the winner follows a decision on another
+	// conflict between the same mods.
+	StatusDerived Status = "derived"
+)
+
+// Decision is the winner chosen for a conflict.
+type Decision struct {
+	// Path is the conflicting file.
+	Path string `json:"path"`
+	// Winner is the ID of the mod whose file should be used.
+	Winner string `json:"winner"`
+	// Reason optionally explains the choice.
+	Reason string `json:"reason,omitempty"`
+	// DecidedAt is when the decision was made.
+	DecidedAt time.Time `json:"decidedAt"`
+}
+
+// Resolution is the current state of a single conflict.
+type Resolution struct {
+	// Path is the conflicting file.
+	Path string `json:"path"`
+	// Severity is the severity of the conflict.
+	Severity conflict.Severity `json:"severity"`
+	// Sources lists the mods providing the file, in load order.
+	Sources []conflict.ModFile `json:"sources"`
+	// DefaultWinner is the mod that wins by load order.
+	DefaultWinner string `json:"defaultWinner"`
+	// Winner is the mod that wins after the session's decisions.
+	Winner string `json:"winner"`
+	// Status is how the winner was determined.
+	Status Status `json:"status"`
+	// DerivedFrom is the path of the decision a derived winner follows.
+	DerivedFrom string `json:"derivedFrom,omitempty"`
+	// Reason is the reason given for a decision on this conflict.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Stats summarizes the progress of a session.
+type Stats struct {
+	Total      int `json:"total"`
+	Accepted   int `json:"accepted"`
+	Overridden int `json:"overridden"`
+	Derived    int `json:"derived"`
+	// Remaining is the number of conflicts still at their load order default.
+	Remaining int `json:"remaining"`
+}
+
+// State is a snapshot of a resolution session.
+type State struct {
+	ID          string       `json:"id"`
+	Slug        string       `json:"slug,omitempty"`
+	Revision    int          `json:"revision,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+	Resolutions []Resolution `json:"resolutions"`
+	Stats       Stats        `json:"stats"`
+}
+
+// Config holds configuration for a Manager.
+type Config struct {
+	// TTL is how long a session is kept after its last decision.
+	TTL time.Duration
+	// MaxSessions bounds how many sessions are kept at once; the least
+	// recently used session is dropped first.
+	MaxSessions int
+}
+
+// Manager keeps resolution sessions in memory.
+type Manager struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	maxSessions int
+	sessions    map[string]*session
+	now         func() time.Time
+}
+
+// session holds the conflicts under resolution and the decisions made so far.
+type session struct {
+	id        string
+	slug      string
+	revision  int
+	createdAt time.Time
+	updatedAt time.Time
+	conflicts []conflict.Conflict
+	byPath    map[string]int
+	// decisions are kept in the order they were made; later decisions take
+	// precedence when deriving winners
+	decisions []Decision
+}
+
+// NewManager creates a new session manager.
+func NewManager(cfg Config) *Manager {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = DefaultMaxSessions
+	}
+	return &Manager{
+		ttl:         cfg.TTL,
+		maxSessions: cfg.MaxSessions,
+		sessions:    make(map[string]*session),
+		now:         time.Now,
+	}
+}
+
+// Create starts a session over the given conflicts. The slug and revision
+// identify the analyzed collection and may be empty.
+func (m *Manager) Create(slug string, revision int, conflicts []conflict.Conflict) (*State, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now().UTC()
+	s := &session{
+		id:        id,
+		slug:      slug,
+		revision:  revision,
+		createdAt: now,
+		updatedAt: now,
+		conflicts: append([]conflict.Conflict(nil), conflicts...),
+		byPath:    make(map[string]int, len(conflicts)),
+	}
+	for i, c := range s.conflicts {
+		s.byPath[c.Path] = i
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evictLocked()
+	m.sessions[id] = s
+	return s.state(), nil
+}
+
+// Get returns the current state of a session.
+func (m *Manager) Get(id string) (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.getLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.state(), nil
+}
+
+// Decide sets the winner of a conflict, replacing any earlier decision for it,
+// and returns the recomputed state.
+func (m *Manager) Decide(id, path, winner, reason string) (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.getLocked(id)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, ok := s.byPath[path]
+	if !ok {
+		return nil, ErrUnknownConflict
+	}
+	if !provides(s.conflicts[idx], winner) {
+		return nil, ErrUnknownMod
+	}
+
+	s.removeDecision(path)
+	s.decisions = append(s.decisions, Decision{
+		Path:      path,
+		Winner:    winner,
+		Reason:    strings.TrimSpace(reason),
+		DecidedAt: m.now().UTC(),
+	})
+	s.updatedAt = m.now().UTC()
+	return s.state(), nil
+}
+
+// Undo removes the decision for a conflict and returns the recomputed state.
+func (m *Manager) Undo(id, path string) (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.getLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.byPath[path]; !ok {
+		return nil, ErrUnknownConflict
+	}
+
+	s.removeDecision(path)
+	s.updatedAt = m.now().UTC()
+	return s.state(), nil
+}
+
+// Delete ends a session.
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getLocked(id); err != nil {
+		return err
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+// getLocked returns a live session, dropping it if it expired.
+func (m *Manager) getLocked(id string) (*session, error) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if m.now().Sub(s.updatedAt) > m.ttl {
+		delete(m.sessions, id)
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+// evictLocked drops expired sessions, then the least recently used ones
+// until there is room for a new session.
+func (m *Manager) evictLocked() {
+	now := m.now()
+	for id, s := range m.sessions {
+		if now.Sub(s.updatedAt) > m.ttl {
+			delete(m.sessions, id)
+		}
+	}
+
+	for len(m.sessions) >= m.maxSessions {
+		var oldest *session
+		for _, s := range m.sessions {
+			if oldest == nil || s.updatedAt.Before(oldest.updatedAt) {
+				oldest = s
+			}
+		}
+		delete(m.sessions, oldest.id)
+	}
+}
+
+// removeDecision drops the decision for a path, if any.
+func (s *session) removeDecision(path string) {
+	for i, d := range s.decisions {
+		if d.Path == path {
+			s.decisions = append(s.decisions[:i], s.decisions[i+1:]...)
+			return
+		}
+	}
+}
+
+// precedence records which of two mods wins, and the decision that said so.
+type precedence struct {
+	winner string
+	path   string
+}
+
+// pairKey identifies an unordered pair of mods.
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "\x00" + b
+}
+
+// precedences collects the mod pair winners implied by the decisions. A
+// decision makes its winner beat every other mod providing the file; later
+// decisions override earlier ones for the same pair.
+func (s *session) precedences() map[string]precedence {
+	prefs := make(map[string]precedence)
+	for _, d := range s.decisions {
+		for _, src := range s.conflicts[s.byPath[d.Path]].Sources {
+			if src.ModID != d.Winner {
+				prefs[pairKey(d.Winner, src.ModID)] = precedence{winner: d.Winner, path: d.Path}
+			}
+		}
+	}
+	return prefs
+}
+
+// state recomputes every conflict's winner from the decisions.
+func (s *session) state() *State {
+	decided := make(map[string]Decision, len(s.decisions))
+	for _, d := range s.decisions {
+		decided[d.Path] = d
+	}
+	prefs := s.precedences()
+
+	st := &State{
+		ID:          s.id,
+		Slug:        s.slug,
+		Revision:    s.revision,
+		CreatedAt:   s.createdAt,
+		UpdatedAt:   s.updatedAt,
+		Resolutions: make([]Resolution, 0, len(s.conflicts)),
+		Stats:       Stats{Total: len(s.conflicts)},
+	}
+
+	for _, c := range s.conflicts {
+		res := Resolution{
+			Path:          c.Path,
+			Severity:      c.Severity,
+			Sources:       c.Sources,
+			DefaultWinner: defaultWinner(c),
+		}
+
+		if d, ok := decided[c.Path]; ok {
+			res.Winner = d.Winner
+			res.Reason = d.Reason
+			if d.Winner == res.DefaultWinner {
+				res.Status = StatusAccepted
+				st.Stats.Accepted++
+			} else {
+				res.Status = StatusOverridden
+				st.Stats.Overridden++
+			}
+		} else {
+			res.Winner, res.DerivedFrom = derivedWinner(c, prefs)
+			if res.DerivedFrom != "" && res.Winner != res.DefaultWinner {
+				res.Status = StatusDerived
+				st.Stats.Derived++
+			} else {
+				res.Status = StatusDefault
+				res.DerivedFrom = ""
+				st.Stats.Remaining++
+			}
+		}
+
+		st.Resolutions = append(st.Resolutions, res)
+	}
+
+	return st
+}
+
+// defaultWinner returns the mod that wins a conflict by load order.
+func defaultWinner(c conflict.Conflict) string {
+	if c.Winner != nil {
+		return c.Winner.ModID
+	}
+	if len(c.Sources) > 0 {
+		return c.Sources[len(c.Sources)-1].ModID
+	}
+	return ""
+}
+
+// derivedWinner walks the sources in load order, letting each later mod win
+// unless a decision says the current winner beats it. It returns the winner
+// and the path of the last decision that kept a mod from being overwritten.
+func derivedWinner(c conflict.Conflict, prefs map[string]precedence) (string, string) {
+	if len(c.Sources) == 0 {
+		return "", ""
+	}
+
+	winner := c.Sources[0].ModID
+	var from string
+	for _, src := range c.Sources[1:] {
+		if p, ok := prefs[pairKey(winner, src.ModID)]; ok && p.winner == winner {
+			from = p.path
+			continue
+		}
+		winner, from = src.ModID, ""
+	}
+	return winner, from
+}
+
+// provides reports whether a mod is one of a conflict's sources.
+func provides(c conflict.Conflict, modID string) bool {
+	for _, src := range c.Sources {
+		if src.ModID == modID {
+			return true
+		}
+	}
+	return false
+}
+
+// newID returns a random session identifier.
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,207 @@
+package resolve
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+// testConflict builds a conflict whose sources load in the given order.
+func testConflict(path string, mods ...string) conflict.Conflict {
+	c := conflict.Conflict{Path: path, Severity: conflict.SeverityMedium}
+	for _, m := range mods {
+		c.Sources = append(c.Sources, conflict.ModFile{ModID: m, ModName: "Mod " + m, Path: path})
+	}
+	winner := c.Sources[len(c.Sources)-1]
+	c.Winner = &winner
+	c.Losers = c.Sources[:len(c.Sources)-1]
+	return c
+}
+
+func resolutionsByPath(st *State) map[string]Resolution {
+	byPath := make(map[string]Resolution, len(st.Resolutions))
+	for _, r := range st.Resolutions {
+		byPath[r.Path] = r
+	}
+	return byPath
+}
+
+func TestManager_Decide(t *testing.T) {
+	m := NewManager(Config{})
+
+	st, err := m.Create("abc", 2, []conflict.Conflict{
+		testConflict("textures/a.dds", "a", "b"),
+		testConflict("textures/b.dds", "a", "b"),
+		testConflict("meshes/c.nif", "a", "b", "c"),
+		testConflict("scripts/d.pex", "b", "c"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.ID == "" || st.Stats.Remaining != 4 {
+		t.Fatalf("expected new session with 4 remaining conflicts, got %+v", st)
+	}
+
+	// Choosing a over b applies to every undecided conflict between them
+	st, err = m.Decide(st.ID, "textures/a.dds", "a", "Better textures")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := resolutionsByPath(st)
+	tests := []struct {
+		path        string
+		winner      string
+		status      Status
+		derivedFrom string
+	}{
+		{"textures/a.dds", "a", StatusOverridden, ""},
+		{"textures/b.dds", "a", StatusDerived, "textures/a.dds"},
+		{"meshes/c.nif", "c", StatusDefault, ""},
+		{"scripts/d.pex", "c", StatusDefault, ""},
+	}
+	for _, tt := range tests {
+		got := res[tt.path]
+		if got.Winner != tt.winner || got.Status != tt.status || got.DerivedFrom != tt.derivedFrom {
+			t.Errorf("%s: expected %s %s %q, got %s %s %q", tt.path, tt.winner, tt.status, tt.derivedFrom, got.Winner, got.Status, got.DerivedFrom)
+		}
+	}
+	if res["textures/a.dds"].Reason != "Better textures" {
+		t.Errorf("expected reason to be kept, got %q", res["textures/a.dds"].Reason)
+	}
+
+	want := Stats{Total: 4, Overridden: 1, Derived: 1, Remaining: 2}
+	if st.Stats != want {
+		t.Errorf("expected stats %+v, got %+v", want, st.Stats)
+	}
+
+	// An explicit decision on a derived conflict takes over
+	st, err = m.Decide(st.ID, "textures/b.dds", "b", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resolutionsByPath(st)["textures/b.dds"]; got.Winner != "b" || got.Status != StatusAccepted {
+		t.Errorf("expected b to be accepted, got %+v", got)
+	}
+
+	// Explicit decisions are never replaced by derived winners
+	if got := resolutionsByPath(st)["textures/a.dds"]; got.Winner != "a" || got.Status != StatusOverridden {
+		t.Errorf("expected a.dds to keep its decision, got %+v", got)
+	}
+
+	st, err = m.Undo(st.ID, "textures/a.dds")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resolutionsByPath(st)["textures/a.dds"]; got.Winner != "b" || got.Status != StatusDefault {
+		t.Errorf("expected a.dds to return to its default, got %+v", got)
+	}
+	want = Stats{Total: 4, Accepted: 1, Remaining: 3}
+	if st.Stats != want {
+		t.Errorf("expected stats %+v, got %+v", want, st.Stats)
+	}
+}
+
+func TestManager_DecideErrors(t *testing.T) {
+	m := NewManager(Config{})
+
+	st, err := m.Create("", 0, []conflict.Conflict{testConflict("a.esp", "a", "b")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Decide("missing", "a.esp", "a", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := m.Decide(st.ID, "b.esp", "a", ""); !errors.Is(err, ErrUnknownConflict) {
+		t.Errorf("expected ErrUnknownConflict, got %v", err)
+	}
+	if _, err := m.Decide(st.ID, "a.esp", "c", ""); !errors.Is(err, ErrUnknownMod) {
+		t.Errorf("expected ErrUnknownMod, got %v", err)
+	}
+
+	if err := m.Delete(st.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Get(st.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted session to be gone, got %v", err)
+	}
+}
+
+func TestManager_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewManager(Config{TTL: time.Hour, MaxSessions: 2})
+	m.now = func() time.Time { return now }
+
+	first, _ := m.Create("", 0, nil)
+	now = now.Add(time.Minute)
+	second, _ := m.Create("", 0, nil)
+	now = now.Add(time.Minute)
+	third, _ := m.Create("", 0, nil)
+
+	if _, err := m.Get(first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected least recently used session to be evicted, got %v", err)
+	}
+	if _, err := m.Get(second.ID); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := m.Get(third.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected idle session to expire, got %v", err)
+	}
+}
+
+func TestManager_Plan(t *testing.T) {
+	m := NewManager(Config{})
+
+	st, err := m.Create("abc", 2, []conflict.Conflict{
+		testConflict("textures/a.dds", "a", "b", "c"),
+		testConflict("textures/b.dds", "a", "b"),
+		testConflict("meshes/c.nif", "b", "c"),
+		testConflict("meshes/d.nif", "a", "c"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Decide(st.ID, "textures/a.dds", "a", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// c keeps winning over a elsewhere, contradicting a moving after c
+	if _, err := m.Decide(st.ID, "meshes/d.nif", "c", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan, err := m.Plan(st.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plan.Decisions) != 2 || plan.Slug != "abc" || plan.Revision != 2 {
+		t.Errorf("expected plan for abc revision 2 with 2 decisions, got %+v", plan)
+	}
+
+	if len(plan.Overrides) != 2 {
+		t.Fatalf("expected 2 overrides, got %+v", plan.Overrides)
+	}
+	if o := plan.Overrides[0]; o.Path != "textures/a.dds" || o.Status != StatusOverridden || len(o.Hide) != 2 {
+		t.Errorf("expected a.dds override hiding b and c, got %+v", o)
+	}
+	if o := plan.Overrides[1]; o.Path != "textures/b.dds" || o.Status != StatusDerived || len(o.Hide) != 1 || o.Hide[0] != "b" {
+		t.Errorf("expected derived b.dds override hiding b, got %+v", o)
+	}
+
+	if len(plan.ModOrder) != 2 {
+		t.Fatalf("expected 2 order rules, got %+v", plan.ModOrder)
+	}
+	ab, ac := plan.ModOrder[0], plan.ModOrder[1]
+	if ab.Mod != "a" || ab.After != "b" || len(ab.Paths) != 2 || ab.Contradicted {
+		t.Errorf("expected a after b for 2 paths, got %+v", ab)
+	}
+	if ac.Mod != "a" || ac.After != "c" || ac.AfterName != "Mod c" || !ac.Contradicted {
+		t.Errorf("expected contradicted a after c, got %+v", ac)
+	}
+}
@@ -0,0 +1,132 @@
+package revision
+
+import "github.com/mod-troubleshooter/backend/internal/pipeline"
+
+// CollectionMod is a mod file in one of two compared collections.
+type CollectionMod struct {
+	// NexusModID is the mod ID on Nexus, if known.
+	NexusModID int `json:"nexusModId,omitempty"`
+	// ModName is the display name of the mod.
+	ModName string `json:"modName"`
+	// FileID is the file the collection uses.
+	FileID int `json:"fileId,omitempty"`
+	// Version is the file version, if known.
+	Version string `json:"version,omitempty"`
+}
+
+// SharedMod is a mod included by both compared collections.
+type SharedMod struct {
+	// NexusModID is the mod ID on Nexus, if known.
+	NexusModID int `json:"nexusModId,omitempty"`
+	// ModName is the display name of the mod in the first collection.
+	ModName string `json:"modName"`
+	// FileIDA and FileIDB are the files each collection uses.
+	FileIDA int `json:"fileIdA,omitempty"`
+	FileIDB int `json:"fileIdB,omitempty"`
+	// VersionA and VersionB are the file versions each collection uses, if known.
+	VersionA string `json:"versionA,omitempty"`
+	VersionB string `json:"versionB,omitempty"`
+	// SameFile is true when both collections use the same file.
+	SameFile bool `json:"sameFile"`
+}
+
+// OverlapStats summarizes how much two collections have in common.
+type OverlapStats struct {
+	// Shared is the number of mods in both collections.
+	Shared int `json:"shared"`
+	// SameFile is the number of shared mods using the same file in both.
+	SameFile int `json:"sameFile"`
+	// OnlyA and OnlyB are the number of mods unique to each collection.
+	OnlyA int `json:"onlyA"`
+	OnlyB int `json:"onlyB"`
+	// Similarity is the share of all mods that are in both collections,
+	// from 0 to 1.
+	Similarity float64 `json:"similarity"`
+}
+
+// Overlap lists the mods two collections share and the mods unique to each.
+type Overlap struct {
+	// Shared are the mods in both collections, in the first collection's order.
+	Shared []SharedMod `json:"shared"`
+	// OnlyA are the mods only in the first collection.
+	OnlyA []CollectionMod `json:"onlyA"`
+	// OnlyB are the mods only in the second collection.
+	OnlyB []CollectionMod `json:"onlyB"`
+	// Stats summarizes the overlap.
+	Stats OverlapStats `json:"stats"`
+}
+
+// CompareCollections matches the mods of two different collections for the
+// same game. Mods are matched by Nexus mod ID, preferring files both use,
+// so collections pinning different versions of a mod still share it.
+func CompareCollections(a, b []pipeline.Source) *Overlap {
+	overlap := &Overlap{Shared: []SharedMod{}, OnlyA: []CollectionMod{}, OnlyB: []CollectionMod{}}
+	partner := make([]int, len(a))
+	for i := range partner {
+		partner[i] = -1
+	}
+	matched := make([]bool, len(b))
+
+	// pair matches the first unmatched source of b accepted by match
+	pair := func(i int, match func(x, y pipeline.Source) bool) {
+		for j, src := range b {
+			if !matched[j] && match(a[i], src) {
+				matched[j] = true
+				partner[i] = j
+				return
+			}
+		}
+	}
+	for i := range a {
+		pair(i, sameFile)
+	}
+	for i := range a {
+		if partner[i] < 0 && a[i].NexusModID > 0 {
+			pair(i, func(x, y pipeline.Source) bool { return x.NexusModID == y.NexusModID })
+		}
+	}
+
+	for i, src := range a {
+		j := partner[i]
+		if j < 0 {
+			overlap.OnlyA = append(overlap.OnlyA, collectionMod(src))
+			continue
+		}
+		dst := b[j]
+		shared := SharedMod{
+			NexusModID: src.NexusModID,
+			ModName:    src.ModName,
+			FileIDA:    src.FileID,
+			FileIDB:    dst.FileID,
+			VersionA:   src.Version,
+			VersionB:   dst.Version,
+			SameFile:   sameFile(src, dst),
+		}
+		overlap.Shared = append(overlap.Shared, shared)
+		if shared.SameFile {
+			overlap.Stats.SameFile++
+		}
+	}
+	for j, dst := range b {
+		if !matched[j] {
+			overlap.OnlyB = append(overlap.OnlyB, collectionMod(dst))
+		}
+	}
+
+	overlap.Stats.Shared = len(overlap.Shared)
+	overlap.Stats.OnlyA = len(overlap.OnlyA)
+	overlap.Stats.OnlyB = len(overlap.OnlyB)
+	if total := overlap.Stats.Shared + overlap.Stats.OnlyA + overlap.Stats.OnlyB; total > 0 {
+		overlap.Stats.Similarity = float64(overlap.Stats.Shared) / float64(total)
+	}
+	return overlap
+}
+
+func collectionMod(src pipeline.Source) CollectionMod {
+	return CollectionMod{
+		NexusModID: src.NexusModID,
+		ModName:    src.ModName,
+		FileID:     src.FileID,
+		Version:    src.Version,
+	}
+}
@@ -0,0 +1,43 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+func TestCompareCollections(t *testing.T) {
+	a := []pipeline.Source{
+		{ModID: "1-10", ModName: "Shared", NexusModID: 1, FileID: 10},
+		{ModID: "2-20", ModName: "Older", NexusModID: 2, FileID: 20, Version: "1.0"},
+		{ModID: "3-30", ModName: "Only A", NexusModID: 3, FileID: 30},
+	}
+	b := []pipeline.Source{
+		{ModID: "2-22", ModName: "Newer", NexusModID: 2, FileID: 22, Version: "1.1"},
+		{ModID: "4-40", ModName: "Only B", NexusModID: 4, FileID: 40},
+		{ModID: "1-10", ModName: "Shared", NexusModID: 1, FileID: 10},
+	}
+
+	overlap := CompareCollections(a, b)
+
+	if len(overlap.Shared) != 2 {
+		t.Fatalf("expected 2 shared mods, got %+v", overlap.Shared)
+	}
+	if s := overlap.Shared[0]; s.ModName != "Shared" || !s.SameFile {
+		t.Errorf("expected same file to be shared first, got %+v", s)
+	}
+	if s := overlap.Shared[1]; s.SameFile || s.FileIDA != 20 || s.FileIDB != 22 || s.VersionA != "1.0" || s.VersionB != "1.1" {
+		t.Errorf("expected versions of mod 2 to be paired, got %+v", s)
+	}
+	if len(overlap.OnlyA) != 1 || overlap.OnlyA[0].ModName != "Only A" {
+		t.Errorf("unexpected mods only in A: %+v", overlap.OnlyA)
+	}
+	if len(overlap.OnlyB) != 1 || overlap.OnlyB[0].ModName != "Only B" {
+		t.Errorf("unexpected mods only in B: %+v", overlap.OnlyB)
+	}
+
+	want := OverlapStats{Shared: 2, SameFile: 1, OnlyA: 1, OnlyB: 1, Similarity: 0.5}
+	if overlap.Stats != want {
+		t.Errorf("expected stats %+v, got %+v", want, overlap.Stats)
+	}
+}
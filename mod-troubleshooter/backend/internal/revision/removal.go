@@ -0,0 +1,181 @@
+package revision
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// LostMaster is a plugin that would be left without one of its masters.
+type LostMaster struct {
+	// Plugin is the plugin that depends on the master.
+	Plugin string `json:"plugin"`
+	// ModName is the mod providing the plugin.
+	ModName string `json:"modName"`
+	// Master is the plugin that would be removed.
+	Master string `json:"master"`
+}
+
+// WinnerChange is a file whose winning copy would come from another mod.
+type WinnerChange struct {
+	// Path is the normalized file path.
+	Path string `json:"path"`
+	// ModName is the mod that would provide the file instead.
+	ModName string `json:"modName"`
+}
+
+// Removal is the impact removing a mod would have on the other mods.
+type Removal struct {
+	// ModID and ModName identify the removed mod.
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// LostMasters are plugins of other mods that depend on plugins only the
+	// removed mod provides. The game refuses to load them.
+	LostMasters []LostMaster `json:"lostMasters"`
+	// WinnerChanges are files the removed mod wins that other mods provide
+	// too, so their copies would be used instead.
+	WinnerChanges []WinnerChange `json:"winnerChanges"`
+	// SafeMidPlaythrough is whether saves made with the mod survive its removal.
+	SafeMidPlaythrough Verdict `json:"safeMidPlaythrough"`
+	// SaveBreaks lists the removed plugins and scripts saves can depend on.
+	SaveBreaks []SaveBreak `json:"saveBreaks,omitempty"`
+	// Error describes why the removed mod's files are not fully known.
+	Error string `json:"error,omitempty"`
+}
+
+// PreviewRemoval works out what removing the mod with the given ID would
+// change for the other gathered mods. Plugins and scripts another mod
+// provides as well are not lost. It returns false if no such mod was gathered.
+func PreviewRemoval(in *pipeline.Inputs, modID string) (*Removal, bool) {
+	index := -1
+	for i, mod := range in.Mods {
+		if mod.ModID == modID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, false
+	}
+	removed := in.Mods[index]
+	others := make([]pipeline.Mod, 0, len(in.Mods)-1)
+	others = append(others, in.Mods[:index]...)
+	others = append(others, in.Mods[index+1:]...)
+
+	removal := &Removal{
+		ModID:         removed.ModID,
+		ModName:       removed.ModName,
+		LostMasters:   []LostMaster{},
+		WinnerChanges: []WinnerChange{},
+		Error:         removed.Error,
+	}
+	if removed.Manifest == nil && removal.Error == "" {
+		removal.Error = "the mod's files are not known"
+	}
+
+	// Plugins still provided after the removal
+	remaining := make(map[string]bool)
+	for _, mod := range others {
+		for _, pf := range mod.Plugins {
+			remaining[strings.ToLower(pf.Filename)] = true
+		}
+	}
+
+	lost := make(map[string]bool)
+	for _, pf := range removed.Plugins {
+		if !remaining[strings.ToLower(pf.Filename)] {
+			lost[strings.ToLower(pf.Filename)] = true
+		}
+	}
+	for _, mod := range others {
+		for _, pf := range mod.Plugins {
+			for _, master := range headerOf(pf).Masters {
+				if lost[strings.ToLower(master.Filename)] {
+					removal.LostMasters = append(removal.LostMasters, LostMaster{
+						Plugin:  pf.Filename,
+						ModName: mod.ModName,
+						Master:  master.Filename,
+					})
+				}
+			}
+		}
+	}
+
+	removal.WinnerChanges = winnerChanges(removed, others)
+
+	change := ModChange{Type: ChangeRemoved, Error: removal.Error}
+	if removal.Error == "" {
+		var plugins []plugin.HeaderDiff
+		for _, diff := range diffPlugins(removed.Plugins, nil) {
+			if lost[strings.ToLower(diff.Filename)] {
+				plugins = append(plugins, diff)
+			}
+		}
+		change.Plugins = plugins
+		change.ScriptsRemoved = removedScripts(removed.Manifest, scriptsOf(others))
+	}
+	change.assess()
+	removal.SafeMidPlaythrough = change.SafeMidPlaythrough
+	removal.SaveBreaks = change.SaveBreaks
+
+	return removal, true
+}
+
+// winnerChanges lists the files the removed mod wins that a mod installed
+// before it provides too, with the mod that would win instead.
+func winnerChanges(removed pipeline.Mod, others []pipeline.Mod) []WinnerChange {
+	changes := []WinnerChange{}
+	if removed.Manifest == nil {
+		return changes
+	}
+
+	// The mod installed last among the others wins each of their files
+	winners := make(map[string]pipeline.Mod)
+	overridden := make(map[string]bool)
+	for _, mod := range others {
+		if mod.Manifest == nil {
+			continue
+		}
+		for _, f := range mod.Manifest.Files {
+			if mod.LoadOrder > removed.LoadOrder {
+				overridden[f.Path] = true
+				continue
+			}
+			if w, ok := winners[f.Path]; !ok || mod.LoadOrder >= w.LoadOrder {
+				winners[f.Path] = mod
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range removed.Manifest.Files {
+		winner, ok := winners[f.Path]
+		if !ok || overridden[f.Path] || seen[f.Path] {
+			continue
+		}
+		seen[f.Path] = true
+		changes = append(changes, WinnerChange{Path: f.Path, ModName: winner.ModName})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// scriptsOf combines the script listings of mods, so scripts they provide
+// are not counted as removed.
+func scriptsOf(mods []pipeline.Mod) *manifest.Manifest {
+	var entries []manifest.FileEntry
+	for _, mod := range mods {
+		if mod.Manifest == nil {
+			continue
+		}
+		for _, f := range mod.Manifest.Files {
+			if f.Extension == ".pex" {
+				entries = append(entries, f)
+			}
+		}
+	}
+	return manifest.NewManifest(entries)
+}
@@ -0,0 +1,78 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+func TestPreviewRemoval(t *testing.T) {
+	header := func(name string, masters ...string) *plugin.PluginHeader {
+		h := &plugin.PluginHeader{Filename: name}
+		for _, m := range masters {
+			h.Masters = append(h.Masters, plugin.Master{Filename: m})
+		}
+		return h
+	}
+	files := func(paths ...string) *manifest.Manifest {
+		m := &manifest.Manifest{}
+		for _, p := range paths {
+			m.Files = append(m.Files, manifest.NewFileEntry(p, 1))
+		}
+		return m
+	}
+
+	in := &pipeline.Inputs{Mods: []pipeline.Mod{
+		{ModID: "base", ModName: "Base", LoadOrder: 0, Manifest: files("textures/rock.dds", "scripts/shared.pex")},
+		{ModID: "framework", ModName: "Framework", LoadOrder: 1,
+			Manifest: files("Framework.esm", "textures/rock.dds", "textures/tree.dds", "meshes/rock.nif", "scripts/shared.pex", "scripts/framework.pex"),
+			Plugins:  []loadorder.PluginFile{{Filename: "Framework.esm", Header: header("Framework.esm", "Skyrim.esm")}},
+		},
+		{ModID: "addon", ModName: "Addon", LoadOrder: 2, Manifest: files("Addon.esp", "meshes/rock.nif"), Plugins: []loadorder.PluginFile{
+			{Filename: "Addon.esp", Header: header("Addon.esp", "Skyrim.esm", "framework.esm")},
+		}},
+		{ModID: "broken", ModName: "Broken", LoadOrder: 3, Error: "download failed"},
+	}}
+
+	removal, ok := PreviewRemoval(in, "framework")
+	if !ok {
+		t.Fatal("expected the mod to be found")
+	}
+
+	if len(removal.LostMasters) != 1 || removal.LostMasters[0].Plugin != "Addon.esp" || removal.LostMasters[0].ModName != "Addon" {
+		t.Errorf("expected Addon.esp to lose its master, got %+v", removal.LostMasters)
+	}
+	// meshes/rock.nif is won by Addon either way
+	if len(removal.WinnerChanges) != 2 || removal.WinnerChanges[1].Path != "textures/rock.dds" || removal.WinnerChanges[1].ModName != "Base" {
+		t.Errorf("expected Base to win its shared files, got %+v", removal.WinnerChanges)
+	}
+	if removal.SafeMidPlaythrough != VerdictUnsafe {
+		t.Errorf("expected removal to be unsafe, got %s", removal.SafeMidPlaythrough)
+	}
+	rules := map[string]Rule{}
+	for _, b := range removal.SaveBreaks {
+		rules[b.Subject] = b.Rule
+	}
+	if len(rules) != 2 || rules["Framework.esm"] != RulePluginRemoved || rules["framework.pex"] != RuleScriptRemoved {
+		t.Errorf("expected the plugin and unshared script to break saves, got %+v", removal.SaveBreaks)
+	}
+
+	// Texture-only mods are safe to remove
+	removal, _ = PreviewRemoval(in, "base")
+	if removal.SafeMidPlaythrough != VerdictSafe || len(removal.WinnerChanges) != 0 || len(removal.LostMasters) != 0 {
+		t.Errorf("expected a harmless removal, got %+v", removal)
+	}
+
+	// Mods that could not be read give no verdict
+	removal, _ = PreviewRemoval(in, "broken")
+	if removal.SafeMidPlaythrough != VerdictUnknown || removal.Error == "" {
+		t.Errorf("expected an unknown verdict, got %+v", removal)
+	}
+
+	if _, ok := PreviewRemoval(in, "missing"); ok {
+		t.Error("expected a mod that isn't gathered to be reported")
+	}
+}
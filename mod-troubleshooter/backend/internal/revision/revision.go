@@ -0,0 +1,324 @@
+// Package revision compares two revisions of a collection.
+package revision
+
+import (
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// ChangeType describes how a mod changed between two revisions.
+type ChangeType string
+
+const (
+	// ChangeAdded indicates the mod is only in the newer revision.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved indicates the mod is only in the older revision.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeUpdated indicates the mod uses a different file in the newer revision.
+	ChangeUpdated ChangeType = "updated"
+)
+
+// ModChange describes a mod that differs between two revisions.
+type ModChange struct {
+	// Type is how the mod changed.
+	Type ChangeType `json:"type"`
+	// NexusModID is the mod ID on Nexus, if known.
+	NexusModID int `json:"nexusModId,omitempty"`
+	// ModName is the display name of the mod.
+	ModName string `json:"modName"`
+	// FromFileID is the file used by the older revision, if any.
+	FromFileID int `json:"fromFileId,omitempty"`
+	// ToFileID is the file used by the newer revision, if any.
+	ToFileID int `json:"toFileId,omitempty"`
+	// FromVersion is the file version in the older revision, if known.
+	FromVersion string `json:"fromVersion,omitempty"`
+	// ToVersion is the file version in the newer revision, if known.
+	ToVersion string `json:"toVersion,omitempty"`
+	// Plugins compares the plugin headers of both versions of an updated mod,
+	// or lists the plugins of a removed mod.
+	Plugins []plugin.HeaderDiff `json:"plugins,omitempty"`
+	// ScriptsRemoved are compiled scripts the newer revision no longer provides.
+	ScriptsRemoved []string `json:"scriptsRemoved,omitempty"`
+	// SafeMidPlaythrough is whether saves made with the older revision survive the change.
+	SafeMidPlaythrough Verdict `json:"safeMidPlaythrough"`
+	// SaveBreaks lists the changes that can break existing saves.
+	SaveBreaks []SaveBreak `json:"saveBreaks,omitempty"`
+	// Error describes why the files of a changed mod could not be compared.
+	Error string `json:"error,omitempty"`
+}
+
+// Stats summarizes the changes between two revisions.
+type Stats struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// ChangedPlugins is the number of added, removed or modified plugins in updated mods.
+	ChangedPlugins int `json:"changedPlugins"`
+	// SaveBreakingPlugins is the number of plugin changes that can break existing saves.
+	SaveBreakingPlugins int `json:"saveBreakingPlugins"`
+	// UnsafeMods is the number of changed mods that are not safe mid-playthrough.
+	UnsafeMods int `json:"unsafeMods"`
+}
+
+// Diff lists the mods that changed between two revisions.
+type Diff struct {
+	// Changes are the changed mods, in the newer revision's order followed by removed mods.
+	Changes []ModChange `json:"changes"`
+	// Stats summarizes the changes.
+	Stats Stats `json:"stats"`
+}
+
+// Compare matches the mods of two revisions. A mod using the same file in both
+// is unchanged; otherwise mods sharing a Nexus mod ID are paired as updates,
+// in order, so collections with several files from one mod pair up sensibly.
+func Compare(from, to []pipeline.Source) *Diff {
+	diff := &Diff{Changes: []ModChange{}}
+	matched := make([]bool, len(from))
+
+	// pair returns the first unmatched older source accepted by match
+	pair := func(match func(pipeline.Source) bool) int {
+		for i, src := range from {
+			if !matched[i] && match(src) {
+				matched[i] = true
+				return i
+			}
+		}
+		return -1
+	}
+
+	pending := make([]int, 0, len(to))
+	for j, dst := range to {
+		if pair(func(src pipeline.Source) bool { return sameFile(src, dst) }) >= 0 {
+			diff.Stats.Unchanged++
+			continue
+		}
+		pending = append(pending, j)
+	}
+
+	for _, j := range pending {
+		dst := to[j]
+		i := -1
+		if dst.NexusModID > 0 {
+			i = pair(func(src pipeline.Source) bool { return src.NexusModID == dst.NexusModID })
+		}
+		if i < 0 {
+			// New mods never remove anything a save depends on
+			diff.Changes = append(diff.Changes, ModChange{
+				Type:               ChangeAdded,
+				NexusModID:         dst.NexusModID,
+				ModName:            dst.ModName,
+				ToFileID:           dst.FileID,
+				ToVersion:          dst.Version,
+				SafeMidPlaythrough: VerdictSafe,
+			})
+			diff.Stats.Added++
+			continue
+		}
+
+		src := from[i]
+		diff.Changes = append(diff.Changes, ModChange{
+			Type:               ChangeUpdated,
+			NexusModID:         dst.NexusModID,
+			ModName:            dst.ModName,
+			FromFileID:         src.FileID,
+			ToFileID:           dst.FileID,
+			FromVersion:        src.Version,
+			ToVersion:          dst.Version,
+			SafeMidPlaythrough: VerdictUnknown,
+		})
+		diff.Stats.Updated++
+	}
+
+	for i, src := range from {
+		if matched[i] {
+			continue
+		}
+		diff.Changes = append(diff.Changes, ModChange{
+			Type:               ChangeRemoved,
+			NexusModID:         src.NexusModID,
+			ModName:            src.ModName,
+			FromFileID:         src.FileID,
+			FromVersion:        src.Version,
+			SafeMidPlaythrough: VerdictUnknown,
+		})
+		diff.Stats.Removed++
+	}
+
+	return diff
+}
+
+// sameFile reports whether two sources refer to the same mod file.
+func sameFile(a, b pipeline.Source) bool {
+	if a.FileID > 0 || b.FileID > 0 {
+		return a.FileID == b.FileID && a.NexusModID == b.NexusModID
+	}
+	return a.ModID == b.ModID
+}
+
+// Sources returns the sources to gather from each revision: both versions of
+// updated mods and the older version of removed mods.
+func (d *Diff) Sources(from, to []pipeline.Source) (fromSources, toSources []pipeline.Source) {
+	fromFiles := make(map[int]bool)
+	toFiles := make(map[int]bool)
+	for _, c := range d.Changes {
+		switch c.Type {
+		case ChangeUpdated:
+			fromFiles[c.FromFileID] = true
+			toFiles[c.ToFileID] = true
+		case ChangeRemoved:
+			fromFiles[c.FromFileID] = true
+		}
+	}
+
+	for _, src := range from {
+		if fromFiles[src.FileID] && src.Unavailable == "" {
+			fromSources = append(fromSources, src)
+		}
+	}
+	for _, src := range to {
+		if toFiles[src.FileID] && src.Unavailable == "" {
+			toSources = append(toSources, src)
+		}
+	}
+	return fromSources, toSources
+}
+
+// AddDetails compares the plugins and scripts gathered for each updated or
+// removed mod, then decides whether the change is safe mid-playthrough.
+// Mods whose files could not be read keep an error instead.
+func (d *Diff) AddDetails(from, to *pipeline.Inputs) {
+	fromMods := modsByFile(from)
+	toMods := modsByFile(to)
+
+	for i := range d.Changes {
+		c := &d.Changes[i]
+
+		switch c.Type {
+		case ChangeUpdated:
+			before, after := fromMods[c.FromFileID], toMods[c.ToFileID]
+			if c.Error = gatherError(before, after); c.Error == "" {
+				c.Plugins = diffPlugins(before.Plugins, after.Plugins)
+				c.ScriptsRemoved = removedScripts(before.Manifest, after.Manifest)
+			}
+		case ChangeRemoved:
+			before := fromMods[c.FromFileID]
+			if c.Error = gatherError(before); c.Error == "" {
+				c.Plugins = diffPlugins(before.Plugins, nil)
+				c.ScriptsRemoved = removedScripts(before.Manifest, nil)
+			}
+		default:
+			continue
+		}
+
+		for _, p := range c.Plugins {
+			if p.Status != plugin.ChangeUnchanged && p.Status != plugin.ChangeUnknown {
+				d.Stats.ChangedPlugins++
+			}
+			if p.SaveBreaking {
+				d.Stats.SaveBreakingPlugins++
+			}
+		}
+
+		c.assess()
+		if c.SafeMidPlaythrough == VerdictUnsafe {
+			d.Stats.UnsafeMods++
+		}
+	}
+}
+
+// gatherError describes why a gathered mod cannot be compared, if anything failed.
+func gatherError(mods ...*pipeline.Mod) string {
+	for _, mod := range mods {
+		switch {
+		case mod == nil:
+			return "mod file is unavailable on Nexus"
+		case mod.Error != "":
+			return mod.Error
+		}
+	}
+	return ""
+}
+
+// modsByFile indexes gathered mods by their Nexus file ID.
+func modsByFile(in *pipeline.Inputs) map[int]*pipeline.Mod {
+	mods := make(map[int]*pipeline.Mod)
+	if in == nil {
+		return mods
+	}
+	for i := range in.Mods {
+		mods[in.Mods[i].FileID] = &in.Mods[i]
+	}
+	return mods
+}
+
+// diffPlugins compares plugins by filename, case-insensitively, in the order
+// of the newer version followed by removed plugins.
+func diffPlugins(before, after []loadorder.PluginFile) []plugin.HeaderDiff {
+	old := make(map[string]loadorder.PluginFile, len(before))
+	for _, p := range before {
+		old[strings.ToLower(p.Filename)] = p
+	}
+
+	seen := make(map[string]bool, len(after))
+	diffs := make([]plugin.HeaderDiff, 0, len(after))
+	for _, p := range after {
+		key := strings.ToLower(p.Filename)
+		seen[key] = true
+		prev, existed := old[key]
+		switch {
+		case !existed:
+			diffs = append(diffs, plugin.DiffHeaders(nil, headerOf(p)))
+		case prev.Header == nil || p.Header == nil:
+			// Without both headers only the plugin's presence is known
+			diffs = append(diffs, plugin.HeaderDiff{Filename: p.Filename, Status: plugin.ChangeUnknown})
+		default:
+			diffs = append(diffs, plugin.DiffHeaders(prev.Header, p.Header))
+		}
+	}
+	for _, p := range before {
+		if !seen[strings.ToLower(p.Filename)] {
+			diffs = append(diffs, plugin.DiffHeaders(headerOf(p), nil))
+		}
+	}
+	return diffs
+}
+
+// removedScripts returns the compiled scripts in before that after no longer
+// provides. Scripts are matched by filename, since the game looks them up by name.
+func removedScripts(before, after *manifest.Manifest) []string {
+	if before == nil {
+		return nil
+	}
+
+	kept := make(map[string]bool)
+	if after != nil {
+		for _, f := range after.Files {
+			if f.Extension == ".pex" {
+				kept[f.Filename] = true
+			}
+		}
+	}
+
+	var removed []string
+	seen := make(map[string]bool)
+	for _, f := range before.Files {
+		if f.Extension == ".pex" && !kept[f.Filename] && !seen[f.Filename] {
+			seen[f.Filename] = true
+			removed = append(removed, f.Filename)
+		}
+	}
+	return removed
+}
+
+// headerOf returns the parsed header of a plugin, or one with just its filename.
+func headerOf(p loadorder.PluginFile) *plugin.PluginHeader {
+	if p.Header != nil {
+		return p.Header
+	}
+	return &plugin.PluginHeader{Filename: p.Filename}
+}
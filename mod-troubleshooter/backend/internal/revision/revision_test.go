@@ -0,0 +1,164 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+func TestCompare(t *testing.T) {
+	from := []pipeline.Source{
+		{ModID: "1-10", ModName: "Kept", NexusModID: 1, FileID: 10},
+		{ModID: "2-20", ModName: "Main", NexusModID: 2, FileID: 20, Version: "1.0"},
+		{ModID: "2-21", ModName: "Optional", NexusModID: 2, FileID: 21},
+		{ModID: "3-30", ModName: "Dropped", NexusModID: 3, FileID: 30},
+	}
+	to := []pipeline.Source{
+		{ModID: "4-40", ModName: "New", NexusModID: 4, FileID: 40},
+		{ModID: "2-21", ModName: "Optional", NexusModID: 2, FileID: 21},
+		{ModID: "2-22", ModName: "Main", NexusModID: 2, FileID: 22, Version: "1.1"},
+		{ModID: "1-10", ModName: "Kept", NexusModID: 1, FileID: 10},
+	}
+
+	diff := Compare(from, to)
+
+	expected := []struct {
+		typ      ChangeType
+		name     string
+		fromFile int
+		toFile   int
+	}{
+		{ChangeAdded, "New", 0, 40},
+		{ChangeUpdated, "Main", 20, 22},
+		{ChangeRemoved, "Dropped", 30, 0},
+	}
+	if len(diff.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), diff.Changes)
+	}
+	for i, want := range expected {
+		got := diff.Changes[i]
+		if got.Type != want.typ || got.ModName != want.name || got.FromFileID != want.fromFile || got.ToFileID != want.toFile {
+			t.Errorf("change %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+	if diff.Changes[1].FromVersion != "1.0" || diff.Changes[1].ToVersion != "1.1" {
+		t.Errorf("expected versions 1.0 -> 1.1, got %+v", diff.Changes[1])
+	}
+
+	want := Stats{Added: 1, Removed: 1, Updated: 1, Unchanged: 2}
+	if diff.Stats != want {
+		t.Errorf("expected stats %+v, got %+v", want, diff.Stats)
+	}
+
+	fromChanged, toChanged := diff.Sources(from, to)
+	if len(fromChanged) != 2 || fromChanged[0].FileID != 20 || fromChanged[1].FileID != 30 {
+		t.Errorf("expected the updated and removed files, got %+v", fromChanged)
+	}
+	if len(toChanged) != 1 || toChanged[0].FileID != 22 {
+		t.Errorf("expected only the updated file, got %+v", toChanged)
+	}
+}
+
+func TestDiff_AddDetails(t *testing.T) {
+	diff := &Diff{Changes: []ModChange{
+		{Type: ChangeUpdated, ModName: "Main", FromFileID: 20, ToFileID: 22},
+		{Type: ChangeUpdated, ModName: "Broken", FromFileID: 50, ToFileID: 51},
+		{Type: ChangeAdded, ModName: "New", ToFileID: 40, SafeMidPlaythrough: VerdictSafe},
+		{Type: ChangeRemoved, ModName: "Dropped", FromFileID: 30},
+		{Type: ChangeUpdated, ModName: "Textures", FromFileID: 60, ToFileID: 61},
+	}}
+
+	header := func(name string, masters ...string) *plugin.PluginHeader {
+		h := &plugin.PluginHeader{Filename: name}
+		for _, m := range masters {
+			h.Masters = append(h.Masters, plugin.Master{Filename: m})
+		}
+		return h
+	}
+	files := func(paths ...string) *manifest.Manifest {
+		m := &manifest.Manifest{}
+		for _, p := range paths {
+			m.Files = append(m.Files, manifest.NewFileEntry(p, 1))
+		}
+		return m
+	}
+
+	from := &pipeline.Inputs{Mods: []pipeline.Mod{
+		{FileID: 20, Manifest: files("Scripts/Main.pex", "Scripts/Helper.pex", "Scripts/Source/Helper.psc"), Plugins: []loadorder.PluginFile{
+			{Filename: "Main.esp", Header: header("Main.esp", "Skyrim.esm", "Old.esm")},
+			{Filename: "Gone.esp", Header: header("Gone.esp")},
+			{Filename: "Opaque.esp"},
+		}},
+		{FileID: 50, Error: "download failed"},
+		{FileID: 30, Manifest: files("Dropped.esp", "scripts/dropped.pex"), Plugins: []loadorder.PluginFile{
+			{Filename: "Dropped.esp", Header: header("Dropped.esp")},
+		}},
+		{FileID: 60, Manifest: files("textures/a.dds")},
+	}}
+	to := &pipeline.Inputs{Mods: []pipeline.Mod{
+		{FileID: 22, Manifest: files("scripts/main.pex"), Plugins: []loadorder.PluginFile{
+			{Filename: "main.esp", Header: header("main.esp", "Skyrim.esm")},
+			{Filename: "Opaque.esp", Header: header("Opaque.esp")},
+			{Filename: "Extra.esp", Header: header("Extra.esp")},
+		}},
+		{FileID: 51},
+		{FileID: 61, Manifest: files("textures/a.dds", "textures/b.dds")},
+	}}
+
+	diff.AddDetails(from, to)
+
+	main := diff.Changes[0]
+	expected := []struct {
+		name   string
+		status plugin.ChangeStatus
+	}{
+		{"main.esp", plugin.ChangeModified},
+		{"Opaque.esp", plugin.ChangeUnknown},
+		{"Extra.esp", plugin.ChangeAdded},
+		{"Gone.esp", plugin.ChangeRemoved},
+	}
+	if len(main.Plugins) != len(expected) {
+		t.Fatalf("expected %d plugin diffs, got %+v", len(expected), main.Plugins)
+	}
+	for i, want := range expected {
+		if main.Plugins[i].Filename != want.name || main.Plugins[i].Status != want.status {
+			t.Errorf("plugin %d: expected %s %s, got %s %s", i, want.name, want.status, main.Plugins[i].Filename, main.Plugins[i].Status)
+		}
+	}
+	if len(main.ScriptsRemoved) != 1 || main.ScriptsRemoved[0] != "helper.pex" {
+		t.Errorf("expected helper.pex to be removed, got %v", main.ScriptsRemoved)
+	}
+	if main.SafeMidPlaythrough != VerdictUnsafe {
+		t.Errorf("expected main update to be unsafe, got %s", main.SafeMidPlaythrough)
+	}
+	rules := make(map[Rule]int)
+	for _, b := range main.SaveBreaks {
+		rules[b.Rule]++
+	}
+	if rules[RuleMasterRemoved] != 1 || rules[RulePluginRemoved] != 1 || rules[RuleScriptRemoved] != 1 {
+		t.Errorf("expected master, plugin and script removal, got %+v", main.SaveBreaks)
+	}
+
+	if broken := diff.Changes[1]; broken.Error != "download failed" || broken.Plugins != nil || broken.SafeMidPlaythrough != VerdictUnknown {
+		t.Errorf("expected gather error with unknown verdict, got %+v", broken)
+	}
+	if added := diff.Changes[2]; added.Plugins != nil || added.SafeMidPlaythrough != VerdictSafe {
+		t.Errorf("expected added mod to be safe without plugin diffs, got %+v", added)
+	}
+
+	dropped := diff.Changes[3]
+	if dropped.SafeMidPlaythrough != VerdictUnsafe || len(dropped.SaveBreaks) != 2 {
+		t.Errorf("expected removed mod to be unsafe with 2 save breaks, got %+v", dropped)
+	}
+
+	if textures := diff.Changes[4]; textures.SafeMidPlaythrough != VerdictSafe || len(textures.SaveBreaks) != 0 {
+		t.Errorf("expected asset-only update to be safe, got %+v", textures)
+	}
+
+	if diff.Stats.ChangedPlugins != 4 || diff.Stats.SaveBreakingPlugins != 3 || diff.Stats.UnsafeMods != 2 {
+		t.Errorf("expected 4 changed, 3 save-breaking plugins and 2 unsafe mods, got %+v", diff.Stats)
+	}
+}
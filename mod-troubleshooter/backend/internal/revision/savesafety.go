@@ -0,0 +1,109 @@
+package revision
+
+import (
+	"fmt"
+
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// Verdict says whether a mod change is safe for an ongoing playthrough.
+type Verdict string
+
+const (
+	// VerdictSafe indicates saves made before the change keep working.
+	VerdictSafe Verdict = "safe"
+	// VerdictUnsafe indicates the change can break existing saves.
+	VerdictUnsafe Verdict = "unsafe"
+	// VerdictUnknown indicates the mod's files could not be fully compared.
+	VerdictUnknown Verdict = "unknown"
+)
+
+// Rule identifies a kind of save-breaking change.
+type Rule string
+
+const (
+	// RulePluginRemoved flags a plugin that is no longer provided. Saves
+	// referencing its records lose them, and the game warns about missing content.
+	RulePluginRemoved Rule = "plugin_removed"
+	// RuleMasterRemoved flags a plugin that no longer depends on a master,
+	// shifting the FormIDs of its overrides and new records.
+	RuleMasterRemoved Rule = "master_removed"
+	// RuleFormIDsCompacted flags a plugin whose FormIDs were renumbered, so
+	// references stored in saves point at the wrong records.
+	RuleFormIDsCompacted Rule = "formids_compacted"
+	// RuleScriptRemoved flags a compiled script that is no longer provided.
+	// Saves keep its running instances and data, which can no longer be resolved.
+	RuleScriptRemoved Rule = "script_removed"
+)
+
+// SaveBreak is a change that can break saves made before it.
+type SaveBreak struct {
+	// Rule is the kind of save-breaking change.
+	Rule Rule `json:"rule"`
+	// Subject is the plugin or script affected.
+	Subject string `json:"subject"`
+	// Message is a human-readable description of the change.
+	Message string `json:"message"`
+}
+
+// saveBreaks applies the save-breaking rules to the plugin and script changes of a mod.
+func (c *ModChange) saveBreaks() []SaveBreak {
+	var breaks []SaveBreak
+
+	for _, p := range c.Plugins {
+		if p.Status == plugin.ChangeRemoved {
+			breaks = append(breaks, SaveBreak{
+				Rule:    RulePluginRemoved,
+				Subject: p.Filename,
+				Message: fmt.Sprintf("Plugin %s is removed", p.Filename),
+			})
+			continue
+		}
+		for _, master := range p.MastersRemoved {
+			breaks = append(breaks, SaveBreak{
+				Rule:    RuleMasterRemoved,
+				Subject: p.Filename,
+				Message: fmt.Sprintf("Plugin %s no longer uses master %s", p.Filename, master),
+			})
+		}
+		if p.Compacted {
+			breaks = append(breaks, SaveBreak{
+				Rule:    RuleFormIDsCompacted,
+				Subject: p.Filename,
+				Message: fmt.Sprintf("Plugin %s renumbered its FormIDs", p.Filename),
+			})
+		}
+	}
+
+	for _, script := range c.ScriptsRemoved {
+		breaks = append(breaks, SaveBreak{
+			Rule:    RuleScriptRemoved,
+			Subject: script,
+			Message: fmt.Sprintf("Script %s is removed", script),
+		})
+	}
+
+	return breaks
+}
+
+// assess records the save-breaking changes of a mod and its verdict. Any
+// save-breaking change makes it unsafe; otherwise it is only safe when every
+// file could be compared.
+func (c *ModChange) assess() {
+	c.SaveBreaks = c.saveBreaks()
+
+	switch {
+	case len(c.SaveBreaks) > 0:
+		c.SafeMidPlaythrough = VerdictUnsafe
+	case c.Error != "":
+		c.SafeMidPlaythrough = VerdictUnknown
+	default:
+		c.SafeMidPlaythrough = VerdictSafe
+		for _, p := range c.Plugins {
+			if p.Status == plugin.ChangeUnknown {
+				c.SafeMidPlaythrough = VerdictUnknown
+				break
+			}
+		}
+	}
+}
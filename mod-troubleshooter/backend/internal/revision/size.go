@@ -0,0 +1,122 @@
+package revision
+
+import (
+	"sort"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+// TypeSize is the space taken by one type of file.
+type TypeSize struct {
+	Type  manifest.FileType `json:"type"`
+	Files int               `json:"files"`
+	Size  int64             `json:"size"`
+}
+
+// ModSize is the space one mod of a collection takes.
+type ModSize struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// DownloadSize is the size of the mod file on Nexus, if known.
+	DownloadSize int64 `json:"downloadSize"`
+	// InstallSize is the size of the mod's files once installed. Without
+	// a file listing it is the download size.
+	InstallSize int64 `json:"installSize"`
+	// ByType breaks InstallSize down by file type, largest first. It is
+	// empty without a file listing.
+	ByType []TypeSize `json:"byType"`
+	// Estimated is true when the mod's files could not be listed, so
+	// InstallSize is only the download size.
+	Estimated bool `json:"estimated,omitempty"`
+	// Error describes why the mod's files could not be listed.
+	Error string `json:"error,omitempty"`
+}
+
+// SizeBudget is the disk space a collection revision needs.
+type SizeBudget struct {
+	// DownloadSize is the total size of the mod files on Nexus.
+	DownloadSize int64 `json:"downloadSize"`
+	// InstallSize is the total size of the installed mods. Each mod is
+	// installed in full, even files other mods override.
+	InstallSize int64 `json:"installSize"`
+	// ByType breaks InstallSize down by file type, largest first, for the
+	// mods whose files were listed.
+	ByType []TypeSize `json:"byType"`
+	// Mods are the mods of the collection, largest install first.
+	Mods []ModSize `json:"mods"`
+	// Estimated counts the mods whose install size is only their download size.
+	Estimated int `json:"estimated"`
+}
+
+// Size works out the disk space the gathered mods need, per mod and per
+// file type. Files packed in BSA or BA2 archives are counted as part of
+// the archive, so nothing is counted twice.
+func Size(in *pipeline.Inputs) *SizeBudget {
+	budget := &SizeBudget{Mods: make([]ModSize, 0, len(in.Mods))}
+	total := make(map[manifest.FileType]*TypeSize)
+
+	for _, mod := range in.Mods {
+		ms := ModSize{
+			ModID:        mod.ModID,
+			ModName:      mod.ModName,
+			DownloadSize: mod.DownloadSize,
+			ByType:       []TypeSize{},
+			Error:        mod.Error,
+		}
+		if mod.Manifest == nil {
+			ms.InstallSize = mod.DownloadSize
+			ms.Estimated = true
+		} else {
+			types := make(map[manifest.FileType]*TypeSize)
+			for _, f := range mod.Manifest.Files {
+				if f.Archive != "" {
+					continue
+				}
+				ms.InstallSize += f.Size
+				addTypeSize(types, f.Type, f.Size)
+				addTypeSize(total, f.Type, f.Size)
+			}
+			ms.ByType = sortedTypeSizes(types)
+		}
+
+		budget.DownloadSize += ms.DownloadSize
+		budget.InstallSize += ms.InstallSize
+		if ms.Estimated {
+			budget.Estimated++
+		}
+		budget.Mods = append(budget.Mods, ms)
+	}
+
+	budget.ByType = sortedTypeSizes(total)
+	sort.SliceStable(budget.Mods, func(i, j int) bool {
+		return budget.Mods[i].InstallSize > budget.Mods[j].InstallSize
+	})
+	return budget
+}
+
+// addTypeSize counts a file of the given type and size.
+func addTypeSize(types map[manifest.FileType]*TypeSize, t manifest.FileType, size int64) {
+	ts, ok := types[t]
+	if !ok {
+		ts = &TypeSize{Type: t}
+		types[t] = ts
+	}
+	ts.Files++
+	ts.Size += size
+}
+
+// sortedTypeSizes lists type sizes largest first, then by type.
+func sortedTypeSizes(types map[manifest.FileType]*TypeSize) []TypeSize {
+	sizes := make([]TypeSize, 0, len(types))
+	for _, ts := range types {
+		sizes = append(sizes, *ts)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size != sizes[j].Size {
+			return sizes[i].Size > sizes[j].Size
+		}
+		return sizes[i].Type < sizes[j].Type
+	})
+	return sizes
+}
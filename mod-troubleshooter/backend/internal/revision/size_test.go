@@ -0,0 +1,77 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+)
+
+func TestSize(t *testing.T) {
+	packed := manifest.NewFileEntry("textures/packed.dds", 500)
+	packed.Archive = "Big.bsa"
+
+	in := &pipeline.Inputs{Mods: []pipeline.Mod{
+		{ModID: "small", ModName: "Small", DownloadSize: 50, Manifest: &manifest.Manifest{Files: []manifest.FileEntry{
+			manifest.NewFileEntry("meshes/rock.nif", 40),
+			manifest.NewFileEntry("textures/rock.dds", 60),
+		}}},
+		{ModID: "big", ModName: "Big", DownloadSize: 900, Manifest: &manifest.Manifest{Files: []manifest.FileEntry{
+			manifest.NewFileEntry("Big.esp", 10),
+			manifest.NewFileEntry("Big.bsa", 1000),
+			packed,
+			manifest.NewFileEntry("textures/big.dds", 300),
+			manifest.NewFileEntry("sound/fx/big.wav", 200),
+		}}},
+		{ModID: "gone", ModName: "Gone", DownloadSize: 70, Error: "download failed"},
+	}}
+
+	budget := Size(in)
+
+	if budget.DownloadSize != 1020 {
+		t.Errorf("DownloadSize = %d, want 1020", budget.DownloadSize)
+	}
+	// Packed files are part of their archive's size
+	if budget.InstallSize != 100+1510+70 {
+		t.Errorf("InstallSize = %d, want %d", budget.InstallSize, 100+1510+70)
+	}
+	if budget.Estimated != 1 {
+		t.Errorf("Estimated = %d, want 1", budget.Estimated)
+	}
+
+	if len(budget.Mods) != 3 {
+		t.Fatalf("got %d mods, want 3", len(budget.Mods))
+	}
+	for i, want := range []string{"big", "small", "gone"} {
+		if budget.Mods[i].ModID != want {
+			t.Errorf("Mods[%d] = %s, want %s (largest first)", i, budget.Mods[i].ModID, want)
+		}
+	}
+	if gone := budget.Mods[2]; !gone.Estimated || gone.InstallSize != 70 || gone.Error == "" || len(gone.ByType) != 0 {
+		t.Errorf("unlisted mod = %+v, want estimated from its download size", gone)
+	}
+
+	big := budget.Mods[0].ByType
+	if len(big) != 4 || big[0].Type != manifest.FileTypeBSA || big[1].Type != manifest.FileTypeTexture || big[1].Files != 1 {
+		t.Errorf("big ByType = %+v, want archive then loose textures", big)
+	}
+
+	want := map[manifest.FileType]TypeSize{
+		manifest.FileTypeBSA:     {Type: manifest.FileTypeBSA, Files: 1, Size: 1000},
+		manifest.FileTypeTexture: {Type: manifest.FileTypeTexture, Files: 2, Size: 360},
+		manifest.FileTypeSound:   {Type: manifest.FileTypeSound, Files: 1, Size: 200},
+		manifest.FileTypeMesh:    {Type: manifest.FileTypeMesh, Files: 1, Size: 40},
+		manifest.FileTypePlugin:  {Type: manifest.FileTypePlugin, Files: 1, Size: 10},
+	}
+	if len(budget.ByType) != len(want) {
+		t.Fatalf("ByType = %+v, want %d types", budget.ByType, len(want))
+	}
+	for i, ts := range budget.ByType {
+		if ts != want[ts.Type] {
+			t.Errorf("ByType %s = %+v, want %+v", ts.Type, ts, want[ts.Type])
+		}
+		if i > 0 && ts.Size > budget.ByType[i-1].Size {
+			t.Errorf("ByType not sorted largest first: %+v", budget.ByType)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Default client settings.
+const (
+	DefaultWorkers = 2
+	DefaultTimeout = 10 * time.Minute
+)
+
+// ErrClosed is returned for requests made after the client was closed.
+var ErrClosed = errors.New("sandbox is closed")
+
+// Config holds configuration for the Client.
+type Config struct {
+	// Command is the worker binary, normally the server executable.
+	Command string
+	// Args start Command as a worker (default: WorkerCommand).
+	Args []string
+	// ReadDirs are the directories workers may read archives from, passed
+	// to them after Args (default: the system temporary directory). Where
+	// the platform allows, the rest of the file system is out of reach.
+	ReadDirs []string
+	// Workers is the number of worker processes (default: DefaultWorkers).
+	Workers int
+	// Timeout bounds a single request (default: DefaultTimeout).
+	Timeout time.Duration
+}
+
+// Client reads archives through a pool of worker processes. Workers are
+// started on first use and replaced when they crash or a request is
+// cancelled, so a malicious archive costs at most one worker.
+type Client struct {
+	command string
+	args    []string
+	timeout time.Duration
+	idle    chan *worker
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// worker is one worker process. A zero worker is started when first used.
+type worker struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *json.Encoder
+	dec   *json.Decoder
+}
+
+// NewClient creates a client for workers started from cfg.Command.
+func NewClient(cfg Config) *Client {
+	args := cfg.Args
+	if args == nil {
+		args = []string{WorkerCommand}
+	}
+	readDirs := cfg.ReadDirs
+	if len(readDirs) == 0 {
+		readDirs = []string{os.TempDir()}
+	}
+	args = append(slices.Clone(args), readDirs...)
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	c := &Client{
+		command: cfg.Command,
+		args:    args,
+		timeout: timeout,
+		idle:    make(chan *worker, workers),
+		closed:  make(chan struct{}),
+	}
+	for range workers {
+		c.idle <- &worker{}
+	}
+	return c
+}
+
+// Manifest lists an archive, reading file contents as opts requires.
+func (c *Client) Manifest(ctx context.Context, path, password string, opts manifest.Options) (*manifest.Manifest, error) {
+	resp, err := c.do(ctx, request{Op: opManifest, Path: path, Password: password, Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Manifest == nil {
+		return manifest.NewManifest(nil), nil
+	}
+	return resp.Manifest, nil
+}
+
+// PluginHeaders parses the header of every plugin in an archive, and
+// collects the form IDs of their records if records is set. Plugins whose
+// header cannot be parsed are returned without one.
+func (c *Client) PluginHeaders(ctx context.Context, path, password string, records bool) ([]loadorder.PluginFile, error) {
+	resp, err := c.do(ctx, request{Op: opPlugins, Path: path, Password: password, Records: records})
+	if err != nil {
+		return nil, err
+	}
+	var plugins []loadorder.PluginFile
+	for _, p := range resp.Plugins {
+		plugins = append(plugins, loadorder.PluginFile{Filename: p.Filename, Header: p.Header, FormIDs: p.FormIDs})
+	}
+	return plugins, nil
+}
+
+// Close stops the workers once their current requests finish.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		for range cap(c.idle) {
+			(<-c.idle).stop()
+		}
+	})
+	return nil
+}
+
+// StopIdle stops the workers not serving a request, freeing their memory.
+// They are started again when next needed.
+func (c *Client) StopIdle() {
+	var stopped []*worker
+	defer func() {
+		for _, w := range stopped {
+			c.idle <- w
+		}
+	}()
+	for range cap(c.idle) {
+		select {
+		case w := <-c.idle:
+			w.stop()
+			stopped = append(stopped, w)
+		default:
+			return
+		}
+	}
+}
+
+// do sends a request to an idle worker and waits for its response.
+func (c *Client) do(ctx context.Context, req request) (*response, error) {
+	var w *worker
+	select {
+	case w = <-c.idle:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.closed:
+		return nil, ErrClosed
+	}
+	defer func() { c.idle <- w }()
+
+	select {
+	case <-c.closed:
+		return nil, ErrClosed
+	default:
+	}
+
+	if w.cmd == nil {
+		if err := w.start(c.command, c.args); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	// Killing the worker is the only way to interrupt it; it is replaced
+	// on the next request
+	process := w.cmd.Process
+	stop := context.AfterFunc(ctx, func() { process.Kill() })
+	defer stop()
+
+	var resp response
+	err := w.enc.Encode(req)
+	if err == nil {
+		err = w.dec.Decode(&resp)
+	}
+	if err != nil {
+		w.stop()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// A worker that dies mid-request most likely choked on the archive
+		return nil, fmt.Errorf("%w: sandbox worker failed: %v", manifest.ErrExtractionFailed, err)
+	}
+
+	if resp.Error != "" {
+		if sentinel, ok := errorKinds[resp.Kind]; ok {
+			return nil, fmt.Errorf("%w: %s", sentinel, resp.Error)
+		}
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// start launches the worker process. Its environment is empty so it never
+// sees the server's secrets, and it dies with the server where supported.
+func (w *worker) start(command string, args []string) error {
+	cmd := exec.Command(command, args...)
+	cmd.Env = []string{}
+	cmd.Stderr = os.Stderr
+	setProcAttr(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("start sandbox worker: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("start sandbox worker: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start sandbox worker: %w", err)
+	}
+
+	w.cmd = cmd
+	w.stdin = stdin
+	w.enc = json.NewEncoder(stdin)
+	w.dec = json.NewDecoder(bufio.NewReader(stdout))
+	return nil
+}
+
+// stop ends the worker process and resets w to be started again.
+func (w *worker) stop() {
+	if w.cmd == nil {
+		return
+	}
+	// Closing stdin asks the worker to exit; kill it if it does not
+	w.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		w.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		w.cmd.Process.Kill()
+		<-done
+	}
+	*w = worker{}
+}
@@ -0,0 +1,248 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// confinedEnv marks a worker that has been started again under its
+// Landlock ruleset.
+const confinedEnv = "SANDBOX_CONFINED"
+
+// confine restricts the worker with Landlock and seccomp.
+//
+// A Landlock ruleset only binds the thread that applies it, and the Go
+// runtime has other threads running by now. The worker therefore applies it
+// on one thread and executes itself again from there: the new process
+// inherits the ruleset on every thread. The seccomp filter is then applied
+// to all threads at once.
+func confine(readDirs []string) error {
+	if os.Getenv(confinedEnv) == "" {
+		abi, err := landlockABI()
+		if err != nil {
+			log.Printf("Warning: Landlock is unavailable (%v); the worker can read any file the server can", err)
+		} else if err := execConfined(abi, readDirs); err != nil {
+			return err
+		}
+	}
+	return filterSyscalls()
+}
+
+// landlockABI returns the Landlock ABI version the kernel supports.
+func landlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(abi), nil
+}
+
+// Landlock rights given to the worker, and the file system rights of
+// Landlock ABI 1, from EXECUTE to MAKE_SYM.
+const (
+	landlockV1Access = unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1
+
+	readAccess = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+	execAccess = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_EXECUTE
+)
+
+// libraryPaths are what a dynamically linked worker loads when it starts
+// again. None of them exist on every system; missing ones are skipped.
+var libraryPaths = []string{"/lib", "/lib64", "/usr/lib", "/usr/lib64", "/etc/ld.so.cache"}
+
+// execConfined applies a Landlock ruleset allowing only reads under
+// readDirs, then executes the worker again under it. It only returns on
+// failure.
+func execConfined(abi int, readDirs []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate worker executable: %w", err)
+	}
+
+	// Every right the kernel knows of is handled, so each is denied
+	// except where a rule grants it
+	attr := unix.LandlockRulesetAttr{Access_fs: landlockV1Access}
+	if abi >= 2 {
+		attr.Access_fs |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		attr.Access_fs |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 4 {
+		attr.Access_net = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+	}
+	if abi >= 5 {
+		attr.Access_fs |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	if abi >= 6 {
+		attr.Scoped = unix.LANDLOCK_SCOPE_ABSTRACT_UNIX_SOCKET | unix.LANDLOCK_SCOPE_SIGNAL
+	}
+
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("create Landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(ruleset))
+
+	if err := allowPath(int(ruleset), executable, execAccess); err != nil {
+		return err
+	}
+	for _, dir := range readDirs {
+		err := allowPath(int(ruleset), dir, readAccess)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: archive directory %s does not exist", dir)
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, lib := range libraryPaths {
+		if err := allowPath(int(ruleset), lib, execAccess|unix.LANDLOCK_ACCESS_FS_READ_DIR); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// The thread applying the ruleset is the one that executes the worker
+	// again, and must not be handed to other goroutines in between
+	runtime.LockOSThread()
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("apply Landlock ruleset: %w", errno)
+	}
+	env := append(os.Environ(), confinedEnv+"=1")
+	if err := syscall.Exec(executable, os.Args, env); err != nil {
+		return fmt.Errorf("start confined worker: %w", err)
+	}
+	return nil
+}
+
+// allowPath grants access to the file or directory tree at path.
+func allowPath(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open %s for Landlock rule: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("stat %s for Landlock rule: %w", path, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		// Rules on files may only grant file rights
+		access &^= unix.LANDLOCK_ACCESS_FS_READ_DIR
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("add Landlock rule for %s: %w", path, errno)
+	}
+	return nil
+}
+
+// auditArchs are the architectures the seccomp filter is written for. Its
+// syscall numbers come from this build, so the filter also rejects calls
+// made through another architecture's ABI.
+var auditArchs = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}
+
+// deniedSyscalls fail with EPERM. Workers only read files and their pipes,
+// so they have no use for sockets, other programs or processes, namespaces
+// and mounts, or kernel interfaces that mostly widen the attack surface.
+var deniedSyscalls = []uint32{
+	unix.SYS_SOCKET, unix.SYS_SOCKETPAIR,
+	unix.SYS_EXECVE, unix.SYS_EXECVEAT,
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_UNSHARE, unix.SYS_SETNS, unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD, unix.SYS_IO_URING_SETUP,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+}
+
+// namespaceFlags are the clone flags creating namespaces.
+const namespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWUTS | unix.CLONE_NEWIPC | unix.CLONE_NEWUSER |
+	unix.CLONE_NEWPID | unix.CLONE_NEWNET | unix.CLONE_NEWCGROUP
+
+// x32SyscallBit marks calls through the x32 ABI on amd64.
+const x32SyscallBit = 0x40000000
+
+// filterSyscalls applies the seccomp filter to every thread of the worker.
+func filterSyscalls() error {
+	arch, ok := auditArchs[runtime.GOARCH]
+	if !ok {
+		log.Printf("Warning: no seccomp filter for %s; the worker can make any system call", runtime.GOARCH)
+		return nil
+	}
+
+	filter := seccompFilter(arch)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	_, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(&prog)))
+	if errno != 0 {
+		return fmt.Errorf("apply seccomp filter: %w", errno)
+	}
+	return nil
+}
+
+// seccompFilter returns the BPF program of the seccomp filter for arch.
+func seccompFilter(arch uint32) []unix.SockFilter {
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+	const (
+		load  = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+		ifEq  = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		ifSet = unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K
+		ret   = unix.BPF_RET | unix.BPF_K
+		deny  = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	)
+	// Offsets into struct seccomp_data. Flags are the low half of the
+	// first argument on these little-endian architectures.
+	const (
+		nrOffset    = 0
+		archOffset  = 4
+		flagsOffset = 16
+	)
+
+	filter := []unix.SockFilter{
+		stmt(load, archOffset),
+		jump(ifEq, arch, 1, 0),
+		stmt(ret, unix.SECCOMP_RET_KILL_PROCESS),
+		stmt(load, nrOffset),
+		jump(ifSet, x32SyscallBit, 0, 1),
+		stmt(ret, deny),
+	}
+	for _, nr := range deniedSyscalls {
+		filter = append(filter, jump(ifEq, nr, 0, 1), stmt(ret, deny))
+	}
+	filter = append(filter,
+		// clone3 passes its flags in memory the filter cannot read; callers
+		// fall back to clone when it is missing
+		jump(ifEq, unix.SYS_CLONE3, 0, 1),
+		stmt(ret, unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)),
+		jump(ifEq, unix.SYS_CLONE, 0, 3),
+		stmt(load, flagsOffset),
+		jump(ifSet, namespaceFlags, 0, 1),
+		stmt(ret, deny),
+		stmt(ret, unix.SECCOMP_RET_ALLOW),
+	)
+	return filter
+}
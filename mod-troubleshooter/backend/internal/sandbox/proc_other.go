@@ -0,0 +1,8 @@
+//go:build !linux
+
+package sandbox
+
+import "os/exec"
+
+// setProcAttr has nothing to set on this platform.
+func setProcAttr(cmd *exec.Cmd) {}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package sandbox
+
+// restrict is not supported on this platform; workers still run in their
+// own process with an empty environment.
+func restrict(readDirs []string) error {
+	return nil
+}
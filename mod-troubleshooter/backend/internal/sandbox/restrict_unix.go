@@ -0,0 +1,29 @@
+//go:build linux || darwin
+
+package sandbox
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// restrict limits what a compromised worker can do: it cannot create or
+// grow files, dump core or hold many files open, and is confined further
+// where the platform allows. Workers only read archives under readDirs and
+// write to their stdout pipe, which the limits do not cover.
+func restrict(readDirs []string) error {
+	limits := []struct {
+		resource int
+		value    uint64
+	}{
+		{syscall.RLIMIT_FSIZE, 0},
+		{syscall.RLIMIT_CORE, 0},
+		{syscall.RLIMIT_NOFILE, 64},
+	}
+	for _, l := range limits {
+		if err := syscall.Setrlimit(l.resource, &syscall.Rlimit{Cur: l.value, Max: l.value}); err != nil {
+			return fmt.Errorf("set resource limit %d: %w", l.resource, err)
+		}
+	}
+	return confine(readDirs)
+}
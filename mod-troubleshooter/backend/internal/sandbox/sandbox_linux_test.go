@@ -0,0 +1,34 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func TestClient_ConfinedToReadDirs(t *testing.T) {
+	if _, err := landlockABI(); err != nil {
+		t.Skipf("Landlock is unavailable: %v", err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	inside := createZip(t, map[string][]byte{"a.esp": pluginData()})
+	outside := createZip(t, map[string][]byte{"a.esp": pluginData()})
+
+	c := NewClient(Config{Command: executable, ReadDirs: []string{filepath.Dir(inside)}, Workers: 1})
+	defer c.Close()
+
+	if _, err := c.Manifest(context.Background(), inside, "", manifest.Options{}); err != nil {
+		t.Fatalf("expected archives under ReadDirs to be readable, got %v", err)
+	}
+	_, err = c.Manifest(context.Background(), outside, "", manifest.Options{})
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected archives elsewhere to be out of reach, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package sandbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// TestMain lets the test binary act as the worker, like the server binary.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == WorkerCommand {
+		RunWorker()
+	}
+	os.Exit(m.Run())
+}
+
+// pluginData returns a minimal plugin flagged as a master of Skyrim.esm.
+func pluginData() []byte {
+	mast := append([]byte("MAST"), 0, 0)
+	mast = append(mast, "Skyrim.esm\x00"...)
+	binary.LittleEndian.PutUint16(mast[4:6], uint16(len(mast)-6))
+	data := append([]byte("TES4"), make([]byte, 20)...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(mast)))
+	binary.LittleEndian.PutUint32(data[8:12], 1)
+	return append(data, mast...)
+}
+
+func createZip(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add zip entry: %v", err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to finalize zip: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "mod.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+	return path
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	c := NewClient(Config{Command: executable, Workers: 1})
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient(t *testing.T) {
+	c := newTestClient(t)
+	path := createZip(t, map[string][]byte{
+		"Data/Armors.esm":        pluginData(),
+		"Data/Textures/a.dds":    []byte("texture"),
+		"Data/Broken.esp":        []byte("not a plugin"),
+		"Data/Scripts/quest.pex": []byte("script"),
+	})
+
+	m, err := c.Manifest(context.Background(), path, "", manifest.Options{Hashes: true})
+	if err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	if m.TotalCount != 4 || !manifest.HasContentHash(m.Files[0]) {
+		t.Errorf("expected 4 hashed files, got %+v", m)
+	}
+
+	plugins, err := c.PluginHeaders(context.Background(), path, "", false)
+	if err != nil {
+		t.Fatalf("PluginHeaders: %v", err)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("expected 2 plugins, got %+v", plugins)
+	}
+	for _, p := range plugins {
+		switch p.Filename {
+		case "Armors.esm":
+			if p.Header == nil || len(p.Header.Masters) != 1 || p.Header.Masters[0].Filename != "Skyrim.esm" {
+				t.Errorf("expected parsed header, got %+v", p.Header)
+			}
+		case "Broken.esp":
+			if p.Header != nil {
+				t.Errorf("expected no header for an invalid plugin, got %+v", p.Header)
+			}
+		default:
+			t.Errorf("unexpected plugin %s", p.Filename)
+		}
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.Manifest(context.Background(), filepath.Join(t.TempDir(), "missing.zip"), "", manifest.Options{})
+	if !errors.Is(err, manifest.ErrArchiveNotFound) {
+		t.Errorf("expected ErrArchiveNotFound, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "junk.7z")
+	os.WriteFile(path, []byte(strings.Repeat("junk", 100)), 0644)
+	if _, err := c.Manifest(context.Background(), path, "", manifest.Options{}); !errors.Is(err, manifest.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestClient_ReplacesDeadWorker(t *testing.T) {
+	c := newTestClient(t)
+	path := createZip(t, map[string][]byte{"a.esp": pluginData()})
+
+	if _, err := c.Manifest(context.Background(), path, "", manifest.Options{}); err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+
+	// Kill the worker as a crashing parser would
+	w := <-c.idle
+	w.cmd.Process.Kill()
+	c.idle <- w
+
+	if _, err := c.Manifest(context.Background(), path, "", manifest.Options{}); !errors.Is(err, manifest.ErrExtractionFailed) {
+		t.Errorf("expected the crash to surface as ErrExtractionFailed, got %v", err)
+	}
+	if _, err := c.Manifest(context.Background(), path, "", manifest.Options{}); err != nil {
+		t.Errorf("expected a fresh worker to serve the next request, got %v", err)
+	}
+}
+
+func TestClient_StopIdle(t *testing.T) {
+	c := newTestClient(t)
+	path := createZip(t, map[string][]byte{"a.esp": pluginData()})
+
+	if _, err := c.Manifest(context.Background(), path, "", manifest.Options{}); err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+
+	c.StopIdle()
+	w := <-c.idle
+	if w.cmd != nil {
+		t.Error("expected the idle worker to be stopped")
+	}
+	c.idle <- w
+
+	if _, err := c.Manifest(context.Background(), path, "", manifest.Options{}); err != nil {
+		t.Errorf("expected the worker to start again when needed, got %v", err)
+	}
+}
+
+func TestClient_Cancelled(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.Manifest(ctx, "x.zip", "", manifest.Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	c.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := c.Manifest(ctx, "x.zip", "", manifest.Options{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package sandbox reads untrusted mod archives in separate worker processes,
+// so a bug in an archive or plugin parser runs outside the server process.
+// The worker is the server binary itself, started with the WorkerCommand
+// argument followed by the directories it may read archives from. It speaks
+// JSON lines over stdin and stdout and handles one request at a time.
+//
+// Workers run as the server's user with an empty environment. What else
+// confines them depends on the platform:
+//
+//   - On Linux and macOS they cannot create or grow files, dump core or hold
+//     more than 64 files open.
+//   - On Linux they die with the server.
+//   - On Linux 5.13 and later, Landlock limits them to reading files under
+//     the allowed directories, plus the system libraries a dynamically linked
+//     binary loads. From 6.7 they also cannot bind or connect TCP sockets,
+//     and from 6.12 they cannot signal processes outside their sandbox.
+//     Where Landlock is unavailable a warning is logged and workers can read
+//     whatever the server's user can.
+//   - On Linux amd64 and arm64, a seccomp filter refuses to create sockets,
+//     run programs, trace or read the memory of other processes, create
+//     namespaces, mount file systems, or use bpf, perf events, userfaultfd,
+//     io_uring or the kernel keyring.
+//
+// Elsewhere, workers only get their own process and empty environment.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mod-troubleshooter/backend/internal/archive"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// WorkerCommand is the argument that starts the server binary as a worker.
+const WorkerCommand = "sandbox-worker"
+
+// Operations a worker performs.
+const (
+	opManifest = "manifest"
+	opPlugins  = "plugins"
+)
+
+// request asks the worker to read an archive.
+type request struct {
+	Op       string `json:"op"`
+	Path     string `json:"path"`
+	Password string `json:"password,omitempty"`
+	// Options select what a manifest reads from file contents.
+	Options manifest.Options `json:"options"`
+	// Records collects the form IDs of plugin records.
+	Records bool `json:"records,omitempty"`
+}
+
+// response is the worker's answer to a request.
+type response struct {
+	Manifest *manifest.Manifest `json:"manifest,omitempty"`
+	Plugins  []pluginResult     `json:"plugins,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	// Kind identifies the error so the client can restore its sentinel.
+	Kind string `json:"kind,omitempty"`
+}
+
+// pluginResult is a plugin found in an archive. Header is nil if the plugin
+// could not be parsed.
+type pluginResult struct {
+	Filename string               `json:"filename"`
+	Header   *plugin.PluginHeader `json:"header,omitempty"`
+	FormIDs  []uint32             `json:"formIds,omitempty"`
+}
+
+// Error kinds carried in responses.
+var errorKinds = map[string]error{
+	"not_found":          manifest.ErrArchiveNotFound,
+	"unsupported_format": manifest.ErrUnsupportedFormat,
+	"extraction_failed":  manifest.ErrExtractionFailed,
+}
+
+// RunWorker serves requests on stdin and stdout until stdin is closed, then
+// exits the process. It is called from main when the binary is started with
+// WorkerCommand; the arguments after it are the directories the worker may
+// read.
+func RunWorker() {
+	log.SetPrefix("sandbox-worker: ")
+	if err := restrict(os.Args[2:]); err != nil {
+		log.Fatalf("restrict worker: %v", err)
+	}
+	if err := Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(0)
+}
+
+// Serve handles requests read from r, writing a response to w for each,
+// until r is exhausted.
+func Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("read request: %w", err)
+		}
+		if err := enc.Encode(handle(ctx, req)); err != nil {
+			return fmt.Errorf("write response: %w", err)
+		}
+	}
+}
+
+// handle performs a single request.
+func handle(ctx context.Context, req request) response {
+	var resp response
+	var err error
+	switch req.Op {
+	case opManifest:
+		resp.Manifest, err = manifest.NewExtractor().ExtractManifestWithOptions(ctx, req.Path, req.Password, req.Options)
+	case opPlugins:
+		resp.Plugins, err = readPlugins(ctx, req.Path, req.Password, req.Records)
+	default:
+		err = fmt.Errorf("unknown operation %q", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		for kind, sentinel := range errorKinds {
+			if errors.Is(err, sentinel) {
+				resp.Kind = kind
+			}
+		}
+	}
+	return resp
+}
+
+// maxScanSize bounds the plugins whose records are scanned, since a scanned
+// plugin is held in memory.
+const maxScanSize = 512 << 20
+
+// readPlugins parses the header of every plugin in an archive, streaming
+// each from the archive instead of extracting it to disk. With records, the
+// form IDs of their records are collected too.
+func readPlugins(ctx context.Context, archivePath, password string, records bool) ([]pluginResult, error) {
+	file, err := os.Open(archivePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", manifest.ErrArchiveNotFound, archivePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	format, input, err := archive.Identify(ctx, archivePath, file, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", manifest.ErrUnsupportedFormat, err)
+	}
+	extractor, ok := format.(archiver.Extractor)
+	if !ok {
+		return nil, fmt.Errorf("%w: format does not support extraction", manifest.ErrUnsupportedFormat)
+	}
+
+	parser := plugin.NewParser()
+	var plugins []pluginResult
+	err = extractor.Extract(ctx, input, func(ctx context.Context, f archiver.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if f.IsDir() || !plugin.IsPluginFile(f.NameInArchive) {
+			return nil
+		}
+
+		result := pluginResult{Filename: path.Base(strings.ReplaceAll(f.NameInArchive, "\\", "/"))}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if records && f.Size() <= maxScanSize {
+			result.Header, result.FormIDs = scanPlugin(ctx, parser, rc, result.Filename)
+			plugins = append(plugins, result)
+			return nil
+		}
+		header, err := parser.Parse(ctx, rc, result.Filename)
+		if err != nil {
+			log.Printf("Warning: could not parse plugin %s: %v", result.Filename, err)
+		} else {
+			result.Header = header
+		}
+		plugins = append(plugins, result)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", manifest.ErrExtractionFailed, err)
+	}
+	return plugins, nil
+}
+
+// scanPlugin reads a plugin into memory to parse its header and collect the
+// form IDs of its records. Failures are logged and leave the results empty.
+func scanPlugin(ctx context.Context, parser *plugin.Parser, r io.Reader, filename string) (*plugin.PluginHeader, []uint32) {
+	data, err := io.ReadAll(io.LimitReader(r, maxScanSize))
+	if err != nil {
+		log.Printf("Warning: could not read plugin %s: %v", filename, err)
+		return nil, nil
+	}
+	header, err := parser.Parse(ctx, bytes.NewReader(data), filename)
+	if err != nil {
+		log.Printf("Warning: could not parse plugin %s: %v", filename, err)
+		return nil, nil
+	}
+
+	var formIDs []uint32
+	err = parser.ScanBytes(ctx, data, func(rec *plugin.Record) error {
+		if rec.Signature != plugin.SignatureTES4 {
+			formIDs = append(formIDs, rec.FormID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Warning: could not scan records of plugin %s: %v", filename, err)
+		return header, nil
+	}
+	return header, formIDs
+}
@@ -0,0 +1,82 @@
+package savegame
+
+import "strings"
+
+// basePlugins are shipped with each game and are never part of a collection.
+var basePlugins = map[Game][]string{
+	GameSkyrim: {
+		"skyrim.esm", "update.esm", "dawnguard.esm", "hearthfires.esm", "dragonborn.esm",
+		"_resourcepack.esl",
+	},
+	GameFallout4: {
+		"fallout4.esm", "dlcrobot.esm", "dlcworkshop01.esm", "dlccoast.esm", "dlcworkshop02.esm",
+		"dlcworkshop03.esm", "dlcnukaworld.esm", "dlcultrahighresolution.esm",
+	},
+}
+
+// IsBasePlugin reports whether a plugin ships with the game.
+// Creation Club plugins (cc*.esl/esm) are treated as base content.
+func IsBasePlugin(game Game, name string) bool {
+	lower := strings.ToLower(name)
+	for _, base := range basePlugins[game] {
+		if lower == base {
+			return true
+		}
+	}
+	return strings.HasPrefix(lower, "cc") && (strings.HasSuffix(lower, ".esl") || strings.HasSuffix(lower, ".esm"))
+}
+
+// CheckResult compares a save's plugins against an available plugin list.
+type CheckResult struct {
+	// Missing are plugins the save depends on that are not available.
+	// Loading the save without them can corrupt it.
+	Missing []string `json:"missing"`
+	// Present are plugins the save depends on that are available.
+	Present []string `json:"present"`
+	// BaseGame are base game and Creation Club plugins the save depends on.
+	BaseGame []string `json:"baseGame"`
+	// Added are available plugins the save does not use yet.
+	Added []string `json:"added"`
+	// Safe is true when no plugins are missing.
+	Safe bool `json:"safe"`
+}
+
+// Check compares the plugins of a save against the available plugins.
+// Names are compared case-insensitively, as the games do.
+func Check(save *SaveInfo, available []string) *CheckResult {
+	result := &CheckResult{
+		Missing:  []string{},
+		Present:  []string{},
+		BaseGame: []string{},
+		Added:    []string{},
+	}
+
+	have := make(map[string]bool, len(available))
+	for _, name := range available {
+		have[strings.ToLower(name)] = true
+	}
+
+	used := make(map[string]bool)
+	saved := append(append([]string{}, save.Plugins...), save.LightPlugins...)
+	for _, name := range saved {
+		lower := strings.ToLower(name)
+		used[lower] = true
+		switch {
+		case have[lower]:
+			result.Present = append(result.Present, name)
+		case IsBasePlugin(save.Game, name):
+			result.BaseGame = append(result.BaseGame, name)
+		default:
+			result.Missing = append(result.Missing, name)
+		}
+	}
+
+	for _, name := range available {
+		if !used[strings.ToLower(name)] {
+			result.Added = append(result.Added, name)
+		}
+	}
+
+	result.Safe = len(result.Missing) == 0
+	return result
+}
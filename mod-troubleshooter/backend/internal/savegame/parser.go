@@ -0,0 +1,284 @@
+// Package savegame reads the plugin list embedded in Skyrim and Fallout 4
+// save files.
+package savegame
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// Common errors returned by the parser.
+var (
+	ErrUnknownFormat          = errors.New("not a supported save file")
+	ErrUnsupportedCompression = errors.New("unsupported save compression")
+	ErrTruncated              = errors.New("save file is truncated")
+)
+
+// Game identifies which game a save belongs to.
+type Game string
+
+const (
+	GameSkyrim   Game = "skyrim"
+	GameFallout4 Game = "fallout4"
+)
+
+const (
+	skyrimMagic   = "TESV_SAVEGAME"
+	fallout4Magic = "FO4_SAVEGAME"
+
+	// skyrimSEVersion is the first save version written by Skyrim Special Edition.
+	skyrimSEVersion = 12
+	// skyrimLightPluginsFormVersion is the first Skyrim form version with light plugins.
+	skyrimLightPluginsFormVersion = 78
+	// fallout4LightPluginsFormVersion is the first Fallout 4 form version with light plugins.
+	fallout4LightPluginsFormVersion = 68
+
+	// maxHeaderSize and maxScreenshotSize guard against corrupt size fields.
+	maxHeaderSize     = 64 * 1024
+	maxScreenshotSize = 64 * 1024 * 1024
+	// maxBodySize limits the decompressed save body.
+	maxBodySize = 512 * 1024 * 1024
+)
+
+// Compression types used by Skyrim Special Edition saves.
+const (
+	compressionNone = 0
+	compressionZlib = 1
+	compressionLZ4  = 2
+)
+
+// SaveInfo is the information read from a save file.
+type SaveInfo struct {
+	Game           Game   `json:"game"`
+	Version        uint32 `json:"version"`
+	SaveNumber     uint32 `json:"saveNumber"`
+	PlayerName     string `json:"playerName"`
+	PlayerLevel    uint32 `json:"playerLevel"`
+	PlayerLocation string `json:"playerLocation"`
+	GameDate       string `json:"gameDate"`
+	// Plugins are the full plugins active when the game was saved, in load order.
+	Plugins []string `json:"plugins"`
+	// LightPlugins are the light (ESL) plugins active when the game was saved.
+	LightPlugins []string `json:"lightPlugins"`
+}
+
+// Parse reads a save file header and its plugin list.
+func Parse(r io.Reader) (*SaveInfo, error) {
+	// Read the shorter magic first, then the rest of Skyrim's
+	magic := make([]byte, len(fallout4Magic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	var info SaveInfo
+	switch string(magic) {
+	case fallout4Magic:
+		info.Game = GameFallout4
+	case skyrimMagic[:len(fallout4Magic)]:
+		rest := make([]byte, len(skyrimMagic)-len(fallout4Magic))
+		if _, err := io.ReadFull(r, rest); err != nil || string(rest) != skyrimMagic[len(fallout4Magic):] {
+			return nil, ErrUnknownFormat
+		}
+		info.Game = GameSkyrim
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	var headerSize uint32
+	if err := binary.Read(r, binary.LittleEndian, &headerSize); err != nil {
+		return nil, ErrTruncated
+	}
+	if headerSize > maxHeaderSize {
+		return nil, fmt.Errorf("%w: header size %d", ErrUnknownFormat, headerSize)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrTruncated
+	}
+
+	shot, compression, err := info.parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the screenshot
+	if shot > maxScreenshotSize {
+		return nil, fmt.Errorf("%w: screenshot size %d", ErrUnknownFormat, shot)
+	}
+	if _, err := io.CopyN(io.Discard, r, shot); err != nil {
+		return nil, ErrTruncated
+	}
+
+	body, err := decompressBody(r, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := info.parsePlugins(body); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// parseHeader reads the header fields and returns the screenshot size in
+// bytes and the body compression type.
+func (info *SaveInfo) parseHeader(header []byte) (int64, uint16, error) {
+	hr := &reader{r: bytes.NewReader(header)}
+
+	info.Version = hr.uint32()
+	info.SaveNumber = hr.uint32()
+	info.PlayerName = hr.wstring()
+	info.PlayerLevel = hr.uint32()
+	info.PlayerLocation = hr.wstring()
+	info.GameDate = hr.wstring()
+	hr.wstring() // player race editor ID
+	hr.uint16()  // player sex
+	hr.uint32()  // current experience
+	hr.uint32()  // experience needed to level up
+	hr.skip(8)   // save time
+	width := hr.uint32()
+	height := hr.uint32()
+
+	var compression uint16
+	bytesPerPixel := int64(4)
+	if info.Game == GameSkyrim {
+		if info.Version >= skyrimSEVersion {
+			compression = hr.uint16()
+		} else {
+			bytesPerPixel = 3
+		}
+	}
+
+	if hr.err != nil {
+		return 0, 0, ErrTruncated
+	}
+
+	return int64(width) * int64(height) * bytesPerPixel, compression, nil
+}
+
+// decompressBody returns a reader over the save body that follows the screenshot.
+func decompressBody(r io.Reader, compression uint16) (io.Reader, error) {
+	if compression == compressionNone {
+		return r, nil
+	}
+
+	var uncompressedLen, compressedLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &uncompressedLen); err != nil {
+		return nil, ErrTruncated
+	}
+	if err := binary.Read(r, binary.LittleEndian, &compressedLen); err != nil {
+		return nil, ErrTruncated
+	}
+	if uncompressedLen > maxBodySize || compressedLen > maxBodySize {
+		return nil, fmt.Errorf("%w: body size %d", ErrUnknownFormat, uncompressedLen)
+	}
+
+	compressed := make([]byte, compressedLen)
+	if _, err := io.ReadFull(r, compressed); err != nil {
+		return nil, ErrTruncated
+	}
+
+	switch compression {
+	case compressionZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("decompress save: %w", err)
+		}
+		return zr, nil
+	case compressionLZ4:
+		body := make([]byte, uncompressedLen)
+		n, err := lz4.UncompressBlock(compressed, body)
+		if err != nil {
+			return nil, fmt.Errorf("decompress save: %w", err)
+		}
+		return bytes.NewReader(body[:n]), nil
+	default:
+		return nil, fmt.Errorf("%w: type %d", ErrUnsupportedCompression, compression)
+	}
+}
+
+// parsePlugins reads the plugin lists at the start of the save body.
+func (info *SaveInfo) parsePlugins(body io.Reader) error {
+	br := &reader{r: body}
+
+	formVersion := br.uint8()
+	if info.Game == GameFallout4 {
+		br.wstring() // game version
+	}
+	br.uint32() // plugin info size
+
+	count := int(br.uint8())
+	info.Plugins = make([]string, 0, count)
+	for i := 0; i < count && br.err == nil; i++ {
+		info.Plugins = append(info.Plugins, br.wstring())
+	}
+
+	info.LightPlugins = []string{}
+	lightThreshold := uint8(skyrimLightPluginsFormVersion)
+	if info.Game == GameFallout4 {
+		lightThreshold = fallout4LightPluginsFormVersion
+	}
+	if formVersion >= lightThreshold {
+		lightCount := int(br.uint16())
+		for i := 0; i < lightCount && br.err == nil; i++ {
+			info.LightPlugins = append(info.LightPlugins, br.wstring())
+		}
+	}
+
+	if br.err != nil {
+		return ErrTruncated
+	}
+	return nil
+}
+
+// reader reads little-endian save fields, remembering the first error.
+type reader struct {
+	r   io.Reader
+	err error
+	buf [8]byte
+}
+
+func (r *reader) read(n int) []byte {
+	if r.err != nil {
+		return r.buf[:n]
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:n]); err != nil {
+		r.err = err
+	}
+	return r.buf[:n]
+}
+
+func (r *reader) uint8() uint8   { return r.read(1)[0] }
+func (r *reader) uint16() uint16 { return binary.LittleEndian.Uint16(r.read(2)) }
+func (r *reader) uint32() uint32 { return binary.LittleEndian.Uint32(r.read(4)) }
+
+func (r *reader) skip(n int64) {
+	if r.err != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, r.r, n); err != nil {
+		r.err = err
+	}
+}
+
+// wstring reads a string prefixed with its uint16 length.
+func (r *reader) wstring() string {
+	n := r.uint16()
+	if r.err != nil {
+		return ""
+	}
+	s := make([]byte, n)
+	if _, err := io.ReadFull(r.r, s); err != nil {
+		r.err = err
+		return ""
+	}
+	return string(s)
+}
@@ -0,0 +1,185 @@
+package savegame
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+type testSave struct {
+	game        Game
+	version     uint32
+	compression uint16
+	formVersion uint8
+	plugins     []string
+	light       []string
+}
+
+func writeWString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.LittleEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+// build encodes a minimal save file with a 2x1 screenshot.
+func (s testSave) build(t *testing.T) []byte {
+	t.Helper()
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, s.version)
+	binary.Write(&header, binary.LittleEndian, uint32(7)) // save number
+	writeWString(&header, "Dovahkiin")
+	binary.Write(&header, binary.LittleEndian, uint32(42)) // level
+	writeWString(&header, "Whiterun")
+	writeWString(&header, "017.18.43")
+	writeWString(&header, "NordRace")
+	binary.Write(&header, binary.LittleEndian, uint16(0))
+	binary.Write(&header, binary.LittleEndian, float32(0))
+	binary.Write(&header, binary.LittleEndian, float32(0))
+	binary.Write(&header, binary.LittleEndian, uint64(0))
+	binary.Write(&header, binary.LittleEndian, uint32(2)) // screenshot width
+	binary.Write(&header, binary.LittleEndian, uint32(1)) // screenshot height
+	bytesPerPixel := 4
+	if s.game == GameSkyrim {
+		if s.version >= skyrimSEVersion {
+			binary.Write(&header, binary.LittleEndian, s.compression)
+		} else {
+			bytesPerPixel = 3
+		}
+	}
+
+	var body bytes.Buffer
+	body.WriteByte(s.formVersion)
+	if s.game == GameFallout4 {
+		writeWString(&body, "1.10.163.0")
+	}
+	binary.Write(&body, binary.LittleEndian, uint32(0)) // plugin info size (unused)
+	body.WriteByte(uint8(len(s.plugins)))
+	for _, p := range s.plugins {
+		writeWString(&body, p)
+	}
+	if s.light != nil {
+		binary.Write(&body, binary.LittleEndian, uint16(len(s.light)))
+		for _, p := range s.light {
+			writeWString(&body, p)
+		}
+	}
+	body.WriteString("rest of the save")
+
+	var out bytes.Buffer
+	if s.game == GameFallout4 {
+		out.WriteString(fallout4Magic)
+	} else {
+		out.WriteString(skyrimMagic)
+	}
+	binary.Write(&out, binary.LittleEndian, uint32(header.Len()))
+	out.Write(header.Bytes())
+	out.Write(make([]byte, 2*bytesPerPixel))
+
+	switch s.compression {
+	case compressionNone:
+		out.Write(body.Bytes())
+	case compressionZlib:
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(body.Bytes())
+		zw.Close()
+		binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+		binary.Write(&out, binary.LittleEndian, uint32(compressed.Len()))
+		out.Write(compressed.Bytes())
+	case compressionLZ4:
+		compressed := make([]byte, lz4.CompressBlockBound(body.Len()))
+		n, err := lz4.CompressBlock(body.Bytes(), compressed, nil)
+		if err != nil || n == 0 {
+			t.Fatalf("failed to compress body: %v", err)
+		}
+		binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+		binary.Write(&out, binary.LittleEndian, uint32(n))
+		out.Write(compressed[:n])
+	}
+
+	return out.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	plugins := []string{"Skyrim.esm", "Update.esm", "SkyUI_SE.esp", "Alternate Start - Live Another Life.esp"}
+
+	tests := []struct {
+		name      string
+		save      testSave
+		wantLight []string
+	}{
+		{"skyrim legendary", testSave{game: GameSkyrim, version: 9, formVersion: 74, plugins: plugins}, []string{}},
+		{"skyrim se zlib", testSave{game: GameSkyrim, version: 12, compression: compressionZlib, formVersion: 78, plugins: plugins, light: []string{"ccQDRSSE001-SurvivalMode.esl"}}, []string{"ccQDRSSE001-SurvivalMode.esl"}},
+		{"skyrim se lz4", testSave{game: GameSkyrim, version: 12, compression: compressionLZ4, formVersion: 78, plugins: plugins, light: []string{}}, []string{}},
+		{"fallout 4", testSave{game: GameFallout4, version: 15, formVersion: 68, plugins: plugins, light: []string{"Light.esl"}}, []string{"Light.esl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Parse(bytes.NewReader(tt.save.build(t)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if info.Game != tt.save.game {
+				t.Errorf("expected game %s, got %s", tt.save.game, info.Game)
+			}
+			if info.PlayerName != "Dovahkiin" || info.PlayerLevel != 42 || info.PlayerLocation != "Whiterun" {
+				t.Errorf("unexpected player info: %+v", info)
+			}
+			if !reflect.DeepEqual(info.Plugins, plugins) {
+				t.Errorf("expected plugins %v, got %v", plugins, info.Plugins)
+			}
+			if !reflect.DeepEqual(info.LightPlugins, tt.wantLight) {
+				t.Errorf("expected light plugins %v, got %v", tt.wantLight, info.LightPlugins)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	if _, err := Parse(bytes.NewReader([]byte("not a save file at all"))); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+
+	data := testSave{game: GameSkyrim, version: 12, compression: compressionZlib, formVersion: 78, plugins: []string{"A.esp"}}.build(t)
+	if _, err := Parse(bytes.NewReader(data[:40])); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+
+	unsupported := testSave{game: GameSkyrim, version: 12, compression: 9, formVersion: 78}.build(t)
+	if _, err := Parse(bytes.NewReader(unsupported)); err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	save := &SaveInfo{
+		Game:         GameSkyrim,
+		Plugins:      []string{"Skyrim.esm", "Update.esm", "SkyUI_SE.esp", "Removed.esp"},
+		LightPlugins: []string{"ccBGSSSE001-Fish.esm", "MyLight.esl"},
+	}
+
+	result := Check(save, []string{"skyui_se.esp", "MyLight.esl", "NewMod.esp"})
+
+	if result.Safe {
+		t.Error("expected save to be unsafe with a missing plugin")
+	}
+	if !reflect.DeepEqual(result.Missing, []string{"Removed.esp"}) {
+		t.Errorf("expected Removed.esp missing, got %v", result.Missing)
+	}
+	if !reflect.DeepEqual(result.Present, []string{"SkyUI_SE.esp", "MyLight.esl"}) {
+		t.Errorf("expected present plugins, got %v", result.Present)
+	}
+	if len(result.BaseGame) != 3 {
+		t.Errorf("expected 3 base game plugins, got %v", result.BaseGame)
+	}
+	if !reflect.DeepEqual(result.Added, []string{"NewMod.esp"}) {
+		t.Errorf("expected NewMod.esp added, got %v", result.Added)
+	}
+}
@@ -0,0 +1,266 @@
+// Package stats records local, anonymous usage statistics.
+// Nothing collected here is ever sent anywhere; it is only exposed
+// through the local API so users can inspect their own usage.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+// Kind identifies the type of analysis being recorded.
+type Kind string
+
+const (
+	KindConflicts Kind = "conflicts"
+	KindLoadOrder Kind = "loadorder"
+	KindFomod     Kind = "fomod"
+)
+
+// maxTopFileTypes limits how many file types are listed in a snapshot.
+const maxTopFileTypes = 10
+
+// Config holds configuration for the stats collector.
+type Config struct {
+	// Path is the JSON file used to persist counters between restarts.
+	// Leave empty to keep counters in memory only.
+	Path string
+}
+
+// counters is the persisted form of the collected statistics.
+type counters struct {
+	Since             time.Time        `json:"since"`
+	Analyses          map[Kind]int64   `json:"analyses"`
+	CacheHits         map[Kind]int64   `json:"cacheHits"`
+	CacheMisses       map[Kind]int64   `json:"cacheMisses"`
+	ConflictFileTypes map[string]int64 `json:"conflictFileTypes"`
+}
+
+// Collector accumulates usage statistics.
+// All methods are safe to call on a nil Collector, which records nothing;
+// this is how stats collection is disabled.
+type Collector struct {
+	mu   sync.Mutex
+	path string
+	c    counters
+}
+
+// New creates a collector, restoring previously saved counters if present.
+func New(cfg Config) (*Collector, error) {
+	col := &Collector{
+		path: cfg.Path,
+		c:    newCounters(),
+	}
+
+	if cfg.Path == "" {
+		return col, nil
+	}
+
+	data, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return col, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read stats file: %w", err)
+	}
+
+	var saved counters
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("parse stats file: %w", err)
+	}
+	col.c = mergeCounters(newCounters(), saved)
+
+	return col, nil
+}
+
+// newCounters returns empty counters starting now.
+func newCounters() counters {
+	return counters{
+		Since:             time.Now().UTC(),
+		Analyses:          make(map[Kind]int64),
+		CacheHits:         make(map[Kind]int64),
+		CacheMisses:       make(map[Kind]int64),
+		ConflictFileTypes: make(map[string]int64),
+	}
+}
+
+// mergeCounters copies saved counters into fresh ones, tolerating missing maps.
+func mergeCounters(dst, src counters) counters {
+	if !src.Since.IsZero() {
+		dst.Since = src.Since
+	}
+	for k, v := range src.Analyses {
+		dst.Analyses[k] = v
+	}
+	for k, v := range src.CacheHits {
+		dst.CacheHits[k] = v
+	}
+	for k, v := range src.CacheMisses {
+		dst.CacheMisses[k] = v
+	}
+	for k, v := range src.ConflictFileTypes {
+		dst.ConflictFileTypes[k] = v
+	}
+	return dst
+}
+
+// RecordAnalysis counts a completed analysis of the given kind.
+func (col *Collector) RecordAnalysis(kind Kind) {
+	if col == nil {
+		return
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	col.c.Analyses[kind]++
+}
+
+// RecordCacheHit counts a cache hit for the given kind of analysis.
+func (col *Collector) RecordCacheHit(kind Kind) {
+	if col == nil {
+		return
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	col.c.CacheHits[kind]++
+}
+
+// RecordCacheMiss counts a cache miss for the given kind of analysis.
+func (col *Collector) RecordCacheMiss(kind Kind) {
+	if col == nil {
+		return
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	col.c.CacheMisses[kind]++
+}
+
+// RecordConflicts counts a completed conflict analysis and its conflicts by file type.
+func (col *Collector) RecordConflicts(result *conflict.AnalysisResult) {
+	if col == nil {
+		return
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	col.c.Analyses[KindConflicts]++
+	if result == nil {
+		return
+	}
+	for fileType, count := range result.Stats.ByFileType {
+		col.c.ConflictFileTypes[string(fileType)] += int64(count)
+	}
+}
+
+// CacheStats summarizes cache usage for one kind of analysis.
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hitRate"`
+}
+
+// FileTypeCount is the number of conflicts seen for a file type.
+type FileTypeCount struct {
+	FileType string `json:"fileType"`
+	Count    int64  `json:"count"`
+}
+
+// Snapshot is a point-in-time view of the collected statistics.
+type Snapshot struct {
+	// Enabled reports whether stats collection is turned on.
+	Enabled bool `json:"enabled"`
+	// Since is when collection started or was last reset.
+	Since time.Time `json:"since,omitempty"`
+	// Analyses counts completed analyses by kind.
+	Analyses map[Kind]int64 `json:"analyses,omitempty"`
+	// Cache summarizes cache usage by kind.
+	Cache map[Kind]CacheStats `json:"cache,omitempty"`
+	// TopConflictFileTypes lists the file types with the most conflicts.
+	TopConflictFileTypes []FileTypeCount `json:"topConflictFileTypes,omitempty"`
+}
+
+// Snapshot returns the current statistics.
+func (col *Collector) Snapshot() Snapshot {
+	if col == nil {
+		return Snapshot{Enabled: false}
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+
+	snap := Snapshot{
+		Enabled:              true,
+		Since:                col.c.Since,
+		Analyses:             make(map[Kind]int64, len(col.c.Analyses)),
+		Cache:                make(map[Kind]CacheStats),
+		TopConflictFileTypes: []FileTypeCount{},
+	}
+	for k, v := range col.c.Analyses {
+		snap.Analyses[k] = v
+	}
+
+	for _, kind := range []Kind{KindConflicts, KindLoadOrder, KindFomod} {
+		hits, misses := col.c.CacheHits[kind], col.c.CacheMisses[kind]
+		if hits+misses == 0 {
+			continue
+		}
+		snap.Cache[kind] = CacheStats{
+			Hits:    hits,
+			Misses:  misses,
+			HitRate: float64(hits) / float64(hits+misses),
+		}
+	}
+
+	for fileType, count := range col.c.ConflictFileTypes {
+		snap.TopConflictFileTypes = append(snap.TopConflictFileTypes, FileTypeCount{FileType: fileType, Count: count})
+	}
+	sort.Slice(snap.TopConflictFileTypes, func(i, j int) bool {
+		a, b := snap.TopConflictFileTypes[i], snap.TopConflictFileTypes[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.FileType < b.FileType
+	})
+	if len(snap.TopConflictFileTypes) > maxTopFileTypes {
+		snap.TopConflictFileTypes = snap.TopConflictFileTypes[:maxTopFileTypes]
+	}
+
+	return snap
+}
+
+// Reset clears all counters.
+func (col *Collector) Reset() {
+	if col == nil {
+		return
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	col.c = newCounters()
+}
+
+// Save writes the counters to the configured file, if any.
+func (col *Collector) Save() error {
+	if col == nil || col.path == "" {
+		return nil
+	}
+	col.mu.Lock()
+	data, err := json.MarshalIndent(col.c, "", "  ")
+	col.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal stats: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(col.path), 0755); err != nil {
+		return fmt.Errorf("create stats directory: %w", err)
+	}
+	if err := os.WriteFile(col.path, data, 0644); err != nil {
+		return fmt.Errorf("write stats file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func TestCollector_NilIsDisabled(t *testing.T) {
+	var col *Collector
+
+	col.RecordAnalysis(KindFomod)
+	col.RecordCacheHit(KindFomod)
+	col.RecordCacheMiss(KindFomod)
+	col.RecordConflicts(nil)
+	col.Reset()
+
+	if err := col.Save(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if snap := col.Snapshot(); snap.Enabled {
+		t.Error("expected nil collector to report disabled")
+	}
+}
+
+func TestCollector_Snapshot(t *testing.T) {
+	col, err := New(Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	col.RecordAnalysis(KindLoadOrder)
+	col.RecordCacheHit(KindLoadOrder)
+	col.RecordCacheMiss(KindLoadOrder)
+	col.RecordCacheMiss(KindLoadOrder)
+	col.RecordCacheMiss(KindLoadOrder)
+	col.RecordConflicts(&conflict.AnalysisResult{
+		Stats: conflict.Stats{ByFileType: map[manifest.FileType]int{
+			manifest.FileTypeTexture: 5,
+			manifest.FileTypeMesh:    2,
+		}},
+	})
+	col.RecordConflicts(&conflict.AnalysisResult{
+		Stats: conflict.Stats{ByFileType: map[manifest.FileType]int{
+			manifest.FileTypeMesh: 4,
+		}},
+	})
+
+	snap := col.Snapshot()
+	if !snap.Enabled {
+		t.Error("expected enabled snapshot")
+	}
+	if snap.Analyses[KindConflicts] != 2 || snap.Analyses[KindLoadOrder] != 1 {
+		t.Errorf("unexpected analysis counts: %v", snap.Analyses)
+	}
+
+	cs, ok := snap.Cache[KindLoadOrder]
+	if !ok {
+		t.Fatal("expected load order cache stats")
+	}
+	if cs.Hits != 1 || cs.Misses != 3 || cs.HitRate != 0.25 {
+		t.Errorf("unexpected cache stats: %+v", cs)
+	}
+	if _, ok := snap.Cache[KindFomod]; ok {
+		t.Error("expected no cache stats for unused kind")
+	}
+
+	if len(snap.TopConflictFileTypes) != 2 {
+		t.Fatalf("expected 2 file types, got %d", len(snap.TopConflictFileTypes))
+	}
+	if top := snap.TopConflictFileTypes[0]; top.FileType != string(manifest.FileTypeMesh) || top.Count != 6 {
+		t.Errorf("expected mesh with 6 conflicts first, got %+v", top)
+	}
+
+	col.Reset()
+	if snap := col.Snapshot(); len(snap.Analyses) != 0 {
+		t.Errorf("expected no analyses after reset, got %v", snap.Analyses)
+	}
+}
+
+func TestCollector_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+
+	col, err := New(Config{Path: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	col.RecordAnalysis(KindFomod)
+	col.RecordCacheHit(KindFomod)
+	if err := col.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := New(Config{Path: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snap := restored.Snapshot()
+	if snap.Analyses[KindFomod] != 1 {
+		t.Errorf("expected 1 fomod analysis after restore, got %d", snap.Analyses[KindFomod])
+	}
+	if snap.Cache[KindFomod].Hits != 1 {
+		t.Errorf("expected 1 fomod cache hit after restore, got %d", snap.Cache[KindFomod].Hits)
+	}
+	if !snap.Since.Equal(col.Snapshot().Since) {
+		t.Error("expected collection start time to be preserved")
+	}
+}
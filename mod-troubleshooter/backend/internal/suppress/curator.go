@@ -0,0 +1,394 @@
+package suppress
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/netguard"
+)
+
+// MaxRuleFileSize is the largest curator rule file that is accepted.
+const MaxRuleFileSize = 1 << 20
+
+// Errors returned for curator rule files.
+var (
+	ErrSignature   = errors.New("invalid curator signature")
+	ErrKeyMismatch = errors.New("rule file is signed with a different key than the collection's")
+)
+
+// SignedRuleFile is a curator rule file as it is uploaded or published. The
+// payload is kept as the exact bytes that were signed, so verification does
+// not depend on how the JSON is formatted.
+type SignedRuleFile struct {
+	// Payload is the base64-encoded JSON of a RuleFile.
+	Payload string `json:"payload"`
+	// PublicKey is the curator's base64-encoded Ed25519 public key.
+	PublicKey string `json:"publicKey"`
+	// Signature is the base64-encoded Ed25519 signature of the decoded payload.
+	Signature string `json:"signature"`
+}
+
+// RuleFile lets a curator pre-answer known false positives in their
+// collection. Its overrides act as suppressions whenever the collection is
+// analyzed.
+type RuleFile struct {
+	// Slug is the collection the file applies to.
+	Slug string `json:"slug"`
+	// Curator names who published the file.
+	Curator string `json:"curator"`
+	// Version increases with each published file. Older versions are
+	// rejected so a stale copy can't bring back revoked overrides.
+	Version int `json:"version"`
+	// Overrides are the findings the curator considers acceptable.
+	Overrides []Override `json:"overrides"`
+}
+
+// Override hides findings like a suppression, with the same scopes.
+type Override struct {
+	Scope  Scope    `json:"scope"`
+	Path   string   `json:"path,omitempty"`
+	Rule   string   `json:"rule,omitempty"`
+	Mods   []string `json:"mods,omitempty"`
+	Reason string   `json:"reason"`
+}
+
+// CuratorRules is a verified rule file attached to a collection.
+type CuratorRules struct {
+	RuleFile
+	// PublicKey is the key the file was signed with. Later files for the
+	// collection must be signed with the same key.
+	PublicKey string `json:"publicKey"`
+	// SourceURL is where the file was fetched from, if it was not uploaded.
+	SourceURL string `json:"sourceUrl,omitempty"`
+	// AttachedAt is when the file was attached.
+	AttachedAt time.Time `json:"attachedAt"`
+}
+
+// Verify checks the signature of a rule file and returns its contents.
+// Signature errors wrap ErrSignature; malformed files wrap ErrInvalid.
+func (f SignedRuleFile) Verify() (*RuleFile, error) {
+	key, err := base64.StdEncoding.DecodeString(f.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: publicKey must be a base64 Ed25519 public key", ErrInvalid)
+	}
+	payload, err := base64.StdEncoding.DecodeString(f.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload must be base64", ErrInvalid)
+	}
+	sig, err := base64.StdEncoding.DecodeString(f.Signature)
+	if err != nil || !ed25519.Verify(key, payload, sig) {
+		return nil, ErrSignature
+	}
+
+	var file RuleFile
+	if err := json.Unmarshal(payload, &file); err != nil {
+		return nil, fmt.Errorf("%w: payload is not a rule file: %v", ErrInvalid, err)
+	}
+	if strings.TrimSpace(file.Slug) == "" {
+		return nil, fmt.Errorf("%w: slug is required", ErrInvalid)
+	}
+	if strings.TrimSpace(file.Curator) == "" {
+		return nil, fmt.Errorf("%w: curator is required", ErrInvalid)
+	}
+	for i, o := range file.Overrides {
+		sup := o.suppression(file.Slug, file.Curator)
+		if err := sup.Validate(); err != nil {
+			return nil, fmt.Errorf("override %d: %w", i, err)
+		}
+		file.Overrides[i] = Override{Scope: sup.Scope, Path: sup.Path, Rule: sup.Rule, Mods: sup.Mods, Reason: sup.Reason}
+	}
+	return &file, nil
+}
+
+// suppression turns an override into the suppression it stands for.
+func (o Override) suppression(slug, curator string) Suppression {
+	sup := Suppression{
+		Slug:      slug,
+		Scope:     o.Scope,
+		Path:      o.Path,
+		Rule:      o.Rule,
+		Mods:      o.Mods,
+		Reason:    o.Reason,
+		CreatedBy: "curator " + curator,
+	}
+	if sup.Scope == ScopePath && sup.Path != "" {
+		sup.Path = manifest.NormalizePath(sup.Path)
+	}
+	if sup.Scope != ScopeModPair {
+		sup.Mods = nil
+	}
+	return sup
+}
+
+// curatorKey is the key a collection's rule files must be signed with, and
+// the newest version attached with it.
+type curatorKey struct {
+	PublicKey string
+	Version   int
+}
+
+// AttachRuleFile verifies a rule file and attaches it to a collection,
+// replacing any earlier file. Files must be signed with the key the
+// operator configured for the collection or, without one, the key of the
+// first file attached to it; others fail with ErrKeyMismatch. The key and
+// newest version stay pinned when the file is detached, so detaching can't
+// be used to attach another curator's file or a stale one.
+func (s *Store) AttachRuleFile(ctx context.Context, slug string, signed SignedRuleFile, sourceURL string) (*CuratorRules, error) {
+	file, err := signed.Verify()
+	if err != nil {
+		return nil, err
+	}
+	if file.Slug != slug {
+		return nil, fmt.Errorf("%w: rule file is for collection %q", ErrInvalid, file.Slug)
+	}
+
+	pinned, err := s.pinnedKey(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if configured, ok := s.curatorKeys[slug]; ok && (pinned == nil || pinned.PublicKey != configured) {
+		// The operator has replaced the pinned key
+		pinned = &curatorKey{PublicKey: configured}
+	}
+	switch {
+	case pinned == nil:
+	case pinned.PublicKey != signed.PublicKey:
+		return nil, ErrKeyMismatch
+	case file.Version < pinned.Version:
+		return nil, fmt.Errorf("%w: version %d is older than the attached version %d", ErrInvalid, file.Version, pinned.Version)
+	}
+
+	rules := &CuratorRules{
+		RuleFile:   *file,
+		PublicKey:  signed.PublicKey,
+		SourceURL:  sourceURL,
+		AttachedAt: s.now().UTC(),
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO curator_keys (slug, public_key, version, pinned_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(slug) DO UPDATE SET
+			pinned_at = CASE WHEN public_key = excluded.public_key THEN pinned_at ELSE excluded.pinned_at END,
+			public_key = excluded.public_key,
+			version = excluded.version
+	`, slug, signed.PublicKey, file.Version, rules.AttachedAt.UnixMilli()); err != nil {
+		return nil, fmt.Errorf("pin curator key: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO curator_rules (slug, public_key, payload, signature, source_url, attached_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(slug) DO UPDATE SET
+			public_key = excluded.public_key,
+			payload = excluded.payload,
+			signature = excluded.signature,
+			source_url = excluded.source_url,
+			attached_at = excluded.attached_at
+	`, slug, signed.PublicKey, signed.Payload, signed.Signature, sourceURL, rules.AttachedAt.UnixMilli()); err != nil {
+		return nil, fmt.Errorf("store rule file: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit rule file: %w", err)
+	}
+	return rules, nil
+}
+
+// pinnedKey returns the key pinned for a collection, or nil if none is.
+func (s *Store) pinnedKey(ctx context.Context, slug string) (*curatorKey, error) {
+	var key curatorKey
+	err := s.db.QueryRowContext(ctx, `SELECT public_key, version FROM curator_keys WHERE slug = ?`, slug).Scan(&key.PublicKey, &key.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query curator key: %w", err)
+	}
+	return &key, nil
+}
+
+// migrateCuratorKeys pins the keys of rule files attached before keys were
+// kept apart from them.
+func migrateCuratorKeys(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT slug, public_key, payload, attached_at FROM curator_rules
+		WHERE slug NOT IN (SELECT slug FROM curator_keys)
+	`)
+	if err != nil {
+		return fmt.Errorf("query unpinned rule files: %w", err)
+	}
+	type pin struct {
+		slug     string
+		key      curatorKey
+		pinnedAt int64
+	}
+	var pins []pin
+	for rows.Next() {
+		var slug, key, payload string
+		var attachedAt int64
+		if err := rows.Scan(&slug, &key, &payload, &attachedAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan rule file: %w", err)
+		}
+		// The payload was verified when attached
+		var file RuleFile
+		if data, err := base64.StdEncoding.DecodeString(payload); err == nil {
+			json.Unmarshal(data, &file)
+		}
+		pins = append(pins, pin{slug: slug, key: curatorKey{PublicKey: key, Version: file.Version}, pinnedAt: attachedAt})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query unpinned rule files: %w", err)
+	}
+
+	for _, pin := range pins {
+		if _, err := db.Exec(`INSERT INTO curator_keys (slug, public_key, version, pinned_at) VALUES (?, ?, ?, ?)`,
+			pin.slug, pin.key.PublicKey, pin.key.Version, pin.pinnedAt); err != nil {
+			return fmt.Errorf("pin curator key: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadCuratorKeys reads the curator keys the operator trusts from a JSON
+// file mapping collection slugs to base64 Ed25519 public keys.
+func LoadCuratorKeys(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read curator keys: %w", err)
+	}
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parse curator keys: %w", err)
+	}
+	for slug, key := range keys {
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("curator key for %q is not a base64 Ed25519 public key", slug)
+		}
+	}
+	return keys, nil
+}
+
+// RuleFile returns the rule file attached to a collection.
+func (s *Store) RuleFile(ctx context.Context, slug string) (*CuratorRules, error) {
+	var signed SignedRuleFile
+	var sourceURL string
+	var attachedAt int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT public_key, payload, signature, source_url, attached_at
+		FROM curator_rules WHERE slug = ?
+	`, slug).Scan(&signed.PublicKey, &signed.Payload, &signed.Signature, &sourceURL, &attachedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query rule file: %w", err)
+	}
+
+	// Stored files were verified when attached; checking again guards
+	// against the database being edited behind our back.
+	file, err := signed.Verify()
+	if err != nil {
+		return nil, fmt.Errorf("stored rule file for %q: %w", slug, err)
+	}
+	return &CuratorRules{
+		RuleFile:   *file,
+		PublicKey:  signed.PublicKey,
+		SourceURL:  sourceURL,
+		AttachedAt: time.UnixMilli(attachedAt).UTC(),
+	}, nil
+}
+
+// DetachRuleFile removes the rule file attached to a collection. The
+// curator's key stays pinned.
+func (s *Store) DetachRuleFile(ctx context.Context, slug string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM curator_rules WHERE slug = ?`, slug)
+	if err != nil {
+		return fmt.Errorf("delete rule file: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// curatorSuppressions returns the overrides of the rule file attached to a
+// collection as suppressions, or none if no file is attached.
+func (s *Store) curatorSuppressions(ctx context.Context, slug string) ([]Suppression, error) {
+	if slug == "" {
+		return nil, nil
+	}
+	rules, err := s.RuleFile(ctx, slug)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sups := make([]Suppression, len(rules.Overrides))
+	for i, o := range rules.Overrides {
+		sups[i] = o.suppression(slug, rules.Curator)
+		sups[i].CreatedAt = rules.AttachedAt
+	}
+	return sups, nil
+}
+
+// NewRuleFileClient returns an HTTP client for fetching rule files from
+// curator URLs. It refuses to connect to loopback, private and link-local
+// addresses, so a rule file URL can't be used to probe the server's network.
+func NewRuleFileClient(timeout time.Duration) *http.Client {
+	var guard netguard.Guard
+	return guard.NewClient(timeout)
+}
+
+// FetchRuleFile downloads a signed rule file from an HTTPS URL. The file
+// is not verified; AttachRuleFile does that.
+func FetchRuleFile(ctx context.Context, client *http.Client, rawURL string) (SignedRuleFile, error) {
+	var signed SignedRuleFile
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return signed, fmt.Errorf("%w: url must be an https URL", ErrInvalid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return signed, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return signed, fmt.Errorf("fetch rule file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return signed, fmt.Errorf("fetch rule file: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxRuleFileSize+1))
+	if err != nil {
+		return signed, fmt.Errorf("read rule file: %w", err)
+	}
+	if len(data) > MaxRuleFileSize {
+		return signed, fmt.Errorf("%w: rule file is larger than %d bytes", ErrInvalid, MaxRuleFileSize)
+	}
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return signed, fmt.Errorf("%w: rule file is not valid JSON: %v", ErrInvalid, err)
+	}
+	return signed, nil
+}
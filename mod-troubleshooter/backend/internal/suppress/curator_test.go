@@ -0,0 +1,259 @@
+package suppress
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+)
+
+func signRuleFile(t *testing.T, key ed25519.PrivateKey, file RuleFile) SignedRuleFile {
+	t.Helper()
+	payload, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("marshal rule file: %v", err)
+	}
+	return SignedRuleFile{
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}
+}
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func TestStore_AttachRuleFile(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	key := newKey(t)
+
+	file := RuleFile{
+		Slug:    "abc",
+		Curator: "Kit",
+		Version: 1,
+		Overrides: []Override{
+			{Scope: ScopePath, Path: `Textures\Sky\*.dds`, Reason: "Sky textures replace each other on purpose"},
+		},
+	}
+	rules, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, key, file), "")
+	if err != nil {
+		t.Fatalf("AttachRuleFile() error = %v", err)
+	}
+	if rules.Overrides[0].Path != "textures/sky/*.dds" {
+		t.Errorf("expected a normalized path, got %q", rules.Overrides[0].Path)
+	}
+
+	active, err := s.Active(ctx, "abc")
+	if err != nil {
+		t.Fatalf("Active() error = %v", err)
+	}
+	if len(active) != 1 || active[0].CreatedBy != "curator Kit" {
+		t.Fatalf("expected the curator override to be active, got %+v", active)
+	}
+	c := conflict.Conflict{Path: "textures/sky/clouds.dds"}
+	if NewSet(active).MatchConflict(c) == nil {
+		t.Error("expected the override to suppress the conflict")
+	}
+
+	if other, _ := s.Active(ctx, "other"); len(other) != 0 {
+		t.Errorf("expected no overrides for another collection, got %+v", other)
+	}
+
+	if err := s.DetachRuleFile(ctx, "abc"); err != nil {
+		t.Fatalf("DetachRuleFile() error = %v", err)
+	}
+	if active, _ := s.Active(ctx, "abc"); len(active) != 0 {
+		t.Errorf("expected no overrides after detaching, got %+v", active)
+	}
+	if err := s.DetachRuleFile(ctx, "abc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_AttachRuleFileRejected(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	key := newKey(t)
+
+	file := RuleFile{Slug: "abc", Curator: "Kit", Version: 2}
+	if _, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, key, file), ""); err != nil {
+		t.Fatalf("AttachRuleFile() error = %v", err)
+	}
+
+	tampered := signRuleFile(t, key, file)
+	tampered.Payload = base64.StdEncoding.EncodeToString([]byte(`{"slug":"abc","curator":"Mallory","version":3}`))
+
+	tests := []struct {
+		name   string
+		slug   string
+		signed SignedRuleFile
+		want   error
+	}{
+		{name: "tampered payload", slug: "abc", signed: tampered, want: ErrSignature},
+		{name: "different key", slug: "abc", signed: signRuleFile(t, newKey(t), RuleFile{Slug: "abc", Curator: "Kit", Version: 3}), want: ErrKeyMismatch},
+		{name: "older version", slug: "abc", signed: signRuleFile(t, key, RuleFile{Slug: "abc", Curator: "Kit", Version: 1}), want: ErrInvalid},
+		{name: "other collection", slug: "xyz", signed: signRuleFile(t, key, file), want: ErrInvalid},
+		{
+			name:   "invalid override",
+			slug:   "abc",
+			signed: signRuleFile(t, key, RuleFile{Slug: "abc", Curator: "Kit", Version: 3, Overrides: []Override{{Scope: ScopeRule}}}),
+			want:   ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.AttachRuleFile(ctx, tt.slug, tt.signed, ""); !errors.Is(err, tt.want) {
+				t.Errorf("AttachRuleFile() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_DetachKeepsPinnedKey(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	key := newKey(t)
+
+	file := RuleFile{Slug: "abc", Curator: "Kit", Version: 2}
+	if _, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, key, file), ""); err != nil {
+		t.Fatalf("AttachRuleFile() error = %v", err)
+	}
+	if err := s.DetachRuleFile(ctx, "abc"); err != nil {
+		t.Fatalf("DetachRuleFile() error = %v", err)
+	}
+
+	other := signRuleFile(t, newKey(t), RuleFile{Slug: "abc", Curator: "Mallory", Version: 3})
+	if _, err := s.AttachRuleFile(ctx, "abc", other, ""); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected a different key to be rejected after detaching, got %v", err)
+	}
+	stale := signRuleFile(t, key, RuleFile{Slug: "abc", Curator: "Kit", Version: 1})
+	if _, err := s.AttachRuleFile(ctx, "abc", stale, ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected an older version to be rejected after detaching, got %v", err)
+	}
+	if _, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, key, file), ""); err != nil {
+		t.Errorf("expected the pinned key to attach again, got %v", err)
+	}
+}
+
+func TestStore_ConfiguredCuratorKey(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "suppressions.db")
+	oldKey, nextKey := newKey(t), newKey(t)
+
+	s, err := New(Config{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if _, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, oldKey, RuleFile{Slug: "abc", Curator: "Kit", Version: 5}), ""); err != nil {
+		t.Fatalf("AttachRuleFile() error = %v", err)
+	}
+	s.Close()
+
+	// The operator moves the collection to the curator's new key
+	configured := base64.StdEncoding.EncodeToString(nextKey.Public().(ed25519.PublicKey))
+	s, err = New(Config{DBPath: dbPath, CuratorKeys: map[string]string{"abc": configured}})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, oldKey, RuleFile{Slug: "abc", Curator: "Kit", Version: 6}), ""); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected the replaced key to be rejected, got %v", err)
+	}
+	rules, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, nextKey, RuleFile{Slug: "abc", Curator: "Kit", Version: 1}), "")
+	if err != nil {
+		t.Fatalf("expected the configured key to replace the pinned one, got %v", err)
+	}
+	if rules.PublicKey != configured {
+		t.Errorf("expected the file to be attached with the configured key, got %s", rules.PublicKey)
+	}
+}
+
+func TestStore_MigratesPinnedKeys(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "suppressions.db")
+	key := newKey(t)
+
+	s, err := New(Config{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if _, err := s.AttachRuleFile(ctx, "abc", signRuleFile(t, key, RuleFile{Slug: "abc", Curator: "Kit", Version: 4}), ""); err != nil {
+		t.Fatalf("AttachRuleFile() error = %v", err)
+	}
+	// A database from before keys were pinned apart from rule files
+	if _, err := s.db.Exec(`DELETE FROM curator_keys`); err != nil {
+		t.Fatalf("failed to drop pins: %v", err)
+	}
+	s.Close()
+
+	s, err = New(Config{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+	pinned, err := s.pinnedKey(ctx, "abc")
+	if err != nil || pinned == nil || pinned.Version != 4 {
+		t.Errorf("expected the attached file's key to be pinned at version 4, got %+v (%v)", pinned, err)
+	}
+}
+
+func TestLoadCuratorKeys(t *testing.T) {
+	dir := t.TempDir()
+	key := base64.StdEncoding.EncodeToString(newKey(t).Public().(ed25519.PublicKey))
+
+	path := filepath.Join(dir, "keys.json")
+	os.WriteFile(path, []byte(`{"abc": "`+key+`"}`), 0644)
+	keys, err := LoadCuratorKeys(path)
+	if err != nil {
+		t.Fatalf("LoadCuratorKeys() error = %v", err)
+	}
+	if keys["abc"] != key {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"abc": "not-a-key"}`), 0644)
+	if _, err := LoadCuratorKeys(invalid); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func TestFetchRuleFile(t *testing.T) {
+	signed := signRuleFile(t, newKey(t), RuleFile{Slug: "abc", Curator: "Kit"})
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(signed)
+	}))
+	defer ts.Close()
+
+	got, err := FetchRuleFile(context.Background(), ts.Client(), ts.URL)
+	if err != nil {
+		t.Fatalf("FetchRuleFile() error = %v", err)
+	}
+	if got != signed {
+		t.Errorf("FetchRuleFile() = %+v, want %+v", got, signed)
+	}
+
+	if _, err := FetchRuleFile(context.Background(), ts.Client(), "http://example.com/rules.json"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected plain http to be rejected, got %v", err)
+	}
+	if _, err := FetchRuleFile(context.Background(), NewRuleFileClient(0), ts.URL); err == nil {
+		t.Error("expected the rule file client to refuse loopback addresses")
+	}
+}
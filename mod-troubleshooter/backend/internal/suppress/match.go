@@ -0,0 +1,154 @@
+package suppress
+
+import (
+	"path"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+// Set matches findings against a list of active suppressions.
+type Set struct {
+	sups []Suppression
+}
+
+// NewSet creates a set from suppressions that are already known to be active.
+func NewSet(sups []Suppression) *Set {
+	return &Set{sups: sups}
+}
+
+// Len returns the number of suppressions in the set. A nil set is empty.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.sups)
+}
+
+// MatchConflict returns the first suppression matching a file conflict, or nil.
+func (s *Set) MatchConflict(c conflict.Conflict) *Suppression {
+	if s == nil {
+		return nil
+	}
+	for i := range s.sups {
+		sup := &s.sups[i]
+		switch sup.Scope {
+		case ScopePath:
+			if matchPath(sup.Path, c.Path) {
+				return sup
+			}
+		case ScopeRule:
+			for _, id := range c.MatchedRules {
+				if id == sup.Rule {
+					return sup
+				}
+			}
+		case ScopeModPair:
+			if hasSource(c, sup.Mods[0]) && hasSource(c, sup.Mods[1]) {
+				return sup
+			}
+		}
+	}
+	return nil
+}
+
+// MatchIssue returns the first suppression matching a load order issue, or nil.
+func (s *Set) MatchIssue(issue loadorder.Issue) *Suppression {
+	if s == nil {
+		return nil
+	}
+	for i := range s.sups {
+		sup := &s.sups[i]
+		switch sup.Scope {
+		case ScopePath:
+			if matchPath(sup.Path, issue.Plugin) {
+				return sup
+			}
+		case ScopeRule:
+			if string(issue.Type) == sup.Rule {
+				return sup
+			}
+		case ScopeModPair:
+			a, b := sup.Mods[0], sup.Mods[1]
+			if (strings.EqualFold(issue.Plugin, a) && strings.EqualFold(issue.RelatedPlugin, b)) ||
+				(strings.EqualFold(issue.Plugin, b) && strings.EqualFold(issue.RelatedPlugin, a)) {
+				return sup
+			}
+		}
+	}
+	return nil
+}
+
+// FilterConflicts returns a copy of the result without suppressed conflicts,
+// together with the conflicts that were removed. Stats are left as computed,
+// so totals still describe the whole collection. The input is not modified.
+func (s *Set) FilterConflicts(result *conflict.AnalysisResult) (*conflict.AnalysisResult, []conflict.Conflict) {
+	if result == nil || s.Len() == 0 {
+		return result, nil
+	}
+
+	var suppressed []conflict.Conflict
+	kept := make([]conflict.Conflict, 0, len(result.Conflicts))
+	for _, c := range result.Conflicts {
+		if s.MatchConflict(c) != nil {
+			suppressed = append(suppressed, c)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	if len(suppressed) == 0 {
+		return result, nil
+	}
+
+	filtered := *result
+	filtered.Conflicts = kept
+	return &filtered, suppressed
+}
+
+// FilterIssues returns a copy of the result without suppressed issues,
+// together with the issues that were removed. Stats are left as computed.
+// The input is not modified.
+func (s *Set) FilterIssues(result *loadorder.AnalysisResult) (*loadorder.AnalysisResult, []loadorder.Issue) {
+	if result == nil || s.Len() == 0 {
+		return result, nil
+	}
+
+	var suppressed []loadorder.Issue
+	kept := make([]loadorder.Issue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		if s.MatchIssue(issue) != nil {
+			suppressed = append(suppressed, issue)
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	if len(suppressed) == 0 {
+		return result, nil
+	}
+
+	filtered := *result
+	filtered.Issues = kept
+	return &filtered, suppressed
+}
+
+// matchPath reports whether a normalized pattern matches a path or plugin name.
+func matchPath(pattern, name string) bool {
+	name = manifest.NormalizePath(name)
+	if pattern == name {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// hasSource reports whether a conflict has a source with the given mod ID.
+func hasSource(c conflict.Conflict, modID string) bool {
+	for _, src := range c.Sources {
+		if src.ModID == modID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package suppress
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	_ "modernc.org/sqlite"
+)
+
+// Common errors returned by the suppression store.
+var (
+	ErrNotFound = errors.New("suppression not found")
+	ErrInvalid  = errors.New("invalid suppression")
+)
+
+// Scope identifies what a suppression matches.
+type Scope string
+
+const (
+	// ScopePath suppresses conflicts on a file path, or issues on a plugin filename.
+	// The pattern may use path.Match wildcards.
+	ScopePath Scope = "path"
+	// ScopeRule suppresses conflicts matching an incompatibility rule ID, or
+	// load order issues of a given type.
+	ScopeRule Scope = "rule"
+	// ScopeModPair suppresses conflicts between two mods, or issues between two plugins.
+	ScopeModPair Scope = "mod_pair"
+)
+
+// Config holds configuration for the suppression store.
+type Config struct {
+	// DBPath is the path to the SQLite database file.
+	DBPath string
+	// CuratorKeys are the base64 Ed25519 keys the operator trusts to sign
+	// rule files, by collection slug. They replace keys pinned by earlier
+	// rule files, and only they can (optional).
+	CuratorKeys map[string]string
+}
+
+// Suppression hides matching findings from analysis results.
+// Revoked suppressions are kept so the audit trail stays complete.
+type Suppression struct {
+	// ID is the unique identifier of the suppression.
+	ID int64 `json:"id"`
+	// Slug limits the suppression to one collection; empty applies to all.
+	Slug string `json:"slug,omitempty"`
+	// Scope is the kind of match.
+	Scope Scope `json:"scope"`
+	// Path is the file path or plugin pattern for ScopePath.
+	Path string `json:"path,omitempty"`
+	// Rule is the rule ID or issue type for ScopeRule.
+	Rule string `json:"rule,omitempty"`
+	// Mods are the two mod IDs or plugin filenames for ScopeModPair, in any order.
+	Mods []string `json:"mods,omitempty"`
+	// Reason explains why the finding is acceptable.
+	Reason string `json:"reason"`
+	// CreatedBy names who added the suppression.
+	CreatedBy string `json:"createdBy"`
+	// CreatedAt is when the suppression was added.
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt is when the suppression stops applying, if set.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// RevokedAt is when the suppression was revoked, if it was.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// RevokedBy names who revoked the suppression.
+	RevokedBy string `json:"revokedBy,omitempty"`
+}
+
+// Validate checks the suppression fields. Errors wrap ErrInvalid.
+func (s *Suppression) Validate() error {
+	if strings.TrimSpace(s.Reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalid)
+	}
+	if strings.TrimSpace(s.CreatedBy) == "" {
+		return fmt.Errorf("%w: createdBy is required", ErrInvalid)
+	}
+	switch s.Scope {
+	case ScopePath:
+		if s.Path == "" {
+			return fmt.Errorf("%w: path scope requires a path", ErrInvalid)
+		}
+		if _, err := path.Match(s.Path, ""); err != nil {
+			return fmt.Errorf("%w: invalid path pattern %q", ErrInvalid, s.Path)
+		}
+	case ScopeRule:
+		if s.Rule == "" {
+			return fmt.Errorf("%w: rule scope requires a rule", ErrInvalid)
+		}
+	case ScopeModPair:
+		if len(s.Mods) != 2 || s.Mods[0] == "" || s.Mods[1] == "" {
+			return fmt.Errorf("%w: mod_pair scope requires two mods", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown scope %q", ErrInvalid, s.Scope)
+	}
+	return nil
+}
+
+// Active reports whether the suppression applies at the given time.
+func (s *Suppression) Active(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+// Store provides SQLite-backed storage of suppressions.
+type Store struct {
+	db          *sql.DB
+	curatorKeys map[string]string
+	now         func() time.Time
+}
+
+// New creates a new suppression store with the given configuration.
+func New(cfg Config) (*Store, error) {
+	// Ensure the directory exists
+	dir := filepath.Dir(cfg.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create suppression directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize schema: %w", err)
+	}
+
+	return &Store{db: db, curatorKeys: cfg.CuratorKeys, now: time.Now}, nil
+}
+
+// initSchema creates the necessary tables.
+func initSchema(db *sql.DB) error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS suppressions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT NOT NULL DEFAULT '',
+			scope TEXT NOT NULL,
+			path TEXT NOT NULL DEFAULT '',
+			rule TEXT NOT NULL DEFAULT '',
+			mod_a TEXT NOT NULL DEFAULT '',
+			mod_b TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			expires_at INTEGER,
+			revoked_at INTEGER,
+			revoked_by TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS curator_rules (
+			slug TEXT PRIMARY KEY,
+			public_key TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			source_url TEXT NOT NULL DEFAULT '',
+			attached_at INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS curator_keys (
+			slug TEXT PRIMARY KEY,
+			public_key TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			pinned_at INTEGER NOT NULL
+		);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	return migrateCuratorKeys(db)
+}
+
+// Add stores a new suppression and returns it with its ID and creation time set.
+// Paths are normalized so patterns match analysis paths regardless of case.
+func (s *Store) Add(ctx context.Context, sup Suppression) (*Suppression, error) {
+	if sup.Scope == ScopePath && sup.Path != "" {
+		sup.Path = manifest.NormalizePath(sup.Path)
+	}
+	if err := sup.Validate(); err != nil {
+		return nil, err
+	}
+	if sup.ExpiresAt != nil && !sup.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalid)
+	}
+
+	sup.CreatedAt = s.now().UTC()
+	sup.RevokedAt = nil
+	sup.RevokedBy = ""
+
+	var modA, modB string
+	if sup.Scope == ScopeModPair {
+		modA, modB = sup.Mods[0], sup.Mods[1]
+	} else {
+		sup.Mods = nil
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO suppressions (slug, scope, path, rule, mod_a, mod_b, reason, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, sup.Slug, string(sup.Scope), sup.Path, sup.Rule, modA, modB, sup.Reason, sup.CreatedBy,
+		sup.CreatedAt.UnixMilli(), nullableMillis(sup.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("insert suppression: %w", err)
+	}
+
+	sup.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read suppression id: %w", err)
+	}
+
+	return &sup, nil
+}
+
+// List returns all suppressions, including expired and revoked ones, newest first.
+func (s *Store) List(ctx context.Context) ([]Suppression, error) {
+	return s.query(ctx, "ORDER BY created_at DESC, id DESC")
+}
+
+// Active returns the suppressions that apply to a collection right now,
+// followed by the overrides of its curator rule file, if one is attached.
+func (s *Store) Active(ctx context.Context, slug string) ([]Suppression, error) {
+	all, err := s.query(ctx, "WHERE revoked_at IS NULL AND (slug = '' OR slug = ?) ORDER BY id", slug)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	active := all[:0]
+	for _, sup := range all {
+		if sup.Active(now) {
+			active = append(active, sup)
+		}
+	}
+
+	curated, err := s.curatorSuppressions(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return append(active, curated...), nil
+}
+
+// Get returns a single suppression.
+func (s *Store) Get(ctx context.Context, id int64) (*Suppression, error) {
+	sups, err := s.query(ctx, "WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sups) == 0 {
+		return nil, ErrNotFound
+	}
+	return &sups[0], nil
+}
+
+// Revoke stops a suppression from applying while keeping its record.
+// Revoking an already revoked suppression keeps the original revocation.
+func (s *Store) Revoke(ctx context.Context, id int64, by string) (*Suppression, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE suppressions SET revoked_at = ?, revoked_by = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`, s.now().UnixMilli(), by, id); err != nil {
+		return nil, fmt.Errorf("revoke suppression: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// query selects suppressions with the given SQL clause appended.
+func (s *Store) query(ctx context.Context, clause string, args ...interface{}) ([]Suppression, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, slug, scope, path, rule, mod_a, mod_b, reason, created_by,
+			created_at, expires_at, revoked_at, revoked_by
+		FROM suppressions `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	sups := []Suppression{}
+	for rows.Next() {
+		var sup Suppression
+		var scope, modA, modB string
+		var createdAt int64
+		var expiresAt, revokedAt sql.NullInt64
+		if err := rows.Scan(&sup.ID, &sup.Slug, &scope, &sup.Path, &sup.Rule, &modA, &modB,
+			&sup.Reason, &sup.CreatedBy, &createdAt, &expiresAt, &revokedAt, &sup.RevokedBy); err != nil {
+			return nil, fmt.Errorf("scan suppression: %w", err)
+		}
+		sup.Scope = Scope(scope)
+		if sup.Scope == ScopeModPair {
+			sup.Mods = []string{modA, modB}
+		}
+		sup.CreatedAt = time.UnixMilli(createdAt).UTC()
+		sup.ExpiresAt = millisTime(expiresAt)
+		sup.RevokedAt = millisTime(revokedAt)
+		sups = append(sups, sup)
+	}
+
+	return sups, rows.Err()
+}
+
+// Close closes the database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// nullableMillis converts an optional time to a nullable column value.
+func nullableMillis(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+// millisTime converts a nullable column value to an optional time.
+func millisTime(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMilli(v.Int64).UTC()
+	return &t
+}
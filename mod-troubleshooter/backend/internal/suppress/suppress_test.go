@@ -0,0 +1,264 @@
+package suppress
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(Config{DBPath: filepath.Join(t.TempDir(), "suppressions.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore_AddListRevoke(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	sup, err := s.Add(ctx, Suppression{
+		Scope:     ScopePath,
+		Path:      `Textures\Sky\*.dds`,
+		Reason:    "Sky textures are meant to replace each other",
+		CreatedBy: "curator",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sup.ID == 0 {
+		t.Error("expected non-zero ID")
+	}
+	if sup.Path != "textures/sky/*.dds" {
+		t.Errorf("expected normalized path, got %q", sup.Path)
+	}
+	if !sup.CreatedAt.Equal(now) {
+		t.Errorf("expected created at %v, got %v", now, sup.CreatedAt)
+	}
+
+	expires := now.Add(time.Hour)
+	if _, err := s.Add(ctx, Suppression{
+		Slug:      "other",
+		Scope:     ScopeModPair,
+		Mods:      []string{"1", "2"},
+		Reason:    "Patched",
+		CreatedBy: "curator",
+		ExpiresAt: &expires,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	all, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 suppressions, got %d", len(all))
+	}
+	if len(all[0].Mods) != 2 || all[0].ExpiresAt == nil || !all[0].ExpiresAt.Equal(expires) {
+		t.Errorf("expected mod pair with expiry first, got %+v", all[0])
+	}
+
+	active, err := s.Active(ctx, "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != sup.ID {
+		t.Errorf("expected only the global suppression for abc, got %+v", active)
+	}
+
+	active, err = s.Active(ctx, "other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 2 {
+		t.Errorf("expected 2 active suppressions for other, got %d", len(active))
+	}
+
+	// Expired suppressions stop applying but stay listed
+	now = now.Add(2 * time.Hour)
+	active, err = s.Active(ctx, "other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 1 {
+		t.Errorf("expected 1 active suppression after expiry, got %d", len(active))
+	}
+
+	revoked, err := s.Revoke(ctx, sup.ID, "admin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked.RevokedAt == nil || revoked.RevokedBy != "admin" {
+		t.Errorf("expected revocation by admin, got %+v", revoked)
+	}
+
+	// A second revocation keeps the first
+	now = now.Add(time.Hour)
+	again, err := s.Revoke(ctx, sup.ID, "someone")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.RevokedBy != "admin" || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("expected original revocation to be kept, got %+v", again)
+	}
+
+	active, err = s.Active(ctx, "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 0 {
+		t.Errorf("expected no active suppressions, got %d", len(active))
+	}
+
+	all, err = s.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected revoked and expired suppressions to stay listed, got %d", len(all))
+	}
+}
+
+func TestStore_RevokeNotFound(t *testing.T) {
+	s := newTestStore(t)
+
+	if _, err := s.Revoke(context.Background(), 42, "admin"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_AddInvalid(t *testing.T) {
+	s := newTestStore(t)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		sup  Suppression
+	}{
+		{"missing reason", Suppression{Scope: ScopeRule, Rule: "r1", CreatedBy: "a"}},
+		{"missing author", Suppression{Scope: ScopeRule, Rule: "r1", Reason: "ok"}},
+		{"unknown scope", Suppression{Scope: "plugin", Reason: "ok", CreatedBy: "a"}},
+		{"empty path", Suppression{Scope: ScopePath, Reason: "ok", CreatedBy: "a"}},
+		{"bad pattern", Suppression{Scope: ScopePath, Path: "[", Reason: "ok", CreatedBy: "a"}},
+		{"empty rule", Suppression{Scope: ScopeRule, Reason: "ok", CreatedBy: "a"}},
+		{"one mod", Suppression{Scope: ScopeModPair, Mods: []string{"1"}, Reason: "ok", CreatedBy: "a"}},
+		{"expired", Suppression{Scope: ScopeRule, Rule: "r1", Reason: "ok", CreatedBy: "a", ExpiresAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Add(context.Background(), tt.sup); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSet_MatchConflict(t *testing.T) {
+	set := NewSet([]Suppression{
+		{ID: 1, Scope: ScopePath, Path: "textures/sky/*.dds"},
+		{ID: 2, Scope: ScopeRule, Rule: "enb-conflict"},
+		{ID: 3, Scope: ScopeModPair, Mods: []string{"10", "20"}},
+	})
+
+	tests := []struct {
+		name     string
+		conflict conflict.Conflict
+		expected int64
+	}{
+		{"path glob", conflict.Conflict{Path: "textures/sky/clouds.dds"}, 1},
+		{"path case", conflict.Conflict{Path: `Textures\Sky\Stars.dds`}, 1},
+		{"path other dir", conflict.Conflict{Path: "textures/sky/sub/clouds.dds"}, 0},
+		{"rule", conflict.Conflict{Path: "a.ini", MatchedRules: []string{"x", "enb-conflict"}}, 2},
+		{"mod pair", conflict.Conflict{Path: "b.esp", Sources: []conflict.ModFile{{ModID: "20"}, {ModID: "30"}, {ModID: "10"}}}, 3},
+		{"half pair", conflict.Conflict{Path: "b.esp", Sources: []conflict.ModFile{{ModID: "10"}, {ModID: "30"}}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int64
+			if sup := set.MatchConflict(tt.conflict); sup != nil {
+				got = sup.ID
+			}
+			if got != tt.expected {
+				t.Errorf("expected suppression %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSet_MatchIssue(t *testing.T) {
+	set := NewSet([]Suppression{
+		{ID: 1, Scope: ScopePath, Path: "unofficial*.esp"},
+		{ID: 2, Scope: ScopeRule, Rule: string(loadorder.IssueDuplicatePlugin)},
+		{ID: 3, Scope: ScopeModPair, Mods: []string{"a.esp", "b.esm"}},
+	})
+
+	tests := []struct {
+		name     string
+		issue    loadorder.Issue
+		expected int64
+	}{
+		{"plugin glob", loadorder.Issue{Type: loadorder.IssueMissingMaster, Plugin: "Unofficial Patch.esp"}, 1},
+		{"issue type", loadorder.Issue{Type: loadorder.IssueDuplicatePlugin, Plugin: "x.esp"}, 2},
+		{"plugin pair reversed", loadorder.Issue{Type: loadorder.IssueWrongOrder, Plugin: "B.esm", RelatedPlugin: "A.esp"}, 3},
+		{"no match", loadorder.Issue{Type: loadorder.IssueWrongOrder, Plugin: "a.esp", RelatedPlugin: "c.esm"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int64
+			if sup := set.MatchIssue(tt.issue); sup != nil {
+				got = sup.ID
+			}
+			if got != tt.expected {
+				t.Errorf("expected suppression %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSet_Filter(t *testing.T) {
+	set := NewSet([]Suppression{{ID: 1, Scope: ScopeRule, Rule: "r1"}})
+
+	conflicts := &conflict.AnalysisResult{
+		Conflicts: []conflict.Conflict{{Path: "a", MatchedRules: []string{"r1"}}, {Path: "b"}},
+		Stats:     conflict.Stats{TotalConflicts: 2},
+	}
+	filtered, suppressed := set.FilterConflicts(conflicts)
+	if len(filtered.Conflicts) != 1 || filtered.Conflicts[0].Path != "b" {
+		t.Errorf("expected only conflict b to remain, got %+v", filtered.Conflicts)
+	}
+	if len(suppressed) != 1 || suppressed[0].Path != "a" {
+		t.Errorf("expected conflict a to be suppressed, got %+v", suppressed)
+	}
+	if filtered.Stats.TotalConflicts != 2 {
+		t.Errorf("expected stats to be kept, got %d", filtered.Stats.TotalConflicts)
+	}
+	if len(conflicts.Conflicts) != 2 {
+		t.Error("expected input to be left unmodified")
+	}
+
+	issues := &loadorder.AnalysisResult{Issues: []loadorder.Issue{{Type: loadorder.IssueWrongOrder}}}
+	filteredIssues, suppressedIssues := set.FilterIssues(issues)
+	if filteredIssues != issues || suppressedIssues != nil {
+		t.Error("expected unmatched result to be returned as is")
+	}
+
+	var empty *Set
+	if got, _ := empty.FilterConflicts(conflicts); got != conflicts {
+		t.Error("expected nil set to return the input")
+	}
+}
@@ -0,0 +1,270 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/netguard"
+)
+
+// ErrNotConfigured is returned for targets the server cannot send to, such
+// as email targets when no SMTP server is configured.
+var ErrNotConfigured = errors.New("notification target not configured")
+
+// Notification is a message about a watched collection.
+type Notification struct {
+	// Slug is the collection slug.
+	Slug string `json:"slug"`
+	// Revision is the analyzed revision, if any.
+	Revision int `json:"revision,omitempty"`
+	// Title is a one-line summary.
+	Title string `json:"title"`
+	// Message is the full text.
+	Message string `json:"message"`
+	// URL links to the analysis results, if any.
+	URL string `json:"url,omitempty"`
+}
+
+// Notifier sends notifications to one target.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// SMTPConfig is the mail server email targets are sent through.
+type SMTPConfig struct {
+	// Host is the server's hostname; email targets are disabled without it.
+	Host string
+	// Port is the server's port (default: 587).
+	Port int
+	// Username and Password authenticate with the server (optional).
+	Username string
+	Password string
+	// From is the sender address.
+	From string
+}
+
+// Enabled returns true if an SMTP server is configured.
+func (c SMTPConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// DispatcherConfig holds configuration for the notification dispatcher.
+type DispatcherConfig struct {
+	// HTTPClient sends webhook, ntfy and Gotify notifications (default:
+	// a client with a 10 second timeout that only connects where Guard
+	// allows).
+	HTTPClient *http.Client
+	// Guard decides which addresses the default client connects to
+	// (default: public addresses only).
+	Guard *netguard.Guard
+	// SMTP is the mail server for email targets (optional).
+	SMTP SMTPConfig
+}
+
+// Dispatcher sends notifications to the targets of a watch.
+type Dispatcher struct {
+	client *http.Client
+	smtp   SMTPConfig
+	// sendMail sends an email; it is smtp.SendMail outside of tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewDispatcher creates a notification dispatcher.
+func NewDispatcher(cfg DispatcherConfig) *Dispatcher {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = cfg.Guard.NewClient(10 * time.Second)
+	}
+	if cfg.SMTP.Port == 0 {
+		cfg.SMTP.Port = 587
+	}
+	return &Dispatcher{client: client, smtp: cfg.SMTP, sendMail: smtp.SendMail}
+}
+
+// Notifier returns the notifier for a target.
+func (d *Dispatcher) Notifier(t Target) (Notifier, error) {
+	switch t.Type {
+	case TargetWebhook:
+		return &webhookNotifier{client: d.client, url: t.URL}, nil
+	case TargetNtfy:
+		return &ntfyNotifier{client: d.client, url: t.URL, token: t.Token}, nil
+	case TargetGotify:
+		return &gotifyNotifier{client: d.client, url: t.URL, token: t.Token}, nil
+	case TargetEmail:
+		if !d.smtp.Enabled() {
+			return nil, fmt.Errorf("%w: email requires an SMTP server", ErrNotConfigured)
+		}
+		return &emailNotifier{smtp: d.smtp, to: t.To, send: d.sendMail}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown target type %q", ErrNotConfigured, t.Type)
+	}
+}
+
+// Delivery is the outcome of sending a notification to one target.
+type Delivery struct {
+	Type      TargetType `json:"type"`
+	Delivered bool       `json:"delivered"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Notify sends a notification to every target of a watch. A target that
+// fails does not keep the others from being notified.
+func (d *Dispatcher) Notify(ctx context.Context, w *Watch, n Notification) []Delivery {
+	deliveries := make([]Delivery, 0, len(w.Targets))
+	for _, t := range w.Targets {
+		delivery := Delivery{Type: t.Type}
+		notifier, err := d.Notifier(t)
+		if err == nil {
+			err = notifier.Notify(ctx, n)
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Delivered = true
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// webhookNotifier posts the notification as JSON.
+type webhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, note Notification) error {
+	body, err := json.Marshal(note)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(n.client, req)
+}
+
+// ntfyNotifier publishes the notification to an ntfy topic.
+type ntfyNotifier struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func (n *ntfyNotifier) Notify(ctx context.Context, note Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, strings.NewReader(note.Message))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	// Headers are sent as is, so non-ASCII titles are RFC 2047 encoded,
+	// which ntfy decodes.
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", note.Title))
+	req.Header.Set("Tags", "package")
+	if note.URL != "" {
+		req.Header.Set("Click", note.URL)
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return send(n.client, req)
+}
+
+// gotifyNotifier creates a message on a Gotify server.
+type gotifyNotifier struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+// gotifyMessage is the body of Gotify's create message request.
+type gotifyMessage struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+func (n *gotifyNotifier) Notify(ctx context.Context, note Notification) error {
+	msg := gotifyMessage{Title: note.Title, Message: note.Message, Priority: 5}
+	if note.URL != "" {
+		msg.Extras = map[string]interface{}{
+			"client::notification": map[string]interface{}{"click": map[string]string{"url": note.URL}},
+		}
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(n.url, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.token)
+	return send(n.client, req)
+}
+
+// send performs a notification request and checks its status.
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("send notification: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emailNotifier mails the notification through the configured SMTP server.
+type emailNotifier struct {
+	smtp SMTPConfig
+	to   []string
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, note Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.smtp.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", note.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body := note.Message
+	if note.URL != "" {
+		body += "\n\n" + note.URL
+	}
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if n.smtp.Username != "" {
+		auth = smtp.PlainAuth("", n.smtp.Username, n.smtp.Password, n.smtp.Host)
+	}
+	addr := net.JoinHostPort(n.smtp.Host, strconv.Itoa(n.smtp.Port))
+	if err := n.send(addr, auth, n.smtp.From, n.to, msg.Bytes()); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+var testNotification = Notification{
+	Slug:     "abc123",
+	Revision: 7,
+	Title:    "Revision 7 analyzed",
+	Message:  "2 new conflicts",
+	URL:      "https://mods.example.org/collections/abc123",
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	var webhook Notification
+	var ntfyTitle, ntfyAuth, ntfyBody string
+	var gotify gotifyMessage
+	var gotifyKey string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hook":
+			json.NewDecoder(r.Body).Decode(&webhook)
+		case "/my-mods":
+			body, _ := io.ReadAll(r.Body)
+			ntfyTitle, ntfyAuth, ntfyBody = r.Header.Get("Title"), r.Header.Get("Authorization"), string(body)
+		case "/gotify/message":
+			gotifyKey = r.Header.Get("X-Gotify-Key")
+			json.NewDecoder(r.Body).Decode(&gotify)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var mailTo []string
+	var mail string
+	d := NewDispatcher(DispatcherConfig{
+		HTTPClient: server.Client(),
+		SMTP:       SMTPConfig{Host: "smtp.example.org", From: "alerts@example.org"},
+	})
+	d.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if addr != "smtp.example.org:587" || from != "alerts@example.org" {
+			t.Errorf("unexpected server %s or sender %s", addr, from)
+		}
+		mailTo, mail = to, string(msg)
+		return nil
+	}
+
+	w := &Watch{Slug: "abc123", Targets: []Target{
+		{Type: TargetWebhook, URL: server.URL + "/hook"},
+		{Type: TargetNtfy, URL: server.URL + "/my-mods", Token: "tk_secret"},
+		{Type: TargetGotify, URL: server.URL + "/gotify/", Token: "AbC.123"},
+		{Type: TargetEmail, To: []string{"me@example.org"}},
+		{Type: TargetWebhook, URL: server.URL + "/missing"},
+	}}
+
+	deliveries := d.Notify(context.Background(), w, testNotification)
+
+	if len(deliveries) != 5 {
+		t.Fatalf("expected 5 deliveries, got %+v", deliveries)
+	}
+	for i, delivery := range deliveries[:4] {
+		if !delivery.Delivered || delivery.Error != "" {
+			t.Errorf("expected delivery %d to succeed, got %+v", i, delivery)
+		}
+	}
+	if deliveries[4].Delivered || !strings.Contains(deliveries[4].Error, "404") {
+		t.Errorf("expected the missing webhook to fail, got %+v", deliveries[4])
+	}
+
+	if webhook != testNotification {
+		t.Errorf("unexpected webhook payload %+v", webhook)
+	}
+	if ntfyTitle != testNotification.Title || ntfyAuth != "Bearer tk_secret" || ntfyBody != testNotification.Message {
+		t.Errorf("unexpected ntfy request: title %q, auth %q, body %q", ntfyTitle, ntfyAuth, ntfyBody)
+	}
+	if gotifyKey != "AbC.123" || gotify.Title != testNotification.Title || gotify.Message != testNotification.Message {
+		t.Errorf("unexpected Gotify request: key %q, message %+v", gotifyKey, gotify)
+	}
+	if len(mailTo) != 1 || mailTo[0] != "me@example.org" ||
+		!strings.Contains(mail, "Subject: Revision 7 analyzed\r\n") || !strings.Contains(mail, testNotification.URL) {
+		t.Errorf("unexpected email to %v:\n%s", mailTo, mail)
+	}
+}
+
+func TestDispatcher_EmailNotConfigured(t *testing.T) {
+	d := NewDispatcher(DispatcherConfig{})
+
+	if _, err := d.Notifier(Target{Type: TargetEmail, To: []string{"me@example.org"}}); err == nil {
+		t.Error("expected email targets to need an SMTP server")
+	}
+}
+
+func TestDispatcher_RefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected no request to reach the loopback server")
+	}))
+	defer server.Close()
+
+	// The default client only connects to public addresses
+	d := NewDispatcher(DispatcherConfig{})
+	w := &Watch{Slug: "abc123", Targets: []Target{{Type: TargetWebhook, URL: server.URL + "/hook"}}}
+	deliveries := d.Notify(context.Background(), w, testNotification)
+	if len(deliveries) != 1 || deliveries[0].Delivered {
+		t.Errorf("expected the loopback webhook to be refused, got %+v", deliveries)
+	}
+}
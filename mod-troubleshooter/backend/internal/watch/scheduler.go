@@ -0,0 +1,319 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+)
+
+// DefaultInterval is how often the scheduler checks watched collections
+// for new revisions.
+const DefaultInterval = time.Hour
+
+const (
+	// jobKind names the jobs analyzing new revisions.
+	jobKind = "watch"
+	// jobOwner is who the jobs are queued for, so watches get their fair
+	// share of the job queue but no more.
+	jobOwner = "watch-scheduler"
+)
+
+// Revision is a published revision of a collection.
+type Revision struct {
+	Number      int
+	PublishedAt time.Time
+}
+
+// RevisionSource lists the published revisions of collections.
+type RevisionSource interface {
+	Revisions(ctx context.Context, slug string) ([]Revision, error)
+}
+
+// Findings summarizes the analysis of a revision for its notification.
+type Findings struct {
+	// Conflicts is the number of file conflicts found.
+	Conflicts int `json:"conflicts"`
+	// Issues is the number of load order issues found.
+	Issues int `json:"issues"`
+	// HealthScore rates the collection, when its health was checked.
+	HealthScore *int `json:"healthScore,omitempty"`
+	// HistoryURL is where the full analysis is stored, if it is.
+	HistoryURL string `json:"historyUrl,omitempty"`
+}
+
+// AnalyzeFunc analyzes a collection revision with the named analyzers, or
+// all of them when names is empty. It is called from a job.
+type AnalyzeFunc func(ctx context.Context, slug string, revision int, names []string) (*Findings, error)
+
+// SchedulerConfig holds configuration for the Scheduler.
+type SchedulerConfig struct {
+	// Store holds the watched collections.
+	Store *Store
+	// Revisions lists the revisions of watched collections.
+	Revisions RevisionSource
+	// Analyze analyzes new revisions.
+	Analyze AnalyzeFunc
+	// Queue runs the analyses as low priority jobs.
+	Queue *jobs.Queue
+	// Dispatcher notifies the targets of a watch once a revision has been
+	// analyzed.
+	Dispatcher *Dispatcher
+	// Interval is how often collections are checked (default: DefaultInterval).
+	Interval time.Duration
+}
+
+// Scheduler checks watched collections for new revisions, analyzes them
+// through the job queue and notifies the watches' targets of the findings.
+type Scheduler struct {
+	store      *Store
+	revisions  RevisionSource
+	analyze    AnalyzeFunc
+	queue      *jobs.Queue
+	dispatcher *Dispatcher
+	interval   time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	paused bool
+	// pending are the collections with an analysis queued or running
+	pending map[string]bool
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewScheduler creates a scheduler and starts checking collections, once
+// right away and then every interval.
+func NewScheduler(cfg SchedulerConfig) *Scheduler {
+	s := newScheduler(cfg)
+	go s.run()
+	return s
+}
+
+// newScheduler creates a scheduler without starting it.
+func newScheduler(cfg SchedulerConfig) *Scheduler {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:      cfg.Store,
+		revisions:  cfg.Revisions,
+		analyze:    cfg.Analyze,
+		queue:      cfg.Queue,
+		dispatcher: cfg.Dispatcher,
+		interval:   interval,
+		now:        time.Now,
+		pending:    make(map[string]bool),
+		wake:       make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+}
+
+// Pause stops checking collections until Resume. Analyses already queued
+// are left to the job queue.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+}
+
+// Resume checks collections again, starting right away.
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops checking collections.
+func (s *Scheduler) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// run checks collections every interval until the scheduler is closed.
+func (s *Scheduler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.check(s.ctx)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// check queues an analysis for each watched collection with a revision
+// that has not been analyzed yet, until the queue is full. Collections
+// left out are checked again next time.
+func (s *Scheduler) check(ctx context.Context) {
+	watches, err := s.store.List(ctx)
+	if err != nil {
+		log.Printf("Error listing watches: %v", err)
+		return
+	}
+
+	for _, w := range watches {
+		if s.isPaused() || ctx.Err() != nil {
+			return
+		}
+		if !s.claim(w.Slug) {
+			continue
+		}
+
+		revision, ok, err := s.newRevision(ctx, w)
+		if err != nil {
+			log.Printf("Error checking watched collection %s: %v", w.Slug, err)
+		}
+		if !ok {
+			s.release(w.Slug)
+			continue
+		}
+
+		_, err = s.queue.SubmitWith(jobKind, jobs.Options{Priority: jobs.PriorityLow, Owner: jobOwner}, s.analyzeFunc(w, revision))
+		if err != nil {
+			s.release(w.Slug)
+			if !errors.Is(err, jobs.ErrQueueFull) {
+				log.Printf("Error queueing analysis of %s revision %d: %v", w.Slug, revision, err)
+			}
+			return
+		}
+	}
+}
+
+// newRevision returns the newest revision of a watched collection if it
+// has not been analyzed and is recent enough for the watch.
+func (s *Scheduler) newRevision(ctx context.Context, w Watch) (int, bool, error) {
+	revisions, err := s.revisions.Revisions(ctx, w.Slug)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var latest Revision
+	for _, r := range revisions {
+		if r.Number > latest.Number {
+			latest = r
+		}
+	}
+	if latest.Number <= w.LastRevision {
+		return 0, false, nil
+	}
+	if w.MaxRevisionAgeDays > 0 && s.now().Sub(latest.PublishedAt) > time.Duration(w.MaxRevisionAgeDays)*24*time.Hour {
+		return 0, false, nil
+	}
+	return latest.Number, true, nil
+}
+
+// analyzeFunc returns the job analyzing a new revision of a watched
+// collection. Once the revision is analyzed, it is not analyzed again and
+// the watch's targets are notified.
+func (s *Scheduler) analyzeFunc(w Watch, revision int) jobs.Func {
+	return func(ctx context.Context) (interface{}, error) {
+		defer s.release(w.Slug)
+
+		findings, err := s.analyze(ctx, w.Slug, revision, w.Analyses)
+		if err != nil {
+			return nil, fmt.Errorf("analyze %s revision %d: %w", w.Slug, revision, err)
+		}
+		if err := s.store.MarkAnalyzed(ctx, w.Slug, revision); err != nil {
+			log.Printf("Error recording analysis of %s revision %d: %v", w.Slug, revision, err)
+		}
+
+		// The targets may have changed, or the watch been removed, while
+		// the job waited
+		current, err := s.store.Get(ctx, w.Slug)
+		if err != nil {
+			return findings, nil
+		}
+		for _, d := range s.dispatcher.Notify(ctx, current, findings.notification(w.Slug, revision)) {
+			if !d.Delivered {
+				log.Printf("Error notifying %s target of %s: %s", d.Type, w.Slug, d.Error)
+			}
+		}
+		return findings, nil
+	}
+}
+
+// notification describes the findings of an analyzed revision.
+func (f *Findings) notification(slug string, revision int) Notification {
+	var found []string
+	if f.Conflicts > 0 {
+		found = append(found, countOf(f.Conflicts, "file conflict"))
+	}
+	if f.Issues > 0 {
+		found = append(found, countOf(f.Issues, "load order issue"))
+	}
+
+	message := "No file conflicts or load order issues found."
+	if len(found) > 0 {
+		message = "Found " + strings.Join(found, " and ") + "."
+	}
+	if f.HealthScore != nil {
+		message += fmt.Sprintf(" Health score: %d/100.", *f.HealthScore)
+	}
+	if f.HistoryURL != "" {
+		message += "\n\nThe full results are in the analysis history at " + f.HistoryURL + "."
+	}
+
+	return Notification{
+		Slug:     slug,
+		Revision: revision,
+		Title:    fmt.Sprintf("Revision %d of %s analyzed", revision, slug),
+		Message:  message,
+	}
+}
+
+// countOf formats a count of things, such as "1 file conflict" or "2 file
+// conflicts".
+func countOf(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// isPaused reports whether the scheduler is paused.
+func (s *Scheduler) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// claim marks a collection as pending, unless it already is.
+func (s *Scheduler) claim(slug string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending[slug] {
+		return false
+	}
+	s.pending[slug] = true
+	return true
+}
+
+// release clears the pending mark of a collection.
+func (s *Scheduler) release(slug string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, slug)
+}
@@ -0,0 +1,160 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/jobs"
+	"github.com/mod-troubleshooter/backend/internal/netguard"
+)
+
+// fakeRevisions serves fixed revisions per collection.
+type fakeRevisions map[string][]Revision
+
+func (f fakeRevisions) Revisions(ctx context.Context, slug string) ([]Revision, error) {
+	return f[slug], nil
+}
+
+func TestScheduler_AnalyzesNewRevisions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	notes := make(chan Notification, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		json.NewDecoder(r.Body).Decode(&n)
+		notes <- n
+	}))
+	defer server.Close()
+
+	store := newTestStore(t)
+	store.guard, _ = netguard.New([]string{"127.0.0.1"})
+	target := []Target{{Type: TargetWebhook, URL: server.URL + "/hook"}}
+	for _, w := range []Watch{
+		{Slug: "fresh", Analyses: []string{"conflicts"}, MaxRevisionAgeDays: 7, Targets: target},
+		{Slug: "stale", MaxRevisionAgeDays: 7, Targets: target},
+	} {
+		if _, _, err := store.Put(ctx, w); err != nil {
+			t.Fatalf("Put(%s) error = %v", w.Slug, err)
+		}
+	}
+
+	var mu sync.Mutex
+	var analyzed []string
+	score := 80
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+
+	s := newScheduler(SchedulerConfig{
+		Store: store,
+		Revisions: fakeRevisions{
+			"fresh": {{Number: 1, PublishedAt: now.AddDate(0, 0, -30)}, {Number: 2, PublishedAt: now.AddDate(0, 0, -1)}},
+			"stale": {{Number: 5, PublishedAt: now.AddDate(0, 0, -30)}},
+		},
+		Analyze: func(ctx context.Context, slug string, revision int, names []string) (*Findings, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			analyzed = append(analyzed, slug)
+			if slug != "fresh" || revision != 2 || len(names) != 1 || names[0] != "conflicts" {
+				t.Errorf("unexpected analysis of %s revision %d with %v", slug, revision, names)
+			}
+			return &Findings{Conflicts: 3, HealthScore: &score, HistoryURL: "/api/history/42"}, nil
+		},
+		Queue:      queue,
+		Dispatcher: NewDispatcher(DispatcherConfig{HTTPClient: server.Client()}),
+	})
+	s.now = func() time.Time { return now }
+
+	s.check(ctx)
+	select {
+	case n := <-notes:
+		if n.Slug != "fresh" || n.Revision != 2 || !strings.Contains(n.Message, "3 file conflicts") || !strings.Contains(n.Message, "/api/history/42") {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the new revision to be notified")
+	}
+
+	// The revision is recorded once the job finishes
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w, err := store.Get(ctx, "fresh")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if w.LastRevision == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected revision 2 to be recorded, got %d", w.LastRevision)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for {
+		s.mu.Lock()
+		pending := s.pending["fresh"]
+		s.mu.Unlock()
+		if !pending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Nothing new: neither collection is analyzed again
+	s.check(ctx)
+
+	select {
+	case n := <-notes:
+		t.Errorf("unexpected notification %+v", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(analyzed) != 1 {
+		t.Errorf("expected a single analysis, got %v", analyzed)
+	}
+}
+
+func TestScheduler_PausedSkipsChecks(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	if _, _, err := store.Put(ctx, Watch{Slug: "abc123"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	queue := jobs.New(jobs.Config{})
+	defer queue.Close()
+
+	analyzed := make(chan string, 1)
+	s := newScheduler(SchedulerConfig{
+		Store:     store,
+		Revisions: fakeRevisions{"abc123": {{Number: 1, PublishedAt: time.Now()}}},
+		Analyze: func(ctx context.Context, slug string, revision int, names []string) (*Findings, error) {
+			analyzed <- slug
+			return &Findings{}, nil
+		},
+		Queue:      queue,
+		Dispatcher: NewDispatcher(DispatcherConfig{}),
+	})
+
+	s.Pause()
+	s.check(ctx)
+	select {
+	case slug := <-analyzed:
+		t.Fatalf("expected a paused scheduler not to analyze, got %s", slug)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.Resume()
+	s.check(ctx)
+	select {
+	case <-analyzed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a resumed scheduler to analyze")
+	}
+}
@@ -0,0 +1,321 @@
+// Package watch stores the collections monitored for new revisions,
+// checks them for new revisions in the background and notifies the
+// watches' targets once a new revision has been analyzed.
+package watch
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/netguard"
+	_ "modernc.org/sqlite"
+)
+
+// Common errors returned by the store.
+var (
+	ErrNotFound = errors.New("watch not found")
+	ErrInvalid  = errors.New("invalid watch")
+)
+
+// TargetType is the kind of notification target.
+type TargetType string
+
+const (
+	// TargetWebhook posts a JSON notification to a URL.
+	TargetWebhook TargetType = "webhook"
+	// TargetNtfy publishes to an ntfy topic, on ntfy.sh or a self-hosted server.
+	TargetNtfy TargetType = "ntfy"
+	// TargetGotify creates a message on a Gotify server.
+	TargetGotify TargetType = "gotify"
+	// TargetEmail mails the notification through the server's SMTP server.
+	TargetEmail TargetType = "email"
+)
+
+// Config holds configuration for the watch store.
+type Config struct {
+	// DBPath is the path to the SQLite database file.
+	DBPath string
+	// Guard decides which hosts targets may point at (default: public
+	// addresses only).
+	Guard *netguard.Guard
+}
+
+// Target is where notifications about a watched collection are sent.
+type Target struct {
+	// Type is the kind of target.
+	Type TargetType `json:"type"`
+	// URL is the address notifications are sent to: the webhook URL, the
+	// ntfy topic URL or the Gotify server URL. Email targets have none.
+	URL string `json:"url,omitempty"`
+	// Token is the Gotify application token or the ntfy access token, which
+	// ntfy topics only need when they are protected. It is stored but never
+	// returned by the API.
+	Token string `json:"token,omitempty"`
+	// To are the addresses of email targets.
+	To []string `json:"to,omitempty"`
+}
+
+// Validate checks the target options. Errors wrap ErrInvalid.
+func (t *Target) Validate() error {
+	switch t.Type {
+	case TargetWebhook, TargetNtfy, TargetGotify:
+		u, err := url.Parse(t.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: needs an http or https URL", ErrInvalid)
+		}
+		if t.Type == TargetNtfy && strings.Trim(u.Path, "/") == "" {
+			return fmt.Errorf("%w: the ntfy URL needs a topic", ErrInvalid)
+		}
+		if t.Type == TargetGotify && t.Token == "" {
+			return fmt.Errorf("%w: Gotify needs an application token", ErrInvalid)
+		}
+	case TargetEmail:
+		if len(t.To) == 0 {
+			return fmt.Errorf("%w: email needs at least one address", ErrInvalid)
+		}
+		for _, addr := range t.To {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return fmt.Errorf("%w: invalid email address %q", ErrInvalid, addr)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalid, t.Type)
+	}
+	return nil
+}
+
+// Watch is a collection monitored for new revisions.
+type Watch struct {
+	// Slug is the collection slug; a collection has at most one watch.
+	Slug string `json:"slug"`
+	// Analyses lists the analyzers to run on new revisions; empty means all.
+	Analyses []string `json:"analyses"`
+	// Targets receive notifications when a new revision has been analyzed.
+	Targets []Target `json:"targets"`
+	// MaxRevisionAgeDays skips revisions published longer ago than this many
+	// days, so watching an old collection doesn't analyze its backlog. Zero means no limit.
+	MaxRevisionAgeDays int `json:"maxRevisionAgeDays,omitempty"`
+	// LastRevision is the newest revision analyzed for the watch; the
+	// scheduler only analyzes revisions after it. Clients cannot set it.
+	LastRevision int `json:"lastRevision,omitempty"`
+	// CreatedAt is when the watch was added.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the watch options were last changed.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks the watch options. Errors wrap ErrInvalid.
+func (w *Watch) Validate() error {
+	if w.Slug == "" {
+		return fmt.Errorf("%w: slug is required", ErrInvalid)
+	}
+	if w.MaxRevisionAgeDays < 0 {
+		return fmt.Errorf("%w: maxRevisionAgeDays cannot be negative", ErrInvalid)
+	}
+	for i, t := range w.Targets {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("target %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Store provides SQLite-backed storage of watches.
+type Store struct {
+	db    *sql.DB
+	guard *netguard.Guard
+	now   func() time.Time
+}
+
+// New creates a new watch store with the given configuration.
+func New(cfg Config) (*Store, error) {
+	// Ensure the directory exists
+	dir := filepath.Dir(cfg.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create watch directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize schema: %w", err)
+	}
+
+	return &Store{db: db, guard: cfg.Guard, now: time.Now}, nil
+}
+
+// initSchema creates the necessary tables.
+func initSchema(db *sql.DB) error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS watches (
+			slug TEXT PRIMARY KEY,
+			data TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+	`
+	_, err := db.Exec(schema)
+	return err
+}
+
+// Put adds a watch or replaces the options of an existing watch for the same
+// slug. Targets without a token keep the token of the existing target with
+// the same type and URL, since tokens are not sent back to clients. It
+// returns the stored watch and whether it was newly created.
+func (s *Store) Put(ctx context.Context, w Watch) (*Watch, bool, error) {
+	existing, err := s.Get(ctx, w.Slug)
+	created := errors.Is(err, ErrNotFound)
+	if err != nil && !created {
+		return nil, false, err
+	}
+	if !created {
+		w.Targets = keepTokens(w.Targets, existing.Targets)
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, false, err
+	}
+	for i, t := range w.Targets {
+		if t.URL == "" {
+			continue
+		}
+		// Validate has checked the URL parses
+		u, _ := url.Parse(t.URL)
+		if err := s.guard.CheckHost(u.Host); err != nil {
+			return nil, false, fmt.Errorf("target %d: %w: %v", i, ErrInvalid, err)
+		}
+	}
+	if w.Analyses == nil {
+		w.Analyses = []string{}
+	}
+	if w.Targets == nil {
+		w.Targets = []Target{}
+	}
+
+	now := s.now().UTC()
+	w.CreatedAt = now
+	w.UpdatedAt = now
+	w.LastRevision = 0
+	if !created {
+		w.CreatedAt = existing.CreatedAt
+		w.LastRevision = existing.LastRevision
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, false, fmt.Errorf("marshal watch: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO watches (slug, data, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(slug) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at
+	`, w.Slug, string(data), w.CreatedAt.UnixMilli(), w.UpdatedAt.UnixMilli())
+	if err != nil {
+		return nil, false, fmt.Errorf("store watch: %w", err)
+	}
+
+	return &w, created, nil
+}
+
+// keepTokens fills in the tokens targets leave out from the stored targets
+// with the same type and URL.
+func keepTokens(targets, stored []Target) []Target {
+	merged := make([]Target, len(targets))
+	for i, t := range targets {
+		if t.Token == "" {
+			for _, old := range stored {
+				if old.Type == t.Type && old.URL == t.URL {
+					t.Token = old.Token
+					break
+				}
+			}
+		}
+		merged[i] = t
+	}
+	return merged
+}
+
+// List returns all watches, oldest first.
+func (s *Store) List(ctx context.Context) ([]Watch, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT data FROM watches ORDER BY created_at, slug")
+	if err != nil {
+		return nil, fmt.Errorf("query watches: %w", err)
+	}
+	defer rows.Close()
+
+	watches := []Watch{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scan watch: %w", err)
+		}
+		var w Watch
+		if err := json.Unmarshal([]byte(data), &w); err != nil {
+			return nil, fmt.Errorf("unmarshal watch: %w", err)
+		}
+		watches = append(watches, w)
+	}
+
+	return watches, rows.Err()
+}
+
+// Get returns the watch for a collection.
+func (s *Store) Get(ctx context.Context, slug string) (*Watch, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM watches WHERE slug = ?", slug).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query watch: %w", err)
+	}
+
+	var w Watch
+	if err := json.Unmarshal([]byte(data), &w); err != nil {
+		return nil, fmt.Errorf("unmarshal watch: %w", err)
+	}
+	return &w, nil
+}
+
+// MarkAnalyzed records that a revision of a watched collection has been
+// analyzed. Older revisions than the one recorded are ignored.
+func (s *Store) MarkAnalyzed(ctx context.Context, slug string, revision int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE watches SET data = json_set(data, '$.lastRevision', ?)
+		WHERE slug = ? AND COALESCE(json_extract(data, '$.lastRevision'), 0) < ?
+	`, revision, slug, revision)
+	if err != nil {
+		return fmt.Errorf("mark watch analyzed: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the watch for a collection.
+func (s *Store) Delete(ctx context.Context, slug string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM watches WHERE slug = ?", slug)
+	if err != nil {
+		return fmt.Errorf("delete watch: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Close closes the database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
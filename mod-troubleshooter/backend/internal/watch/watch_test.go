@@ -0,0 +1,144 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/netguard"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(Config{DBPath: filepath.Join(t.TempDir(), "watches.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore_PutGetList(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	w, created, err := s.Put(ctx, Watch{
+		Slug:               "abc123",
+		Analyses:           []string{"conflicts"},
+		Targets:            []Target{{Type: TargetWebhook, URL: "https://example.com/hook"}},
+		MaxRevisionAgeDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if !created || !w.CreatedAt.Equal(now) {
+		t.Errorf("expected new watch created at %v, got created=%v %v", now, created, w.CreatedAt)
+	}
+
+	// Putting the same slug again replaces its options but keeps CreatedAt
+	later := now.Add(time.Hour)
+	s.now = func() time.Time { return later }
+	w, created, err = s.Put(ctx, Watch{Slug: "abc123", Analyses: []string{"loadorder"}})
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if created {
+		t.Error("expected existing watch to be updated")
+	}
+	if !w.CreatedAt.Equal(now) || !w.UpdatedAt.Equal(later) {
+		t.Errorf("expected createdAt %v and updatedAt %v, got %v and %v", now, later, w.CreatedAt, w.UpdatedAt)
+	}
+
+	got, err := s.Get(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Analyses) != 1 || got.Analyses[0] != "loadorder" || len(got.Targets) != 0 || got.MaxRevisionAgeDays != 0 {
+		t.Errorf("expected replaced options, got %+v", got)
+	}
+
+	s.Put(ctx, Watch{Slug: "def456"})
+	list, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 2 || list[0].Slug != "abc123" {
+		t.Errorf("expected 2 watches oldest first, got %+v", list)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	s.Put(ctx, Watch{Slug: "abc123"})
+	if err := s.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get(ctx, "abc123"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := s.Delete(ctx, "abc123"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a missing watch, got %v", err)
+	}
+}
+
+func TestStore_PutRejectsInternalTargets(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, url := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://169.254.169.254/latest", "https://[fd00::1]/topic", "http://10.0.0.2/message"} {
+		target := Target{Type: TargetWebhook, URL: url}
+		if _, _, err := s.Put(ctx, Watch{Slug: "abc123", Targets: []Target{target}}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Put(%s) error = %v, want ErrInvalid", url, err)
+		}
+	}
+
+	// Networks the operator allows are accepted
+	guard, err := netguard.New([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("netguard.New() error = %v", err)
+	}
+	s.guard = guard
+	if _, _, err := s.Put(ctx, Watch{Slug: "abc123", Targets: []Target{{Type: TargetWebhook, URL: "http://10.0.0.2/message"}}}); err != nil {
+		t.Errorf("expected an allowed network to be accepted, got %v", err)
+	}
+}
+
+func TestWatch_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		watch   Watch
+		wantErr bool
+	}{
+		{"valid", Watch{Slug: "abc", Targets: []Target{{Type: TargetWebhook, URL: "http://localhost:9000/hook"}}}, false},
+		{"missing slug", Watch{}, true},
+		{"negative age", Watch{Slug: "abc", MaxRevisionAgeDays: -1}, true},
+		{"unknown target", Watch{Slug: "abc", Targets: []Target{{Type: "carrier-pigeon", URL: "https://example.com"}}}, true},
+		{"bad url", Watch{Slug: "abc", Targets: []Target{{Type: TargetWebhook, URL: "ftp://example.com"}}}, true},
+		{"ntfy", Watch{Slug: "abc", Targets: []Target{{Type: TargetNtfy, URL: "https://ntfy.sh/my-mods"}}}, false},
+		{"ntfy without topic", Watch{Slug: "abc", Targets: []Target{{Type: TargetNtfy, URL: "https://ntfy.sh/"}}}, true},
+		{"gotify", Watch{Slug: "abc", Targets: []Target{{Type: TargetGotify, URL: "https://gotify.lan", Token: "AbC.123"}}}, false},
+		{"gotify without token", Watch{Slug: "abc", Targets: []Target{{Type: TargetGotify, URL: "https://gotify.lan"}}}, true},
+		{"email", Watch{Slug: "abc", Targets: []Target{{Type: TargetEmail, To: []string{"Me <me@example.org>"}}}}, false},
+		{"email without address", Watch{Slug: "abc", Targets: []Target{{Type: TargetEmail}}}, true},
+		{"bad email", Watch{Slug: "abc", Targets: []Target{{Type: TargetEmail, To: []string{"not an address"}}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.watch.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
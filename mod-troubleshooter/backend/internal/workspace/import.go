@@ -0,0 +1,191 @@
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParsePluginsTxt reads a plugins.txt load order. Newer games mark active
+// plugins with '*' and list inactive ones without it; older games list
+// only active plugins, so without any '*' every plugin is enabled.
+func ParsePluginsTxt(r io.Reader) ([]Plugin, error) {
+	var plugins []Plugin
+	starred := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		enabled := strings.HasPrefix(line, "*")
+		if enabled {
+			starred = true
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		}
+		plugins = append(plugins, Plugin{Filename: line, Enabled: enabled})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: read plugins.txt: %v", ErrInvalid, err)
+	}
+
+	if !starred {
+		for i := range plugins {
+			plugins[i].Enabled = true
+		}
+	}
+	return plugins, nil
+}
+
+// mo2Separator is the suffix Mod Organizer gives separators, which are
+// listed like mods but hold no files.
+const mo2Separator = "_separator"
+
+// ParseModlist reads a Mod Organizer 2 modlist.txt. Enabled mods start with
+// '+', disabled ones with '-'; entries starting with '*' are files MO2 does
+// not manage, such as DLC, and are skipped with separators. MO2 lists the
+// highest priority first, so the result is reversed into install order.
+func ParseModlist(r io.Reader) ([]Mod, error) {
+	var mods []Mod
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var enabled bool
+		switch line[0] {
+		case '+':
+			enabled = true
+		case '-':
+		case '*':
+			continue
+		default:
+			return nil, fmt.Errorf("%w: unexpected modlist.txt line %q", ErrInvalid, line)
+		}
+		name := strings.TrimSpace(line[1:])
+		if name == "" || strings.HasSuffix(name, mo2Separator) {
+			continue
+		}
+		mods = append(mods, Mod{Name: name, Enabled: enabled})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: read modlist.txt: %v", ErrInvalid, err)
+	}
+
+	for i, j := 0, len(mods)-1; i < j; i, j = i+1, j-1 {
+		mods[i], mods[j] = mods[j], mods[i]
+	}
+	return mods, nil
+}
+
+// vortexState is the part of a Vortex state backup that lists mods.
+type vortexState struct {
+	Persistent struct {
+		// Mods maps Vortex game IDs to installed mods by mod ID.
+		Mods map[string]map[string]vortexMod `json:"mods"`
+		// Profiles maps profile IDs to the mods they enable.
+		Profiles map[string]vortexProfile `json:"profiles"`
+	} `json:"persistent"`
+}
+
+type vortexMod struct {
+	State      string                     `json:"state"`
+	Attributes map[string]json.RawMessage `json:"attributes"`
+}
+
+type vortexProfile struct {
+	GameID   string `json:"gameId"`
+	ModState map[string]struct {
+		Enabled bool `json:"enabled"`
+	} `json:"modState"`
+}
+
+// ParseVortex reads the installed mods of one game from a Vortex state
+// backup. gameID is Vortex's game ID, such as "skyrimse"; it may be empty
+// when the backup holds a single game. A mod is enabled if any profile of
+// the game enables it. Vortex orders mods by rules rather than a list, so
+// mods are returned by name.
+func ParseVortex(data []byte, gameID string) ([]Mod, error) {
+	var state vortexState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: parse Vortex state: %v", ErrInvalid, err)
+	}
+
+	games := state.Persistent.Mods
+	if gameID == "" {
+		if len(games) != 1 {
+			return nil, fmt.Errorf("%w: Vortex state holds %d games, choose one", ErrInvalid, len(games))
+		}
+		for id := range games {
+			gameID = id
+		}
+	}
+	installed, ok := games[gameID]
+	if !ok {
+		return nil, fmt.Errorf("%w: Vortex state has no mods for %s", ErrInvalid, gameID)
+	}
+
+	enabled := make(map[string]bool)
+	profiles := false
+	for _, p := range state.Persistent.Profiles {
+		if p.GameID != gameID {
+			continue
+		}
+		profiles = true
+		for id, s := range p.ModState {
+			enabled[id] = enabled[id] || s.Enabled
+		}
+	}
+
+	var mods []Mod
+	for id, m := range installed {
+		if m.State != "" && m.State != "installed" {
+			continue
+		}
+		mod := Mod{
+			Name:       vortexString(m.Attributes, "customFileName", "modName", "name", "logicalFileName"),
+			Enabled:    !profiles || enabled[id],
+			NexusModID: vortexInt(m.Attributes["modId"]),
+			FileID:     vortexInt(m.Attributes["fileId"]),
+			Version:    vortexString(m.Attributes, "version"),
+		}
+		if mod.Name == "" {
+			mod.Name = id
+		}
+		mods = append(mods, mod)
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		return strings.ToLower(mods[i].Name) < strings.ToLower(mods[j].Name)
+	})
+	return mods, nil
+}
+
+// vortexString returns the first non-empty string attribute of keys.
+func vortexString(attrs map[string]json.RawMessage, keys ...string) string {
+	for _, key := range keys {
+		var s string
+		if json.Unmarshal(attrs[key], &s) == nil && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// vortexInt reads an ID attribute, which Vortex stores as a number or a
+// string depending on where the mod came from.
+func vortexInt(raw json.RawMessage) int {
+	var n int
+	if json.Unmarshal(raw, &n) == nil {
+		return n
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		n, _ = strconv.Atoi(s)
+	}
+	return n
+}
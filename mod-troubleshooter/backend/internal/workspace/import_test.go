@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePluginsTxt(t *testing.T) {
+	plugins, err := ParsePluginsTxt(strings.NewReader("# This file is used by the game\n*Unofficial Skyrim Special Edition Patch.esp\nOldMod.esp\n\n*SkyUI_SE.esp\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Plugin{
+		{Filename: "Unofficial Skyrim Special Edition Patch.esp", Enabled: true},
+		{Filename: "OldMod.esp", Enabled: false},
+		{Filename: "SkyUI_SE.esp", Enabled: true},
+	}
+	if len(plugins) != len(want) {
+		t.Fatalf("expected %d plugins, got %+v", len(want), plugins)
+	}
+	for i := range want {
+		if plugins[i] != want[i] {
+			t.Errorf("plugin %d: expected %+v, got %+v", i, want[i], plugins[i])
+		}
+	}
+
+	// Older games list only active plugins, without markers
+	plugins, err = ParsePluginsTxt(strings.NewReader("Update.esm\nSkyUI.esp\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plugins) != 2 || !plugins[0].Enabled || !plugins[1].Enabled {
+		t.Errorf("expected all plugins enabled, got %+v", plugins)
+	}
+}
+
+func TestParseModlist(t *testing.T) {
+	mods, err := ParseModlist(strings.NewReader("# This file was automatically generated by Mod Organizer.\n+Patches\n-Disabled Mod\n+UI_separator\n+SkyUI\n*DLC: Dawnguard\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Mod{
+		{Name: "SkyUI", Enabled: true},
+		{Name: "Disabled Mod", Enabled: false},
+		{Name: "Patches", Enabled: true},
+	}
+	if len(mods) != len(want) {
+		t.Fatalf("expected %d mods, got %+v", len(want), mods)
+	}
+	for i := range want {
+		if mods[i].Name != want[i].Name || mods[i].Enabled != want[i].Enabled {
+			t.Errorf("mod %d: expected %+v, got %+v", i, want[i], mods[i])
+		}
+	}
+
+	if _, err := ParseModlist(strings.NewReader("SkyUI\n")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a line without a marker, got %v", err)
+	}
+}
+
+func TestParseVortex(t *testing.T) {
+	state := []byte(`{
+		"persistent": {
+			"mods": {
+				"skyrimse": {
+					"SkyUI-12604-5-2SE": {"state": "installed", "attributes": {"modName": "SkyUI", "modId": 12604, "fileId": "35407", "version": "5.2SE"}},
+					"manual-mod": {"state": "installed", "attributes": {"name": "Manual Mod"}},
+					"half-done": {"state": "installing", "attributes": {"name": "Half Done"}}
+				}
+			},
+			"profiles": {
+				"p1": {"gameId": "skyrimse", "modState": {"SkyUI-12604-5-2SE": {"enabled": true}}},
+				"p2": {"gameId": "fallout4", "modState": {"manual-mod": {"enabled": true}}}
+			}
+		}
+	}`)
+
+	mods, err := ParseVortex(state, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mods) != 2 {
+		t.Fatalf("expected 2 installed mods, got %+v", mods)
+	}
+	if m := mods[0]; m.Name != "Manual Mod" || m.Enabled {
+		t.Errorf("expected disabled manual mod first, got %+v", m)
+	}
+	if m := mods[1]; m.Name != "SkyUI" || !m.Enabled || m.NexusModID != 12604 || m.FileID != 35407 || m.Version != "5.2SE" {
+		t.Errorf("unexpected SkyUI entry: %+v", m)
+	}
+
+	if _, err := ParseVortex(state, "fallout4"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a game without mods, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+// AddPreview is the impact adding a mod would have on a workspace.
+type AddPreview struct {
+	// ModID and ModName identify the previewed mod.
+	ModID   string `json:"modId"`
+	ModName string `json:"modName"`
+	// Replaces names the installed mod the previewed one would replace,
+	// such as an older version of it.
+	Replaces string `json:"replaces,omitempty"`
+	// Conflicts are the file conflicts the mod would introduce. It is
+	// installed last, so it wins all of them.
+	Conflicts []conflict.Conflict `json:"conflicts"`
+	// Plugins are the plugins the mod adds to the end of the load order.
+	Plugins []string `json:"plugins"`
+	// LoadOrderIssues are problems with the mod's plugins in the
+	// workspace's load order, such as masters the workspace doesn't have.
+	LoadOrderIssues []loadorder.Issue `json:"loadOrderIssues"`
+	// Fomod is the mod's FOMOD installer, whose steps are the choices the
+	// user would be asked to make, if it has one.
+	Fomod *fomod.FomodData `json:"fomod,omitempty"`
+	// Unlisted names enabled mods whose files are not known, so conflicts
+	// with them could not be checked.
+	Unlisted []string `json:"unlisted,omitempty"`
+}
+
+// Sources lists the enabled mods of the workspace that can be looked up on
+// Nexus and are missing some of the needed inputs, so they can be gathered.
+func (w *Workspace) Sources(need pipeline.Input) []pipeline.Source {
+	var sources []pipeline.Source
+	for i, m := range w.Mods {
+		if !m.Enabled || m.NexusModID <= 0 || m.FileID <= 0 {
+			continue
+		}
+		missing := need.Has(pipeline.InputManifests) && m.Manifest == nil ||
+			need.Has(pipeline.InputPluginHeaders) && !m.pluginsKnown()
+		if !missing {
+			continue
+		}
+		sources = append(sources, pipeline.Source{
+			ModID:      m.Key(),
+			ModName:    m.Name,
+			LoadOrder:  i,
+			Game:       w.Game,
+			NexusModID: m.NexusModID,
+			FileID:     m.FileID,
+			Version:    m.Version,
+		})
+	}
+	return sources
+}
+
+// pluginsKnown reports whether the mod's plugins are known: they were
+// gathered, or its file listing has none.
+func (m Mod) pluginsKnown() bool {
+	if m.Plugins != nil {
+		return true
+	}
+	if m.Manifest == nil {
+		return false
+	}
+	for _, f := range m.Manifest.Files {
+		if plugin.IsPluginFile(f.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddGathered fills in the inputs gathered for the workspace's mods, as
+// listed by Sources, and reports whether anything was added.
+func (w *Workspace) AddGathered(in *pipeline.Inputs, need pipeline.Input) bool {
+	gathered := make(map[string]pipeline.Mod, len(in.Mods))
+	for _, mod := range in.Mods {
+		gathered[mod.ModID] = mod
+	}
+
+	added := false
+	for i, m := range w.Mods {
+		mod, ok := gathered[m.Key()]
+		if !ok {
+			continue
+		}
+		if m.Manifest == nil && mod.Manifest != nil {
+			w.Mods[i].Manifest = mod.Manifest
+			added = true
+		}
+		// Plugins are only known when every one of them could be read
+		if m.Plugins == nil && need.Has(pipeline.InputPluginHeaders) && mod.Error == "" {
+			plugins := make([]ModPlugin, 0, len(mod.Plugins))
+			for _, pf := range mod.Plugins {
+				mp := ModPlugin{Filename: pf.Filename, Masters: []string{}}
+				if pf.Header != nil {
+					for _, master := range pf.Header.Masters {
+						mp.Masters = append(mp.Masters, master.Filename)
+					}
+				}
+				plugins = append(plugins, mp)
+			}
+			w.Mods[i].Plugins = plugins
+			added = true
+		}
+	}
+	return added
+}
+
+// Find returns the mod with the given Nexus mod ID or, without one, the
+// given name, ignoring case.
+func (w *Workspace) Find(name string, nexusModID int) (Mod, bool) {
+	for _, m := range w.Mods {
+		if nexusModID > 0 && m.NexusModID == nexusModID ||
+			nexusModID <= 0 && strings.EqualFold(m.Name, strings.TrimSpace(name)) {
+			return m, true
+		}
+	}
+	return Mod{}, false
+}
+
+// Inputs converts the enabled mods of the workspace into pipeline inputs,
+// with the file listings and plugins known for them. Mod IDs are the mods' keys.
+func (w *Workspace) Inputs() *pipeline.Inputs {
+	in := &pipeline.Inputs{Game: w.Game}
+	for i, m := range w.Mods {
+		if !m.Enabled {
+			continue
+		}
+		mod := pipeline.Mod{
+			ModID:      m.Key(),
+			ModName:    m.Name,
+			LoadOrder:  i,
+			NexusModID: m.NexusModID,
+			FileID:     m.FileID,
+			Version:    m.Version,
+			Manifest:   m.Manifest,
+		}
+		for _, p := range m.Plugins {
+			header := &plugin.PluginHeader{Filename: p.Filename}
+			for _, master := range p.Masters {
+				header.Masters = append(header.Masters, plugin.Master{Filename: master})
+			}
+			mod.Plugins = append(mod.Plugins, loadorder.PluginFile{Filename: p.Filename, Header: header})
+		}
+		in.Mods = append(in.Mods, mod)
+	}
+	return in
+}
+
+// PreviewAdd works out what adding mod, gathered with its manifest and
+// plugin headers, would change in the workspace. The mod is installed last,
+// overwriting the workspace's mods, and its plugins are added to the end of
+// the load order. An installed mod with the same Nexus mod ID is replaced.
+func PreviewAdd(ctx context.Context, w *Workspace, mod pipeline.Mod) (*AddPreview, error) {
+	preview := &AddPreview{
+		ModID:           mod.ModID,
+		ModName:         mod.ModName,
+		Conflicts:       []conflict.Conflict{},
+		Plugins:         []string{},
+		LoadOrderIssues: []loadorder.Issue{},
+	}
+
+	in := &pipeline.Inputs{Game: w.Game}
+	for _, m := range w.Inputs().Mods {
+		switch {
+		case mod.NexusModID > 0 && m.NexusModID == mod.NexusModID:
+			preview.Replaces = m.ModName
+		case m.Manifest == nil:
+			preview.Unlisted = append(preview.Unlisted, m.ModName)
+		default:
+			in.Mods = append(in.Mods, m)
+		}
+	}
+
+	if mod.Manifest != nil {
+		mod.LoadOrder = len(w.Mods)
+		in.Mods = append(in.Mods, mod)
+		conflicts, err := pipeline.NewConflictStage().AnalyzeConflicts(ctx, in)
+		if err != nil {
+			return nil, fmt.Errorf("analyze conflicts: %w", err)
+		}
+		for _, c := range conflicts.Conflicts {
+			if involves(c, mod.ModID) {
+				preview.Conflicts = append(preview.Conflicts, c)
+			}
+		}
+	}
+
+	plugins, added := w.loadOrderWith(mod.Plugins)
+	for _, pf := range added {
+		preview.Plugins = append(preview.Plugins, pf.Filename)
+	}
+	if len(added) > 0 {
+		result, err := loadorder.NewAnalyzer().AnalyzeGame(ctx, w.Game, plugins)
+		if err != nil {
+			return nil, fmt.Errorf("analyze load order: %w", err)
+		}
+		for _, issue := range result.Issues {
+			if issue.Index >= len(plugins)-len(added) {
+				preview.LoadOrderIssues = append(preview.LoadOrderIssues, issue)
+			}
+		}
+	}
+
+	return preview, nil
+}
+
+// loadOrderWith returns the workspace's active plugins with the given
+// plugins added to the end, along with the ones that were not already
+// active. Only the added plugins have headers.
+func (w *Workspace) loadOrderWith(extra []loadorder.PluginFile) (plugins, added []loadorder.PluginFile) {
+	active := make(map[string]bool)
+	for _, p := range w.Plugins {
+		if p.Enabled {
+			active[strings.ToLower(p.Filename)] = true
+			plugins = append(plugins, loadorder.PluginFile{Filename: p.Filename})
+		}
+	}
+	for _, pf := range extra {
+		if active[strings.ToLower(pf.Filename)] {
+			continue
+		}
+		active[strings.ToLower(pf.Filename)] = true
+		added = append(added, pf)
+	}
+	return append(plugins, added...), added
+}
+
+// involves reports whether a mod is one of the sources of a conflict.
+func involves(c conflict.Conflict, modID string) bool {
+	for _, src := range c.Sources {
+		if src.ModID == modID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	"github.com/mod-troubleshooter/backend/internal/pipeline"
+	"github.com/mod-troubleshooter/backend/internal/plugin"
+)
+
+func listing(paths ...string) *manifest.Manifest {
+	entries := make([]manifest.FileEntry, 0, len(paths))
+	for _, p := range paths {
+		entries = append(entries, manifest.NewFileEntry(p, 100))
+	}
+	return manifest.NewManifest(entries)
+}
+
+func withMasters(filename string, masters ...string) loadorder.PluginFile {
+	header := &plugin.PluginHeader{Filename: filename}
+	for _, m := range masters {
+		header.Masters = append(header.Masters, plugin.Master{Filename: m})
+	}
+	return loadorder.PluginFile{Filename: filename, Header: header}
+}
+
+func TestPreviewAdd(t *testing.T) {
+	w := &Workspace{
+		Game: "skyrimspecialedition",
+		Mods: []Mod{
+			{Name: "Textures", Enabled: true, NexusModID: 1, FileID: 10, Manifest: listing("textures/rock.dds", "textures/tree.dds")},
+			{Name: "Old Version", Enabled: true, NexusModID: 5, FileID: 50, Manifest: listing("meshes/rock.nif")},
+			{Name: "Disabled", Enabled: false, Manifest: listing("meshes/rock.nif")},
+			{Name: "Manual Mod", Enabled: true},
+		},
+		Plugins: []Plugin{
+			{Filename: "Skyrim.esm", Enabled: true},
+			{Filename: "Unloaded.esp", Enabled: false},
+		},
+	}
+	candidate := pipeline.Mod{
+		ModID:      "5-51",
+		ModName:    "New Version",
+		NexusModID: 5,
+		FileID:     51,
+		Manifest:   listing("textures/rock.dds", "meshes/rock.nif", "NewVersion.esp"),
+		Plugins:    []loadorder.PluginFile{withMasters("NewVersion.esp", "Skyrim.esm", "Unloaded.esp")},
+	}
+
+	preview, err := PreviewAdd(context.Background(), w, candidate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if preview.Replaces != "Old Version" {
+		t.Errorf("expected the installed version to be replaced, got %q", preview.Replaces)
+	}
+	if len(preview.Conflicts) != 1 || preview.Conflicts[0].Path != "textures/rock.dds" {
+		t.Errorf("expected a single conflict over textures/rock.dds, got %+v", preview.Conflicts)
+	} else if preview.Conflicts[0].Winner.ModID != "5-51" {
+		t.Errorf("expected the previewed mod to win, got %+v", preview.Conflicts[0].Winner)
+	}
+	if len(preview.Unlisted) != 1 || preview.Unlisted[0] != "Manual Mod" {
+		t.Errorf("expected Manual Mod to be unlisted, got %v", preview.Unlisted)
+	}
+	if len(preview.Plugins) != 1 || preview.Plugins[0] != "NewVersion.esp" {
+		t.Errorf("expected NewVersion.esp to be added, got %v", preview.Plugins)
+	}
+	if len(preview.LoadOrderIssues) != 1 || preview.LoadOrderIssues[0].RelatedPlugin != "Unloaded.esp" {
+		t.Errorf("expected the inactive master to be missing, got %+v", preview.LoadOrderIssues)
+	}
+}
+
+func TestWorkspace_SourcesAndGathered(t *testing.T) {
+	w := &Workspace{
+		Game: "skyrimspecialedition",
+		Mods: []Mod{
+			{Name: "Listed", Enabled: true, NexusModID: 1, FileID: 10, Manifest: listing("a.esp")},
+			{Name: "Nexus", Enabled: true, NexusModID: 2, FileID: 20},
+			{Name: "Disabled", NexusModID: 3, FileID: 30},
+			{Name: "Manual", Enabled: true},
+		},
+	}
+
+	sources := w.Sources(pipeline.InputManifests)
+	if len(sources) != 1 || sources[0].NexusModID != 2 || sources[0].LoadOrder != 1 {
+		t.Fatalf("expected only the unlisted Nexus mod, got %+v", sources)
+	}
+
+	in := &pipeline.Inputs{Mods: []pipeline.Mod{{ModID: sources[0].ModID, Manifest: listing("b.esp")}}}
+	if !w.AddGathered(in, pipeline.InputManifests) {
+		t.Fatal("expected a manifest to be added")
+	}
+	if w.Mods[1].Manifest == nil || w.Mods[1].Manifest.TotalCount != 1 || w.Mods[1].Plugins != nil {
+		t.Errorf("expected Nexus mod to be listed without plugins, got %+v", w.Mods[1])
+	}
+	if w.AddGathered(in, pipeline.InputManifests) {
+		t.Error("expected nothing new the second time")
+	}
+
+	// Both listed mods ship plugins whose masters are not known yet
+	sources = w.Sources(pipeline.InputPluginHeaders)
+	if len(sources) != 2 {
+		t.Fatalf("expected both Nexus mods to need plugins, got %+v", sources)
+	}
+	in = &pipeline.Inputs{Mods: []pipeline.Mod{{ModID: sources[1].ModID, Plugins: []loadorder.PluginFile{withMasters("b.esp", "a.esp")}}}}
+	if !w.AddGathered(in, pipeline.InputPluginHeaders) {
+		t.Fatal("expected plugins to be added")
+	}
+
+	mods := w.Inputs().Mods
+	if len(mods) != 3 {
+		t.Fatalf("expected the enabled mods, got %+v", mods)
+	}
+	if p := mods[1].Plugins; len(p) != 1 || p[0].Header == nil || p[0].Header.Masters[0].Filename != "a.esp" {
+		t.Errorf("expected b.esp with its master, got %+v", p)
+	}
+}
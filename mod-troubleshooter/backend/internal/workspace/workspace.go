@@ -0,0 +1,330 @@
+// Package workspace stores users' own mod setups, imported once from their
+// mod manager, so later questions about adding or removing a mod are
+// answered against the stored setup without uploading it again.
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+	_ "modernc.org/sqlite"
+)
+
+// Common errors returned by the store.
+var (
+	ErrNotFound = errors.New("workspace not found")
+	ErrInvalid  = errors.New("invalid workspace")
+)
+
+// Config holds configuration for the workspace store.
+type Config struct {
+	// DBPath is the path to the SQLite database file.
+	DBPath string
+}
+
+// Mod is a mod installed in a workspace.
+type Mod struct {
+	// Name is the mod's name in the mod manager.
+	Name string `json:"name"`
+	// Enabled is false for mods installed but switched off.
+	Enabled bool `json:"enabled"`
+	// NexusModID and FileID identify the installed file on Nexus; zero if unknown.
+	NexusModID int `json:"nexusModId,omitempty"`
+	FileID     int `json:"fileId,omitempty"`
+	// Version is the installed version, if known.
+	Version string `json:"version,omitempty"`
+	// Manifest lists the mod's files once they are known, so they are read
+	// from Nexus at most once.
+	Manifest *manifest.Manifest `json:"manifest,omitempty"`
+	// Plugins lists the mod's plugins and their masters once they are known.
+	Plugins []ModPlugin `json:"plugins,omitempty"`
+}
+
+// ModPlugin is a plugin provided by a workspace mod.
+type ModPlugin struct {
+	Filename string `json:"filename"`
+	// Masters are the plugins it depends on.
+	Masters []string `json:"masters"`
+}
+
+// Key identifies the installed file of a mod across imports.
+func (m Mod) Key() string {
+	if m.FileID > 0 {
+		return fmt.Sprintf("nexus:%d:%d", m.NexusModID, m.FileID)
+	}
+	return "name:" + strings.ToLower(m.Name) + ":" + m.Version
+}
+
+// Plugin is a plugin in a workspace's load order.
+type Plugin struct {
+	Filename string `json:"filename"`
+	// Enabled is false for plugins listed but not loaded.
+	Enabled bool `json:"enabled"`
+}
+
+// Workspace is a user's own mod setup.
+type Workspace struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Game is the Nexus game domain of the setup.
+	Game string `json:"game"`
+	// Mods are the installed mods in install order, lowest priority first.
+	Mods []Mod `json:"mods"`
+	// Plugins are the plugins in load order.
+	Plugins []Plugin `json:"plugins"`
+	// CreatedAt is when the workspace was imported.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is when the workspace was last changed.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks the workspace. Errors wrap ErrInvalid.
+func (w *Workspace) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if w.Game == "" {
+		return fmt.Errorf("%w: game is required", ErrInvalid)
+	}
+	if len(w.Mods) == 0 && len(w.Plugins) == 0 {
+		return fmt.Errorf("%w: at least one mod or plugin is required", ErrInvalid)
+	}
+	for i, m := range w.Mods {
+		if strings.TrimSpace(m.Name) == "" {
+			return fmt.Errorf("%w: mod %d has no name", ErrInvalid, i)
+		}
+	}
+	for i, p := range w.Plugins {
+		if strings.TrimSpace(p.Filename) == "" {
+			return fmt.Errorf("%w: plugin %d has no filename", ErrInvalid, i)
+		}
+	}
+	return nil
+}
+
+// Store provides SQLite-backed storage of workspaces.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// New creates a new workspace store with the given configuration.
+func New(cfg Config) (*Store, error) {
+	// Ensure the directory exists
+	dir := filepath.Dir(cfg.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create workspace directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize schema: %w", err)
+	}
+
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// initSchema creates the necessary tables.
+func initSchema(db *sql.DB) error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS workspaces (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			data TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+	`
+	_, err := db.Exec(schema)
+	return err
+}
+
+// Create stores a new workspace and returns it with its ID.
+func (s *Store) Create(ctx context.Context, w Workspace) (*Workspace, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	normalize(&w)
+
+	now := s.now().UTC()
+	w.CreatedAt = now
+	w.UpdatedAt = now
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO workspaces (data, created_at, updated_at) VALUES ('{}', ?, ?)
+	`, w.CreatedAt.UnixMilli(), w.UpdatedAt.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("store workspace: %w", err)
+	}
+	if w.ID, err = res.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("store workspace: %w", err)
+	}
+
+	if err := s.write(ctx, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Update replaces a stored workspace, keeping its creation time. Mods that
+// were already in the workspace keep their file listings and plugins unless
+// new ones are given.
+func (s *Store) Update(ctx context.Context, w Workspace) (*Workspace, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	normalize(&w)
+
+	existing, err := s.Get(ctx, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	w.CreatedAt = existing.CreatedAt
+	w.UpdatedAt = s.now().UTC()
+
+	keepGathered(w.Mods, existing.Mods)
+
+	if err := s.write(ctx, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SaveGathered stores the file listings and plugins of a workspace's mods,
+// leaving the rest of the stored workspace as it is, so each mod is looked
+// up at most once.
+func (s *Store) SaveGathered(ctx context.Context, w *Workspace) error {
+	existing, err := s.Get(ctx, w.ID)
+	if err != nil {
+		return err
+	}
+	keepGathered(existing.Mods, w.Mods)
+	return s.write(ctx, existing)
+}
+
+// write stores the data of an existing workspace row.
+func (s *Store) write(ctx context.Context, w *Workspace) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("marshal workspace: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE workspaces SET data = ?, updated_at = ? WHERE id = ?
+	`, string(data), w.UpdatedAt.UnixMilli(), w.ID)
+	if err != nil {
+		return fmt.Errorf("store workspace: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns all workspaces, oldest first.
+func (s *Store) List(ctx context.Context) ([]Workspace, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT data FROM workspaces ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := []Workspace{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scan workspace: %w", err)
+		}
+		var w Workspace
+		if err := json.Unmarshal([]byte(data), &w); err != nil {
+			return nil, fmt.Errorf("unmarshal workspace: %w", err)
+		}
+		workspaces = append(workspaces, w)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// Get returns a workspace by ID.
+func (s *Store) Get(ctx context.Context, id int64) (*Workspace, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM workspaces WHERE id = ?", id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query workspace: %w", err)
+	}
+
+	var w Workspace
+	if err := json.Unmarshal([]byte(data), &w); err != nil {
+		return nil, fmt.Errorf("unmarshal workspace: %w", err)
+	}
+	return &w, nil
+}
+
+// Delete removes a workspace.
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM workspaces WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete workspace: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Close closes the database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// normalize trims names and replaces nil lists so they encode as [].
+func normalize(w *Workspace) {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Mods == nil {
+		w.Mods = []Mod{}
+	}
+	if w.Plugins == nil {
+		w.Plugins = []Plugin{}
+	}
+	for i := range w.Mods {
+		w.Mods[i].Name = strings.TrimSpace(w.Mods[i].Name)
+	}
+	for i := range w.Plugins {
+		w.Plugins[i].Filename = strings.TrimSpace(w.Plugins[i].Filename)
+	}
+}
+
+// keepGathered fills in missing file listings and plugins of mods from the
+// known mods with the same key.
+func keepGathered(mods, known []Mod) {
+	byKey := make(map[string]Mod, len(known))
+	for _, m := range known {
+		byKey[m.Key()] = m
+	}
+	for i, m := range mods {
+		k, ok := byKey[m.Key()]
+		if !ok {
+			continue
+		}
+		if m.Manifest == nil {
+			mods[i].Manifest = k.Manifest
+		}
+		if m.Plugins == nil {
+			mods[i].Plugins = k.Plugins
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mod-troubleshooter/backend/internal/manifest"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(Config{DBPath: filepath.Join(t.TempDir(), "workspaces.db")})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore_CreateGetUpdate(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	listing := manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("SkyUI_SE.esp", 10)})
+	w, err := s.Create(ctx, Workspace{
+		Name: " My setup ",
+		Game: "skyrimspecialedition",
+		Mods: []Mod{
+			{Name: "SkyUI", Enabled: true, NexusModID: 12604, FileID: 1, Manifest: listing},
+			{Name: "Manual Mod", Enabled: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if w.ID == 0 || w.Name != "My setup" || w.Plugins == nil {
+		t.Errorf("unexpected workspace: %+v", w)
+	}
+
+	got, err := s.Get(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Mods) != 2 || got.Mods[0].Manifest == nil || got.Mods[0].Manifest.TotalCount != 1 {
+		t.Errorf("expected mods with their listings, got %+v", got.Mods)
+	}
+
+	// A fresh import keeps listings of mods that are still installed
+	later := now.Add(time.Hour)
+	s.now = func() time.Time { return later }
+	updated, err := s.Update(ctx, Workspace{
+		ID:      w.ID,
+		Name:    "My setup",
+		Game:    "skyrimspecialedition",
+		Mods:    []Mod{{Name: "SkyUI", Enabled: true, NexusModID: 12604, FileID: 1}},
+		Plugins: []Plugin{{Filename: "SkyUI_SE.esp", Enabled: true}},
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !updated.CreatedAt.Equal(now) || !updated.UpdatedAt.Equal(later) {
+		t.Errorf("expected createdAt %v and updatedAt %v, got %v and %v", now, later, updated.CreatedAt, updated.UpdatedAt)
+	}
+	if len(updated.Mods) != 1 || updated.Mods[0].Manifest == nil {
+		t.Errorf("expected listing to be kept, got %+v", updated.Mods)
+	}
+
+	list, err := s.List(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+
+	if err := s.Delete(ctx, w.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get(ctx, w.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if _, err := s.Update(ctx, *updated); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound updating a deleted workspace, got %v", err)
+	}
+}
+
+func TestStore_Create_Invalid(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	tests := []Workspace{
+		{Game: "skyrim", Plugins: []Plugin{{Filename: "a.esp"}}},
+		{Name: "No game", Plugins: []Plugin{{Filename: "a.esp"}}},
+		{Name: "Empty", Game: "skyrim"},
+		{Name: "Unnamed mod", Game: "skyrim", Mods: []Mod{{Name: " "}}},
+	}
+	for _, w := range tests {
+		if _, err := s.Create(ctx, w); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Create(%+v) error = %v, want ErrInvalid", w, err)
+		}
+	}
+}
+
+func TestStore_SaveGathered(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	w, err := s.Create(ctx, Workspace{
+		Name: "Setup",
+		Game: "skyrimspecialedition",
+		Mods: []Mod{{Name: "SkyUI", Enabled: true, NexusModID: 12604, FileID: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	w.Name = "Renamed"
+	w.Mods[0].Manifest = manifest.NewManifest([]manifest.FileEntry{manifest.NewFileEntry("SkyUI_SE.esp", 10)})
+	w.Mods[0].Plugins = []ModPlugin{{Filename: "SkyUI_SE.esp", Masters: []string{"Skyrim.esm"}}}
+	if err := s.SaveGathered(ctx, w); err != nil {
+		t.Fatalf("SaveGathered() error = %v", err)
+	}
+
+	got, err := s.Get(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Name != "Setup" {
+		t.Errorf("expected only manifests to be saved, got name %q", got.Name)
+	}
+	if got.Mods[0].Manifest == nil || len(got.Mods[0].Plugins) != 1 {
+		t.Errorf("expected the manifest and plugins to be saved, got %+v", got.Mods[0])
+	}
+}
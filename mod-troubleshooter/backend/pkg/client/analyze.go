@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AnalyzeOptions are the optional parameters of collection analyses.
+type AnalyzeOptions struct {
+	// Include lists the analyzers to run; empty runs all of them.
+	// Only used by AnalyzeCollection.
+	Include []string
+	// Profile overrides the server's analysis profile: "quick",
+	// "standard" or "deep". Only used by AnalyzeCollection.
+	Profile string
+	// CommunityReports scans the latest Nexus bug reports of each mod.
+	// Only used by AnalyzeCollection.
+	CommunityReports bool
+	// IncludeHashes enables content-based duplicate detection. Only used
+	// by CollectionConflicts.
+	IncludeHashes bool
+	// ShowSuppressed lists the findings hidden by suppressions.
+	ShowSuppressed bool
+}
+
+// query encodes the options as query parameters.
+func (o *AnalyzeOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if len(o.Include) > 0 {
+		q.Set("include", strings.Join(o.Include, ","))
+	}
+	if o.Profile != "" {
+		q.Set("profile", o.Profile)
+	}
+	if o.CommunityReports {
+		q.Set("communityReports", "true")
+	}
+	if o.IncludeHashes {
+		q.Set("includeHashes", "true")
+	}
+	if o.ShowSuppressed {
+		q.Set("showSuppressed", "true")
+	}
+	return q
+}
+
+// collectionPath returns the path of a collection revision endpoint.
+func collectionPath(slug string, revision int, endpoint string) string {
+	return fmt.Sprintf("/api/collections/%s/revisions/%s/%s", url.PathEscape(slug), strconv.Itoa(revision), endpoint)
+}
+
+// AnalyzeCollection runs the combined analysis of a collection revision,
+// downloading each mod once for all analyzers.
+func (c *Client) AnalyzeCollection(ctx context.Context, slug string, revision int, opts *AnalyzeOptions) (*CollectionAnalysis, error) {
+	var result CollectionAnalysis
+	if err := c.do(ctx, http.MethodGet, collectionPath(slug, revision, "analyze"), opts.query(), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CollectionConflicts analyzes the file conflicts of a collection revision.
+func (c *Client) CollectionConflicts(ctx context.Context, slug string, revision int, opts *AnalyzeOptions) (*ConflictAnalysis, error) {
+	var result ConflictAnalysis
+	if err := c.do(ctx, http.MethodGet, collectionPath(slug, revision, "conflicts"), opts.query(), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CollectionLoadOrder analyzes the load order of a collection revision.
+func (c *Client) CollectionLoadOrder(ctx context.Context, slug string, revision int, opts *AnalyzeOptions) (*LoadOrderAnalysis, error) {
+	var result LoadOrderAnalysis
+	if err := c.do(ctx, http.MethodGet, collectionPath(slug, revision, "loadorder"), opts.query(), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AnalyzeConflicts analyzes the file conflicts of a list of mods.
+func (c *Client) AnalyzeConflicts(ctx context.Context, req ConflictRequest) (*ConflictAnalysis, error) {
+	var result ConflictAnalysis
+	if err := c.do(ctx, http.MethodPost, "/api/conflicts/analyze", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AnalyzeLoadOrder analyzes the load order of a list of plugins.
+func (c *Client) AnalyzeLoadOrder(ctx context.Context, req LoadOrderRequest) (*LoadOrderAnalysis, error) {
+	var result LoadOrderAnalysis
+	if err := c.do(ctx, http.MethodPost, "/api/loadorder/analyze", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
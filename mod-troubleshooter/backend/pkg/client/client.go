@@ -0,0 +1,234 @@
+// Package client is a Go client for the mod troubleshooter HTTP API, for
+// tools that run analyses without hand-rolling requests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds configuration for the API client.
+type Config struct {
+	// BaseURL is the server address, such as "http://localhost:8080".
+	BaseURL string
+	// HTTPClient sends the requests (optional). Analyses of large
+	// collections take minutes, so the default has no timeout; bound
+	// calls with their context instead.
+	HTTPClient *http.Client
+	// PollInterval is how long Wait pauses between attempts when the
+	// server doesn't say how long to wait (default 2s).
+	PollInterval time.Duration
+}
+
+// Client calls the analysis API.
+type Client struct {
+	baseURL      *url.URL
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+// New creates a new API client with the given configuration.
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+
+	return &Client{
+		baseURL:      base,
+		httpClient:   httpClient,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+// Error is a failed API response.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Code is the machine-readable error code, such as "rate_limited".
+	Code string
+	// Message is the human-readable description.
+	Message string
+	// Details is extra context for debugging, if the server gave any.
+	Details string
+	// CorrelationID is the request ID, for matching a report with the server logs.
+	CorrelationID string
+	// RetryAfter is how long the server asked to wait before retrying.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("api error %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed if it is repeated
+// later: the server or Nexus is busy, rather than the request being wrong
+// or the server being configured not to serve it.
+func (e *Error) Temporary() bool {
+	switch e.Code {
+	case "read_only", "no_api_key":
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// envelope is the standard response body of the API.
+type envelope struct {
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Error   *struct {
+		Code          string `json:"code"`
+		Message       string `json:"message"`
+		Details       string `json:"details"`
+		CorrelationID string `json:"correlationId"`
+		RetryAfter    int    `json:"retryAfter"`
+	} `json:"error"`
+}
+
+// Health checks that the server is up.
+func (c *Client) Health(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/health", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+	return nil
+}
+
+// do sends a request and decodes the data of the response into result,
+// if it is not nil. Failed responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, path, query, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	decodeErr := json.NewDecoder(resp.Body).Decode(&env)
+
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		if decodeErr == nil && env.Error != nil {
+			apiErr.Code = env.Error.Code
+			apiErr.Message = env.Error.Message
+			apiErr.Details = env.Error.Details
+			apiErr.CorrelationID = env.Error.CorrelationID
+			apiErr.RetryAfter = time.Duration(env.Error.RetryAfter) * time.Second
+		}
+		if apiErr.RetryAfter == 0 {
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+				apiErr.RetryAfter = time.Duration(secs) * time.Second
+			}
+		}
+		return apiErr
+	}
+
+	if decodeErr != nil {
+		return fmt.Errorf("decode response: %w", decodeErr)
+	}
+	if result != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, result); err != nil {
+			return fmt.Errorf("decode data: %w", err)
+		}
+	}
+	return nil
+}
+
+// newRequest builds a request for an API path.
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *c.baseURL
+	u.Path = c.baseURL.Path + path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// Wait calls fn until it succeeds, fails with an error that is not
+// temporary, or ctx is done. Between attempts it waits as long as the
+// server asked, or the client's poll interval. Use it to sit out rate
+// limits and busy periods in long-running tools:
+//
+//	result, err := client.Wait(ctx, c, func(ctx context.Context) (*client.CollectionAnalysis, error) {
+//		return c.AnalyzeCollection(ctx, slug, revision, nil)
+//	})
+func Wait[T any](ctx context.Context, c *Client, fn func(context.Context) (T, error)) (T, error) {
+	for {
+		result, err := fn(ctx)
+		var apiErr *Error
+		if err == nil || !errors.As(err, &apiErr) || !apiErr.Temporary() {
+			return result, err
+		}
+
+		delay := apiErr.RetryAfter
+		if delay <= 0 {
+			delay = c.pollInterval
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
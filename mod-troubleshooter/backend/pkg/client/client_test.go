@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	c, err := New(Config{BaseURL: ts.URL, PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestClient_AnalyzeCollection(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/collections/abc/revisions/3/analyze" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("include"); got != "conflicts,health" {
+			t.Errorf("include = %q", got)
+		}
+		w.Write([]byte(`{"data":{"slug":"abc","revision":3,"analyzers":["conflicts","health"],"results":{
+			"conflicts":{"data":{"conflicts":[{"path":"textures/a.dds","sources":[{"modId":"1"},{"modId":"2"}]}]}},
+			"health":{"error":"boom"}}}}`))
+	})
+
+	result, err := c.AnalyzeCollection(context.Background(), "abc", 3, &AnalyzeOptions{Include: []string{AnalyzerConflicts, AnalyzerHealth}})
+	if err != nil {
+		t.Fatalf("AnalyzeCollection() error = %v", err)
+	}
+
+	conflicts, err := result.Conflicts()
+	if err != nil {
+		t.Fatalf("Conflicts() error = %v", err)
+	}
+	if len(conflicts.Conflicts) != 1 || conflicts.Conflicts[0].Path != "textures/a.dds" {
+		t.Errorf("unexpected conflicts %+v", conflicts.Conflicts)
+	}
+	if _, err := result.Health(); err == nil {
+		t.Error("expected the failed health analyzer to return an error")
+	}
+	if _, err := result.LoadOrder(); err == nil {
+		t.Error("expected an error for an analyzer that was not run")
+	}
+}
+
+func TestClient_AnalyzeConflicts(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ConflictRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Mods) != 2 {
+			t.Errorf("unexpected request %+v (%v)", req, err)
+		}
+		w.Write([]byte(`{"data":{"conflicts":[],"cached":true,"fingerprint":"f"}}`))
+	})
+
+	result, err := c.AnalyzeConflicts(context.Background(), ConflictRequest{Mods: []ModReference{{ModID: "a"}, {ModID: "b"}}})
+	if err != nil {
+		t.Fatalf("AnalyzeConflicts() error = %v", err)
+	}
+	if !result.Cached || result.Fingerprint != "f" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"code":"read_only","message":"Server is in read-only mode","correlationId":"req-1"}}`))
+	})
+
+	_, err := c.CollectionConflicts(context.Background(), "abc", 1, nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if apiErr.Code != "read_only" || apiErr.CorrelationID != "req-1" || apiErr.Temporary() {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+}
+
+func TestWait(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"code":"rate_limited","message":"slow down"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"plugins":[]}}`))
+	})
+
+	_, err := Wait(context.Background(), c, func(ctx context.Context) (*LoadOrderAnalysis, error) {
+		return c.CollectionLoadOrder(ctx, "abc", 1, nil)
+	})
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	if _, err := Wait(ctx, c, func(ctx context.Context) (*LoadOrderAnalysis, error) {
+		return c.CollectionLoadOrder(context.Background(), "abc", 1, nil)
+	}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the wait to stop with the context, got %v", err)
+	}
+}
+
+func TestClient_AsyncConflicts(t *testing.T) {
+	polls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Query().Get("async") == "true":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"data":{"id":"j1","kind":"conflicts","status":"queued"}}`))
+		case r.URL.Path == "/api/jobs/j1":
+			polls++
+			if polls < 2 {
+				w.Write([]byte(`{"data":{"id":"j1","status":"running"}}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"id":"j1","status":"succeeded","result":{"conflicts":[],"fingerprint":"f"}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	})
+
+	job, err := c.AnalyzeConflictsAsync(context.Background(), ConflictRequest{})
+	if err != nil {
+		t.Fatalf("AnalyzeConflictsAsync() error = %v", err)
+	}
+	job, err = c.WaitJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("WaitJob() error = %v", err)
+	}
+	result, err := job.ConflictAnalysis()
+	if err != nil {
+		t.Fatalf("ConflictAnalysis() error = %v", err)
+	}
+	if result.Fingerprint != "f" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Job states reported by the server.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is an analysis running in the background on the server.
+type Job struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Result is the outcome of a succeeded job, as the synchronous
+	// endpoint would have returned it.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error describes why a failed job failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Done reports whether the job has finished.
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// ConflictAnalysis decodes the result of a succeeded conflict job.
+func (j *Job) ConflictAnalysis() (*ConflictAnalysis, error) {
+	if j.Status != JobSucceeded {
+		return nil, fmt.Errorf("job %s has not succeeded: %s", j.ID, j.Status)
+	}
+	var result ConflictAnalysis
+	if err := json.Unmarshal(j.Result, &result); err != nil {
+		return nil, fmt.Errorf("decode job result: %w", err)
+	}
+	return &result, nil
+}
+
+// AnalyzeConflictsAsync hands a conflict analysis to the server's job
+// queue and returns the job without waiting for it. A full queue fails
+// with a temporary *Error, so the call can be retried with Wait.
+func (c *Client) AnalyzeConflictsAsync(ctx context.Context, req ConflictRequest) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodPost, "/api/conflicts/analyze", url.Values{"async": {"true"}}, req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob returns the current state of a job.
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, "/api/jobs/"+url.PathEscape(id), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WaitJob polls a job at the client's poll interval until it finishes or
+// ctx is done. A failed job is returned along with an error carrying its
+// message.
+func (c *Client) WaitJob(ctx context.Context, id string) (*Job, error) {
+	for {
+		job, err := Wait(ctx, c, func(ctx context.Context) (*Job, error) {
+			return c.GetJob(ctx, id)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if job.Status == JobFailed {
+			return job, errors.New(job.Error)
+		}
+		if job.Done() {
+			return job, nil
+		}
+
+		timer := time.NewTimer(c.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return job, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mod-troubleshooter/backend/internal/conflict"
+	"github.com/mod-troubleshooter/backend/internal/fomod"
+	"github.com/mod-troubleshooter/backend/internal/health"
+	"github.com/mod-troubleshooter/backend/internal/loadorder"
+	"github.com/mod-troubleshooter/backend/internal/patch"
+	"github.com/mod-troubleshooter/backend/internal/perf"
+)
+
+// Result types are shared with the server so they can't drift from what it
+// sends.
+type (
+	// ConflictResult is the result of the conflict analyzer.
+	ConflictResult = conflict.AnalysisResult
+	// Conflict is a file provided by more than one mod.
+	Conflict = conflict.Conflict
+	// LoadOrderResult is the result of the load order analyzer.
+	LoadOrderResult = loadorder.AnalysisResult
+	// Issue is a load order problem.
+	Issue = loadorder.Issue
+	// HealthReport is the result of the health analyzer.
+	HealthReport = health.Report
+	// PerformanceBudget is the result of the performance analyzer.
+	PerformanceBudget = perf.Budget
+	// InstallerChoices are the options picked in a FOMOD installer.
+	InstallerChoices = fomod.Choices
+	// PatchFile is a binary patch a collection applies to a mod's file.
+	PatchFile = patch.File
+)
+
+// Analyzer names accepted by AnalyzeOptions.Include.
+const (
+	AnalyzerConflicts   = "conflicts"
+	AnalyzerLoadOrder   = "loadorder"
+	AnalyzerFomod       = "fomod"
+	AnalyzerHealth      = "health"
+	AnalyzerPerformance = "performance"
+)
+
+// ModReference identifies a mod for conflict analysis.
+type ModReference struct {
+	// ModID is a unique identifier for this mod (used for display and tracking).
+	ModID string `json:"modId"`
+	// ModName is the display name of the mod.
+	ModName string `json:"modName"`
+	// Game is the game domain for downloading from Nexus.
+	Game string `json:"game"`
+	// NexusModID is the mod ID on Nexus.
+	NexusModID int `json:"nexusModId"`
+	// FileID is the file ID on Nexus (optional). Without it the mod's
+	// current main file is used.
+	FileID int `json:"fileId"`
+	// Password opens the archive if it is encrypted (optional).
+	Password string `json:"password,omitempty"`
+	// Choices are the options picked in the mod's FOMOD installer (optional).
+	Choices *InstallerChoices `json:"choices,omitempty"`
+	// Patches are binary patches applied to the mod's files (optional).
+	Patches []PatchFile `json:"patches,omitempty"`
+}
+
+// ConflictRequest is the request body for conflict analysis.
+type ConflictRequest struct {
+	// Mods are the mods to analyze, in their intended load order.
+	Mods []ModReference `json:"mods"`
+	// IncludeContentHashes enables content-based duplicate detection (slower).
+	IncludeContentHashes bool `json:"includeContentHashes,omitempty"`
+}
+
+// PluginReference identifies a plugin for load order analysis.
+type PluginReference struct {
+	// Filename is the plugin filename (required).
+	Filename string `json:"filename"`
+	// Game is the game domain for downloading from Nexus (optional).
+	Game string `json:"game,omitempty"`
+	// ModID is the mod ID on Nexus (optional).
+	ModID int `json:"modId,omitempty"`
+	// FileID is the file ID on Nexus (optional).
+	FileID int `json:"fileId,omitempty"`
+	// Password opens the downloaded archive if it is encrypted (optional).
+	Password string `json:"password,omitempty"`
+}
+
+// LoadOrderRequest is the request body for load order analysis.
+type LoadOrderRequest struct {
+	// Plugins are the plugins to analyze, in their intended load order.
+	Plugins []PluginReference `json:"plugins"`
+}
+
+// Warning describes a mod whose data is incomplete, making results partial.
+type Warning struct {
+	Type    string `json:"type"`
+	ModID   string `json:"modId"`
+	ModName string `json:"modName,omitempty"`
+	Message string `json:"message"`
+}
+
+// ModNote is a collection curator's note on one mod, such as which
+// installer options to choose.
+type ModNote struct {
+	ModID   string `json:"modId"`
+	ModName string `json:"modName,omitempty"`
+	Note    string `json:"note"`
+}
+
+// SuppressedFindings lists the findings hidden by suppressions.
+type SuppressedFindings struct {
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Issues    []Issue    `json:"issues,omitempty"`
+}
+
+// ConflictAnalysis is the response from conflict analysis.
+type ConflictAnalysis struct {
+	*ConflictResult
+	Cached bool `json:"cached"`
+	// Fingerprint identifies the result; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+	// SuppressedFindings is the number of findings hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings"`
+	// Suppressed lists the hidden findings when ShowSuppressed was requested.
+	Suppressed *SuppressedFindings `json:"suppressed,omitempty"`
+	// Warnings lists mods whose data was incomplete.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// ModNotes are the collection curator's notes on its mods.
+	ModNotes []ModNote `json:"modNotes,omitempty"`
+}
+
+// LoadOrderAnalysis is the response from load order analysis.
+type LoadOrderAnalysis struct {
+	*LoadOrderResult
+	Cached bool `json:"cached"`
+	// Fingerprint identifies the result; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+	// SuppressedFindings is the number of findings hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings"`
+	// Suppressed lists the hidden findings when ShowSuppressed was requested.
+	Suppressed *SuppressedFindings `json:"suppressed,omitempty"`
+	// Warnings lists mods whose data was incomplete.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// ModNotes are the collection curator's notes on its mods.
+	ModNotes []ModNote `json:"modNotes,omitempty"`
+}
+
+// AnalyzerResult is the outcome of one analyzer in a combined analysis.
+type AnalyzerResult struct {
+	// Data is the analyzer's result, or null if it failed. Decode it with
+	// the typed accessors of CollectionAnalysis.
+	Data json.RawMessage `json:"data,omitempty"`
+	// Error describes why the analyzer failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// CollectionAnalysis is the response from a combined collection analysis.
+type CollectionAnalysis struct {
+	Slug       string `json:"slug"`
+	Revision   int    `json:"revision"`
+	GameDomain string `json:"gameDomain"`
+	// Analyzers lists the analyzers that were run, in request order.
+	Analyzers []string `json:"analyzers"`
+	// Profile is the analysis profile the results were gathered with.
+	Profile string `json:"profile"`
+	// ModsTotal is the number of mod files in the revision.
+	ModsTotal int `json:"modsTotal"`
+	// Results maps analyzer name to its result.
+	Results map[string]AnalyzerResult `json:"results"`
+	// Fingerprint identifies the results; identical analyses share it.
+	Fingerprint string `json:"fingerprint"`
+	// SuppressedFindings is the number of findings hidden by suppressions.
+	SuppressedFindings int `json:"suppressedFindings"`
+	// Suppressed lists the hidden findings when ShowSuppressed was requested.
+	Suppressed *SuppressedFindings `json:"suppressed,omitempty"`
+	// Warnings lists mods whose data was incomplete.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// ModNotes are the collection curator's notes on its mods.
+	ModNotes []ModNote `json:"modNotes,omitempty"`
+	// Timings breaks down where the time went.
+	Timings json.RawMessage `json:"timings,omitempty"`
+}
+
+// Result decodes the result of an analyzer into v. It fails if the
+// analyzer was not run or failed.
+func (a *CollectionAnalysis) Result(name string, v interface{}) error {
+	res, ok := a.Results[name]
+	if !ok {
+		return fmt.Errorf("analyzer %q was not run", name)
+	}
+	if res.Error != "" {
+		return fmt.Errorf("analyzer %q failed: %s", name, res.Error)
+	}
+	if err := json.Unmarshal(res.Data, v); err != nil {
+		return fmt.Errorf("decode %s result: %w", name, err)
+	}
+	return nil
+}
+
+// Conflicts returns the result of the conflict analyzer.
+func (a *CollectionAnalysis) Conflicts() (*ConflictResult, error) {
+	var result ConflictResult
+	if err := a.Result(AnalyzerConflicts, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LoadOrder returns the result of the load order analyzer.
+func (a *CollectionAnalysis) LoadOrder() (*LoadOrderResult, error) {
+	var result LoadOrderResult
+	if err := a.Result(AnalyzerLoadOrder, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Health returns the result of the health analyzer.
+func (a *CollectionAnalysis) Health() (*HealthReport, error) {
+	var result HealthReport
+	if err := a.Result(AnalyzerHealth, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Performance returns the result of the performance analyzer.
+func (a *CollectionAnalysis) Performance() (*PerformanceBudget, error) {
+	var result PerformanceBudget
+	if err := a.Result(AnalyzerPerformance, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,1247 @@
+// gRPC interface for programmatic consumers such as mod manager plugins and
+// chat bots. Messages mirror the JSON types served by the HTTP API; field
+// comments name the Go type each message corresponds to.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: proto/modtroubleshooter/v1/analyzer.proto
+
+package modtroubleshooterv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Progress_Stage int32
+
+const (
+	Progress_STAGE_UNSPECIFIED Progress_Stage = 0
+	Progress_STAGE_DOWNLOADING Progress_Stage = 1
+	Progress_STAGE_EXTRACTING  Progress_Stage = 2
+	Progress_STAGE_ANALYZING   Progress_Stage = 3
+)
+
+// Enum value maps for Progress_Stage.
+var (
+	Progress_Stage_name = map[int32]string{
+		0: "STAGE_UNSPECIFIED",
+		1: "STAGE_DOWNLOADING",
+		2: "STAGE_EXTRACTING",
+		3: "STAGE_ANALYZING",
+	}
+	Progress_Stage_value = map[string]int32{
+		"STAGE_UNSPECIFIED": 0,
+		"STAGE_DOWNLOADING": 1,
+		"STAGE_EXTRACTING":  2,
+		"STAGE_ANALYZING":   3,
+	}
+)
+
+func (x Progress_Stage) Enum() *Progress_Stage {
+	p := new(Progress_Stage)
+	*p = x
+	return p
+}
+
+func (x Progress_Stage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Progress_Stage) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_enumTypes[0].Descriptor()
+}
+
+func (Progress_Stage) Type() protoreflect.EnumType {
+	return &file_proto_modtroubleshooter_v1_analyzer_proto_enumTypes[0]
+}
+
+func (x Progress_Stage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Progress_Stage.Descriptor instead.
+func (Progress_Stage) EnumDescriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{2, 0}
+}
+
+// AnalyzeRequest selects a collection revision and the analyzers to run.
+type AnalyzeRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Slug     string                 `protobuf:"bytes,1,opt,name=slug,proto3" json:"slug,omitempty"`
+	Revision int32                  `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Analyzers to run, by name; all registered analyzers when empty.
+	Analyzers     []string `protobuf:"bytes,3,rep,name=analyzers,proto3" json:"analyzers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeRequest) Reset() {
+	*x = AnalyzeRequest{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeRequest) ProtoMessage() {}
+
+func (x *AnalyzeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeRequest.ProtoReflect.Descriptor instead.
+func (*AnalyzeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AnalyzeRequest) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *AnalyzeRequest) GetAnalyzers() []string {
+	if x != nil {
+		return x.Analyzers
+	}
+	return nil
+}
+
+// AnalyzeEvent is a single message of the Analyze stream. Every successful
+// stream ends with exactly one result. Callers that join an analysis already
+// running for the same request receive the result without progress.
+type AnalyzeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*AnalyzeEvent_Progress
+	//	*AnalyzeEvent_Result
+	Event         isAnalyzeEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeEvent) Reset() {
+	*x = AnalyzeEvent{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeEvent) ProtoMessage() {}
+
+func (x *AnalyzeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeEvent.ProtoReflect.Descriptor instead.
+func (*AnalyzeEvent) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AnalyzeEvent) GetEvent() isAnalyzeEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *AnalyzeEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*AnalyzeEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *AnalyzeEvent) GetResult() *AnalyzeResult {
+	if x != nil {
+		if x, ok := x.Event.(*AnalyzeEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isAnalyzeEvent_Event interface {
+	isAnalyzeEvent_Event()
+}
+
+type AnalyzeEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type AnalyzeEvent_Result struct {
+	Result *AnalyzeResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*AnalyzeEvent_Progress) isAnalyzeEvent_Event() {}
+
+func (*AnalyzeEvent_Result) isAnalyzeEvent_Event() {}
+
+// Progress reports how far the gather pass has got.
+type Progress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Stage Progress_Stage         `protobuf:"varint,1,opt,name=stage,proto3,enum=modtroubleshooter.v1.Progress_Stage" json:"stage,omitempty"`
+	// Mods done so far out of mods_total.
+	ModsDone  int32 `protobuf:"varint,2,opt,name=mods_done,json=modsDone,proto3" json:"mods_done,omitempty"`
+	ModsTotal int32 `protobuf:"varint,3,opt,name=mods_total,json=modsTotal,proto3" json:"mods_total,omitempty"`
+	// Display name of the mod being processed, if any.
+	CurrentMod    string `protobuf:"bytes,4,opt,name=current_mod,json=currentMod,proto3" json:"current_mod,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetStage() Progress_Stage {
+	if x != nil {
+		return x.Stage
+	}
+	return Progress_STAGE_UNSPECIFIED
+}
+
+func (x *Progress) GetModsDone() int32 {
+	if x != nil {
+		return x.ModsDone
+	}
+	return 0
+}
+
+func (x *Progress) GetModsTotal() int32 {
+	if x != nil {
+		return x.ModsTotal
+	}
+	return 0
+}
+
+func (x *Progress) GetCurrentMod() string {
+	if x != nil {
+		return x.CurrentMod
+	}
+	return ""
+}
+
+// AnalyzeResult corresponds to handlers.CollectionAnalyzeResponse.
+type AnalyzeResult struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Slug       string                 `protobuf:"bytes,1,opt,name=slug,proto3" json:"slug,omitempty"`
+	Revision   int32                  `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	GameDomain string                 `protobuf:"bytes,3,opt,name=game_domain,json=gameDomain,proto3" json:"game_domain,omitempty"`
+	Analyzers  []string               `protobuf:"bytes,4,rep,name=analyzers,proto3" json:"analyzers,omitempty"`
+	ModsTotal  int32                  `protobuf:"varint,5,opt,name=mods_total,json=modsTotal,proto3" json:"mods_total,omitempty"`
+	// Results maps analyzer name to its result.
+	Results            map[string]*AnalyzerResult `protobuf:"bytes,6,rep,name=results,proto3" json:"results,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Fingerprint        string                     `protobuf:"bytes,7,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	SuppressedFindings int32                      `protobuf:"varint,8,opt,name=suppressed_findings,json=suppressedFindings,proto3" json:"suppressed_findings,omitempty"`
+	Warnings           []*Warning                 `protobuf:"bytes,9,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AnalyzeResult) Reset() {
+	*x = AnalyzeResult{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeResult) ProtoMessage() {}
+
+func (x *AnalyzeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeResult.ProtoReflect.Descriptor instead.
+func (*AnalyzeResult) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AnalyzeResult) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *AnalyzeResult) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *AnalyzeResult) GetGameDomain() string {
+	if x != nil {
+		return x.GameDomain
+	}
+	return ""
+}
+
+func (x *AnalyzeResult) GetAnalyzers() []string {
+	if x != nil {
+		return x.Analyzers
+	}
+	return nil
+}
+
+func (x *AnalyzeResult) GetModsTotal() int32 {
+	if x != nil {
+		return x.ModsTotal
+	}
+	return 0
+}
+
+func (x *AnalyzeResult) GetResults() map[string]*AnalyzerResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *AnalyzeResult) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *AnalyzeResult) GetSuppressedFindings() int32 {
+	if x != nil {
+		return x.SuppressedFindings
+	}
+	return 0
+}
+
+func (x *AnalyzeResult) GetWarnings() []*Warning {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+// AnalyzerResult corresponds to pipeline.Result. Analyzer results differ in
+// shape, so data carries the same JSON document the HTTP API returns.
+type AnalyzerResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DataJson      []byte                 `protobuf:"bytes,1,opt,name=data_json,json=dataJson,proto3" json:"data_json,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzerResult) Reset() {
+	*x = AnalyzerResult{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzerResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzerResult) ProtoMessage() {}
+
+func (x *AnalyzerResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzerResult.ProtoReflect.Descriptor instead.
+func (*AnalyzerResult) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AnalyzerResult) GetDataJson() []byte {
+	if x != nil {
+		return x.DataJson
+	}
+	return nil
+}
+
+func (x *AnalyzerResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Warning corresponds to pipeline.Warning.
+type Warning struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	ModId         string                 `protobuf:"bytes,2,opt,name=mod_id,json=modId,proto3" json:"mod_id,omitempty"`
+	ModName       string                 `protobuf:"bytes,3,opt,name=mod_name,json=modName,proto3" json:"mod_name,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Warning) Reset() {
+	*x = Warning{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Warning) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Warning) ProtoMessage() {}
+
+func (x *Warning) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Warning.ProtoReflect.Descriptor instead.
+func (*Warning) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Warning) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Warning) GetModId() string {
+	if x != nil {
+		return x.ModId
+	}
+	return ""
+}
+
+func (x *Warning) GetModName() string {
+	if x != nil {
+		return x.ModName
+	}
+	return ""
+}
+
+func (x *Warning) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// GetManifestRequest identifies a mod file on Nexus.
+type GetManifestRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	GameDomain string                 `protobuf:"bytes,1,opt,name=game_domain,json=gameDomain,proto3" json:"game_domain,omitempty"`
+	ModId      int32                  `protobuf:"varint,2,opt,name=mod_id,json=modId,proto3" json:"mod_id,omitempty"`
+	FileId     int32                  `protobuf:"varint,3,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Hash every file; slower, but enables hash-based comparisons.
+	IncludeHashes bool `protobuf:"varint,4,opt,name=include_hashes,json=includeHashes,proto3" json:"include_hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetManifestRequest) Reset() {
+	*x = GetManifestRequest{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetManifestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManifestRequest) ProtoMessage() {}
+
+func (x *GetManifestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManifestRequest.ProtoReflect.Descriptor instead.
+func (*GetManifestRequest) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetManifestRequest) GetGameDomain() string {
+	if x != nil {
+		return x.GameDomain
+	}
+	return ""
+}
+
+func (x *GetManifestRequest) GetModId() int32 {
+	if x != nil {
+		return x.ModId
+	}
+	return 0
+}
+
+func (x *GetManifestRequest) GetFileId() int32 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *GetManifestRequest) GetIncludeHashes() bool {
+	if x != nil {
+		return x.IncludeHashes
+	}
+	return false
+}
+
+// Manifest corresponds to manifest.Manifest.
+type Manifest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         []*FileEntry           `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	TotalSize     int64                  `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	TotalCount    int32                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	ByType        map[string]int32       `protobuf:"bytes,4,rep,name=by_type,json=byType,proto3" json:"by_type,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	ByExtension   map[string]int32       `protobuf:"bytes,5,rep,name=by_extension,json=byExtension,proto3" json:"by_extension,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Manifest) Reset() {
+	*x = Manifest{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Manifest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Manifest) ProtoMessage() {}
+
+func (x *Manifest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Manifest.ProtoReflect.Descriptor instead.
+func (*Manifest) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Manifest) GetFiles() []*FileEntry {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *Manifest) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *Manifest) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *Manifest) GetByType() map[string]int32 {
+	if x != nil {
+		return x.ByType
+	}
+	return nil
+}
+
+func (x *Manifest) GetByExtension() map[string]int32 {
+	if x != nil {
+		return x.ByExtension
+	}
+	return nil
+}
+
+// FileEntry corresponds to manifest.FileEntry.
+type FileEntry struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Path         string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	OriginalPath string                 `protobuf:"bytes,2,opt,name=original_path,json=originalPath,proto3" json:"original_path,omitempty"`
+	Size         int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Hash         string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	// One of plugin, mesh, texture, sound, script, interface, seq, bsa, other.
+	Type          string `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	Extension     string `protobuf:"bytes,6,opt,name=extension,proto3" json:"extension,omitempty"`
+	Directory     string `protobuf:"bytes,7,opt,name=directory,proto3" json:"directory,omitempty"`
+	Filename      string `protobuf:"bytes,8,opt,name=filename,proto3" json:"filename,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileEntry) Reset() {
+	*x = FileEntry{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileEntry) ProtoMessage() {}
+
+func (x *FileEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileEntry.ProtoReflect.Descriptor instead.
+func (*FileEntry) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FileEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileEntry) GetOriginalPath() string {
+	if x != nil {
+		return x.OriginalPath
+	}
+	return ""
+}
+
+func (x *FileEntry) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileEntry) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *FileEntry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *FileEntry) GetExtension() string {
+	if x != nil {
+		return x.Extension
+	}
+	return ""
+}
+
+func (x *FileEntry) GetDirectory() string {
+	if x != nil {
+		return x.Directory
+	}
+	return ""
+}
+
+func (x *FileEntry) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+// ParsePluginHeaderRequest carries a plugin file, or at least its header.
+type ParsePluginHeaderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParsePluginHeaderRequest) Reset() {
+	*x = ParsePluginHeaderRequest{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParsePluginHeaderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParsePluginHeaderRequest) ProtoMessage() {}
+
+func (x *ParsePluginHeaderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParsePluginHeaderRequest.ProtoReflect.Descriptor instead.
+func (*ParsePluginHeaderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ParsePluginHeaderRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ParsePluginHeaderRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// PluginHeader corresponds to plugin.PluginHeader.
+type PluginHeader struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	// One of ESM, ESP, ESL.
+	Type          string       `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Flags         *PluginFlags `protobuf:"bytes,3,opt,name=flags,proto3" json:"flags,omitempty"`
+	Author        string       `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	Description   string       `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Masters       []*Master    `protobuf:"bytes,6,rep,name=masters,proto3" json:"masters,omitempty"`
+	FormVersion   uint32       `protobuf:"varint,7,opt,name=form_version,json=formVersion,proto3" json:"form_version,omitempty"`
+	NumRecords    uint32       `protobuf:"varint,8,opt,name=num_records,json=numRecords,proto3" json:"num_records,omitempty"`
+	NextObjectId  uint32       `protobuf:"varint,9,opt,name=next_object_id,json=nextObjectId,proto3" json:"next_object_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginHeader) Reset() {
+	*x = PluginHeader{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginHeader) ProtoMessage() {}
+
+func (x *PluginHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginHeader.ProtoReflect.Descriptor instead.
+func (*PluginHeader) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PluginHeader) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *PluginHeader) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PluginHeader) GetFlags() *PluginFlags {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *PluginHeader) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *PluginHeader) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PluginHeader) GetMasters() []*Master {
+	if x != nil {
+		return x.Masters
+	}
+	return nil
+}
+
+func (x *PluginHeader) GetFormVersion() uint32 {
+	if x != nil {
+		return x.FormVersion
+	}
+	return 0
+}
+
+func (x *PluginHeader) GetNumRecords() uint32 {
+	if x != nil {
+		return x.NumRecords
+	}
+	return 0
+}
+
+func (x *PluginHeader) GetNextObjectId() uint32 {
+	if x != nil {
+		return x.NextObjectId
+	}
+	return 0
+}
+
+// PluginFlags corresponds to plugin.PluginFlags.
+type PluginFlags struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IsMaster      bool                   `protobuf:"varint,1,opt,name=is_master,json=isMaster,proto3" json:"is_master,omitempty"`
+	IsLight       bool                   `protobuf:"varint,2,opt,name=is_light,json=isLight,proto3" json:"is_light,omitempty"`
+	IsLocalized   bool                   `protobuf:"varint,3,opt,name=is_localized,json=isLocalized,proto3" json:"is_localized,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginFlags) Reset() {
+	*x = PluginFlags{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginFlags) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginFlags) ProtoMessage() {}
+
+func (x *PluginFlags) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginFlags.ProtoReflect.Descriptor instead.
+func (*PluginFlags) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PluginFlags) GetIsMaster() bool {
+	if x != nil {
+		return x.IsMaster
+	}
+	return false
+}
+
+func (x *PluginFlags) GetIsLight() bool {
+	if x != nil {
+		return x.IsLight
+	}
+	return false
+}
+
+func (x *PluginFlags) GetIsLocalized() bool {
+	if x != nil {
+		return x.IsLocalized
+	}
+	return false
+}
+
+// Master corresponds to plugin.Master.
+type Master struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Size          uint64                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Master) Reset() {
+	*x = Master{}
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Master) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Master) ProtoMessage() {}
+
+func (x *Master) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Master.ProtoReflect.Descriptor instead.
+func (*Master) Descriptor() ([]byte, []int) {
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Master) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Master) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+var File_proto_modtroubleshooter_v1_analyzer_proto protoreflect.FileDescriptor
+
+const file_proto_modtroubleshooter_v1_analyzer_proto_rawDesc = "" +
+	"\n" +
+	")proto/modtroubleshooter/v1/analyzer.proto\x12\x14modtroubleshooter.v1\"^\n" +
+	"\x0eAnalyzeRequest\x12\x12\n" +
+	"\x04slug\x18\x01 \x01(\tR\x04slug\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x05R\brevision\x12\x1c\n" +
+	"\tanalyzers\x18\x03 \x03(\tR\tanalyzers\"\x94\x01\n" +
+	"\fAnalyzeEvent\x12<\n" +
+	"\bprogress\x18\x01 \x01(\v2\x1e.modtroubleshooter.v1.ProgressH\x00R\bprogress\x12=\n" +
+	"\x06result\x18\x02 \x01(\v2#.modtroubleshooter.v1.AnalyzeResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\x85\x02\n" +
+	"\bProgress\x12:\n" +
+	"\x05stage\x18\x01 \x01(\x0e2$.modtroubleshooter.v1.Progress.StageR\x05stage\x12\x1b\n" +
+	"\tmods_done\x18\x02 \x01(\x05R\bmodsDone\x12\x1d\n" +
+	"\n" +
+	"mods_total\x18\x03 \x01(\x05R\tmodsTotal\x12\x1f\n" +
+	"\vcurrent_mod\x18\x04 \x01(\tR\n" +
+	"currentMod\"`\n" +
+	"\x05Stage\x12\x15\n" +
+	"\x11STAGE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11STAGE_DOWNLOADING\x10\x01\x12\x14\n" +
+	"\x10STAGE_EXTRACTING\x10\x02\x12\x13\n" +
+	"\x0fSTAGE_ANALYZING\x10\x03\"\xd9\x03\n" +
+	"\rAnalyzeResult\x12\x12\n" +
+	"\x04slug\x18\x01 \x01(\tR\x04slug\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x05R\brevision\x12\x1f\n" +
+	"\vgame_domain\x18\x03 \x01(\tR\n" +
+	"gameDomain\x12\x1c\n" +
+	"\tanalyzers\x18\x04 \x03(\tR\tanalyzers\x12\x1d\n" +
+	"\n" +
+	"mods_total\x18\x05 \x01(\x05R\tmodsTotal\x12J\n" +
+	"\aresults\x18\x06 \x03(\v20.modtroubleshooter.v1.AnalyzeResult.ResultsEntryR\aresults\x12 \n" +
+	"\vfingerprint\x18\a \x01(\tR\vfingerprint\x12/\n" +
+	"\x13suppressed_findings\x18\b \x01(\x05R\x12suppressedFindings\x129\n" +
+	"\bwarnings\x18\t \x03(\v2\x1d.modtroubleshooter.v1.WarningR\bwarnings\x1a`\n" +
+	"\fResultsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12:\n" +
+	"\x05value\x18\x02 \x01(\v2$.modtroubleshooter.v1.AnalyzerResultR\x05value:\x028\x01\"C\n" +
+	"\x0eAnalyzerResult\x12\x1b\n" +
+	"\tdata_json\x18\x01 \x01(\fR\bdataJson\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"i\n" +
+	"\aWarning\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x15\n" +
+	"\x06mod_id\x18\x02 \x01(\tR\x05modId\x12\x19\n" +
+	"\bmod_name\x18\x03 \x01(\tR\amodName\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\x8c\x01\n" +
+	"\x12GetManifestRequest\x12\x1f\n" +
+	"\vgame_domain\x18\x01 \x01(\tR\n" +
+	"gameDomain\x12\x15\n" +
+	"\x06mod_id\x18\x02 \x01(\x05R\x05modId\x12\x17\n" +
+	"\afile_id\x18\x03 \x01(\x05R\x06fileId\x12%\n" +
+	"\x0einclude_hashes\x18\x04 \x01(\bR\rincludeHashes\"\x95\x03\n" +
+	"\bManifest\x125\n" +
+	"\x05files\x18\x01 \x03(\v2\x1f.modtroubleshooter.v1.FileEntryR\x05files\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x03R\ttotalSize\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x05R\n" +
+	"totalCount\x12C\n" +
+	"\aby_type\x18\x04 \x03(\v2*.modtroubleshooter.v1.Manifest.ByTypeEntryR\x06byType\x12R\n" +
+	"\fby_extension\x18\x05 \x03(\v2/.modtroubleshooter.v1.Manifest.ByExtensionEntryR\vbyExtension\x1a9\n" +
+	"\vByTypeEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a>\n" +
+	"\x10ByExtensionEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xd8\x01\n" +
+	"\tFileEntry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12#\n" +
+	"\roriginal_path\x18\x02 \x01(\tR\foriginalPath\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\x12\x1c\n" +
+	"\textension\x18\x06 \x01(\tR\textension\x12\x1c\n" +
+	"\tdirectory\x18\a \x01(\tR\tdirectory\x12\x1a\n" +
+	"\bfilename\x18\b \x01(\tR\bfilename\"J\n" +
+	"\x18ParsePluginHeaderRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\xd3\x02\n" +
+	"\fPluginHeader\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x127\n" +
+	"\x05flags\x18\x03 \x01(\v2!.modtroubleshooter.v1.PluginFlagsR\x05flags\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x126\n" +
+	"\amasters\x18\x06 \x03(\v2\x1c.modtroubleshooter.v1.MasterR\amasters\x12!\n" +
+	"\fform_version\x18\a \x01(\rR\vformVersion\x12\x1f\n" +
+	"\vnum_records\x18\b \x01(\rR\n" +
+	"numRecords\x12$\n" +
+	"\x0enext_object_id\x18\t \x01(\rR\fnextObjectId\"h\n" +
+	"\vPluginFlags\x12\x1b\n" +
+	"\tis_master\x18\x01 \x01(\bR\bisMaster\x12\x19\n" +
+	"\bis_light\x18\x02 \x01(\bR\aisLight\x12!\n" +
+	"\fis_localized\x18\x03 \x01(\bR\visLocalized\"8\n" +
+	"\x06Master\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x04R\x04size2h\n" +
+	"\x0fAnalyzerService\x12U\n" +
+	"\aAnalyze\x12$.modtroubleshooter.v1.AnalyzeRequest\x1a\".modtroubleshooter.v1.AnalyzeEvent0\x012j\n" +
+	"\x0fManifestService\x12W\n" +
+	"\vGetManifest\x12(.modtroubleshooter.v1.GetManifestRequest\x1a\x1e.modtroubleshooter.v1.Manifest2~\n" +
+	"\x13PluginHeaderService\x12g\n" +
+	"\x11ParsePluginHeader\x12..modtroubleshooter.v1.ParsePluginHeaderRequest\x1a\".modtroubleshooter.v1.PluginHeaderBVZTgithub.com/mod-troubleshooter/backend/proto/modtroubleshooter/v1;modtroubleshooterv1b\x06proto3"
+
+var (
+	file_proto_modtroubleshooter_v1_analyzer_proto_rawDescOnce sync.Once
+	file_proto_modtroubleshooter_v1_analyzer_proto_rawDescData []byte
+)
+
+func file_proto_modtroubleshooter_v1_analyzer_proto_rawDescGZIP() []byte {
+	file_proto_modtroubleshooter_v1_analyzer_proto_rawDescOnce.Do(func() {
+		file_proto_modtroubleshooter_v1_analyzer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_modtroubleshooter_v1_analyzer_proto_rawDesc), len(file_proto_modtroubleshooter_v1_analyzer_proto_rawDesc)))
+	})
+	return file_proto_modtroubleshooter_v1_analyzer_proto_rawDescData
+}
+
+var file_proto_modtroubleshooter_v1_analyzer_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_proto_modtroubleshooter_v1_analyzer_proto_goTypes = []any{
+	(Progress_Stage)(0),              // 0: modtroubleshooter.v1.Progress.Stage
+	(*AnalyzeRequest)(nil),           // 1: modtroubleshooter.v1.AnalyzeRequest
+	(*AnalyzeEvent)(nil),             // 2: modtroubleshooter.v1.AnalyzeEvent
+	(*Progress)(nil),                 // 3: modtroubleshooter.v1.Progress
+	(*AnalyzeResult)(nil),            // 4: modtroubleshooter.v1.AnalyzeResult
+	(*AnalyzerResult)(nil),           // 5: modtroubleshooter.v1.AnalyzerResult
+	(*Warning)(nil),                  // 6: modtroubleshooter.v1.Warning
+	(*GetManifestRequest)(nil),       // 7: modtroubleshooter.v1.GetManifestRequest
+	(*Manifest)(nil),                 // 8: modtroubleshooter.v1.Manifest
+	(*FileEntry)(nil),                // 9: modtroubleshooter.v1.FileEntry
+	(*ParsePluginHeaderRequest)(nil), // 10: modtroubleshooter.v1.ParsePluginHeaderRequest
+	(*PluginHeader)(nil),             // 11: modtroubleshooter.v1.PluginHeader
+	(*PluginFlags)(nil),              // 12: modtroubleshooter.v1.PluginFlags
+	(*Master)(nil),                   // 13: modtroubleshooter.v1.Master
+	nil,                              // 14: modtroubleshooter.v1.AnalyzeResult.ResultsEntry
+	nil,                              // 15: modtroubleshooter.v1.Manifest.ByTypeEntry
+	nil,                              // 16: modtroubleshooter.v1.Manifest.ByExtensionEntry
+}
+var file_proto_modtroubleshooter_v1_analyzer_proto_depIdxs = []int32{
+	3,  // 0: modtroubleshooter.v1.AnalyzeEvent.progress:type_name -> modtroubleshooter.v1.Progress
+	4,  // 1: modtroubleshooter.v1.AnalyzeEvent.result:type_name -> modtroubleshooter.v1.AnalyzeResult
+	0,  // 2: modtroubleshooter.v1.Progress.stage:type_name -> modtroubleshooter.v1.Progress.Stage
+	14, // 3: modtroubleshooter.v1.AnalyzeResult.results:type_name -> modtroubleshooter.v1.AnalyzeResult.ResultsEntry
+	6,  // 4: modtroubleshooter.v1.AnalyzeResult.warnings:type_name -> modtroubleshooter.v1.Warning
+	9,  // 5: modtroubleshooter.v1.Manifest.files:type_name -> modtroubleshooter.v1.FileEntry
+	15, // 6: modtroubleshooter.v1.Manifest.by_type:type_name -> modtroubleshooter.v1.Manifest.ByTypeEntry
+	16, // 7: modtroubleshooter.v1.Manifest.by_extension:type_name -> modtroubleshooter.v1.Manifest.ByExtensionEntry
+	12, // 8: modtroubleshooter.v1.PluginHeader.flags:type_name -> modtroubleshooter.v1.PluginFlags
+	13, // 9: modtroubleshooter.v1.PluginHeader.masters:type_name -> modtroubleshooter.v1.Master
+	5,  // 10: modtroubleshooter.v1.AnalyzeResult.ResultsEntry.value:type_name -> modtroubleshooter.v1.AnalyzerResult
+	1,  // 11: modtroubleshooter.v1.AnalyzerService.Analyze:input_type -> modtroubleshooter.v1.AnalyzeRequest
+	7,  // 12: modtroubleshooter.v1.ManifestService.GetManifest:input_type -> modtroubleshooter.v1.GetManifestRequest
+	10, // 13: modtroubleshooter.v1.PluginHeaderService.ParsePluginHeader:input_type -> modtroubleshooter.v1.ParsePluginHeaderRequest
+	2,  // 14: modtroubleshooter.v1.AnalyzerService.Analyze:output_type -> modtroubleshooter.v1.AnalyzeEvent
+	8,  // 15: modtroubleshooter.v1.ManifestService.GetManifest:output_type -> modtroubleshooter.v1.Manifest
+	11, // 16: modtroubleshooter.v1.PluginHeaderService.ParsePluginHeader:output_type -> modtroubleshooter.v1.PluginHeader
+	14, // [14:17] is the sub-list for method output_type
+	11, // [11:14] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_proto_modtroubleshooter_v1_analyzer_proto_init() }
+func file_proto_modtroubleshooter_v1_analyzer_proto_init() {
+	if File_proto_modtroubleshooter_v1_analyzer_proto != nil {
+		return
+	}
+	file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes[1].OneofWrappers = []any{
+		(*AnalyzeEvent_Progress)(nil),
+		(*AnalyzeEvent_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_modtroubleshooter_v1_analyzer_proto_rawDesc), len(file_proto_modtroubleshooter_v1_analyzer_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_proto_modtroubleshooter_v1_analyzer_proto_goTypes,
+		DependencyIndexes: file_proto_modtroubleshooter_v1_analyzer_proto_depIdxs,
+		EnumInfos:         file_proto_modtroubleshooter_v1_analyzer_proto_enumTypes,
+		MessageInfos:      file_proto_modtroubleshooter_v1_analyzer_proto_msgTypes,
+	}.Build()
+	File_proto_modtroubleshooter_v1_analyzer_proto = out.File
+	file_proto_modtroubleshooter_v1_analyzer_proto_goTypes = nil
+	file_proto_modtroubleshooter_v1_analyzer_proto_depIdxs = nil
+}
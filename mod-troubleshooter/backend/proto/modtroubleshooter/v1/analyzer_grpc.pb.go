@@ -0,0 +1,354 @@
+// gRPC interface for programmatic consumers such as mod manager plugins and
+// chat bots. Messages mirror the JSON types served by the HTTP API; field
+// comments name the Go type each message corresponds to.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: proto/modtroubleshooter/v1/analyzer.proto
+
+package modtroubleshooterv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnalyzerService_Analyze_FullMethodName = "/modtroubleshooter.v1.AnalyzerService/Analyze"
+)
+
+// AnalyzerServiceClient is the client API for AnalyzerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AnalyzerService runs the collection analyzers.
+type AnalyzerServiceClient interface {
+	// Analyze runs analyzers over a collection revision from a single download
+	// pass, streaming progress while mods are gathered and ending with the
+	// result. Mirrors GET /api/collections/{slug}/revisions/{revision}/analyze.
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnalyzeEvent], error)
+}
+
+type analyzerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyzerServiceClient(cc grpc.ClientConnInterface) AnalyzerServiceClient {
+	return &analyzerServiceClient{cc}
+}
+
+func (c *analyzerServiceClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnalyzeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnalyzerService_ServiceDesc.Streams[0], AnalyzerService_Analyze_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AnalyzeRequest, AnalyzeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalyzerService_AnalyzeClient = grpc.ServerStreamingClient[AnalyzeEvent]
+
+// AnalyzerServiceServer is the server API for AnalyzerService service.
+// All implementations must embed UnimplementedAnalyzerServiceServer
+// for forward compatibility.
+//
+// AnalyzerService runs the collection analyzers.
+type AnalyzerServiceServer interface {
+	// Analyze runs analyzers over a collection revision from a single download
+	// pass, streaming progress while mods are gathered and ending with the
+	// result. Mirrors GET /api/collections/{slug}/revisions/{revision}/analyze.
+	Analyze(*AnalyzeRequest, grpc.ServerStreamingServer[AnalyzeEvent]) error
+	mustEmbedUnimplementedAnalyzerServiceServer()
+}
+
+// UnimplementedAnalyzerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnalyzerServiceServer struct{}
+
+func (UnimplementedAnalyzerServiceServer) Analyze(*AnalyzeRequest, grpc.ServerStreamingServer[AnalyzeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Analyze not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) mustEmbedUnimplementedAnalyzerServiceServer() {}
+func (UnimplementedAnalyzerServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeAnalyzerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyzerServiceServer will
+// result in compilation errors.
+type UnsafeAnalyzerServiceServer interface {
+	mustEmbedUnimplementedAnalyzerServiceServer()
+}
+
+func RegisterAnalyzerServiceServer(s grpc.ServiceRegistrar, srv AnalyzerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAnalyzerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnalyzerService_ServiceDesc, srv)
+}
+
+func _AnalyzerService_Analyze_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AnalyzeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyzerServiceServer).Analyze(m, &grpc.GenericServerStream[AnalyzeRequest, AnalyzeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalyzerService_AnalyzeServer = grpc.ServerStreamingServer[AnalyzeEvent]
+
+// AnalyzerService_ServiceDesc is the grpc.ServiceDesc for AnalyzerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyzerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "modtroubleshooter.v1.AnalyzerService",
+	HandlerType: (*AnalyzerServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Analyze",
+			Handler:       _AnalyzerService_Analyze_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/modtroubleshooter/v1/analyzer.proto",
+}
+
+const (
+	ManifestService_GetManifest_FullMethodName = "/modtroubleshooter.v1.ManifestService/GetManifest"
+)
+
+// ManifestServiceClient is the client API for ManifestService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ManifestService lists the contents of mod archives.
+type ManifestServiceClient interface {
+	// GetManifest downloads a mod file and returns its file listing.
+	GetManifest(ctx context.Context, in *GetManifestRequest, opts ...grpc.CallOption) (*Manifest, error)
+}
+
+type manifestServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewManifestServiceClient(cc grpc.ClientConnInterface) ManifestServiceClient {
+	return &manifestServiceClient{cc}
+}
+
+func (c *manifestServiceClient) GetManifest(ctx context.Context, in *GetManifestRequest, opts ...grpc.CallOption) (*Manifest, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Manifest)
+	err := c.cc.Invoke(ctx, ManifestService_GetManifest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ManifestServiceServer is the server API for ManifestService service.
+// All implementations must embed UnimplementedManifestServiceServer
+// for forward compatibility.
+//
+// ManifestService lists the contents of mod archives.
+type ManifestServiceServer interface {
+	// GetManifest downloads a mod file and returns its file listing.
+	GetManifest(context.Context, *GetManifestRequest) (*Manifest, error)
+	mustEmbedUnimplementedManifestServiceServer()
+}
+
+// UnimplementedManifestServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedManifestServiceServer struct{}
+
+func (UnimplementedManifestServiceServer) GetManifest(context.Context, *GetManifestRequest) (*Manifest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetManifest not implemented")
+}
+func (UnimplementedManifestServiceServer) mustEmbedUnimplementedManifestServiceServer() {}
+func (UnimplementedManifestServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeManifestServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ManifestServiceServer will
+// result in compilation errors.
+type UnsafeManifestServiceServer interface {
+	mustEmbedUnimplementedManifestServiceServer()
+}
+
+func RegisterManifestServiceServer(s grpc.ServiceRegistrar, srv ManifestServiceServer) {
+	// If the following call pancis, it indicates UnimplementedManifestServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ManifestService_ServiceDesc, srv)
+}
+
+func _ManifestService_GetManifest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetManifestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManifestServiceServer).GetManifest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManifestService_GetManifest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManifestServiceServer).GetManifest(ctx, req.(*GetManifestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ManifestService_ServiceDesc is the grpc.ServiceDesc for ManifestService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ManifestService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "modtroubleshooter.v1.ManifestService",
+	HandlerType: (*ManifestServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetManifest",
+			Handler:    _ManifestService_GetManifest_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/modtroubleshooter/v1/analyzer.proto",
+}
+
+const (
+	PluginHeaderService_ParsePluginHeader_FullMethodName = "/modtroubleshooter.v1.PluginHeaderService/ParsePluginHeader"
+)
+
+// PluginHeaderServiceClient is the client API for PluginHeaderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PluginHeaderService parses plugin file headers.
+type PluginHeaderServiceClient interface {
+	// ParsePluginHeader parses the TES4 header of an uploaded plugin.
+	ParsePluginHeader(ctx context.Context, in *ParsePluginHeaderRequest, opts ...grpc.CallOption) (*PluginHeader, error)
+}
+
+type pluginHeaderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginHeaderServiceClient(cc grpc.ClientConnInterface) PluginHeaderServiceClient {
+	return &pluginHeaderServiceClient{cc}
+}
+
+func (c *pluginHeaderServiceClient) ParsePluginHeader(ctx context.Context, in *ParsePluginHeaderRequest, opts ...grpc.CallOption) (*PluginHeader, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PluginHeader)
+	err := c.cc.Invoke(ctx, PluginHeaderService_ParsePluginHeader_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PluginHeaderServiceServer is the server API for PluginHeaderService service.
+// All implementations must embed UnimplementedPluginHeaderServiceServer
+// for forward compatibility.
+//
+// PluginHeaderService parses plugin file headers.
+type PluginHeaderServiceServer interface {
+	// ParsePluginHeader parses the TES4 header of an uploaded plugin.
+	ParsePluginHeader(context.Context, *ParsePluginHeaderRequest) (*PluginHeader, error)
+	mustEmbedUnimplementedPluginHeaderServiceServer()
+}
+
+// UnimplementedPluginHeaderServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPluginHeaderServiceServer struct{}
+
+func (UnimplementedPluginHeaderServiceServer) ParsePluginHeader(context.Context, *ParsePluginHeaderRequest) (*PluginHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ParsePluginHeader not implemented")
+}
+func (UnimplementedPluginHeaderServiceServer) mustEmbedUnimplementedPluginHeaderServiceServer() {}
+func (UnimplementedPluginHeaderServiceServer) testEmbeddedByValue()                             {}
+
+// UnsafePluginHeaderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PluginHeaderServiceServer will
+// result in compilation errors.
+type UnsafePluginHeaderServiceServer interface {
+	mustEmbedUnimplementedPluginHeaderServiceServer()
+}
+
+func RegisterPluginHeaderServiceServer(s grpc.ServiceRegistrar, srv PluginHeaderServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPluginHeaderServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PluginHeaderService_ServiceDesc, srv)
+}
+
+func _PluginHeaderService_ParsePluginHeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParsePluginHeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginHeaderServiceServer).ParsePluginHeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PluginHeaderService_ParsePluginHeader_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginHeaderServiceServer).ParsePluginHeader(ctx, req.(*ParsePluginHeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PluginHeaderService_ServiceDesc is the grpc.ServiceDesc for PluginHeaderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PluginHeaderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "modtroubleshooter.v1.PluginHeaderService",
+	HandlerType: (*PluginHeaderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ParsePluginHeader",
+			Handler:    _PluginHeaderService_ParsePluginHeader_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/modtroubleshooter/v1/analyzer.proto",
+}
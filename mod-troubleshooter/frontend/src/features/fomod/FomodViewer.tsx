@@ -260,16 +260,25 @@ const ErrorDisplay: React.FC<ErrorDisplayProps> = ({ error, onRetry }) => {
   let message = 'An unexpected error occurred while analyzing the FOMOD.';
 
   if (error instanceof ApiError) {
-    if (error.status === 404) {
-      message = 'Mod file not found. Please check the mod ID and file ID.';
-    } else if (error.status === 401 || error.status === 403) {
-      message = 'API key is missing or invalid. Please configure the backend.';
-    } else if (error.status === 402) {
-      message = 'This feature requires a Nexus Mods Premium account.';
-    } else if (error.status >= 500) {
-      message = 'Server error. Please try again later.';
-    } else {
-      message = error.message;
+    switch (error.code) {
+      case 'not_found':
+        message = 'Mod file not found. Please check the mod ID and file ID.';
+        break;
+      case 'unauthorized':
+      case 'no_api_key':
+        message = 'API key is missing or invalid. Please configure the backend.';
+        break;
+      case 'premium_required':
+        message = 'This feature requires a Nexus Mods Premium account.';
+        break;
+      case 'rate_limited':
+      case 'nexus_degraded':
+        message = error.retryAfter
+          ? `Nexus is busy. Please try again in ${error.retryAfter} seconds.`
+          : 'Nexus is busy. Please try again later.';
+        break;
+      default:
+        message = error.status >= 500 ? 'Server error. Please try again later.' : error.message;
     }
   }
 
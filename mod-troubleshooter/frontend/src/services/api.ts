@@ -1,23 +1,47 @@
 import { z } from 'zod';
 
+import { ApiErrorSchema, ApiResponseSchema } from '@/types/api.ts';
+import type { ApiErrorBody } from '@/types/api.ts';
+
 /** Custom error class for API errors */
 export class ApiError extends Error {
   status: number;
-
-  constructor(status: number, message: string) {
+  /** Stable error code from the backend, e.g. 'not_found' or 'rate_limited' */
+  code: string;
+  details?: string;
+  correlationId?: string;
+  /** Seconds to wait before retrying, when the backend asked for a delay */
+  retryAfter?: number;
+
+  constructor(status: number, message: string, body?: Omit<ApiErrorBody, 'message'>) {
     super(message);
     this.name = 'ApiError';
     this.status = status;
+    this.code = body?.code ?? 'unknown';
+    this.details = body?.details;
+    this.correlationId = body?.correlationId;
+    this.retryAfter = body?.retryAfter;
   }
 }
 
-/** API response envelope schema - matches backend Response struct */
-const ResponseEnvelopeSchema = <T extends z.ZodTypeAny>(dataSchema: T) =>
-  z.object({
-    data: dataSchema.optional(),
-    error: z.string().optional(),
-    message: z.string().optional(),
-  });
+/** Build an ApiError from a backend error object */
+function toApiError(status: number, body: ApiErrorBody): ApiError {
+  return new ApiError(status, body.message, body);
+}
+
+/** Parse a failed response into an ApiError */
+async function parseErrorResponse(response: Response): Promise<ApiError> {
+  const text = await response.text().catch(() => '');
+  try {
+    const envelope = z.object({ error: ApiErrorSchema.optional() }).parse(JSON.parse(text));
+    if (envelope.error) {
+      return toApiError(response.status, envelope.error);
+    }
+    return new ApiError(response.status, 'Unknown error');
+  } catch {
+    return new ApiError(response.status, `API error: ${text || 'Unknown error'}`);
+  }
+}
 
 /** Base API configuration */
 const API_BASE_URL = '/api';
@@ -39,23 +63,14 @@ async function fetchApi<T>(
   });
 
   if (!response.ok) {
-    // Try to parse error response
-    try {
-      const errorJson = await response.json();
-      const errorEnvelope = z.object({ error: z.string().optional() }).parse(errorJson);
-      throw new ApiError(response.status, errorEnvelope.error ?? 'Unknown error');
-    } catch (e) {
-      if (e instanceof ApiError) throw e;
-      const errorText = await response.text().catch(() => 'Unknown error');
-      throw new ApiError(response.status, `API error: ${errorText}`);
-    }
+    throw await parseErrorResponse(response);
   }
 
   const json: unknown = await response.json();
-  const envelope = ResponseEnvelopeSchema(schema).parse(json);
+  const envelope = ApiResponseSchema(schema).parse(json);
 
   if (envelope.error) {
-    throw new ApiError(500, envelope.error);
+    throw toApiError(500, envelope.error);
   }
 
   if (envelope.data === undefined) {
@@ -81,25 +96,17 @@ async function fetchApiMessage(
   });
 
   if (!response.ok) {
-    try {
-      const errorJson = await response.json();
-      const errorEnvelope = z.object({ error: z.string().optional() }).parse(errorJson);
-      throw new ApiError(response.status, errorEnvelope.error ?? 'Unknown error');
-    } catch (e) {
-      if (e instanceof ApiError) throw e;
-      const errorText = await response.text().catch(() => 'Unknown error');
-      throw new ApiError(response.status, `API error: ${errorText}`);
-    }
+    throw await parseErrorResponse(response);
   }
 
   const json: unknown = await response.json();
   const envelope = z.object({
     message: z.string().optional(),
-    error: z.string().optional(),
+    error: ApiErrorSchema.optional(),
   }).parse(json);
 
   if (envelope.error) {
-    throw new ApiError(500, envelope.error);
+    throw toApiError(500, envelope.error);
   }
 
   return envelope.message ?? 'Success';
@@ -1,10 +1,19 @@
 import { z } from 'zod';
 
+/** API error schema - matches backend APIError struct */
+export const ApiErrorSchema = z.object({
+  code: z.string(),
+  message: z.string(),
+  details: z.string().optional(),
+  correlationId: z.string().optional(),
+  retryAfter: z.number().optional(),
+});
+
 /** API response envelope schema - matches backend Response struct */
 export const ApiResponseSchema = <T extends z.ZodTypeAny>(dataSchema: T) =>
   z.object({
     data: dataSchema.optional(),
-    error: z.string().optional(),
+    error: ApiErrorSchema.optional(),
     message: z.string().optional(),
   });
 
@@ -100,6 +109,7 @@ export const CollectionSchema = z.object({
 });
 
 /** Type exports inferred from schemas */
+export type ApiErrorBody = z.infer<typeof ApiErrorSchema>;
 export type ApiResponse<T> = z.infer<ReturnType<typeof ApiResponseSchema<z.ZodType<T>>>>;
 export type User = z.infer<typeof UserSchema>;
 export type Game = z.infer<typeof GameSchema>;
@@ -57,6 +57,7 @@ export interface CollectionsData {
 
 export type {
   ApiResponse,
+  ApiErrorBody,
   User,
   Game,
   Image,
@@ -121,6 +122,7 @@ export type {
 
 export {
   ApiResponseSchema,
+  ApiErrorSchema,
   UserSchema,
   GameSchema,
   ImageSchema,